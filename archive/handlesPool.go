@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type openHandle struct {
+	path       string
+	persister  types.Persister
+	numInUse   int
+	lruElement *list.Element
+}
+
+// HandlesPool bounds the number of persisters opened by the static storers sharing it, thus the number of file
+// descriptors: the least recently used idle persisters are closed once the bound is exceeded, to be reopened on demand.
+// The persisters in use are never closed, so the bound can be temporarily exceeded
+type HandlesPool struct {
+	mut              sync.Mutex
+	maxOpenHandles   int
+	persisterFactory types.PersisterFactory
+	handles          map[string]*openHandle
+	// idle holds the paths of the open persisters not in use, the least recently used at the back
+	idle *list.List
+}
+
+// NewHandlesPool creates a new handles pool, opening the persisters with the provided factory, which should create
+// read only persisters
+func NewHandlesPool(maxOpenHandles int, persisterFactory types.PersisterFactory) (*HandlesPool, error) {
+	if maxOpenHandles <= 0 {
+		return nil, fmt.Errorf("%w: maxOpenHandles should be positive", common.ErrInvalidConfig)
+	}
+	if check.IfNil(persisterFactory) {
+		return nil, common.ErrNilPersisterFactory
+	}
+
+	return &HandlesPool{
+		maxOpenHandles:   maxOpenHandles,
+		persisterFactory: persisterFactory,
+		handles:          make(map[string]*openHandle),
+		idle:             list.New(),
+	}, nil
+}
+
+// acquire returns the persister rooted in the provided path, opening it if needed, which should be released after use
+func (pool *HandlesPool) acquire(path string) (types.Persister, error) {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	handle, ok := pool.handles[path]
+	if !ok {
+		persister, err := pool.persisterFactory.Create(path)
+		if err != nil {
+			return nil, err
+		}
+
+		handle = &openHandle{
+			path:      path,
+			persister: persister,
+		}
+		pool.handles[path] = handle
+	}
+
+	if handle.lruElement != nil {
+		pool.idle.Remove(handle.lruElement)
+		handle.lruElement = nil
+	}
+	handle.numInUse++
+	pool.closeExceedingNoLock()
+
+	return handle.persister, nil
+}
+
+func (pool *HandlesPool) release(path string) {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	handle, ok := pool.handles[path]
+	if !ok || handle.numInUse == 0 {
+		return
+	}
+
+	handle.numInUse--
+	if handle.numInUse == 0 {
+		handle.lruElement = pool.idle.PushFront(handle)
+	}
+	pool.closeExceedingNoLock()
+}
+
+func (pool *HandlesPool) closeExceedingNoLock() {
+	for len(pool.handles) > pool.maxOpenHandles && pool.idle.Len() > 0 {
+		handle := pool.idle.Remove(pool.idle.Back()).(*openHandle)
+		pool.closeNoLock(handle)
+	}
+}
+
+func (pool *HandlesPool) closeNoLock(handle *openHandle) {
+	delete(pool.handles, handle.path)
+	err := handle.persister.Close()
+	if err != nil {
+		log.Warn("HandlesPool: could not close persister", "path", handle.path, "error", err)
+	}
+}
+
+// closePath closes the persister rooted in the provided path, if open and not in use
+func (pool *HandlesPool) closePath(path string) {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	handle, ok := pool.handles[path]
+	if !ok || handle.numInUse > 0 {
+		return
+	}
+
+	pool.idle.Remove(handle.lruElement)
+	pool.closeNoLock(handle)
+}
+
+// NumOpenHandles returns the number of open persisters
+func (pool *HandlesPool) NumOpenHandles() int {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	return len(pool.handles)
+}
+
+// Close closes all the open persisters
+func (pool *HandlesPool) Close() error {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	errs := make([]error, 0)
+	for path, handle := range pool.handles {
+		err := handle.persister.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w while closing %s", err, path))
+		}
+	}
+	pool.handles = make(map[string]*openHandle)
+	pool.idle.Init()
+
+	return errors.Join(errs...)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pool *HandlesPool) IsInterfaceNil() bool {
+	return pool == nil
+}
@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/data"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Storer = (*StaticStorer)(nil)
+
+var log = logger.GetOrCreate("storage/archive")
+
+// StaticStorer serves the immutable data of a sealed epoch directory, opened read only, on demand, through a
+// HandlesPool. The writes are rejected with common.ErrDBIsReadOnly
+type StaticStorer struct {
+	path  string
+	epoch uint32
+	pool  *HandlesPool
+}
+
+// NewStaticStorer creates a new static storer of the directory of the provided epoch. The directory is not opened
+// until the first read
+func NewStaticStorer(path string, epoch uint32, pool *HandlesPool) (*StaticStorer, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: empty path", common.ErrInvalidConfig)
+	}
+	if check.IfNil(pool) {
+		return nil, fmt.Errorf("%w: nil handles pool", common.ErrInvalidConfig)
+	}
+
+	return &StaticStorer{
+		path:  path,
+		epoch: epoch,
+		pool:  pool,
+	}, nil
+}
+
+// Get gets the value associated to the key
+func (ss *StaticStorer) Get(key []byte) ([]byte, error) {
+	persister, err := ss.pool.acquire(ss.path)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.pool.release(ss.path)
+
+	return persister.Get(key)
+}
+
+// Has returns nil if the key is present
+func (ss *StaticStorer) Has(key []byte) error {
+	persister, err := ss.pool.acquire(ss.path)
+	if err != nil {
+		return err
+	}
+	defer ss.pool.release(ss.path)
+
+	return persister.Has(key)
+}
+
+// SearchFirst calls Get, as the storer holds a single epoch
+func (ss *StaticStorer) SearchFirst(key []byte) ([]byte, error) {
+	return ss.Get(key)
+}
+
+// GetFromEpoch calls Get if the provided epoch is the one of the storer
+func (ss *StaticStorer) GetFromEpoch(key []byte, epoch uint32) ([]byte, error) {
+	if epoch != ss.epoch {
+		return nil, fmt.Errorf("%w: the static storer holds epoch %d, not %d", common.ErrInvalidEpoch, ss.epoch, epoch)
+	}
+
+	return ss.Get(key)
+}
+
+// GetBulkFromEpoch returns the (key, value) pairs found, skipping the missing keys
+func (ss *StaticStorer) GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]data.KeyValuePair, error) {
+	if epoch != ss.epoch {
+		return nil, fmt.Errorf("%w: the static storer holds epoch %d, not %d", common.ErrInvalidEpoch, ss.epoch, epoch)
+	}
+
+	persister, err := ss.pool.acquire(ss.path)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.pool.release(ss.path)
+
+	results := make([]data.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		val, errGet := persister.Get(key)
+		if errGet != nil {
+			continue
+		}
+
+		results = append(results, data.KeyValuePair{Key: key, Value: val})
+	}
+
+	return results, nil
+}
+
+// GetOldestEpoch returns the epoch of the storer
+func (ss *StaticStorer) GetOldestEpoch() (uint32, error) {
+	return ss.epoch, nil
+}
+
+// RangeKeys iterates over the (key, value) pairs. The persister stays open meanwhile
+func (ss *StaticStorer) RangeKeys(handler func(key []byte, val []byte) bool) {
+	persister, err := ss.pool.acquire(ss.path)
+	if err != nil {
+		log.Warn("StaticStorer.RangeKeys: could not open persister", "path", ss.path, "error", err)
+		return
+	}
+	defer ss.pool.release(ss.path)
+
+	persister.RangeKeys(handler)
+}
+
+// Put returns ErrDBIsReadOnly
+func (ss *StaticStorer) Put(_, _ []byte) error {
+	return common.ErrDBIsReadOnly
+}
+
+// PutInEpoch returns ErrDBIsReadOnly
+func (ss *StaticStorer) PutInEpoch(_, _ []byte, _ uint32) error {
+	return common.ErrDBIsReadOnly
+}
+
+// Remove returns ErrDBIsReadOnly
+func (ss *StaticStorer) Remove(_ []byte) error {
+	return common.ErrDBIsReadOnly
+}
+
+// RemoveFromCurrentEpoch returns ErrDBIsReadOnly
+func (ss *StaticStorer) RemoveFromCurrentEpoch(_ []byte) error {
+	return common.ErrDBIsReadOnly
+}
+
+// DestroyUnit returns ErrDBIsReadOnly
+func (ss *StaticStorer) DestroyUnit() error {
+	return common.ErrDBIsReadOnly
+}
+
+// ClearCache does nothing, as the storer has no cache
+func (ss *StaticStorer) ClearCache() {
+}
+
+// Close closes the persister, unless in use. It is reopened by the next read
+func (ss *StaticStorer) Close() error {
+	ss.pool.closePath(ss.path)
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ss *StaticStorer) IsInterfaceNil() bool {
+	return ss == nil
+}
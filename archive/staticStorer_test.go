@@ -0,0 +1,103 @@
+package archive_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/archive"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSealedEpochs(t *testing.T, numEpochs int) []string {
+	argDB := factory.ArgDB{
+		DBType:            common.LvlDBSerial,
+		BatchDelaySeconds: 1,
+		MaxBatchSize:      1,
+		MaxOpenFiles:      10,
+	}
+
+	paths := make([]string, numEpochs)
+	for i := range paths {
+		paths[i] = filepath.Join(t.TempDir(), fmt.Sprintf("Epoch_%d", i))
+		persister, err := factory.NewPersisterFactory(argDB).Create(paths[i])
+		require.Nil(t, err)
+		require.Nil(t, persister.Put([]byte("key"), []byte(fmt.Sprintf("value%d", i))))
+		require.Nil(t, persister.Close())
+	}
+
+	return paths
+}
+
+func createHandlesPool(t *testing.T, maxOpenHandles int) *archive.HandlesPool {
+	argDB := factory.ArgDB{
+		DBType:       common.LvlDBReadOnly,
+		MaxOpenFiles: 10,
+	}
+	pool, err := archive.NewHandlesPool(maxOpenHandles, factory.NewPersisterFactory(argDB))
+	require.Nil(t, err)
+
+	return pool
+}
+
+func TestStaticStorer_ShouldServeReadsAndRejectWrites(t *testing.T) {
+	t.Parallel()
+
+	paths := createSealedEpochs(t, 1)
+	pool := createHandlesPool(t, 1)
+	ss, err := archive.NewStaticStorer(paths[0], 0, pool)
+	require.Nil(t, err)
+	assert.Equal(t, 0, pool.NumOpenHandles())
+
+	val, err := ss.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+	assert.Nil(t, ss.Has([]byte("key")))
+	assert.NotNil(t, ss.Has([]byte("missing")))
+	_, err = ss.GetFromEpoch([]byte("key"), 1)
+	assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+
+	assert.Equal(t, common.ErrDBIsReadOnly, ss.Put([]byte("key"), []byte("value")))
+	assert.Equal(t, common.ErrDBIsReadOnly, ss.Remove([]byte("key")))
+	assert.Equal(t, common.ErrDBIsReadOnly, ss.DestroyUnit())
+
+	assert.Nil(t, ss.Close())
+	assert.Equal(t, 0, pool.NumOpenHandles())
+	val, _ = ss.Get([]byte("key"))
+	assert.Equal(t, []byte("value0"), val)
+	assert.Nil(t, pool.Close())
+}
+
+func TestHandlesPool_ShouldBoundTheOpenHandles(t *testing.T) {
+	t.Parallel()
+
+	paths := createSealedEpochs(t, 3)
+	pool := createHandlesPool(t, 2)
+	storers := make([]*archive.StaticStorer, len(paths))
+	for i, path := range paths {
+		storers[i], _ = archive.NewStaticStorer(path, uint32(i), pool)
+	}
+
+	for round := 0; round < 3; round++ {
+		for i, ss := range storers {
+			val, err := ss.Get([]byte("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+			assert.LessOrEqual(t, pool.NumOpenHandles(), 2)
+		}
+	}
+
+	// the persister in use is not closed while ranging
+	storers[0].RangeKeys(func(key []byte, val []byte) bool {
+		_, _ = storers[1].Get(key)
+		_, _ = storers[2].Get(key)
+		assert.Nil(t, storers[0].Has(key))
+		return true
+	})
+	assert.Nil(t, pool.Close())
+	assert.Equal(t, 0, pool.NumOpenHandles())
+}
@@ -0,0 +1,175 @@
+package benchmarks_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/benchmarks"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createWorkload(tb testing.TB, zipfS float64) *benchmarks.Workload {
+	workload, err := benchmarks.NewWorkload(benchmarks.ArgsWorkload{
+		NumKeys:      1000,
+		KeySize:      32,
+		MinValueSize: 10,
+		MaxValueSize: 100,
+		ReadRatio:    0.8,
+		ZipfS:        zipfS,
+		Seed:         3,
+	})
+	require.Nil(tb, err)
+
+	return workload
+}
+
+func TestNewWorkload(t *testing.T) {
+	t.Parallel()
+
+	workload, err := benchmarks.NewWorkload(benchmarks.ArgsWorkload{KeySize: 4, ZipfS: 0.5, ReadRatio: 2})
+	assert.Nil(t, workload)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "NumKeys")
+	assert.Contains(t, err.Error(), "KeySize")
+	assert.Contains(t, err.Error(), "ZipfS")
+	assert.Contains(t, err.Error(), "ReadRatio")
+
+	workload = createWorkload(t, 0)
+	assert.Equal(t, 1000, workload.NumKeys())
+	assert.Equal(t, 32, len(workload.Key(7)))
+}
+
+func TestWorkload_StreamShouldBeDeterministic(t *testing.T) {
+	t.Parallel()
+
+	first := createWorkload(t, 1.2).Stream(1)
+	second := createWorkload(t, 1.2).Stream(1)
+	other := createWorkload(t, 1.2).Stream(2)
+
+	numReads, numDifferent := 0, 0
+	for i := 0; i < 1000; i++ {
+		op := first.Next()
+		assert.Equal(t, op, second.Next())
+		if fmt.Sprint(op) != fmt.Sprint(other.Next()) {
+			numDifferent++
+		}
+		if op.Operation == benchmarks.ReadOperation {
+			numReads++
+			assert.Nil(t, op.Value)
+			continue
+		}
+		assert.True(t, len(op.Value) >= 10 && len(op.Value) <= 100)
+	}
+
+	assert.InDelta(t, 800, numReads, 60)
+	assert.Greater(t, numDifferent, 900)
+}
+
+func TestWorkload_ZipfianStreamShouldFavorTheLowerKeys(t *testing.T) {
+	t.Parallel()
+
+	workload := createWorkload(t, 1.5)
+	stream := workload.Stream(0)
+	hotKey := string(workload.Key(0))
+	numHot := 0
+	for i := 0; i < 1000; i++ {
+		if string(stream.Next().Key) == hotKey {
+			numHot++
+		}
+	}
+
+	// with s = 1.5, the first key gets about a third of the operations, instead of 1 in 1000
+	assert.Greater(t, numHot, 200)
+}
+
+func TestRunPersister(t *testing.T) {
+	t.Parallel()
+
+	workload := createWorkload(t, 0)
+	_, err := benchmarks.RunPersister(nil, benchmarks.ArgsRun{Workload: workload, NumOps: 10, NumGoroutines: 1})
+	assert.Equal(t, common.ErrNilPersister, err)
+	_, err = benchmarks.RunPersister(memorydb.New(), benchmarks.ArgsRun{Workload: workload})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	db := memorydb.New()
+	require.Nil(t, benchmarks.PreloadPersister(db, workload))
+	result, err := benchmarks.RunPersister(db, benchmarks.ArgsRun{Workload: workload, NumOps: 1001, NumGoroutines: 4})
+	require.Nil(t, err)
+	assert.Equal(t, 1001, result.NumOps)
+	assert.Equal(t, 0, result.NumErrors)
+	assert.Greater(t, result.OpsPerSecond, 0.0)
+	assert.True(t, result.LatencyP50 <= result.LatencyP99 && result.LatencyP99 <= result.LatencyMax)
+	assert.Contains(t, result.String(), "ops=1001 errors=0")
+}
+
+func TestRunCacher(t *testing.T) {
+	t.Parallel()
+
+	workload := createWorkload(t, 0)
+	_, err := benchmarks.RunCacher(nil, benchmarks.ArgsRun{Workload: workload, NumOps: 10, NumGoroutines: 1})
+	assert.Equal(t, common.ErrNilCacher, err)
+
+	cache, _ := lrucache.NewCache(100)
+	benchmarks.PreloadCacher(cache, workload)
+	result, err := benchmarks.RunCacher(cache, benchmarks.ArgsRun{Workload: workload, NumOps: 500, NumGoroutines: 2})
+	require.Nil(t, err)
+	assert.Equal(t, 500, result.NumOps)
+}
+
+func BenchmarkMemoryDB_ZipfianReadMostly(b *testing.B) {
+	benchmarkPersister(b, memorydb.New())
+}
+
+func BenchmarkMemoryDB_LockFreeReads_ZipfianReadMostly(b *testing.B) {
+	benchmarkPersister(b, memorydb.New(memorydb.WithLockFreeReads(), memorydb.WithNumShards(64)))
+}
+
+func BenchmarkLevelDB_ZipfianReadMostly(b *testing.B) {
+	db, err := leveldb.NewDB(b.TempDir(), 2, 1000, 10)
+	require.Nil(b, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	benchmarkPersister(b, db)
+}
+
+func BenchmarkSerialLevelDB_ZipfianReadMostly(b *testing.B) {
+	db, err := leveldb.NewSerialDB(b.TempDir(), 2, 1000, 10, leveldb.WithNumReaders(4))
+	require.Nil(b, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	benchmarkPersister(b, db)
+}
+
+// benchmarkPersister runs the same workload on each persister, so that memorydb, the reference persister of the tests,
+// can be compared to the leveldb ones
+func benchmarkPersister(b *testing.B, persister types.Persister) {
+	workload := createWorkload(b, 1.1)
+	require.Nil(b, benchmarks.PreloadPersister(persister, workload))
+
+	b.ResetTimer()
+	result, err := benchmarks.RunPersister(persister, benchmarks.ArgsRun{Workload: workload, NumOps: b.N, NumGoroutines: 4})
+	require.Nil(b, err)
+	result.ReportTo(b)
+}
+
+func BenchmarkLRUCache_ZipfianReadMostly(b *testing.B) {
+	workload := createWorkload(b, 1.1)
+	cache, _ := lrucache.NewCache(500)
+	benchmarks.PreloadCacher(cache, workload)
+
+	b.ResetTimer()
+	result, err := benchmarks.RunCacher(cache, benchmarks.ArgsRun{Workload: workload, NumOps: b.N, NumGoroutines: 4})
+	require.Nil(b, err)
+	result.ReportTo(b)
+}
@@ -0,0 +1,201 @@
+package benchmarks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ArgsRun describes a benchmark run
+type ArgsRun struct {
+	Workload      *Workload
+	NumOps        int
+	NumGoroutines int
+}
+
+func (args ArgsRun) validate() error {
+	var validator common.ConfigValidator
+	validator.Check(args.Workload != nil, common.ErrInvalidConfig, "Workload should not be nil")
+	validator.Check(args.NumOps > 0, common.ErrInvalidConfig, "NumOps should be positive")
+	validator.Check(args.NumGoroutines > 0, common.ErrInvalidConfig, "NumGoroutines should be positive")
+
+	return validator.Err()
+}
+
+// Result holds the numbers measured by a benchmark run
+type Result struct {
+	NumOps       int
+	NumErrors    int
+	Duration     time.Duration
+	OpsPerSecond float64
+	LatencyP50   time.Duration
+	LatencyP99   time.Duration
+	LatencyMax   time.Duration
+}
+
+// String returns the numbers on one line, to be compared between runs
+func (result Result) String() string {
+	return fmt.Sprintf("ops=%d errors=%d duration=%v throughput=%.0f ops/s p50=%v p99=%v max=%v",
+		result.NumOps, result.NumErrors, result.Duration, result.OpsPerSecond,
+		result.LatencyP50, result.LatencyP99, result.LatencyMax)
+}
+
+// MetricsReporter is implemented by *testing.B, so that the numbers show up along the ones of the go benchmarks
+type MetricsReporter interface {
+	ReportMetric(n float64, unit string)
+}
+
+// ReportTo reports the throughput and the latencies to the reporter
+func (result Result) ReportTo(reporter MetricsReporter) {
+	reporter.ReportMetric(result.OpsPerSecond, "ops/s")
+	reporter.ReportMetric(float64(result.LatencyP50.Nanoseconds()), "p50-ns")
+	reporter.ReportMetric(float64(result.LatencyP99.Nanoseconds()), "p99-ns")
+}
+
+// PreloadPersister writes one value of the maximum size for each key of the workload, so that the reads find them
+func PreloadPersister(persister types.Persister, workload *Workload) error {
+	for i := 0; i < workload.NumKeys(); i++ {
+		err := persister.Put(workload.Key(i), workload.Value(i, 0, workload.args.MaxValueSize))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PreloadCacher adds one value of the maximum size for each key of the workload, so that the reads find them
+func PreloadCacher(cacher types.Cacher, workload *Workload) {
+	for i := 0; i < workload.NumKeys(); i++ {
+		value := workload.Value(i, 0, workload.args.MaxValueSize)
+		_ = cacher.Put(workload.Key(i), value, len(value))
+	}
+}
+
+// RunPersister issues the operations of the workload on the persister, split between the goroutines. A failed Get of
+// a missing key is not counted as an error
+func RunPersister(persister types.Persister, args ArgsRun) (Result, error) {
+	if check.IfNil(persister) {
+		return Result{}, common.ErrNilPersister
+	}
+
+	return run(args, func(op Op) error {
+		if op.Operation == WriteOperation {
+			return persister.Put(op.Key, op.Value)
+		}
+
+		_, err := persister.Get(op.Key)
+		if common.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	})
+}
+
+// RunCacher issues the operations of the workload on the cacher, split between the goroutines
+func RunCacher(cacher types.Cacher, args ArgsRun) (Result, error) {
+	if check.IfNil(cacher) {
+		return Result{}, common.ErrNilCacher
+	}
+
+	return run(args, func(op Op) error {
+		if op.Operation == WriteOperation {
+			_ = cacher.Put(op.Key, op.Value, len(op.Value))
+			return nil
+		}
+
+		_, _ = cacher.Get(op.Key)
+		return nil
+	})
+}
+
+func run(args ArgsRun, execute func(op Op) error) (Result, error) {
+	err := args.validate()
+	if err != nil {
+		return Result{}, fmt.Errorf("%w for the benchmark run", err)
+	}
+
+	// the operations are generated ahead, so that the generation is not measured
+	streams := make([][]Op, args.NumGoroutines)
+	for i := range streams {
+		numOps := args.NumOps / args.NumGoroutines
+		if i < args.NumOps%args.NumGoroutines {
+			numOps++
+		}
+
+		stream := args.Workload.Stream(i)
+		streams[i] = make([]Op, numOps)
+		for j := range streams[i] {
+			streams[i][j] = stream.Next()
+		}
+	}
+
+	latencies := make([][]time.Duration, args.NumGoroutines)
+	numErrors := make([]int, args.NumGoroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(args.NumGoroutines)
+	start := time.Now()
+	for i := 0; i < args.NumGoroutines; i++ {
+		go func(workerIndex int) {
+			defer wg.Done()
+
+			workerLatencies := make([]time.Duration, 0, len(streams[workerIndex]))
+			for _, op := range streams[workerIndex] {
+				opStart := time.Now()
+				errExecute := execute(op)
+				workerLatencies = append(workerLatencies, time.Since(opStart))
+				if errExecute != nil {
+					numErrors[workerIndex]++
+				}
+			}
+			latencies[workerIndex] = workerLatencies
+		}(i)
+	}
+	wg.Wait()
+
+	return newResult(time.Since(start), latencies, numErrors), nil
+}
+
+func newResult(duration time.Duration, latencies [][]time.Duration, numErrors []int) Result {
+	all := make([]time.Duration, 0)
+	for _, workerLatencies := range latencies {
+		all = append(all, workerLatencies...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i] < all[j]
+	})
+
+	result := Result{
+		NumOps:   len(all),
+		Duration: duration,
+	}
+	for _, num := range numErrors {
+		result.NumErrors += num
+	}
+	if duration > 0 {
+		result.OpsPerSecond = float64(len(all)) / duration.Seconds()
+	}
+	if len(all) > 0 {
+		result.LatencyP50 = percentile(all, 50)
+		result.LatencyP99 = percentile(all, 99)
+		result.LatencyMax = all[len(all)-1]
+	}
+
+	return result
+}
+
+// percentile returns the percentile of the sorted, non empty, latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+
+	return sorted[index]
+}
@@ -0,0 +1,155 @@
+package benchmarks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// Operation is the kind of an operation issued by a workload
+type Operation int
+
+const (
+	// ReadOperation reads the value of a key
+	ReadOperation Operation = iota
+	// WriteOperation writes the value of a key
+	WriteOperation
+)
+
+// String returns the name of the operation
+func (op Operation) String() string {
+	if op == WriteOperation {
+		return "write"
+	}
+
+	return "read"
+}
+
+// ArgsWorkload describes a deterministic workload of reads and writes over a fixed set of keys
+type ArgsWorkload struct {
+	NumKeys int
+	// KeySize is the size of the keys, at least 8 bytes, so that the keys are distinct
+	KeySize      int
+	MinValueSize int
+	MaxValueSize int
+	// ReadRatio is the fraction in [0, 1] of the read operations, the others being writes
+	ReadRatio float64
+	// ZipfS skews the popularity of the keys with a zipfian distribution of the provided exponent, which should be
+	// greater than 1, the lower keys being the most popular. If 0, all the keys are equally popular
+	ZipfS float64
+	// Seed initializes the random sources, so that the same arguments always issue the same operations
+	Seed int64
+}
+
+func (args ArgsWorkload) validate() error {
+	var validator common.ConfigValidator
+	validator.Check(args.NumKeys > 0, common.ErrInvalidConfig, "NumKeys should be positive")
+	validator.Check(args.KeySize >= 8, common.ErrInvalidConfig, "KeySize should be at least 8")
+	validator.Check(args.MinValueSize >= 0 && args.MinValueSize <= args.MaxValueSize, common.ErrInvalidConfig,
+		"the value sizes should satisfy 0 <= MinValueSize <= MaxValueSize")
+	validator.Check(args.ReadRatio >= 0 && args.ReadRatio <= 1, common.ErrInvalidConfig, "ReadRatio should be in [0, 1]")
+	validator.Check(args.ZipfS == 0 || args.ZipfS > 1, common.ErrInvalidConfig, "ZipfS should be 0 or greater than 1")
+
+	return validator.Err()
+}
+
+// Workload is a deterministic workload, issuing the same operations on each run, so that the numbers measured on
+// different persisters and caches, or on different revisions, are comparable
+type Workload struct {
+	args ArgsWorkload
+}
+
+// NewWorkload creates a new workload
+func NewWorkload(args ArgsWorkload) (*Workload, error) {
+	err := args.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w for the benchmark workload", err)
+	}
+
+	return &Workload{
+		args: args,
+	}, nil
+}
+
+// NumKeys returns the number of the distinct keys of the workload
+func (w *Workload) NumKeys() int {
+	return w.args.NumKeys
+}
+
+// Key returns the key with the provided index
+func (w *Workload) Key(index int) []byte {
+	key := make([]byte, w.args.KeySize)
+	binary.BigEndian.PutUint64(key, uint64(index))
+
+	return key
+}
+
+// Value returns a value of the provided size for the key, its content depending only on the key and the version
+func (w *Workload) Value(index int, version int, size int) []byte {
+	value := make([]byte, size)
+	rnd := rand.New(rand.NewSource(w.args.Seed ^ int64(index)<<20 ^ int64(version)))
+	_, _ = rnd.Read(value)
+
+	return value
+}
+
+// Op is an operation issued by a workload. Value is nil for the reads
+type Op struct {
+	Operation Operation
+	Key       []byte
+	Value     []byte
+}
+
+// Stream returns the stream of the operations issued by one worker. The streams of different workers issue different
+// operations, while the stream of a worker is the same on each run
+func (w *Workload) Stream(workerIndex int) *Stream {
+	rnd := rand.New(rand.NewSource(w.args.Seed + int64(workerIndex)))
+	stream := &Stream{
+		workload: w,
+		rnd:      rnd,
+	}
+	if w.args.ZipfS > 1 {
+		stream.zipf = rand.NewZipf(rnd, w.args.ZipfS, 1, uint64(w.args.NumKeys-1))
+	}
+
+	return stream
+}
+
+// Stream issues the operations of one worker. A stream is not concurrent safe
+type Stream struct {
+	workload   *Workload
+	rnd        *rand.Rand
+	zipf       *rand.Zipf
+	numWritten int
+}
+
+// Next returns the next operation
+func (s *Stream) Next() Op {
+	args := s.workload.args
+	index := s.nextKeyIndex()
+	if s.rnd.Float64() < args.ReadRatio {
+		return Op{
+			Operation: ReadOperation,
+			Key:       s.workload.Key(index),
+		}
+	}
+
+	s.numWritten++
+	size := args.MinValueSize + s.rnd.Intn(args.MaxValueSize-args.MinValueSize+1)
+
+	return Op{
+		Operation: WriteOperation,
+		Key:       s.workload.Key(index),
+		Value:     s.workload.Value(index, s.numWritten, size),
+	}
+}
+
+func (s *Stream) nextKeyIndex() int {
+	if s.zipf != nil {
+		return int(s.zipf.Uint64())
+	}
+
+	return s.rnd.Intn(s.workload.args.NumKeys)
+}
@@ -0,0 +1,120 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+const bitsPerWord = 64
+
+// Config holds the sizing of a bloom filter
+type Config struct {
+	// ExpectedNumKeys is the number of keys the filter is sized for. Adding more keys raises the false positive rate
+	ExpectedNumKeys uint64
+	// FalsePositiveRate is the probability, in (0, 1), of a missing key being reported as possibly present, once the
+	// filter holds ExpectedNumKeys keys
+	FalsePositiveRate float64
+}
+
+func (config Config) validate() error {
+	if config.ExpectedNumKeys == 0 {
+		return fmt.Errorf("%w: ExpectedNumKeys should be positive", common.ErrInvalidConfig)
+	}
+	if config.FalsePositiveRate <= 0 || config.FalsePositiveRate >= 1 {
+		return fmt.Errorf("%w: FalsePositiveRate should be in (0, 1)", common.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Filter is a bloom filter over byte slice keys: MayContain never returns false for an added key, while it returns
+// true for a missing key with the configured false positive rate. The keys can not be removed, so the removed keys
+// keep being reported as possibly present. It is safe for concurrent use, the bits being set atomically
+type Filter struct {
+	words     []uint64
+	numBits   uint64
+	numHashes uint64
+}
+
+// NewFilter creates a new empty bloom filter, sized for the provided config
+func NewFilter(config Config) (*Filter, error) {
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// m = -n * ln(p) / ln(2)^2 bits and k = m / n * ln(2) hashes minimize the false positive rate
+	numBits := uint64(math.Ceil(-float64(config.ExpectedNumKeys) * math.Log(config.FalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	numWords := (numBits + bitsPerWord - 1) / bitsPerWord
+	numHashes := uint64(math.Round(float64(numBits) / float64(config.ExpectedNumKeys) * math.Ln2))
+	if numHashes == 0 {
+		numHashes = 1
+	}
+
+	return &Filter{
+		words:     make([]uint64, numWords),
+		numBits:   numWords * bitsPerWord,
+		numHashes: numHashes,
+	}, nil
+}
+
+// Add adds the key to the filter
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.numHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		atomic.OrUint64(&f.words[bit/bitsPerWord], 1<<(bit%bitsPerWord))
+	}
+}
+
+// MayContain returns false if the key was surely not added, true if it was possibly added
+func (f *Filter) MayContain(key []byte) bool {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < f.numHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		if atomic.LoadUint64(&f.words[bit/bitsPerWord])&(1<<(bit%bitsPerWord)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reset removes all the keys
+func (f *Filter) Reset() {
+	for i := range f.words {
+		atomic.StoreUint64(&f.words[i], 0)
+	}
+}
+
+// NumBits returns the size of the filter, in bits
+func (f *Filter) NumBits() uint64 {
+	return f.numBits
+}
+
+// NumHashes returns the number of bits set for each key
+func (f *Filter) NumHashes() uint64 {
+	return f.numHashes
+}
+
+// hashes derives the two hashes combined by double hashing from the FNV-1a hash of the key, without allocating
+func hashes(key []byte) (uint64, uint64) {
+	hash := uint64(14695981039346656037)
+	for _, b := range key {
+		hash ^= uint64(b)
+		hash *= 1099511628211
+	}
+
+	// the second hash is made odd, so that it is never zero
+	h2 := (hash*0x9E3779B97F4A7C15)>>17 | 1
+
+	return hash, h2
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (f *Filter) IsInterfaceNil() bool {
+	return f == nil
+}
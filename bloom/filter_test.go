@@ -0,0 +1,64 @@
+package bloom_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no expected keys should error", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := bloom.NewFilter(bloom.Config{FalsePositiveRate: 0.01})
+		assert.Nil(t, f)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("invalid false positive rate should error", func(t *testing.T) {
+		t.Parallel()
+
+		for _, rate := range []float64{0, 1, -0.1, 2} {
+			f, err := bloom.NewFilter(bloom.Config{ExpectedNumKeys: 10, FalsePositiveRate: rate})
+			assert.Nil(t, f)
+			assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		}
+	})
+	t.Run("should size the filter", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := bloom.NewFilter(bloom.Config{ExpectedNumKeys: 1000, FalsePositiveRate: 0.01})
+		require.Nil(t, err)
+		// 9586 bits rounded up to whole words, 7 hashes
+		assert.Equal(t, uint64(9600), f.NumBits())
+		assert.Equal(t, uint64(7), f.NumHashes())
+	})
+}
+
+func TestFilter_AddAndMayContain(t *testing.T) {
+	t.Parallel()
+
+	numKeys := 10000
+	f, _ := bloom.NewFilter(bloom.Config{ExpectedNumKeys: uint64(numKeys), FalsePositiveRate: 0.01})
+	for i := 0; i < numKeys; i++ {
+		f.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+
+	for i := 0; i < numKeys; i++ {
+		assert.True(t, f.MayContain([]byte(fmt.Sprintf("key%d", i))))
+	}
+
+	numFalsePositives := 0
+	for i := 0; i < numKeys; i++ {
+		if f.MayContain([]byte(fmt.Sprintf("missing%d", i))) {
+			numFalsePositives++
+		}
+	}
+	assert.Less(t, numFalsePositives, numKeys*3/100)
+}
@@ -0,0 +1,310 @@
+package clockcache
+
+import (
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Cacher = (*ClockCache)(nil)
+var _ types.StatsProvider = (*ClockCache)(nil)
+
+var log = logger.GetOrCreate("storage/clockcache")
+
+// slot holds one entry of the clock ring. The referenced flag is the "second chance" bit and is the only field
+// touched on the read path, so reads never need the write lock
+type slot struct {
+	key        string
+	value      interface{}
+	size       int
+	occupied   bool
+	referenced atomic.Flag
+}
+
+// ClockCache implements a CLOCK (second-chance) eviction cache. Compared to an LRU cache, a hit only sets an
+// atomic reference bit instead of moving a list element under an exclusive lock, which makes it suitable for
+// extremely hot read paths
+type ClockCache struct {
+	mutSlots sync.RWMutex
+	slots    []*slot
+	index    map[string]int
+	free     []int
+	hand     int
+	numBytes uint64
+	maxsize  int
+
+	addedDataHandlers *dispatch.Dispatcher
+}
+
+// NewClockCache creates a new clock cache instance able to hold at most size elements
+func NewClockCache(size int) (*ClockCache, error) {
+	if size < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	cc := &ClockCache{
+		slots:             make([]*slot, size),
+		maxsize:           size,
+		addedDataHandlers: dispatch.NewDefaultDispatcher("clockcache"),
+	}
+	for i := range cc.slots {
+		cc.slots[i] = &slot{}
+	}
+	cc.resetIndexNoLock()
+
+	return cc, nil
+}
+
+func (cc *ClockCache) resetIndexNoLock() {
+	cc.index = make(map[string]int, cc.maxsize)
+	cc.free = make([]int, 0, cc.maxsize)
+	for i := cc.maxsize - 1; i >= 0; i-- {
+		cc.free = append(cc.free, i)
+	}
+	cc.hand = 0
+	cc.numBytes = 0
+}
+
+// Clear is used to completely clear the cache.
+func (cc *ClockCache) Clear() {
+	cc.mutSlots.Lock()
+	defer cc.mutSlots.Unlock()
+
+	for _, s := range cc.slots {
+		cc.emptySlot(s)
+	}
+	cc.resetIndexNoLock()
+}
+
+// Put adds a value to the cache.  Returns true if an eviction occurred.
+func (cc *ClockCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	cc.mutSlots.Lock()
+	evicted = cc.putNoLock(string(key), value, sizeInBytes)
+	cc.mutSlots.Unlock()
+
+	cc.addedDataHandlers.Dispatch(key, value)
+
+	return evicted
+}
+
+func (cc *ClockCache) putNoLock(key string, value interface{}, sizeInBytes int) (evicted bool) {
+	idx, exists := cc.index[key]
+	if exists {
+		s := cc.slots[idx]
+		cc.numBytes -= uint64(s.size)
+		s.value = value
+		s.size = sizeInBytes
+		cc.numBytes += uint64(sizeInBytes)
+		s.referenced.SetValue(true)
+
+		return false
+	}
+
+	idx = cc.findSlotNoLock()
+	s := cc.slots[idx]
+	evicted = s.occupied
+	if evicted {
+		delete(cc.index, s.key)
+		cc.numBytes -= uint64(s.size)
+	}
+
+	s.key = key
+	s.value = value
+	s.size = sizeInBytes
+	s.occupied = true
+	// newly added entries do not get a second chance until they are read at least once
+	s.referenced.Reset()
+	cc.index[key] = idx
+	cc.numBytes += uint64(sizeInBytes)
+
+	return evicted
+}
+
+// findSlotNoLock returns a free slot, if any, otherwise the slot of the element to be evicted
+func (cc *ClockCache) findSlotNoLock() int {
+	numFree := len(cc.free)
+	if numFree > 0 {
+		idx := cc.free[numFree-1]
+		cc.free = cc.free[:numFree-1]
+		return idx
+	}
+
+	return cc.findVictimNoLock()
+}
+
+// findVictimNoLock sweeps the hand over the full ring, clearing reference bits, until it finds a slot which has not
+// been referenced since the hand last passed over it. The hand is left right after the victim
+func (cc *ClockCache) findVictimNoLock() int {
+	for {
+		idx := cc.hand
+		cc.hand = (cc.hand + 1) % len(cc.slots)
+
+		s := cc.slots[idx]
+		if s.referenced.IsSet() {
+			s.referenced.Reset()
+			continue
+		}
+
+		return idx
+	}
+}
+
+func (cc *ClockCache) emptySlot(s *slot) {
+	s.key = ""
+	s.value = nil
+	s.size = 0
+	s.occupied = false
+	s.referenced.Reset()
+}
+
+// Get looks up a key's value from the cache.
+func (cc *ClockCache) Get(key []byte) (value interface{}, ok bool) {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	idx, ok := cc.index[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	s := cc.slots[idx]
+	// avoid writing the shared flag when it is already set, this keeps hot entries contention free
+	if !s.referenced.IsSet() {
+		s.referenced.SetValue(true)
+	}
+
+	return s.value, true
+}
+
+// Has checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (cc *ClockCache) Has(key []byte) bool {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	_, ok := cc.index[string(key)]
+
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (cc *ClockCache) Peek(key []byte) (value interface{}, ok bool) {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	idx, ok := cc.index[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return cc.slots[idx].value, true
+}
+
+// HasOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether the item existed before and whether it has been added.
+func (cc *ClockCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	cc.mutSlots.Lock()
+	_, has = cc.index[string(key)]
+	if !has {
+		_ = cc.putNoLock(string(key), value, sizeInBytes)
+	}
+	cc.mutSlots.Unlock()
+
+	if !has {
+		cc.addedDataHandlers.Dispatch(key, value)
+	}
+
+	return has, !has
+}
+
+// Remove removes the provided key from the cache.
+func (cc *ClockCache) Remove(key []byte) {
+	cc.mutSlots.Lock()
+	defer cc.mutSlots.Unlock()
+
+	idx, ok := cc.index[string(key)]
+	if !ok {
+		return
+	}
+
+	s := cc.slots[idx]
+	delete(cc.index, s.key)
+	cc.numBytes -= uint64(s.size)
+	cc.emptySlot(s)
+	cc.free = append(cc.free, idx)
+}
+
+// Keys returns a slice of the keys in the cache, in the order in which the clock hand will reach them
+func (cc *ClockCache) Keys() [][]byte {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	keys := make([][]byte, 0, len(cc.index))
+	for i := 0; i < len(cc.slots); i++ {
+		s := cc.slots[(cc.hand+i)%len(cc.slots)]
+		if !s.occupied {
+			continue
+		}
+
+		keys = append(keys, []byte(s.key))
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (cc *ClockCache) Len() int {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	return len(cc.index)
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (cc *ClockCache) SizeInBytesContained() uint64 {
+	cc.mutSlots.RLock()
+	defer cc.mutSlots.RUnlock()
+
+	return cc.numBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (cc *ClockCache) MaxSize() int {
+	return cc.maxsize
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (cc *ClockCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	cc.addedDataHandlers.Register(id, handler)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (cc *ClockCache) UnRegisterHandler(id string) {
+	cc.addedDataHandlers.Unregister(id)
+}
+
+// Close does nothing for this cacher implementation
+func (cc *ClockCache) Close() error {
+	return nil
+}
+
+// Stats returns the key stats of the cache
+func (cc *ClockCache) Stats() map[string]interface{} {
+	return types.CacheStats(string(common.ClockCache), cc)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cc *ClockCache) IsInterfaceNil() bool {
+	return cc == nil
+}
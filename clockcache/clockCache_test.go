@@ -0,0 +1,283 @@
+package clockcache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/clockcache"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var timeoutWaitForWaitGroups = time.Second * 2
+
+func TestNewClockCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid size should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := clockcache.NewClockCache(0)
+		assert.True(t, check.IfNil(c))
+		assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := clockcache.NewClockCache(10)
+		assert.False(t, check.IfNil(c))
+		assert.Nil(t, err)
+		assert.Equal(t, 10, c.MaxSize())
+	})
+}
+
+func TestClockCache_PutGetPeekHas(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(10)
+	key, val := []byte("key"), []byte("value")
+
+	evicted := c.Put(key, val, len(val))
+	assert.False(t, evicted)
+	assert.Equal(t, 1, c.Len())
+	assert.True(t, c.Has(key))
+
+	recovered, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, val, recovered)
+
+	recovered, ok = c.Peek(key)
+	assert.True(t, ok)
+	assert.Equal(t, val, recovered)
+
+	recovered, ok = c.Get([]byte("missing"))
+	assert.False(t, ok)
+	assert.Nil(t, recovered)
+	assert.False(t, c.Has([]byte("missing")))
+}
+
+func TestClockCache_PutPresentRewritesValueAndSize(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(10)
+	key := []byte("key")
+
+	c.Put(key, []byte("value1"), 6)
+	c.Put(key, []byte("value22"), 7)
+
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(7), c.SizeInBytesContained())
+	recovered, _ := c.Get(key)
+	assert.Equal(t, []byte("value22"), recovered)
+}
+
+func TestClockCache_EvictionGivesSecondChanceToReferencedItems(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(3)
+	c.Put([]byte("a"), "a", 1)
+	c.Put([]byte("b"), "b", 1)
+	c.Put([]byte("c"), "c", 1)
+
+	// "a" was read, so it survives the first sweep
+	_, _ = c.Get([]byte("a"))
+
+	evicted := c.Put([]byte("d"), "d", 1)
+	require.True(t, evicted)
+	require.Equal(t, 3, c.Len())
+	require.True(t, c.Has([]byte("a")))
+	require.False(t, c.Has([]byte("b")))
+	require.True(t, c.Has([]byte("c")))
+	require.True(t, c.Has([]byte("d")))
+
+	// the reference bit of "a" has been consumed, so "c" is next, then "a"
+	c.Put([]byte("e"), "e", 1)
+	require.False(t, c.Has([]byte("c")))
+	c.Put([]byte("f"), "f", 1)
+	require.False(t, c.Has([]byte("a")))
+	require.Equal(t, uint64(3), c.SizeInBytesContained())
+}
+
+func TestClockCache_PeekAndHasDoNotGiveSecondChance(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(2)
+	c.Put([]byte("a"), "a", 1)
+	c.Put([]byte("b"), "b", 1)
+
+	_, _ = c.Peek([]byte("a"))
+	_ = c.Has([]byte("a"))
+
+	c.Put([]byte("c"), "c", 1)
+	assert.False(t, c.Has([]byte("a")))
+	assert.True(t, c.Has([]byte("b")))
+}
+
+func TestClockCache_RemoveFreesSlot(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(2)
+	c.Put([]byte("a"), "a", 4)
+	c.Put([]byte("b"), "b", 5)
+
+	c.Remove([]byte("a"))
+	c.Remove([]byte("missing"))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(5), c.SizeInBytesContained())
+
+	evicted := c.Put([]byte("c"), "c", 6)
+	assert.False(t, evicted)
+	assert.True(t, c.Has([]byte("b")))
+	assert.True(t, c.Has([]byte("c")))
+}
+
+func TestClockCache_HasOrAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(10)
+	key := []byte("key")
+
+	has, added := c.HasOrAdd(key, "value1", 1)
+	assert.False(t, has)
+	assert.True(t, added)
+
+	has, added = c.HasOrAdd(key, "value2", 1)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	recovered, _ := c.Get(key)
+	assert.Equal(t, "value1", recovered)
+}
+
+func TestClockCache_KeysAndClear(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(10)
+	for i := 0; i < 5; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 1)
+	}
+
+	keys := c.Keys()
+	require.Len(t, keys, 5)
+	for i := 0; i < 5; i++ {
+		require.Equal(t, []byte(fmt.Sprintf("key%d", i)), keys[i])
+	}
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+	assert.Empty(t, c.Keys())
+}
+
+func TestClockCache_RegisterHandlerShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(10)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	chDone := make(chan bool)
+
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		assert.Equal(t, []byte("key"), key)
+		wg.Done()
+	}, "id")
+	c.RegisterHandler(nil, "nil")
+
+	go func() {
+		wg.Wait()
+		chDone <- true
+	}()
+
+	c.Put([]byte("key"), "value", 0)
+
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "should have been called")
+		return
+	}
+
+	c.UnRegisterHandler("id")
+}
+
+func TestClockCache_ConcurrentOperationsShouldNotPanic(t *testing.T) {
+	t.Parallel()
+
+	c, _ := clockcache.NewClockCache(100)
+	numOperations := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			key := []byte(fmt.Sprintf("key%d", idx%250))
+			switch idx % 5 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.Get(key)
+			case 2:
+				_, _ = c.HasOrAdd(key, idx, 1)
+			case 3:
+				c.Remove(key)
+			case 4:
+				_ = c.Keys()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(t, c.Len() <= 100)
+}
+
+func TestClockCache_Stress(t *testing.T) {
+	t.Parallel()
+
+	args := testscommon.ArgsCacheStress{
+		NumGoroutines:       8,
+		NumOpsPerGoroutine:  2000,
+		NumKeysPerGoroutine: 20,
+		OperationWeights: map[testscommon.CacheOperation]int{
+			testscommon.CachePut:    5,
+			testscommon.CacheGet:    10,
+			testscommon.CacheRemove: 2,
+			testscommon.CacheKeys:   1,
+		},
+		Seed: 7,
+	}
+
+	t.Run("without evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := clockcache.NewClockCache(1000)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 1000
+		stressArgs.ExpectNoEvictions = true
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Equal(t, 0, report.NumEvictions)
+	})
+	t.Run("with evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := clockcache.NewClockCache(40)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 40
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Greater(t, report.NumEvictions, 0)
+	})
+}
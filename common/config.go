@@ -2,6 +2,8 @@ package common
 
 import (
 	"encoding/json"
+	"fmt"
+	"runtime"
 
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 )
@@ -17,6 +19,26 @@ type CacheConfig struct {
 	Capacity             uint32
 	SizePerSender        uint32
 	Shards               uint32
+	EvictionStrategy     EvictionStrategy
+	// L1Capacity and L2Type are only used by the two-level caches: the L1 cache is a plain LRU cache holding at most
+	// L1Capacity items, while the L2 cache is created from the rest of the config, as if its type was L2Type
+	L1Capacity uint32
+	L2Type     CacheType
+	// TTLInSeconds and SweepIntervalInSeconds are used by the time caches and, optionally, by the FIFO sharded caches:
+	// the items expire TTLInSeconds after being added, the expired items being swept every SweepIntervalInSeconds
+	// (defaulting to TTLInSeconds). The items of the FIFO sharded caches do not expire if TTLInSeconds is 0
+	TTLInSeconds           uint32
+	SweepIntervalInSeconds uint32
+	// EvictionPolicy is only used by the two-level caches, selecting the algorithm of the L1 cache (defaulting to LRU)
+	EvictionPolicy EvictionPolicy
+	// ShardingStrategy is only used by the sharded caches, selecting how their number of shards is chosen (defaulting
+	// to the configured Shards)
+	ShardingStrategy ShardingStrategy
+	// OnAdded and OnEvicted are registered on the created cache, the first one being called for each added item and the
+	// second one for each item evicted (or expired) in order to make room for new ones. They are not part of the
+	// config files
+	OnAdded   func(key []byte, value interface{}) `json:"-" toml:"-"`
+	OnEvicted func(key []byte, value interface{}) `json:"-" toml:"-"`
 }
 
 // String returns a readable representation of the object
@@ -29,6 +51,257 @@ func (config *CacheConfig) String() string {
 	return string(bytes)
 }
 
+// ApplyDefaults fills the unset elements which have sane defaults
+func (config *CacheConfig) ApplyDefaults() {
+	if isShardedCacheType(config.Type) || (config.Type == TwoLevelCache && isShardedCacheType(config.L2Type)) {
+		config.Shards = config.NumShards()
+		if config.Shards == 0 {
+			config.Shards = DefaultNumShards
+		}
+	}
+	if config.isExpiringCacheType(config.Type) || (config.Type == TwoLevelCache && config.isExpiringCacheType(config.L2Type)) {
+		if config.SweepIntervalInSeconds == 0 {
+			config.SweepIntervalInSeconds = config.TTLInSeconds
+		}
+	}
+}
+
+// Validate checks all the elements of the config, returning an aggregated error which names every invalid element
+func (config *CacheConfig) Validate() error {
+	validator := ConfigValidator{}
+	validator.Add(config.validateType(config.Type)...)
+	validator.Check(isSupportedEvictionPolicy(config.EvictionPolicy), ErrInvalidConfig, "EvictionPolicy %q", config.EvictionPolicy)
+	validator.Check(isSupportedShardingStrategy(config.ShardingStrategy), ErrInvalidConfig, "ShardingStrategy %q", config.ShardingStrategy)
+
+	return validator.Err()
+}
+
+func isSupportedEvictionPolicy(policy EvictionPolicy) bool {
+	switch policy {
+	case "", LRUEvictionPolicy, FIFOEvictionPolicy, ClockEvictionPolicy:
+		return true
+	default:
+		return false
+	}
+}
+
+func isSupportedShardingStrategy(strategy ShardingStrategy) bool {
+	switch strategy {
+	case "", FixedSharding, PerCPUSharding:
+		return true
+	default:
+		return false
+	}
+}
+
+// NumShards returns the number of shards (or chunks) of the configured sharded cache, as chosen by the sharding strategy
+func (config *CacheConfig) NumShards() uint32 {
+	cacheType := config.Type
+	if cacheType == TwoLevelCache {
+		cacheType = config.L2Type
+	}
+
+	return config.numShards(cacheType)
+}
+
+func (config *CacheConfig) numShards(cacheType CacheType) uint32 {
+	if config.ShardingStrategy != PerCPUSharding {
+		return config.Shards
+	}
+
+	shards := uint32(runtime.GOMAXPROCS(0))
+	if cacheType == ImmunityCache && shards > MaxNumChunksForImmunityCache {
+		return MaxNumChunksForImmunityCache
+	}
+
+	return shards
+}
+
+func (config *CacheConfig) validateType(cacheType CacheType) []error {
+	switch cacheType {
+	case LRUCache:
+		errs := config.validateCapacity()
+		if config.SizeInBytes != 0 {
+			errs = append(errs, fmt.Errorf("%w: SizeInBytes should not be set", ErrLRUCacheWithProvidedSize))
+		}
+		return errs
+	case SizeLRUCache:
+		errs := config.validateCapacity()
+		if config.SizeInBytes < MinSizeInBytesForSizeLRUCache {
+			errs = append(errs, fmt.Errorf("%w: SizeInBytes is %d, minimum %d",
+				ErrLRUCacheInvalidSize,
+				config.SizeInBytes,
+				MinSizeInBytesForSizeLRUCache,
+			))
+		}
+		return errs
+	case FIFOShardedCache:
+		errs := config.validateCapacity()
+		if config.numShards(cacheType) == 0 {
+			errs = append(errs, fmt.Errorf("%w: Shards should be positive", ErrInvalidConfig))
+		}
+		return errs
+	case ClockCache, SyncMapCache:
+		return config.validateCapacity()
+	case ImmunityCache:
+		return config.validateImmunityCache()
+	case TimeCache:
+		return config.validateTimeCache()
+	case TwoLevelCache:
+		return config.validateTwoLevelCache()
+	default:
+		return []error{fmt.Errorf("%w: Type %q, supported types: %s", ErrNotSupportedCacheType, cacheType, joinNames(supportedCacheTypes))}
+	}
+}
+
+func (config *CacheConfig) validateCapacity() []error {
+	if config.Capacity == 0 {
+		return []error{fmt.Errorf("%w: Capacity should be positive", ErrCacheSizeInvalid)}
+	}
+
+	return nil
+}
+
+func (config *CacheConfig) validateImmunityCache() []error {
+	errs := make([]error, 0)
+	if len(config.Name) == 0 {
+		errs = append(errs, fmt.Errorf("%w: Name is required for the immunity caches", ErrInvalidConfig))
+	}
+	shards := config.numShards(ImmunityCache)
+	if shards == 0 || shards > MaxNumChunksForImmunityCache {
+		errs = append(errs, fmt.Errorf("%w: Shards should be between 1 and %d", ErrInvalidConfig, MaxNumChunksForImmunityCache))
+	}
+	if config.Capacity < MinCapacityForImmunityCache {
+		errs = append(errs, fmt.Errorf("%w: Capacity should be at least %d", ErrInvalidConfig, MinCapacityForImmunityCache))
+	}
+	if config.SizeInBytes < MinCapacityForImmunityCache || config.SizeInBytes > MaxSizeInBytesForImmunityCache {
+		errs = append(errs, fmt.Errorf("%w: SizeInBytes should be between %d and %d",
+			ErrInvalidConfig,
+			MinCapacityForImmunityCache,
+			MaxSizeInBytesForImmunityCache,
+		))
+	}
+
+	switch config.EvictionStrategy {
+	case "", FIFOEviction, OldestFirstEviction, LargestFirstEviction:
+	default:
+		errs = append(errs, fmt.Errorf("%w: EvictionStrategy %q", ErrInvalidConfig, config.EvictionStrategy))
+	}
+
+	return errs
+}
+
+func (config *CacheConfig) validateTimeCache() []error {
+	errs := make([]error, 0)
+	if config.TTLInSeconds == 0 {
+		errs = append(errs, fmt.Errorf("%w: TTLInSeconds should be positive", ErrInvalidDefaultSpan))
+	}
+	if config.SweepIntervalInSeconds == 0 {
+		errs = append(errs, fmt.Errorf("%w: SweepIntervalInSeconds should be positive", ErrInvalidCacheExpiry))
+	}
+
+	return errs
+}
+
+func (config *CacheConfig) validateTwoLevelCache() []error {
+	errs := make([]error, 0)
+	if config.L1Capacity == 0 {
+		errs = append(errs, fmt.Errorf("%w: L1Capacity should be positive", ErrCacheSizeInvalid))
+	}
+	if config.L2Type == TwoLevelCache {
+		return append(errs, fmt.Errorf("%w: L2Type %q", ErrNotSupportedCacheType, config.L2Type))
+	}
+
+	for _, err := range config.validateType(config.L2Type) {
+		errs = append(errs, fmt.Errorf("%w for the L2 cache", err))
+	}
+
+	return errs
+}
+
+// Clamp adjusts the out of range elements to their nearest valid values, returning the description of each adjustment.
+// The elements without a nearest valid value, like a missing capacity, are left for Validate to report
+func (config *CacheConfig) Clamp() []string {
+	return config.clampType(config.Type)
+}
+
+func (config *CacheConfig) clampType(cacheType CacheType) []string {
+	adjustments := make([]string, 0)
+	switch cacheType {
+	case LRUCache:
+		if config.SizeInBytes != 0 {
+			adjustments = append(adjustments, fmt.Sprintf("SizeInBytes changed from %d to 0, as the LRU caches are not sized", config.SizeInBytes))
+			config.SizeInBytes = 0
+		}
+	case SizeLRUCache:
+		if config.SizeInBytes < MinSizeInBytesForSizeLRUCache {
+			adjustments = append(adjustments, fmt.Sprintf("SizeInBytes changed from %d to %d", config.SizeInBytes, MinSizeInBytesForSizeLRUCache))
+			config.SizeInBytes = MinSizeInBytesForSizeLRUCache
+		}
+	case FIFOShardedCache:
+		if config.numShards(cacheType) == 0 {
+			adjustments = append(adjustments, fmt.Sprintf("Shards changed from 0 to %d", DefaultNumShards))
+			config.Shards = DefaultNumShards
+		}
+	case ImmunityCache:
+		adjustments = append(adjustments, config.clampImmunityCache()...)
+	case TwoLevelCache:
+		if config.L2Type != TwoLevelCache {
+			for _, adjustment := range config.clampType(config.L2Type) {
+				adjustments = append(adjustments, adjustment+" for the L2 cache")
+			}
+		}
+	}
+
+	return adjustments
+}
+
+func (config *CacheConfig) clampImmunityCache() []string {
+	if config.ShardingStrategy == PerCPUSharding {
+		return config.clampImmunityCacheSize()
+	}
+
+	adjustments := make([]string, 0)
+	if config.Shards == 0 {
+		adjustments = append(adjustments, fmt.Sprintf("Shards changed from 0 to %d", DefaultNumShards))
+		config.Shards = DefaultNumShards
+	}
+	if config.Shards > MaxNumChunksForImmunityCache {
+		adjustments = append(adjustments, fmt.Sprintf("Shards changed from %d to %d", config.Shards, MaxNumChunksForImmunityCache))
+		config.Shards = MaxNumChunksForImmunityCache
+	}
+	adjustments = append(adjustments, config.clampImmunityCacheSize()...)
+
+	return adjustments
+}
+
+func (config *CacheConfig) clampImmunityCacheSize() []string {
+	adjustments := make([]string, 0)
+	if config.Capacity != 0 && config.Capacity < MinCapacityForImmunityCache {
+		adjustments = append(adjustments, fmt.Sprintf("Capacity changed from %d to %d", config.Capacity, MinCapacityForImmunityCache))
+		config.Capacity = MinCapacityForImmunityCache
+	}
+	if config.SizeInBytes != 0 && config.SizeInBytes < MinCapacityForImmunityCache {
+		adjustments = append(adjustments, fmt.Sprintf("SizeInBytes changed from %d to %d", config.SizeInBytes, MinCapacityForImmunityCache))
+		config.SizeInBytes = MinCapacityForImmunityCache
+	}
+	if config.SizeInBytes > MaxSizeInBytesForImmunityCache {
+		adjustments = append(adjustments, fmt.Sprintf("SizeInBytes changed from %d to %d", config.SizeInBytes, MaxSizeInBytesForImmunityCache))
+		config.SizeInBytes = MaxSizeInBytesForImmunityCache
+	}
+
+	return adjustments
+}
+
+// isExpiringCacheType returns true for the time caches and for the FIFO sharded caches configured with a time to live
+func (config *CacheConfig) isExpiringCacheType(cacheType CacheType) bool {
+	return cacheType == TimeCache || (cacheType == FIFOShardedCache && config.TTLInSeconds > 0)
+}
+
+func isShardedCacheType(cacheType CacheType) bool {
+	return cacheType == FIFOShardedCache || cacheType == ImmunityCache
+}
+
 // DBConfig holds the configurable elements of a database
 type DBConfig struct {
 	FilePath          string
@@ -36,4 +309,54 @@ type DBConfig struct {
 	BatchDelaySeconds int
 	MaxBatchSize      int
 	MaxOpenFiles      int
+	// BlockCacheSizeInBytes, if positive, enables the block cache of the LevelDB persisters, with the provided budget
+	BlockCacheSizeInBytes int
+	// NumReaders, if positive, serves the reads of the serial LevelDB persisters from a pool of NumReaders go routines
+	NumReaders int
+	// WriteAroundThresholdInBytes, if positive, makes the LevelDB persisters write the values at least this large
+	// straight to the database, bypassing the batch, asynchronously if WriteAroundAsync is set
+	WriteAroundThresholdInBytes int
+	WriteAroundAsync            bool
+}
+
+// WritePolicyConfig holds the write policy of a storage unit. The unset policy is the write through one
+type WritePolicyConfig struct {
+	Policy WritePolicy
+	// FlushIntervalInSeconds and MaxNumDirty are only used by the write back policy: the dirty values are persisted
+	// every FlushIntervalInSeconds, or as soon as MaxNumDirty of them are held
+	FlushIntervalInSeconds uint32
+	MaxNumDirty            uint32
+	// BypassThresholdInBytes is only used by the cache bypass policy: the values at least this large are not cached
+	BypassThresholdInBytes uint32
+}
+
+// ApplyDefaults fills the unset elements which have sane defaults
+func (config *WritePolicyConfig) ApplyDefaults() {
+	if config.Policy != WriteBackPolicy {
+		return
+	}
+
+	if config.FlushIntervalInSeconds == 0 {
+		config.FlushIntervalInSeconds = DefaultWriteBackFlushIntervalInSeconds
+	}
+	if config.MaxNumDirty == 0 {
+		config.MaxNumDirty = DefaultWriteBackMaxNumDirty
+	}
+}
+
+// Validate checks all the elements of the config, returning an aggregated error which names every invalid element
+func (config *WritePolicyConfig) Validate() error {
+	validator := ConfigValidator{}
+	switch config.Policy {
+	case "", WriteThroughPolicy:
+	case WriteBackPolicy:
+		validator.Check(config.FlushIntervalInSeconds > 0, ErrInvalidConfig, "FlushIntervalInSeconds should be positive for %s", config.Policy)
+		validator.Check(config.MaxNumDirty > 0, ErrInvalidConfig, "MaxNumDirty should be positive for %s", config.Policy)
+	case CacheBypassPolicy:
+		validator.Check(config.BypassThresholdInBytes > 0, ErrInvalidConfig, "BypassThresholdInBytes should be positive for %s", config.Policy)
+	default:
+		validator.Check(false, ErrInvalidConfig, "Policy %q", config.Policy)
+	}
+
+	return validator.Err()
 }
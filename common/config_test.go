@@ -0,0 +1,192 @@
+package common
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheConfig_ApplyDefaults(t *testing.T) {
+	t.Parallel()
+
+	config := CacheConfig{Type: ImmunityCache}
+	config.ApplyDefaults()
+	assert.Equal(t, uint32(DefaultNumShards), config.Shards)
+
+	config = CacheConfig{Type: TwoLevelCache, L2Type: FIFOShardedCache, Shards: 4}
+	config.ApplyDefaults()
+	assert.Equal(t, uint32(4), config.Shards)
+
+	config = CacheConfig{Type: LRUCache}
+	config.ApplyDefaults()
+	assert.Zero(t, config.Shards)
+
+	config = CacheConfig{Type: TimeCache, TTLInSeconds: 60}
+	config.ApplyDefaults()
+	assert.Equal(t, uint32(60), config.SweepIntervalInSeconds)
+
+	config = CacheConfig{Type: FIFOShardedCache, Shards: 4, ShardingStrategy: PerCPUSharding}
+	config.ApplyDefaults()
+	assert.Equal(t, uint32(runtime.GOMAXPROCS(0)), config.Shards)
+}
+
+func TestCacheConfig_NumShards(t *testing.T) {
+	t.Parallel()
+
+	config := CacheConfig{Type: FIFOShardedCache, Shards: 4}
+	assert.Equal(t, uint32(4), config.NumShards())
+
+	config.ShardingStrategy = FixedSharding
+	assert.Equal(t, uint32(4), config.NumShards())
+
+	config.ShardingStrategy = PerCPUSharding
+	assert.Equal(t, uint32(runtime.GOMAXPROCS(0)), config.NumShards())
+
+	config = CacheConfig{Type: TwoLevelCache, L2Type: ImmunityCache, ShardingStrategy: PerCPUSharding}
+	assert.LessOrEqual(t, config.NumShards(), uint32(MaxNumChunksForImmunityCache))
+	assert.Positive(t, config.NumShards())
+}
+
+func TestCacheConfig_StringShouldSkipTheCallbacks(t *testing.T) {
+	t.Parallel()
+
+	config := CacheConfig{
+		Name:      "txs",
+		Type:      LRUCache,
+		OnAdded:   func(_ []byte, _ interface{}) {},
+		OnEvicted: func(_ []byte, _ interface{}) {},
+	}
+	assert.Contains(t, config.String(), `"Name":"txs"`)
+	assert.NotContains(t, config.String(), "OnAdded")
+}
+
+func TestCacheConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid configs should work", func(t *testing.T) {
+		t.Parallel()
+
+		validConfigs := []CacheConfig{
+			{Type: LRUCache, Capacity: 10},
+			{Type: SizeLRUCache, Capacity: 10, SizeInBytes: MinSizeInBytesForSizeLRUCache},
+			{Type: FIFOShardedCache, Capacity: 10, Shards: 2},
+			{Type: ClockCache, Capacity: 10},
+			{Type: SyncMapCache, Capacity: 10},
+			{Type: ImmunityCache, Name: "txs", Capacity: 10, SizeInBytes: 1024, Shards: 4, EvictionStrategy: LargestFirstEviction},
+			{Type: TimeCache, TTLInSeconds: 60, SweepIntervalInSeconds: 10},
+			{Type: TwoLevelCache, L1Capacity: 2, L2Type: LRUCache, Capacity: 10},
+			{Type: TwoLevelCache, L1Capacity: 2, L2Type: LRUCache, Capacity: 10, EvictionPolicy: ClockEvictionPolicy},
+			{Type: FIFOShardedCache, Capacity: 10, ShardingStrategy: PerCPUSharding},
+			{Type: ImmunityCache, Name: "txs", Capacity: 10, SizeInBytes: 1024, ShardingStrategy: PerCPUSharding},
+		}
+		for _, config := range validConfigs {
+			assert.Nil(t, config.Validate(), config.String())
+		}
+	})
+	t.Run("unknown type should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: "NotLRU", Capacity: 10}
+		assert.True(t, errors.Is(config.Validate(), ErrNotSupportedCacheType))
+	})
+	t.Run("LRU cache with size in bytes should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: LRUCache, SizeInBytes: 10}
+		err := config.Validate()
+		assert.True(t, errors.Is(err, ErrLRUCacheWithProvidedSize))
+		assert.True(t, errors.Is(err, ErrCacheSizeInvalid))
+	})
+	t.Run("time cache without TTL and sweep interval should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: TimeCache}
+		err := config.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidDefaultSpan))
+		assert.True(t, errors.Is(err, ErrInvalidCacheExpiry))
+	})
+	t.Run("immunity cache should name every invalid field", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: ImmunityCache, Capacity: 2, Shards: 200, EvictionStrategy: "Random"}
+		err := config.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidConfig))
+		for _, field := range []string{"Name", "Shards", "Capacity", "EvictionStrategy"} {
+			assert.Contains(t, err.Error(), field)
+		}
+	})
+	t.Run("two-level cache should validate the L2 cache", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: TwoLevelCache, L2Type: SizeLRUCache, Capacity: 10}
+		err := config.Validate()
+		assert.True(t, errors.Is(err, ErrCacheSizeInvalid))
+		assert.True(t, errors.Is(err, ErrLRUCacheInvalidSize))
+		assert.Contains(t, err.Error(), "L1Capacity")
+		assert.Contains(t, err.Error(), "for the L2 cache")
+
+		config = CacheConfig{Type: TwoLevelCache, L1Capacity: 2, L2Type: TwoLevelCache}
+		assert.True(t, errors.Is(config.Validate(), ErrNotSupportedCacheType))
+	})
+	t.Run("unknown eviction policy and sharding strategy should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := CacheConfig{Type: LRUCache, Capacity: 10, EvictionPolicy: "Random", ShardingStrategy: "PerCore"}
+		err := config.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "EvictionPolicy")
+		assert.Contains(t, err.Error(), "ShardingStrategy")
+	})
+}
+
+func TestCacheConfig_Clamp(t *testing.T) {
+	t.Parallel()
+
+	config := CacheConfig{Type: SizeLRUCache, Capacity: 10, SizeInBytes: 10}
+	assert.Equal(t, []string{"SizeInBytes changed from 10 to 1024"}, config.Clamp())
+	assert.Nil(t, config.Validate())
+	assert.Empty(t, config.Clamp())
+
+	config = CacheConfig{Type: ImmunityCache, Name: "txs", Capacity: 2, Shards: 200, SizeInBytes: 2 * MaxSizeInBytesForImmunityCache}
+	assert.Len(t, config.Clamp(), 3)
+	assert.Equal(t, uint32(MaxNumChunksForImmunityCache), config.Shards)
+	assert.Equal(t, uint32(MinCapacityForImmunityCache), config.Capacity)
+	assert.Equal(t, uint64(MaxSizeInBytesForImmunityCache), config.SizeInBytes)
+	assert.Nil(t, config.Validate())
+
+	config = CacheConfig{Type: TwoLevelCache, L1Capacity: 2, L2Type: LRUCache, Capacity: 10, SizeInBytes: 10}
+	assert.Equal(t, []string{"SizeInBytes changed from 10 to 0, as the LRU caches are not sized for the L2 cache"}, config.Clamp())
+	assert.Nil(t, config.Validate())
+
+	config = CacheConfig{Type: LRUCache}
+	assert.Empty(t, config.Clamp())
+	assert.True(t, errors.Is(config.Validate(), ErrCacheSizeInvalid))
+}
+
+func TestWritePolicyConfig_ApplyDefaultsAndValidate(t *testing.T) {
+	t.Parallel()
+
+	config := WritePolicyConfig{}
+	config.ApplyDefaults()
+	assert.Equal(t, WritePolicyConfig{}, config)
+	assert.Nil(t, config.Validate())
+
+	config = WritePolicyConfig{Policy: WriteBackPolicy}
+	assert.True(t, errors.Is(config.Validate(), ErrInvalidConfig))
+	config.ApplyDefaults()
+	assert.Equal(t, uint32(DefaultWriteBackFlushIntervalInSeconds), config.FlushIntervalInSeconds)
+	assert.Equal(t, uint32(DefaultWriteBackMaxNumDirty), config.MaxNumDirty)
+	assert.Nil(t, config.Validate())
+
+	config = WritePolicyConfig{Policy: CacheBypassPolicy}
+	err := config.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "BypassThresholdInBytes should be positive for CacheBypass")
+
+	config = WritePolicyConfig{Policy: "WriteAround"}
+	err = config.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), `Policy "WriteAround"`)
+}
@@ -10,18 +10,130 @@ const (
 	LRUCache         CacheType = "LRU"
 	SizeLRUCache     CacheType = "SizeLRU"
 	FIFOShardedCache CacheType = "FIFOSharded"
+	ClockCache       CacheType = "Clock"
+	ImmunityCache    CacheType = "Immunity"
+	SyncMapCache     CacheType = "SyncMap"
+	TwoLevelCache    CacheType = "TwoLevel"
+	TimeCache        CacheType = "Time"
 )
 
+// EvictionStrategy represents the order in which the (non-immune) items of an immunity cache are evicted
+type EvictionStrategy string
+
+// Eviction strategies that are currently supported
+const (
+	// FIFOEviction evicts the items in the order they were added
+	FIFOEviction EvictionStrategy = "FIFO"
+	// OldestFirstEviction evicts the items with the oldest timestamp first, the timestamp being refreshed on each Get
+	OldestFirstEviction EvictionStrategy = "OldestFirst"
+	// LargestFirstEviction evicts the largest items first
+	LargestFirstEviction EvictionStrategy = "LargestFirst"
+)
+
+// EvictionPolicy represents the algorithm choosing the items evicted from the L1 cache of a two-level cache
+type EvictionPolicy string
+
+// Eviction policies that are currently supported
+const (
+	// LRUEvictionPolicy evicts the least recently used items
+	LRUEvictionPolicy EvictionPolicy = "LRU"
+	// FIFOEvictionPolicy evicts the items in the order they were added
+	FIFOEvictionPolicy EvictionPolicy = "FIFO"
+	// ClockEvictionPolicy evicts the items not referenced since the clock hand last passed over them
+	ClockEvictionPolicy EvictionPolicy = "Clock"
+)
+
+// ShardingStrategy represents the way the number of shards (or chunks) of a sharded cache is chosen
+type ShardingStrategy string
+
+// Sharding strategies that are currently supported
+const (
+	// FixedSharding uses the configured number of shards
+	FixedSharding ShardingStrategy = "Fixed"
+	// PerCPUSharding uses one shard for each of the CPUs usable by the process, ignoring the configured number
+	PerCPUSharding ShardingStrategy = "PerCPU"
+)
+
+// DefaultNumShards is the number of shards (or chunks) of the sharded caches, if not configured
+const DefaultNumShards = 1
+
+// MaxNumChunksForImmunityCache is the maximum number of chunks of an immunity cache
+const MaxNumChunksForImmunityCache = 128
+
+// MinCapacityForImmunityCache is the minimum number of items (and bytes) of a bounded immunity cache
+const MinCapacityForImmunityCache = 4
+
+// MaxSizeInBytesForImmunityCache is the maximum size in bytes of an immunity cache
+const MaxSizeInBytesForImmunityCache = 1_073_741_824
+
+// MinSizeInBytesForSizeLRUCache is the minimum size in bytes of a sized LRU cache
+const MinSizeInBytesForSizeLRUCache = 1024
+
 // DBType represents the type of the supported databases
 type DBType string
 
 // DB types that are currently supported
 const (
-	LvlDB       DBType = "LvlDB"
-	LvlDBSerial DBType = "LvlDBSerial"
-	MemoryDB    DBType = "MemoryDB"
+	LvlDB         DBType = "LvlDB"
+	LvlDBSerial   DBType = "LvlDBSerial"
+	LvlDBReadOnly DBType = "LvlDBReadOnly"
+	MemoryDB      DBType = "MemoryDB"
+	ShardedDB     DBType = "Sharded"
 )
 
+// ConfigMode represents the way the factory handles the out of range config values
+type ConfigMode string
+
+// Config modes that are currently supported
+const (
+	// StrictConfig rejects the out of range values
+	StrictConfig ConfigMode = "Strict"
+	// LenientConfig clamps the out of range values to the nearest valid ones, emitting a warning for each of them
+	LenientConfig ConfigMode = "Lenient"
+)
+
+// PersisterDecorator represents the type of a decorator wrapping a persister
+type PersisterDecorator string
+
+// Persister decorators that are currently supported
+const (
+	// CompressionDecorator compresses the stored values with snappy
+	CompressionDecorator PersisterDecorator = "snappy"
+	// EncryptionDecorator encrypts the stored values with AES-GCM
+	EncryptionDecorator PersisterDecorator = "aes"
+	// ChecksumDecorator prepends a CRC-32C checksum to the stored values
+	ChecksumDecorator PersisterDecorator = "crc"
+	// RetryDecorator retries the operations which failed with a transient error
+	RetryDecorator PersisterDecorator = "retry"
+	// TracingDecorator starts a span around each operation, as configured by tracing.Setup
+	TracingDecorator PersisterDecorator = "tracing"
+	// VersioningDecorator prepends a format version to the stored values, upgrading the older ones when read
+	VersioningDecorator PersisterDecorator = "versioned"
+	// ReopenDecorator reopens the persister found closed by an operation, replaying the latest writes
+	ReopenDecorator PersisterDecorator = "reopen"
+)
+
+// WritePolicy represents the way a storage unit applies the writes on its cache and its persister
+type WritePolicy string
+
+// Write policies that are currently supported
+const (
+	// WriteThroughPolicy persists the values before caching them
+	WriteThroughPolicy WritePolicy = "WriteThrough"
+	// WriteBackPolicy caches the values, persisting them later, in batches
+	WriteBackPolicy WritePolicy = "WriteBack"
+	// CacheBypassPolicy persists the values like the write through policy, without caching the very large ones
+	CacheBypassPolicy WritePolicy = "CacheBypass"
+)
+
+// DefaultWriteBackFlushIntervalInSeconds is the interval between the flushes of the dirty values of the write back
+// storage units, if not configured
+const DefaultWriteBackFlushIntervalInSeconds = 1
+
+// DefaultWriteBackMaxNumDirty is the number of dirty values triggering a flush of the write back storage units, if not
+// configured
+const DefaultWriteBackMaxNumDirty = 10000
+
 // ShardIDProviderType represents the type for the supported shard id provider
 type ShardIDProviderType string
 
@@ -35,3 +147,10 @@ const MaxRetriesToCreateDB = 10
 
 // SleepTimeBetweenCreateDBRetries represents the number of seconds to sleep between DB creates
 const SleepTimeBetweenCreateDBRetries = 5 * time.Second
+
+// EpochPlaceholder is the placeholder replaced by the epoch in the path templates of the per-epoch persisters
+const EpochPlaceholder = "{epoch}"
+
+// RemovingDirSuffix ends the names the directories are renamed to before being removed, so that the leftovers of the
+// interrupted removals are detected
+const RemovingDirSuffix = ".removing"
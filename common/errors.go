@@ -80,3 +80,94 @@ var ErrInvalidCacheExpiry = errors.New("invalid cache expiry")
 
 // ErrDBIsClosed is raised when the DB is closed
 var ErrDBIsClosed = core.ErrDBIsClosed
+
+// ErrInvalidNumberOfShards signals that an invalid number of shards was provided
+var ErrInvalidNumberOfShards = errors.New("invalid number of shards")
+
+// ErrInvalidSweepInterval signals that an invalid sweep interval was provided
+var ErrInvalidSweepInterval = errors.New("invalid sweep interval")
+
+// ErrNilWriter signals that a nil writer has been provided
+var ErrNilWriter = errors.New("nil writer")
+
+// ErrNilReader signals that a nil reader has been provided
+var ErrNilReader = errors.New("nil reader")
+
+// ErrInvalidValueLength signals that a value with an unexpected length was read
+var ErrInvalidValueLength = errors.New("invalid value length")
+
+// ErrPinningCapacityReached signals that the capacity reserved for the pinned entries of a cache is reached
+var ErrPinningCapacityReached = errors.New("capacity reached for pinned entries")
+
+// ErrNilClock signals that a nil clock has been provided
+var ErrNilClock = errors.New("nil clock")
+
+// ErrCloseTimeout signals that a component could not be gracefully closed within the allotted time
+var ErrCloseTimeout = errors.New("timeout while closing")
+
+// ErrUnsupportedConfigFormat signals that the format of a config file is not supported
+var ErrUnsupportedConfigFormat = errors.New("unsupported config format")
+
+// ErrDBIsReadOnly signals that a write operation was attempted on a read only persister
+var ErrDBIsReadOnly = errors.New("db is read only")
+
+// ErrDBIsShared signals that an operation reserved to the sole user of a persister was attempted on a shared one
+var ErrDBIsShared = errors.New("db is shared")
+
+// ErrChecksumMismatch signals that a stored value does not match its checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrInvalidEncryptionKey signals that an invalid encryption key has been provided
+var ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+
+// ErrInvalidEpoch signals that an invalid epoch has been provided
+var ErrInvalidEpoch = errors.New("invalid epoch")
+
+// ErrProbeFailed signals that the probe of a persister did not read back the expected data
+var ErrProbeFailed = errors.New("persister probe failed")
+
+// ErrInvalidDumpFormat signals that a dump file does not have the expected format
+var ErrInvalidDumpFormat = errors.New("invalid dump format")
+
+// ErrNilPersisterFactory signals that a nil persister factory has been provided
+var ErrNilPersisterFactory = errors.New("nil persister factory")
+
+// ErrUnknownCheckpoint signals that an operation was attempted on a checkpoint which does not exist
+var ErrUnknownCheckpoint = errors.New("unknown checkpoint")
+
+// ErrInvalidKeyRange signals that the end key of a range is before its start key
+var ErrInvalidKeyRange = errors.New("invalid key range")
+
+// ErrUnsupportedFormatVersion signals that a stored value has a format version which can not be upgraded to the
+// current one
+var ErrUnsupportedFormatVersion = errors.New("unsupported format version")
+
+// ErrNilStorer signals that a nil storer has been provided
+var ErrNilStorer = errors.New("nil storer")
+
+// ErrTransactionDone signals an operation on a transaction already committed or rolled back
+var ErrTransactionDone = errors.New("transaction already committed or rolled back")
+
+// ErrNilCloser signals that a nil closer has been provided
+var ErrNilCloser = errors.New("nil closer")
+
+// ErrCloserAlreadyRegistered signals that a closer was registered under a name already in use
+var ErrCloserAlreadyRegistered = errors.New("closer already registered")
+
+// ErrUnknownCloser signals that an operation referenced a closer which is not registered
+var ErrUnknownCloser = errors.New("unknown closer")
+
+// ErrCloserHasDependents signals that a closer still having dependents was deregistered
+var ErrCloserHasDependents = errors.New("closer has dependents")
+
+// ErrRegistryClosed signals that an operation was attempted on a closer registry already closed
+var ErrRegistryClosed = errors.New("registry is closed")
+
+// ErrRetryQueueFull signals that a failed batch could not be queued for retry, as too many batches are already queued
+var ErrRetryQueueFull = errors.New("retry queue is full")
+
+// ErrWrongTypeAssertion signals that a decoded value is not of the expected type
+var ErrWrongTypeAssertion = errors.New("wrong type assertion")
+
+// ErrAlreadyStarted signals that a background worker was started again, while already running
+var ErrAlreadyStarted = errors.New("already started")
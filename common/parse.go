@@ -0,0 +1,68 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+var supportedCacheTypes = []CacheType{
+	LRUCache,
+	SizeLRUCache,
+	FIFOShardedCache,
+	ClockCache,
+	ImmunityCache,
+	SyncMapCache,
+	TwoLevelCache,
+	TimeCache,
+}
+
+var supportedDBTypes = []DBType{
+	LvlDB,
+	LvlDBSerial,
+	LvlDBReadOnly,
+	MemoryDB,
+	ShardedDB,
+}
+
+// SupportedCacheTypes returns the cache types the factory is able to create
+func SupportedCacheTypes() []CacheType {
+	return append([]CacheType(nil), supportedCacheTypes...)
+}
+
+// SupportedDBTypes returns the persister types the factory is able to create
+func SupportedDBTypes() []DBType {
+	return append([]DBType(nil), supportedDBTypes...)
+}
+
+// ParseCacheType returns the supported cache type matching the provided name, regardless of its case. The error
+// lists the supported types, for the unknown names
+func ParseCacheType(name string) (CacheType, error) {
+	for _, cacheType := range supportedCacheTypes {
+		if strings.EqualFold(string(cacheType), name) {
+			return cacheType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q, supported types: %s", ErrNotSupportedCacheType, name, joinNames(supportedCacheTypes))
+}
+
+// ParseDBType returns the supported persister type matching the provided name, regardless of its case. The error
+// lists the supported types, for the unknown names
+func ParseDBType(name string) (DBType, error) {
+	for _, dbType := range supportedDBTypes {
+		if strings.EqualFold(string(dbType), name) {
+			return dbType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q, supported types: %s", ErrNotSupportedDBType, name, joinNames(supportedDBTypes))
+}
+
+func joinNames[T ~string](names []T) string {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, string(name))
+	}
+
+	return strings.Join(parts, ", ")
+}
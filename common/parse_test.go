@@ -0,0 +1,55 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCacheType(t *testing.T) {
+	t.Parallel()
+
+	for _, cacheType := range SupportedCacheTypes() {
+		parsed, err := ParseCacheType(string(cacheType))
+		assert.Nil(t, err)
+		assert.Equal(t, cacheType, parsed)
+	}
+
+	parsed, err := ParseCacheType("sizelru")
+	assert.Nil(t, err)
+	assert.Equal(t, SizeLRUCache, parsed)
+
+	parsed, err = ParseCacheType("NotLRU")
+	assert.True(t, errors.Is(err, ErrNotSupportedCacheType))
+	assert.Empty(t, parsed)
+	assert.Contains(t, err.Error(), `"NotLRU", supported types: LRU, SizeLRU, FIFOSharded`)
+}
+
+func TestParseDBType(t *testing.T) {
+	t.Parallel()
+
+	for _, dbType := range SupportedDBTypes() {
+		parsed, err := ParseDBType(string(dbType))
+		assert.Nil(t, err)
+		assert.Equal(t, dbType, parsed)
+	}
+
+	parsed, err := ParseDBType("LVLDBSERIAL")
+	assert.Nil(t, err)
+	assert.Equal(t, LvlDBSerial, parsed)
+
+	parsed, err = ParseDBType("")
+	assert.True(t, errors.Is(err, ErrNotSupportedDBType))
+	assert.Empty(t, parsed)
+	assert.Contains(t, err.Error(), "supported types: LvlDB, LvlDBSerial, LvlDBReadOnly, MemoryDB, Sharded")
+}
+
+func TestSupportedTypesShouldReturnCopies(t *testing.T) {
+	t.Parallel()
+
+	SupportedCacheTypes()[0] = "changed"
+	SupportedDBTypes()[0] = "changed"
+	assert.Equal(t, LRUCache, SupportedCacheTypes()[0])
+	assert.Equal(t, LvlDB, SupportedDBTypes()[0])
+}
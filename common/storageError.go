@@ -0,0 +1,109 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// maxKeyBytesInErrors is the number of leading key bytes shown by the storage errors
+const maxKeyBytesInErrors = 8
+
+var redactKeysInErrors atomic.Bool
+
+// SetRedactKeysInErrors sets whether the storage errors show only the length of the keys, for the deployments whose
+// keys should not end up in the logs
+func SetRedactKeysInErrors(redact bool) {
+	redactKeysInErrors.Store(redact)
+}
+
+// StorageError carries the context of a failed storage operation: the key, truncated, the name of the storage unit
+// and the path of the persister, any of them being empty when unknown. It wraps the cause, so it remains matchable via
+// errors.Is, e.g. against ErrKeyNotFound or ErrDBIsClosed
+type StorageError struct {
+	Err  error
+	Unit string
+	Path string
+	// Key holds at most the first maxKeyBytesInErrors bytes of the key, KeyLen being its full length
+	Key    []byte
+	KeyLen int
+}
+
+// WrapStorageError wraps the error in a StorageError holding the provided context, returning nil for a nil error. If
+// the error already is a StorageError, the provided context only fills its missing fields, so that each layer adds
+// what it knows
+func WrapStorageError(err error, key []byte, unit string, path string) error {
+	if err == nil {
+		return nil
+	}
+
+	storageErr := &StorageError{Err: err}
+	existing, ok := err.(*StorageError)
+	if ok {
+		*storageErr = *existing
+	}
+	if len(storageErr.Unit) == 0 {
+		storageErr.Unit = unit
+	}
+	if len(storageErr.Path) == 0 {
+		storageErr.Path = path
+	}
+	if storageErr.KeyLen == 0 && len(key) > 0 {
+		storageErr.KeyLen = len(key)
+		storageErr.Key = append([]byte(nil), key[:min(len(key), maxKeyBytesInErrors)]...)
+	}
+
+	return storageErr
+}
+
+// NewKeyNotFoundError returns ErrKeyNotFound wrapped with the provided context
+func NewKeyNotFoundError(key []byte, unit string, path string) error {
+	return WrapStorageError(ErrKeyNotFound, key, unit, path)
+}
+
+// IsNotFound returns true if the error signals a missing key
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrKeyNotFound)
+}
+
+// IsClosed returns true if the error signals a closed persister
+func IsClosed(err error) bool {
+	return errors.Is(err, ErrDBIsClosed)
+}
+
+// Error returns the message of the wrapped error, followed by the context
+func (se *StorageError) Error() string {
+	context := make([]string, 0, 3)
+	if len(se.Unit) > 0 {
+		context = append(context, "unit: "+se.Unit)
+	}
+	if len(se.Path) > 0 {
+		context = append(context, "path: "+se.Path)
+	}
+	if se.KeyLen > 0 {
+		context = append(context, "key: "+se.keyString())
+	}
+	if len(context) == 0 {
+		return se.Err.Error()
+	}
+
+	return fmt.Sprintf("%s (%s)", se.Err.Error(), strings.Join(context, ", "))
+}
+
+func (se *StorageError) keyString() string {
+	if redactKeysInErrors.Load() {
+		return fmt.Sprintf("<redacted, %d bytes>", se.KeyLen)
+	}
+	if se.KeyLen > len(se.Key) {
+		return fmt.Sprintf("%s... (%d bytes)", hex.EncodeToString(se.Key), se.KeyLen)
+	}
+
+	return hex.EncodeToString(se.Key)
+}
+
+// Unwrap returns the wrapped error
+func (se *StorageError) Unwrap() error {
+	return se.Err
+}
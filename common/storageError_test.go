@@ -0,0 +1,50 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapStorageError(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, WrapStorageError(nil, []byte("key"), "Blocks", "db/Blocks"))
+
+	err := NewKeyNotFoundError([]byte{0x01, 0x02}, "", "db/Blocks")
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsClosed(err))
+	assert.Equal(t, "key not found (path: db/Blocks, key: 0102)", err.Error())
+
+	// the outer layers fill the missing fields only
+	err = WrapStorageError(err, []byte("other key"), "Blocks", "other/path")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, "key not found (unit: Blocks, path: db/Blocks, key: 0102)", err.Error())
+
+	// the errors wrapping a StorageError are wrapped again
+	err = WrapStorageError(fmt.Errorf("%w while reading", err), nil, "Outer", "")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "key not found (unit: Blocks, path: db/Blocks, key: 0102) while reading (unit: Outer)", err.Error())
+
+	err = WrapStorageError(ErrDBIsClosed, nil, "", "")
+	assert.True(t, IsClosed(err))
+	assert.Equal(t, ErrDBIsClosed.Error(), err.Error())
+
+	var storageErr *StorageError
+	longKey := []byte("0123456789abcdef")
+	err = NewKeyNotFoundError(longKey, "Blocks", "")
+	assert.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, longKey[:maxKeyBytesInErrors], storageErr.Key)
+	assert.Equal(t, len(longKey), storageErr.KeyLen)
+	assert.Equal(t, "key not found (unit: Blocks, key: 3031323334353637... (16 bytes))", err.Error())
+}
+
+func TestSetRedactKeysInErrors(t *testing.T) {
+	SetRedactKeysInErrors(true)
+	defer SetRedactKeysInErrors(false)
+
+	err := NewKeyNotFoundError([]byte("secret"), "Accounts", "")
+	assert.Equal(t, "key not found (unit: Accounts, key: <redacted, 6 bytes>)", err.Error())
+}
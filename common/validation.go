@@ -0,0 +1,54 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfigValidator collects the problems found while validating a config, so that all of them are reported at once.
+// The zero value is ready to use
+type ConfigValidator struct {
+	errs []error
+}
+
+// Check records a problem if the condition does not hold, wrapping the provided error with the formatted description,
+// which should name the invalid element
+func (cv *ConfigValidator) Check(condition bool, err error, format string, args ...interface{}) {
+	if condition {
+		return
+	}
+
+	cv.errs = append(cv.errs, fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...)))
+}
+
+// Add records the provided problems, the nil ones being skipped
+func (cv *ConfigValidator) Add(errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			cv.errs = append(cv.errs, err)
+		}
+	}
+}
+
+// AddNested records the problems of a nested config, as returned by its Validate method, each one being suffixed with
+// the provided description of the nested config, e.g. "for the cache"
+func (cv *ConfigValidator) AddNested(err error, suffix string) {
+	if err == nil {
+		return
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		cv.errs = append(cv.errs, fmt.Errorf("%w %s", err, suffix))
+		return
+	}
+
+	for _, nestedErr := range joined.Unwrap() {
+		cv.AddNested(nestedErr, suffix)
+	}
+}
+
+// Err returns an aggregated error listing all the recorded problems, or nil if there is none
+func (cv *ConfigValidator) Err() error {
+	return errors.Join(cv.errs...)
+}
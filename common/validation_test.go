@@ -0,0 +1,40 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidator(t *testing.T) {
+	t.Parallel()
+
+	validator := ConfigValidator{}
+	assert.Nil(t, validator.Err())
+
+	validator.Check(true, ErrInvalidConfig, "Name is required")
+	validator.Add(nil)
+	assert.Nil(t, validator.Err())
+
+	validator.Check(false, ErrInvalidConfig, "Shards is %d", 0)
+	validator.Add(ErrDBIsClosed, nil)
+	nested := errors.Join(
+		fmt.Errorf("%w: Capacity should be positive", ErrCacheSizeInvalid),
+		fmt.Errorf("%w: Type %q", ErrNotSupportedCacheType, "NotLRU"),
+	)
+	validator.AddNested(nested, "for the cache")
+	validator.AddNested(nil, "for the db")
+
+	err := validator.Err()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.True(t, errors.Is(err, ErrDBIsClosed))
+	assert.True(t, errors.Is(err, ErrCacheSizeInvalid))
+	assert.True(t, errors.Is(err, ErrNotSupportedCacheType))
+	expected := "invalid config: Shards is 0\n" +
+		ErrDBIsClosed.Error() + "\n" +
+		ErrCacheSizeInvalid.Error() + ": Capacity should be positive for the cache\n" +
+		ErrNotSupportedCacheType.Error() + ": Type \"NotLRU\" for the cache"
+	assert.Equal(t, expected, err.Error())
+}
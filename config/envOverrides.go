@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the storage configs
+const EnvPrefix = "DRT_STORAGE"
+
+const envTag = "env"
+const envTagInline = "inline"
+
+// ApplyEnvOverrides overrides the elements of the provided config (a pointer to a StorageUnitConfig, a
+// common.CacheConfig or a factory.ArgDB) from the environment variables named DRT_STORAGE_<UNIT>_<FIELD>, e.g.
+// DRT_STORAGE_TXS_MAXOPENFILES. The nested elements are named after their parent, e.g. DRT_STORAGE_TXS_SHARDED_NUMSHARDS,
+// while the lists are comma separated. The variables with the unit prefix not matching any element are rejected
+func ApplyEnvOverrides(unitName string, config interface{}) error {
+	return applyEnvOverrides(unitName, config, os.Environ())
+}
+
+func applyEnvOverrides(unitName string, config interface{}, environ []string) error {
+	value := reflect.ValueOf(config)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected a pointer to a config struct, got %T", common.ErrInvalidConfig, config)
+	}
+
+	fields := make(map[string]reflect.Value)
+	err := collectEnvFields(value.Elem(), "", fields)
+	if err != nil {
+		return err
+	}
+
+	prefix := EnvPrefix + "_" + envName(unitName) + "_"
+	overrides := make(map[string]string)
+	for _, entry := range environ {
+		name, val, found := strings.Cut(entry, "=")
+		if !found || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		overrides[strings.TrimPrefix(name, prefix)] = val
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("%w: the environment variable %s%s does not match any config element", common.ErrInvalidConfig, prefix, name)
+		}
+
+		err = setEnvField(field, overrides[name])
+		if err != nil {
+			return fmt.Errorf("%w: environment variable %s%s: %s", common.ErrInvalidConfig, prefix, name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func collectEnvFields(value reflect.Value, prefix string, fields map[string]reflect.Value) error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		structField := valueType.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			nestedPrefix := prefix + envName(structField.Name) + "_"
+			if structField.Tag.Get(envTag) == envTagInline {
+				nestedPrefix = prefix
+			}
+
+			err := collectEnvFields(field, nestedPrefix, fields)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := prefix + envName(structField.Name)
+		_, isDuplicate := fields[name]
+		if isDuplicate {
+			return fmt.Errorf("%w: more than one config element maps onto the environment variable suffix %s", common.ErrInvalidConfig, name)
+		}
+		fields[name] = field
+	}
+
+	return nil
+}
+
+func setEnvField(field reflect.Value, val string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list of %s", field.Type().Elem().Kind())
+		}
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(val, ",") {
+			item = strings.TrimSpace(item)
+			if len(item) > 0 {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(field.Type().Elem()))
+			}
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("unsupported element kind %s", field.Kind())
+	}
+
+	return nil
+}
+
+// envName converts the provided name to the environment variable format: upper case, with any character other than
+// letters and digits replaced by underscores
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should override the storage unit elements", func(t *testing.T) {
+		t.Parallel()
+
+		environ := []string{
+			"DRT_STORAGE_TX_POOL_MAXOPENFILES=50",
+			"DRT_STORAGE_TX_POOL_CAPACITY=1000",
+			"DRT_STORAGE_TX_POOL_DBTYPE=Sharded",
+			"DRT_STORAGE_TX_POOL_SHARDED_NUMSHARDS=4",
+			"DRT_STORAGE_TX_POOL_DECORATORS=crc, snappy",
+			"DRT_STORAGE_OTHER_CAPACITY=1",
+			"PATH=/usr/bin",
+		}
+		storageUnitConfig := StorageUnitConfig{
+			Cache: common.CacheConfig{Type: common.LRUCache, Capacity: 10},
+			DB:    factory.ArgDB{DBType: common.LvlDB, MaxOpenFiles: 10},
+		}
+		err := applyEnvOverrides("tx-pool", &storageUnitConfig, environ)
+		require.Nil(t, err)
+
+		assert.Equal(t, StorageUnitConfig{
+			Cache: common.CacheConfig{Type: common.LRUCache, Capacity: 1000},
+			DB: factory.ArgDB{
+				DBType:       common.ShardedDB,
+				MaxOpenFiles: 50,
+				Sharded:      factory.ShardedDBOptions{NumShards: 4},
+				Decorators:   []common.PersisterDecorator{common.ChecksumDecorator, common.CompressionDecorator},
+			},
+		}, storageUnitConfig)
+	})
+	t.Run("should override a standalone cache config", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConfig := common.CacheConfig{}
+		err := applyEnvOverrides("headers", &cacheConfig, []string{"DRT_STORAGE_HEADERS_TYPE=SizeLRU", "DRT_STORAGE_HEADERS_SIZEINBYTES=2048"})
+		require.Nil(t, err)
+		assert.Equal(t, common.CacheConfig{Type: common.SizeLRUCache, SizeInBytes: 2048}, cacheConfig)
+	})
+	t.Run("unknown element should error", func(t *testing.T) {
+		t.Parallel()
+
+		argDB := factory.ArgDB{}
+		err := applyEnvOverrides("unit", &argDB, []string{"DRT_STORAGE_UNIT_MAXOPENFILE=50"})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "DRT_STORAGE_UNIT_MAXOPENFILE")
+	})
+	t.Run("invalid value should error", func(t *testing.T) {
+		t.Parallel()
+
+		argDB := factory.ArgDB{}
+		err := applyEnvOverrides("unit", &argDB, []string{"DRT_STORAGE_UNIT_MAXOPENFILES=many"})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "DRT_STORAGE_UNIT_MAXOPENFILES")
+
+		cacheConfig := common.CacheConfig{}
+		err = applyEnvOverrides("unit", &cacheConfig, []string{"DRT_STORAGE_UNIT_CAPACITY=-1"})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("not a pointer to a struct should error", func(t *testing.T) {
+		t.Parallel()
+
+		err := applyEnvOverrides("unit", factory.ArgDB{}, nil)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+}
+
+func TestLoadStorageUnitConfigWithEnvOverrides(t *testing.T) {
+	content := `
+[Cache]
+	Type = "LRU"
+	Capacity = 10
+
+[DB]
+	DBType = "LvlDB"
+	Path = "db"
+`
+	t.Setenv("DRT_STORAGE_BLOCKS_MAXOPENFILES", "50")
+	t.Setenv("DRT_STORAGE_BLOCKS_PATH", "/data/blocks")
+
+	storageUnitConfig, err := LoadStorageUnitConfigWithEnvOverrides(writeFile(t, "unit.toml", content), "blocks")
+	require.Nil(t, err)
+	assert.Equal(t, 50, storageUnitConfig.DB.MaxOpenFiles)
+	assert.Equal(t, "/data/blocks", storageUnitConfig.DB.Path)
+	assert.Equal(t, 10, storageUnitConfig.DB.MaxBatchSize)
+
+	t.Setenv("DRT_STORAGE_BLOCKS_MAXOPENFILES", "0")
+	_, err = LoadStorageUnitConfigWithEnvOverrides(writeFile(t, "unit.toml", content), "blocks")
+	assert.Nil(t, err, "0 should be defaulted")
+
+	t.Setenv("DRT_STORAGE_BLOCKS_CAPACITY", "0")
+	_, err = LoadStorageUnitConfigWithEnvOverrides(writeFile(t, "unit.toml", content), "blocks")
+	assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+}
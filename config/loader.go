@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/pelletier/go-toml"
+)
+
+// StorageUnitConfig holds the configuration of a storage unit, as expected by factory.NewStorageUnit. The write policy
+// is passed with factory.WithWritePolicy
+type StorageUnitConfig struct {
+	Cache       common.CacheConfig `env:"inline"`
+	DB          factory.ArgDB      `env:"inline"`
+	WritePolicy common.WritePolicyConfig
+}
+
+// LoadCacheConfig loads a cache config from the provided TOML or JSON file, filling the defaults
+func LoadCacheConfig(filePath string) (common.CacheConfig, error) {
+	cacheConfig := common.CacheConfig{}
+	err := loadFile(filePath, &cacheConfig)
+	if err != nil {
+		return common.CacheConfig{}, err
+	}
+
+	normalizeCacheTypes(&cacheConfig)
+	cacheConfig.ApplyDefaults()
+	err = cacheConfig.Validate()
+	if err != nil {
+		return common.CacheConfig{}, fmt.Errorf("%w in file %s", err, filePath)
+	}
+
+	return cacheConfig, nil
+}
+
+// LoadArgDB loads a persister config from the provided TOML or JSON file, filling the defaults
+func LoadArgDB(filePath string) (factory.ArgDB, error) {
+	argDB := factory.ArgDB{}
+	err := loadFile(filePath, &argDB)
+	if err != nil {
+		return factory.ArgDB{}, err
+	}
+
+	normalizeDBTypes(&argDB)
+	argDB.ApplyDefaults()
+	err = argDB.Validate()
+	if err != nil {
+		return factory.ArgDB{}, fmt.Errorf("%w in file %s", err, filePath)
+	}
+
+	return argDB, nil
+}
+
+// LoadStorageUnitConfig loads a storage unit config from the provided TOML or JSON file, filling the defaults
+func LoadStorageUnitConfig(filePath string) (StorageUnitConfig, error) {
+	storageUnitConfig := StorageUnitConfig{}
+	err := loadFile(filePath, &storageUnitConfig)
+	if err != nil {
+		return StorageUnitConfig{}, err
+	}
+
+	return finalizeStorageUnitConfig(storageUnitConfig, filePath)
+}
+
+// LoadStorageUnitConfigWithEnvOverrides loads a storage unit config from the provided TOML or JSON file, then applies
+// the environment variables overrides of the provided unit, before filling the defaults
+func LoadStorageUnitConfigWithEnvOverrides(filePath string, unitName string) (StorageUnitConfig, error) {
+	storageUnitConfig := StorageUnitConfig{}
+	err := loadFile(filePath, &storageUnitConfig)
+	if err != nil {
+		return StorageUnitConfig{}, err
+	}
+
+	err = ApplyEnvOverrides(unitName, &storageUnitConfig)
+	if err != nil {
+		return StorageUnitConfig{}, err
+	}
+
+	return finalizeStorageUnitConfig(storageUnitConfig, filePath)
+}
+
+func finalizeStorageUnitConfig(storageUnitConfig StorageUnitConfig, filePath string) (StorageUnitConfig, error) {
+	normalizeCacheTypes(&storageUnitConfig.Cache)
+	normalizeDBTypes(&storageUnitConfig.DB)
+	storageUnitConfig.Cache.ApplyDefaults()
+	storageUnitConfig.WritePolicy.ApplyDefaults()
+	isMaxBatchSizeMissing := storageUnitConfig.DB.MaxBatchSize == 0
+	storageUnitConfig.DB.ApplyDefaults()
+	if isMaxBatchSizeMissing && storageUnitConfig.DB.MaxBatchSize > int(storageUnitConfig.Cache.Capacity) {
+		storageUnitConfig.DB.MaxBatchSize = int(storageUnitConfig.Cache.Capacity)
+	}
+
+	err := storageUnitConfig.Validate()
+	if err != nil {
+		return StorageUnitConfig{}, fmt.Errorf("%w in file %s", err, filePath)
+	}
+
+	return storageUnitConfig, nil
+}
+
+// Validate checks all the elements of the cache, db and write policy configs, returning an aggregated error which names
+// every invalid element
+func (config *StorageUnitConfig) Validate() error {
+	validator := common.ConfigValidator{}
+	validator.AddNested(config.Cache.Validate(), "for the cache")
+	validator.AddNested(config.DB.Validate(), "for the db")
+	validator.AddNested(config.WritePolicy.Validate(), "for the write policy")
+
+	return validator.Err()
+}
+
+// normalizeCacheTypes replaces the cache types written in another case with the supported ones, e.g. "lru" with "LRU".
+// The unknown types are left for Validate to report
+func normalizeCacheTypes(cacheConfig *common.CacheConfig) {
+	normalize := func(cacheType *common.CacheType) {
+		parsed, err := common.ParseCacheType(string(*cacheType))
+		if err == nil {
+			*cacheType = parsed
+		}
+	}
+
+	normalize(&cacheConfig.Type)
+	normalize(&cacheConfig.L2Type)
+}
+
+// normalizeDBTypes replaces the persister types written in another case with the supported ones, e.g. "memorydb" with
+// "MemoryDB". The unknown types are left for Validate to report
+func normalizeDBTypes(argDB *factory.ArgDB) {
+	normalize := func(dbType *common.DBType) {
+		parsed, err := common.ParseDBType(string(*dbType))
+		if err == nil {
+			*dbType = parsed
+		}
+	}
+
+	normalize(&argDB.DBType)
+	normalize(&argDB.Sharded.BaseDBType)
+}
+
+// loadFile decodes the file into the provided object, based on the file extension. The unknown fields are rejected,
+// so that the misspelled options do not go unnoticed
+func loadFile(filePath string, dest interface{}) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	extension := strings.ToLower(filepath.Ext(filePath))
+	switch extension {
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(content)).Strict(true).Decode(dest)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(dest)
+	default:
+		return fmt.Errorf("%w: %s", common.ErrUnsupportedConfigFormat, extension)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s, file %s", common.ErrInvalidConfig, err.Error(), filePath)
+	}
+
+	return nil
+}
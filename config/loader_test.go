@@ -0,0 +1,260 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, fileName string, content string) string {
+	filePath := filepath.Join(t.TempDir(), fileName)
+	err := os.WriteFile(filePath, []byte(content), 0600)
+	require.Nil(t, err)
+
+	return filePath
+}
+
+func TestLoadCacheConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing file should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadCacheConfig(filepath.Join(t.TempDir(), "missing.toml"))
+		assert.NotNil(t, err)
+	})
+	t.Run("unsupported format should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadCacheConfig(writeFile(t, "cache.yaml", "Type: LRU"))
+		assert.True(t, errors.Is(err, common.ErrUnsupportedConfigFormat))
+	})
+	t.Run("unknown field should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadCacheConfig(writeFile(t, "cache.toml", "Type = \"LRU\"\nCapacity = 10\nCapacty = 10\n"))
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+		_, err = LoadCacheConfig(writeFile(t, "cache.json", `{"Type": "LRU", "Capacity": 10, "Capacty": 10}`))
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("unknown cache type should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadCacheConfig(writeFile(t, "cache.toml", "Type = \"NotLRU\"\nCapacity = 10\n"))
+		assert.True(t, errors.Is(err, common.ErrNotSupportedCacheType))
+		assert.Contains(t, err.Error(), "supported types: LRU")
+	})
+	t.Run("cache types in another case should be normalized", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConfig, err := LoadCacheConfig(writeFile(t, "cache.toml", "Type = \"twolevel\"\nL1Capacity = 2\nL2Type = \"lru\"\nCapacity = 10\n"))
+		require.Nil(t, err)
+		assert.Equal(t, common.TwoLevelCache, cacheConfig.Type)
+		assert.Equal(t, common.LRUCache, cacheConfig.L2Type)
+	})
+	t.Run("missing capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadCacheConfig(writeFile(t, "cache.toml", "Type = \"LRU\"\n"))
+		assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+		assert.Contains(t, err.Error(), "Capacity")
+	})
+	t.Run("toml should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConfig, err := LoadCacheConfig(writeFile(t, "cache.toml", "Name = \"txs\"\nType = \"FIFOSharded\"\nCapacity = 10\n"))
+		require.Nil(t, err)
+		assert.Equal(t, common.CacheConfig{
+			Name:     "txs",
+			Type:     common.FIFOShardedCache,
+			Capacity: 10,
+			Shards:   common.DefaultNumShards,
+		}, cacheConfig)
+	})
+	t.Run("json should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConfig, err := LoadCacheConfig(writeFile(t, "cache.json", `{"Type": "SizeLRU", "Capacity": 10, "SizeInBytes": 2048}`))
+		require.Nil(t, err)
+		assert.Equal(t, common.CacheConfig{
+			Type:        common.SizeLRUCache,
+			Capacity:    10,
+			SizeInBytes: 2048,
+		}, cacheConfig)
+	})
+}
+
+func TestLoadArgDB(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown db type should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadArgDB(writeFile(t, "db.toml", "DBType = \"NotLvlDB\"\n"))
+		assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+		assert.Contains(t, err.Error(), "supported types: [LvlDB")
+	})
+	t.Run("db types in another case should be normalized", func(t *testing.T) {
+		t.Parallel()
+
+		argDB, err := LoadArgDB(writeFile(t, "db.json", `{"DBType": "memorydb"}`))
+		require.Nil(t, err)
+		assert.Equal(t, common.MemoryDB, argDB.DBType)
+	})
+	t.Run("missing path should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadArgDB(writeFile(t, "db.toml", "DBType = \"LvlDB\"\n"))
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "Path")
+	})
+	t.Run("negative values should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadArgDB(writeFile(t, "db.toml", "DBType = \"LvlDB\"\nPath = \"db\"\nMaxOpenFiles = -1\n"))
+		assert.True(t, errors.Is(err, common.ErrInvalidNumOpenFiles))
+	})
+	t.Run("memory db should not be defaulted", func(t *testing.T) {
+		t.Parallel()
+
+		argDB, err := LoadArgDB(writeFile(t, "db.json", `{"DBType": "MemoryDB"}`))
+		require.Nil(t, err)
+		assert.Equal(t, factory.ArgDB{DBType: common.MemoryDB}, argDB)
+	})
+	t.Run("leveldb should be defaulted", func(t *testing.T) {
+		t.Parallel()
+
+		argDB, err := LoadArgDB(writeFile(t, "db.toml", "DBType = \"LvlDBSerial\"\nPath = \"db\"\nMaxOpenFiles = 20\n"))
+		require.Nil(t, err)
+		assert.Equal(t, factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			Path:              "db",
+			BatchDelaySeconds: factory.DefaultBatchDelaySeconds,
+			MaxBatchSize:      factory.DefaultMaxBatchSize,
+			MaxOpenFiles:      20,
+		}, argDB)
+	})
+}
+
+func TestLoadStorageUnitConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		content := `
+[Cache]
+	Type = "LRU"
+
+[DB]
+	DBType = "MemoryDB"
+`
+		_, err := LoadStorageUnitConfig(writeFile(t, "unit.toml", content))
+		assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+		assert.Contains(t, err.Error(), "cache")
+	})
+	t.Run("invalid db should error", func(t *testing.T) {
+		t.Parallel()
+
+		content := `
+[Cache]
+	Type = "LRU"
+	Capacity = 10
+
+[DB]
+	DBType = "LvlDB"
+`
+		_, err := LoadStorageUnitConfig(writeFile(t, "unit.toml", content))
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "db")
+	})
+	t.Run("default batch size should be limited by the cache capacity", func(t *testing.T) {
+		t.Parallel()
+
+		content := `
+[Cache]
+	Type = "LRU"
+	Capacity = 10
+
+[DB]
+	DBType = "LvlDB"
+	Path = "db"
+`
+		storageUnitConfig, err := LoadStorageUnitConfig(writeFile(t, "unit.toml", content))
+		require.Nil(t, err)
+		assert.Equal(t, 10, storageUnitConfig.DB.MaxBatchSize)
+	})
+	t.Run("should map onto the storage unit factory", func(t *testing.T) {
+		t.Parallel()
+
+		content := `{
+	"Cache": {"Type": "LRU", "Capacity": 10},
+	"DB": {"DBType": "MemoryDB"}
+}`
+		storageUnitConfig, err := LoadStorageUnitConfig(writeFile(t, "unit.json", content))
+		require.Nil(t, err)
+
+		unit, err := factory.NewStorageUnit(storageUnitConfig.Cache, storageUnitConfig.DB)
+		require.Nil(t, err)
+		assert.Nil(t, unit.Put([]byte("key"), []byte("value")))
+		assert.Nil(t, unit.Close())
+	})
+	t.Run("should load the write policy", func(t *testing.T) {
+		t.Parallel()
+
+		content := `
+[Cache]
+	Type = "LRU"
+	Capacity = 10
+
+[DB]
+	DBType = "MemoryDB"
+
+[WritePolicy]
+	Policy = "WriteBack"
+	MaxNumDirty = 100
+`
+		storageUnitConfig, err := LoadStorageUnitConfig(writeFile(t, "unit.toml", content))
+		require.Nil(t, err)
+		assert.Equal(t, common.WritePolicyConfig{
+			Policy:                 common.WriteBackPolicy,
+			FlushIntervalInSeconds: common.DefaultWriteBackFlushIntervalInSeconds,
+			MaxNumDirty:            100,
+		}, storageUnitConfig.WritePolicy)
+
+		content = `{
+	"Cache": {"Type": "LRU", "Capacity": 10},
+	"DB": {"DBType": "MemoryDB"},
+	"WritePolicy": {"Policy": "CacheBypass"}
+}`
+		_, err = LoadStorageUnitConfig(writeFile(t, "unit.json", content))
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "for the write policy")
+	})
+}
+
+func TestStorageUnitConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	storageUnitConfig := StorageUnitConfig{
+		Cache: common.CacheConfig{Type: common.LRUCache},
+		DB:    factory.ArgDB{DBType: common.LvlDB, Path: "db", MaxOpenFiles: 10},
+	}
+	err := storageUnitConfig.Validate()
+	assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "Capacity should be positive for the cache")
+	assert.Contains(t, err.Error(), "BatchDelaySeconds should be positive for the db")
+	assert.Contains(t, err.Error(), "MaxBatchSize should be positive for the db")
+
+	storageUnitConfig.Cache.Capacity = 10
+	storageUnitConfig.DB.ApplyDefaults()
+	assert.Nil(t, storageUnitConfig.Validate())
+}
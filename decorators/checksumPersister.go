@@ -0,0 +1,60 @@
+package decorators
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*ChecksumPersister)(nil)
+var _ types.MultiPutter = (*ChecksumPersister)(nil)
+var _ types.CapabilitiesProvider = (*ChecksumPersister)(nil)
+var _ types.HealthChecker = (*ChecksumPersister)(nil)
+
+const checksumLength = 4
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumPersister prepends a CRC-32C checksum to the stored values, detecting their corruption when read
+type ChecksumPersister struct {
+	*transformingPersister
+}
+
+// NewChecksumPersister wraps the provided persister in a ChecksumPersister
+func NewChecksumPersister(persister types.Persister) (*ChecksumPersister, error) {
+	tp, err := newTransformingPersister("checksum", persister, &checksumCodec{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChecksumPersister{
+		transformingPersister: tp,
+	}, nil
+}
+
+type checksumCodec struct{}
+
+func (codec *checksumCodec) encode(value []byte) ([]byte, error) {
+	encoded := make([]byte, checksumLength, checksumLength+len(value))
+	binary.BigEndian.PutUint32(encoded, crc32.Checksum(value, crcTable))
+
+	return append(encoded, value...), nil
+}
+
+func (codec *checksumCodec) decode(value []byte) ([]byte, error) {
+	if len(value) < checksumLength {
+		return nil, fmt.Errorf("%w: %d bytes, missing the checksum", common.ErrInvalidValueLength, len(value))
+	}
+
+	payload := value[checksumLength:]
+	if binary.BigEndian.Uint32(value) != crc32.Checksum(payload, crcTable) {
+		monitoring.PublishEvent(monitoring.NewCorruptionDetectedEvent("", common.ErrChecksumMismatch, false))
+		return nil, common.ErrChecksumMismatch
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,81 @@
+package decorators_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChecksumPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewChecksumPersister(nil)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	persister, err = decorators.NewChecksumPersister(memorydb.New())
+	assert.Nil(t, err)
+	assert.False(t, persister.IsInterfaceNil())
+}
+
+func TestChecksumPersister_ShouldDetectTheCorruptedValues(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	persister, _ := decorators.NewChecksumPersister(db)
+
+	require.Nil(t, persister.Put([]byte("key"), []byte("value")))
+	require.Nil(t, persister.MultiPut(map[string][]byte{"key1": []byte("value1"), "key2": {}}))
+
+	stored, _ := db.Get([]byte("key"))
+	assert.Equal(t, 4+len("value"), len(stored))
+	val, err := persister.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	val, err = persister.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Empty(t, val)
+
+	stored[len(stored)-1] ^= 0xFF
+	_ = db.Put([]byte("key"), stored)
+	_ = db.Put([]byte("short"), []byte{1, 2})
+
+	chCorruptions := make(chan monitoring.Event, 10)
+	monitoring.GlobalEventBus().Subscribe(t.Name(), func(event monitoring.Event) {
+		if event.Type() == monitoring.CorruptionDetectedEventType {
+			chCorruptions <- event
+		}
+	})
+	defer monitoring.GlobalEventBus().Unsubscribe(t.Name())
+
+	val, err = persister.Get([]byte("key"))
+	assert.Nil(t, val)
+	assert.True(t, errors.Is(err, common.ErrChecksumMismatch))
+	select {
+	case event := <-chCorruptions:
+		assert.Equal(t, common.ErrChecksumMismatch, event.(*monitoring.CorruptionDetectedEvent).Err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the corruption should have been published")
+	}
+	_, err = persister.Get([]byte("short"))
+	assert.True(t, errors.Is(err, common.ErrInvalidValueLength))
+
+	values := make(map[string]string)
+	persister.RangeKeys(func(key []byte, val []byte) bool {
+		values[string(key)] = string(val)
+		return true
+	})
+	assert.Equal(t, map[string]string{"key1": "value1", "key2": ""}, values)
+
+	_, err = persister.Get([]byte("missing"))
+	assert.NotNil(t, err)
+	assert.Nil(t, persister.Remove([]byte("key1")))
+	assert.NotNil(t, persister.Has([]byte("key1")))
+}
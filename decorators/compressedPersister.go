@@ -0,0 +1,38 @@
+package decorators
+
+import (
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/golang/snappy"
+)
+
+var _ types.Persister = (*CompressedPersister)(nil)
+var _ types.MultiPutter = (*CompressedPersister)(nil)
+var _ types.CapabilitiesProvider = (*CompressedPersister)(nil)
+var _ types.HealthChecker = (*CompressedPersister)(nil)
+
+// CompressedPersister stores the values compressed with snappy
+type CompressedPersister struct {
+	*transformingPersister
+}
+
+// NewCompressedPersister wraps the provided persister in a CompressedPersister
+func NewCompressedPersister(persister types.Persister) (*CompressedPersister, error) {
+	tp, err := newTransformingPersister("compression", persister, &snappyCodec{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompressedPersister{
+		transformingPersister: tp,
+	}, nil
+}
+
+type snappyCodec struct{}
+
+func (codec *snappyCodec) encode(value []byte) ([]byte, error) {
+	return snappy.Encode(nil, value), nil
+}
+
+func (codec *snappyCodec) decode(value []byte) ([]byte, error) {
+	return snappy.Decode(nil, value)
+}
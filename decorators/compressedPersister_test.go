@@ -0,0 +1,42 @@
+package decorators_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCompressedPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewCompressedPersister(nil)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+}
+
+func TestCompressedPersister_ShouldStoreTheCompressedValues(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	persister, err := decorators.NewCompressedPersister(db)
+	require.Nil(t, err)
+
+	value := bytes.Repeat([]byte("value"), 100)
+	require.Nil(t, persister.Put([]byte("key"), value))
+
+	stored, _ := db.Get([]byte("key"))
+	assert.Less(t, len(stored), len(value))
+	val, err := persister.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, value, val)
+
+	_ = db.Put([]byte("key"), []byte("not compressed"))
+	_, err = persister.Get([]byte("key"))
+	assert.NotNil(t, err)
+}
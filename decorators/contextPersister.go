@@ -0,0 +1,156 @@
+package decorators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.PersisterWithContext = (*ContextPersister)(nil)
+var _ types.MultiPutter = (*ContextPersister)(nil)
+var _ types.CapabilitiesProvider = (*ContextPersister)(nil)
+var _ types.HealthChecker = (*ContextPersister)(nil)
+
+// ContextPersister adapts a plain persister to types.PersisterWithContext: the context aware operations fail with the
+// error of the provided context once it is done, otherwise they call the wrapped persister, which is not interrupted
+// once called
+type ContextPersister struct {
+	persister types.Persister
+}
+
+// NewContextPersister wraps the provided persister in a ContextPersister
+func NewContextPersister(persister types.Persister) (*ContextPersister, error) {
+	if check.IfNil(persister) {
+		return nil, fmt.Errorf("%w for the context adapter", common.ErrNilPersister)
+	}
+
+	return &ContextPersister{
+		persister: persister,
+	}, nil
+}
+
+// AsPersisterWithContext returns the provided persister if it honors the contexts natively, otherwise it wraps it in a
+// ContextPersister
+func AsPersisterWithContext(persister types.Persister) (types.PersisterWithContext, error) {
+	persisterWithContext, ok := persister.(types.PersisterWithContext)
+	if ok && !check.IfNil(persisterWithContext) {
+		return persisterWithContext, nil
+	}
+
+	return NewContextPersister(persister)
+}
+
+// PutCtx adds the value to the wrapped persister, unless the context is done
+func (cp *ContextPersister) PutCtx(ctx context.Context, key, val []byte) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.persister.Put(key, val)
+}
+
+// Put adds the value to the wrapped persister
+func (cp *ContextPersister) Put(key, val []byte) error {
+	return cp.persister.Put(key, val)
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (cp *ContextPersister) MultiPut(data map[string][]byte) error {
+	multiPutter, ok := cp.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	for key, val := range data {
+		err := cp.persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCtx gets the value associated to the key from the wrapped persister, unless the context is done
+func (cp *ContextPersister) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.persister.Get(key)
+}
+
+// Get gets the value associated to the key from the wrapped persister
+func (cp *ContextPersister) Get(key []byte) ([]byte, error) {
+	return cp.persister.Get(key)
+}
+
+// HasCtx returns nil if the given key is present in the wrapped persister, unless the context is done
+func (cp *ContextPersister) HasCtx(ctx context.Context, key []byte) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.persister.Has(key)
+}
+
+// Has returns nil if the given key is present in the wrapped persister
+func (cp *ContextPersister) Has(key []byte) error {
+	return cp.persister.Has(key)
+}
+
+// RemoveCtx removes the data associated to the given key from the wrapped persister, unless the context is done
+func (cp *ContextPersister) RemoveCtx(ctx context.Context, key []byte) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.persister.Remove(key)
+}
+
+// Remove removes the data associated to the given key from the wrapped persister
+func (cp *ContextPersister) Remove(key []byte) error {
+	return cp.persister.Remove(key)
+}
+
+// Close closes the wrapped persister
+func (cp *ContextPersister) Close() error {
+	return cp.persister.Close()
+}
+
+// Destroy destroys the wrapped persister
+func (cp *ContextPersister) Destroy() error {
+	return cp.persister.Destroy()
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (cp *ContextPersister) DestroyClosed() error {
+	return cp.persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the wrapped persister
+func (cp *ContextPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	cp.persister.RangeKeys(handler)
+}
+
+// Capabilities returns the durability of the wrapped persister, the snapshots and the iteration not being forwarded
+func (cp *ContextPersister) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(cp.persister)
+}
+
+// Health returns the health of the wrapped persister
+func (cp *ContextPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(cp.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cp *ContextPersister) IsInterfaceNil() bool {
+	return cp == nil
+}
@@ -0,0 +1,57 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsPersisterWithContext(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.AsPersisterWithContext(nil)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	persister, err = decorators.AsPersisterWithContext(memorydb.New())
+	assert.Nil(t, err)
+	assert.IsType(t, &decorators.ContextPersister{}, persister)
+
+	tracingPersister, _ := decorators.NewTracingPersister(memorydb.New(), "db")
+	persister, err = decorators.AsPersisterWithContext(tracingPersister)
+	assert.Nil(t, err)
+	assert.True(t, persister == tracingPersister)
+}
+
+func TestContextPersister_ShouldHonorTheContext(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	persister, err := decorators.NewContextPersister(mdb)
+	require.Nil(t, err)
+
+	ctx := context.Background()
+	require.Nil(t, persister.PutCtx(ctx, []byte("key"), []byte("value")))
+	val, err := persister.GetCtx(ctx, []byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.Nil(t, persister.HasCtx(ctx, []byte("key")))
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, persister.PutCtx(cancelledCtx, []byte("other"), []byte("value")))
+	assert.Equal(t, context.Canceled, persister.RemoveCtx(cancelledCtx, []byte("key")))
+	_, err = persister.GetCtx(cancelledCtx, []byte("key"))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, persister.HasCtx(cancelledCtx, []byte("key")))
+	assert.Equal(t, 1, mdb.Len())
+
+	require.Nil(t, persister.RemoveCtx(ctx, []byte("key")))
+	assert.Equal(t, 0, mdb.Len())
+}
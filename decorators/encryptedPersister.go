@@ -0,0 +1,66 @@
+package decorators
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*EncryptedPersister)(nil)
+var _ types.MultiPutter = (*EncryptedPersister)(nil)
+var _ types.CapabilitiesProvider = (*EncryptedPersister)(nil)
+var _ types.HealthChecker = (*EncryptedPersister)(nil)
+
+// EncryptedPersister stores the values encrypted with AES-GCM, each value having its own random nonce
+type EncryptedPersister struct {
+	*transformingPersister
+}
+
+// NewEncryptedPersister wraps the provided persister in an EncryptedPersister. The key should have 16, 24 or 32 bytes,
+// selecting AES-128, AES-192 or AES-256
+func NewEncryptedPersister(persister types.Persister, key []byte) (*EncryptedPersister, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", common.ErrInvalidEncryptionKey, err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	tp, err := newTransformingPersister("encryption", persister, &aesCodec{aead: aead})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedPersister{
+		transformingPersister: tp,
+	}, nil
+}
+
+type aesCodec struct {
+	aead cipher.AEAD
+}
+
+func (codec *aesCodec) encode(value []byte) ([]byte, error) {
+	nonce := make([]byte, codec.aead.NonceSize(), codec.aead.NonceSize()+len(value)+codec.aead.Overhead())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.aead.Seal(nonce, nonce, value, nil), nil
+}
+
+func (codec *aesCodec) decode(value []byte) ([]byte, error) {
+	nonceSize := codec.aead.NonceSize()
+	if len(value) < nonceSize {
+		return nil, fmt.Errorf("%w: %d bytes, missing the nonce", common.ErrInvalidValueLength, len(value))
+	}
+
+	return codec.aead.Open(nil, value[:nonceSize], value[nonceSize:], nil)
+}
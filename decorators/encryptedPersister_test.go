@@ -0,0 +1,50 @@
+package decorators_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEncryptedPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewEncryptedPersister(memorydb.New(), []byte("short key"))
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrInvalidEncryptionKey))
+
+	persister, err = decorators.NewEncryptedPersister(nil, make([]byte, 32))
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+}
+
+func TestEncryptedPersister_ShouldStoreTheEncryptedValues(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	key := bytes.Repeat([]byte{1}, 16)
+	persister, err := decorators.NewEncryptedPersister(db, key)
+	require.Nil(t, err)
+
+	require.Nil(t, persister.Put([]byte("key1"), []byte("value")))
+	require.Nil(t, persister.Put([]byte("key2"), []byte("value")))
+
+	stored1, _ := db.Get([]byte("key1"))
+	stored2, _ := db.Get([]byte("key2"))
+	assert.False(t, bytes.Contains(stored1, []byte("value")))
+	assert.NotEqual(t, stored1, stored2)
+
+	val, err := persister.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+
+	otherPersister, _ := decorators.NewEncryptedPersister(db, bytes.Repeat([]byte{2}, 16))
+	_, err = otherPersister.Get([]byte("key1"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,344 @@
+package decorators
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var _ types.Persister = (*ReopeningPersister)(nil)
+var _ types.MultiPutter = (*ReopeningPersister)(nil)
+var _ types.CapabilitiesProvider = (*ReopeningPersister)(nil)
+var _ types.HealthChecker = (*ReopeningPersister)(nil)
+var _ types.StatsProvider = (*ReopeningPersister)(nil)
+
+// ArgsReopeningPersister holds the arguments needed to create a ReopeningPersister
+type ArgsReopeningPersister struct {
+	Persister types.Persister
+	// Reopen opens again the persister, at the same path
+	Reopen func() (types.Persister, error)
+	// MaxAttempts is the number of reopen attempts, after each closed persister detected
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed reopen attempt, doubled after each one, up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxInFlightWrites is the number of the latest writes replayed on the reopened persister, as the batched
+	// persisters may have lost them when closed
+	MaxInFlightWrites int
+}
+
+func checkArgsReopeningPersister(args ArgsReopeningPersister) error {
+	if check.IfNil(args.Persister) {
+		return fmt.Errorf("%w for the reopening decorator", common.ErrNilPersister)
+	}
+
+	var validator common.ConfigValidator
+	validator.Check(args.Reopen != nil, common.ErrInvalidConfig, "Reopen should not be nil")
+	validator.Check(args.MaxAttempts > 0, common.ErrInvalidConfig, "MaxAttempts should be positive")
+	validator.Check(args.InitialBackoff >= 0, common.ErrInvalidConfig, "InitialBackoff should not be negative")
+	validator.Check(args.MaxBackoff >= args.InitialBackoff, common.ErrInvalidConfig, "MaxBackoff should not be lower than InitialBackoff")
+	validator.Check(args.MaxInFlightWrites >= 0, common.ErrInvalidConfig, "MaxInFlightWrites should not be negative")
+
+	return validator.Err()
+}
+
+type inFlightWrite struct {
+	key     []byte
+	val     []byte
+	removed bool
+}
+
+// ReopeningPersister reopens the wrapped persister when an operation finds it closed, which happens when it was closed
+// by a component racing with the ones still using it. The reopen is attempted with a backoff, then the latest writes
+// are replayed on the reopened persister and the operation is attempted again. The persister closed by Close is not
+// reopened
+type ReopeningPersister struct {
+	mut            sync.RWMutex
+	persister      types.Persister
+	generation     uint64
+	reopen         func() (types.Persister, error)
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mutInFlight       sync.Mutex
+	inFlight          []inFlightWrite
+	maxInFlightWrites int
+
+	isClosed         atomic.Bool
+	numReopens       atomic.Uint64
+	numFailedReopens atomic.Uint64
+}
+
+// NewReopeningPersister wraps the provided persister in a ReopeningPersister
+func NewReopeningPersister(args ArgsReopeningPersister) (*ReopeningPersister, error) {
+	err := checkArgsReopeningPersister(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReopeningPersister{
+		persister:         args.Persister,
+		reopen:            args.Reopen,
+		maxAttempts:       args.MaxAttempts,
+		initialBackoff:    args.InitialBackoff,
+		maxBackoff:        args.MaxBackoff,
+		inFlight:          make([]inFlightWrite, 0, args.MaxInFlightWrites),
+		maxInFlightWrites: args.MaxInFlightWrites,
+	}, nil
+}
+
+// isClosedError returns true for the errors of a closed persister, including the ones of the leveldb library
+func isClosedError(err error) bool {
+	return common.IsClosed(err) || errors.Is(err, leveldb.ErrClosed)
+}
+
+func (rp *ReopeningPersister) current() (types.Persister, uint64) {
+	rp.mut.RLock()
+	defer rp.mut.RUnlock()
+
+	return rp.persister, rp.generation
+}
+
+// do calls the operation on the wrapped persister, reopening it and calling the operation again if it was found closed
+func (rp *ReopeningPersister) do(operation string, handler func(persister types.Persister) error) error {
+	persister, generation := rp.current()
+	err := handler(persister)
+	if !isClosedError(err) || rp.isClosed.Load() {
+		return err
+	}
+
+	errReopen := rp.reopenIfNotReopened(operation, generation)
+	if errReopen != nil {
+		return fmt.Errorf("%w, reopen failed: %v", err, errReopen)
+	}
+
+	persister, _ = rp.current()
+	return handler(persister)
+}
+
+// reopenIfNotReopened reopens the persister, unless another operation already reopened it after it was found closed
+func (rp *ReopeningPersister) reopenIfNotReopened(operation string, generation uint64) error {
+	rp.mut.Lock()
+	defer rp.mut.Unlock()
+
+	if rp.generation != generation {
+		return nil
+	}
+
+	var err error
+	backoff := rp.initialBackoff
+	for attempt := 1; attempt <= rp.maxAttempts; attempt++ {
+		if rp.isClosed.Load() {
+			return common.ErrDBIsClosed
+		}
+
+		var reopened types.Persister
+		reopened, err = rp.reopen()
+		if err == nil {
+			err = rp.replayInFlightWrites(reopened)
+			if err == nil {
+				rp.persister = reopened
+				rp.generation++
+				rp.numReopens.Add(1)
+				log.Warn("ReopeningPersister: the closed persister was reopened", "operation", operation,
+					"attempt", attempt)
+				return nil
+			}
+			_ = reopened.Close()
+		}
+
+		rp.numFailedReopens.Add(1)
+		log.Warn("ReopeningPersister: reopen failed", "operation", operation, "attempt", attempt, "error", err)
+		if attempt < rp.maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > rp.maxBackoff {
+				backoff = rp.maxBackoff
+			}
+		}
+	}
+
+	return err
+}
+
+func (rp *ReopeningPersister) replayInFlightWrites(persister types.Persister) error {
+	rp.mutInFlight.Lock()
+	defer rp.mutInFlight.Unlock()
+
+	for _, write := range rp.inFlight {
+		var err error
+		if write.removed {
+			err = persister.Remove(write.key)
+		} else {
+			err = persister.Put(write.key, write.val)
+		}
+		if err != nil {
+			return fmt.Errorf("%w while replaying the in flight writes", err)
+		}
+	}
+
+	return nil
+}
+
+// addInFlightWrites keeps the latest maxInFlightWrites writes, oldest first
+func (rp *ReopeningPersister) addInFlightWrites(writes ...inFlightWrite) {
+	if rp.maxInFlightWrites == 0 {
+		return
+	}
+
+	rp.mutInFlight.Lock()
+	defer rp.mutInFlight.Unlock()
+
+	rp.inFlight = append(rp.inFlight, writes...)
+	numExceeding := len(rp.inFlight) - rp.maxInFlightWrites
+	if numExceeding > 0 {
+		rp.inFlight = append(rp.inFlight[:0], rp.inFlight[numExceeding:]...)
+	}
+}
+
+// Put adds the value to the wrapped persister
+func (rp *ReopeningPersister) Put(key, val []byte) error {
+	err := rp.do("Put", func(persister types.Persister) error {
+		return persister.Put(key, val)
+	})
+	if err == nil {
+		rp.addInFlightWrites(inFlightWrite{key: key, val: val})
+	}
+
+	return err
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (rp *ReopeningPersister) MultiPut(data map[string][]byte) error {
+	err := rp.do("MultiPut", func(persister types.Persister) error {
+		multiPutter, ok := persister.(types.MultiPutter)
+		if ok {
+			return multiPutter.MultiPut(data)
+		}
+
+		for key, val := range data {
+			errPut := persister.Put([]byte(key), val)
+			if errPut != nil {
+				return errPut
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writes := make([]inFlightWrite, 0, len(data))
+	for key, val := range data {
+		writes = append(writes, inFlightWrite{key: []byte(key), val: val})
+	}
+	rp.addInFlightWrites(writes...)
+
+	return nil
+}
+
+// Get gets the value associated to the key from the wrapped persister
+func (rp *ReopeningPersister) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := rp.do("Get", func(persister types.Persister) error {
+		var errGet error
+		val, errGet = persister.Get(key)
+		return errGet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the given key is present in the wrapped persister
+func (rp *ReopeningPersister) Has(key []byte) error {
+	return rp.do("Has", func(persister types.Persister) error {
+		return persister.Has(key)
+	})
+}
+
+// Remove removes the data associated to the given key from the wrapped persister
+func (rp *ReopeningPersister) Remove(key []byte) error {
+	err := rp.do("Remove", func(persister types.Persister) error {
+		return persister.Remove(key)
+	})
+	if err == nil {
+		rp.addInFlightWrites(inFlightWrite{key: key, removed: true})
+	}
+
+	return err
+}
+
+// Close closes the wrapped persister, which is not reopened afterwards
+func (rp *ReopeningPersister) Close() error {
+	rp.isClosed.Store(true)
+	persister, _ := rp.current()
+
+	return persister.Close()
+}
+
+// Destroy destroys the wrapped persister, which is not reopened afterwards
+func (rp *ReopeningPersister) Destroy() error {
+	rp.isClosed.Store(true)
+	persister, _ := rp.current()
+
+	return persister.Destroy()
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (rp *ReopeningPersister) DestroyClosed() error {
+	rp.isClosed.Store(true)
+	persister, _ := rp.current()
+
+	return persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the wrapped persister
+func (rp *ReopeningPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	persister, _ := rp.current()
+	persister.RangeKeys(handler)
+}
+
+// Capabilities returns the durability of the wrapped persister, the snapshots and the iteration not being forwarded
+func (rp *ReopeningPersister) Capabilities() types.PersisterCapabilities {
+	persister, _ := rp.current()
+	return types.ForwardedCapabilities(persister)
+}
+
+// Health returns the health of the wrapped persister
+func (rp *ReopeningPersister) Health() types.HealthStatus {
+	persister, _ := rp.current()
+	return types.ForwardedHealth(persister)
+}
+
+// Stats returns the number of the reopens, along with the stats of the wrapped persister, if any
+func (rp *ReopeningPersister) Stats() map[string]interface{} {
+	stats := make(map[string]interface{})
+	persister, _ := rp.current()
+	statsProvider, ok := persister.(types.StatsProvider)
+	if ok {
+		for key, value := range statsProvider.Stats() {
+			stats[key] = value
+		}
+	}
+	stats["numReopens"] = rp.numReopens.Load()
+	stats["numFailedReopens"] = rp.numFailedReopens.Load()
+
+	return stats
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rp *ReopeningPersister) IsInterfaceNil() bool {
+	return rp == nil
+}
@@ -0,0 +1,179 @@
+package decorators_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgsReopeningPersister(persister types.Persister, reopen func() (types.Persister, error)) decorators.ArgsReopeningPersister {
+	return decorators.ArgsReopeningPersister{
+		Persister:         persister,
+		Reopen:            reopen,
+		MaxAttempts:       3,
+		InitialBackoff:    0,
+		MaxBackoff:        0,
+		MaxInFlightWrites: 2,
+	}
+}
+
+// createClosablePersister returns a memorydb whose operations fail with ErrDBIsClosed once closed
+func createClosablePersister() (*testscommon.PersisterStub, *atomic.Bool) {
+	db := memorydb.New()
+	isClosed := &atomic.Bool{}
+	stub := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if isClosed.Load() {
+				return common.ErrDBIsClosed
+			}
+			return db.Put(key, val)
+		},
+		GetCalled: func(key []byte) ([]byte, error) {
+			if isClosed.Load() {
+				return nil, common.ErrDBIsClosed
+			}
+			return db.Get(key)
+		},
+		RemoveCalled: func(key []byte) error {
+			if isClosed.Load() {
+				return common.ErrDBIsClosed
+			}
+			return db.Remove(key)
+		},
+		CloseCalled: func() error {
+			isClosed.Store(true)
+			return nil
+		},
+	}
+
+	return stub, isClosed
+}
+
+func TestNewReopeningPersister(t *testing.T) {
+	t.Parallel()
+
+	reopen := func() (types.Persister, error) {
+		return memorydb.New(), nil
+	}
+
+	persister, err := decorators.NewReopeningPersister(createArgsReopeningPersister(nil, reopen))
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	args := createArgsReopeningPersister(memorydb.New(), nil)
+	args.MaxAttempts = 0
+	args.MaxInFlightWrites = -1
+	persister, err = decorators.NewReopeningPersister(args)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "Reopen should not be nil")
+	assert.Contains(t, err.Error(), "MaxAttempts should be positive")
+	assert.Contains(t, err.Error(), "MaxInFlightWrites should not be negative")
+
+	persister, err = decorators.NewReopeningPersister(createArgsReopeningPersister(memorydb.New(), reopen))
+	assert.Nil(t, err)
+	assert.False(t, persister.IsInterfaceNil())
+}
+
+func TestReopeningPersister_ShouldReopenAndReplayTheLatestWrites(t *testing.T) {
+	t.Parallel()
+
+	closable, isClosed := createClosablePersister()
+	// the reopened persister lost all the writes, as a batched persister closed before flushing
+	reopened := memorydb.New()
+	numReopens := 0
+	persister, _ := decorators.NewReopeningPersister(createArgsReopeningPersister(closable, func() (types.Persister, error) {
+		numReopens++
+		return reopened, nil
+	}))
+
+	require.Nil(t, persister.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, persister.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, persister.Put([]byte("key3"), []byte("value3")))
+	require.Nil(t, persister.Remove([]byte("key2")))
+
+	// closed by a racing component
+	_ = closable.Close()
+	assert.True(t, isClosed.Load())
+
+	val, err := persister.Get([]byte("key3"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value3"), val)
+	assert.Equal(t, 1, numReopens)
+
+	// only the latest 2 writes were replayed
+	assert.True(t, common.IsNotFound(reopened.Has([]byte("key1"))))
+	assert.True(t, common.IsNotFound(reopened.Has([]byte("key2"))))
+
+	require.Nil(t, persister.Put([]byte("key4"), []byte("value4")))
+	assert.Nil(t, reopened.Has([]byte("key4")))
+	assert.Equal(t, uint64(1), persister.Stats()["numReopens"])
+}
+
+func TestReopeningPersister_FailedReopenShouldError(t *testing.T) {
+	t.Parallel()
+
+	closable, _ := createClosablePersister()
+	errReopen := errors.New("reopen error")
+	numReopens := 0
+	persister, _ := decorators.NewReopeningPersister(createArgsReopeningPersister(closable, func() (types.Persister, error) {
+		numReopens++
+		return nil, errReopen
+	}))
+
+	_ = closable.Close()
+	err := persister.Put([]byte("key"), []byte("value"))
+	assert.True(t, common.IsClosed(err))
+	assert.Contains(t, err.Error(), errReopen.Error())
+	assert.Equal(t, 3, numReopens)
+	assert.Equal(t, uint64(3), persister.Stats()["numFailedReopens"])
+}
+
+func TestReopeningPersister_ClosedShouldNotReopen(t *testing.T) {
+	t.Parallel()
+
+	closable, _ := createClosablePersister()
+	numReopens := 0
+	persister, _ := decorators.NewReopeningPersister(createArgsReopeningPersister(closable, func() (types.Persister, error) {
+		numReopens++
+		return memorydb.New(), nil
+	}))
+
+	require.Nil(t, persister.Close())
+	err := persister.Put([]byte("key"), []byte("value"))
+	assert.True(t, common.IsClosed(err))
+	assert.Equal(t, 0, numReopens)
+}
+
+func TestReopeningPersister_ShouldReopenTheClosedLevelDB(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir()
+	open := func() (types.Persister, error) {
+		return leveldb.NewSerialDB(path, 1, 100, 10)
+	}
+	db, err := open()
+	require.Nil(t, err)
+	persister, _ := decorators.NewReopeningPersister(createArgsReopeningPersister(db, open))
+
+	for i := 0; i < 10; i++ {
+		require.Nil(t, persister.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+	}
+	require.Nil(t, db.Close())
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, persister.Has([]byte(fmt.Sprintf("key%d", i))))
+	}
+	assert.Equal(t, uint64(1), persister.Stats()["numReopens"])
+	require.Nil(t, persister.Close())
+}
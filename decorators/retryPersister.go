@@ -0,0 +1,156 @@
+package decorators
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*RetryPersister)(nil)
+var _ types.MultiPutter = (*RetryPersister)(nil)
+var _ types.CapabilitiesProvider = (*RetryPersister)(nil)
+var _ types.HealthChecker = (*RetryPersister)(nil)
+
+// RetryPersister retries the operations of the wrapped persister which failed with a transient error, waiting the
+// provided backoff between the attempts. The missing keys, the closed or read only persisters are not retried
+type RetryPersister struct {
+	persister   types.Persister
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewRetryPersister wraps the provided persister in a RetryPersister, each operation being attempted at most
+// maxAttempts times
+func NewRetryPersister(persister types.Persister, maxAttempts int, backoff time.Duration) (*RetryPersister, error) {
+	if check.IfNil(persister) {
+		return nil, fmt.Errorf("%w for the retry decorator", common.ErrNilPersister)
+	}
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("%w: max attempts %d", common.ErrInvalidConfig, maxAttempts)
+	}
+	if backoff < 0 {
+		return nil, fmt.Errorf("%w: backoff %v", common.ErrInvalidConfig, backoff)
+	}
+
+	return &RetryPersister{
+		persister:   persister,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}, nil
+}
+
+func (rp *RetryPersister) retry(operation string, handler func() error) error {
+	var err error
+	for attempt := 1; attempt <= rp.maxAttempts; attempt++ {
+		err = handler()
+		if err == nil || !isTransientError(err) {
+			return err
+		}
+		if attempt < rp.maxAttempts {
+			log.Debug("RetryPersister: retrying", "operation", operation, "attempt", attempt, "error", err)
+			time.Sleep(rp.backoff)
+		}
+	}
+
+	return err
+}
+
+func isTransientError(err error) bool {
+	return !errors.Is(err, common.ErrKeyNotFound) &&
+		!errors.Is(err, common.ErrDBIsClosed) &&
+		!errors.Is(err, common.ErrDBIsReadOnly)
+}
+
+// Put adds the value to the wrapped persister
+func (rp *RetryPersister) Put(key, val []byte) error {
+	return rp.retry("Put", func() error {
+		return rp.persister.Put(key, val)
+	})
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (rp *RetryPersister) MultiPut(data map[string][]byte) error {
+	multiPutter, ok := rp.persister.(types.MultiPutter)
+	if ok {
+		return rp.retry("MultiPut", func() error {
+			return multiPutter.MultiPut(data)
+		})
+	}
+
+	for key, val := range data {
+		err := rp.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key from the wrapped persister
+func (rp *RetryPersister) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := rp.retry("Get", func() error {
+		var errGet error
+		val, errGet = rp.persister.Get(key)
+		return errGet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the given key is present in the wrapped persister
+func (rp *RetryPersister) Has(key []byte) error {
+	return rp.retry("Has", func() error {
+		return rp.persister.Has(key)
+	})
+}
+
+// Close closes the wrapped persister
+func (rp *RetryPersister) Close() error {
+	return rp.persister.Close()
+}
+
+// Remove removes the data associated to the given key from the wrapped persister
+func (rp *RetryPersister) Remove(key []byte) error {
+	return rp.retry("Remove", func() error {
+		return rp.persister.Remove(key)
+	})
+}
+
+// Destroy destroys the wrapped persister
+func (rp *RetryPersister) Destroy() error {
+	return rp.persister.Destroy()
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (rp *RetryPersister) DestroyClosed() error {
+	return rp.persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the wrapped persister
+func (rp *RetryPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	rp.persister.RangeKeys(handler)
+}
+
+// Capabilities returns the durability of the wrapped persister, the snapshots and the iteration not being forwarded
+func (rp *RetryPersister) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(rp.persister)
+}
+
+// Health returns the health of the wrapped persister
+func (rp *RetryPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(rp.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rp *RetryPersister) IsInterfaceNil() bool {
+	return rp == nil
+}
@@ -0,0 +1,119 @@
+package decorators_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetryPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewRetryPersister(nil, 1, 0)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	persister, err = decorators.NewRetryPersister(&testscommon.PersisterStub{}, 0, 0)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	persister, err = decorators.NewRetryPersister(&testscommon.PersisterStub{}, 1, -1)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestRetryPersister_ShouldRetryTheTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	errTransient := errors.New("transient error")
+	numPuts := 0
+	numGets := 0
+	persister, _ := decorators.NewRetryPersister(&testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			numPuts++
+			if numPuts < 3 {
+				return errTransient
+			}
+			return nil
+		},
+		GetCalled: func(key []byte) ([]byte, error) {
+			numGets++
+			return nil, common.ErrKeyNotFound
+		},
+		HasCalled: func(key []byte) error {
+			return errTransient
+		},
+	}, 3, 0)
+
+	assert.Nil(t, persister.Put([]byte("key"), []byte("value")))
+	assert.Equal(t, 3, numPuts)
+
+	_, err := persister.Get([]byte("key"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.Equal(t, 1, numGets)
+
+	assert.Equal(t, errTransient, persister.Has([]byte("key")))
+
+	numPuts = 0
+	assert.Nil(t, persister.MultiPut(map[string][]byte{"key": []byte("value")}))
+	assert.Equal(t, 3, numPuts)
+}
+
+func TestRetryPersister_ShouldMaskTheInjectedFaults(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	faulty := testscommon.NewFaultInjectionPersister(mdb, testscommon.FaultConfig{
+		ErrorRates: map[testscommon.FaultOperation]float64{
+			testscommon.FaultPut: 0.3,
+			testscommon.FaultGet: 0.3,
+		},
+		Latencies: map[testscommon.FaultOperation]testscommon.LatencyDistribution{
+			testscommon.FaultGet: testscommon.UniformLatency(0, time.Microsecond),
+		},
+		Seed: 1,
+	})
+	persister, _ := decorators.NewRetryPersister(faulty, 20, 0)
+
+	numKeys := 100
+	for i := 0; i < numKeys; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		require.Nil(t, persister.Put(key, key))
+		val, err := persister.Get(key)
+		require.Nil(t, err)
+		require.Equal(t, key, val)
+	}
+	assert.Positive(t, faulty.NumInjectedFaults())
+	assert.Equal(t, numKeys, mdb.Len())
+
+	faulty.SetConfig(testscommon.FaultConfig{CloseAfterOps: 1})
+	assert.Nil(t, persister.Has([]byte("key0")))
+	err := persister.Has([]byte("key0"))
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+}
+
+func TestRetryPersister_ShouldRetryThePartiallyAppliedBatches(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	faulty := testscommon.NewFaultInjectionPersister(mdb, testscommon.FaultConfig{MultiPutFailAfter: 1})
+	persister, _ := decorators.NewRetryPersister(faulty, 2, 0)
+
+	data := map[string][]byte{"a": []byte("a"), "b": []byte("b")}
+	err := persister.MultiPut(data)
+	assert.True(t, errors.Is(err, testscommon.ErrInjectedFault))
+	assert.Equal(t, 1, mdb.Len())
+	assert.Nil(t, mdb.Has([]byte("a")))
+
+	faulty.SetConfig(testscommon.FaultConfig{})
+	assert.Nil(t, persister.MultiPut(data))
+	assert.Equal(t, 2, mdb.Len())
+}
@@ -0,0 +1,108 @@
+package decorators
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.SnapshotablePersister = (*NoSnapshotPersister)(nil)
+var _ types.MultiPutter = (*NoSnapshotPersister)(nil)
+var _ types.CapabilitiesProvider = (*NoSnapshotPersister)(nil)
+var _ types.HealthChecker = (*NoSnapshotPersister)(nil)
+var _ types.PersisterView = (*liveView)(nil)
+
+// NoSnapshotPersister adapts a persister without snapshots to types.SnapshotablePersister: its snapshots read the live
+// contents of the wrapped persister, so they are point in time views only as long as the writes are quiesced, as done
+// by the snapshot manager
+type NoSnapshotPersister struct {
+	types.Persister
+}
+
+// NewNoSnapshotPersister wraps the provided persister in a NoSnapshotPersister
+func NewNoSnapshotPersister(persister types.Persister) (*NoSnapshotPersister, error) {
+	if check.IfNil(persister) {
+		return nil, fmt.Errorf("%w for the snapshot adapter", common.ErrNilPersister)
+	}
+
+	return &NoSnapshotPersister{
+		Persister: persister,
+	}, nil
+}
+
+// AsSnapshotablePersister returns the provided persister if it supports the snapshots natively, otherwise it wraps it
+// in a NoSnapshotPersister
+func AsSnapshotablePersister(persister types.Persister) (types.SnapshotablePersister, error) {
+	snapshotable, ok := persister.(types.SnapshotablePersister)
+	if ok && !check.IfNil(snapshotable) {
+		return snapshotable, nil
+	}
+
+	return NewNoSnapshotPersister(persister)
+}
+
+// TakeSnapshot returns a view reading the live contents of the wrapped persister
+func (nsp *NoSnapshotPersister) TakeSnapshot() (types.PersisterView, error) {
+	return &liveView{persister: nsp.Persister}, nil
+}
+
+// ReleaseSnapshot does nothing, as the views hold no resources
+func (nsp *NoSnapshotPersister) ReleaseSnapshot(_ types.PersisterView) {
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (nsp *NoSnapshotPersister) MultiPut(data map[string][]byte) error {
+	multiPutter, ok := nsp.Persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	for key, val := range data {
+		err := nsp.Persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Capabilities returns the capabilities of the wrapped persister, whose snapshots are not native, so not reported
+func (nsp *NoSnapshotPersister) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(nsp.Persister)
+}
+
+// Health returns the health of the wrapped persister
+func (nsp *NoSnapshotPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(nsp.Persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (nsp *NoSnapshotPersister) IsInterfaceNil() bool {
+	return nsp == nil
+}
+
+type liveView struct {
+	persister types.Persister
+}
+
+// Get gets the value associated to the key from the live persister
+func (lv *liveView) Get(key []byte) ([]byte, error) {
+	return lv.persister.Get(key)
+}
+
+// Has returns nil if the given key is present in the live persister
+func (lv *liveView) Has(key []byte) error {
+	return lv.persister.Has(key)
+}
+
+// RangeKeys iterates over the (key, value) pairs of the live persister
+func (lv *liveView) RangeKeys(handler func(key []byte, val []byte) bool) {
+	lv.persister.RangeKeys(handler)
+}
+
+// Release does nothing, as the view holds no resources
+func (lv *liveView) Release() {
+}
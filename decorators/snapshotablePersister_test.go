@@ -0,0 +1,71 @@
+package decorators_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsSnapshotablePersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.AsSnapshotablePersister(nil)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	mdb := memorydb.New()
+	persister, err = decorators.AsSnapshotablePersister(mdb)
+	assert.Nil(t, err)
+	assert.True(t, persister == mdb)
+
+	persister, err = decorators.AsSnapshotablePersister(testscommon.NewMemDbMock())
+	assert.Nil(t, err)
+	assert.IsType(t, &decorators.NoSnapshotPersister{}, persister)
+}
+
+func TestNoSnapshotPersister_ShouldReadTheLiveContents(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewNoSnapshotPersister(testscommon.NewMemDbMock())
+	require.Nil(t, err)
+	assert.False(t, persister.IsInterfaceNil())
+	_ = persister.MultiPut(map[string][]byte{"key1": []byte("value1")})
+
+	view, err := persister.TakeSnapshot()
+	require.Nil(t, err)
+	defer persister.ReleaseSnapshot(view)
+
+	_ = persister.Put([]byte("key2"), []byte("value2"))
+	val, err := view.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, view.Has([]byte("key2")))
+	numKeys := 0
+	view.RangeKeys(func(key []byte, val []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 2, numKeys)
+}
+
+func TestNoSnapshotPersister_CapabilitiesShouldNotReportTheSnapshots(t *testing.T) {
+	t.Parallel()
+
+	checksumPersister, err := decorators.NewChecksumPersister(memorydb.New())
+	require.Nil(t, err)
+	assert.Equal(t, types.PersisterCapabilities{Durability: types.VolatileDurability}, checksumPersister.Capabilities())
+
+	persister, err := decorators.NewNoSnapshotPersister(checksumPersister)
+	require.Nil(t, err)
+
+	capabilities := persister.Capabilities()
+	assert.False(t, capabilities.SupportsSnapshots)
+	assert.Equal(t, types.VolatileDurability, capabilities.Durability)
+}
@@ -0,0 +1,164 @@
+package decorators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/tracing"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var _ types.Persister = (*TracingPersister)(nil)
+var _ types.MultiPutter = (*TracingPersister)(nil)
+var _ types.CapabilitiesProvider = (*TracingPersister)(nil)
+var _ types.HealthChecker = (*TracingPersister)(nil)
+var _ types.PersisterWithContext = (*TracingPersister)(nil)
+
+// TracingPersister starts a span around each operation of the wrapped persister, as configured by tracing.Setup. The
+// context aware operations start the spans as children of the ones held by the provided contexts, passing the
+// contexts holding the spans to the wrapped persister
+type TracingPersister struct {
+	persister types.PersisterWithContext
+	dbAttr    attribute.KeyValue
+}
+
+// NewTracingPersister wraps the provided persister in a TracingPersister, the spans being tagged with the provided
+// name, usually the path of the persister
+func NewTracingPersister(persister types.Persister, name string) (*TracingPersister, error) {
+	if check.IfNil(persister) {
+		return nil, fmt.Errorf("%w for the tracing decorator", common.ErrNilPersister)
+	}
+	persisterWithContext, err := AsPersisterWithContext(persister)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TracingPersister{
+		persister: persisterWithContext,
+		dbAttr:    attribute.String("db", name),
+	}, nil
+}
+
+// PutCtx adds the value to the wrapped persister, in a span child of the one held by the context
+func (tp *TracingPersister) PutCtx(ctx context.Context, key, val []byte) error {
+	ctx, span := tracing.StartSpan(ctx, "persister.Put", tp.dbAttr, attribute.Int("size", len(val)))
+	err := tp.persister.PutCtx(ctx, key, val)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+// Put adds the value to the wrapped persister
+func (tp *TracingPersister) Put(key, val []byte) error {
+	return tp.PutCtx(context.Background(), key, val)
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (tp *TracingPersister) MultiPut(data map[string][]byte) error {
+	_, span := tracing.StartSpan(context.Background(), "persister.MultiPut", tp.dbAttr, attribute.Int("num_values", len(data)))
+	err := tp.multiPut(data)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+func (tp *TracingPersister) multiPut(data map[string][]byte) error {
+	multiPutter, ok := tp.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	for key, val := range data {
+		err := tp.persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCtx gets the value associated to the key from the wrapped persister, in a span child of the one held by the
+// context
+func (tp *TracingPersister) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	ctx, span := tracing.StartSpan(ctx, "persister.Get", tp.dbAttr)
+	val, err := tp.persister.GetCtx(ctx, key)
+	tracing.EndSpan(span, err)
+
+	return val, err
+}
+
+// Get gets the value associated to the key from the wrapped persister
+func (tp *TracingPersister) Get(key []byte) ([]byte, error) {
+	return tp.GetCtx(context.Background(), key)
+}
+
+// HasCtx returns nil if the given key is present in the wrapped persister, in a span child of the one held by the
+// context
+func (tp *TracingPersister) HasCtx(ctx context.Context, key []byte) error {
+	ctx, span := tracing.StartSpan(ctx, "persister.Has", tp.dbAttr)
+	err := tp.persister.HasCtx(ctx, key)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+// Has returns nil if the given key is present in the wrapped persister
+func (tp *TracingPersister) Has(key []byte) error {
+	return tp.HasCtx(context.Background(), key)
+}
+
+// RemoveCtx removes the data associated to the given key from the wrapped persister, in a span child of the one held
+// by the context
+func (tp *TracingPersister) RemoveCtx(ctx context.Context, key []byte) error {
+	ctx, span := tracing.StartSpan(ctx, "persister.Remove", tp.dbAttr)
+	err := tp.persister.RemoveCtx(ctx, key)
+	tracing.EndSpan(span, err)
+
+	return err
+}
+
+// Remove removes the data associated to the given key from the wrapped persister
+func (tp *TracingPersister) Remove(key []byte) error {
+	return tp.RemoveCtx(context.Background(), key)
+}
+
+// Close closes the wrapped persister
+func (tp *TracingPersister) Close() error {
+	return tp.persister.Close()
+}
+
+// Destroy destroys the wrapped persister
+func (tp *TracingPersister) Destroy() error {
+	return tp.persister.Destroy()
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (tp *TracingPersister) DestroyClosed() error {
+	return tp.persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the wrapped persister
+func (tp *TracingPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	_, span := tracing.StartSpan(context.Background(), "persister.RangeKeys", tp.dbAttr)
+	tp.persister.RangeKeys(handler)
+	tracing.EndSpan(span, nil)
+}
+
+// Capabilities returns the durability of the wrapped persister, the snapshots and the iteration not being forwarded
+func (tp *TracingPersister) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(tp.persister)
+}
+
+// Health returns the health of the wrapped persister
+func (tp *TracingPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(tp.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tp *TracingPersister) IsInterfaceNil() bool {
+	return tp == nil
+}
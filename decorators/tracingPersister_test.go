@@ -0,0 +1,58 @@
+package decorators_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTracingPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewTracingPersister(nil, "db")
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	persister, err = decorators.NewTracingPersister(memorydb.New(), "db")
+	assert.Nil(t, err)
+	assert.False(t, persister.IsInterfaceNil())
+}
+
+func TestTracingPersister_ShouldTraceTheOperations(t *testing.T) {
+	tracer := &testscommon.TracerMock{}
+	require.Nil(t, tracing.Setup(tracing.Config{Tracer: tracer, SamplingRatio: 1}))
+	defer tracing.Disable()
+
+	persister, _ := decorators.NewTracingPersister(memorydb.New(), "db")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	require.Nil(t, persister.PutCtx(ctx, []byte("key"), []byte("value")))
+	val, err := persister.GetCtx(ctx, []byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.Nil(t, persister.HasCtx(ctx, []byte("key")))
+	assert.Nil(t, persister.RemoveCtx(ctx, []byte("key")))
+	_, err = persister.Get([]byte("key"))
+	assert.NotNil(t, err)
+	parent.End()
+
+	spans := tracer.Spans()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+		assert.True(t, span.Ended)
+		if span.Name != "parent" {
+			assert.Equal(t, "db", span.Attributes["db"].AsString())
+		}
+	}
+	assert.Equal(t, []string{"parent", "persister.Put", "persister.Get", "persister.Has", "persister.Remove", "persister.Get"}, names)
+	assert.Equal(t, int64(len("value")), spans[1].Attributes["size"].AsInt64())
+}
@@ -0,0 +1,146 @@
+package decorators
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var log = logger.GetOrCreate("storage/decorators")
+
+type valueCodec interface {
+	encode(value []byte) ([]byte, error)
+	decode(value []byte) ([]byte, error)
+}
+
+// transformingPersister encodes the values before passing them to the wrapped persister and decodes them back when
+// reading. The keys are never transformed, so that the lookups work as before
+type transformingPersister struct {
+	name      string
+	persister types.Persister
+	codec     valueCodec
+}
+
+func newTransformingPersister(name string, persister types.Persister, codec valueCodec) (*transformingPersister, error) {
+	if check.IfNil(persister) {
+		return nil, fmt.Errorf("%w for the %s decorator", common.ErrNilPersister, name)
+	}
+
+	return &transformingPersister{
+		name:      name,
+		persister: persister,
+		codec:     codec,
+	}, nil
+}
+
+// Put encodes the value and adds it to the wrapped persister
+func (tp *transformingPersister) Put(key, val []byte) error {
+	encoded, err := tp.codec.encode(val)
+	if err != nil {
+		return err
+	}
+
+	return tp.persister.Put(key, encoded)
+}
+
+// MultiPut encodes all the provided values and adds them to the wrapped persister, in one go if it supports it
+func (tp *transformingPersister) MultiPut(data map[string][]byte) error {
+	encodedData := make(map[string][]byte, len(data))
+	for key, val := range data {
+		encoded, err := tp.codec.encode(val)
+		if err != nil {
+			return err
+		}
+		encodedData[key] = encoded
+	}
+
+	multiPutter, ok := tp.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(encodedData)
+	}
+
+	for key, val := range encodedData {
+		err := tp.persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key from the wrapped persister and decodes it
+func (tp *transformingPersister) Get(key []byte) ([]byte, error) {
+	val, err := tp.persister.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := tp.codec.decode(val)
+	if err != nil {
+		return nil, fmt.Errorf("%w for key %x", err, key)
+	}
+
+	return decoded, nil
+}
+
+// Has returns nil if the given key is present in the wrapped persister
+func (tp *transformingPersister) Has(key []byte) error {
+	return tp.persister.Has(key)
+}
+
+// Close closes the wrapped persister
+func (tp *transformingPersister) Close() error {
+	return tp.persister.Close()
+}
+
+// Remove removes the data associated to the given key from the wrapped persister
+func (tp *transformingPersister) Remove(key []byte) error {
+	return tp.persister.Remove(key)
+}
+
+// Destroy destroys the wrapped persister
+func (tp *transformingPersister) Destroy() error {
+	return tp.persister.Destroy()
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (tp *transformingPersister) DestroyClosed() error {
+	return tp.persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the (key, decoded value) pairs of the wrapped persister. The values which can not be
+// decoded are skipped
+func (tp *transformingPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	tp.persister.RangeKeys(func(key []byte, val []byte) bool {
+		decoded, err := tp.codec.decode(val)
+		if err != nil {
+			log.Warn("RangeKeys: skipping the value which can not be decoded", "decorator", tp.name, "key", key, "error", err)
+			return true
+		}
+
+		return handler(key, decoded)
+	})
+}
+
+// Capabilities returns the durability of the wrapped persister, the snapshots and the iteration not being forwarded
+func (tp *transformingPersister) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(tp.persister)
+}
+
+// Health returns the health of the wrapped persister
+func (tp *transformingPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(tp.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tp *transformingPersister) IsInterfaceNil() bool {
+	return tp == nil
+}
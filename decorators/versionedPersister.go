@@ -0,0 +1,146 @@
+package decorators
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*VersionedPersister)(nil)
+var _ types.MultiPutter = (*VersionedPersister)(nil)
+var _ types.CapabilitiesProvider = (*VersionedPersister)(nil)
+var _ types.HealthChecker = (*VersionedPersister)(nil)
+
+const versionLength = 1
+
+// Migration upgrades a value from the format version preceding the one it is registered for
+type Migration func(value []byte) ([]byte, error)
+
+// VersionedPersister prepends the current format version to the stored values. The values stored with an older
+// version are upgraded when read, by the migrations registered for each of the following versions, then rewritten
+// with the current version, so that the schemas of the persisted objects evolve without migrating the whole persister
+// offline. RangeKeys upgrades the values as well, without rewriting them
+type VersionedPersister struct {
+	*transformingPersister
+	codec       *versionCodec
+	numMigrated uint64
+}
+
+// NewVersionedPersister wraps the provided persister in a VersionedPersister. The migrations are keyed by the version
+// they upgrade the values to, in (0, currentVersion]
+func NewVersionedPersister(persister types.Persister, currentVersion byte, migrations map[byte]Migration) (*VersionedPersister, error) {
+	for version, migration := range migrations {
+		if version == 0 || version > currentVersion {
+			return nil, fmt.Errorf("%w: migration to version %d is outside (0, %d]", common.ErrInvalidConfig, version, currentVersion)
+		}
+		if migration == nil {
+			return nil, fmt.Errorf("%w: nil migration to version %d", common.ErrInvalidConfig, version)
+		}
+	}
+
+	codec := &versionCodec{
+		currentVersion: currentVersion,
+		migrations:     migrations,
+	}
+	tp, err := newTransformingPersister("versioning", persister, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VersionedPersister{
+		transformingPersister: tp,
+		codec:                 codec,
+	}, nil
+}
+
+// Get gets the value associated to the key from the wrapped persister, upgrading it to the current version if stored
+// with an older one. An upgraded value is rewritten, a failed rewrite being retried on the next read
+func (vp *VersionedPersister) Get(key []byte) ([]byte, error) {
+	val, err := vp.persister.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, version, err := vp.codec.decodeVersion(val)
+	if err != nil {
+		return nil, fmt.Errorf("%w for key %x", err, key)
+	}
+	if version == vp.codec.currentVersion {
+		return payload, nil
+	}
+
+	upgraded, err := vp.codec.migrate(payload, version)
+	if err != nil {
+		return nil, fmt.Errorf("%w for key %x", err, key)
+	}
+
+	encoded, _ := vp.codec.encode(upgraded)
+	err = vp.persister.Put(key, encoded)
+	if err != nil {
+		log.Warn("VersionedPersister: could not rewrite the upgraded value", "key", key, "version", version, "error", err)
+	} else {
+		atomic.AddUint64(&vp.numMigrated, 1)
+	}
+
+	return upgraded, nil
+}
+
+// NumMigrated returns the number of values upgraded and rewritten by Get
+func (vp *VersionedPersister) NumMigrated() uint64 {
+	return atomic.LoadUint64(&vp.numMigrated)
+}
+
+type versionCodec struct {
+	currentVersion byte
+	migrations     map[byte]Migration
+}
+
+func (codec *versionCodec) encode(value []byte) ([]byte, error) {
+	encoded := make([]byte, versionLength, versionLength+len(value))
+	encoded[0] = codec.currentVersion
+
+	return append(encoded, value...), nil
+}
+
+func (codec *versionCodec) decode(value []byte) ([]byte, error) {
+	payload, version, err := codec.decodeVersion(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.migrate(payload, version)
+}
+
+func (codec *versionCodec) decodeVersion(value []byte) ([]byte, byte, error) {
+	if len(value) < versionLength {
+		return nil, 0, fmt.Errorf("%w: %d bytes, missing the format version", common.ErrInvalidValueLength, len(value))
+	}
+
+	version := value[0]
+	if version > codec.currentVersion {
+		return nil, 0, fmt.Errorf("%w: version %d is newer than the current version %d",
+			common.ErrUnsupportedFormatVersion, version, codec.currentVersion)
+	}
+
+	return value[versionLength:], version, nil
+}
+
+// migrate applies, in order, the migrations following the provided version
+func (codec *versionCodec) migrate(payload []byte, version byte) ([]byte, error) {
+	for v := version + 1; v <= codec.currentVersion && v > version; v++ {
+		migration, ok := codec.migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration to version %d", common.ErrUnsupportedFormatVersion, v)
+		}
+
+		var err error
+		payload, err = migration(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w while migrating to version %d", err, v)
+		}
+	}
+
+	return payload, nil
+}
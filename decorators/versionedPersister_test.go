@@ -0,0 +1,104 @@
+package decorators_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendingMigration(suffix string) decorators.Migration {
+	return func(value []byte) ([]byte, error) {
+		return append(append([]byte{}, value...), suffix...), nil
+	}
+}
+
+func TestNewVersionedPersister(t *testing.T) {
+	t.Parallel()
+
+	persister, err := decorators.NewVersionedPersister(nil, 0, nil)
+	assert.Nil(t, persister)
+	assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+	for _, migrations := range []map[byte]decorators.Migration{
+		{0: appendingMigration("0")},
+		{3: appendingMigration("3")},
+		{1: nil},
+	} {
+		persister, err = decorators.NewVersionedPersister(memorydb.New(), 2, migrations)
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	}
+}
+
+func TestVersionedPersister_ShouldUpgradeTheOlderValues(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	persister, err := decorators.NewVersionedPersister(db, 2, map[byte]decorators.Migration{
+		1: appendingMigration("_v1"),
+		2: appendingMigration("_v2"),
+	})
+	require.Nil(t, err)
+
+	require.Nil(t, persister.Put([]byte("current"), []byte("value")))
+	stored, _ := db.Get([]byte("current"))
+	assert.Equal(t, append([]byte{2}, "value"...), stored)
+
+	_ = db.Put([]byte("v0"), append([]byte{0}, "value"...))
+	_ = db.Put([]byte("v1"), append([]byte{1}, "value"...))
+
+	ranged := make(map[string]string)
+	persister.RangeKeys(func(key []byte, val []byte) bool {
+		ranged[string(key)] = string(val)
+		return true
+	})
+	assert.Equal(t, map[string]string{"current": "value", "v0": "value_v1_v2", "v1": "value_v2"}, ranged)
+	assert.Equal(t, uint64(0), persister.NumMigrated())
+
+	val, err := persister.Get([]byte("v0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value_v1_v2"), val)
+	stored, _ = db.Get([]byte("v0"))
+	assert.Equal(t, append([]byte{2}, "value_v1_v2"...), stored)
+
+	val, _ = persister.Get([]byte("v0"))
+	assert.Equal(t, []byte("value_v1_v2"), val)
+	val, _ = persister.Get([]byte("current"))
+	assert.Equal(t, []byte("value"), val)
+	assert.Equal(t, uint64(1), persister.NumMigrated())
+}
+
+func TestVersionedPersister_ShouldErrorForTheValuesWhichCanNotBeUpgraded(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	persister, _ := decorators.NewVersionedPersister(db, 2, map[byte]decorators.Migration{
+		2: func(_ []byte) ([]byte, error) {
+			return nil, errors.New("migration error")
+		},
+	})
+
+	_ = db.Put([]byte("newer"), append([]byte{3}, "value"...))
+	_, err := persister.Get([]byte("newer"))
+	assert.True(t, errors.Is(err, common.ErrUnsupportedFormatVersion))
+
+	_ = db.Put([]byte("missing migration"), append([]byte{0}, "value"...))
+	_, err = persister.Get([]byte("missing migration"))
+	assert.True(t, errors.Is(err, common.ErrUnsupportedFormatVersion))
+
+	_ = db.Put([]byte("failed migration"), append([]byte{1}, "value"...))
+	_, err = persister.Get([]byte("failed migration"))
+	assert.Equal(t, "migration error while migrating to version 2 for key 6661696c6564206d6967726174696f6e", err.Error())
+
+	_ = db.Put([]byte("empty"), nil)
+	_, err = persister.Get([]byte("empty"))
+	assert.True(t, errors.Is(err, common.ErrInvalidValueLength))
+
+	stored, _ := db.Get([]byte("failed migration"))
+	assert.Equal(t, append([]byte{1}, "value"...), stored)
+}
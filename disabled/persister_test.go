@@ -1,6 +1,7 @@
 package disabled
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -22,7 +23,7 @@ func TestPersister_MethodsDoNotPanic(t *testing.T) {
 	p := NewPersister()
 	assert.False(t, check.IfNil(p))
 	assert.Nil(t, p.Put(nil, nil))
-	assert.Equal(t, common.ErrKeyNotFound, p.Has(nil))
+	assert.True(t, errors.Is(p.Has(nil), common.ErrKeyNotFound))
 	assert.Nil(t, p.Close())
 	assert.Nil(t, p.Remove(nil))
 	assert.Nil(t, p.Destroy())
@@ -31,5 +32,5 @@ func TestPersister_MethodsDoNotPanic(t *testing.T) {
 
 	val, err := p.Get(nil)
 	assert.Nil(t, val)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
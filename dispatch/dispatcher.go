@@ -0,0 +1,358 @@
+package dispatch
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+var log = logger.GetOrCreate("storage/dispatch")
+
+const (
+	// DefaultMaxPendingCallsPerHandler is the number of calls queued for a single handler, used by the caches
+	DefaultMaxPendingCallsPerHandler = 4096
+	// DefaultMaxConsecutivePanics is the number of consecutive panics deregistering a handler, used by the caches
+	DefaultMaxConsecutivePanics = 64
+)
+
+// Handler is the handler of the added data, registered on the caches
+type Handler func(key []byte, value interface{})
+
+// ArgsDispatcher holds the arguments needed to create a Dispatcher
+type ArgsDispatcher struct {
+	// Name identifies the dispatcher, e.g. the name of its cache, in the logs
+	Name string
+	// MaxPendingCallsPerHandler, if not zero, bounds the calls queued for a handler. Once reached, Dispatch waits for
+	// the handler to catch up, unless DropWhenFull is set
+	MaxPendingCallsPerHandler int
+	// DropWhenFull makes Dispatch drop, rather than wait for, the calls of a handler having MaxPendingCallsPerHandler
+	// calls queued. The dropped calls are counted, the handler staying registered
+	DropWhenFull bool
+	// MaxConsecutivePanics, if not zero, is the number of consecutive panics of a handler deregistering it
+	MaxConsecutivePanics int
+}
+
+// HandlerStats holds the counters of a registered handler
+type HandlerStats struct {
+	NumCalls          uint64
+	NumPanics         uint64
+	NumDropped        uint64
+	NumPending        int
+	ConsecutivePanics uint64
+	TotalLatency      time.Duration
+	MaxLatency        time.Duration
+}
+
+type pendingCall struct {
+	key   []byte
+	value interface{}
+}
+
+type registeredHandler struct {
+	id      string
+	handler Handler
+
+	mut sync.Mutex
+	// cond is signaled whenever a pending call is taken, or the handler is removed
+	cond      *sync.Cond
+	pending   []pendingCall
+	isRunning bool
+	isRemoved bool
+
+	numCalls          atomic.Uint64
+	numPanics         atomic.Uint64
+	numDropped        atomic.Uint64
+	consecutivePanics atomic.Uint64
+	totalLatency      atomic.Int64
+	maxLatency        atomic.Int64
+}
+
+func newRegisteredHandler(id string, handler Handler) *registeredHandler {
+	rh := &registeredHandler{
+		id:      id,
+		handler: handler,
+	}
+	rh.cond = sync.NewCond(&rh.mut)
+
+	return rh
+}
+
+// remove drops the pending calls of the handler and releases the callers waiting to queue new ones
+func (rh *registeredHandler) remove() {
+	rh.mut.Lock()
+	rh.isRemoved = true
+	rh.pending = nil
+	rh.cond.Broadcast()
+	rh.mut.Unlock()
+}
+
+// Dispatcher calls the registered handlers in the background, recovering their panics and accounting their
+// latencies, so that a misbehaving handler can not crash the cache calling Dispatch. Each handler is called in the
+// dispatch order, on a go routine of its own started whenever it has pending calls, so that a slow handler does not
+// delay the others. No call is lost: once a handler has MaxPendingCallsPerHandler calls queued, Dispatch waits for it
+// to catch up, unless dropping is explicitly enabled. The handlers panicking MaxConsecutivePanics times in a row are
+// deregistered
+type Dispatcher struct {
+	name                      string
+	maxPendingCallsPerHandler int
+	dropWhenFull              bool
+	maxConsecutivePanics      uint64
+
+	mut      sync.RWMutex
+	handlers map[string]*registeredHandler
+
+	numDropped          atomic.Uint64
+	numAutoDeregistered atomic.Uint64
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(args ArgsDispatcher) (*Dispatcher, error) {
+	var validator common.ConfigValidator
+	validator.Check(args.MaxPendingCallsPerHandler >= 0, common.ErrInvalidConfig, "MaxPendingCallsPerHandler should not be negative")
+	validator.Check(!args.DropWhenFull || args.MaxPendingCallsPerHandler > 0, common.ErrInvalidConfig,
+		"DropWhenFull requires a positive MaxPendingCallsPerHandler")
+	validator.Check(args.MaxConsecutivePanics >= 0, common.ErrInvalidConfig, "MaxConsecutivePanics should not be negative")
+	err := validator.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%w for the dispatcher %q", err, args.Name)
+	}
+
+	return &Dispatcher{
+		name:                      args.Name,
+		maxPendingCallsPerHandler: args.MaxPendingCallsPerHandler,
+		dropWhenFull:              args.DropWhenFull,
+		maxConsecutivePanics:      uint64(args.MaxConsecutivePanics),
+		handlers:                  make(map[string]*registeredHandler),
+	}, nil
+}
+
+// NewDefaultDispatcher creates a Dispatcher with the default bounds, used by the caches
+func NewDefaultDispatcher(name string) *Dispatcher {
+	dispatcher, _ := NewDispatcher(ArgsDispatcher{
+		Name:                      name,
+		MaxPendingCallsPerHandler: DefaultMaxPendingCallsPerHandler,
+		MaxConsecutivePanics:      DefaultMaxConsecutivePanics,
+	})
+
+	return dispatcher
+}
+
+// Register registers the handler under the provided id, replacing the one already registered under it, if any
+func (d *Dispatcher) Register(id string, handler Handler) {
+	if handler == nil {
+		return
+	}
+
+	d.mut.Lock()
+	replaced, ok := d.handlers[id]
+	d.handlers[id] = newRegisteredHandler(id, handler)
+	d.mut.Unlock()
+
+	if ok {
+		replaced.remove()
+	}
+}
+
+// Unregister removes the handler registered under the provided id, if any, dropping its pending calls
+func (d *Dispatcher) Unregister(id string) {
+	d.mut.Lock()
+	rh, ok := d.handlers[id]
+	delete(d.handlers, id)
+	d.mut.Unlock()
+
+	if ok {
+		rh.remove()
+	}
+}
+
+// HandlerIDs returns the sorted ids of the registered handlers
+func (d *Dispatcher) HandlerIDs() []string {
+	d.mut.RLock()
+	ids := make([]string, 0, len(d.handlers))
+	for id := range d.handlers {
+		ids = append(ids, id)
+	}
+	d.mut.RUnlock()
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (d *Dispatcher) registeredHandlers() []*registeredHandler {
+	d.mut.RLock()
+	defer d.mut.RUnlock()
+
+	handlers := make([]*registeredHandler, 0, len(d.handlers))
+	for _, rh := range d.handlers {
+		handlers = append(handlers, rh)
+	}
+
+	return handlers
+}
+
+// Dispatch queues the call of all the registered handlers, without waiting for them, unless a handler already has
+// MaxPendingCallsPerHandler calls queued. Hence, a handler should not wait for the cache it is registered on to be
+// written
+func (d *Dispatcher) Dispatch(key []byte, value interface{}) {
+	for _, rh := range d.registeredHandlers() {
+		d.enqueue(rh, pendingCall{key: key, value: value})
+	}
+}
+
+func (d *Dispatcher) isFullNoLock(rh *registeredHandler) bool {
+	return d.maxPendingCallsPerHandler > 0 && len(rh.pending) >= d.maxPendingCallsPerHandler
+}
+
+func (d *Dispatcher) enqueue(rh *registeredHandler, call pendingCall) {
+	rh.mut.Lock()
+	defer rh.mut.Unlock()
+
+	for !d.dropWhenFull && !rh.isRemoved && d.isFullNoLock(rh) {
+		rh.cond.Wait()
+	}
+	if rh.isRemoved {
+		return
+	}
+	if d.isFullNoLock(rh) {
+		rh.numDropped.Add(1)
+		d.numDropped.Add(1)
+		return
+	}
+
+	rh.pending = append(rh.pending, call)
+	if !rh.isRunning {
+		rh.isRunning = true
+		go d.drain(rh)
+	}
+}
+
+// drain calls the handler for its pending calls, in order, until none is left
+func (d *Dispatcher) drain(rh *registeredHandler) {
+	for {
+		rh.mut.Lock()
+		if rh.isRemoved || len(rh.pending) == 0 {
+			rh.isRunning = false
+			rh.pending = nil
+			rh.mut.Unlock()
+			return
+		}
+
+		call := rh.pending[0]
+		rh.pending[0] = pendingCall{}
+		rh.pending = rh.pending[1:]
+		rh.cond.Broadcast()
+		rh.mut.Unlock()
+
+		d.call(rh, call)
+	}
+}
+
+func (d *Dispatcher) call(rh *registeredHandler, call pendingCall) {
+	start := time.Now()
+	defer func() {
+		latency := time.Since(start)
+		rh.totalLatency.Add(int64(latency))
+		storeMax(&rh.maxLatency, int64(latency))
+		rh.numCalls.Add(1)
+
+		r := recover()
+		if r == nil {
+			rh.consecutivePanics.Store(0)
+			return
+		}
+
+		rh.numPanics.Add(1)
+		log.Error("dispatcher: recovered from a handler panic", "dispatcher", d.name, "handler", rh.id,
+			"panic", r, "stack", string(debug.Stack()))
+		d.onPanic(rh)
+	}()
+
+	rh.handler(call.key, call.value)
+}
+
+// onPanic deregisters the handler once it panicked maxConsecutivePanics times in a row
+func (d *Dispatcher) onPanic(rh *registeredHandler) {
+	numPanics := rh.consecutivePanics.Add(1)
+	if d.maxConsecutivePanics == 0 || numPanics != d.maxConsecutivePanics {
+		return
+	}
+
+	d.mut.Lock()
+	isRegistered := d.handlers[rh.id] == rh
+	if isRegistered {
+		delete(d.handlers, rh.id)
+	}
+	d.mut.Unlock()
+	if !isRegistered {
+		return
+	}
+
+	rh.remove()
+	d.numAutoDeregistered.Add(1)
+	log.Warn("dispatcher: deregistered the repeatedly panicking handler", "dispatcher", d.name, "handler", rh.id,
+		"num consecutive panics", d.maxConsecutivePanics)
+}
+
+func storeMax(target *atomic.Int64, value int64) {
+	for {
+		current := target.Load()
+		if value <= current || target.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// HandlerStats returns the counters of the handler registered under the provided id
+func (d *Dispatcher) HandlerStats(id string) (HandlerStats, bool) {
+	d.mut.RLock()
+	rh, ok := d.handlers[id]
+	d.mut.RUnlock()
+	if !ok {
+		return HandlerStats{}, false
+	}
+
+	rh.mut.Lock()
+	numPending := len(rh.pending)
+	rh.mut.Unlock()
+
+	return HandlerStats{
+		NumCalls:          rh.numCalls.Load(),
+		NumPanics:         rh.numPanics.Load(),
+		NumDropped:        rh.numDropped.Load(),
+		NumPending:        numPending,
+		ConsecutivePanics: rh.consecutivePanics.Load(),
+		TotalLatency:      time.Duration(rh.totalLatency.Load()),
+		MaxLatency:        time.Duration(rh.maxLatency.Load()),
+	}, true
+}
+
+// Stats returns the number of the registered handlers, of their pending calls, of the calls dropped, if dropping is
+// enabled, and of the handlers deregistered for panicking repeatedly
+func (d *Dispatcher) Stats() map[string]interface{} {
+	handlers := d.registeredHandlers()
+	numPending := 0
+	for _, rh := range handlers {
+		rh.mut.Lock()
+		numPending += len(rh.pending)
+		rh.mut.Unlock()
+	}
+
+	return map[string]interface{}{
+		"numHandlers":         len(handlers),
+		"numPendingCalls":     numPending,
+		"numDroppedCalls":     d.numDropped.Load(),
+		"numAutoDeregistered": d.numAutoDeregistered.Load(),
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (d *Dispatcher) IsInterfaceNil() bool {
+	return d == nil
+}
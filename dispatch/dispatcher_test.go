@@ -0,0 +1,322 @@
+package dispatch_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgs() dispatch.ArgsDispatcher {
+	return dispatch.ArgsDispatcher{
+		Name:                      "test",
+		MaxPendingCallsPerHandler: 5,
+		MaxConsecutivePanics:      3,
+	}
+}
+
+func TestNewDispatcher(t *testing.T) {
+	t.Parallel()
+
+	args := createArgs()
+	args.MaxPendingCallsPerHandler = -1
+	args.MaxConsecutivePanics = -1
+	dispatcher, err := dispatch.NewDispatcher(args)
+	assert.Nil(t, dispatcher)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "MaxPendingCallsPerHandler")
+	assert.Contains(t, err.Error(), "MaxConsecutivePanics")
+
+	args = createArgs()
+	args.MaxPendingCallsPerHandler = 0
+	args.DropWhenFull = true
+	dispatcher, err = dispatch.NewDispatcher(args)
+	assert.Nil(t, dispatcher)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "DropWhenFull")
+
+	dispatcher, err = dispatch.NewDispatcher(createArgs())
+	assert.Nil(t, err)
+	assert.False(t, dispatcher.IsInterfaceNil())
+	assert.False(t, dispatch.NewDefaultDispatcher("test").IsInterfaceNil())
+}
+
+func TestDispatcher_RegisterAndDispatch(t *testing.T) {
+	t.Parallel()
+
+	dispatcher, _ := dispatch.NewDispatcher(createArgs())
+	chCalled := make(chan string, 10)
+	dispatcher.Register("a", func(key []byte, value interface{}) {
+		chCalled <- "a:" + string(key) + ":" + value.(string)
+	})
+	dispatcher.Register("b", func(key []byte, value interface{}) {
+		chCalled <- "b:" + string(key) + ":" + value.(string)
+	})
+	dispatcher.Register("nil", nil)
+	assert.Equal(t, []string{"a", "b"}, dispatcher.HandlerIDs())
+
+	dispatcher.Dispatch([]byte("key"), "value")
+	called := []string{<-chCalled, <-chCalled}
+	assert.ElementsMatch(t, []string{"a:key:value", "b:key:value"}, called)
+
+	dispatcher.Unregister("b")
+	dispatcher.Dispatch([]byte("key"), "other")
+	assert.Equal(t, "a:key:other", <-chCalled)
+	assert.Equal(t, []string{"a"}, dispatcher.HandlerIDs())
+
+	require.Eventually(t, func() bool {
+		stats, _ := dispatcher.HandlerStats("a")
+		return stats.NumCalls == 2
+	}, time.Second, time.Millisecond)
+	_, ok := dispatcher.HandlerStats("b")
+	assert.False(t, ok)
+}
+
+func TestDispatcher_PanickingHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should recover and deregister the repeatedly failing handler", func(t *testing.T) {
+		t.Parallel()
+
+		dispatcher, _ := dispatch.NewDispatcher(createArgs())
+		dispatcher.Register("panicking", func(key []byte, value interface{}) {
+			panic("handler failure")
+		})
+		numCalls := atomic.Int64{}
+		dispatcher.Register("healthy", func(key []byte, value interface{}) {
+			numCalls.Add(1)
+		})
+
+		for i := 0; i < 3; i++ {
+			dispatcher.Dispatch([]byte("key"), i)
+			require.Eventually(t, func() bool {
+				stats, ok := dispatcher.HandlerStats("panicking")
+				return !ok || stats.NumPanics == uint64(i+1)
+			}, time.Second, time.Millisecond)
+		}
+
+		require.Eventually(t, func() bool {
+			return len(dispatcher.HandlerIDs()) == 1
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []string{"healthy"}, dispatcher.HandlerIDs())
+		assert.Equal(t, uint64(1), dispatcher.Stats()["numAutoDeregistered"])
+		require.Eventually(t, func() bool {
+			return numCalls.Load() == 3
+		}, time.Second, time.Millisecond)
+	})
+	t.Run("a successful call should reset the consecutive failures", func(t *testing.T) {
+		t.Parallel()
+
+		dispatcher, _ := dispatch.NewDispatcher(createArgs())
+		dispatcher.Register("flaky", func(key []byte, value interface{}) {
+			if value.(bool) {
+				panic("handler failure")
+			}
+		})
+
+		for _, shouldPanic := range []bool{true, true, false, true, true} {
+			dispatcher.Dispatch([]byte("key"), shouldPanic)
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		stats, ok := dispatcher.HandlerStats("flaky")
+		require.True(t, ok)
+		assert.Equal(t, uint64(5), stats.NumCalls)
+		assert.Equal(t, uint64(4), stats.NumPanics)
+		assert.Equal(t, uint64(2), stats.ConsecutivePanics)
+	})
+}
+
+func TestDispatcher_SlowHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should stay registered and receive every call, in order", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgs()
+		args.MaxPendingCallsPerHandler = 2
+		dispatcher, _ := dispatch.NewDispatcher(args)
+		numCalls := 100
+		chCalled := make(chan int, numCalls)
+		dispatcher.Register("slow", func(key []byte, value interface{}) {
+			time.Sleep(time.Millisecond)
+			chCalled <- value.(int)
+		})
+
+		// the calls past the pending ones wait for the handler to catch up
+		for i := 0; i < numCalls; i++ {
+			dispatcher.Dispatch([]byte("key"), i)
+		}
+		for i := 0; i < numCalls; i++ {
+			assert.Equal(t, i, <-chCalled)
+		}
+
+		assert.Equal(t, []string{"slow"}, dispatcher.HandlerIDs())
+		require.Eventually(t, func() bool {
+			stats, _ := dispatcher.HandlerStats("slow")
+			return stats.NumCalls == uint64(numCalls)
+		}, time.Second, time.Millisecond)
+		stats, _ := dispatcher.HandlerStats("slow")
+		assert.Zero(t, stats.NumDropped)
+		assert.Zero(t, stats.NumPending)
+		assert.Equal(t, uint64(0), dispatcher.Stats()["numDroppedCalls"])
+	})
+	t.Run("should not delay the other handlers", func(t *testing.T) {
+		t.Parallel()
+
+		dispatcher, _ := dispatch.NewDispatcher(createArgs())
+		chUnblock := make(chan struct{})
+		dispatcher.Register("stalled", func(key []byte, value interface{}) {
+			<-chUnblock
+		})
+		dispatcher.Register("healthy", func(key []byte, value interface{}) {})
+
+		for i := 0; i < 3; i++ {
+			dispatcher.Dispatch([]byte("key"), i)
+		}
+		require.Eventually(t, func() bool {
+			stats, _ := dispatcher.HandlerStats("healthy")
+			return stats.NumCalls == 3
+		}, time.Second, time.Millisecond)
+
+		stats, _ := dispatcher.HandlerStats("stalled")
+		assert.Equal(t, 2, stats.NumPending)
+		assert.Equal(t, 2, dispatcher.Stats()["numPendingCalls"])
+		close(chUnblock)
+		require.Eventually(t, func() bool {
+			stats, _ = dispatcher.HandlerStats("stalled")
+			return stats.NumCalls == 3
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []string{"healthy", "stalled"}, dispatcher.HandlerIDs())
+	})
+	t.Run("should drop the calls past MaxPendingCallsPerHandler, if enabled, without deregistering", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgs()
+		args.MaxPendingCallsPerHandler = 1
+		args.DropWhenFull = true
+		dispatcher, _ := dispatch.NewDispatcher(args)
+		chUnblock := make(chan struct{})
+		chStarted := make(chan struct{}, 1)
+		dispatcher.Register("stalled", func(key []byte, value interface{}) {
+			chStarted <- struct{}{}
+			<-chUnblock
+		})
+
+		dispatcher.Dispatch([]byte("key"), 1)
+		<-chStarted
+		// the second call is queued, the next ones are dropped
+		for i := 2; i < 10; i++ {
+			dispatcher.Dispatch([]byte("key"), i)
+		}
+
+		stats, _ := dispatcher.HandlerStats("stalled")
+		assert.Equal(t, uint64(7), stats.NumDropped)
+		assert.Zero(t, stats.ConsecutivePanics)
+		assert.Equal(t, uint64(7), dispatcher.Stats()["numDroppedCalls"])
+		assert.Equal(t, []string{"stalled"}, dispatcher.HandlerIDs())
+
+		close(chUnblock)
+		require.Eventually(t, func() bool {
+			stats, _ = dispatcher.HandlerStats("stalled")
+			return stats.NumCalls == 2
+		}, time.Second, time.Millisecond)
+		assert.GreaterOrEqual(t, stats.TotalLatency, stats.MaxLatency)
+	})
+	t.Run("unregistering should release the waiting callers", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgs()
+		args.MaxPendingCallsPerHandler = 1
+		dispatcher, _ := dispatch.NewDispatcher(args)
+		chUnblock := make(chan struct{})
+		defer close(chUnblock)
+		chStarted := make(chan struct{}, 1)
+		dispatcher.Register("stalled", func(key []byte, value interface{}) {
+			chStarted <- struct{}{}
+			<-chUnblock
+		})
+
+		dispatcher.Dispatch([]byte("key"), 1)
+		<-chStarted
+		dispatcher.Dispatch([]byte("key"), 2)
+		chDispatched := make(chan struct{})
+		go func() {
+			dispatcher.Dispatch([]byte("key"), 3)
+			close(chDispatched)
+		}()
+
+		select {
+		case <-chDispatched:
+			require.Fail(t, "the caller should wait for the handler to catch up")
+		case <-time.After(20 * time.Millisecond):
+		}
+		dispatcher.Unregister("stalled")
+		select {
+		case <-chDispatched:
+		case <-time.After(time.Second):
+			require.Fail(t, "the caller should have been released")
+		}
+	})
+}
+
+func TestDispatcher_DispatchEvictions(t *testing.T) {
+	t.Parallel()
+
+	args := createArgs()
+	args.MaxPendingCallsPerHandler = 1
+	dispatcher, _ := dispatch.NewDispatcher(args)
+	chEvicted := make(chan string, 100)
+	dispatcher.RegisterEvictionHandler("handler", func(key []byte, value interface{}, reason types.EvictionReason) {
+		assert.Equal(t, types.EvictionReasonExpired, reason)
+		chEvicted <- string(key) + ":" + value.(string)
+	})
+	dispatcher.RegisterEvictionHandler("nil", nil)
+	assert.Equal(t, []string{"handler"}, dispatcher.HandlerIDs())
+
+	// a burst of evictions takes a single call of the handler
+	items := make([]dispatch.EvictedItem, 0, 50)
+	for i := 0; i < 50; i++ {
+		items = append(items, dispatch.EvictedItem{Key: []byte(fmt.Sprintf("key%d", i)), Value: "value"})
+	}
+	dispatcher.DispatchEvictions(items, types.EvictionReasonExpired)
+	dispatcher.DispatchEvictions(nil, types.EvictionReasonExpired)
+
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, fmt.Sprintf("key%d:value", i), <-chEvicted)
+	}
+	require.Eventually(t, func() bool {
+		stats, _ := dispatcher.HandlerStats("handler")
+		return stats.NumCalls == 1
+	}, time.Second, time.Millisecond)
+	stats, _ := dispatcher.HandlerStats("handler")
+	assert.Zero(t, stats.NumDropped)
+}
+
+func TestNewEvictionDispatcher(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := dispatch.NewEvictionDispatcher("test")
+	dispatcher.RegisterEvictionHandler("panicking", func(key []byte, value interface{}, reason types.EvictionReason) {
+		panic("eviction handler failure")
+	})
+
+	// past the default number of consecutive panics, the eviction handler stays registered
+	numDispatches := 2 * dispatch.DefaultMaxConsecutivePanics
+	for i := 0; i < numDispatches; i++ {
+		dispatcher.DispatchEvictions([]dispatch.EvictedItem{{Key: []byte("key"), Value: i}}, types.EvictionReasonCapacity)
+	}
+	require.Eventually(t, func() bool {
+		stats, _ := dispatcher.HandlerStats("panicking")
+		return stats.NumPanics == uint64(numDispatches)
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"panicking"}, dispatcher.HandlerIDs())
+	assert.Equal(t, uint64(0), dispatcher.Stats()["numAutoDeregistered"])
+}
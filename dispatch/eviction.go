@@ -0,0 +1,59 @@
+package dispatch
+
+import "github.com/TerraDharitri/drt-go-chain-storage/types"
+
+// EvictedItem is an item evicted from a cache, notified to the eviction handlers
+type EvictedItem struct {
+	Key   []byte
+	Value interface{}
+}
+
+// evictionBatch is the value dispatched to the eviction handlers: the items evicted at once, for the same reason
+type evictionBatch struct {
+	items  []EvictedItem
+	reason types.EvictionReason
+}
+
+// NewEvictionDispatcher creates a Dispatcher for the eviction handlers of the caches, which never loses an eviction:
+// the evictions are queued without bound, as some caches notify them while holding their locks, and the handlers are
+// never deregistered, their panics being only recovered and counted
+func NewEvictionDispatcher(name string) *Dispatcher {
+	dispatcher, _ := NewDispatcher(ArgsDispatcher{
+		Name: name,
+	})
+
+	return dispatcher
+}
+
+// RegisterEvictionHandler registers the eviction handler under the provided id. A dispatcher of eviction handlers
+// should be fed by DispatchEvictions only, hence it should not be shared with the added data handlers
+func (d *Dispatcher) RegisterEvictionHandler(id string, handler types.EvictedItemHandler) {
+	if handler == nil {
+		return
+	}
+
+	d.Register(id, func(_ []byte, value interface{}) {
+		batch, ok := value.(*evictionBatch)
+		if !ok {
+			return
+		}
+
+		for _, item := range batch.items {
+			handler(item.Key, item.Value, batch.reason)
+		}
+	})
+}
+
+// DispatchEvictions calls the registered eviction handlers in the background, in the dispatch order, each one being
+// called once for all the items, so that a burst of evictions takes one call per handler. A panicking handler is not
+// called for the remaining items of the batch
+func (d *Dispatcher) DispatchEvictions(items []EvictedItem, reason types.EvictionReason) {
+	if len(items) == 0 {
+		return
+	}
+
+	d.Dispatch(nil, &evictionBatch{
+		items:  items,
+		reason: reason,
+	})
+}
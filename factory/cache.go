@@ -2,23 +2,93 @@ package factory
 
 import (
 	"fmt"
+	"math"
+	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/clockcache"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/fifocache"
+	"github.com/TerraDharitri/drt-go-chain-storage/immunitycache"
 	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
-	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/syncmapcache"
+	"github.com/TerraDharitri/drt-go-chain-storage/timecache"
+	"github.com/TerraDharitri/drt-go-chain-storage/twolevelcache"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
-const minimumSizeForLRUCache = 1024
+const minimumSizeForLRUCache = common.MinSizeInBytesForSizeLRUCache
 
 // NewCache creates a new cache from a cache config
-func NewCache(config common.CacheConfig) (types.Cacher, error) {
-	monitoring.MonitorNewCache(config.Name, config.SizeInBytes)
+func NewCache(config common.CacheConfig, opts ...Option) (types.Cacher, error) {
+	o := newOptions(opts)
+	if o.configMode == common.LenientConfig {
+		for _, adjustment := range config.Clamp() {
+			o.log.Warn("NewCache: out of range config value clamped", "name", config.Name, "adjustment", adjustment)
+		}
+	}
+
+	cacher, err := newCache(config)
+	if err != nil {
+		return nil, err
+	}
+	registerCallbacks(cacher, config)
+	if !isSelfMonitored(config) {
+		o.monitor.MonitorNewCache(config.Name, config.SizeInBytes)
+	}
+
+	return cacher, nil
+}
+
+// isSelfMonitored returns true if the configured cache registers itself to the monitoring, under the same name
+func isSelfMonitored(config common.CacheConfig) bool {
+	if config.Type == common.TwoLevelCache {
+		return config.L2Type == common.ImmunityCache
+	}
+
+	return config.Type == common.ImmunityCache
+}
+
+// registerCallbacks registers the callbacks of the config on the created cache. The eviction callback of the two-level
+// caches is registered on their L2 cache, whose evictions drop the items altogether
+func registerCallbacks(cacher types.Cacher, config common.CacheConfig) {
+	if config.OnAdded != nil {
+		cacher.RegisterHandler(config.OnAdded, config.Name)
+	}
+	if config.Type != common.TwoLevelCache {
+		registerEvictionCallback(cacher, config)
+	}
+}
+
+func registerEvictionCallback(cacher types.Cacher, config common.CacheConfig) {
+	onEvicted := config.OnEvicted
+	if onEvicted == nil {
+		return
+	}
+
+	switch notifier := cacher.(type) {
+	case types.EvictionNotifier:
+		notifier.RegisterEvictionHandler(func(key []byte, value interface{}, _ types.EvictionReason) {
+			onEvicted(key, value)
+		}, config.Name)
+	case expiryNotifier:
+		notifier.RegisterEvictionHandler(func(key string) {
+			onEvicted([]byte(key), nil)
+		})
+	default:
+		log.Warn("NewCache: the cache does not notify its evictions, OnEvicted ignored", "name", config.Name, "type", config.Type)
+	}
+}
 
+// expiryNotifier defines the time caches, notifying the keys of their expired items
+type expiryNotifier interface {
+	RegisterEvictionHandler(handler func(key string))
+}
+
+func newCache(config common.CacheConfig) (types.Cacher, error) {
 	cacheType := config.Type
 	capacity := config.Capacity
-	shards := config.Shards
+	shards := config.NumShards()
 	sizeInBytes := config.SizeInBytes
 
 	switch cacheType {
@@ -39,8 +109,77 @@ func NewCache(config common.CacheConfig) (types.Cacher, error) {
 
 		return lrucache.NewCacheWithSizeInBytes(int(capacity), int64(sizeInBytes))
 	case common.FIFOShardedCache:
-		return fifocache.NewShardedCache(int(capacity), int(shards))
+		return newFIFOShardedCache(config)
+	case common.ClockCache:
+		return clockcache.NewClockCache(int(capacity))
+	case common.ImmunityCache:
+		return immunitycache.NewImmunityCache(immunitycache.CacheConfig{
+			Name:                        config.Name,
+			NumChunks:                   shards,
+			MaxNumItems:                 capacity,
+			MaxNumBytes:                 uint32(core.MinUint64(sizeInBytes, math.MaxUint32)),
+			NumItemsToPreemptivelyEvict: shards,
+			EvictionStrategy:            config.EvictionStrategy,
+		})
+	case common.SyncMapCache:
+		return syncmapcache.NewSyncMapCache(int(capacity))
+	case common.TimeCache:
+		return newTimeCache(config)
+	case common.TwoLevelCache:
+		return newTwoLevelCache(config)
 	default:
 		return nil, common.ErrNotSupportedCacheType
 	}
 }
+
+func newTimeCache(config common.CacheConfig) (types.Cacher, error) {
+	config.ApplyDefaults()
+
+	return timecache.NewTimeCacher(timecache.ArgTimeCacher{
+		DefaultSpan: time.Duration(config.TTLInSeconds) * time.Second,
+		CacheExpiry: time.Duration(config.SweepIntervalInSeconds) * time.Second,
+	})
+}
+
+func newFIFOShardedCache(config common.CacheConfig) (types.Cacher, error) {
+	config.ApplyDefaults()
+
+	return fifocache.NewShardedCache(int(config.Capacity), int(config.NumShards()),
+		fifocache.WithTTL(time.Duration(config.TTLInSeconds)*time.Second),
+		fifocache.WithSweepInterval(time.Duration(config.SweepIntervalInSeconds)*time.Second),
+	)
+}
+
+func newTwoLevelCache(config common.CacheConfig) (types.Cacher, error) {
+	if config.L2Type == common.TwoLevelCache {
+		return nil, fmt.Errorf("%w for the L2 cache: %s", common.ErrNotSupportedCacheType, config.L2Type)
+	}
+
+	l1, err := newL1Cache(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the L1 cache", err)
+	}
+
+	l2Config := config
+	l2Config.Type = config.L2Type
+	l2, err := newCache(l2Config)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the L2 cache", err)
+	}
+	registerEvictionCallback(l2, l2Config)
+
+	return twolevelcache.NewTwoLevelCache(l1, l2)
+}
+
+func newL1Cache(config common.CacheConfig) (types.Cacher, error) {
+	switch config.EvictionPolicy {
+	case "", common.LRUEvictionPolicy:
+		return lrucache.NewCache(int(config.L1Capacity))
+	case common.FIFOEvictionPolicy:
+		return fifocache.NewShardedCache(int(config.L1Capacity), common.DefaultNumShards)
+	case common.ClockEvictionPolicy:
+		return clockcache.NewClockCache(int(config.L1Capacity))
+	default:
+		return nil, fmt.Errorf("%w: EvictionPolicy %q", common.ErrInvalidConfig, config.EvictionPolicy)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -94,4 +96,305 @@ func TestNewCache(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, "*fifocache.FIFOShardedCache", fmt.Sprintf("%T", cacher))
 	})
+	t.Run("FIFOShardedCache type with TTL, should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:         common.FIFOShardedCache,
+			Capacity:     100,
+			Shards:       1,
+			TTLInSeconds: 60,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		defer func() {
+			_ = cacher.Close()
+		}()
+
+		stats := cacher.(types.StatsProvider).Stats()
+		require.Equal(t, "1m0s", stats["ttl"])
+	})
+	t.Run("ClockCache type, should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:     common.ClockCache,
+			Capacity: 100,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Equal(t, "*clockcache.ClockCache", fmt.Sprintf("%T", cacher))
+	})
+	t.Run("SyncMapCache type, should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:     common.SyncMapCache,
+			Capacity: 100,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Equal(t, "*syncmapcache.SyncMapCache", fmt.Sprintf("%T", cacher))
+	})
+	t.Run("ImmunityCache type, invalid eviction strategy, should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Name:             "test",
+			Type:             common.ImmunityCache,
+			Capacity:         100,
+			Shards:           4,
+			SizeInBytes:      1024,
+			EvictionStrategy: "unknown",
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+		require.Nil(t, cacher)
+	})
+	t.Run("ImmunityCache type, should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Name:             "test",
+			Type:             common.ImmunityCache,
+			Capacity:         100,
+			Shards:           4,
+			SizeInBytes:      1024,
+			EvictionStrategy: common.LargestFirstEviction,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Equal(t, "*immunitycache.ImmunityCache", fmt.Sprintf("%T", cacher))
+	})
+	t.Run("ImmunityCache type, without size in bytes, should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Name:     "test",
+			Type:     common.ImmunityCache,
+			Capacity: 100,
+			Shards:   4,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+		require.Nil(t, cacher)
+	})
+	t.Run("ImmunityCache type, without capacity, should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Name:        "test",
+			Type:        common.ImmunityCache,
+			Shards:      4,
+			SizeInBytes: 1024,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+		require.Nil(t, cacher)
+	})
+	t.Run("TimeCache type should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:         common.TimeCache,
+			TTLInSeconds: 60,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Equal(t, "*timecache.timeCacher", fmt.Sprintf("%T", cacher))
+
+		cacher.Put([]byte("key"), "value", 0)
+		require.True(t, cacher.Has([]byte("key")))
+		require.Nil(t, cacher.Close())
+	})
+	t.Run("TimeCache type without TTL should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type: common.TimeCache,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrInvalidDefaultSpan))
+		require.Nil(t, cacher)
+	})
+	t.Run("TwoLevelCache type should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:       common.TwoLevelCache,
+			Capacity:   100,
+			Shards:     4,
+			L1Capacity: 10,
+			L2Type:     common.FIFOShardedCache,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Equal(t, 100, cacher.MaxSize())
+	})
+	t.Run("TwoLevelCache type with invalid L1 capacity should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:     common.TwoLevelCache,
+			Capacity: 100,
+			L2Type:   common.LRUCache,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "L1")
+		require.Nil(t, cacher)
+	})
+	t.Run("TwoLevelCache type with invalid L2 type should fail", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:       common.TwoLevelCache,
+			Capacity:   100,
+			L1Capacity: 10,
+			L2Type:     common.TwoLevelCache,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrNotSupportedCacheType))
+		require.Nil(t, cacher)
+
+		cacheConf.L2Type = "unknown"
+		cacher, err = factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrNotSupportedCacheType))
+		require.Nil(t, cacher)
+	})
+	t.Run("TwoLevelCache type with time L2 cache should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:         common.TwoLevelCache,
+			L1Capacity:   10,
+			L2Type:       common.TimeCache,
+			TTLInSeconds: 60,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.Nil(t, cacher.Close())
+	})
+	t.Run("TwoLevelCache type should honor the eviction policy of the L1 cache", func(t *testing.T) {
+		t.Parallel()
+
+		for _, policy := range []common.EvictionPolicy{common.LRUEvictionPolicy, common.FIFOEvictionPolicy, common.ClockEvictionPolicy} {
+			cacheConf := common.CacheConfig{
+				Type:           common.TwoLevelCache,
+				Capacity:       100,
+				L1Capacity:     10,
+				L2Type:         common.LRUCache,
+				EvictionPolicy: policy,
+			}
+			cacher, err := factory.NewCache(cacheConf)
+			require.Nil(t, err, policy)
+			cacher.Put([]byte("key"), "value", 0)
+			value, ok := cacher.Get([]byte("key"))
+			require.True(t, ok)
+			require.Equal(t, "value", value)
+		}
+
+		cacheConf := common.CacheConfig{
+			Type:           common.TwoLevelCache,
+			Capacity:       100,
+			L1Capacity:     10,
+			L2Type:         common.LRUCache,
+			EvictionPolicy: "Random",
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+		require.Nil(t, cacher)
+	})
+	t.Run("FIFOShardedCache type with per CPU sharding should work", func(t *testing.T) {
+		t.Parallel()
+
+		cacheConf := common.CacheConfig{
+			Type:             common.FIFOShardedCache,
+			Capacity:         100,
+			ShardingStrategy: common.PerCPUSharding,
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+		require.NotNil(t, cacher)
+	})
+	t.Run("callbacks should be registered", func(t *testing.T) {
+		t.Parallel()
+
+		added := make(chan string, 10)
+		evicted := make(chan string, 10)
+		cacheConf := common.CacheConfig{
+			Name:     "callbacks",
+			Type:     common.LRUCache,
+			Capacity: 1,
+			OnAdded: func(key []byte, _ interface{}) {
+				added <- string(key)
+			},
+			OnEvicted: func(key []byte, _ interface{}) {
+				evicted <- string(key)
+			},
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+
+		cacher.Put([]byte("a"), "value", 0)
+		cacher.Put([]byte("b"), "value", 0)
+		require.ElementsMatch(t, []string{"a", "b"}, []string{receive(t, added), receive(t, added)})
+		require.Equal(t, "a", receive(t, evicted))
+	})
+	t.Run("eviction callback of a two-level cache should be registered on the L2 cache", func(t *testing.T) {
+		t.Parallel()
+
+		evicted := make(chan string, 10)
+		cacheConf := common.CacheConfig{
+			Type:       common.TwoLevelCache,
+			Capacity:   1,
+			L1Capacity: 10,
+			L2Type:     common.LRUCache,
+			OnEvicted: func(key []byte, _ interface{}) {
+				evicted <- string(key)
+			},
+		}
+		cacher, err := factory.NewCache(cacheConf)
+		require.Nil(t, err)
+
+		cacher.Put([]byte("a"), "value", 0)
+		cacher.Put([]byte("b"), "value", 0)
+		require.Equal(t, "a", receive(t, evicted))
+	})
+}
+
+func receive(t *testing.T, ch chan string) string {
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(time.Second):
+		require.Fail(t, "timeout waiting for the callback")
+		return ""
+	}
+}
+
+func TestNewCache_AllTypesShouldExposeTheirStats(t *testing.T) {
+	t.Parallel()
+
+	cacheConfigs := []common.CacheConfig{
+		{Type: common.LRUCache, Capacity: 10},
+		{Type: common.SizeLRUCache, Capacity: 10, SizeInBytes: 1024},
+		{Type: common.FIFOShardedCache, Capacity: 10, Shards: 2},
+		{Type: common.ClockCache, Capacity: 10},
+		{Type: common.ImmunityCache, Name: "TestNewCache_AllTypesShouldExposeTheirStats", Capacity: 10, SizeInBytes: 1024, Shards: 2},
+		{Type: common.SyncMapCache, Capacity: 10},
+		{Type: common.TimeCache, TTLInSeconds: 60},
+		{Type: common.TwoLevelCache, Capacity: 10, L1Capacity: 2, L2Type: common.LRUCache},
+	}
+	for _, cacheConfig := range cacheConfigs {
+		cacher, err := factory.NewCache(cacheConfig)
+		require.Nil(t, err, cacheConfig.Type)
+
+		cacher.Put([]byte("key"), []byte("value"), 5)
+		stats := types.StatsOf(cacher)
+		require.NotNil(t, stats, cacheConfig.Type)
+		require.Equal(t, string(cacheConfig.Type), stats[types.StatType])
+		require.EqualValues(t, 1, stats[types.StatNumItems], cacheConfig.Type)
+		require.Nil(t, cacher.Close())
+	}
 }
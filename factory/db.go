@@ -1,9 +1,13 @@
 package factory
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
 	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/sharded"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
@@ -14,18 +18,288 @@ type ArgDB struct {
 	BatchDelaySeconds int
 	MaxBatchSize      int
 	MaxOpenFiles      int
+	// BlockCacheSizeInBytes, if positive, enables the block cache of the LevelDB persisters, with the provided budget
+	// for each persister, e.g. for each base persister of a sharded one. It is meant for the persisters not fronted by
+	// a cache of their own
+	BlockCacheSizeInBytes int
+	// NumReaders, if positive, serves the reads of the serial LevelDB persisters from a pool of NumReaders go routines,
+	// concurrently with the writes, which stay serialized. By default, the reads are serialized along with the writes
+	NumReaders int
+	// WriteAroundThresholdInBytes, if positive, makes the LevelDB persisters write the values at least this large straight
+	// to the database, bypassing the batch, asynchronously if WriteAroundAsync is set. By default, all the values are
+	// batched
+	WriteAroundThresholdInBytes int
+	WriteAroundAsync            bool
+	// Sharded is only used by the sharded persisters
+	Sharded   ShardedDBOptions
+	OpenRetry OpenRetryConfig
+	// Decorators lists the decorators wrapping the persister, always applied in the same order, regardless of the
+	// listed one: the values are compressed, then encrypted, then checksummed, before reaching the retried persister.
+	// The tracing decorator, if listed, wraps all the others
+	Decorators []common.PersisterDecorator
+}
+
+// ShardedDBOptions holds the options of a sharded persister, which splits the keys over NumShards persisters of type
+// BaseDBType, each one stored in a sub-directory of the configured path. The base persisters are created from the
+// rest of the ArgDB elements
+type ShardedDBOptions struct {
+	NumShards           int32
+	ShardIDProviderType common.ShardIDProviderType
+	BaseDBType          common.DBType
+}
+
+const (
+	// DefaultMaxOpenFiles is the number of open files of the LevelDB persisters, if not configured
+	DefaultMaxOpenFiles = 10
+	// DefaultBatchDelaySeconds is the batch delay of the LevelDB persisters, if not configured
+	DefaultBatchDelaySeconds = 2
+	// DefaultMaxBatchSize is the maximum batch size of the LevelDB persisters, if not configured
+	DefaultMaxBatchSize = 100
+	// DefaultShardedBaseDBType is the type of the persisters a sharded persister is made of, if not configured
+	DefaultShardedBaseDBType = common.LvlDBSerial
+	// minNumShards is the minimum number of shards of a sharded persister
+	minNumShards = 2
+)
+
+// ApplyDefaults fills the unset elements which have sane defaults
+func (argDB *ArgDB) ApplyDefaults() {
+	argDB.OpenRetry.applyDefaults()
+	if argDB.DBType == common.ShardedDB {
+		if len(argDB.Sharded.ShardIDProviderType) == 0 {
+			argDB.Sharded.ShardIDProviderType = common.BinarySplit
+		}
+		if len(argDB.Sharded.BaseDBType) == 0 {
+			argDB.Sharded.BaseDBType = DefaultShardedBaseDBType
+		}
+	}
+
+	dbType := argDB.baseDBType()
+	if !isLevelDBType(dbType) && dbType != common.LvlDBReadOnly {
+		return
+	}
+
+	if argDB.MaxOpenFiles == 0 {
+		argDB.MaxOpenFiles = DefaultMaxOpenFiles
+	}
+	if dbType == common.LvlDBReadOnly {
+		return
+	}
+	if argDB.BatchDelaySeconds == 0 {
+		argDB.BatchDelaySeconds = DefaultBatchDelaySeconds
+	}
+	if argDB.MaxBatchSize == 0 {
+		argDB.MaxBatchSize = DefaultMaxBatchSize
+	}
+}
+
+// Validate checks all the elements of the arguments, returning an aggregated error which names every invalid element
+func (argDB *ArgDB) Validate() error {
+	errs := argDB.OpenRetry.validate()
+	errs = append(errs, validateDecorators(argDB.Decorators)...)
+	if argDB.DBType == common.ShardedDB {
+		errs = append(errs, argDB.validateSharded()...)
+		if argDB.Sharded.BaseDBType == common.ShardedDB {
+			return errors.Join(errs...)
+		}
+	}
+
+	dbType := argDB.baseDBType()
+	switch dbType {
+	case common.MemoryDB:
+		return errors.Join(errs...)
+	case common.LvlDB, common.LvlDBSerial, common.LvlDBReadOnly:
+	default:
+		return errors.Join(append(errs, fmt.Errorf("%w: DBType %q, supported types: %v", common.ErrNotSupportedDBType, dbType, common.SupportedDBTypes()))...)
+	}
+
+	if len(argDB.Path) == 0 {
+		errs = append(errs, fmt.Errorf("%w: Path is required for %s", common.ErrInvalidConfig, argDB.DBType))
+	}
+	if argDB.MaxOpenFiles < 1 {
+		errs = append(errs, fmt.Errorf("%w: MaxOpenFiles should be positive", common.ErrInvalidNumOpenFiles))
+	}
+	if argDB.BlockCacheSizeInBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: BlockCacheSizeInBytes should not be negative", common.ErrInvalidConfig))
+	}
+	if dbType == common.LvlDBReadOnly {
+		return errors.Join(errs...)
+	}
+	if argDB.NumReaders < 0 {
+		errs = append(errs, fmt.Errorf("%w: NumReaders should not be negative", common.ErrInvalidConfig))
+	}
+	if argDB.WriteAroundThresholdInBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: WriteAroundThresholdInBytes should not be negative", common.ErrInvalidConfig))
+	}
+	if argDB.BatchDelaySeconds < 1 {
+		errs = append(errs, fmt.Errorf("%w: BatchDelaySeconds should be positive", common.ErrInvalidConfig))
+	}
+	if argDB.MaxBatchSize < 1 {
+		errs = append(errs, fmt.Errorf("%w: MaxBatchSize should be positive", common.ErrInvalidConfig))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Clamp adjusts the out of range elements to their nearest valid values, returning the description of each adjustment
+func (argDB *ArgDB) Clamp() []string {
+	adjustments := argDB.OpenRetry.clamp()
+	if argDB.DBType == common.ShardedDB && argDB.Sharded.NumShards < minNumShards {
+		adjustments = append(adjustments, fmt.Sprintf("Sharded.NumShards changed from %d to %d", argDB.Sharded.NumShards, minNumShards))
+		argDB.Sharded.NumShards = minNumShards
+	}
+
+	dbType := argDB.baseDBType()
+	if !isLevelDBType(dbType) && dbType != common.LvlDBReadOnly {
+		return adjustments
+	}
+
+	clampToOne := func(name string, value *int) {
+		if *value < 1 {
+			adjustments = append(adjustments, fmt.Sprintf("%s changed from %d to 1", name, *value))
+			*value = 1
+		}
+	}
+	clampToOne("MaxOpenFiles", &argDB.MaxOpenFiles)
+	if argDB.BlockCacheSizeInBytes < 0 {
+		adjustments = append(adjustments, fmt.Sprintf("BlockCacheSizeInBytes changed from %d to 0", argDB.BlockCacheSizeInBytes))
+		argDB.BlockCacheSizeInBytes = 0
+	}
+	if dbType != common.LvlDBReadOnly {
+		clampToOne("BatchDelaySeconds", &argDB.BatchDelaySeconds)
+		clampToOne("MaxBatchSize", &argDB.MaxBatchSize)
+		if argDB.NumReaders < 0 {
+			adjustments = append(adjustments, fmt.Sprintf("NumReaders changed from %d to 0", argDB.NumReaders))
+			argDB.NumReaders = 0
+		}
+		if argDB.WriteAroundThresholdInBytes < 0 {
+			adjustments = append(adjustments, fmt.Sprintf("WriteAroundThresholdInBytes changed from %d to 0", argDB.WriteAroundThresholdInBytes))
+			argDB.WriteAroundThresholdInBytes = 0
+		}
+	}
+
+	return adjustments
 }
 
-// NewDB creates a new database from database config
-func NewDB(argDB ArgDB) (types.Persister, error) {
+func (argDB *ArgDB) validateSharded() []error {
+	errs := make([]error, 0)
+	if len(argDB.Path) == 0 {
+		errs = append(errs, fmt.Errorf("%w: Path is required for %s", common.ErrInvalidConfig, argDB.DBType))
+	}
+	if argDB.Sharded.NumShards < minNumShards {
+		errs = append(errs, fmt.Errorf("%w: Sharded.NumShards should be at least %d", common.ErrInvalidNumberOfShards, minNumShards))
+	}
+	if argDB.Sharded.ShardIDProviderType != common.BinarySplit {
+		errs = append(errs, fmt.Errorf("%w: Sharded.ShardIDProviderType %q", common.ErrInvalidConfig, argDB.Sharded.ShardIDProviderType))
+	}
+	if argDB.Sharded.BaseDBType == common.ShardedDB {
+		errs = append(errs, fmt.Errorf("%w: Sharded.BaseDBType %q", common.ErrNotSupportedDBType, argDB.Sharded.BaseDBType))
+	}
+
+	return errs
+}
+
+// baseDBType returns the type of the persisters actually storing the data
+func (argDB *ArgDB) baseDBType() common.DBType {
+	if argDB.DBType == common.ShardedDB {
+		return argDB.Sharded.BaseDBType
+	}
+
+	return argDB.DBType
+}
+
+func isLevelDBType(dbType common.DBType) bool {
+	return dbType == common.LvlDB || dbType == common.LvlDBSerial
+}
+
+// NewDB creates a new database from database config, retrying the failed openings as configured in argDB.OpenRetry
+func NewDB(argDB ArgDB, opts ...Option) (types.Persister, error) {
+	o := newOptions(opts)
+	if o.configMode == common.LenientConfig {
+		for _, adjustment := range argDB.Clamp() {
+			o.log.Warn("NewDB: out of range config value clamped", "path", argDB.Path, "adjustment", adjustment)
+		}
+	}
+
+	open := func() (types.Persister, error) {
+		if argDB.DBType == common.ShardedDB {
+			// each base persister retries its own opening
+			return newShardedDB(argDB, o)
+		}
+
+		return openWithRetry(argDB.OpenRetry, o, func() (types.Persister, error) {
+			return newDB(argDB)
+		})
+	}
+	persister, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	persister, err = decoratePersister(persister, argDB, o, open)
+	if err != nil {
+		return nil, err
+	}
+
+	o.monitor.MonitorNewDB(string(argDB.DBType), argDB.Path)
+
+	return persister, nil
+}
+
+func newDB(argDB ArgDB) (types.Persister, error) {
+	levelDBOptions := []leveldb.Option{
+		leveldb.WithBlockCache(argDB.BlockCacheSizeInBytes),
+		leveldb.WithNumReaders(argDB.NumReaders),
+		leveldb.WithWriteAround(argDB.WriteAroundThresholdInBytes, argDB.WriteAroundAsync),
+	}
+
 	switch argDB.DBType {
 	case common.LvlDB:
-		return leveldb.NewDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles)
+		return leveldb.NewDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles, levelDBOptions...)
 	case common.LvlDBSerial:
-		return leveldb.NewSerialDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles)
+		return leveldb.NewSerialDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles, levelDBOptions...)
+	case common.LvlDBReadOnly:
+		return leveldb.NewReadOnlyDB(argDB.Path, argDB.MaxOpenFiles, levelDBOptions...)
 	case common.MemoryDB:
 		return memorydb.New(), nil
 	default:
 		return nil, common.ErrNotSupportedDBType
 	}
 }
+
+func newShardedDB(argDB ArgDB, options *options) (types.Persister, error) {
+	if argDB.Sharded.ShardIDProviderType != common.BinarySplit {
+		return nil, fmt.Errorf("%w: shard id provider type %q", common.ErrInvalidConfig, argDB.Sharded.ShardIDProviderType)
+	}
+	if argDB.Sharded.BaseDBType == common.ShardedDB {
+		return nil, fmt.Errorf("%w for the base persisters: %s", common.ErrNotSupportedDBType, argDB.Sharded.BaseDBType)
+	}
+
+	idProvider, err := sharded.NewShardIDProvider(argDB.Sharded.NumShards)
+	if err != nil {
+		return nil, err
+	}
+
+	return sharded.NewShardedPersister(argDB.Path, &persisterCreator{argDB: argDB, options: options}, idProvider)
+}
+
+// persisterCreator creates the base persisters of a sharded persister
+type persisterCreator struct {
+	argDB   ArgDB
+	options *options
+}
+
+// CreateBasePersister creates a base persister stored at the provided path
+func (pc *persisterCreator) CreateBasePersister(path string) (types.Persister, error) {
+	argDB := pc.argDB
+	argDB.DBType = argDB.Sharded.BaseDBType
+	argDB.Path = path
+
+	return openWithRetry(argDB.OpenRetry, pc.options, func() (types.Persister, error) {
+		return newDB(argDB)
+	})
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pc *persisterCreator) IsInterfaceNil() bool {
+	return pc == nil
+}
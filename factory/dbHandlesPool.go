@@ -0,0 +1,254 @@
+package factory
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type sharedDB struct {
+	argDB      ArgDB
+	path       string
+	persister  types.Persister
+	numHandles int
+	pool       *DBHandlesPool
+}
+
+// DBHandlesPool deduplicates the persisters opened on the same path, so that two storage units can not accidentally
+// open the same database directory twice. Each NewDB call returns its own handle, the underlying persister being
+// closed when the last handle is closed. The memory persisters are never shared
+type DBHandlesPool struct {
+	mut sync.Mutex
+	dbs map[string]*sharedDB
+}
+
+// NewDBHandlesPool creates a new, empty, pool of persister handles
+func NewDBHandlesPool() *DBHandlesPool {
+	return &DBHandlesPool{
+		dbs: make(map[string]*sharedDB),
+	}
+}
+
+// NewDB returns a handle of the persister opened on the provided path, creating the persister if it is not already
+// opened. The same path can not be opened with different arguments
+func (pool *DBHandlesPool) NewDB(argDB ArgDB, opts ...Option) (types.Persister, error) {
+	if argDB.DBType == common.MemoryDB {
+		return NewDB(argDB, opts...)
+	}
+
+	path, err := filepath.Abs(argDB.Path)
+	if err != nil {
+		return nil, err
+	}
+	argDB.Path = path
+
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	db, ok := pool.dbs[path]
+	if ok {
+		if !reflect.DeepEqual(db.argDB, argDB) {
+			return nil, fmt.Errorf("%w: path %s is already opened with different arguments", common.ErrInvalidConfig, path)
+		}
+
+		db.numHandles++
+		return newDBHandle(db), nil
+	}
+
+	persister, err := NewDB(argDB, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	db = &sharedDB{
+		argDB:      argDB,
+		path:       path,
+		persister:  persister,
+		numHandles: 1,
+		pool:       pool,
+	}
+	pool.dbs[path] = db
+
+	return newDBHandle(db), nil
+}
+
+// release closes the persister once its last handle is released
+func (pool *DBHandlesPool) release(db *sharedDB) error {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	db.numHandles--
+	if db.numHandles > 0 {
+		return nil
+	}
+
+	delete(pool.dbs, db.path)
+
+	return db.persister.Close()
+}
+
+// destroy destroys the persister, if the provided handle is its only one
+func (pool *DBHandlesPool) destroy(db *sharedDB) error {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	if db.numHandles > 1 {
+		return fmt.Errorf("%w by %d handles", common.ErrDBIsShared, db.numHandles)
+	}
+
+	db.numHandles = 0
+	delete(pool.dbs, db.path)
+
+	return db.persister.Destroy()
+}
+
+// NumOpenDBs returns the number of the persisters opened through the pool
+func (pool *DBHandlesPool) NumOpenDBs() int {
+	pool.mut.Lock()
+	defer pool.mut.Unlock()
+
+	return len(pool.dbs)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pool *DBHandlesPool) IsInterfaceNil() bool {
+	return pool == nil
+}
+
+var _ types.Persister = (*dbHandle)(nil)
+var _ types.MultiPutter = (*dbHandle)(nil)
+var _ types.CapabilitiesProvider = (*dbHandle)(nil)
+var _ types.HealthChecker = (*dbHandle)(nil)
+
+// dbHandle is the persister returned by the pool: it forwards the calls to the shared persister until it is closed
+type dbHandle struct {
+	db       *sharedDB
+	isClosed atomic.Flag
+}
+
+func newDBHandle(db *sharedDB) *dbHandle {
+	return &dbHandle{
+		db: db,
+	}
+}
+
+// errClosed returns common.ErrDBIsClosed, carrying the path of the shared database
+func (handle *dbHandle) errClosed() error {
+	return common.WrapStorageError(common.ErrDBIsClosed, nil, "", handle.db.path)
+}
+
+// Put adds the value to the shared persister
+func (handle *dbHandle) Put(key, val []byte) error {
+	if handle.isClosed.IsSet() {
+		return handle.errClosed()
+	}
+
+	return handle.db.persister.Put(key, val)
+}
+
+// MultiPut adds all the provided values to the shared persister, in one go if the persister supports it
+func (handle *dbHandle) MultiPut(data map[string][]byte) error {
+	if handle.isClosed.IsSet() {
+		return handle.errClosed()
+	}
+
+	multiPutter, ok := handle.db.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	for key, val := range data {
+		err := handle.db.persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key from the shared persister
+func (handle *dbHandle) Get(key []byte) ([]byte, error) {
+	if handle.isClosed.IsSet() {
+		return nil, handle.errClosed()
+	}
+
+	return handle.db.persister.Get(key)
+}
+
+// Has returns nil if the given key is present in the shared persister
+func (handle *dbHandle) Has(key []byte) error {
+	if handle.isClosed.IsSet() {
+		return handle.errClosed()
+	}
+
+	return handle.db.persister.Has(key)
+}
+
+// Remove removes the data associated to the given key from the shared persister
+func (handle *dbHandle) Remove(key []byte) error {
+	if handle.isClosed.IsSet() {
+		return handle.errClosed()
+	}
+
+	return handle.db.persister.Remove(key)
+}
+
+// RangeKeys iterates over the (key, value) pairs of the shared persister
+func (handle *dbHandle) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handle.isClosed.IsSet() {
+		return
+	}
+
+	handle.db.persister.RangeKeys(handler)
+}
+
+// Close releases the handle, the shared persister being closed if this was its last handle
+func (handle *dbHandle) Close() error {
+	if handle.isClosed.SetReturningPrevious() {
+		return nil
+	}
+
+	return handle.db.pool.release(handle.db)
+}
+
+// Destroy destroys the shared persister, returning ErrDBIsShared if other handles are still using it
+func (handle *dbHandle) Destroy() error {
+	if handle.isClosed.IsSet() {
+		return handle.errClosed()
+	}
+
+	err := handle.db.pool.destroy(handle.db)
+	if err != nil {
+		return err
+	}
+
+	handle.isClosed.SetValue(true)
+
+	return nil
+}
+
+// DestroyClosed removes the stored data of the shared persister, once it was closed by all its handles
+func (handle *dbHandle) DestroyClosed() error {
+	return handle.db.persister.DestroyClosed()
+}
+
+// Capabilities returns the durability of the shared persister, its snapshots and iteration not being forwarded
+func (handle *dbHandle) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(handle.db.persister)
+}
+
+// Health returns the health of the shared persister
+func (handle *dbHandle) Health() types.HealthStatus {
+	return types.ForwardedHealth(handle.db.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (handle *dbHandle) IsInterfaceNil() bool {
+	return handle == nil
+}
@@ -0,0 +1,141 @@
+package factory_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createLevelDBArgs(path string) factory.ArgDB {
+	return factory.ArgDB{
+		DBType:            common.LvlDBSerial,
+		Path:              path,
+		BatchDelaySeconds: 10,
+		MaxBatchSize:      1,
+		MaxOpenFiles:      10,
+	}
+}
+
+func TestDBHandlesPool_NewDBShouldShareThePersisterOfTheSamePath(t *testing.T) {
+	t.Parallel()
+
+	pool := factory.NewDBHandlesPool()
+	assert.False(t, pool.IsInterfaceNil())
+
+	path := t.TempDir()
+	first, err := pool.NewDB(createLevelDBArgs(path))
+	require.Nil(t, err)
+	second, err := pool.NewDB(createLevelDBArgs(path + "/"))
+	require.Nil(t, err)
+	assert.Equal(t, 1, pool.NumOpenDBs())
+
+	require.Nil(t, first.Put([]byte("key"), []byte("value")))
+	val, err := second.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+
+	assert.Nil(t, first.Close())
+	assert.Nil(t, first.Close())
+	assert.True(t, errors.Is(first.Has([]byte("key")), common.ErrDBIsClosed))
+	assert.Nil(t, second.Has([]byte("key")))
+	assert.Equal(t, 1, pool.NumOpenDBs())
+
+	assert.Nil(t, second.Close())
+	assert.Equal(t, 0, pool.NumOpenDBs())
+
+	third, err := pool.NewDB(createLevelDBArgs(path))
+	require.Nil(t, err)
+	assert.Nil(t, third.Has([]byte("key")))
+	assert.Nil(t, third.Close())
+}
+
+func TestDBHandlesPool_NewDBWithDifferentArgumentsShouldError(t *testing.T) {
+	t.Parallel()
+
+	pool := factory.NewDBHandlesPool()
+	path := t.TempDir()
+	persister, err := pool.NewDB(createLevelDBArgs(path))
+	require.Nil(t, err)
+
+	argsDB := createLevelDBArgs(path)
+	argsDB.DBType = common.LvlDB
+	otherPersister, err := pool.NewDB(argsDB)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Nil(t, otherPersister)
+
+	assert.Nil(t, persister.Close())
+}
+
+func TestDBHandlesPool_NewDBShouldNotShareMemoryPersisters(t *testing.T) {
+	t.Parallel()
+
+	pool := factory.NewDBHandlesPool()
+	argsDB := factory.ArgDB{DBType: common.MemoryDB}
+	first, err := pool.NewDB(argsDB)
+	require.Nil(t, err)
+	second, err := pool.NewDB(argsDB)
+	require.Nil(t, err)
+	assert.Equal(t, 0, pool.NumOpenDBs())
+
+	require.Nil(t, first.Put([]byte("key"), []byte("value")))
+	assert.True(t, errors.Is(second.Has([]byte("key")), common.ErrKeyNotFound))
+}
+
+func TestDBHandlesPool_DestroyShouldErrorWhileShared(t *testing.T) {
+	t.Parallel()
+
+	pool := factory.NewDBHandlesPool()
+	path := t.TempDir()
+	first, err := pool.NewDB(createLevelDBArgs(path))
+	require.Nil(t, err)
+	second, err := pool.NewDB(createLevelDBArgs(path))
+	require.Nil(t, err)
+
+	err = first.Destroy()
+	assert.True(t, errors.Is(err, common.ErrDBIsShared))
+	assert.Nil(t, first.Close())
+
+	assert.Nil(t, second.Destroy())
+	assert.True(t, errors.Is(second.Put([]byte("key"), []byte("value")), common.ErrDBIsClosed))
+	assert.Equal(t, 0, pool.NumOpenDBs())
+}
+
+func TestDBHandlesPool_ConcurrentNewDBAndClose(t *testing.T) {
+	t.Parallel()
+
+	pool := factory.NewDBHandlesPool()
+	path := t.TempDir()
+	numHandles := 20
+	handles := make([]types.Persister, numHandles)
+
+	wg := sync.WaitGroup{}
+	wg.Add(numHandles)
+	for i := 0; i < numHandles; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			persister, err := pool.NewDB(createLevelDBArgs(path))
+			assert.Nil(t, err)
+			handles[idx] = persister
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, pool.NumOpenDBs())
+
+	wg.Add(numHandles)
+	for i := 0; i < numHandles; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			assert.Nil(t, handles[idx].Close())
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 0, pool.NumOpenDBs())
+}
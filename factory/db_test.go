@@ -1,6 +1,7 @@
 package factory_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -104,4 +105,219 @@ func TestNewDB(t *testing.T) {
 		err = persister.Close()
 		require.Nil(t, err)
 	})
+	t.Run("LvlDBReadOnly type, should work", func(t *testing.T) {
+		t.Parallel()
+
+		path := t.TempDir()
+		writableDB, err := factory.NewDB(factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			Path:              path,
+			BatchDelaySeconds: 10,
+			MaxBatchSize:      1,
+			MaxOpenFiles:      10,
+		})
+		require.Nil(t, err)
+		require.Nil(t, writableDB.Put([]byte("key"), []byte("value")))
+		require.Nil(t, writableDB.Close())
+
+		persister, err := factory.NewDB(factory.ArgDB{
+			DBType:       common.LvlDBReadOnly,
+			Path:         path,
+			MaxOpenFiles: 10,
+		})
+		require.Nil(t, err)
+		require.Equal(t, "*leveldb.ReadOnlyDB", fmt.Sprintf("%T", persister))
+		require.Nil(t, persister.Has([]byte("key")))
+		require.Equal(t, common.ErrDBIsReadOnly, persister.Put([]byte("key"), []byte("value")))
+
+		err = persister.Close()
+		require.Nil(t, err)
+	})
+	t.Run("Sharded type, should work", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType: common.ShardedDB,
+			Path:   t.TempDir(),
+			Sharded: factory.ShardedDBOptions{
+				NumShards: 4,
+			},
+		}
+		argsDB.ApplyDefaults()
+		require.Nil(t, argsDB.Validate())
+
+		persister, err := factory.NewDB(argsDB)
+		require.Nil(t, err)
+		require.Equal(t, "*sharded.shardedPersister", fmt.Sprintf("%T", persister))
+
+		for i := 0; i < 10; i++ {
+			require.Nil(t, persister.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value")))
+		}
+		for i := 0; i < 10; i++ {
+			require.Nil(t, persister.Has([]byte(fmt.Sprintf("key%d", i))))
+		}
+
+		err = persister.Close()
+		require.Nil(t, err)
+	})
+	t.Run("Sharded type with invalid options, should fail", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType: common.ShardedDB,
+			Path:   t.TempDir(),
+			Sharded: factory.ShardedDBOptions{
+				NumShards:           4,
+				ShardIDProviderType: common.BinarySplit,
+				BaseDBType:          common.ShardedDB,
+			},
+		}
+		persister, err := factory.NewDB(argsDB)
+		require.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+		require.Nil(t, persister)
+
+		argsDB.Sharded.BaseDBType = common.MemoryDB
+		argsDB.Sharded.ShardIDProviderType = "unknown"
+		persister, err = factory.NewDB(argsDB)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+		require.Nil(t, persister)
+	})
+}
+
+func TestArgDB_ApplyDefaultsAndValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("memory db should not be defaulted", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{DBType: common.MemoryDB}
+		argsDB.ApplyDefaults()
+		assert.Equal(t, factory.ArgDB{DBType: common.MemoryDB}, argsDB)
+		assert.Nil(t, argsDB.Validate())
+	})
+	t.Run("wrong db type should error", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{DBType: "NotLvlDB"}
+		assert.True(t, errors.Is(argsDB.Validate(), common.ErrNotSupportedDBType))
+	})
+	t.Run("should name every invalid field", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:            common.LvlDB,
+			BatchDelaySeconds: -1,
+		}
+		err := argsDB.Validate()
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.True(t, errors.Is(err, common.ErrInvalidNumOpenFiles))
+		for _, field := range []string{"Path", "MaxOpenFiles", "BatchDelaySeconds", "MaxBatchSize"} {
+			assert.Contains(t, err.Error(), field)
+		}
+	})
+	t.Run("negative block cache size, number of readers or write around threshold should error", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:                      common.LvlDBSerial,
+			Path:                        "test",
+			BatchDelaySeconds:           1,
+			MaxBatchSize:                1,
+			MaxOpenFiles:                1,
+			BlockCacheSizeInBytes:       -1,
+			NumReaders:                  -1,
+			WriteAroundThresholdInBytes: -1,
+		}
+		err := argsDB.Validate()
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "BlockCacheSizeInBytes")
+		assert.Contains(t, err.Error(), "NumReaders")
+		assert.Contains(t, err.Error(), "WriteAroundThresholdInBytes")
+	})
+	t.Run("sharded db should be defaulted and validated", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{DBType: common.ShardedDB, Path: "test"}
+		argsDB.ApplyDefaults()
+		assert.Equal(t, factory.ShardedDBOptions{
+			ShardIDProviderType: common.BinarySplit,
+			BaseDBType:          factory.DefaultShardedBaseDBType,
+		}, argsDB.Sharded)
+		assert.Equal(t, factory.DefaultMaxOpenFiles, argsDB.MaxOpenFiles)
+		assert.Equal(t, factory.DefaultMaxBatchSize, argsDB.MaxBatchSize)
+
+		err := argsDB.Validate()
+		assert.True(t, errors.Is(err, common.ErrInvalidNumberOfShards))
+		assert.Contains(t, err.Error(), "Sharded.NumShards")
+
+		argsDB.Sharded.NumShards = 2
+		assert.Nil(t, argsDB.Validate())
+	})
+	t.Run("read only db should only require the number of open files", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{DBType: common.LvlDBReadOnly, Path: "test"}
+		argsDB.ApplyDefaults()
+		assert.Equal(t, factory.ArgDB{
+			DBType:       common.LvlDBReadOnly,
+			Path:         "test",
+			MaxOpenFiles: factory.DefaultMaxOpenFiles,
+		}, argsDB)
+		assert.Nil(t, argsDB.Validate())
+	})
+	t.Run("defaults should be valid", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:       common.LvlDBSerial,
+			Path:         "test",
+			MaxOpenFiles: 5,
+		}
+		argsDB.ApplyDefaults()
+		assert.Equal(t, factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			Path:              "test",
+			BatchDelaySeconds: factory.DefaultBatchDelaySeconds,
+			MaxBatchSize:      factory.DefaultMaxBatchSize,
+			MaxOpenFiles:      5,
+		}, argsDB)
+		assert.Nil(t, argsDB.Validate())
+	})
+}
+
+func TestArgDB_Clamp(t *testing.T) {
+	t.Parallel()
+
+	argsDB := factory.ArgDB{
+		DBType:                      common.ShardedDB,
+		Path:                        "test",
+		BatchDelaySeconds:           -1,
+		BlockCacheSizeInBytes:       -1,
+		NumReaders:                  -1,
+		WriteAroundThresholdInBytes: -1,
+		Sharded: factory.ShardedDBOptions{
+			ShardIDProviderType: common.BinarySplit,
+			BaseDBType:          common.LvlDB,
+		},
+		OpenRetry: factory.OpenRetryConfig{
+			MaxAttempts:                -1,
+			InitialBackoffMilliseconds: 100,
+			MaxBackoffMilliseconds:     10,
+		},
+	}
+	adjustments := argsDB.Clamp()
+	assert.Len(t, adjustments, 9)
+	assert.Nil(t, argsDB.Validate())
+	assert.Equal(t, int32(2), argsDB.Sharded.NumShards)
+	assert.Equal(t, 1, argsDB.MaxOpenFiles)
+	assert.Equal(t, 1, argsDB.BatchDelaySeconds)
+	assert.Equal(t, 1, argsDB.MaxBatchSize)
+	assert.Equal(t, 100, argsDB.OpenRetry.MaxBackoffMilliseconds)
+	assert.Zero(t, argsDB.BlockCacheSizeInBytes)
+	assert.Zero(t, argsDB.NumReaders)
+	assert.Zero(t, argsDB.WriteAroundThresholdInBytes)
+
+	argsDB = factory.ArgDB{DBType: common.LvlDBReadOnly, Path: "test"}
+	assert.Equal(t, []string{"MaxOpenFiles changed from 0 to 1"}, argsDB.Clamp())
+	assert.Nil(t, argsDB.Validate())
 }
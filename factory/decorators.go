@@ -0,0 +1,143 @@
+package factory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const (
+	// DefaultRetryDecoratorMaxAttempts is the number of times the retry decorator attempts an operation
+	DefaultRetryDecoratorMaxAttempts = 3
+	// DefaultRetryDecoratorBackoff is the delay between the attempts of the retry decorator
+	DefaultRetryDecoratorBackoff = 100 * time.Millisecond
+	// DefaultReopenDecoratorMaxAttempts is the number of times the reopen decorator attempts to reopen a closed persister
+	DefaultReopenDecoratorMaxAttempts = 5
+	// DefaultReopenDecoratorInitialBackoff is the delay after the first failed reopen attempt, doubled after each one
+	DefaultReopenDecoratorInitialBackoff = 100 * time.Millisecond
+	// DefaultReopenDecoratorMaxBackoff is the maximum delay between the reopen attempts
+	DefaultReopenDecoratorMaxBackoff = 5 * time.Second
+)
+
+// decoratorsOrder holds the decorators from the innermost to the outermost one: a written value passes through them
+// in reverse order, so it is compressed before being encrypted, as the encrypted data does not compress. The
+// versioning decorator sees the plain values, so that the migrations work on them. The tracing decorator is the
+// outermost one, so that the spans cover the whole operations, retries included. The reopen decorator is the innermost
+// one, as it replaces the base persister
+var decoratorsOrder = []common.PersisterDecorator{
+	common.ReopenDecorator,
+	common.RetryDecorator,
+	common.ChecksumDecorator,
+	common.EncryptionDecorator,
+	common.CompressionDecorator,
+	common.VersioningDecorator,
+	common.TracingDecorator,
+}
+
+func validateDecorators(names []common.PersisterDecorator) []error {
+	errs := make([]error, 0)
+	seen := make(map[common.PersisterDecorator]struct{}, len(names))
+	for _, name := range names {
+		if !isKnownDecorator(name) {
+			errs = append(errs, fmt.Errorf("%w: Decorators contains the unknown decorator %q", common.ErrInvalidConfig, name))
+			continue
+		}
+		_, isDuplicate := seen[name]
+		if isDuplicate {
+			errs = append(errs, fmt.Errorf("%w: Decorators contains %q more than once", common.ErrInvalidConfig, name))
+		}
+		seen[name] = struct{}{}
+	}
+
+	return errs
+}
+
+func isKnownDecorator(name common.PersisterDecorator) bool {
+	for _, known := range decoratorsOrder {
+		if name == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decoratePersister wraps the persister in the decorators listed by argDB, closing it if any of them can not be created.
+// The reopen decorator opens the base persister again with the provided function
+func decoratePersister(
+	persister types.Persister,
+	argDB ArgDB,
+	options *options,
+	reopen func() (types.Persister, error),
+) (types.Persister, error) {
+	names := argDB.Decorators
+	errs := validateDecorators(names)
+	if len(errs) > 0 {
+		_ = persister.Close()
+		return nil, errs[0]
+	}
+
+	decorated := persister
+	for _, name := range decoratorsOrder {
+		if !containsDecorator(names, name) {
+			continue
+		}
+
+		var err error
+		decorated, err = newDecorator(name, decorated, argDB, options, reopen)
+		if err != nil {
+			_ = persister.Close()
+			return nil, fmt.Errorf("%w while creating the %s decorator", err, name)
+		}
+	}
+
+	return decorated, nil
+}
+
+func containsDecorator(names []common.PersisterDecorator, name common.PersisterDecorator) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newDecorator(
+	name common.PersisterDecorator,
+	persister types.Persister,
+	argDB ArgDB,
+	options *options,
+	reopen func() (types.Persister, error),
+) (types.Persister, error) {
+	switch name {
+	case common.ReopenDecorator:
+		// the batched writes not yet flushed when the persister was closed are replayed
+		return decorators.NewReopeningPersister(decorators.ArgsReopeningPersister{
+			Persister:         persister,
+			Reopen:            reopen,
+			MaxAttempts:       DefaultReopenDecoratorMaxAttempts,
+			InitialBackoff:    DefaultReopenDecoratorInitialBackoff,
+			MaxBackoff:        DefaultReopenDecoratorMaxBackoff,
+			MaxInFlightWrites: argDB.MaxBatchSize,
+		})
+	case common.RetryDecorator:
+		return decorators.NewRetryPersister(persister, DefaultRetryDecoratorMaxAttempts, DefaultRetryDecoratorBackoff)
+	case common.ChecksumDecorator:
+		return decorators.NewChecksumPersister(persister)
+	case common.EncryptionDecorator:
+		return decorators.NewEncryptedPersister(persister, options.encryptionKey)
+	case common.CompressionDecorator:
+		return decorators.NewCompressedPersister(persister)
+	case common.VersioningDecorator:
+		return decorators.NewVersionedPersister(persister, options.formatVersion, options.migrations)
+	case common.TracingDecorator:
+		return decorators.NewTracingPersister(persister, argDB.Path)
+	default:
+		return nil, fmt.Errorf("%w: unknown decorator %q", common.ErrInvalidConfig, name)
+	}
+}
@@ -0,0 +1,114 @@
+package factory_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDB_WithDecorators(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should apply the decorators in order", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType: common.MemoryDB,
+			Decorators: []common.PersisterDecorator{
+				common.ChecksumDecorator,
+				common.RetryDecorator,
+				common.CompressionDecorator,
+				common.EncryptionDecorator,
+				common.TracingDecorator,
+			},
+		}
+		require.Nil(t, argsDB.Validate())
+
+		persister, err := factory.NewDB(argsDB, factory.WithEncryptionKey(bytes.Repeat([]byte{1}, 32)))
+		require.Nil(t, err)
+		assert.Equal(t, "*decorators.TracingPersister", fmt.Sprintf("%T", persister))
+
+		value := bytes.Repeat([]byte("value"), 100)
+		require.Nil(t, persister.Put([]byte("key"), value))
+		val, err := persister.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, value, val)
+	})
+	t.Run("encryption without key should error", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:     common.MemoryDB,
+			Decorators: []common.PersisterDecorator{common.EncryptionDecorator},
+		}
+		persister, err := factory.NewDB(argsDB)
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, common.ErrInvalidEncryptionKey))
+	})
+	t.Run("versioning should use the provided format version", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:     common.MemoryDB,
+			Decorators: []common.PersisterDecorator{common.VersioningDecorator, common.CompressionDecorator},
+		}
+		persister, err := factory.NewDB(argsDB, factory.WithFormatVersion(1, map[byte]decorators.Migration{2: nil}))
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+		persister, err = factory.NewDB(argsDB, factory.WithFormatVersion(1, nil))
+		require.Nil(t, err)
+		assert.Equal(t, "*decorators.VersionedPersister", fmt.Sprintf("%T", persister))
+		require.Nil(t, persister.Put([]byte("key"), []byte("value")))
+		val, err := persister.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), val)
+	})
+	t.Run("reopen should reopen the closed base persister", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			Path:              t.TempDir(),
+			BatchDelaySeconds: 1,
+			MaxBatchSize:      100,
+			MaxOpenFiles:      10,
+			Decorators:        []common.PersisterDecorator{common.ReopenDecorator},
+		}
+		persister, err := factory.NewDB(argsDB)
+		require.Nil(t, err)
+		reopening, ok := persister.(*decorators.ReopeningPersister)
+		require.True(t, ok)
+
+		require.Nil(t, persister.Put([]byte("key"), []byte("value")))
+		val, err := persister.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), val)
+		require.Nil(t, persister.Close())
+		assert.True(t, common.IsClosed(persister.Has([]byte("key"))))
+		assert.Equal(t, uint64(0), reopening.Stats()["numReopens"])
+	})
+	t.Run("unknown or duplicated decorators should error", func(t *testing.T) {
+		t.Parallel()
+
+		argsDB := factory.ArgDB{
+			DBType:     common.MemoryDB,
+			Decorators: []common.PersisterDecorator{"zstd", common.ChecksumDecorator, common.ChecksumDecorator},
+		}
+		err := argsDB.Validate()
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), `unknown decorator "zstd"`)
+		assert.Contains(t, err.Error(), `"crc" more than once`)
+
+		persister, err := factory.NewDB(argsDB)
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+}
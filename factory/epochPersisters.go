@@ -0,0 +1,270 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.EpochSubscriber = (*EpochPersisters)(nil)
+
+// EpochPlaceholder is the placeholder replaced by the epoch in the path templates
+const EpochPlaceholder = common.EpochPlaceholder
+
+// ArgEpochPersisters is the argument used to create a new EpochPersisters instance
+type ArgEpochPersisters struct {
+	// PathTemplate is the path of the persisters, containing the EpochPlaceholder, e.g. "db/Epoch_{epoch}/Blocks"
+	PathTemplate string
+	// DB holds the arguments of the persisters, except for the path which is computed for each epoch
+	DB ArgDB
+	// NumActiveEpochs is the number of the last epochs whose persisters are kept open for writing
+	NumActiveEpochs uint32
+	// StartEpoch is the epoch whose persister is opened on creation
+	StartEpoch uint32
+	// EpochNotifier, if set, moves the active epochs window each time a newer epoch is confirmed
+	EpochNotifier types.EpochNotifier
+}
+
+// EpochPersisters creates the persisters rooted in per-epoch directories. The persisters of the last NumActiveEpochs
+// epochs are writable and kept open, while the ones of the older epochs are opened read only, on demand
+type EpochPersisters struct {
+	mut             sync.Mutex
+	pathTemplate    string
+	argDB           ArgDB
+	numActiveEpochs uint32
+	currentEpoch    uint32
+	opts            []Option
+	active          map[uint32]types.Persister
+	readOnly        map[uint32]types.Persister
+	isClosed        bool
+	epochNotifier   types.EpochNotifier
+}
+
+// NewEpochPersisters creates a new EpochPersisters instance, opening the persister of the start epoch. If an epoch
+// notifier is provided, the instance subscribes to it, catching up with its current epoch
+func NewEpochPersisters(args ArgEpochPersisters, opts ...Option) (*EpochPersisters, error) {
+	if !strings.Contains(args.PathTemplate, EpochPlaceholder) {
+		return nil, fmt.Errorf("%w: PathTemplate should contain %s", common.ErrInvalidConfig, EpochPlaceholder)
+	}
+	if args.NumActiveEpochs == 0 {
+		return nil, fmt.Errorf("%w: NumActiveEpochs should be positive", common.ErrInvalidConfig)
+	}
+
+	ep := &EpochPersisters{
+		pathTemplate:    args.PathTemplate,
+		argDB:           args.DB,
+		numActiveEpochs: args.NumActiveEpochs,
+		currentEpoch:    args.StartEpoch,
+		opts:            opts,
+		active:          make(map[uint32]types.Persister),
+		readOnly:        make(map[uint32]types.Persister),
+	}
+
+	_, err := ep.openActiveNoLock(args.StartEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if !check.IfNil(args.EpochNotifier) {
+		ep.epochNotifier = args.EpochNotifier
+		args.EpochNotifier.RegisterNotifyHandler(ep)
+	}
+
+	return ep, nil
+}
+
+// EpochConfirmed is called by the epoch notifier, moving the active epochs window when a newer epoch is confirmed
+func (ep *EpochPersisters) EpochConfirmed(epoch uint32) {
+	ep.mut.Lock()
+	isNewer := !ep.isClosed && epoch > ep.currentEpoch
+	ep.mut.Unlock()
+	if !isNewer {
+		return
+	}
+
+	err := ep.SetEpoch(epoch)
+	if err != nil {
+		log.Error("EpochPersisters: could not change the epoch", "path", ep.pathTemplate, "epoch", epoch, "error", err)
+	}
+}
+
+// PathForEpoch returns the path of the persister of the provided epoch
+func (ep *EpochPersisters) PathForEpoch(epoch uint32) string {
+	return strings.ReplaceAll(ep.pathTemplate, EpochPlaceholder, strconv.FormatUint(uint64(epoch), 10))
+}
+
+// Persister returns the persister of the provided epoch: writable for the active epochs, read only for the older ones
+func (ep *EpochPersisters) Persister(epoch uint32) (types.Persister, error) {
+	ep.mut.Lock()
+	defer ep.mut.Unlock()
+
+	if ep.isClosed {
+		return nil, common.ErrDBIsClosed
+	}
+	if epoch > ep.currentEpoch {
+		return nil, fmt.Errorf("%w: epoch %d is after the current epoch %d", common.ErrInvalidEpoch, epoch, ep.currentEpoch)
+	}
+	if ep.isActive(epoch) {
+		return ep.openActiveNoLock(epoch)
+	}
+
+	return ep.openReadOnlyNoLock(epoch)
+}
+
+// SetEpoch moves the active epochs window so that it ends at the provided epoch, opening its persister. The persisters
+// of the epochs left out of the window are closed, to be reopened read only when needed
+func (ep *EpochPersisters) SetEpoch(epoch uint32) error {
+	ep.mut.Lock()
+	defer ep.mut.Unlock()
+
+	if ep.isClosed {
+		return common.ErrDBIsClosed
+	}
+	if epoch < ep.currentEpoch {
+		return fmt.Errorf("%w: epoch %d is before the current epoch %d", common.ErrInvalidEpoch, epoch, ep.currentEpoch)
+	}
+
+	previousEpoch := ep.currentEpoch
+	ep.currentEpoch = epoch
+	_, err := ep.openActiveNoLock(epoch)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+	retiredEpochs := make([]uint32, 0)
+	for activeEpoch, persister := range ep.active {
+		if ep.isActive(activeEpoch) {
+			continue
+		}
+
+		delete(ep.active, activeEpoch)
+		retiredEpochs = append(retiredEpochs, activeEpoch)
+		errClose := persister.Close()
+		if errClose != nil {
+			errs = append(errs, fmt.Errorf("%w while closing the persister of epoch %d", errClose, activeEpoch))
+		}
+	}
+
+	if epoch != previousEpoch {
+		sort.Slice(retiredEpochs, func(i, j int) bool {
+			return retiredEpochs[i] < retiredEpochs[j]
+		})
+		monitoring.PublishEvent(monitoring.NewEpochRotatedEvent(ep.pathTemplate, epoch, retiredEpochs))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ActiveEpochs returns the sorted epochs whose writable persisters are open
+func (ep *EpochPersisters) ActiveEpochs() []uint32 {
+	ep.mut.Lock()
+	defer ep.mut.Unlock()
+
+	epochs := make([]uint32, 0, len(ep.active))
+	for epoch := range ep.active {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	return epochs
+}
+
+// Close unsubscribes from the epoch notifier, if any, and closes all the open persisters
+func (ep *EpochPersisters) Close() error {
+	if !check.IfNil(ep.epochNotifier) {
+		ep.epochNotifier.UnregisterNotifyHandler(ep)
+	}
+
+	ep.mut.Lock()
+	defer ep.mut.Unlock()
+
+	if ep.isClosed {
+		return nil
+	}
+	ep.isClosed = true
+
+	errs := make([]error, 0)
+	for _, persisters := range []map[uint32]types.Persister{ep.active, ep.readOnly} {
+		for epoch, persister := range persisters {
+			err := persister.Close()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w while closing the persister of epoch %d", err, epoch))
+			}
+		}
+	}
+	ep.active = make(map[uint32]types.Persister)
+	ep.readOnly = make(map[uint32]types.Persister)
+
+	return errors.Join(errs...)
+}
+
+func (ep *EpochPersisters) isActive(epoch uint32) bool {
+	return epoch <= ep.currentEpoch && ep.currentEpoch-epoch < ep.numActiveEpochs
+}
+
+func (ep *EpochPersisters) openActiveNoLock(epoch uint32) (types.Persister, error) {
+	persister, ok := ep.active[epoch]
+	if ok {
+		return persister, nil
+	}
+
+	argDB := ep.argDB
+	argDB.Path = ep.PathForEpoch(epoch)
+	persister, err := NewDB(argDB, ep.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w for epoch %d", err, epoch)
+	}
+	ep.active[epoch] = persister
+
+	return persister, nil
+}
+
+func (ep *EpochPersisters) openReadOnlyNoLock(epoch uint32) (types.Persister, error) {
+	persister, ok := ep.readOnly[epoch]
+	if ok {
+		return persister, nil
+	}
+
+	argDB, err := readOnlyArgDB(ep.argDB)
+	if err != nil {
+		return nil, err
+	}
+	argDB.Path = ep.PathForEpoch(epoch)
+	persister, err = NewDB(argDB, ep.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w for epoch %d", err, epoch)
+	}
+	ep.readOnly[epoch] = persister
+
+	return persister, nil
+}
+
+// readOnlyArgDB returns the arguments opening the same persister in read only mode
+func readOnlyArgDB(argDB ArgDB) (ArgDB, error) {
+	switch {
+	case isLevelDBType(argDB.DBType):
+		argDB.DBType = common.LvlDBReadOnly
+	case argDB.DBType == common.ShardedDB && isLevelDBType(argDB.Sharded.BaseDBType):
+		argDB.Sharded.BaseDBType = common.LvlDBReadOnly
+	case argDB.DBType == common.LvlDBReadOnly:
+	default:
+		return ArgDB{}, fmt.Errorf("%w: %s can not be opened read only", common.ErrNotSupportedDBType, argDB.DBType)
+	}
+
+	return argDB, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ep *EpochPersisters) IsInterfaceNil() bool {
+	return ep == nil
+}
@@ -0,0 +1,128 @@
+package factory_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/pruning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgEpochPersisters(t *testing.T) factory.ArgEpochPersisters {
+	return factory.ArgEpochPersisters{
+		PathTemplate:    filepath.Join(t.TempDir(), "Epoch_"+factory.EpochPlaceholder, "Blocks"),
+		DB:              createLevelDBArgs(""),
+		NumActiveEpochs: 2,
+		StartEpoch:      3,
+	}
+}
+
+func TestNewEpochPersisters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing placeholder should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgEpochPersisters(t)
+		args.PathTemplate = "db"
+		ep, err := factory.NewEpochPersisters(args)
+		assert.Nil(t, ep)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("no active epochs should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgEpochPersisters(t)
+		args.NumActiveEpochs = 0
+		ep, err := factory.NewEpochPersisters(args)
+		assert.Nil(t, ep)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("should open the start epoch", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgEpochPersisters(t)
+		ep, err := factory.NewEpochPersisters(args)
+		require.Nil(t, err)
+		assert.False(t, ep.IsInterfaceNil())
+		assert.Equal(t, []uint32{3}, ep.ActiveEpochs())
+		assert.Equal(t, filepath.Join(filepath.Dir(filepath.Dir(args.PathTemplate)), "Epoch_3", "Blocks"), ep.PathForEpoch(3))
+		assert.Nil(t, ep.Close())
+	})
+}
+
+func TestEpochPersisters_ShouldKeepTheLastEpochsWritable(t *testing.T) {
+	t.Parallel()
+
+	ep, err := factory.NewEpochPersisters(createArgEpochPersisters(t))
+	require.Nil(t, err)
+
+	for epoch := uint32(3); epoch <= 5; epoch++ {
+		if epoch > 3 {
+			require.Nil(t, ep.SetEpoch(epoch))
+		}
+		persister, errGet := ep.Persister(epoch)
+		require.Nil(t, errGet)
+		require.Nil(t, persister.Put([]byte("key"), []byte{byte(epoch)}))
+	}
+	assert.Equal(t, []uint32{4, 5}, ep.ActiveEpochs())
+
+	persister, err := ep.Persister(3)
+	require.Nil(t, err)
+	val, err := persister.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{3}, val)
+	assert.Equal(t, common.ErrDBIsReadOnly, persister.Put([]byte("key"), []byte{0}))
+
+	sameReadOnly, _ := ep.Persister(3)
+	assert.True(t, persister == sameReadOnly)
+
+	_, err = ep.Persister(1)
+	assert.NotNil(t, err, "the epoch 1 was never created")
+	_, err = ep.Persister(6)
+	assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+	assert.True(t, errors.Is(ep.SetEpoch(4), common.ErrInvalidEpoch))
+
+	assert.Nil(t, ep.Close())
+	assert.Nil(t, ep.Close())
+	_, err = ep.Persister(5)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+}
+
+func TestEpochPersisters_MemoryPersistersCanNotBeReopened(t *testing.T) {
+	t.Parallel()
+
+	args := createArgEpochPersisters(t)
+	args.DB = factory.ArgDB{DBType: common.MemoryDB}
+	args.NumActiveEpochs = 1
+	ep, err := factory.NewEpochPersisters(args)
+	require.Nil(t, err)
+	require.Nil(t, ep.SetEpoch(4))
+
+	_, err = ep.Persister(3)
+	assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+	assert.Nil(t, ep.Close())
+}
+
+func TestEpochPersisters_EpochConfirmed(t *testing.T) {
+	t.Parallel()
+
+	en := pruning.NewEpochNotifier(4)
+	args := createArgEpochPersisters(t)
+	args.EpochNotifier = en
+	ep, err := factory.NewEpochPersisters(args)
+	require.Nil(t, err)
+	assert.Equal(t, []uint32{3, 4}, ep.ActiveEpochs())
+
+	en.CheckEpoch(6)
+	assert.Equal(t, []uint32{6}, ep.ActiveEpochs())
+
+	require.Nil(t, ep.Close())
+	assert.Equal(t, 0, en.NumSubscribers())
+	en.CheckEpoch(7)
+	assert.Empty(t, ep.ActiveEpochs())
+}
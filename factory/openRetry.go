@@ -0,0 +1,112 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// DefaultOpenRetryBackoffMilliseconds is the delay before the first retry of a failed persister opening, if not configured
+var DefaultOpenRetryBackoffMilliseconds = int(common.SleepTimeBetweenCreateDBRetries.Milliseconds())
+
+// OpenRetryConfig holds the retry behavior of the persister opening, used to overcome the transient failures, like
+// the LOCK files lingering after an unclean shutdown. The opening is attempted MaxAttempts times (once, if not set),
+// the delay between the attempts starting at InitialBackoffMilliseconds and doubling after each failure, up to
+// MaxBackoffMilliseconds (if set)
+type OpenRetryConfig struct {
+	MaxAttempts                int
+	InitialBackoffMilliseconds int
+	MaxBackoffMilliseconds     int
+}
+
+func (config *OpenRetryConfig) applyDefaults() {
+	if config.MaxAttempts > 1 && config.InitialBackoffMilliseconds == 0 {
+		config.InitialBackoffMilliseconds = DefaultOpenRetryBackoffMilliseconds
+	}
+}
+
+func (config *OpenRetryConfig) validate() []error {
+	errs := make([]error, 0)
+	if config.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("%w: OpenRetry.MaxAttempts should not be negative", common.ErrInvalidConfig))
+	}
+	if config.InitialBackoffMilliseconds < 0 {
+		errs = append(errs, fmt.Errorf("%w: OpenRetry.InitialBackoffMilliseconds should not be negative", common.ErrInvalidConfig))
+	}
+	if config.MaxBackoffMilliseconds < 0 {
+		errs = append(errs, fmt.Errorf("%w: OpenRetry.MaxBackoffMilliseconds should not be negative", common.ErrInvalidConfig))
+	}
+	if config.MaxBackoffMilliseconds > 0 && config.MaxBackoffMilliseconds < config.InitialBackoffMilliseconds {
+		errs = append(errs, fmt.Errorf("%w: OpenRetry.MaxBackoffMilliseconds should not be lower than OpenRetry.InitialBackoffMilliseconds", common.ErrInvalidConfig))
+	}
+
+	return errs
+}
+
+func (config *OpenRetryConfig) clamp() []string {
+	adjustments := make([]string, 0)
+	clampToZero := func(name string, value *int) {
+		if *value < 0 {
+			adjustments = append(adjustments, fmt.Sprintf("OpenRetry.%s changed from %d to 0", name, *value))
+			*value = 0
+		}
+	}
+	clampToZero("MaxAttempts", &config.MaxAttempts)
+	clampToZero("InitialBackoffMilliseconds", &config.InitialBackoffMilliseconds)
+	clampToZero("MaxBackoffMilliseconds", &config.MaxBackoffMilliseconds)
+	if config.MaxBackoffMilliseconds > 0 && config.MaxBackoffMilliseconds < config.InitialBackoffMilliseconds {
+		adjustments = append(adjustments, fmt.Sprintf("OpenRetry.MaxBackoffMilliseconds changed from %d to %d",
+			config.MaxBackoffMilliseconds,
+			config.InitialBackoffMilliseconds,
+		))
+		config.MaxBackoffMilliseconds = config.InitialBackoffMilliseconds
+	}
+
+	return adjustments
+}
+
+// backoff returns the delay after the provided failed attempt, counted from 1
+func (config *OpenRetryConfig) backoff(attempt int) time.Duration {
+	delay := time.Duration(config.InitialBackoffMilliseconds) * time.Millisecond
+	maxDelay := time.Duration(config.MaxBackoffMilliseconds) * time.Millisecond
+	for i := 1; i < attempt; i++ {
+		if maxDelay > 0 && delay >= maxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+func openWithRetry(config OpenRetryConfig, options *options, open func() (types.Persister, error)) (types.Persister, error) {
+	attempt := 1
+	for {
+		persister, err := open()
+		if err == nil {
+			return persister, nil
+		}
+		if attempt >= config.MaxAttempts || isPermanentOpenError(err) {
+			return nil, err
+		}
+
+		options.log.Debug("error opening persister, retrying", "attempt", attempt, "error", err)
+		options.onOpenRetry(attempt, err)
+		options.sleep(config.backoff(attempt))
+		attempt++
+	}
+}
+
+// isPermanentOpenError returns true for the configuration errors, which a retry can not fix
+func isPermanentOpenError(err error) bool {
+	return errors.Is(err, common.ErrNotSupportedDBType) ||
+		errors.Is(err, common.ErrInvalidConfig) ||
+		errors.Is(err, common.ErrInvalidNumOpenFiles) ||
+		errors.Is(err, common.ErrInvalidNumberOfShards)
+}
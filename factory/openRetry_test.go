@@ -0,0 +1,155 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRetryConfig_Backoff(t *testing.T) {
+	t.Parallel()
+
+	config := OpenRetryConfig{
+		MaxAttempts:                10,
+		InitialBackoffMilliseconds: 100,
+	}
+	assert.Equal(t, 100*time.Millisecond, config.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, config.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, config.backoff(4))
+
+	config.MaxBackoffMilliseconds = 300
+	assert.Equal(t, 200*time.Millisecond, config.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, config.backoff(3))
+	assert.Equal(t, 300*time.Millisecond, config.backoff(9))
+}
+
+func TestOpenRetryConfig_ApplyDefaultsAndValidate(t *testing.T) {
+	t.Parallel()
+
+	config := OpenRetryConfig{}
+	config.applyDefaults()
+	assert.Equal(t, OpenRetryConfig{}, config)
+	assert.Empty(t, config.validate())
+
+	config = OpenRetryConfig{MaxAttempts: 3}
+	config.applyDefaults()
+	assert.Equal(t, DefaultOpenRetryBackoffMilliseconds, config.InitialBackoffMilliseconds)
+	assert.Empty(t, config.validate())
+
+	config = OpenRetryConfig{
+		MaxAttempts:                -1,
+		InitialBackoffMilliseconds: 100,
+		MaxBackoffMilliseconds:     10,
+	}
+	err := errors.Join(config.validate()...)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "OpenRetry.MaxAttempts")
+	assert.Contains(t, err.Error(), "OpenRetry.MaxBackoffMilliseconds")
+}
+
+func TestOpenWithRetry(t *testing.T) {
+	t.Parallel()
+
+	errOpen := errors.New("transient error")
+	config := OpenRetryConfig{
+		MaxAttempts:                4,
+		InitialBackoffMilliseconds: 10,
+	}
+
+	t.Run("should retry until it succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		retries := make([]int, 0)
+		sleeps := make([]time.Duration, 0)
+		options := newOptions([]Option{WithOpenRetryHandler(func(attempt int, err error) {
+			assert.Equal(t, errOpen, err)
+			retries = append(retries, attempt)
+		})})
+		options.sleep = func(duration time.Duration) {
+			sleeps = append(sleeps, duration)
+		}
+
+		numCalls := 0
+		persister, err := openWithRetry(config, options, func() (types.Persister, error) {
+			numCalls++
+			if numCalls < 3 {
+				return nil, errOpen
+			}
+			return testscommon.NewMemDbMock(), nil
+		})
+		assert.Nil(t, err)
+		assert.NotNil(t, persister)
+		assert.Equal(t, []int{1, 2}, retries)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, sleeps)
+	})
+	t.Run("should return the last error after the max attempts", func(t *testing.T) {
+		t.Parallel()
+
+		options := newOptions(nil)
+		options.sleep = func(_ time.Duration) {}
+
+		numCalls := 0
+		persister, err := openWithRetry(config, options, func() (types.Persister, error) {
+			numCalls++
+			return nil, fmt.Errorf("%w %d", errOpen, numCalls)
+		})
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, errOpen))
+		assert.Equal(t, "transient error 4", err.Error())
+		assert.Equal(t, 4, numCalls)
+	})
+	t.Run("should not retry the configuration errors", func(t *testing.T) {
+		t.Parallel()
+
+		options := newOptions(nil)
+		options.sleep = func(_ time.Duration) {
+			assert.Fail(t, "should not sleep")
+		}
+
+		numCalls := 0
+		persister, err := openWithRetry(config, options, func() (types.Persister, error) {
+			numCalls++
+			return nil, common.ErrNotSupportedDBType
+		})
+		assert.Nil(t, persister)
+		assert.Equal(t, common.ErrNotSupportedDBType, err)
+		assert.Equal(t, 1, numCalls)
+	})
+}
+
+func TestNewDB_ShouldRetryTheFailedOpenings(t *testing.T) {
+	t.Parallel()
+
+	// a file found instead of the database directory makes the opening fail until it is removed
+	path := filepath.Join(t.TempDir(), "db")
+	require.Nil(t, os.WriteFile(path, []byte("not a db"), 0600))
+
+	argDB := ArgDB{
+		DBType:            common.LvlDBSerial,
+		Path:              path,
+		BatchDelaySeconds: 10,
+		MaxBatchSize:      10,
+		MaxOpenFiles:      10,
+		OpenRetry: OpenRetryConfig{
+			MaxAttempts:                3,
+			InitialBackoffMilliseconds: 1,
+		},
+	}
+	numRetries := 0
+	persister, err := NewDB(argDB, WithOpenRetryHandler(func(_ int, _ error) {
+		numRetries++
+		_ = os.Remove(path)
+	}))
+	require.Nil(t, err)
+	assert.Equal(t, 1, numRetries)
+	assert.Nil(t, persister.Close())
+}
@@ -0,0 +1,116 @@
+package factory
+
+import (
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var log = logger.GetOrCreate("storage/factory")
+
+// Option customizes the components created by the factory
+type Option func(options *options)
+
+type options struct {
+	log           logger.Logger
+	monitor       types.StorageMonitor
+	onOpenRetry   func(attempt int, err error)
+	sleep         func(duration time.Duration)
+	encryptionKey []byte
+	configMode    common.ConfigMode
+	bloomFilter   *bloom.Config
+	writePolicy   *common.WritePolicyConfig
+	formatVersion byte
+	migrations    map[byte]decorators.Migration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		log:         log,
+		monitor:     &monitoring.GlobalMonitor{},
+		onOpenRetry: func(_ int, _ error) {},
+		sleep:       time.Sleep,
+		configMode:  common.StrictConfig,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithLogger sets the logger used instead of the package level one
+func WithLogger(logger logger.Logger) Option {
+	return func(options *options) {
+		if !check.IfNil(logger) {
+			options.log = logger
+		}
+	}
+}
+
+// WithMonitor sets the component receiving the metrics of the created caches and persisters, instead of the
+// package level monitoring functions
+func WithMonitor(monitor types.StorageMonitor) Option {
+	return func(options *options) {
+		if !check.IfNil(monitor) {
+			options.monitor = monitor
+		}
+	}
+}
+
+// WithOpenRetryHandler sets the handler called with the failed attempt number and its error, each time a failed
+// persister opening is about to be retried
+func WithOpenRetryHandler(handler func(attempt int, err error)) Option {
+	return func(options *options) {
+		if handler != nil {
+			options.onOpenRetry = handler
+		}
+	}
+}
+
+// WithEncryptionKey sets the key of the encryption decorator
+func WithEncryptionKey(key []byte) Option {
+	return func(options *options) {
+		options.encryptionKey = key
+	}
+}
+
+// WithConfigMode sets the way the out of range config values are handled: the strict mode (the default one) rejects
+// them, while the lenient mode clamps them to the nearest valid values, emitting a warning for each of them
+func WithConfigMode(mode common.ConfigMode) Option {
+	return func(options *options) {
+		if mode == common.StrictConfig || mode == common.LenientConfig {
+			options.configMode = mode
+		}
+	}
+}
+
+// WithBloomFilter makes the created storage units keep a bloom filter of their persisted keys, sized by the provided
+// config, so that the reads of the missing keys skip the persister
+func WithBloomFilter(config bloom.Config) Option {
+	return func(options *options) {
+		options.bloomFilter = &config
+	}
+}
+
+// WithWritePolicy sets the write policy of the created storage units, see storageUnit.Unit.SetWritePolicy
+func WithWritePolicy(config common.WritePolicyConfig) Option {
+	return func(options *options) {
+		options.writePolicy = &config
+	}
+}
+
+// WithFormatVersion sets the current format version of the versioning decorator, along with the migrations upgrading
+// the values stored with the older versions, keyed by the version they upgrade the values to
+func WithFormatVersion(version byte, migrations map[byte]decorators.Migration) Option {
+	return func(options *options) {
+		options.formatVersion = version
+		options.migrations = migrations
+	}
+}
@@ -0,0 +1,171 @@
+package factory_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMonitor(t *testing.T) {
+	t.Parallel()
+
+	newCaches := make(map[string]uint64)
+	newDBs := make(map[string]common.DBType)
+	monitor := &testscommon.StorageMonitorStub{
+		MonitorNewCacheCalled: func(name string, sizeInBytes uint64) {
+			newCaches[name] = sizeInBytes
+		},
+		MonitorNewDBCalled: func(dbType string, path string) {
+			newDBs[path] = common.DBType(dbType)
+		},
+	}
+
+	cacher, err := factory.NewCache(common.CacheConfig{
+		Name:        "headers",
+		Type:        common.SizeLRUCache,
+		Capacity:    10,
+		SizeInBytes: 2048,
+	}, factory.WithMonitor(monitor))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]uint64{"headers": 2048}, newCaches)
+	_ = cacher.Close()
+
+	path := t.TempDir()
+	persister, err := factory.NewDB(factory.ArgDB{
+		DBType:  common.ShardedDB,
+		Path:    path,
+		Sharded: factory.ShardedDBOptions{NumShards: 2, ShardIDProviderType: common.BinarySplit, BaseDBType: common.MemoryDB},
+	}, factory.WithMonitor(monitor))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]common.DBType{path: common.ShardedDB}, newDBs)
+	_ = persister.Close()
+
+	_, err = factory.NewDB(factory.ArgDB{DBType: "unknown"}, factory.WithMonitor(monitor))
+	assert.NotNil(t, err)
+	assert.Len(t, newDBs, 1)
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	mut := sync.Mutex{}
+	messages := make([]string, 0)
+	log := &testscommon.LoggerStub{
+		LogCalled: func(logLevel logger.LogLevel, message string, args ...interface{}) {
+			mut.Lock()
+			messages = append(messages, message)
+			mut.Unlock()
+		},
+	}
+
+	// a file found instead of the database directory makes the first opening fail
+	path := filepath.Join(t.TempDir(), "db")
+	require.Nil(t, os.WriteFile(path, []byte("not a db"), 0600))
+
+	persister, err := factory.NewDB(factory.ArgDB{
+		DBType:            common.LvlDBSerial,
+		Path:              path,
+		BatchDelaySeconds: 10,
+		MaxBatchSize:      10,
+		MaxOpenFiles:      10,
+		OpenRetry: factory.OpenRetryConfig{
+			MaxAttempts:                2,
+			InitialBackoffMilliseconds: 1,
+		},
+	},
+		factory.WithLogger(log),
+		factory.WithOpenRetryHandler(func(_ int, _ error) {
+			_ = os.Remove(path)
+		}),
+	)
+	require.Nil(t, err)
+	_ = persister.Close()
+
+	mut.Lock()
+	assert.Equal(t, []string{"error opening persister, retrying"}, messages)
+	mut.Unlock()
+}
+
+func TestOptions_NilValuesShouldBeIgnored(t *testing.T) {
+	t.Parallel()
+
+	persister, err := factory.NewDB(
+		factory.ArgDB{DBType: common.MemoryDB},
+		factory.WithLogger(nil),
+		factory.WithMonitor(nil),
+		factory.WithOpenRetryHandler(nil),
+	)
+	assert.Nil(t, err)
+	assert.NotNil(t, persister)
+}
+
+func TestWithConfigMode(t *testing.T) {
+	t.Parallel()
+
+	cacheConfig := common.CacheConfig{
+		Type:        common.SizeLRUCache,
+		Capacity:    10,
+		SizeInBytes: 10,
+	}
+
+	t.Run("strict mode should reject the out of range values", func(t *testing.T) {
+		t.Parallel()
+
+		cacher, err := factory.NewCache(cacheConfig, factory.WithConfigMode(common.StrictConfig))
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrLRUCacheInvalidSize))
+
+		unit, err := factory.NewStorageUnit(
+			common.CacheConfig{Type: common.LRUCache, Capacity: 10},
+			factory.ArgDB{DBType: common.MemoryDB, MaxBatchSize: 100},
+		)
+		assert.Nil(t, unit)
+		assert.Equal(t, common.ErrCacheSizeIsLowerThanBatchSize, err)
+	})
+	t.Run("lenient mode should clamp the out of range values", func(t *testing.T) {
+		t.Parallel()
+
+		mut := sync.Mutex{}
+		warnings := make([]string, 0)
+		log := &testscommon.LoggerStub{
+			LogCalled: func(logLevel logger.LogLevel, message string, args ...interface{}) {
+				mut.Lock()
+				defer mut.Unlock()
+
+				if logLevel == logger.LogWarning {
+					warnings = append(warnings, fmt.Sprintf("%v", args[len(args)-1]))
+				}
+			},
+		}
+		lenientOptions := []factory.Option{factory.WithConfigMode(common.LenientConfig), factory.WithLogger(log)}
+
+		cacher, err := factory.NewCache(cacheConfig, lenientOptions...)
+		require.Nil(t, err)
+		assert.Equal(t, 10, cacher.MaxSize())
+
+		unit, err := factory.NewStorageUnit(
+			common.CacheConfig{Type: common.LRUCache, Capacity: 10},
+			factory.ArgDB{DBType: common.MemoryDB, MaxBatchSize: 100},
+			lenientOptions...,
+		)
+		require.Nil(t, err)
+		_ = unit.Close()
+
+		mut.Lock()
+		assert.Equal(t, []string{
+			"SizeInBytes changed from 10 to 1024",
+			"MaxBatchSize changed from 100 to the cache capacity 10",
+		}, warnings)
+		mut.Unlock()
+	})
+}
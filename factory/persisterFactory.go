@@ -0,0 +1,34 @@
+package factory
+
+import (
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.PersisterFactory = (*PersisterFactory)(nil)
+
+// PersisterFactory creates persisters sharing the same arguments, except for the path
+type PersisterFactory struct {
+	argDB ArgDB
+	opts  []Option
+}
+
+// NewPersisterFactory creates a new PersisterFactory, the path of the provided arguments being ignored
+func NewPersisterFactory(argDB ArgDB, opts ...Option) *PersisterFactory {
+	return &PersisterFactory{
+		argDB: argDB,
+		opts:  opts,
+	}
+}
+
+// Create creates a new persister rooted in the provided path
+func (pf *PersisterFactory) Create(path string) (types.Persister, error) {
+	argDB := pf.argDB
+	argDB.Path = path
+
+	return NewDB(argDB, pf.opts...)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pf *PersisterFactory) IsInterfaceNil() bool {
+	return pf == nil
+}
@@ -0,0 +1,33 @@
+package factory_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersisterFactory_Create(t *testing.T) {
+	t.Parallel()
+
+	argDB := factory.ArgDB{
+		DBType:            common.LvlDBSerial,
+		Path:              "ignored",
+		BatchDelaySeconds: 1,
+		MaxBatchSize:      10,
+		MaxOpenFiles:      10,
+	}
+	pf := factory.NewPersisterFactory(argDB)
+	assert.False(t, pf.IsInterfaceNil())
+
+	path := filepath.Join(t.TempDir(), "Epoch_0")
+	persister, err := pf.Create(path)
+	require.Nil(t, err)
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+	assert.Nil(t, persister.Close())
+}
@@ -0,0 +1,165 @@
+package factory
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const probeDirPattern = ".probe_*"
+
+var (
+	probeKey   = []byte("probe key")
+	probeValue = []byte("probe value")
+)
+
+// ProbeDB checks that the persisters described by the provided arguments can be used, by creating a temporary DB
+// next to the configured path and performing a write, read, sync (close and reopen) and remove cycle on it. The
+// latency of each step is logged and the temporary DB is destroyed afterwards. It is meant to be called on the node
+// startup, so that unusable disks or wrong permissions are reported before any component is created
+func ProbeDB(argDB ArgDB, opts ...Option) error {
+	o := newOptions(opts)
+	argDB.ApplyDefaults()
+
+	dbType := argDB.baseDBType()
+	switch {
+	case dbType == common.MemoryDB:
+		return nil
+	case dbType == common.LvlDBReadOnly:
+		_, err := os.Stat(argDB.Path)
+		if err != nil {
+			return fmt.Errorf("%w while probing the read only persister", err)
+		}
+		return nil
+	case !isLevelDBType(dbType):
+		return fmt.Errorf("%w: DBType %q", common.ErrNotSupportedDBType, dbType)
+	}
+	if len(argDB.Path) == 0 {
+		return fmt.Errorf("%w: Path is required for %s", common.ErrInvalidConfig, argDB.DBType)
+	}
+
+	parentDir := filepath.Dir(argDB.Path)
+	err := os.MkdirAll(parentDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("%w while creating the probe parent directory", err)
+	}
+	probeDir, err := os.MkdirTemp(parentDir, probeDirPattern)
+	if err != nil {
+		return fmt.Errorf("%w while creating the probe directory", err)
+	}
+	defer func() {
+		errRemove := os.RemoveAll(probeDir)
+		if errRemove != nil {
+			o.log.Warn("ProbeDB: could not remove the probe directory", "path", probeDir, "error", errRemove)
+		}
+	}()
+
+	probeArg := ArgDB{
+		DBType:            dbType,
+		Path:              probeDir,
+		BatchDelaySeconds: argDB.BatchDelaySeconds,
+		MaxBatchSize:      argDB.MaxBatchSize,
+		MaxOpenFiles:      argDB.MaxOpenFiles,
+	}
+	latencies, err := runProbe(probeArg)
+	if err != nil {
+		return fmt.Errorf("%w while probing %s", err, argDB.Path)
+	}
+
+	o.log.Info("ProbeDB: persister usable", "path", argDB.Path, "db type", dbType,
+		"write", latencies[0], "read", latencies[1], "sync", latencies[2], "remove", latencies[3])
+
+	return nil
+}
+
+// runProbe performs the probe cycle on a new persister, returning the latencies of the write, read, sync and
+// remove steps
+func runProbe(argDB ArgDB) ([]time.Duration, error) {
+	persister, err := newDB(argDB)
+	if err != nil {
+		return nil, err
+	}
+
+	latencies := make([]time.Duration, 0, 4)
+	timed := func(step func() error) error {
+		start := time.Now()
+		errStep := step()
+		latencies = append(latencies, time.Since(start))
+		return errStep
+	}
+
+	err = timed(func() error {
+		return persister.Put(probeKey, probeValue)
+	})
+	if err != nil {
+		_ = persister.Destroy()
+		return nil, fmt.Errorf("%w on write", err)
+	}
+
+	err = timed(func() error {
+		return checkProbeValue(persister)
+	})
+	if err != nil {
+		_ = persister.Destroy()
+		return nil, fmt.Errorf("%w on read", err)
+	}
+
+	err = timed(func() error {
+		errClose := persister.Close()
+		if errClose != nil {
+			return errClose
+		}
+
+		persister, errClose = newDB(argDB)
+		if errClose != nil {
+			return errClose
+		}
+
+		return checkProbeValue(persister)
+	})
+	if err != nil {
+		if !check.IfNil(persister) {
+			_ = persister.Destroy()
+		}
+		return nil, fmt.Errorf("%w on sync", err)
+	}
+
+	err = timed(func() error {
+		errRemove := persister.Remove(probeKey)
+		if errRemove != nil {
+			return errRemove
+		}
+		if persister.Has(probeKey) == nil {
+			return fmt.Errorf("%w: key still present after removal", common.ErrProbeFailed)
+		}
+
+		return nil
+	})
+	errDestroy := persister.Destroy()
+	if err != nil {
+		return nil, fmt.Errorf("%w on remove", err)
+	}
+	if errDestroy != nil {
+		return nil, fmt.Errorf("%w on destroy", errDestroy)
+	}
+
+	return latencies, nil
+}
+
+func checkProbeValue(persister types.Persister) error {
+	val, err := persister.Get(probeKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(val, probeValue) {
+		return fmt.Errorf("%w: read value differs from the written one", common.ErrProbeFailed)
+	}
+
+	return nil
+}
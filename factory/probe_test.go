@@ -0,0 +1,74 @@
+package factory_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeDB(t *testing.T) {
+	t.Parallel()
+
+	t.Run("memory persister should not probe", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, factory.ProbeDB(factory.ArgDB{DBType: common.MemoryDB}))
+	})
+	t.Run("unknown db type should error", func(t *testing.T) {
+		t.Parallel()
+
+		err := factory.ProbeDB(factory.ArgDB{DBType: "NotLvlDB", Path: t.TempDir()})
+		assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+	})
+	t.Run("missing path should error", func(t *testing.T) {
+		t.Parallel()
+
+		err := factory.ProbeDB(factory.ArgDB{DBType: common.LvlDBSerial})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("missing read only persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		err := factory.ProbeDB(factory.ArgDB{DBType: common.LvlDBReadOnly, Path: filepath.Join(t.TempDir(), "missing")})
+		assert.NotNil(t, err)
+	})
+	t.Run("unusable directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "file")
+		require.Nil(t, os.WriteFile(file, []byte("data"), 0600))
+
+		err := factory.ProbeDB(createLevelDBArgs(filepath.Join(file, "Blocks")))
+		assert.NotNil(t, err)
+	})
+	t.Run("LevelDB persister should work and leave nothing behind", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		for _, dbType := range []common.DBType{common.LvlDB, common.LvlDBSerial} {
+			args := createLevelDBArgs(filepath.Join(dir, "Blocks"))
+			args.DBType = dbType
+			require.Nil(t, factory.ProbeDB(args))
+		}
+
+		entries, err := os.ReadDir(dir)
+		require.Nil(t, err)
+		assert.Empty(t, entries)
+	})
+	t.Run("sharded persister should probe its base persisters type", func(t *testing.T) {
+		t.Parallel()
+
+		args := factory.ArgDB{
+			DBType:  common.ShardedDB,
+			Path:    filepath.Join(t.TempDir(), "Blocks"),
+			Sharded: factory.ShardedDBOptions{NumShards: 2},
+		}
+		assert.Nil(t, factory.ProbeDB(args))
+	})
+}
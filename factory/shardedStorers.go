@@ -0,0 +1,170 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const metachainShardName = "metachain"
+
+// ShardedUnitConfig holds the configuration of a storage unit of a node
+type ShardedUnitConfig struct {
+	Name  string
+	Cache common.CacheConfig
+	DB    ArgDB
+	// AllShards creates a storer for each shard, including the metachain, instead of a single storer for the self shard
+	AllShards bool
+}
+
+// ArgShardedStorers is the argument used to create the storers of a node
+type ArgShardedStorers struct {
+	BasePath    string
+	NumShards   uint32
+	SelfShardID uint32
+	Units       []ShardedUnitConfig
+}
+
+// ShardDirectoryName returns the name of the directory holding the storers of the provided shard
+func ShardDirectoryName(shardID uint32) string {
+	return "Shard_" + shardName(shardID)
+}
+
+// ShardedStorerName returns the name of the storer of the provided unit and shard. The storers of the units not
+// created for all shards are named after their unit
+func ShardedStorerName(unitName string, shardID uint32) string {
+	return fmt.Sprintf("%s_%s", unitName, shardName(shardID))
+}
+
+func shardName(shardID uint32) string {
+	if shardID == core.MetachainShardId {
+		return metachainShardName
+	}
+
+	return fmt.Sprintf("%d", shardID)
+}
+
+// NewShardedStorers creates the storers of a node, keyed by their names: the storer of a unit created for the self
+// shard only is named after its unit, while the ones of a unit created for all shards are named by ShardedStorerName.
+// The persisters are stored under <BasePath>/Shard_<self shard>/<storer name>
+func NewShardedStorers(args ArgShardedStorers, opts ...Option) (map[string]types.Storer, error) {
+	err := checkArgShardedStorers(args)
+	if err != nil {
+		return nil, err
+	}
+
+	shardDirectory := filepath.Join(args.BasePath, ShardDirectoryName(args.SelfShardID))
+	storers := make(map[string]types.Storer)
+	for _, unit := range args.Units {
+		names := []string{unit.Name}
+		if unit.AllShards {
+			names = allShardsStorerNames(unit.Name, args.NumShards)
+		}
+
+		for _, name := range names {
+			storer, errCreate := newShardedStorer(unit, name, filepath.Join(shardDirectory, name), opts)
+			if errCreate != nil {
+				closeStorers(storers)
+				return nil, fmt.Errorf("%w for the storer %s", errCreate, name)
+			}
+			storers[name] = storer
+		}
+	}
+
+	return storers, nil
+}
+
+func checkArgShardedStorers(args ArgShardedStorers) error {
+	if args.NumShards == 0 {
+		return common.ErrInvalidNumberOfShards
+	}
+	if args.SelfShardID >= args.NumShards && args.SelfShardID != core.MetachainShardId {
+		return fmt.Errorf("%w: self shard %d, number of shards %d", common.ErrInvalidConfig, args.SelfShardID, args.NumShards)
+	}
+
+	return errors.Join(validateUnitNames(args.Units)...)
+}
+
+func validateUnitNames(units []ShardedUnitConfig) []error {
+	errs := make([]error, 0)
+	unitNames := make(map[string]struct{}, len(units))
+	for _, unit := range units {
+		if len(unit.Name) == 0 {
+			errs = append(errs, fmt.Errorf("%w: unit with empty name", common.ErrInvalidConfig))
+			continue
+		}
+		_, isDuplicate := unitNames[unit.Name]
+		if isDuplicate {
+			errs = append(errs, fmt.Errorf("%w: unit %s is configured more than once", common.ErrInvalidConfig, unit.Name))
+		}
+		unitNames[unit.Name] = struct{}{}
+	}
+
+	return errs
+}
+
+// Validate checks all the elements of the arguments, including the configs of the units, returning an aggregated error
+// which names every invalid element
+func (args *ArgShardedStorers) Validate() error {
+	validator := common.ConfigValidator{}
+	validator.Check(len(args.BasePath) > 0, common.ErrInvalidConfig, "BasePath is required")
+	validator.Check(args.NumShards > 0, common.ErrInvalidNumberOfShards, "NumShards should be positive")
+	validator.Check(args.NumShards == 0 || args.SelfShardID < args.NumShards || args.SelfShardID == core.MetachainShardId,
+		common.ErrInvalidConfig, "SelfShardID is %d, should be lower than NumShards %d or the metachain", args.SelfShardID, args.NumShards)
+	validator.Add(validateUnitNames(args.Units)...)
+	for _, unit := range args.Units {
+		validator.AddNested(unit.Validate(), "for the unit "+unit.Name)
+	}
+
+	return validator.Err()
+}
+
+// Validate checks the cache and db configs of the unit, returning an aggregated error which names every invalid
+// element. The db path and the cache name are not checked, as they are filled by NewShardedStorers
+func (unit *ShardedUnitConfig) Validate() error {
+	cacheConfig := unit.Cache
+	if len(cacheConfig.Name) == 0 {
+		cacheConfig.Name = unit.Name
+	}
+	argDB := unit.DB
+	argDB.Path = filepath.Join(ShardDirectoryName(0), unit.Name)
+
+	validator := common.ConfigValidator{}
+	validator.AddNested(cacheConfig.Validate(), "for the cache")
+	validator.AddNested(argDB.Validate(), "for the db")
+
+	return validator.Err()
+}
+
+func allShardsStorerNames(unitName string, numShards uint32) []string {
+	names := make([]string, 0, numShards+1)
+	for shardID := uint32(0); shardID < numShards; shardID++ {
+		names = append(names, ShardedStorerName(unitName, shardID))
+	}
+
+	return append(names, ShardedStorerName(unitName, core.MetachainShardId))
+}
+
+func newShardedStorer(unit ShardedUnitConfig, name string, path string, opts []Option) (types.Storer, error) {
+	cacheConfig := unit.Cache
+	if len(cacheConfig.Name) == 0 {
+		cacheConfig.Name = name
+	}
+	argDB := unit.DB
+	argDB.Path = path
+
+	return NewStorageUnit(cacheConfig, argDB, opts...)
+}
+
+func closeStorers(storers map[string]types.Storer) {
+	for name, storer := range storers {
+		err := storer.Close()
+		if err != nil {
+			log.Warn("error closing storer", "name", name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package factory_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createShardedUnitConfig(name string, allShards bool) factory.ShardedUnitConfig {
+	return factory.ShardedUnitConfig{
+		Name:  name,
+		Cache: common.CacheConfig{Type: common.LRUCache, Capacity: 10},
+		DB: factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			BatchDelaySeconds: 10,
+			MaxBatchSize:      1,
+			MaxOpenFiles:      10,
+		},
+		AllShards: allShards,
+	}
+}
+
+func TestShardedStorerNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Shard_1", factory.ShardDirectoryName(1))
+	assert.Equal(t, "Shard_metachain", factory.ShardDirectoryName(core.MetachainShardId))
+	assert.Equal(t, "MiniBlocks_0", factory.ShardedStorerName("MiniBlocks", 0))
+	assert.Equal(t, "MiniBlocks_metachain", factory.ShardedStorerName("MiniBlocks", core.MetachainShardId))
+}
+
+func TestNewShardedStorers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid shards should error", func(t *testing.T) {
+		t.Parallel()
+
+		storers, err := factory.NewShardedStorers(factory.ArgShardedStorers{BasePath: t.TempDir()})
+		assert.Nil(t, storers)
+		assert.Equal(t, common.ErrInvalidNumberOfShards, err)
+
+		storers, err = factory.NewShardedStorers(factory.ArgShardedStorers{BasePath: t.TempDir(), NumShards: 2, SelfShardID: 2})
+		assert.Nil(t, storers)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("duplicated units should error", func(t *testing.T) {
+		t.Parallel()
+
+		storers, err := factory.NewShardedStorers(factory.ArgShardedStorers{
+			BasePath:  t.TempDir(),
+			NumShards: 2,
+			Units:     []factory.ShardedUnitConfig{createShardedUnitConfig("Blocks", false), createShardedUnitConfig("Blocks", true)},
+		})
+		assert.Nil(t, storers)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("should create the storers of each shard", func(t *testing.T) {
+		t.Parallel()
+
+		basePath := t.TempDir()
+		storers, err := factory.NewShardedStorers(factory.ArgShardedStorers{
+			BasePath:    basePath,
+			NumShards:   2,
+			SelfShardID: core.MetachainShardId,
+			Units:       []factory.ShardedUnitConfig{createShardedUnitConfig("Blocks", false), createShardedUnitConfig("MiniBlocks", true)},
+		})
+		require.Nil(t, err)
+
+		expectedNames := []string{"Blocks", "MiniBlocks_0", "MiniBlocks_1", "MiniBlocks_metachain"}
+		assert.Len(t, storers, len(expectedNames))
+		for _, name := range expectedNames {
+			storer, ok := storers[name]
+			require.True(t, ok, name)
+			assert.Nil(t, storer.Put([]byte("key"), []byte(name)))
+
+			_, err = os.Stat(filepath.Join(basePath, "Shard_metachain", name))
+			assert.Nil(t, err, name)
+		}
+
+		for _, storer := range storers {
+			assert.Nil(t, storer.Close())
+		}
+	})
+	t.Run("failing unit should close the created storers", func(t *testing.T) {
+		t.Parallel()
+
+		invalidUnit := createShardedUnitConfig("Invalid", false)
+		invalidUnit.DB.DBType = "unknown"
+		basePath := t.TempDir()
+		storers, err := factory.NewShardedStorers(factory.ArgShardedStorers{
+			BasePath:  basePath,
+			NumShards: 1,
+			Units:     []factory.ShardedUnitConfig{createShardedUnitConfig("Blocks", false), invalidUnit},
+		})
+		assert.Nil(t, storers)
+		assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+		assert.Contains(t, err.Error(), "Invalid")
+
+		storers, err = factory.NewShardedStorers(factory.ArgShardedStorers{
+			BasePath:  basePath,
+			NumShards: 1,
+			Units:     []factory.ShardedUnitConfig{createShardedUnitConfig("Blocks", false)},
+		})
+		require.Nil(t, err, "the Blocks persister should have been released")
+		assert.Nil(t, storers["Blocks"].Close())
+	})
+}
+
+func TestArgShardedStorers_Validate(t *testing.T) {
+	t.Parallel()
+
+	args := factory.ArgShardedStorers{
+		BasePath:    t.TempDir(),
+		NumShards:   2,
+		SelfShardID: core.MetachainShardId,
+		Units:       []factory.ShardedUnitConfig{createShardedUnitConfig("Blocks", false), createShardedUnitConfig("Txs", true)},
+	}
+	require.Nil(t, args.Validate())
+
+	invalidUnit := createShardedUnitConfig("Txs", false)
+	invalidUnit.Cache.Capacity = 0
+	invalidUnit.DB.MaxOpenFiles = 0
+	args = factory.ArgShardedStorers{
+		NumShards:   2,
+		SelfShardID: 2,
+		Units:       []factory.ShardedUnitConfig{createShardedUnitConfig("Txs", false), invalidUnit},
+	}
+	err := args.Validate()
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+	assert.True(t, errors.Is(err, common.ErrInvalidNumOpenFiles))
+	assert.Contains(t, err.Error(), "BasePath")
+	assert.Contains(t, err.Error(), "SelfShardID")
+	assert.Contains(t, err.Error(), "unit Txs is configured more than once")
+	assert.Contains(t, err.Error(), "Capacity should be positive for the cache for the unit Txs")
+	assert.Contains(t, err.Error(), "MaxOpenFiles should be positive for the db for the unit Txs")
+	assert.NotContains(t, err.Error(), "Path is required for")
+
+	args = factory.ArgShardedStorers{BasePath: "base"}
+	assert.True(t, errors.Is(args.Validate(), common.ErrInvalidNumberOfShards))
+}
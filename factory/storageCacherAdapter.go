@@ -0,0 +1,150 @@
+package factory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/marshal"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageCacherAdapter"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var (
+	mutStoredDataFactories sync.RWMutex
+	storedDataFactories    = make(map[string]types.StoredDataFactory)
+)
+
+// RegisterStoredDataFactory registers the factory under the provided name, so that the storage cacher adapters
+// declared in the configuration can refer to it
+func RegisterStoredDataFactory(name string, storedDataFactory types.StoredDataFactory) error {
+	if len(name) == 0 {
+		return fmt.Errorf("%w: the stored data factory name should not be empty", common.ErrInvalidConfig)
+	}
+	if check.IfNil(storedDataFactory) {
+		return common.ErrNilStoredDataFactory
+	}
+
+	mutStoredDataFactories.Lock()
+	defer mutStoredDataFactories.Unlock()
+
+	_, exists := storedDataFactories[name]
+	if exists {
+		return fmt.Errorf("%w: the stored data factory %q is already registered", common.ErrInvalidConfig, name)
+	}
+	storedDataFactories[name] = storedDataFactory
+
+	return nil
+}
+
+// UnregisterStoredDataFactory removes the factory registered under the provided name, if any
+func UnregisterStoredDataFactory(name string) {
+	mutStoredDataFactories.Lock()
+	delete(storedDataFactories, name)
+	mutStoredDataFactories.Unlock()
+}
+
+func getStoredDataFactory(name string) (types.StoredDataFactory, error) {
+	mutStoredDataFactories.RLock()
+	defer mutStoredDataFactories.RUnlock()
+
+	storedDataFactory, ok := storedDataFactories[name]
+	if ok {
+		return storedDataFactory, nil
+	}
+
+	names := make([]string, 0, len(storedDataFactories))
+	for registered := range storedDataFactories {
+		names = append(names, registered)
+	}
+	sort.Strings(names)
+
+	return nil, fmt.Errorf("%w: unknown stored data factory %q, registered factories: %v", common.ErrInvalidConfig, name, names)
+}
+
+// ArgStorageCacherAdapter holds the arguments of NewStorageCacherAdapter. The cache should be of the SizeLRU type,
+// as the adapter persists the values evicted by a size bounded LRU cache
+type ArgStorageCacherAdapter struct {
+	CacheConfig common.CacheConfig
+	ArgDB       ArgDB
+	// StoredDataFactoryName names the factory registered with RegisterStoredDataFactory, creating the objects read back
+	// from the persister
+	StoredDataFactoryName string
+	Marshalizer           marshal.Marshalizer
+	// CodecRegistry is optional, letting the adapter read the values persisted with the older format versions
+	CodecRegistry *types.CodecRegistry
+}
+
+// NewStorageCacherAdapterFromConf creates a new storage cacher adapter from a storage unit config and the name of a
+// registered stored data factory
+func NewStorageCacherAdapterFromConf(
+	cacheConf common.CacheConfig,
+	dbConf common.DBConfig,
+	storedDataFactoryName string,
+	marshalizer marshal.Marshalizer,
+	opts ...Option,
+) (types.Cacher, error) {
+	return NewStorageCacherAdapter(ArgStorageCacherAdapter{
+		CacheConfig:           cacheConf,
+		ArgDB:                 argDBFromConf(dbConf),
+		StoredDataFactoryName: storedDataFactoryName,
+		Marshalizer:           marshalizer,
+	}, opts...)
+}
+
+// NewStorageCacherAdapter creates a new storage cacher adapter, holding a size bounded LRU cache whose evicted values
+// are written to the persister, so that the trie node storage can be declared in the configuration
+func NewStorageCacherAdapter(args ArgStorageCacherAdapter, opts ...Option) (types.Cacher, error) {
+	o := newOptions(opts)
+	cacheConf := args.CacheConfig
+	if o.configMode == common.LenientConfig {
+		for _, adjustment := range cacheConf.Clamp() {
+			o.log.Warn("NewStorageCacherAdapter: out of range config value clamped", "name", cacheConf.Name, "adjustment", adjustment)
+		}
+	}
+	if cacheConf.Type != common.SizeLRUCache {
+		return nil, fmt.Errorf("%w: the storage cacher adapter requires the %s cache type, not %q",
+			common.ErrNotSupportedCacheType, common.SizeLRUCache, cacheConf.Type)
+	}
+	err := cacheConf.Validate()
+	if err != nil {
+		return nil, err
+	}
+	storedDataFactory, err := getStoredDataFactory(args.StoredDataFactoryName)
+	if err != nil {
+		return nil, err
+	}
+	if check.IfNil(args.Marshalizer) {
+		return nil, common.ErrNilMarshalizer
+	}
+
+	cacher, err := capacity.NewCapacityLRU(int(cacheConf.Capacity), int64(cacheConf.SizeInBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := NewDB(args.ArgDB, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter, err := storageCacherAdapter.NewStorageCacherAdapterWithArgs(storageCacherAdapter.ArgsStorageCacherAdapter{
+		Name:              cacheConf.Name,
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: storedDataFactory,
+		Marshalizer:       args.Marshalizer,
+		RestoreMode:       storageCacherAdapter.RestoreCountByRangeKeys,
+		CodecRegistry:     args.CodecRegistry,
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	registerCallbacks(adapter, cacheConf)
+
+	return adapter, nil
+}
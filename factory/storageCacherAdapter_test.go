@@ -0,0 +1,120 @@
+package factory_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgStorageCacherAdapter(t *testing.T, storedDataFactoryName string) factory.ArgStorageCacherAdapter {
+	return factory.ArgStorageCacherAdapter{
+		CacheConfig: common.CacheConfig{
+			Name:        "trieNodes",
+			Type:        common.SizeLRUCache,
+			Capacity:    2,
+			SizeInBytes: 4096,
+		},
+		ArgDB: factory.ArgDB{
+			DBType:            common.LvlDBSerial,
+			Path:              t.TempDir(),
+			BatchDelaySeconds: 1,
+			MaxBatchSize:      1,
+			MaxOpenFiles:      10,
+		},
+		StoredDataFactoryName: storedDataFactoryName,
+		Marshalizer:           &testscommon.MarshalizerMock{},
+	}
+}
+
+func TestRegisterStoredDataFactory(t *testing.T) {
+	t.Parallel()
+
+	err := factory.RegisterStoredDataFactory("", trieFactory.NewSerializedDataFactory())
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	err = factory.RegisterStoredDataFactory("registerTest", nil)
+	assert.Equal(t, common.ErrNilStoredDataFactory, err)
+
+	err = factory.RegisterStoredDataFactory("registerTest", trieFactory.NewSerializedDataFactory())
+	assert.Nil(t, err)
+	err = factory.RegisterStoredDataFactory("registerTest", trieFactory.NewSerializedDataFactory())
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	factory.UnregisterStoredDataFactory("registerTest")
+	err = factory.RegisterStoredDataFactory("registerTest", trieFactory.NewSerializedDataFactory())
+	assert.Nil(t, err)
+	factory.UnregisterStoredDataFactory("registerTest")
+}
+
+func TestNewStorageCacherAdapter(t *testing.T) {
+	t.Parallel()
+
+	name := "newStorageCacherAdapterTest"
+	err := factory.RegisterStoredDataFactory(name, trieFactory.NewSerializedDataFactory())
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		factory.UnregisterStoredDataFactory(name)
+	})
+
+	t.Run("unknown stored data factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		cacher, err := factory.NewStorageCacherAdapter(createArgStorageCacherAdapter(t, "missing"))
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), name)
+	})
+	t.Run("unsupported cache type should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgStorageCacherAdapter(t, name)
+		args.CacheConfig.Type = common.LRUCache
+		cacher, err := factory.NewStorageCacherAdapter(args)
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrNotSupportedCacheType))
+	})
+	t.Run("nil marshalizer should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgStorageCacherAdapter(t, name)
+		args.Marshalizer = nil
+		cacher, err := factory.NewStorageCacherAdapter(args)
+		assert.Nil(t, cacher)
+		assert.Equal(t, common.ErrNilMarshalizer, err)
+	})
+	t.Run("should persist the evicted values", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgStorageCacherAdapter(t, name)
+		cacher, err := factory.NewStorageCacherAdapter(args)
+		require.Nil(t, err)
+
+		for _, key := range []string{"a", "b", "c", "d"} {
+			_ = cacher.Put([]byte(key), &trieFactory.SerializedStoredData{Serialized: []byte("value-" + key)}, 100)
+		}
+		require.Nil(t, cacher.Close())
+
+		// reopened from the same config, the values are read back from the persister
+		cacher, err = factory.NewStorageCacherAdapterFromConf(args.CacheConfig, common.DBConfig{
+			FilePath:          args.ArgDB.Path,
+			Type:              args.ArgDB.DBType,
+			BatchDelaySeconds: args.ArgDB.BatchDelaySeconds,
+			MaxBatchSize:      args.ArgDB.MaxBatchSize,
+			MaxOpenFiles:      args.ArgDB.MaxOpenFiles,
+		}, name, args.Marshalizer)
+		require.Nil(t, err)
+		defer func() {
+			_ = cacher.Close()
+		}()
+
+		value, ok := cacher.Get([]byte("a"))
+		require.True(t, ok)
+		assert.Equal(t, []byte("value-a"), value.(*trieFactory.SerializedStoredData).Serialized)
+	})
+}
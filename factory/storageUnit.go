@@ -1,32 +1,75 @@
 package factory
 
 import (
+	"fmt"
+
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
 )
 
 // NewStorageUnitFromConf creates a new storage unit from a storage unit config
-func NewStorageUnitFromConf(cacheConf common.CacheConfig, dbConf common.DBConfig) (*storageUnit.Unit, error) {
-	if dbConf.MaxBatchSize > int(cacheConf.Capacity) {
-		return nil, common.ErrCacheSizeIsLowerThanBatchSize
+func NewStorageUnitFromConf(cacheConf common.CacheConfig, dbConf common.DBConfig, opts ...Option) (*storageUnit.Unit, error) {
+	return NewStorageUnit(cacheConf, argDBFromConf(dbConf), opts...)
+}
+
+func argDBFromConf(dbConf common.DBConfig) ArgDB {
+	return ArgDB{
+		DBType:                      dbConf.Type,
+		Path:                        dbConf.FilePath,
+		BatchDelaySeconds:           dbConf.BatchDelaySeconds,
+		MaxBatchSize:                dbConf.MaxBatchSize,
+		MaxOpenFiles:                dbConf.MaxOpenFiles,
+		BlockCacheSizeInBytes:       dbConf.BlockCacheSizeInBytes,
+		NumReaders:                  dbConf.NumReaders,
+		WriteAroundThresholdInBytes: dbConf.WriteAroundThresholdInBytes,
+		WriteAroundAsync:            dbConf.WriteAroundAsync,
+	}
+}
+
+// NewStorageUnit creates a new storage unit, holding a cache in front of a persister: the reads go through the cache,
+// while the writes & the removals are applied on both
+func NewStorageUnit(cacheConf common.CacheConfig, argDB ArgDB, opts ...Option) (*storageUnit.Unit, error) {
+	o := newOptions(opts)
+	if argDB.MaxBatchSize > int(cacheConf.Capacity) {
+		if o.configMode != common.LenientConfig {
+			return nil, common.ErrCacheSizeIsLowerThanBatchSize
+		}
+
+		o.log.Warn("NewStorageUnit: out of range config value clamped", "path", argDB.Path,
+			"adjustment", fmt.Sprintf("MaxBatchSize changed from %d to the cache capacity %d", argDB.MaxBatchSize, cacheConf.Capacity))
+		argDB.MaxBatchSize = int(cacheConf.Capacity)
 	}
 
-	cache, err := NewCache(cacheConf)
+	cache, err := NewCache(cacheConf, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	argDB := ArgDB{
-		DBType:            dbConf.Type,
-		Path:              dbConf.FilePath,
-		BatchDelaySeconds: dbConf.BatchDelaySeconds,
-		MaxBatchSize:      dbConf.MaxBatchSize,
-		MaxOpenFiles:      dbConf.MaxOpenFiles,
+	db, err := NewDB(argDB, opts...)
+	if err != nil {
+		_ = cache.Close()
+		return nil, err
 	}
-	db, err := NewDB(argDB)
+
+	unit, err := storageUnit.NewStorageUnit(cache, db)
 	if err != nil {
 		return nil, err
 	}
+	unit.SetName(cacheConf.Name)
+	if o.bloomFilter != nil {
+		err = unit.EnableBloomFilter(*o.bloomFilter)
+		if err != nil {
+			_ = unit.Close()
+			return nil, err
+		}
+	}
+	if o.writePolicy != nil {
+		err = unit.SetWritePolicy(*o.writePolicy)
+		if err != nil {
+			_ = unit.Close()
+			return nil, err
+		}
+	}
 
-	return storageUnit.NewStorageUnit(cache, db)
+	return unit, nil
 }
@@ -1,11 +1,14 @@
 package factory_test
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/factory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewStorageUnitFromConf_WrongCacheSizeVsBatchSize(t *testing.T) {
@@ -103,3 +106,97 @@ func TestNewStorageUnitFromConf_ShouldWorkLvlDB(t *testing.T) {
 	err = storer.DestroyUnit()
 	assert.Nil(t, err, "no error expected destroying the persister")
 }
+
+func TestNewStorageUnit_WrongConfigsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	storer, err := factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB, MaxBatchSize: 11},
+	)
+	assert.Equal(t, common.ErrCacheSizeIsLowerThanBatchSize, err)
+	assert.Nil(t, storer)
+
+	storer, err = factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: "NotLvlDB"},
+	)
+	assert.Equal(t, common.ErrNotSupportedDBType, err)
+	assert.Nil(t, storer)
+}
+
+func TestNewStorageUnit_ShouldComposeTheCacheAndThePersister(t *testing.T) {
+	t.Parallel()
+
+	storer, err := factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB},
+	)
+	assert.Nil(t, err)
+	assert.NotNil(t, storer)
+
+	key, value := []byte("key"), []byte("value")
+	assert.Nil(t, storer.Put(key, value))
+	assert.Nil(t, storer.Has(key))
+
+	storer.ClearCache()
+	retrieved, err := storer.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, value, retrieved)
+
+	assert.Nil(t, storer.Remove(key))
+	assert.NotNil(t, storer.Has(key))
+	_, err = storer.Get(key)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, storer.Close())
+}
+
+func TestNewStorageUnit_WithBloomFilter(t *testing.T) {
+	t.Parallel()
+
+	storer, err := factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB},
+		factory.WithBloomFilter(bloom.Config{ExpectedNumKeys: 10}),
+	)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Nil(t, storer)
+
+	storer, err = factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB},
+		factory.WithBloomFilter(bloom.Config{ExpectedNumKeys: 10, FalsePositiveRate: 0.01}),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+	storer.ClearCache()
+	assert.Nil(t, storer.Has([]byte("key")))
+	assert.True(t, errors.Is(storer.Has([]byte("missing")), common.ErrKeyNotFound))
+
+	assert.Nil(t, storer.Close())
+}
+
+func TestNewStorageUnit_WithWritePolicy(t *testing.T) {
+	t.Parallel()
+
+	storer, err := factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB},
+		factory.WithWritePolicy(common.WritePolicyConfig{Policy: common.CacheBypassPolicy}),
+	)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Nil(t, storer)
+
+	storer, err = factory.NewStorageUnit(
+		common.CacheConfig{Capacity: 10, Type: common.LRUCache},
+		factory.ArgDB{DBType: common.MemoryDB},
+		factory.WithWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy}),
+	)
+	require.Nil(t, err)
+	assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+	assert.Equal(t, 1, storer.NumDirty())
+	assert.Equal(t, string(common.WriteBackPolicy), storer.Stats()["writePolicy"])
+
+	assert.Nil(t, storer.Close())
+}
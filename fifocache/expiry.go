@@ -0,0 +1,73 @@
+package fifocache
+
+import (
+	"context"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// PutWithTTL adds a value to the cache, expiring ttl after being added, regardless of the time to live of the cache. A
+// non positive ttl means that the value does not expire. Returns true if an eviction occurred
+func (c *FIFOShardedCache) PutWithTTL(key []byte, value interface{}, sizeInBytes int, ttl time.Duration) (evicted bool) {
+	return c.putExpiringAt(key, value, sizeInBytes, c.expiryTime(ttl))
+}
+
+// expiryTime returns the zero time, standing for no expiry, if ttl is not positive
+func (c *FIFOShardedCache) expiryTime(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return c.clock.Now().Add(ttl)
+}
+
+// Sweep removes the expired items, notifying the eviction handlers, and returns their number. The expired items are
+// not returned by the cache even before being swept
+func (c *FIFOShardedCache) Sweep() int {
+	c.mutShards.RLock()
+	var expired []*fifoItem
+	for _, shard := range c.shards {
+		expired = append(expired, shard.removeExpired()...)
+	}
+	c.mutShards.RUnlock()
+
+	c.onExpired(expired)
+	if len(expired) > 0 {
+		log.Trace("fifocache swept", "num expired", len(expired))
+	}
+
+	return len(expired)
+}
+
+func (c *FIFOShardedCache) onExpired(expired []*fifoItem) {
+	c.numExpired.Add(int64(len(expired)))
+	c.callEvictionHandlers(expired, types.EvictionReasonExpired)
+}
+
+func (c *FIFOShardedCache) startSweeping() {
+	if c.sweepInterval <= 0 {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.cancelSweep = context.WithCancel(context.Background())
+	go c.sweepPeriodically(ctx)
+}
+
+func (c *FIFOShardedCache) sweepPeriodically(ctx context.Context) {
+	timer := time.NewTimer(c.sweepInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(c.sweepInterval)
+
+		select {
+		case <-timer.C:
+			c.Sweep()
+		case <-ctx.Done():
+			log.Debug("closing the fifocache sweep go routine")
+			return
+		}
+	}
+}
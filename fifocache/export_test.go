@@ -1,5 +1,16 @@
 package fifocache
 
-func (c *FIFOShardedCache) AddedDataHandlers() map[string]func(key []byte, value interface{}) {
-	return c.mapDataHandlers
+func (c *FIFOShardedCache) AddedDataHandlers() []string {
+	return c.addedDataHandlers.HandlerIDs()
+}
+
+func (c *FIFOShardedCache) EvictionHandlers() []string {
+	return c.evictionHandlers.HandlerIDs()
+}
+
+func (c *FIFOShardedCache) ShardIndex(key []byte) int {
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return int(fnv32(string(key)) % uint32(len(c.shards)))
 }
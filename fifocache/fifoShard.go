@@ -0,0 +1,255 @@
+package fifocache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type fifoItem struct {
+	key      string
+	value    interface{}
+	size     int
+	sequence uint64
+	// expiresAt is zero for the items which do not expire
+	expiresAt time.Time
+}
+
+func (item *fifoItem) isExpired(now time.Time) bool {
+	return !item.expiresAt.IsZero() && !now.Before(item.expiresAt)
+}
+
+// fifoShard is a bounded map which evicts its oldest entries, in insertion order, when it overflows
+type fifoShard struct {
+	mutex    sync.RWMutex
+	maxSize  int
+	items    map[string]*list.Element
+	order    *list.List
+	numBytes uint64
+	sequence *atomic.Counter
+	clock    types.Clock
+}
+
+// newFIFOShard creates a shard. The sequence counter is shared by all the shards of a cache,
+// so that the insertion order can be reconstructed across shards
+func newFIFOShard(maxSize int, sequence *atomic.Counter, clock types.Clock) *fifoShard {
+	return &fifoShard{
+		maxSize:  maxSize,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		sequence: sequence,
+		clock:    clock,
+	}
+}
+
+// newItemNoLock must be called under the shard's lock, so the sequence numbers are increasing along the shard's list
+func (shard *fifoShard) newItemNoLock(key string, value interface{}, size int, expiresAt time.Time) *fifoItem {
+	if size < 0 {
+		size = 0
+	}
+
+	return &fifoItem{
+		key:       key,
+		value:     value,
+		size:      size,
+		sequence:  uint64(shard.sequence.Increment()),
+		expiresAt: expiresAt,
+	}
+}
+
+// isExpiredNoLock only reads the clock for the items which expire
+func (shard *fifoShard) isExpiredNoLock(item *fifoItem) bool {
+	if item.expiresAt.IsZero() {
+		return false
+	}
+
+	return item.isExpired(shard.clock.Now())
+}
+
+func (shard *fifoShard) pushBackNoLock(item *fifoItem) {
+	shard.items[item.key] = shard.order.PushBack(item)
+	shard.numBytes += uint64(item.size)
+}
+
+func (shard *fifoShard) removeElementNoLock(element *list.Element) *fifoItem {
+	item := element.Value.(*fifoItem)
+	shard.order.Remove(element)
+	delete(shard.items, item.key)
+	shard.numBytes -= uint64(item.size)
+
+	return item
+}
+
+// set adds or replaces the value of the provided key. A replaced key is moved at the end of the insertion order.
+// It returns the items displaced to make room for the new one
+func (shard *fifoShard) set(key string, value interface{}, size int, expiresAt time.Time) []*fifoItem {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	element, exists := shard.items[key]
+	if exists {
+		shard.removeElementNoLock(element)
+	}
+
+	shard.pushBackNoLock(shard.newItemNoLock(key, value, size, expiresAt))
+
+	return shard.evictOverflowNoLock()
+}
+
+// setIfAbsent adds the value only if the key is not already present, an expired item counting as absent.
+// It returns whether the value was added, the items displaced to make room for it and the expired item it replaced
+func (shard *fifoShard) setIfAbsent(key string, value interface{}, size int, expiresAt time.Time) (bool, []*fifoItem, *fifoItem) {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	var expired *fifoItem
+	element, exists := shard.items[key]
+	if exists {
+		if !shard.isExpiredNoLock(element.Value.(*fifoItem)) {
+			return false, nil, nil
+		}
+
+		expired = shard.removeElementNoLock(element)
+	}
+
+	shard.pushBackNoLock(shard.newItemNoLock(key, value, size, expiresAt))
+
+	return true, shard.evictOverflowNoLock(), expired
+}
+
+// appendItems adds already sequenced items at the end of the shard, keeping their sequence numbers.
+// The items must be provided in increasing sequence order. It returns the items displaced to make room for them
+func (shard *fifoShard) appendItems(items []*fifoItem) []*fifoItem {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	for _, item := range items {
+		shard.pushBackNoLock(item)
+	}
+
+	return shard.evictOverflowNoLock()
+}
+
+func (shard *fifoShard) evictOverflowNoLock() []*fifoItem {
+	var evicted []*fifoItem
+	for shard.order.Len() > shard.maxSize {
+		evicted = append(evicted, shard.removeElementNoLock(shard.order.Front()))
+	}
+
+	return evicted
+}
+
+func (shard *fifoShard) get(key string) (interface{}, bool) {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	element, ok := shard.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := element.Value.(*fifoItem)
+	if shard.isExpiredNoLock(item) {
+		return nil, false
+	}
+
+	return item.value, true
+}
+
+func (shard *fifoShard) has(key string) bool {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	element, ok := shard.items[key]
+
+	return ok && !shard.isExpiredNoLock(element.Value.(*fifoItem))
+}
+
+func (shard *fifoShard) remove(key string) {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	element, ok := shard.items[key]
+	if !ok {
+		return
+	}
+
+	shard.removeElementNoLock(element)
+}
+
+func (shard *fifoShard) clear() {
+	shard.mutex.Lock()
+	shard.items = make(map[string]*list.Element)
+	shard.order.Init()
+	shard.numBytes = 0
+	shard.mutex.Unlock()
+}
+
+// appendKeys accumulates the keys of the items not expired, from oldest to newest, in a given slice
+func (shard *fifoShard) appendKeys(keys []string) []string {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	now := shard.clock.Now()
+	for element := shard.order.Front(); element != nil; element = element.Next() {
+		item := element.Value.(*fifoItem)
+		if !item.isExpired(now) {
+			keys = append(keys, item.key)
+		}
+	}
+
+	return keys
+}
+
+// itemsInOrder returns a snapshot of the contained items, from oldest to newest, the expired ones included only if
+// requested
+func (shard *fifoShard) itemsInOrder(includeExpired bool) []*fifoItem {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	now := shard.clock.Now()
+	items := make([]*fifoItem, 0, shard.order.Len())
+	for element := shard.order.Front(); element != nil; element = element.Next() {
+		item := element.Value.(*fifoItem)
+		if includeExpired || !item.isExpired(now) {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// removeExpired removes and returns the expired items
+func (shard *fifoShard) removeExpired() []*fifoItem {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	var expired []*fifoItem
+	now := shard.clock.Now()
+	for element := shard.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*fifoItem).isExpired(now) {
+			expired = append(expired, shard.removeElementNoLock(element))
+		}
+		element = next
+	}
+
+	return expired
+}
+
+func (shard *fifoShard) count() int {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	return len(shard.items)
+}
+
+func (shard *fifoShard) sizeInBytes() uint64 {
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	return shard.numBytes
+}
@@ -1,52 +1,128 @@
 package fifocache
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
 
-	cmap "github.com/TerraDharitri/concurrent-map"
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var _ types.Cacher = (*FIFOShardedCache)(nil)
+var _ types.EvictionNotifier = (*FIFOShardedCache)(nil)
+var _ types.StatsProvider = (*FIFOShardedCache)(nil)
 
 var log = logger.GetOrCreate("storage/fifocache")
 
-// FIFOShardedCache implements a First In First Out eviction cache
+// FIFOShardedCache implements a First In First Out eviction cache, whose items optionally expire after a time to live
 type FIFOShardedCache struct {
-	cache   *cmap.ConcurrentMap
-	maxsize int
+	mutShards sync.RWMutex
+	shards    []*fifoShard
+	maxsize   int
+	sequence  atomic.Counter
 
-	mutAddedDataHandlers sync.RWMutex
-	mapDataHandlers      map[string]func(key []byte, value interface{})
+	ttl           time.Duration
+	sweepInterval time.Duration
+	clock         types.Clock
+	cancelSweep   context.CancelFunc
+	numExpired    atomic.Counter
+
+	addedDataHandlers *dispatch.Dispatcher
+	evictionHandlers  *dispatch.Dispatcher
 }
 
-// NewShardedCache creates a new cache instance
-func NewShardedCache(size int, shards int) (*FIFOShardedCache, error) {
-	cache := cmap.New(size, shards)
+// NewShardedCache creates a new cache instance. If the items expire, the expired ones are swept on a dedicated go
+// routine, stopped by calling Close
+func NewShardedCache(size int, shards int, opts ...Option) (*FIFOShardedCache, error) {
+	if shards <= 0 {
+		return nil, fmt.Errorf("%w: %d", common.ErrInvalidNumberOfShards, shards)
+	}
+
+	o := newOptions(opts)
+	if o.ttl < 0 {
+		return nil, fmt.Errorf("%w: negative time to live %v", common.ErrInvalidCacheExpiry, o.ttl)
+	}
+	if o.sweepInterval < 0 {
+		return nil, fmt.Errorf("%w: %v", common.ErrInvalidSweepInterval, o.sweepInterval)
+	}
+
 	fifoShardedCache := &FIFOShardedCache{
-		cache:                cache,
-		maxsize:              size,
-		mutAddedDataHandlers: sync.RWMutex{},
-		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
+		maxsize:           size,
+		ttl:               o.ttl,
+		sweepInterval:     o.sweepInterval,
+		clock:             o.clock,
+		addedDataHandlers: dispatch.NewDefaultDispatcher("fifocache"),
+		evictionHandlers:  dispatch.NewEvictionDispatcher("fifocache eviction"),
 	}
 
+	fifoShardedCache.shards = createShards(size, shards, &fifoShardedCache.sequence, o.clock)
+	fifoShardedCache.startSweeping()
+
 	return fifoShardedCache, nil
 }
 
+func createShards(size int, numShards int, sequence *atomic.Counter, clock types.Clock) []*fifoShard {
+	shardSize := size / numShards
+	if shardSize == 0 {
+		shardSize = 1
+	}
+	if size%numShards != 0 {
+		shardSize++
+	}
+
+	shards := make([]*fifoShard, numShards)
+	for i := 0; i < numShards; i++ {
+		shards[i] = newFIFOShard(shardSize, sequence, clock)
+	}
+
+	return shards
+}
+
+// getShard must be called under the shards mutex, so the shard is not replaced by a concurrent rehash
+func (c *FIFOShardedCache) getShard(key string) *fifoShard {
+	return c.shards[fnv32(key)%uint32(len(c.shards))]
+}
+
+// fnv32 implements https://en.wikipedia.org/wiki/Fowler–Noll–Vo_hash_function for 32 bits
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+	const prime32 = uint32(16777619)
+	for i := 0; i < len(key); i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+	return hash
+}
+
 // Clear is used to completely clear the cache.
 func (c *FIFOShardedCache) Clear() {
-	keys := c.cache.Keys()
-	for _, key := range keys {
-		c.cache.Remove(key)
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	for _, shard := range c.shards {
+		shard.clear()
 	}
 }
 
 // Put adds a value to the cache.  Returns true if an eviction occurred.
-// the int parameter for size is not used as, for now, fifo sharded cache can not count for its contained data size
-func (c *FIFOShardedCache) Put(key []byte, value interface{}, _ int) (evicted bool) {
-	c.cache.Set(string(key), value)
-	c.callAddedDataHandlers(key, value)
+// The size in bytes is only accounted for, it does not trigger evictions. The value expires after the time to live of
+// the cache, if any
+func (c *FIFOShardedCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	return c.putExpiringAt(key, value, sizeInBytes, c.expiryTime(c.ttl))
+}
+
+func (c *FIFOShardedCache) putExpiringAt(key []byte, value interface{}, sizeInBytes int, expiresAt time.Time) bool {
+	c.mutShards.RLock()
+	evictedItems := c.getShard(string(key)).set(string(key), value, sizeInBytes, expiresAt)
+	c.mutShards.RUnlock()
+
+	c.addedDataHandlers.Dispatch(key, value)
+	c.callEvictionHandlers(evictedItems, types.EvictionReasonCapacity)
 
 	return true
 }
@@ -58,64 +134,104 @@ func (c *FIFOShardedCache) RegisterHandler(handler func(key []byte, value interf
 		return
 	}
 
-	c.mutAddedDataHandlers.Lock()
-	c.mapDataHandlers[id] = handler
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataHandlers.Register(id, handler)
 }
 
 // UnRegisterHandler removes the handler from the list
 func (c *FIFOShardedCache) UnRegisterHandler(id string) {
-	c.mutAddedDataHandlers.Lock()
-	delete(c.mapDataHandlers, id)
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataHandlers.Unregister(id)
+}
+
+// RegisterEvictionHandler registers a new handler to be called whenever an item is displaced because its shard overflowed,
+// or removed because it expired
+func (c *FIFOShardedCache) RegisterEvictionHandler(handler types.EvictedItemHandler, id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a cacher object")
+		return
+	}
+
+	c.evictionHandlers.RegisterEvictionHandler(id, handler)
+}
+
+// UnRegisterEvictionHandler removes the eviction handler from the list
+func (c *FIFOShardedCache) UnRegisterEvictionHandler(id string) {
+	c.evictionHandlers.Unregister(id)
 }
 
 // Get looks up a key's value from the cache.
 func (c *FIFOShardedCache) Get(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return c.getShard(string(key)).get(string(key))
 }
 
 // Has checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *FIFOShardedCache) Has(key []byte) bool {
-	return c.cache.Has(string(key))
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return c.getShard(string(key)).has(string(key))
 }
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *FIFOShardedCache) Peek(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return c.getShard(string(key)).get(string(key))
 }
 
 // HasOrAdd checks if a key is in the cache without updating the
 // recent-ness or deleting it for being stale, and if not, adds the value.
-// Returns whether the item existed before and whether it has been added.
-func (c *FIFOShardedCache) HasOrAdd(key []byte, value interface{}, _ int) (has, added bool) {
-	added = c.cache.SetIfAbsent(string(key), value)
+// Returns whether the item existed before and whether it has been added. An expired item is replaced
+func (c *FIFOShardedCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mutShards.RLock()
+	added, evictedItems, expiredItem := c.getShard(string(key)).setIfAbsent(string(key), value, sizeInBytes, c.expiryTime(c.ttl))
+	c.mutShards.RUnlock()
 
 	if added {
-		c.callAddedDataHandlers(key, value)
+		if expiredItem != nil {
+			c.onExpired([]*fifoItem{expiredItem})
+		}
+		c.addedDataHandlers.Dispatch(key, value)
+		c.callEvictionHandlers(evictedItems, types.EvictionReasonCapacity)
 	}
 
 	return !added, added
 }
 
-func (c *FIFOShardedCache) callAddedDataHandlers(key []byte, value interface{}) {
-	c.mutAddedDataHandlers.RLock()
-	for _, handler := range c.mapDataHandlers {
-		go handler(key, value)
+func (c *FIFOShardedCache) callEvictionHandlers(evictedItems []*fifoItem, reason types.EvictionReason) {
+	if len(evictedItems) == 0 {
+		return
+	}
+
+	items := make([]dispatch.EvictedItem, 0, len(evictedItems))
+	for _, item := range evictedItems {
+		items = append(items, dispatch.EvictedItem{Key: []byte(item.key), Value: item.value})
 	}
-	c.mutAddedDataHandlers.RUnlock()
+	c.evictionHandlers.DispatchEvictions(items, reason)
 }
 
 // Remove removes the provided key from the cache.
 func (c *FIFOShardedCache) Remove(key []byte) {
-	c.cache.Remove(string(key))
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	c.getShard(string(key)).remove(string(key))
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache, from oldest to newest within each shard. The expired keys are left out
 func (c *FIFOShardedCache) Keys() [][]byte {
-	res := c.cache.Keys()
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	res := make([]string, 0, c.lenNoLock())
+	for _, shard := range c.shards {
+		res = shard.appendKeys(res)
+	}
 	r := make([][]byte, len(res))
 
 	for i := 0; i < len(res); i++ {
@@ -125,14 +241,154 @@ func (c *FIFOShardedCache) Keys() [][]byte {
 	return r
 }
 
-// Len returns the number of items in the cache.
+// KeysInOrder returns a slice of the keys in the cache, from oldest to newest, across all shards
+func (c *FIFOShardedCache) KeysInOrder() [][]byte {
+	items := c.itemsInOrder()
+	keys := make([][]byte, len(items))
+	for i, item := range items {
+		keys[i] = []byte(item.key)
+	}
+
+	return keys
+}
+
+// ForEachItemInOrder iterates over the items in the cache, from oldest to newest, across all shards.
+// The iteration is done on a snapshot, so the function is free to call back into the cache
+func (c *FIFOShardedCache) ForEachItemInOrder(function types.ForEachItem) {
+	if function == nil {
+		return
+	}
+
+	for _, item := range c.itemsInOrder() {
+		function([]byte(item.key), item.value)
+	}
+}
+
+func (c *FIFOShardedCache) itemsInOrder() []*fifoItem {
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return c.itemsInOrderNoLock(false)
+}
+
+func (c *FIFOShardedCache) itemsInOrderNoLock(includeExpired bool) []*fifoItem {
+	snapshots := make([][]*fifoItem, len(c.shards))
+	for i, shard := range c.shards {
+		snapshots[i] = shard.itemsInOrder(includeExpired)
+	}
+
+	return mergeBySequence(snapshots)
+}
+
+// Len returns the number of items in the cache, the expired ones included until they are swept
 func (c *FIFOShardedCache) Len() int {
-	return c.cache.Count()
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	return c.lenNoLock()
+}
+
+func (c *FIFOShardedCache) lenNoLock() int {
+	count := 0
+	for _, shard := range c.shards {
+		count += shard.count()
+	}
+
+	return count
 }
 
-// SizeInBytesContained returns 0
+// SizeInBytesContained returns the size in bytes of all contained elements
 func (c *FIFOShardedCache) SizeInBytesContained() uint64 {
-	return 0
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	total := uint64(0)
+	for _, shard := range c.shards {
+		total += shard.sizeInBytes()
+	}
+
+	return total
+}
+
+// ShardsStatistics returns the number of items and bytes held by each shard
+func (c *FIFOShardedCache) ShardsStatistics() []ShardStatistics {
+	c.mutShards.RLock()
+	defer c.mutShards.RUnlock()
+
+	statistics := make([]ShardStatistics, len(c.shards))
+	for i, shard := range c.shards {
+		statistics[i] = ShardStatistics{
+			NumItems:    shard.count(),
+			NumBytes:    shard.sizeInBytes(),
+			MaxNumItems: shard.maxSize,
+		}
+	}
+
+	return statistics
+}
+
+// Skew returns the ratio between the number of items held by the most loaded shard and the average number of
+// items per shard. A value of 1 means a perfect distribution, while a value close to the number of shards
+// means that (almost) all the keys end up in the same shard
+func (c *FIFOShardedCache) Skew() float64 {
+	return computeSkew(c.ShardsStatistics())
+}
+
+// IsSkewed returns true if the keys are so badly distributed that a shard will start evicting items long before
+// the cache reaches its capacity
+func (c *FIFOShardedCache) IsSkewed() bool {
+	statistics := c.ShardsStatistics()
+	if len(statistics) < 2 {
+		return false
+	}
+
+	numItems := 0
+	for _, shardStatistics := range statistics {
+		numItems += shardStatistics.NumItems
+	}
+	if numItems < minItemsPerShardForSkewDetection*len(statistics) {
+		return false
+	}
+
+	return computeSkew(statistics) >= maxAcceptedSkew
+}
+
+// Rehash redistributes the contained items into the provided (greater) number of shards. The insertion order
+// is preserved, the items which do not fit into their new shards are evicted, oldest first
+func (c *FIFOShardedCache) Rehash(numShards int) error {
+	c.mutShards.Lock()
+	currentNumShards := len(c.shards)
+	if numShards <= currentNumShards {
+		c.mutShards.Unlock()
+		return fmt.Errorf("%w: provided %d, current %d", common.ErrInvalidNumberOfShards, numShards, currentNumShards)
+	}
+
+	// the expired items are moved as well, to be swept and notified afterwards
+	items := c.itemsInOrderNoLock(true)
+	c.shards = createShards(c.maxsize, numShards, &c.sequence, c.clock)
+
+	itemsPerShard := make(map[*fifoShard][]*fifoItem, numShards)
+	for _, item := range items {
+		shard := c.getShard(item.key)
+		itemsPerShard[shard] = append(itemsPerShard[shard], item)
+	}
+
+	var evictedItems []*fifoItem
+	for _, shard := range c.shards {
+		evictedItems = append(evictedItems, shard.appendItems(itemsPerShard[shard])...)
+	}
+	c.mutShards.Unlock()
+
+	log.Debug("fifocache rehashed",
+		"old num shards", currentNumShards,
+		"new num shards", numShards,
+		"num items", len(items),
+		"num evicted", len(evictedItems),
+	)
+
+	c.callEvictionHandlers(evictedItems, types.EvictionReasonCapacity)
+
+	return nil
 }
 
 // MaxSize returns the maximum number of items which can be stored in cache.
@@ -140,11 +396,27 @@ func (c *FIFOShardedCache) MaxSize() int {
 	return c.maxsize
 }
 
-// Close does nothing for this cacher implementation
+// Close stops the sweep go routine, if any. It is safe to call it multiple times
 func (c *FIFOShardedCache) Close() error {
+	if c.cancelSweep != nil {
+		c.cancelSweep()
+	}
+
 	return nil
 }
 
+// Stats returns the key stats of the cache
+func (c *FIFOShardedCache) Stats() map[string]interface{} {
+	stats := types.CacheStats(string(common.FIFOShardedCache), c)
+	stats["numShards"] = len(c.ShardsStatistics())
+	if c.ttl > 0 {
+		stats["ttl"] = c.ttl.String()
+	}
+	stats["numExpired"] = c.numExpired.Get()
+
+	return stats
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (c *FIFOShardedCache) IsInterfaceNil() bool {
 	return c == nil
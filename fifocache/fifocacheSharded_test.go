@@ -7,8 +7,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/fifocache"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var timeoutWaitForWaitGroups = time.Second * 2
@@ -423,3 +427,520 @@ func TestFifoShardedCache_ConcurrentOperation(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestFIFOShardedCache_RegisterEvictionHandlerNilHandlerShouldIgnore(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 2)
+	c.RegisterEvictionHandler(nil, "")
+
+	assert.Equal(t, 0, len(c.EvictionHandlers()))
+}
+
+func TestFIFOShardedCache_EvictionHandlerIsCalledWithDisplacedItems(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(2, 1)
+
+	mutEvicted := sync.Mutex{}
+	evicted := make(map[string]interface{})
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		assert.Equal(t, types.EvictionReasonCapacity, reason)
+		mutEvicted.Lock()
+		evicted[string(key)] = value
+		mutEvicted.Unlock()
+		wg.Done()
+	}, "id")
+
+	c.Put([]byte("key0"), "value0", 0)
+	c.Put([]byte("key1"), "value1", 0)
+	c.Put([]byte("key2"), "value2", 0)
+	_, _ = c.HasOrAdd([]byte("key3"), "value3", 0)
+
+	chDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "eviction handler should have been called twice")
+		return
+	}
+
+	mutEvicted.Lock()
+	assert.Equal(t, map[string]interface{}{"key0": "value0", "key1": "value1"}, evicted)
+	mutEvicted.Unlock()
+	assert.Equal(t, [][]byte{[]byte("key2"), []byte("key3")}, c.Keys())
+}
+
+func TestFIFOShardedCache_PanickingEvictionHandlerShouldNotAffectTheOthers(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(2, 1)
+
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		panic("eviction handler failure")
+	}, "panicking")
+	evicted := make(chan string, 10)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		evicted <- string(key)
+	}, "healthy")
+
+	for i := 0; i < 4; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	evictedKeys := make([]string, 0, 2)
+	for len(evictedKeys) < 2 {
+		select {
+		case key := <-evicted:
+			evictedKeys = append(evictedKeys, key)
+		case <-time.After(timeoutWaitForWaitGroups):
+			assert.Fail(t, "healthy eviction handler should have been called")
+			return
+		}
+	}
+	assert.ElementsMatch(t, []string{"key0", "key1"}, evictedKeys)
+	assert.Equal(t, []string{"healthy", "panicking"}, c.EvictionHandlers())
+}
+
+func TestFIFOShardedCache_SlowEvictionHandlerShouldNotLoseEvictions(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 1)
+
+	chUnblock := make(chan struct{})
+	numEvictions := 2000
+	evicted := make(chan string, numEvictions)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		<-chUnblock
+		evicted <- string(key)
+	}, "slow")
+
+	// the handler is stalled during the whole burst of evictions
+	for i := 0; i < numEvictions+10; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+	close(chUnblock)
+
+	for i := 0; i < numEvictions; i++ {
+		select {
+		case key := <-evicted:
+			assert.Equal(t, fmt.Sprintf("key%d", i), key)
+		case <-time.After(timeoutWaitForWaitGroups):
+			assert.Fail(t, "eviction lost", "num evictions received", i)
+			return
+		}
+	}
+	assert.Equal(t, []string{"slow"}, c.EvictionHandlers())
+}
+
+func TestFIFOShardedCache_EvictionHandlerNotCalledWithoutOverflow(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(2, 1)
+
+	called := make(chan struct{}, 10)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, _ types.EvictionReason) {
+		called <- struct{}{}
+	}, "id")
+
+	c.Put([]byte("key0"), "value0", 0)
+	c.Put([]byte("key0"), "value0-updated", 0)
+	c.Put([]byte("key1"), "value1", 0)
+	c.Remove([]byte("key0"))
+	c.Put([]byte("key2"), "value2", 0)
+
+	select {
+	case <-called:
+		assert.Fail(t, "eviction handler should not have been called")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	c.UnRegisterEvictionHandler("id")
+	assert.Equal(t, 0, len(c.EvictionHandlers()))
+}
+
+func TestFIFOShardedCache_KeysInOrderShouldMergeShardsByInsertionOrder(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(100, 8)
+
+	numKeys := 50
+	expectedKeys := make([][]byte, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		c.Put(key, i, 0)
+		expectedKeys = append(expectedKeys, key)
+	}
+
+	assert.Equal(t, expectedKeys, c.KeysInOrder())
+
+	// a re-put key moves at the end, a removed key disappears
+	c.Put([]byte("key3"), 3, 0)
+	c.Remove([]byte("key7"))
+	expectedKeys = append(expectedKeys[:3], expectedKeys[4:]...)
+	expectedKeys = append(expectedKeys[:6], expectedKeys[7:]...)
+	expectedKeys = append(expectedKeys, []byte("key3"))
+
+	assert.Equal(t, expectedKeys, c.KeysInOrder())
+}
+
+func TestFIFOShardedCache_KeysInOrderOnEmptyCache(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 2)
+
+	assert.Empty(t, c.KeysInOrder())
+}
+
+func TestFIFOShardedCache_ForEachItemInOrder(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(100, 4)
+	for i := 0; i < 20; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	c.ForEachItemInOrder(nil)
+
+	values := make([]interface{}, 0, 20)
+	c.ForEachItemInOrder(func(key []byte, value interface{}) {
+		assert.Equal(t, []byte(fmt.Sprintf("key%d", value)), key)
+		values = append(values, value)
+		// calling back into the cache must not deadlock
+		c.Remove(key)
+	})
+
+	assert.Len(t, values, 20)
+	for i, value := range values {
+		assert.Equal(t, i, value)
+	}
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestFIFOShardedCache_SizeInBytesContained(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 2)
+
+	c.Put([]byte("key0"), "value0", 3)
+	c.Put([]byte("key1"), "value1", 5)
+	assert.Equal(t, uint64(8), c.SizeInBytesContained())
+
+	c.Put([]byte("key0"), "value0", 7)
+	assert.Equal(t, uint64(12), c.SizeInBytesContained())
+
+	_, _ = c.HasOrAdd([]byte("key2"), "value2", 1)
+	_, _ = c.HasOrAdd([]byte("key2"), "value2", 100)
+	assert.Equal(t, uint64(13), c.SizeInBytesContained())
+
+	c.Remove([]byte("key1"))
+	assert.Equal(t, uint64(8), c.SizeInBytesContained())
+
+	c.Clear()
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestFIFOShardedCache_ShardsStatistics(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 2)
+	for i := 0; i < 6; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		c.Put(key, i, i)
+	}
+
+	statistics := c.ShardsStatistics()
+	require.Len(t, statistics, 2)
+
+	expected := make([]fifocache.ShardStatistics, 2)
+	for i := 0; i < 6; i++ {
+		idx := c.ShardIndex([]byte(fmt.Sprintf("key%d", i)))
+		expected[idx].NumItems++
+		expected[idx].NumBytes += uint64(i)
+	}
+	expected[0].MaxNumItems = 5
+	expected[1].MaxNumItems = 5
+
+	assert.Equal(t, expected, statistics)
+}
+
+func TestFIFOShardedCache_SkewDetection(t *testing.T) {
+	t.Run("empty cache is not skewed", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(100, 4)
+
+		assert.Equal(t, float64(0), c.Skew())
+		assert.False(t, c.IsSkewed())
+	})
+	t.Run("single shard is never skewed", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(100, 1)
+		for i := 0; i < 100; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		assert.Equal(t, float64(1), c.Skew())
+		assert.False(t, c.IsSkewed())
+	})
+	t.Run("all keys in the same shard", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(1000, 4)
+		for i := 0; c.Len() < 100; i++ {
+			key := []byte(fmt.Sprintf("key%d", i))
+			if c.ShardIndex(key) == 0 {
+				c.Put(key, i, 0)
+			}
+		}
+
+		assert.Equal(t, float64(4), c.Skew())
+		assert.True(t, c.IsSkewed())
+	})
+	t.Run("too few items to decide", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(1000, 4)
+		for i := 0; c.Len() < 10; i++ {
+			key := []byte(fmt.Sprintf("key%d", i))
+			if c.ShardIndex(key) == 0 {
+				c.Put(key, i, 0)
+			}
+		}
+
+		assert.Equal(t, float64(4), c.Skew())
+		assert.False(t, c.IsSkewed())
+	})
+}
+
+func TestFIFOShardedCache_Rehash(t *testing.T) {
+	t.Run("fewer or equal number of shards should error", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(100, 4)
+
+		err := c.Rehash(4)
+		assert.ErrorIs(t, err, common.ErrInvalidNumberOfShards)
+		err = c.Rehash(2)
+		assert.ErrorIs(t, err, common.ErrInvalidNumberOfShards)
+		assert.Len(t, c.ShardsStatistics(), 4)
+	})
+	t.Run("should keep items, sizes and insertion order", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(100, 2)
+		for i := 0; i < 50; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 2)
+		}
+		keysBefore := c.KeysInOrder()
+
+		err := c.Rehash(8)
+		require.Nil(t, err)
+
+		assert.Len(t, c.ShardsStatistics(), 8)
+		assert.Equal(t, 50, c.Len())
+		assert.Equal(t, uint64(100), c.SizeInBytesContained())
+		assert.Equal(t, keysBefore, c.KeysInOrder())
+		for i := 0; i < 50; i++ {
+			value, ok := c.Get([]byte(fmt.Sprintf("key%d", i)))
+			assert.True(t, ok)
+			assert.Equal(t, i, value)
+		}
+
+		// new items are ordered after the rehashed ones
+		c.Put([]byte("new"), "new", 0)
+		keys := c.KeysInOrder()
+		assert.Equal(t, []byte("new"), keys[len(keys)-1])
+	})
+	t.Run("items not fitting into the new shards should be evicted", func(t *testing.T) {
+		c, _ := fifocache.NewShardedCache(4, 1)
+
+		mutEvicted := sync.Mutex{}
+		numEvicted := 0
+		c.RegisterEvictionHandler(func(key []byte, value interface{}, _ types.EvictionReason) {
+			mutEvicted.Lock()
+			numEvicted++
+			mutEvicted.Unlock()
+		}, "id")
+
+		for i := 0; i < 4; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		// 4 shards of a single item each
+		err := c.Rehash(4)
+		require.Nil(t, err)
+
+		expectedNumEvicted := 4 - c.Len()
+		time.Sleep(time.Millisecond * 100)
+		mutEvicted.Lock()
+		assert.Equal(t, expectedNumEvicted, numEvicted)
+		mutEvicted.Unlock()
+		for _, statistics := range c.ShardsStatistics() {
+			assert.True(t, statistics.NumItems <= 1)
+		}
+	})
+}
+
+func TestFIFOShardedCache_RehashWhileOperating(t *testing.T) {
+	// large enough so no shard overflows, whatever the number of shards
+	c, _ := fifocache.NewShardedCache(100000, 2)
+	numOperations := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			key := []byte(fmt.Sprintf("key%d", idx))
+			switch idx % 4 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.HasOrAdd(key, idx, 1)
+			case 2:
+				_ = c.Rehash(2 + idx/4)
+			case 3:
+				_ = c.ShardsStatistics()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 500, c.Len())
+	assert.Equal(t, uint64(500), c.SizeInBytesContained())
+}
+
+func TestFIFOShardedCache_Stress(t *testing.T) {
+	t.Parallel()
+
+	args := testscommon.ArgsCacheStress{
+		NumGoroutines:       8,
+		NumOpsPerGoroutine:  2000,
+		NumKeysPerGoroutine: 20,
+		OperationWeights: map[testscommon.CacheOperation]int{
+			testscommon.CachePut:    5,
+			testscommon.CacheGet:    10,
+			testscommon.CacheRemove: 2,
+			testscommon.CacheKeys:   1,
+		},
+		Seed: 7,
+	}
+
+	t.Run("without evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := fifocache.NewShardedCache(1000, 4)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 1000
+		stressArgs.ExpectNoEvictions = true
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+	})
+	t.Run("with evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := fifocache.NewShardedCache(40, 4)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 40
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+	})
+}
+
+func TestFIFOShardedCache_InvalidNumberOfShardsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := fifocache.NewShardedCache(10, 0)
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, common.ErrInvalidNumberOfShards)
+
+	c, err = fifocache.NewShardedCache(10, -1)
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, common.ErrInvalidNumberOfShards)
+}
+
+//------- TTL
+
+func TestFIFOShardedCache_InvalidExpiryShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := fifocache.NewShardedCache(10, 2, fifocache.WithTTL(-time.Second))
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, common.ErrInvalidCacheExpiry)
+
+	c, err = fifocache.NewShardedCache(10, 2, fifocache.WithTTL(time.Second), fifocache.WithSweepInterval(-time.Second))
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, common.ErrInvalidSweepInterval)
+}
+
+func TestFIFOShardedCache_ExpiredItemsShouldNotBeReturned(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Now())
+	c, _ := fifocache.NewShardedCache(10, 2, fifocache.WithTTL(time.Minute), fifocache.WithSweepInterval(time.Hour), fifocache.WithClock(clock))
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("key0"), "value0", 0)
+	c.PutWithTTL([]byte("key1"), "value1", 0, time.Hour)
+	c.PutWithTTL([]byte("key2"), "value2", 0, 0)
+	clock.Advance(time.Minute)
+
+	assert.False(t, c.Has([]byte("key0")))
+	_, ok := c.Get([]byte("key0"))
+	assert.False(t, ok)
+	_, ok = c.Peek([]byte("key0"))
+	assert.False(t, ok)
+	assert.Equal(t, [][]byte{[]byte("key1"), []byte("key2")}, c.KeysInOrder())
+	assert.Len(t, c.Keys(), 2)
+	assert.Equal(t, 3, c.Len(), "the expired items should be counted until swept")
+
+	value, ok := c.Get([]byte("key1"))
+	assert.True(t, ok)
+	assert.Equal(t, "value1", value)
+
+	clock.Advance(time.Hour)
+	assert.True(t, c.Has([]byte("key2")), "the items added without a time to live should not expire")
+	assert.Equal(t, 2, c.Sweep())
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, int64(2), c.Stats()["numExpired"])
+}
+
+func TestFIFOShardedCache_HasOrAddShouldReplaceTheExpiredItem(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Now())
+	c, _ := fifocache.NewShardedCache(10, 1, fifocache.WithTTL(time.Minute), fifocache.WithClock(clock))
+	defer func() {
+		_ = c.Close()
+	}()
+
+	chExpired := make(chan string, 1)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		assert.Equal(t, types.EvictionReasonExpired, reason)
+		chExpired <- string(key)
+	}, "id")
+
+	has, added := c.HasOrAdd([]byte("key"), "value0", 0)
+	assert.False(t, has)
+	assert.True(t, added)
+	has, added = c.HasOrAdd([]byte("key"), "value1", 0)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	clock.Advance(time.Minute)
+	has, added = c.HasOrAdd([]byte("key"), "value2", 0)
+	assert.False(t, has)
+	assert.True(t, added)
+	value, _ := c.Get([]byte("key"))
+	assert.Equal(t, "value2", value)
+
+	select {
+	case key := <-chExpired:
+		assert.Equal(t, "key", key)
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "the eviction handler should have been called for the expired item")
+	}
+}
+
+func TestFIFOShardedCache_ExpiredItemsShouldBeSweptPeriodically(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(10, 2, fifocache.WithTTL(time.Millisecond*10))
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("key0"), "value0", 0)
+	c.Put([]byte("key1"), "value1", 0)
+
+	assert.Eventually(t, func() bool {
+		return c.Len() == 0
+	}, timeoutWaitForWaitGroups, time.Millisecond*10)
+	assert.Nil(t, c.Close())
+	assert.Nil(t, c.Close())
+}
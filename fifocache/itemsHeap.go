@@ -0,0 +1,80 @@
+package fifocache
+
+import "container/heap"
+
+// shardCursor points to the next (not yet merged) item of a shard snapshot
+type shardCursor struct {
+	items    []*fifoItem
+	position int
+}
+
+func (cursor *shardCursor) currentItem() *fifoItem {
+	return cursor.items[cursor.position]
+}
+
+// cursorsHeap is a min-heap of shard cursors, ordered by the sequence number of their current item
+type cursorsHeap struct {
+	items []*shardCursor
+}
+
+// Len returns the number of elements in the heap.
+func (h *cursorsHeap) Len() int { return len(h.items) }
+
+// Less reports whether the element with index i should sort before the element with index j.
+func (h *cursorsHeap) Less(i, j int) bool {
+	return h.items[i].currentItem().sequence < h.items[j].currentItem().sequence
+}
+
+// Swap swaps the elements with indexes i and j.
+func (h *cursorsHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+// Push pushes the element x onto the heap.
+func (h *cursorsHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(*shardCursor))
+}
+
+// Pop removes and returns the minimum element (according to the sequence number) from the heap.
+func (h *cursorsHeap) Pop() interface{} {
+	// Standard code when storing the heap in a slice:
+	// https://pkg.go.dev/container/heap
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	h.items = old[0 : n-1]
+	return item
+}
+
+// mergeBySequence merges the shard snapshots (each one already ordered) into a single slice, ordered by sequence number
+func mergeBySequence(snapshots [][]*fifoItem) []*fifoItem {
+	total := 0
+	cursors := &cursorsHeap{
+		items: make([]*shardCursor, 0, len(snapshots)),
+	}
+	for _, snapshot := range snapshots {
+		if len(snapshot) == 0 {
+			continue
+		}
+
+		total += len(snapshot)
+		cursors.items = append(cursors.items, &shardCursor{items: snapshot})
+	}
+	heap.Init(cursors)
+
+	merged := make([]*fifoItem, 0, total)
+	for cursors.Len() > 0 {
+		cursor := cursors.items[0]
+		merged = append(merged, cursor.currentItem())
+
+		cursor.position++
+		if cursor.position < len(cursor.items) {
+			heap.Fix(cursors, 0)
+			continue
+		}
+
+		heap.Pop(cursors)
+	}
+
+	return merged
+}
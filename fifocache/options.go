@@ -0,0 +1,70 @@
+package fifocache
+
+import (
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// Option customizes the cache created by NewShardedCache
+type Option func(options *options)
+
+type options struct {
+	ttl           time.Duration
+	sweepInterval time.Duration
+	clock         types.Clock
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if check.IfNil(o.clock) {
+		o.clock = &systemClock{}
+	}
+	if o.sweepInterval == 0 {
+		o.sweepInterval = o.ttl
+	}
+
+	return o
+}
+
+// WithTTL makes the items added by Put and HasOrAdd expire ttl after being added. The expired items are not returned
+// anymore, being removed by the periodic sweep. By default, the items do not expire
+func WithTTL(ttl time.Duration) Option {
+	return func(options *options) {
+		options.ttl = ttl
+	}
+}
+
+// WithSweepInterval sets the interval between the sweeps removing the expired items, which defaults to the time to live
+// set by WithTTL. Without a sweep interval or a time to live, the expired items added by PutWithTTL are only removed
+// by the explicit calls of Sweep
+func WithSweepInterval(interval time.Duration) Option {
+	return func(options *options) {
+		options.sweepInterval = interval
+	}
+}
+
+// WithClock sets the clock deciding the expiry of the items, the system clock being used by default
+func WithClock(clock types.Clock) Option {
+	return func(options *options) {
+		options.clock = clock
+	}
+}
+
+// systemClock is the default clock of the cache, relying on the system time
+type systemClock struct{}
+
+// Now returns the current system time
+func (sc *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sc *systemClock) IsInterfaceNil() bool {
+	return sc == nil
+}
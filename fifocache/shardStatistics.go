@@ -0,0 +1,33 @@
+package fifocache
+
+const maxAcceptedSkew = 2.0
+const minItemsPerShardForSkewDetection = 16
+
+// ShardStatistics holds the load of a single shard of the FIFO sharded cache
+type ShardStatistics struct {
+	NumItems    int
+	NumBytes    uint64
+	MaxNumItems int
+}
+
+func computeSkew(statistics []ShardStatistics) float64 {
+	if len(statistics) == 0 {
+		return 0
+	}
+
+	numItems := 0
+	maxNumItems := 0
+	for _, shardStatistics := range statistics {
+		numItems += shardStatistics.NumItems
+		if shardStatistics.NumItems > maxNumItems {
+			maxNumItems = shardStatistics.NumItems
+		}
+	}
+	if numItems == 0 {
+		return 0
+	}
+
+	average := float64(numItems) / float64(len(statistics))
+
+	return float64(maxNumItems) / average
+}
@@ -3,23 +3,27 @@ module github.com/TerraDharitri/drt-go-chain-storage
 go 1.23
 
 require (
-	github.com/TerraDharitri/concurrent-map v0.0.2
 	github.com/TerraDharitri/drt-go-chain-core v1.0.1
 	github.com/TerraDharitri/drt-go-chain-logger v1.0.0
+	github.com/golang/snappy v0.0.4
 	github.com/hashicorp/golang-lru v0.6.0
-	github.com/stretchr/testify v1.7.2
+	github.com/pelletier/go-toml v1.9.3
+	github.com/stretchr/testify v1.9.0
 	github.com/syndtr/goleveldb v1.0.1-0.20220721030215-126854af5e6d
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/denisbrodbeck/machineid v1.0.1 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/golang/snappy v0.0.4 // indirect
 	github.com/mr-tron/base58 v1.2.0 // indirect
-	github.com/pelletier/go-toml v1.9.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
 	golang.org/x/net v0.2.0 // indirect
 	golang.org/x/sys v0.2.0 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
@@ -6,11 +6,15 @@ import (
 	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
 	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var _ types.Cacher = (*ImmunityCache)(nil)
+var _ types.EvictionNotifier = (*ImmunityCache)(nil)
+var _ types.StatsProvider = (*ImmunityCache)(nil)
+var _ types.HealthChecker = (*ImmunityCache)(nil)
 
 var log = logger.GetOrCreate("storage/immunitycache")
 
@@ -24,43 +28,87 @@ type ImmunityCache struct {
 	chunks                        []*immunityChunk
 	hospitality                   atomic.Counter
 	numCapacityReachedOccurrences atomic.Counter
-	mutex                         sync.RWMutex
+	// eviction counters of the chunks discarded by Clear(), so that the diagnostics cover the whole lifetime of the cache
+	numEvictedInClearedChunks         atomic.Counter
+	numFailedEvictionsInClearedChunks atomic.Counter
+	mutex                             sync.RWMutex
+	// spill is optional: when set, the evicted items are written to storage and read back on Get
+	spill    *spillPersister
+	isClosed atomic.Flag
+
+	addedDataHandlers   *dispatch.Dispatcher
+	mutEvictionHandlers sync.RWMutex
+	mapEvictionHandlers map[string]types.EvictedItemHandler
 }
 
 // NewImmunityCache creates a new cache
 func NewImmunityCache(config CacheConfig) (*ImmunityCache, error) {
 	log.Debug("NewImmunityCache", "config", config.String())
-	monitoring.MonitorNewCache(config.Name, uint64(config.MaxNumBytes))
 
 	err := config.Verify()
 	if err != nil {
 		return nil, err
 	}
 
+	monitoring.MonitorNewCache(config.Name, uint64(config.MaxNumBytes))
+
 	cache := ImmunityCache{
-		config: config,
+		config:              config,
+		addedDataHandlers:   dispatch.NewDefaultDispatcher("immunitycache"),
+		mapEvictionHandlers: make(map[string]types.EvictedItemHandler),
 	}
 
 	cache.initializeChunksWithLock()
+	monitoring.RegisterDiagnosticsProvider(config.Name, &cache)
+
 	return &cache, nil
 }
 
+// NewImmunityCacheWithSpill creates a new cache which writes the evicted (non-immune) items to the provided persister,
+// and transparently reads them back on Get. This allows smaller memory budgets, at the cost of storage lookups on misses
+func NewImmunityCacheWithSpill(config CacheConfig, args ArgsSpillPersister) (*ImmunityCache, error) {
+	err := checkArgsSpillPersister(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := NewImmunityCache(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.spill = newSpillPersister(args)
+	cache.initializeChunksWithLock()
+
+	return cache, nil
+}
+
 func (ic *ImmunityCache) initializeChunksWithLock() {
 	ic.mutex.Lock()
 	defer ic.mutex.Unlock()
 
+	for _, chunk := range ic.chunks {
+		chunkDiagnostics := chunk.Diagnose()
+		ic.numEvictedInClearedChunks.Add(int64(chunkDiagnostics.NumEvicted))
+		ic.numFailedEvictionsInClearedChunks.Add(int64(chunkDiagnostics.NumFailedEvictions))
+	}
+
 	config := ic.config
 	chunkConfig := config.getChunkConfig()
 
 	ic.chunks = make([]*immunityChunk, config.NumChunks)
 	for i := uint32(0); i < config.NumChunks; i++ {
 		ic.chunks[i] = newImmunityChunk(chunkConfig)
+		ic.chunks[i].onEvicted = ic.onItemEvicted
 	}
 }
 
 // ImmunizeKeys marks items as immune to eviction
 func (ic *ImmunityCache) ImmunizeKeys(keys [][]byte) (numNowTotal, numFutureTotal int) {
-	immuneItemsCapacityReached := ic.CountImmune()+len(keys) > int(ic.config.MaxNumItems)
+	immuneItemsCapacityReached := ic.isImmuneItemsCapacityReached(len(keys))
+	if immuneItemsCapacityReached && ic.removeExpiredImmunities() > 0 {
+		immuneItemsCapacityReached = ic.isImmuneItemsCapacityReached(len(keys))
+	}
 	if immuneItemsCapacityReached {
 		logLevel := ic.decideLogLevelOnCapacityReached()
 		log.Log(logLevel, "ImmunityCache.ImmunizeKeys(): will not immunize", "err", common.ErrImmuneItemsCapacityReached)
@@ -82,6 +130,51 @@ func (ic *ImmunityCache) ImmunizeKeys(keys [][]byte) (numNowTotal, numFutureTota
 	return
 }
 
+// ImmunizeKeysWithPrefix marks the items whose keys have the given prefix (both existing and future ones) as immune to eviction
+func (ic *ImmunityCache) ImmunizeKeysWithPrefix(prefix []byte) (numNowTotal int) {
+	// The number of keys to be immunized is not known in advance, so we require room for at least one of them
+	immuneItemsCapacityReached := ic.isImmuneItemsCapacityReached(1)
+	if immuneItemsCapacityReached && ic.removeExpiredImmunities() > 0 {
+		immuneItemsCapacityReached = ic.isImmuneItemsCapacityReached(1)
+	}
+	if immuneItemsCapacityReached {
+		logLevel := ic.decideLogLevelOnCapacityReached()
+		log.Log(logLevel, "ImmunityCache.ImmunizeKeysWithPrefix(): will not immunize", "err", common.ErrImmuneItemsCapacityReached)
+		return
+	}
+
+	ic.forgetCapacityHadBeenReachedInThePast()
+
+	for _, chunk := range ic.getChunksWithLock() {
+		numNowTotal += chunk.ImmunizeKeysWithPrefix(prefix)
+	}
+
+	return
+}
+
+// RemoveImmunityByPrefix releases the immunity of the keys having the given prefix
+func (ic *ImmunityCache) RemoveImmunityByPrefix(prefix []byte) (numRemovedTotal int) {
+	for _, chunk := range ic.getChunksWithLock() {
+		numRemovedTotal += chunk.RemoveImmunityByPrefix(prefix)
+	}
+
+	return
+}
+
+func (ic *ImmunityCache) isImmuneItemsCapacityReached(numKeysToImmunize int) bool {
+	return ic.CountImmune()+numKeysToImmunize > int(ic.config.MaxNumItems)
+}
+
+// removeExpiredImmunities releases the immunities which have expired (e.g. leaked ones, for keys never added)
+func (ic *ImmunityCache) removeExpiredImmunities() int {
+	numRemoved := 0
+	for _, chunk := range ic.getChunksWithLock() {
+		numRemoved += chunk.RemoveExpiredImmunities()
+	}
+
+	return numRemoved
+}
+
 func (ic *ImmunityCache) decideLogLevelOnCapacityReached() logger.LogLevel {
 	logLevel := logger.LogDebug
 	if ic.numCapacityReachedOccurrences.GetUint64()%capacityReachedWarningPeriod == 0 {
@@ -136,13 +229,33 @@ func (ic *ImmunityCache) getChunkByKeyWithLock(key string) *immunityChunk {
 	return ic.chunks[chunkIndex]
 }
 
-// Get gets an item (payload) by key
+func (ic *ImmunityCache) onItemEvicted(item *cacheItem, reason types.EvictionReason) {
+	if ic.spill != nil {
+		ic.spill.put(item.key, item.payload)
+	}
+
+	ic.mutEvictionHandlers.RLock()
+	for _, handler := range ic.mapEvictionHandlers {
+		go handler([]byte(item.key), item.payload, reason)
+	}
+	ic.mutEvictionHandlers.RUnlock()
+}
+
+// Get gets an item (payload) by key. If a spill persister is set, the items not found in memory are searched in storage
 func (ic *ImmunityCache) Get(key []byte) (value interface{}, ok bool) {
 	item, ok := ic.getItem(key)
 	if ok {
+		if ic.config.EvictionStrategy == common.OldestFirstEviction {
+			item.touch()
+		}
+
 		return item.payload, true
 	}
 
+	if ic.spill != nil {
+		return ic.spill.get(key)
+	}
+
 	return nil, false
 }
 
@@ -152,16 +265,25 @@ func (ic *ImmunityCache) getItem(key []byte) (*cacheItem, bool) {
 	return chunk.GetItem(string(key))
 }
 
-// Has checks is an item exists
+// Has checks is an item exists (in memory or, if a spill persister is set, in storage)
 func (ic *ImmunityCache) Has(key []byte) bool {
 	chunk := ic.getChunkByKeyWithLock(string(key))
 	_, ok := chunk.GetItem(string(key))
-	return ok
+	if ok {
+		return true
+	}
+
+	return ic.spill != nil && ic.spill.has(key)
 }
 
-// Peek gets an item
+// Peek gets an item, searching only in memory
 func (ic *ImmunityCache) Peek(key []byte) (value interface{}, ok bool) {
-	return ic.Get(key)
+	item, ok := ic.getItem(key)
+	if ok {
+		return item.payload, true
+	}
+
+	return nil, false
 }
 
 // HasOrAdd adds an item in the cache
@@ -176,6 +298,9 @@ func (ic *ImmunityCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int
 			ic.hospitality.Decrement()
 		}
 	}
+	if added {
+		ic.addedDataHandlers.Dispatch(key, value)
+	}
 
 	return has, added
 }
@@ -195,7 +320,14 @@ func (ic *ImmunityCache) Remove(key []byte) {
 // TODO: In the future, add this method to the "storage.Cacher" interface. EN-6739.
 func (ic *ImmunityCache) RemoveWithResult(key []byte) bool {
 	chunk := ic.getChunkByKeyWithLock(string(key))
-	return chunk.RemoveItem(string(key))
+	removed := chunk.RemoveItem(string(key))
+
+	if ic.spill != nil {
+		removedFromStorage := ic.spill.remove(key)
+		removed = removed || removedFromStorage
+	}
+
+	return removed
 }
 
 // RemoveOldest is not implemented
@@ -270,14 +402,39 @@ func (ic *ImmunityCache) Keys() [][]byte {
 	return keys
 }
 
-// RegisterHandler is not implemented
-func (ic *ImmunityCache) RegisterHandler(func(key []byte, value interface{}), string) {
-	log.Error("ImmunityCache.RegisterHandler is not implemented")
+// RegisterHandler registers a new handler to be called when a new data is added. The duplicates, as well as the items
+// rejected for lack of room, are not notified
+func (ic *ImmunityCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	ic.addedDataHandlers.Register(id, handler)
 }
 
 // UnRegisterHandler removes the handler from the list
-func (ic *ImmunityCache) UnRegisterHandler(_ string) {
-	log.Error("ImmunityCache.UnRegisterHandler is not implemented")
+func (ic *ImmunityCache) UnRegisterHandler(id string) {
+	ic.addedDataHandlers.Unregister(id)
+}
+
+// RegisterEvictionHandler registers a new handler to be called whenever a (non-immune) item is evicted to make room for new ones
+func (ic *ImmunityCache) RegisterEvictionHandler(handler types.EvictedItemHandler, id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a cacher object")
+		return
+	}
+
+	ic.mutEvictionHandlers.Lock()
+	ic.mapEvictionHandlers[id] = handler
+	ic.mutEvictionHandlers.Unlock()
+}
+
+// UnRegisterEvictionHandler removes the eviction handler from the list
+func (ic *ImmunityCache) UnRegisterEvictionHandler(id string) {
+	ic.mutEvictionHandlers.Lock()
+	delete(ic.mapEvictionHandlers, id)
+	ic.mutEvictionHandlers.Unlock()
 }
 
 // ForEachItem iterates over the items in the cache
@@ -287,24 +444,54 @@ func (ic *ImmunityCache) ForEachItem(function types.ForEachItem) {
 	}
 }
 
-// Diagnose displays a summary of the internal state of the cache
-func (ic *ImmunityCache) Diagnose(_ bool) {
-	count := ic.Count()
-	countImmune := ic.CountImmune()
-	numBytes := ic.NumBytes()
-	hospitality := ic.hospitality.Get()
+// Diagnostics returns a summary of the internal state of the cache, including per-chunk details
+func (ic *ImmunityCache) Diagnostics() CacheDiagnostics {
+	chunks := ic.getChunksWithLock()
+	diagnostics := CacheDiagnostics{
+		Name:               ic.config.Name,
+		NumEvicted:         ic.numEvictedInClearedChunks.GetUint64(),
+		NumFailedEvictions: ic.numFailedEvictionsInClearedChunks.GetUint64(),
+		Hospitality:        ic.hospitality.Get(),
+		Chunks:             make([]ChunkDiagnostics, len(chunks)),
+	}
+
+	for i, chunk := range chunks {
+		chunkDiagnostics := chunk.Diagnose()
+		diagnostics.Chunks[i] = chunkDiagnostics
+		diagnostics.NumItems += chunkDiagnostics.NumItems
+		diagnostics.NumImmune += chunkDiagnostics.NumImmune
+		diagnostics.NumBytes += chunkDiagnostics.NumBytes
+		diagnostics.NumEvicted += chunkDiagnostics.NumEvicted
+		diagnostics.NumFailedEvictions += chunkDiagnostics.NumFailedEvictions
+	}
+
+	return diagnostics
+}
+
+// DiagnosticsSnapshot returns the Diagnostics, exposed by the monitoring debug handler
+func (ic *ImmunityCache) DiagnosticsSnapshot() interface{} {
+	return ic.Diagnostics()
+}
+
+// Diagnose displays a summary of the internal state of the cache. If deep is set, the state of each chunk is displayed, as well
+func (ic *ImmunityCache) Diagnose(deep bool) {
+	diagnostics := ic.Diagnostics()
+	hospitality := diagnostics.Hospitality
 
 	isNotHospitable := hospitality <= hospitalityWarnThreshold
 	if isNotHospitable {
 		// After emitting a Warn, we reset the hospitality indicator
 		log.Warn("ImmunityCache.Diagnose(): cache is not hospitable",
-			"name", ic.config.Name,
-			"count", count,
-			"countImmune", countImmune,
-			"numBytes", numBytes,
+			"name", diagnostics.Name,
+			"count", diagnostics.NumItems,
+			"countImmune", diagnostics.NumImmune,
+			"numBytes", diagnostics.NumBytes,
+			"numEvicted", diagnostics.NumEvicted,
+			"numFailedEvictions", diagnostics.NumFailedEvictions,
 			"hospitality", hospitality,
 		)
 		ic.hospitality.Reset()
+		ic.diagnoseChunks(deep, diagnostics)
 		return
 	}
 
@@ -313,19 +500,70 @@ func (ic *ImmunityCache) Diagnose(_ bool) {
 	}
 
 	log.Trace("ImmunityCache.Diagnose()",
-		"name", ic.config.Name,
-		"count", count,
-		"countImmune", countImmune,
-		"numBytes", numBytes,
+		"name", diagnostics.Name,
+		"count", diagnostics.NumItems,
+		"countImmune", diagnostics.NumImmune,
+		"numBytes", diagnostics.NumBytes,
+		"numEvicted", diagnostics.NumEvicted,
+		"numFailedEvictions", diagnostics.NumFailedEvictions,
 		"hospitality", hospitality,
 	)
+	ic.diagnoseChunks(deep, diagnostics)
+}
+
+func (ic *ImmunityCache) diagnoseChunks(deep bool, diagnostics CacheDiagnostics) {
+	if !deep || log.GetLevel() > logger.LogDebug {
+		return
+	}
+
+	for i, chunkDiagnostics := range diagnostics.Chunks {
+		log.Debug("ImmunityCache.Diagnose(): chunk",
+			"name", diagnostics.Name,
+			"chunk", i,
+			"count", chunkDiagnostics.NumItems,
+			"countImmune", chunkDiagnostics.NumImmune,
+			"numBytes", chunkDiagnostics.NumBytes,
+			"numEvicted", chunkDiagnostics.NumEvicted,
+			"numFailedEvictions", chunkDiagnostics.NumFailedEvictions,
+		)
+	}
 }
 
-// Close does nothing for this cacher implementation
+// Close closes the spill persister, if any, and deregisters the cache and its diagnostics from the monitoring
 func (ic *ImmunityCache) Close() error {
+	if ic.isClosed.SetReturningPrevious() {
+		return nil
+	}
+
+	monitoring.MonitorClosedCache(ic.config.Name, uint64(ic.config.MaxNumBytes))
+	monitoring.DeregisterDiagnosticsProvider(ic.config.Name, ic)
+	if ic.spill != nil {
+		return ic.spill.close()
+	}
+
 	return nil
 }
 
+// Stats returns the key stats of the cache. Unlike SizeInBytesContained, the size in bytes is the one of the items held
+func (ic *ImmunityCache) Stats() map[string]interface{} {
+	stats := types.CacheStats(string(common.ImmunityCache), ic)
+	stats[types.StatSizeInBytes] = ic.NumBytes()
+	stats["numImmune"] = ic.CountImmune()
+	stats["numChunks"] = ic.config.NumChunks
+
+	return stats
+}
+
+// Health reports the cache as degraded once its immune items fill its capacity, as no more keys can be immunized
+func (ic *ImmunityCache) Health() types.HealthStatus {
+	status := types.NewHealthStatus()
+	if ic.isImmuneItemsCapacityReached(1) {
+		status.Degrade("immune items capacity reached: %d", ic.CountImmune())
+	}
+
+	return status
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (ic *ImmunityCache) IsInterfaceNil() bool {
 	return ic == nil
@@ -1,22 +1,33 @@
 package immunitycache
 
 import (
+	"time"
+
 	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 )
 
 type cacheItem struct {
-	payload  interface{}
-	key      string
-	size     int
-	isImmune atomic.Flag
+	payload   interface{}
+	key       string
+	size      int
+	isImmune  atomic.Flag
+	timestamp atomic.Counter
 }
 
 func newCacheItem(payload interface{}, key string, size int) *cacheItem {
-	return &cacheItem{
+	item := &cacheItem{
 		payload: payload,
 		key:     key,
 		size:    size,
 	}
+	item.touch()
+
+	return item
+}
+
+// touch refreshes the timestamp of the item, used by the "oldest first" eviction strategy
+func (item *cacheItem) touch() {
+	item.timestamp.Set(time.Now().UnixNano())
 }
 
 func (item *cacheItem) isImmuneToEviction() bool {
@@ -26,3 +37,7 @@ func (item *cacheItem) isImmuneToEviction() bool {
 func (item *cacheItem) immunizeAgainstEviction() {
 	_ = item.isImmune.SetReturningPrevious()
 }
+
+func (item *cacheItem) removeImmunity() {
+	item.isImmune.Reset()
+}
@@ -6,9 +6,12 @@ import (
 	"math"
 	"sync"
 	"testing"
+	"time"
 
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,6 +49,14 @@ func TestNewImmunityCache(t *testing.T) {
 	invalidConfig = config
 	invalidConfig.NumItemsToPreemptivelyEvict = 0
 	requireErrorOnNewCache(t, invalidConfig, common.ErrInvalidConfig, "config.NumItemsToPreemptivelyEvict")
+
+	invalidConfig = config
+	invalidConfig.EvictionStrategy = "unknown"
+	requireErrorOnNewCache(t, invalidConfig, common.ErrInvalidConfig, "config.EvictionStrategy")
+
+	invalidConfig = config
+	invalidConfig.ImmunityExpiration = -time.Second
+	requireErrorOnNewCache(t, invalidConfig, common.ErrInvalidConfig, "config.ImmunityExpiration")
 }
 
 func requireErrorOnNewCache(t *testing.T, config CacheConfig, errExpected error, errPartialMessage string) {
@@ -79,11 +90,13 @@ func TestImmunityCache_ImmunizeAgainstEviction(t *testing.T) {
 
 func TestImmunityCache_ImmunizeDoesNothingIfCapacityReached(t *testing.T) {
 	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+	require.True(t, cache.Health().IsOK())
 
 	numNow, numFuture := cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "c", "d"}))
 	require.Equal(t, 0, numNow)
 	require.Equal(t, 4, numFuture)
 	require.Equal(t, 4, cache.CountImmune())
+	require.Equal(t, types.HealthDegraded, cache.Health().State)
 
 	numNow, numFuture = cache.ImmunizeKeys(keysAsBytes([]string{"e", "f", "g", "h"}))
 	require.Equal(t, 0, numNow)
@@ -91,6 +104,102 @@ func TestImmunityCache_ImmunizeDoesNothingIfCapacityReached(t *testing.T) {
 	require.Equal(t, 4, cache.CountImmune())
 }
 
+func TestImmunityCache_ImmunizeReleasesExpiredImmunitiesIfCapacityReached(t *testing.T) {
+	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+	cache.config.ImmunityExpiration = time.Millisecond * 50
+	cache.initializeChunksWithLock()
+
+	// Leaked immunities, keys never added
+	numNow, numFuture := cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "c", "d"}))
+	require.Equal(t, 0, numNow)
+	require.Equal(t, 4, numFuture)
+
+	numNow, numFuture = cache.ImmunizeKeys(keysAsBytes([]string{"e", "f"}))
+	require.Equal(t, 0, numNow)
+	require.Equal(t, 0, numFuture)
+	require.Equal(t, 4, cache.CountImmune())
+
+	time.Sleep(time.Millisecond * 100)
+
+	numNow, numFuture = cache.ImmunizeKeys(keysAsBytes([]string{"e", "f"}))
+	require.Equal(t, 0, numNow)
+	require.Equal(t, 2, numFuture)
+	require.Equal(t, 2, cache.CountImmune())
+}
+
+func TestImmunityCache_ImmunityExpiresOnEviction(t *testing.T) {
+	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+	cache.config.ImmunityExpiration = time.Millisecond * 50
+	cache.initializeChunksWithLock()
+
+	cache.addTestItems("a", "b", "c", "d")
+	numNow, _ := cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "c", "d"}))
+	require.Equal(t, 4, numNow)
+
+	// All items are immune, nothing can be evicted
+	_, added := cache.HasOrAdd([]byte("e"), "foo", 1)
+	require.False(t, added)
+
+	time.Sleep(time.Millisecond * 100)
+
+	_, added = cache.HasOrAdd([]byte("e"), "foo", 1)
+	require.True(t, added)
+	require.ElementsMatch(t, []string{"b", "c", "d", "e"}, keysAsStrings(cache.Keys()))
+	require.Equal(t, 0, cache.CountImmune())
+}
+
+func TestImmunityCache_ImmunizeKeysWithPrefix(t *testing.T) {
+	cache := newCacheToTest(4, 16, maxNumBytesUpperBound)
+
+	cache.addTestItems("r1-a", "r1-b", "r2-a", "x")
+	numNow := cache.ImmunizeKeysWithPrefix([]byte("r1-"))
+	require.Equal(t, 2, numNow)
+	require.Equal(t, 2, cache.CountImmune())
+
+	// Future keys having the prefix are immunized on add
+	cache.addTestItems("r1-c", "r2-b")
+	require.Equal(t, 3, cache.CountImmune())
+
+	numRemoved := cache.RemoveImmunityByPrefix([]byte("r1-"))
+	require.Equal(t, 3, numRemoved)
+	require.Equal(t, 0, cache.CountImmune())
+
+	cache.addTestItems("r1-d")
+	require.Equal(t, 0, cache.CountImmune())
+}
+
+func TestImmunityCache_ImmunizeKeysWithPrefixDoesNothingIfCapacityReached(t *testing.T) {
+	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "c", "d"}))
+	cache.addTestItems("r1-a")
+
+	numNow := cache.ImmunizeKeysWithPrefix([]byte("r1-"))
+	require.Equal(t, 0, numNow)
+	require.Equal(t, 4, cache.CountImmune())
+}
+
+func TestImmunityCache_OldestFirstEvictionRefreshesTimestampOnGet(t *testing.T) {
+	cache, _ := NewImmunityCache(CacheConfig{
+		Name:                        "test",
+		NumChunks:                   1,
+		MaxNumItems:                 4,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 1,
+		EvictionStrategy:            common.OldestFirstEviction,
+	})
+
+	cache.addTestItems("a", "b", "c", "d")
+	time.Sleep(time.Millisecond)
+	_, _ = cache.Get([]byte("a"))
+	// Has and Peek do not refresh the timestamp
+	_ = cache.Has([]byte("b"))
+	_, _ = cache.Peek([]byte("b"))
+
+	cache.addTestItems("e")
+	require.ElementsMatch(t, []string{"a", "c", "d", "e"}, keysAsStrings(cache.Keys()))
+}
+
 func TestImmunityCache_AddThenRemove(t *testing.T) {
 	cache := newCacheToTest(1, 8, maxNumBytesUpperBound)
 
@@ -187,16 +296,109 @@ func TestImmunityCache_AddThenRemove_ChangesNumBytes(t *testing.T) {
 	_, _ = cache.HasOrAdd([]byte("d"), "foo-d", 200)
 	require.Equal(t, 1000, cache.NumBytes())
 
-	// Eviction takes place
+	// Eviction takes place, until the added item fits: "a", "b" and "c" (100 + 300 + 400) are evicted
 	_, _ = cache.HasOrAdd([]byte("e"), "foo-e", 500)
-	// Edge case, added item overflows.
-	// Should not be an issue in practice, when we preemptively evict a large number of items.
-	require.Equal(t, 1400, cache.NumBytes())
-	require.ElementsMatch(t, []string{"b", "c", "d", "e"}, keysAsStrings(cache.Keys()))
+	require.Equal(t, 700, cache.NumBytes())
+	require.ElementsMatch(t, []string{"d", "e"}, keysAsStrings(cache.Keys()))
 
-	// "b" and "c" (300 + 400) will be evicted
+	// "d" (200) will be evicted
 	_, _ = cache.HasOrAdd([]byte("f"), "foo-f", 400)
-	require.Equal(t, 1100, cache.NumBytes())
+	require.Equal(t, 900, cache.NumBytes())
+	require.ElementsMatch(t, []string{"e", "f"}, keysAsStrings(cache.Keys()))
+
+	// Too large to ever fit, nothing is evicted
+	_, added := cache.HasOrAdd([]byte("g"), "foo-g", 1001)
+	require.False(t, added)
+	require.Equal(t, 900, cache.NumBytes())
+}
+
+func TestImmunityCache_AddDoesNotWork_WhenBytesTakenByImmune(t *testing.T) {
+	cache := newCacheToTest(1, 8, 1000)
+
+	_, _ = cache.HasOrAdd([]byte("a"), "foo-a", 100)
+	_, _ = cache.HasOrAdd([]byte("b"), "foo-b", 800)
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"b"}))
+
+	// The immune "b" leaves no room, even if "a" was evicted, hence nothing is evicted
+	_, added := cache.HasOrAdd([]byte("c"), "foo-c", 300)
+	require.False(t, added)
+	require.ElementsMatch(t, []string{"a", "b"}, keysAsStrings(cache.Keys()))
+	require.Equal(t, 900, cache.NumBytes())
+
+	// Once evictable items are evicted, there is room for "d"
+	_, added = cache.HasOrAdd([]byte("d"), "foo-d", 200)
+	require.True(t, added)
+	require.ElementsMatch(t, []string{"b", "d"}, keysAsStrings(cache.Keys()))
+}
+
+func TestImmunityCache_EvictionHandlersReceiveReason(t *testing.T) {
+	cache := newCacheToTest(1, 4, 1000)
+
+	chEvicted := make(chan string, 10)
+	cache.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		chEvicted <- fmt.Sprintf("%s:%v:%s", key, value, reason)
+	}, "id")
+	cache.RegisterEvictionHandler(nil, "nil")
+
+	cache.addTestItems("a", "b", "c", "d", "e")
+	// first, "b" is evicted to make room for a new item, then "c", "d" and "e" to make room for its bytes
+	_, added := cache.HasOrAdd([]byte("large"), "foo-large", 998)
+	require.True(t, added)
+
+	evicted := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		select {
+		case item := <-chEvicted:
+			evicted = append(evicted, item)
+		case <-time.After(time.Second * 2):
+			require.Fail(t, "eviction handler should have been called")
+		}
+	}
+	expected := []string{"a:foo-a:capacity", "b:foo-b:capacity", "c:foo-c:size", "d:foo-d:size", "e:foo-e:size"}
+	require.ElementsMatch(t, expected, evicted)
+
+	// explicit removals are not notified
+	cache.Remove([]byte("large"))
+	cache.UnRegisterEvictionHandler("id")
+	cache.addTestItems("f", "g", "h", "i", "j")
+	select {
+	case item := <-chEvicted:
+		require.Fail(t, "eviction handler should not have been called", item)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestImmunityCache_AddedDataHandlers(t *testing.T) {
+	cache := newCacheToTest(1, 4, 1000)
+
+	chAdded := make(chan string, 10)
+	cache.RegisterHandler(func(key []byte, value interface{}) {
+		chAdded <- fmt.Sprintf("%s:%v", key, value)
+	}, "id")
+	cache.RegisterHandler(nil, "nil")
+
+	cache.addTestItems("a", "b")
+	added := make([]string, 0, 2)
+	for i := 0; i < 2; i++ {
+		select {
+		case item := <-chAdded:
+			added = append(added, item)
+		case <-time.After(time.Second * 2):
+			require.Fail(t, "handler should have been called")
+		}
+	}
+	require.ElementsMatch(t, []string{"a:foo-a", "b:foo-b"}, added)
+
+	// duplicates and rejected items are not notified
+	_, _ = cache.HasOrAdd([]byte("a"), "foo-a", 1)
+	_, _ = cache.HasOrAdd([]byte("huge"), "foo-huge", 2000)
+	cache.UnRegisterHandler("id")
+	cache.addTestItems("c")
+	select {
+	case item := <-chAdded:
+		require.Fail(t, "handler should not have been called", item)
+	case <-time.After(time.Millisecond * 100):
+	}
 }
 
 func TestImmunityCache_AddDoesNotWork_WhenFullWithImmune(t *testing.T) {
@@ -277,6 +479,46 @@ func TestImmunityCache_DiagnoseResetsHospitalityAfterWarn(t *testing.T) {
 	require.Equal(t, 0, int(cache.hospitality.Get()))
 }
 
+func TestImmunityCache_Diagnostics(t *testing.T) {
+	cache := newCacheToTest(1, 4, 1000)
+	cache.addTestItems("a", "b", "c", "d")
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a", "b"}))
+
+	// "c" and "d" are evicted
+	cache.addTestItems("e", "f")
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"f"}))
+	// "e" is evicted
+	cache.addTestItems("g")
+
+	diagnostics := cache.Diagnostics()
+	require.Equal(t, "test", diagnostics.Name)
+	require.Equal(t, 4, diagnostics.NumItems)
+	require.Equal(t, 3, diagnostics.NumImmune)
+	require.Equal(t, 4*100, diagnostics.NumBytes)
+	require.Equal(t, uint64(3), diagnostics.NumEvicted)
+	require.Equal(t, uint64(0), diagnostics.NumFailedEvictions)
+	require.Equal(t, int64(7), diagnostics.Hospitality)
+	require.Equal(t, []ChunkDiagnostics{
+		{NumItems: 4, NumImmune: 3, NumBytes: 400, NumEvicted: 3},
+	}, diagnostics.Chunks)
+
+	// Only immune items are left, the eviction fails
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"g"}))
+	cache.addTestItems("h")
+	diagnostics = cache.Diagnostics()
+	require.Equal(t, uint64(1), diagnostics.NumFailedEvictions)
+
+	// Eviction counters survive Clear()
+	cache.Clear()
+	diagnostics = cache.Diagnostics()
+	require.Equal(t, 0, diagnostics.NumItems)
+	require.Equal(t, uint64(3), diagnostics.NumEvicted)
+	require.Equal(t, uint64(1), diagnostics.NumFailedEvictions)
+	require.Equal(t, uint64(0), diagnostics.Chunks[0].NumEvicted)
+
+	cache.Diagnose(true)
+}
+
 func TestImmunityCache_ClearConcurrentWithRangeOverChunks(t *testing.T) {
 	cache := newCacheToTest(16, 4, 1000)
 	require.Equal(t, 16, len(cache.chunks))
@@ -310,6 +552,37 @@ func TestImmunityCache_CloseDoesNotErr(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestImmunityCache_CloseShouldDeregisterTheCacheOnce(t *testing.T) {
+	config := CacheConfig{
+		Name:                        "TestImmunityCache_CloseShouldDeregisterTheCacheOnce",
+		NumChunks:                   1,
+		MaxNumItems:                 4,
+		MaxNumBytes:                 1000,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+	isRegistered := func() bool {
+		for _, info := range monitoring.ListCaches() {
+			if info.Name == config.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	cache, err := NewImmunityCache(config)
+	require.Nil(t, err)
+	assert.True(t, isRegistered())
+
+	assert.Nil(t, cache.Close())
+	assert.False(t, isRegistered())
+	assert.Nil(t, cache.Close())
+
+	config.NumChunks = 0
+	_, err = NewImmunityCache(config)
+	assert.NotNil(t, err)
+	assert.False(t, isRegistered(), "an invalid cache should not be registered")
+}
+
 func TestImmunityCache_DecideLogLevelOnCapacityReached(t *testing.T) {
 	cache := newCacheToTest(1, 4, 1000)
 
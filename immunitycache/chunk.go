@@ -2,22 +2,34 @@ package immunitycache
 
 import (
 	"container/list"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
-var emptyStruct struct{}
-
 type immunityChunk struct {
 	config      immunityChunkConfig
 	items       map[string]chunkItemWrapper
 	itemsAsList *list.List
-	immuneKeys  map[string]struct{}
-	numBytes    int
-	mutex       sync.RWMutex
+	immuneKeys  map[string]time.Time // the value is the immunity's expiration time (zero, if it never expires)
+	// immunePrefixes holds the prefixes of the keys to be immunized when added, along with the immunity's expiration time
+	immunePrefixes map[string]time.Time
+	// numImmuneItems and numImmuneBytes account for the stored items which are immune to eviction, while
+	// nextImmunityExpiration is no later than the earliest expiration of their immunities (zero, if none expires)
+	numImmuneItems         int
+	numImmuneBytes         int
+	nextImmunityExpiration time.Time
+	numBytes               int
+	numEvicted             uint64
+	numFailedEvictions     uint64
+	mutex                  sync.RWMutex
+	// onEvicted, if set, is called (under the chunk's lock) for each item evicted to make room for new ones
+	onEvicted func(item *cacheItem, reason types.EvictionReason)
 }
 
 type chunkItemWrapper struct {
@@ -32,7 +44,9 @@ func newImmunityChunk(config immunityChunkConfig) *immunityChunk {
 		config:      config,
 		items:       make(map[string]chunkItemWrapper),
 		itemsAsList: list.New(),
-		immuneKeys:  make(map[string]struct{}),
+		immuneKeys:  make(map[string]time.Time),
+
+		immunePrefixes: make(map[string]time.Time),
 	}
 }
 
@@ -41,12 +55,14 @@ func (chunk *immunityChunk) ImmunizeKeys(keys [][]byte) (numNow, numFuture int)
 	chunk.mutex.Lock()
 	defer chunk.mutex.Unlock()
 
+	expirationTime := chunk.computeImmunityExpirationTime()
+
 	for _, key := range keys {
 		item, ok := chunk.getItemNoLock(string(key))
 
 		if ok {
 			// Item exists, immunize now!
-			item.immunizeAgainstEviction()
+			chunk.immunizeItemNoLock(item, expirationTime)
 			numNow++
 		} else {
 			// Item not yet in cache, will be immunized in the future
@@ -54,12 +70,135 @@ func (chunk *immunityChunk) ImmunizeKeys(keys [][]byte) (numNow, numFuture int)
 		}
 
 		// Disregarding the items presence, we hold the immune key
-		chunk.immuneKeys[string(key)] = emptyStruct
+		chunk.immuneKeys[string(key)] = expirationTime
+	}
+
+	return
+}
+
+// ImmunizeKeysWithPrefix marks the existing keys having the given prefix as immune to eviction, and holds the prefix
+// so that keys added in the future are immunized, as well
+func (chunk *immunityChunk) ImmunizeKeysWithPrefix(prefix []byte) (numNow int) {
+	chunk.mutex.Lock()
+	defer chunk.mutex.Unlock()
+
+	expirationTime := chunk.computeImmunityExpirationTime()
+	chunk.immunePrefixes[string(prefix)] = expirationTime
+
+	for key, wrapper := range chunk.items {
+		if !strings.HasPrefix(key, string(prefix)) {
+			continue
+		}
+
+		chunk.immunizeItemNoLock(wrapper.item, expirationTime)
+		chunk.immuneKeys[key] = expirationTime
+		numNow++
+	}
+
+	return
+}
+
+// RemoveImmunityByPrefix releases the immunity of all keys having the given prefix, including the intent to
+// immunize them in the future. It returns the number of keys which are not immune anymore
+func (chunk *immunityChunk) RemoveImmunityByPrefix(prefix []byte) (numRemoved int) {
+	chunk.mutex.Lock()
+	defer chunk.mutex.Unlock()
+
+	delete(chunk.immunePrefixes, string(prefix))
+
+	for key := range chunk.immuneKeys {
+		if !strings.HasPrefix(key, string(prefix)) {
+			continue
+		}
+
+		chunk.removeImmunityNoLock(key)
+		numRemoved++
 	}
 
 	return
 }
 
+func (chunk *immunityChunk) removeImmunityNoLock(key string) {
+	delete(chunk.immuneKeys, key)
+
+	item, ok := chunk.getItemNoLock(key)
+	if ok {
+		chunk.removeItemImmunityNoLock(item)
+	}
+}
+
+func (chunk *immunityChunk) immunizeItemNoLock(item *cacheItem, expirationTime time.Time) {
+	if !expirationTime.IsZero() && (chunk.nextImmunityExpiration.IsZero() || expirationTime.Before(chunk.nextImmunityExpiration)) {
+		chunk.nextImmunityExpiration = expirationTime
+	}
+	if item.isImmuneToEviction() {
+		return
+	}
+
+	item.immunizeAgainstEviction()
+	chunk.numImmuneItems++
+	chunk.numImmuneBytes += item.size
+}
+
+func (chunk *immunityChunk) removeItemImmunityNoLock(item *cacheItem) {
+	if !item.isImmuneToEviction() {
+		return
+	}
+
+	item.removeImmunity()
+	chunk.numImmuneItems--
+	chunk.numImmuneBytes -= item.size
+}
+
+func (chunk *immunityChunk) computeImmunityExpirationTime() time.Time {
+	if chunk.config.immunityExpiration == 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(chunk.config.immunityExpiration)
+}
+
+func isImmunityExpired(expirationTime time.Time, now time.Time) bool {
+	return !expirationTime.IsZero() && now.After(expirationTime)
+}
+
+// RemoveExpiredImmunities makes the items whose immunity has expired evictable again
+func (chunk *immunityChunk) RemoveExpiredImmunities() int {
+	chunk.mutex.Lock()
+	defer chunk.mutex.Unlock()
+
+	return chunk.removeExpiredImmunitiesNoLock()
+}
+
+func (chunk *immunityChunk) removeExpiredImmunitiesNoLock() int {
+	if chunk.config.immunityExpiration == 0 {
+		return 0
+	}
+
+	numRemoved := 0
+	now := time.Now()
+	chunk.nextImmunityExpiration = time.Time{}
+	for key, expirationTime := range chunk.immuneKeys {
+		if !isImmunityExpired(expirationTime, now) {
+			if chunk.nextImmunityExpiration.IsZero() || expirationTime.Before(chunk.nextImmunityExpiration) {
+				chunk.nextImmunityExpiration = expirationTime
+			}
+			continue
+		}
+
+		chunk.removeImmunityNoLock(key)
+		numRemoved++
+	}
+
+	for prefix, expirationTime := range chunk.immunePrefixes {
+		if isImmunityExpired(expirationTime, now) {
+			delete(chunk.immunePrefixes, prefix)
+		}
+	}
+
+	return numRemoved
+}
+
 func (chunk *immunityChunk) getItemNoLock(key string) (*cacheItem, bool) {
 	wrapper, ok := chunk.items[key]
 	if !ok {
@@ -74,7 +213,13 @@ func (chunk *immunityChunk) AddItem(item *cacheItem) (has, added bool) {
 	chunk.mutex.Lock()
 	defer chunk.mutex.Unlock()
 
-	err := chunk.evictItemsIfCapacityExceededNoLock()
+	if chunk.isTooLargeNoLock(item) {
+		// Evicting would not make room for the new item
+		log.Trace("immunityChunk.AddItem(): item too large", "name", chunk.config.cacheName, "size", item.size)
+		return chunk.itemExistsNoLock(item), false
+	}
+
+	err := chunk.evictItemsIfCapacityExceededNoLock(item.size)
 	if err != nil {
 		// No more room for the new item
 		return false, false
@@ -91,42 +236,114 @@ func (chunk *immunityChunk) AddItem(item *cacheItem) (has, added bool) {
 	return false, true
 }
 
-func (chunk *immunityChunk) evictItemsIfCapacityExceededNoLock() error {
-	if !chunk.isCapacityExceededNoLock() {
+func (chunk *immunityChunk) isTooLargeNoLock(item *cacheItem) bool {
+	return item.size > int(chunk.config.maxNumBytes)
+}
+
+// evictItemsIfCapacityExceededNoLock makes room for an incoming item of the given size,
+// honoring both the bound on the number of items and the bound on the number of bytes.
+// Nothing is evicted if the immune items would leave no room for the incoming item anyway
+func (chunk *immunityChunk) evictItemsIfCapacityExceededNoLock(incomingSize int) error {
+	if !chunk.isCapacityExceededNoLock(incomingSize) {
 		return nil
 	}
+	if !chunk.fitsAlongImmuneItemsNoLock(incomingSize) {
+		chunk.monitorEvictionNoLock(0, common.ErrFailedCacheEviction)
+		return common.ErrFailedCacheEviction
+	}
 
-	numRemoved, err := chunk.evictItemsNoLock()
+	numRemoved, err := chunk.evictItemsNoLock(incomingSize)
 	chunk.monitorEvictionNoLock(numRemoved, err)
 	return err
 }
 
-func (chunk *immunityChunk) isCapacityExceededNoLock() bool {
-	tooManyItems := len(chunk.items) >= int(chunk.config.maxNumItems)
-	tooManyBytes := chunk.numBytes >= int(chunk.config.maxNumBytes)
-	return tooManyItems || tooManyBytes
+// fitsAlongImmuneItemsNoLock tells whether an incoming item of the given size would fit once all the evictable items
+// are evicted. The items whose immunity has expired are evictable: their immunities are removed, if any has expired
+// since the last removal, before checking again
+func (chunk *immunityChunk) fitsAlongImmuneItemsNoLock(incomingSize int) bool {
+	if chunk.fitsAlongImmuneCountersNoLock(incomingSize) {
+		return true
+	}
+	if !isImmunityExpired(chunk.nextImmunityExpiration, time.Now()) {
+		return false
+	}
+
+	chunk.removeExpiredImmunitiesNoLock()
+	return chunk.fitsAlongImmuneCountersNoLock(incomingSize)
+}
+
+func (chunk *immunityChunk) fitsAlongImmuneCountersNoLock(incomingSize int) bool {
+	return chunk.numImmuneItems < int(chunk.config.maxNumItems) && chunk.numImmuneBytes+incomingSize <= int(chunk.config.maxNumBytes)
+}
+
+func (chunk *immunityChunk) isCapacityExceededNoLock(incomingSize int) bool {
+	return chunk.hasTooManyItemsNoLock() || chunk.hasTooManyBytesNoLock(incomingSize)
+}
+
+func (chunk *immunityChunk) hasTooManyItemsNoLock() bool {
+	return len(chunk.items) >= int(chunk.config.maxNumItems)
+}
+
+// hasTooManyBytesNoLock tells whether storing an incoming item of the given size would exceed the bound on the number of
+// bytes. The incoming size is accounted for, so that the last added item can not overflow the bound
+func (chunk *immunityChunk) hasTooManyBytesNoLock(incomingSize int) bool {
+	return chunk.numBytes+incomingSize > int(chunk.config.maxNumBytes)
+}
+
+// evictionReasonNoLock tells which of the bounds requires the next eviction step, the number of items taking precedence
+func (chunk *immunityChunk) evictionReasonNoLock() types.EvictionReason {
+	if chunk.hasTooManyItemsNoLock() {
+		return types.EvictionReasonCapacity
+	}
+
+	return types.EvictionReasonSize
 }
 
-func (chunk *immunityChunk) evictItemsNoLock() (numRemoved int, err error) {
+func (chunk *immunityChunk) evictItemsNoLock(incomingSize int) (numRemoved int, err error) {
 	numToRemoveEachStep := int(chunk.config.numItemsToPreemptivelyEvict)
 
 	// We perform the first step out of the loop in order to detect & return error
-	numRemovedInStep := chunk.removeOldestNoLock(numToRemoveEachStep)
+	numRemovedInStep := chunk.removeOldestNoLock(numToRemoveEachStep, chunk.evictionReasonNoLock())
+	if numRemovedInStep == 0 && chunk.removeExpiredImmunitiesNoLock() > 0 {
+		// Only immune items were left, but some of them are not immune anymore
+		numRemovedInStep = chunk.removeOldestNoLock(numToRemoveEachStep, chunk.evictionReasonNoLock())
+	}
 	numRemoved += numRemovedInStep
 
 	if numRemovedInStep == 0 {
 		return 0, common.ErrFailedCacheEviction
 	}
 
-	for chunk.isCapacityExceededNoLock() && numRemovedInStep == numToRemoveEachStep {
-		numRemovedInStep = chunk.removeOldestNoLock(numToRemoveEachStep)
+	for chunk.isCapacityExceededNoLock(incomingSize) && numRemovedInStep == numToRemoveEachStep {
+		numRemovedInStep = chunk.removeOldestNoLock(numToRemoveEachStep, chunk.evictionReasonNoLock())
 		numRemoved += numRemovedInStep
 	}
 
+	if chunk.isCapacityExceededNoLock(incomingSize) {
+		// Only immune items were left, and they still take too much room
+		return numRemoved, common.ErrFailedCacheEviction
+	}
+
 	return numRemoved, nil
 }
 
-func (chunk *immunityChunk) removeOldestNoLock(numToRemove int) int {
+// removeOldestNoLock evicts a number of non-immune items, in the order given by the configured eviction strategy
+func (chunk *immunityChunk) removeOldestNoLock(numToRemove int, reason types.EvictionReason) int {
+	switch chunk.config.evictionStrategy {
+	case common.OldestFirstEviction:
+		return chunk.removeSortedNoLock(numToRemove, reason, func(a, b *cacheItem) bool {
+			return a.timestamp.Get() < b.timestamp.Get()
+		})
+	case common.LargestFirstEviction:
+		return chunk.removeSortedNoLock(numToRemove, reason, func(a, b *cacheItem) bool {
+			return a.size > b.size
+		})
+	default:
+		return chunk.removeInInsertionOrderNoLock(numToRemove, reason)
+	}
+}
+
+func (chunk *immunityChunk) removeInInsertionOrderNoLock(numToRemove int, reason types.EvictionReason) int {
 	numRemoved := 0
 	element := chunk.itemsAsList.Front()
 
@@ -141,22 +358,60 @@ func (chunk *immunityChunk) removeOldestNoLock(numToRemove int) int {
 		elementToRemove := element
 		element = element.Next()
 
-		chunk.removeNoLock(elementToRemove)
+		chunk.evictNoLock(elementToRemove, reason)
+		numRemoved++
+	}
+
+	return numRemoved
+}
+
+// removeSortedNoLock sorts the non-immune items (ties are broken by the insertion order) and evicts the first ones
+func (chunk *immunityChunk) removeSortedNoLock(numToRemove int, reason types.EvictionReason, less func(a, b *cacheItem) bool) int {
+	candidates := make([]*list.Element, 0, chunk.itemsAsList.Len())
+	for element := chunk.itemsAsList.Front(); element != nil; element = element.Next() {
+		if !element.Value.(*cacheItem).isImmuneToEviction() {
+			candidates = append(candidates, element)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return less(candidates[i].Value.(*cacheItem), candidates[j].Value.(*cacheItem))
+	})
+
+	numRemoved := 0
+	for _, element := range candidates {
+		if numRemoved >= numToRemove {
+			break
+		}
+
+		chunk.evictNoLock(element, reason)
 		numRemoved++
 	}
 
 	return numRemoved
 }
 
+func (chunk *immunityChunk) evictNoLock(element *list.Element, reason types.EvictionReason) {
+	item := element.Value.(*cacheItem)
+	chunk.removeNoLock(element)
+
+	if chunk.onEvicted != nil {
+		chunk.onEvicted(item, reason)
+	}
+}
+
 func (chunk *immunityChunk) removeNoLock(element *list.Element) {
 	item := element.Value.(*cacheItem)
 	delete(chunk.items, item.key)
 	chunk.itemsAsList.Remove(element)
+	chunk.removeItemImmunityNoLock(item)
 	chunk.trackNumBytesOnRemoveNoLock(item)
 }
 
 func (chunk *immunityChunk) monitorEvictionNoLock(numRemoved int, err error) {
+	chunk.numEvicted += uint64(numRemoved)
 	if err != nil {
+		chunk.numFailedEvictions++
 		log.Trace("immunityChunk.monitorEviction()", "name", chunk.config.cacheName, "numRemoved", numRemoved, "err", err)
 	}
 }
@@ -174,10 +429,31 @@ func (chunk *immunityChunk) addItemNoLock(item *cacheItem) {
 }
 
 func (chunk *immunityChunk) immunizeItemOnAddNoLock(item *cacheItem) {
-	if _, immunize := chunk.immuneKeys[item.key]; immunize {
-		item.immunizeAgainstEviction()
-		// We do not remove the key from "immuneKeys", we hold it there until item's removal.
+	expirationTime, immunize := chunk.immuneKeys[item.key]
+	if !immunize {
+		expirationTime, immunize = chunk.findImmunePrefixNoLock(item.key)
+	}
+	if !immunize {
+		return
+	}
+	if isImmunityExpired(expirationTime, time.Now()) {
+		delete(chunk.immuneKeys, item.key)
+		return
+	}
+
+	chunk.immunizeItemNoLock(item, expirationTime)
+	// We do not remove the key from "immuneKeys", we hold it there until item's removal.
+	chunk.immuneKeys[item.key] = expirationTime
+}
+
+func (chunk *immunityChunk) findImmunePrefixNoLock(key string) (time.Time, bool) {
+	for prefix, expirationTime := range chunk.immunePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return expirationTime, true
+		}
 	}
+
+	return time.Time{}, false
 }
 
 func (chunk *immunityChunk) trackNumBytesOnAddNoLock(item *cacheItem) {
@@ -218,7 +494,7 @@ func (chunk *immunityChunk) trackNumBytesOnRemoveNoLock(item *cacheItem) {
 func (chunk *immunityChunk) RemoveOldest(numToRemove int) int {
 	chunk.mutex.Lock()
 	defer chunk.mutex.Unlock()
-	return chunk.removeOldestNoLock(numToRemove)
+	return chunk.removeOldestNoLock(numToRemove, types.EvictionReasonCapacity)
 }
 
 // Count counts the items
@@ -242,6 +518,20 @@ func (chunk *immunityChunk) NumBytes() int {
 	return chunk.numBytes
 }
 
+// Diagnose returns a summary of the internal state of the chunk
+func (chunk *immunityChunk) Diagnose() ChunkDiagnostics {
+	chunk.mutex.RLock()
+	defer chunk.mutex.RUnlock()
+
+	return ChunkDiagnostics{
+		NumItems:           len(chunk.items),
+		NumImmune:          len(chunk.immuneKeys),
+		NumBytes:           chunk.numBytes,
+		NumEvicted:         chunk.numEvicted,
+		NumFailedEvictions: chunk.numFailedEvictions,
+	}
+}
+
 // KeysInOrder gets the keys, in order
 func (chunk *immunityChunk) KeysInOrder() [][]byte {
 	chunk.mutex.RLock()
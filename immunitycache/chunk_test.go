@@ -3,7 +3,9 @@ package immunitycache
 import (
 	"math"
 	"testing"
+	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,6 +34,123 @@ func TestImmunityChunk_ImmunizeKeys(t *testing.T) {
 	require.Equal(t, []string{"x", "z"}, keysAsStrings(chunk.KeysInOrder()))
 }
 
+func TestImmunityChunk_RemoveExpiredImmunities(t *testing.T) {
+	t.Run("without expiration, immunities are kept", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.addTestItems("x", "y")
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x", "z"}))
+
+		require.Equal(t, 0, chunk.RemoveExpiredImmunities())
+		require.Equal(t, 2, chunk.CountImmune())
+	})
+	t.Run("with expiration", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.config.immunityExpiration = time.Millisecond * 50
+		chunk.addTestItems("x", "y")
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x", "z"}))
+
+		require.Equal(t, 0, chunk.RemoveExpiredImmunities())
+		require.Equal(t, 2, chunk.CountImmune())
+
+		time.Sleep(time.Millisecond * 100)
+
+		require.Equal(t, 2, chunk.RemoveExpiredImmunities())
+		require.Equal(t, 0, chunk.CountImmune())
+		require.Equal(t, 2, chunk.RemoveOldest(42))
+	})
+	t.Run("expired immunity is not applied on add", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.config.immunityExpiration = time.Millisecond * 50
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x"}))
+
+		time.Sleep(time.Millisecond * 100)
+
+		chunk.addTestItems("x")
+		require.Equal(t, 0, chunk.CountImmune())
+		require.Equal(t, 1, chunk.RemoveOldest(42))
+	})
+}
+
+func TestImmunityChunk_ImmunizeKeysWithPrefix(t *testing.T) {
+	chunk := newUnconstrainedChunkToTest()
+	chunk.addTestItems("r1-x", "r2-x", "r1-y")
+
+	numNow := chunk.ImmunizeKeysWithPrefix([]byte("r1-"))
+	require.Equal(t, 2, numNow)
+
+	chunk.addTestItems("r1-z", "r2-y")
+	require.Equal(t, 3, chunk.CountImmune())
+
+	numRemoved := chunk.RemoveOldest(42)
+	require.Equal(t, 2, numRemoved)
+	require.Equal(t, []string{"r1-x", "r1-y", "r1-z"}, keysAsStrings(chunk.KeysInOrder()))
+
+	// Removing the immunity also discards the individually immunized keys having the prefix
+	_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"r1-w", "r3-w"}))
+	numRemoved = chunk.RemoveImmunityByPrefix([]byte("r1-"))
+	require.Equal(t, 4, numRemoved)
+	require.Equal(t, 1, chunk.CountImmune())
+
+	numRemoved = chunk.RemoveOldest(42)
+	require.Equal(t, 3, numRemoved)
+}
+
+func TestImmunityChunk_ImmunePrefixExpires(t *testing.T) {
+	chunk := newUnconstrainedChunkToTest()
+	chunk.config.immunityExpiration = time.Millisecond * 50
+
+	_ = chunk.ImmunizeKeysWithPrefix([]byte("r1-"))
+	time.Sleep(time.Millisecond * 100)
+
+	chunk.addTestItems("r1-x")
+	require.Equal(t, 0, chunk.CountImmune())
+
+	_ = chunk.RemoveExpiredImmunities()
+	require.Empty(t, chunk.immunePrefixes)
+}
+
+func TestImmunityChunk_EvictionStrategies(t *testing.T) {
+	addItems := func(chunk *immunityChunk) {
+		_, _ = chunk.AddItem(newCacheItem("foo", "a", 10))
+		_, _ = chunk.AddItem(newCacheItem("foo", "b", 30))
+		_, _ = chunk.AddItem(newCacheItem("foo", "c", 20))
+		_, _ = chunk.AddItem(newCacheItem("foo", "d", 30))
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"d"}))
+	}
+
+	t.Run("FIFO", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.config.evictionStrategy = common.FIFOEviction
+		addItems(chunk)
+
+		require.Equal(t, 2, chunk.RemoveOldest(2))
+		require.Equal(t, []string{"c", "d"}, keysAsStrings(chunk.KeysInOrder()))
+	})
+	t.Run("largest first", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.config.evictionStrategy = common.LargestFirstEviction
+		addItems(chunk)
+
+		require.Equal(t, 2, chunk.RemoveOldest(2))
+		require.Equal(t, []string{"a", "d"}, keysAsStrings(chunk.KeysInOrder()))
+	})
+	t.Run("oldest first", func(t *testing.T) {
+		chunk := newUnconstrainedChunkToTest()
+		chunk.config.evictionStrategy = common.OldestFirstEviction
+		addItems(chunk)
+
+		item, _ := chunk.GetItem("a")
+		item.timestamp.Set(item.timestamp.Get() + int64(time.Hour))
+
+		require.Equal(t, 2, chunk.RemoveOldest(2))
+		require.Equal(t, []string{"a", "d"}, keysAsStrings(chunk.KeysInOrder()))
+
+		// Immune items are never evicted
+		require.Equal(t, 1, chunk.RemoveOldest(42))
+		require.Equal(t, []string{"d"}, keysAsStrings(chunk.KeysInOrder()))
+	})
+}
+
 func TestImmunityChunk_AddItemIgnoresDuplicates(t *testing.T) {
 	chunk := newUnconstrainedChunkToTest()
 	chunk.addTestItems("x", "y", "z")
@@ -76,6 +195,69 @@ func TestImmunityChunk_AddItemDoesNotEvictImmuneItems(t *testing.T) {
 	require.Equal(t, []string{"x", "y", "b"}, keysAsStrings(chunk.KeysInOrder()))
 }
 
+func TestImmunityChunk_AddItemAccountsForIncomingSize(t *testing.T) {
+	chunk := newChunkToTest(math.MaxUint32, 250)
+	chunk.addTestItems("x", "y")
+	require.Equal(t, 200, chunk.NumBytes())
+
+	// The stored bytes are below the bound, but the incoming item would exceed it
+	chunk.addTestItems("z")
+	require.Equal(t, []string{"y", "z"}, keysAsStrings(chunk.KeysInOrder()))
+	require.Equal(t, 200, chunk.NumBytes())
+}
+
+func TestImmunityChunk_ImmuneCounters(t *testing.T) {
+	t.Run("should follow immunization, removal and eviction", func(t *testing.T) {
+		chunk := newChunkToTest(4, math.MaxUint32)
+		chunk.addTestItems("x", "y", "z")
+
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x", "y", "a"}))
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x"}))
+		require.Equal(t, 2, chunk.numImmuneItems)
+		require.Equal(t, 200, chunk.numImmuneBytes)
+
+		// "a" is immunized on add
+		chunk.addTestItems("a")
+		require.Equal(t, 3, chunk.numImmuneItems)
+		require.Equal(t, 300, chunk.numImmuneBytes)
+
+		// "z" is evicted, being the only evictable item
+		chunk.addTestItems("b")
+		require.Equal(t, []string{"x", "y", "a", "b"}, keysAsStrings(chunk.KeysInOrder()))
+		require.Equal(t, 3, chunk.numImmuneItems)
+
+		require.True(t, chunk.RemoveItem("x"))
+		require.Equal(t, 2, chunk.numImmuneItems)
+		require.Equal(t, 200, chunk.numImmuneBytes)
+
+		require.Equal(t, 1, chunk.RemoveImmunityByPrefix([]byte("y")))
+		require.Equal(t, 1, chunk.numImmuneItems)
+		require.Equal(t, 100, chunk.numImmuneBytes)
+
+		_ = chunk.ImmunizeKeysWithPrefix([]byte("b"))
+		require.Equal(t, 2, chunk.numImmuneItems)
+		require.Equal(t, 200, chunk.numImmuneBytes)
+	})
+
+	t.Run("expired immunities should make room for the incoming item", func(t *testing.T) {
+		chunk := newChunkToTest(2, math.MaxUint32)
+		chunk.config.immunityExpiration = time.Millisecond * 50
+		chunk.addTestItems("x", "y")
+		_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x", "y"}))
+
+		_, added := chunk.AddItem(newCacheItem("foo", "a", 100))
+		require.False(t, added)
+
+		time.Sleep(time.Millisecond * 100)
+
+		_, added = chunk.AddItem(newCacheItem("foo", "a", 100))
+		require.True(t, added)
+		require.Equal(t, []string{"y", "a"}, keysAsStrings(chunk.KeysInOrder()))
+		require.Equal(t, 0, chunk.numImmuneItems)
+		require.Equal(t, 0, chunk.numImmuneBytes)
+	})
+}
+
 func newUnconstrainedChunkToTest() *immunityChunk {
 	chunk := newImmunityChunk(immunityChunkConfig{
 		maxNumItems:                 math.MaxUint32,
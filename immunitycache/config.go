@@ -3,25 +3,31 @@ package immunitycache
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 )
 
 const numChunksLowerBound = 1
-const numChunksUpperBound = 128
-const maxNumItemsLowerBound = 4
+const numChunksUpperBound = common.MaxNumChunksForImmunityCache
+const maxNumItemsLowerBound = common.MinCapacityForImmunityCache
 const maxNumBytesLowerBound = maxNumItemsLowerBound * 1
-const maxNumBytesUpperBound = 1_073_741_824 // one GB
+const maxNumBytesUpperBound = common.MaxSizeInBytesForImmunityCache // one GB
 const numItemsToPreemptivelyEvictLowerBound = 1
 
 // CacheConfig holds cache configuration
 type CacheConfig struct {
-	Name                        string
-	NumChunks                   uint32
+	Name      string
+	NumChunks uint32
+	// MaxNumItems and MaxNumBytes are enforced at the same time, eviction honoring whichever bound is violated
 	MaxNumItems                 uint32
 	MaxNumBytes                 uint32
 	NumItemsToPreemptivelyEvict uint32
+	// ImmunityExpiration is the duration after which immunized keys become evictable again. Zero means no expiration
+	ImmunityExpiration time.Duration
+	// EvictionStrategy is the order in which the non-immune items are evicted. Empty means FIFO
+	EvictionStrategy common.EvictionStrategy
 }
 
 // Verify verifies the validity of the configuration
@@ -41,21 +47,43 @@ func (config *CacheConfig) Verify() error {
 	if config.NumItemsToPreemptivelyEvict < numItemsToPreemptivelyEvictLowerBound {
 		return fmt.Errorf("%w: config.NumItemsToPreemptivelyEvict is invalid", common.ErrInvalidConfig)
 	}
+	if config.ImmunityExpiration < 0 {
+		return fmt.Errorf("%w: config.ImmunityExpiration is invalid", common.ErrInvalidConfig)
+	}
+	if !isEvictionStrategySupported(config.EvictionStrategy) {
+		return fmt.Errorf("%w: config.EvictionStrategy is invalid", common.ErrInvalidConfig)
+	}
 
 	return nil
 }
 
+func isEvictionStrategySupported(strategy common.EvictionStrategy) bool {
+	switch strategy {
+	case "", common.FIFOEviction, common.OldestFirstEviction, common.LargestFirstEviction:
+		return true
+	default:
+		return false
+	}
+}
+
 func (config *CacheConfig) getChunkConfig() immunityChunkConfig {
 	numChunks := core.MaxUint32(config.NumChunks, 1)
 
 	return immunityChunkConfig{
 		cacheName:                   config.Name,
-		maxNumItems:                 config.MaxNumItems / numChunks,
-		maxNumBytes:                 config.MaxNumBytes / numChunks,
+		maxNumItems:                 splitBound(config.MaxNumItems, numChunks),
+		maxNumBytes:                 splitBound(config.MaxNumBytes, numChunks),
 		numItemsToPreemptivelyEvict: config.NumItemsToPreemptivelyEvict / numChunks,
+		immunityExpiration:          config.ImmunityExpiration,
+		evictionStrategy:            config.EvictionStrategy,
 	}
 }
 
+// splitBound divides a bound among the chunks, each one getting at least one unit
+func splitBound(bound uint32, numChunks uint32) uint32 {
+	return core.MaxUint32(bound/numChunks, 1)
+}
+
 // String returns a readable representation of the object
 func (config *CacheConfig) String() string {
 	bytes, err := json.Marshal(config)
@@ -71,14 +99,18 @@ type immunityChunkConfig struct {
 	maxNumItems                 uint32
 	maxNumBytes                 uint32
 	numItemsToPreemptivelyEvict uint32
+	immunityExpiration          time.Duration
+	evictionStrategy            common.EvictionStrategy
 }
 
 // String returns a readable representation of the object
 func (config *immunityChunkConfig) String() string {
 	return fmt.Sprintf(
-		"maxNumItems: %d, maxNumBytes: %d, numItemsToPreemptivelyEvict: %d",
+		"maxNumItems: %d, maxNumBytes: %d, numItemsToPreemptivelyEvict: %d, immunityExpiration: %s, evictionStrategy: %s",
 		config.maxNumItems,
 		config.maxNumBytes,
 		config.numItemsToPreemptivelyEvict,
+		config.immunityExpiration,
+		config.evictionStrategy,
 	)
 }
@@ -0,0 +1,22 @@
+package immunitycache
+
+// ChunkDiagnostics holds a summary of the internal state of a chunk
+type ChunkDiagnostics struct {
+	NumItems           int
+	NumImmune          int
+	NumBytes           int
+	NumEvicted         uint64
+	NumFailedEvictions uint64
+}
+
+// CacheDiagnostics holds a summary of the internal state of the cache
+type CacheDiagnostics struct {
+	Name               string
+	NumItems           int
+	NumImmune          int
+	NumBytes           int
+	NumEvicted         uint64
+	NumFailedEvictions uint64
+	Hospitality        int64
+	Chunks             []ChunkDiagnostics
+}
@@ -0,0 +1,158 @@
+package immunitycache
+
+import (
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/marshal"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ArgsSpillPersister holds the components used to spill the evicted (non-immune) items to storage
+type ArgsSpillPersister struct {
+	Persister         types.Persister
+	StoredDataFactory types.StoredDataFactory
+	Marshalizer       marshal.Marshalizer
+}
+
+func checkArgsSpillPersister(args ArgsSpillPersister) error {
+	if check.IfNil(args.Persister) {
+		return common.ErrNilPersister
+	}
+	if check.IfNil(args.StoredDataFactory) {
+		return common.ErrNilStoredDataFactory
+	}
+	if check.IfNil(args.Marshalizer) {
+		return common.ErrNilMarshalizer
+	}
+
+	return nil
+}
+
+// spillPersister writes the evicted items to a persister and reads them back, the same way storageCacherAdapter does
+type spillPersister struct {
+	mutClosed         sync.RWMutex
+	isClosed          bool
+	persister         types.Persister
+	storedDataFactory types.StoredDataFactory
+	marshalizer       marshal.Marshalizer
+}
+
+func newSpillPersister(args ArgsSpillPersister) *spillPersister {
+	return &spillPersister{
+		persister:         args.Persister,
+		storedDataFactory: args.StoredDataFactory,
+		marshalizer:       args.Marshalizer,
+	}
+}
+
+func (spill *spillPersister) put(key string, payload interface{}) {
+	spill.mutClosed.RLock()
+	defer spill.mutClosed.RUnlock()
+
+	if spill.isClosed {
+		return
+	}
+
+	payloadBytes := spill.getBytes(payload)
+	if len(payloadBytes) == 0 {
+		return
+	}
+
+	err := spill.persister.Put([]byte(key), payloadBytes)
+	if err != nil {
+		log.Error("immunitycache: could not spill item to storage", "error", err)
+	}
+}
+
+func (spill *spillPersister) getBytes(payload interface{}) []byte {
+	serialized, ok := payload.(types.SerializedStoredData)
+	if ok {
+		return serialized.GetSerialized()
+	}
+
+	payloadBytes, err := spill.marshalizer.Marshal(payload)
+	if err != nil {
+		log.Error("immunitycache: could not marshal value", "error", err)
+		return nil
+	}
+
+	return payloadBytes
+}
+
+func (spill *spillPersister) get(key []byte) (interface{}, bool) {
+	spill.mutClosed.RLock()
+	defer spill.mutClosed.RUnlock()
+
+	if spill.isClosed {
+		return nil, false
+	}
+
+	payloadBytes, err := spill.persister.Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	payload, err := spill.getData(payloadBytes)
+	if err != nil {
+		log.Error("immunitycache: could not get data", "error", err)
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func (spill *spillPersister) getData(serializedData []byte) (interface{}, error) {
+	storedData := spill.storedDataFactory.CreateEmpty()
+	data, ok := storedData.(types.SerializedStoredData)
+	if ok {
+		data.SetSerialized(serializedData)
+		return data, nil
+	}
+
+	err := spill.marshalizer.Unmarshal(storedData, serializedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return storedData, nil
+}
+
+func (spill *spillPersister) has(key []byte) bool {
+	spill.mutClosed.RLock()
+	defer spill.mutClosed.RUnlock()
+
+	if spill.isClosed {
+		return false
+	}
+
+	return spill.persister.Has(key) == nil
+}
+
+func (spill *spillPersister) remove(key []byte) bool {
+	spill.mutClosed.RLock()
+	defer spill.mutClosed.RUnlock()
+
+	if spill.isClosed {
+		return false
+	}
+
+	if spill.persister.Has(key) != nil {
+		return false
+	}
+
+	return spill.persister.Remove(key) == nil
+}
+
+func (spill *spillPersister) close() error {
+	spill.mutClosed.Lock()
+	defer spill.mutClosed.Unlock()
+
+	if spill.isClosed {
+		return nil
+	}
+
+	spill.isClosed = true
+	return spill.persister.Close()
+}
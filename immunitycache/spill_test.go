@@ -0,0 +1,127 @@
+package immunitycache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+type spillTestPayload struct {
+	Value string
+}
+
+type spillTestPayloadFactory struct {
+}
+
+func (factory *spillTestPayloadFactory) CreateEmpty() interface{} {
+	return &spillTestPayload{}
+}
+
+func (factory *spillTestPayloadFactory) IsInterfaceNil() bool {
+	return factory == nil
+}
+
+func createArgsSpillPersister() ArgsSpillPersister {
+	return ArgsSpillPersister{
+		Persister:         testscommon.NewMemDbMock(),
+		StoredDataFactory: &spillTestPayloadFactory{},
+		Marshalizer:       &testscommon.MarshalizerMock{},
+	}
+}
+
+func createConfigForSpillTest() CacheConfig {
+	return CacheConfig{
+		Name:                        "test",
+		NumChunks:                   1,
+		MaxNumItems:                 4,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+}
+
+func TestNewImmunityCacheWithSpill(t *testing.T) {
+	t.Run("nil persister should error", func(t *testing.T) {
+		args := createArgsSpillPersister()
+		args.Persister = nil
+
+		cache, err := NewImmunityCacheWithSpill(createConfigForSpillTest(), args)
+		require.Nil(t, cache)
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("nil stored data factory should error", func(t *testing.T) {
+		args := createArgsSpillPersister()
+		args.StoredDataFactory = nil
+
+		cache, err := NewImmunityCacheWithSpill(createConfigForSpillTest(), args)
+		require.Nil(t, cache)
+		require.Equal(t, common.ErrNilStoredDataFactory, err)
+	})
+	t.Run("nil marshalizer should error", func(t *testing.T) {
+		args := createArgsSpillPersister()
+		args.Marshalizer = nil
+
+		cache, err := NewImmunityCacheWithSpill(createConfigForSpillTest(), args)
+		require.Nil(t, cache)
+		require.Equal(t, common.ErrNilMarshalizer, err)
+	})
+	t.Run("invalid config should error", func(t *testing.T) {
+		config := createConfigForSpillTest()
+		config.Name = ""
+
+		cache, err := NewImmunityCacheWithSpill(config, createArgsSpillPersister())
+		require.Nil(t, cache)
+		require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("should work", func(t *testing.T) {
+		cache, err := NewImmunityCacheWithSpill(createConfigForSpillTest(), createArgsSpillPersister())
+		require.Nil(t, err)
+		require.NotNil(t, cache)
+	})
+}
+
+func TestImmunityCache_SpillEvictedItems(t *testing.T) {
+	args := createArgsSpillPersister()
+	persister := args.Persister.(*testscommon.MemDbMock)
+	cache, _ := NewImmunityCacheWithSpill(createConfigForSpillTest(), args)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_, _ = cache.HasOrAdd([]byte(key), &spillTestPayload{Value: "value-" + key}, 1)
+	}
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a"}))
+
+	// "b" and "c" are evicted, while the immune "a" is kept in memory
+	_, _ = cache.HasOrAdd([]byte("e"), &spillTestPayload{Value: "value-e"}, 1)
+	_, _ = cache.HasOrAdd([]byte("f"), &spillTestPayload{Value: "value-f"}, 1)
+	require.ElementsMatch(t, []string{"a", "d", "e", "f"}, keysAsStrings(cache.Keys()))
+	require.Nil(t, persister.Has([]byte("b")))
+	require.Nil(t, persister.Has([]byte("c")))
+	require.NotNil(t, persister.Has([]byte("a")))
+
+	value, ok := cache.Get([]byte("b"))
+	require.True(t, ok)
+	require.Equal(t, &spillTestPayload{Value: "value-b"}, value)
+	require.True(t, cache.Has([]byte("b")))
+
+	// Peek only searches in memory
+	_, ok = cache.Peek([]byte("b"))
+	require.False(t, ok)
+
+	ok = cache.RemoveWithResult([]byte("b"))
+	require.True(t, ok)
+	require.False(t, cache.Has([]byte("b")))
+	_, ok = cache.Get([]byte("b"))
+	require.False(t, ok)
+
+	err := cache.Close()
+	require.Nil(t, err)
+	_, ok = cache.Get([]byte("c"))
+	require.False(t, ok)
+	require.False(t, cache.Has([]byte("c")))
+
+	// closing twice does not error
+	err = cache.Close()
+	require.Nil(t, err)
+}
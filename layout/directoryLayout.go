@@ -0,0 +1,268 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+var log = logger.GetOrCreate("storage/layout")
+
+const (
+	// EpochDirPrefix starts the names of the per-epoch directories
+	EpochDirPrefix = "Epoch_"
+	// ShardDirPrefix starts the names of the per-shard directories, nested in the per-epoch ones
+	ShardDirPrefix = "Shard_"
+	// MetachainDirSuffix ends the name of the directory of the metachain, instead of its shard ID
+	MetachainDirSuffix = "metachain"
+)
+
+// IncompleteDirectory describes a shard directory missing some of the expected storage units
+type IncompleteDirectory struct {
+	Path         string
+	Epoch        uint32
+	ShardID      uint32
+	MissingUnits []string
+}
+
+// DirectoryLayout owns the construction of the paths of the storage units, laid out as
+// <base path>/Epoch_<epoch>/Shard_<shard ID>/<unit name>, the metachain directory being Shard_metachain.
+// It also enumerates the epochs & shards found on disk, detecting and repairing the partially created directories
+type DirectoryLayout struct {
+	basePath string
+}
+
+// NewDirectoryLayout creates a new directory layout rooted in the provided base path
+func NewDirectoryLayout(basePath string) (*DirectoryLayout, error) {
+	if len(basePath) == 0 {
+		return nil, fmt.Errorf("%w: empty base path", common.ErrInvalidConfig)
+	}
+
+	return &DirectoryLayout{
+		basePath: filepath.Clean(basePath),
+	}, nil
+}
+
+// BasePath returns the directory holding the per-epoch directories
+func (dl *DirectoryLayout) BasePath() string {
+	return dl.basePath
+}
+
+// EpochPath returns the directory of the provided epoch
+func (dl *DirectoryLayout) EpochPath(epoch uint32) string {
+	return filepath.Join(dl.basePath, epochDirName(epoch))
+}
+
+// ShardPath returns the directory of the provided shard, in the provided epoch
+func (dl *DirectoryLayout) ShardPath(epoch uint32, shardID uint32) string {
+	return filepath.Join(dl.EpochPath(epoch), shardDirName(shardID))
+}
+
+// UnitPath returns the directory of the provided storage unit, in the provided shard & epoch
+func (dl *DirectoryLayout) UnitPath(epoch uint32, shardID uint32, unitName string) string {
+	return filepath.Join(dl.ShardPath(epoch, shardID), unitName)
+}
+
+// UnitPathTemplate returns the path of the provided storage unit, in the provided shard, the epoch being replaced by
+// the common.EpochPlaceholder, as expected by the per-epoch persisters and the pruning storers
+func (dl *DirectoryLayout) UnitPathTemplate(shardID uint32, unitName string) string {
+	return filepath.Join(dl.basePath, EpochDirPrefix+common.EpochPlaceholder, shardDirName(shardID), unitName)
+}
+
+func epochDirName(epoch uint32) string {
+	return EpochDirPrefix + strconv.FormatUint(uint64(epoch), 10)
+}
+
+func shardDirName(shardID uint32) string {
+	if shardID == core.MetachainShardId {
+		return ShardDirPrefix + MetachainDirSuffix
+	}
+
+	return ShardDirPrefix + strconv.FormatUint(uint64(shardID), 10)
+}
+
+func parseEpochDirName(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, EpochDirPrefix) {
+		return 0, false
+	}
+
+	epoch, err := strconv.ParseUint(strings.TrimPrefix(name, EpochDirPrefix), 10, 32)
+	return uint32(epoch), err == nil
+}
+
+func parseShardDirName(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, ShardDirPrefix) {
+		return 0, false
+	}
+
+	suffix := strings.TrimPrefix(name, ShardDirPrefix)
+	if suffix == MetachainDirSuffix {
+		return core.MetachainShardId, true
+	}
+
+	shardID, err := strconv.ParseUint(suffix, 10, 32)
+	return uint32(shardID), err == nil
+}
+
+// listDirs returns the names of the sub directories, none if the directory does not exist
+func listDirs(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// ListEpochs returns the sorted epochs whose directories exist, ignoring the other directories
+func (dl *DirectoryLayout) ListEpochs() ([]uint32, error) {
+	names, err := listDirs(dl.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	epochs := make([]uint32, 0, len(names))
+	for _, name := range names {
+		epoch, ok := parseEpochDirName(name)
+		if ok {
+			epochs = append(epochs, epoch)
+		}
+	}
+	sort.Slice(epochs, func(i, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	return epochs, nil
+}
+
+// ListShards returns the sorted shard IDs whose directories exist in the provided epoch, the metachain being last
+func (dl *DirectoryLayout) ListShards(epoch uint32) ([]uint32, error) {
+	names, err := listDirs(dl.EpochPath(epoch))
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs := make([]uint32, 0, len(names))
+	for _, name := range names {
+		shardID, ok := parseShardDirName(name)
+		if ok {
+			shardIDs = append(shardIDs, shardID)
+		}
+	}
+	sort.Slice(shardIDs, func(i, j int) bool {
+		return shardIDs[i] < shardIDs[j]
+	})
+
+	return shardIDs, nil
+}
+
+// LatestEpoch returns the highest epoch whose directory exists
+func (dl *DirectoryLayout) LatestEpoch() (uint32, error) {
+	epochs, err := dl.ListEpochs()
+	if err != nil {
+		return 0, err
+	}
+	if len(epochs) == 0 {
+		return 0, fmt.Errorf("no epoch directory found in %s", dl.basePath)
+	}
+
+	return epochs[len(epochs)-1], nil
+}
+
+// DetectIncomplete returns the shard directories missing some of the expected storage units, as left behind by an
+// interrupted creation
+func (dl *DirectoryLayout) DetectIncomplete(expectedUnits []string) ([]IncompleteDirectory, error) {
+	epochs, err := dl.ListEpochs()
+	if err != nil {
+		return nil, err
+	}
+
+	incomplete := make([]IncompleteDirectory, 0)
+	for _, epoch := range epochs {
+		shardIDs, errList := dl.ListShards(epoch)
+		if errList != nil {
+			return nil, errList
+		}
+
+		for _, shardID := range shardIDs {
+			missingUnits, errMissing := dl.missingUnits(epoch, shardID, expectedUnits)
+			if errMissing != nil {
+				return nil, errMissing
+			}
+			if len(missingUnits) == 0 {
+				continue
+			}
+
+			incomplete = append(incomplete, IncompleteDirectory{
+				Path:         dl.ShardPath(epoch, shardID),
+				Epoch:        epoch,
+				ShardID:      shardID,
+				MissingUnits: missingUnits,
+			})
+		}
+	}
+
+	return incomplete, nil
+}
+
+func (dl *DirectoryLayout) missingUnits(epoch uint32, shardID uint32, expectedUnits []string) ([]string, error) {
+	missingUnits := make([]string, 0)
+	for _, unitName := range expectedUnits {
+		info, err := os.Stat(dl.UnitPath(epoch, shardID, unitName))
+		if errors.Is(err, os.ErrNotExist) {
+			missingUnits = append(missingUnits, unitName)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dl.UnitPath(epoch, shardID, unitName))
+		}
+	}
+
+	return missingUnits, nil
+}
+
+// Repair creates the missing storage unit directories of the incomplete shard directories, returning the repaired ones
+func (dl *DirectoryLayout) Repair(expectedUnits []string) ([]IncompleteDirectory, error) {
+	incomplete, err := dl.DetectIncomplete(expectedUnits)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range incomplete {
+		for _, unitName := range dir.MissingUnits {
+			err = os.MkdirAll(filepath.Join(dir.Path, unitName), os.ModePerm)
+			if err != nil {
+				return nil, err
+			}
+		}
+		log.Info("DirectoryLayout: repaired partially created directory", "path", dir.Path, "created units", strings.Join(dir.MissingUnits, ", "))
+	}
+
+	return incomplete, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (dl *DirectoryLayout) IsInterfaceNil() bool {
+	return dl == nil
+}
@@ -0,0 +1,76 @@
+package layout_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDirectoryLayout(t *testing.T) {
+	t.Parallel()
+
+	dl, err := layout.NewDirectoryLayout("")
+	assert.Nil(t, dl)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	dl, err = layout.NewDirectoryLayout("db/chain/")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("db", "chain"), dl.BasePath())
+}
+
+func TestDirectoryLayout_Paths(t *testing.T) {
+	t.Parallel()
+
+	dl, _ := layout.NewDirectoryLayout("db")
+	assert.Equal(t, filepath.Join("db", "Epoch_3"), dl.EpochPath(3))
+	assert.Equal(t, filepath.Join("db", "Epoch_3", "Shard_1"), dl.ShardPath(3, 1))
+	assert.Equal(t, filepath.Join("db", "Epoch_3", "Shard_metachain", "Blocks"), dl.UnitPath(3, core.MetachainShardId, "Blocks"))
+	assert.Equal(t, filepath.Join("db", "Epoch_"+common.EpochPlaceholder, "Shard_0", "Blocks"), dl.UnitPathTemplate(0, "Blocks"))
+}
+
+func TestDirectoryLayout_ListAndRepair(t *testing.T) {
+	t.Parallel()
+
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	epochs, err := dl.ListEpochs()
+	assert.Nil(t, err)
+	assert.Empty(t, epochs)
+	_, err = dl.LatestEpoch()
+	assert.NotNil(t, err)
+
+	units := []string{"Blocks", "Transactions"}
+	for _, unit := range units {
+		require.Nil(t, os.MkdirAll(dl.UnitPath(2, core.MetachainShardId, unit), os.ModePerm))
+		require.Nil(t, os.MkdirAll(dl.UnitPath(10, 0, unit), os.ModePerm))
+	}
+	require.Nil(t, os.MkdirAll(dl.UnitPath(10, 1, "Blocks"), os.ModePerm))
+	require.Nil(t, os.MkdirAll(filepath.Join(dl.BasePath(), "Static"), os.ModePerm))
+
+	epochs, _ = dl.ListEpochs()
+	assert.Equal(t, []uint32{2, 10}, epochs)
+	latest, _ := dl.LatestEpoch()
+	assert.Equal(t, uint32(10), latest)
+	shardIDs, _ := dl.ListShards(10)
+	assert.Equal(t, []uint32{0, 1}, shardIDs)
+	shardIDs, _ = dl.ListShards(2)
+	assert.Equal(t, []uint32{core.MetachainShardId}, shardIDs)
+
+	incomplete, err := dl.DetectIncomplete(units)
+	assert.Nil(t, err)
+	require.Len(t, incomplete, 1)
+	assert.Equal(t, dl.ShardPath(10, 1), incomplete[0].Path)
+	assert.Equal(t, []string{"Transactions"}, incomplete[0].MissingUnits)
+
+	repaired, err := dl.Repair(units)
+	assert.Nil(t, err)
+	assert.Len(t, repaired, 1)
+	incomplete, _ = dl.DetectIncomplete(units)
+	assert.Empty(t, incomplete)
+}
@@ -0,0 +1,256 @@
+package layout
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// UnitDir describes the directory of a storage unit found on disk
+type UnitDir struct {
+	Name        string
+	Path        string
+	SizeInBytes uint64
+}
+
+// ShardDir describes a shard directory found on disk, along with its storage units
+type ShardDir struct {
+	ShardID     uint32
+	Path        string
+	SizeInBytes uint64
+	Units       []UnitDir
+}
+
+// EpochDir describes an epoch directory found on disk, along with its shards
+type EpochDir struct {
+	Epoch       uint32
+	Path        string
+	SizeInBytes uint64
+	Shards      []ShardDir
+}
+
+// StaleDir describes a directory left behind by an interrupted operation, safe to be removed
+type StaleDir struct {
+	Path   string
+	Reason string
+}
+
+// ScanReport describes the directories found on disk, sorted by epoch, shard ID and unit name
+type ScanReport struct {
+	Epochs      []EpochDir
+	SizeInBytes uint64
+	Stale       []StaleDir
+}
+
+// Scan enumerates the epoch, shard & storage unit directories found on disk, computing their sizes, and detects the
+// stale ones: the leftovers of the interrupted removals, along with the empty epoch & shard directories
+func (dl *DirectoryLayout) Scan() (*ScanReport, error) {
+	report := &ScanReport{
+		Epochs: make([]EpochDir, 0),
+		Stale:  make([]StaleDir, 0),
+	}
+
+	err := report.addRemovingDirs(dl.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	epochs, err := dl.ListEpochs()
+	if err != nil {
+		return nil, err
+	}
+	for _, epoch := range epochs {
+		epochDir, errScan := dl.scanEpoch(epoch, report)
+		if errScan != nil {
+			return nil, errScan
+		}
+		if epochDir == nil {
+			continue
+		}
+
+		report.Epochs = append(report.Epochs, *epochDir)
+		report.SizeInBytes += epochDir.SizeInBytes
+	}
+
+	return report, nil
+}
+
+// scanEpoch returns the description of the epoch directory, nil if it is stale
+func (dl *DirectoryLayout) scanEpoch(epoch uint32, report *ScanReport) (*EpochDir, error) {
+	epochDir := &EpochDir{
+		Epoch:  epoch,
+		Path:   dl.EpochPath(epoch),
+		Shards: make([]ShardDir, 0),
+	}
+	err := report.addRemovingDirs(epochDir.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs, err := dl.ListShards(epoch)
+	if err != nil {
+		return nil, err
+	}
+	for _, shardID := range shardIDs {
+		shardDir, errScan := dl.scanShard(epoch, shardID, report)
+		if errScan != nil {
+			return nil, errScan
+		}
+		if shardDir == nil {
+			continue
+		}
+
+		epochDir.Shards = append(epochDir.Shards, *shardDir)
+		epochDir.SizeInBytes += shardDir.SizeInBytes
+	}
+
+	if len(epochDir.Shards) == 0 && len(shardIDs) == 0 {
+		isEmpty, errEmpty := isEmptyDir(epochDir.Path)
+		if errEmpty != nil {
+			return nil, errEmpty
+		}
+		if isEmpty {
+			report.Stale = append(report.Stale, StaleDir{Path: epochDir.Path, Reason: "empty epoch directory"})
+			return nil, nil
+		}
+	}
+
+	return epochDir, nil
+}
+
+// scanShard returns the description of the shard directory, nil if it is stale
+func (dl *DirectoryLayout) scanShard(epoch uint32, shardID uint32, report *ScanReport) (*ShardDir, error) {
+	shardDir := &ShardDir{
+		ShardID: shardID,
+		Path:    dl.ShardPath(epoch, shardID),
+		Units:   make([]UnitDir, 0),
+	}
+	names, err := listDirs(shardDir.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	numRemoving := 0
+	for _, name := range names {
+		path := filepath.Join(shardDir.Path, name)
+		if strings.HasSuffix(name, common.RemovingDirSuffix) {
+			report.Stale = append(report.Stale, StaleDir{Path: path, Reason: "interrupted removal"})
+			numRemoving++
+			continue
+		}
+
+		size, errSize := DirSize(path)
+		if errSize != nil {
+			return nil, errSize
+		}
+		shardDir.Units = append(shardDir.Units, UnitDir{Name: name, Path: path, SizeInBytes: size})
+		shardDir.SizeInBytes += size
+	}
+
+	if len(shardDir.Units) == 0 && numRemoving == 0 {
+		isEmpty, errEmpty := isEmptyDir(shardDir.Path)
+		if errEmpty != nil {
+			return nil, errEmpty
+		}
+		if isEmpty {
+			report.Stale = append(report.Stale, StaleDir{Path: shardDir.Path, Reason: "empty shard directory"})
+			return nil, nil
+		}
+	}
+
+	return shardDir, nil
+}
+
+// addRemovingDirs adds the leftovers of the interrupted removals found in the directory to the stale ones
+func (report *ScanReport) addRemovingDirs(path string) error {
+	names, err := listDirs(path)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if strings.HasSuffix(name, common.RemovingDirSuffix) {
+			report.Stale = append(report.Stale, StaleDir{Path: filepath.Join(path, name), Reason: "interrupted removal"})
+		}
+	}
+
+	return nil
+}
+
+// CleanStale removes the stale directories detected by Scan, returning the removed ones
+func (dl *DirectoryLayout) CleanStale() ([]StaleDir, error) {
+	report, err := dl.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]StaleDir, 0, len(report.Stale))
+	for _, stale := range report.Stale {
+		err = os.RemoveAll(stale.Path)
+		if err != nil {
+			return removed, err
+		}
+
+		log.Info("DirectoryLayout: removed stale directory", "path", stale.Path, "reason", stale.Reason)
+		removed = append(removed, stale)
+	}
+
+	return removed, nil
+}
+
+// DirSize returns the total size of the files held by the directory, recursively, 0 if it does not exist
+func DirSize(path string) (uint64, error) {
+	size := uint64(0)
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += uint64(info.Size())
+
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	return size, err
+}
+
+// RemoveDir removes the directory along with its content. The directory is renamed first, by appending the
+// common.RemovingDirSuffix, so that an interrupted removal leaves behind a directory detected as stale by Scan, instead
+// of a partially removed one
+func RemoveDir(path string) error {
+	removingPath := filepath.Clean(path) + common.RemovingDirSuffix
+	err := os.RemoveAll(removingPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(path, removingPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return os.RemoveAll(path)
+	}
+
+	return os.RemoveAll(removingPath)
+}
+
+func isEmptyDir(path string) (bool, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	return len(entries) == 0, nil
+}
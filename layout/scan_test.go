@@ -0,0 +1,69 @@
+package layout_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryLayout_ScanAndCleanStale(t *testing.T) {
+	t.Parallel()
+
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	blocksPath := dl.UnitPath(1, 0, "Blocks")
+	require.Nil(t, os.MkdirAll(blocksPath, os.ModePerm))
+	require.Nil(t, os.WriteFile(filepath.Join(blocksPath, "000001.ldb"), make([]byte, 100), os.ModePerm))
+	require.Nil(t, os.MkdirAll(dl.UnitPath(1, 0, "Trie.removing"), os.ModePerm))
+	require.Nil(t, os.MkdirAll(dl.ShardPath(1, 1), os.ModePerm))
+	require.Nil(t, os.MkdirAll(dl.EpochPath(2), os.ModePerm))
+	require.Nil(t, os.MkdirAll(dl.EpochPath(3)+".removing", os.ModePerm))
+
+	report, err := dl.Scan()
+	require.Nil(t, err)
+	assert.Equal(t, uint64(100), report.SizeInBytes)
+	require.Len(t, report.Epochs, 1)
+	assert.Equal(t, uint32(1), report.Epochs[0].Epoch)
+	require.Len(t, report.Epochs[0].Shards, 1)
+	assert.Equal(t, []layout.UnitDir{{Name: "Blocks", Path: blocksPath, SizeInBytes: 100}}, report.Epochs[0].Shards[0].Units)
+	expectedStale := []layout.StaleDir{
+		{Path: dl.EpochPath(3) + ".removing", Reason: "interrupted removal"},
+		{Path: dl.UnitPath(1, 0, "Trie.removing"), Reason: "interrupted removal"},
+		{Path: dl.ShardPath(1, 1), Reason: "empty shard directory"},
+		{Path: dl.EpochPath(2), Reason: "empty epoch directory"},
+	}
+	assert.Equal(t, expectedStale, report.Stale)
+
+	removed, err := dl.CleanStale()
+	require.Nil(t, err)
+	assert.Equal(t, expectedStale, removed)
+	for _, stale := range expectedStale {
+		assert.NoDirExists(t, stale.Path)
+	}
+	report, _ = dl.Scan()
+	assert.Empty(t, report.Stale)
+	assert.FileExists(t, filepath.Join(blocksPath, "000001.ldb"))
+}
+
+func TestRemoveDir(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "Blocks")
+	require.Nil(t, os.MkdirAll(filepath.Join(path, "sub"), os.ModePerm))
+	require.Nil(t, os.MkdirAll(path+".removing", os.ModePerm))
+	size, err := layout.DirSize(path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+
+	assert.Nil(t, layout.RemoveDir(path))
+	assert.NoDirExists(t, path)
+	assert.NoDirExists(t, path+".removing")
+	assert.Nil(t, layout.RemoveDir(path))
+
+	size, err = layout.DirSize(path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+}
@@ -13,6 +13,7 @@ type batch struct {
 	batch       *leveldb.Batch
 	cachedData  map[string][]byte
 	removedData map[string]struct{}
+	sizeInBytes uint64
 	mutBatch    sync.RWMutex
 }
 
@@ -30,8 +31,9 @@ func NewBatch() *batch {
 func (b *batch) Put(key []byte, val []byte) error {
 	b.mutBatch.Lock()
 	b.batch.Put(key, val)
+	b.forgetEntryNoLock(key)
 	b.cachedData[string(key)] = val
-	delete(b.removedData, string(key))
+	b.sizeInBytes += uint64(len(key) + len(val))
 	b.mutBatch.Unlock()
 	return nil
 }
@@ -40,8 +42,9 @@ func (b *batch) Put(key []byte, val []byte) error {
 func (b *batch) Delete(key []byte) error {
 	b.mutBatch.Lock()
 	b.batch.Delete(key)
+	b.forgetEntryNoLock(key)
 	b.removedData[string(key)] = struct{}{}
-	delete(b.cachedData, string(key))
+	b.sizeInBytes += uint64(len(key))
 	b.mutBatch.Unlock()
 	return nil
 }
@@ -52,9 +55,59 @@ func (b *batch) Reset() {
 	b.batch.Reset()
 	b.cachedData = make(map[string][]byte)
 	b.removedData = make(map[string]struct{})
+	b.sizeInBytes = 0
 	b.mutBatch.Unlock()
 }
 
+// forgetEntryNoLock removes the previous entry of the key, superseded by a new one
+func (b *batch) forgetEntryNoLock(key []byte) {
+	val, found := b.cachedData[string(key)]
+	if found {
+		delete(b.cachedData, string(key))
+		b.sizeInBytes -= uint64(len(key) + len(val))
+	}
+	_, found = b.removedData[string(key)]
+	if found {
+		delete(b.removedData, string(key))
+		b.sizeInBytes -= uint64(len(key))
+	}
+}
+
+// mergeOlder adds the entries of the older batch whose keys are neither written nor marked for removal in this batch,
+// as if the older batch was written first
+func (b *batch) mergeOlder(older *batch) {
+	b.mutBatch.Lock()
+	defer b.mutBatch.Unlock()
+	older.mutBatch.RLock()
+	defer older.mutBatch.RUnlock()
+
+	for key, val := range older.cachedData {
+		if b.hasEntryNoLock(key) {
+			continue
+		}
+
+		b.batch.Put([]byte(key), val)
+		b.cachedData[key] = val
+		b.sizeInBytes += uint64(len(key) + len(val))
+	}
+	for key := range older.removedData {
+		if b.hasEntryNoLock(key) {
+			continue
+		}
+
+		b.batch.Delete([]byte(key))
+		b.removedData[key] = struct{}{}
+		b.sizeInBytes += uint64(len(key))
+	}
+}
+
+func (b *batch) hasEntryNoLock(key string) bool {
+	_, written := b.cachedData[key]
+	_, removed := b.removedData[key]
+
+	return written || removed
+}
+
 // Get returns the value
 func (b *batch) Get(key []byte) []byte {
 	b.mutBatch.RLock()
@@ -73,6 +126,44 @@ func (b *batch) IsRemoved(key []byte) bool {
 	return found
 }
 
+// Len returns the number of distinct keys written or marked for removal in the batch
+func (b *batch) Len() int {
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	return len(b.cachedData) + len(b.removedData)
+}
+
+// SizeInBytes returns the accumulated size of the keys and values of the batch entries
+func (b *batch) SizeInBytes() uint64 {
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	return b.sizeInBytes
+}
+
+// Range calls the handler for each entry of the batch, in no particular order, the removed flag marking the keys
+// marked for removal. The handler should not modify the batch
+func (b *batch) Range(handler func(key []byte, val []byte, removed bool) bool) {
+	if handler == nil {
+		return
+	}
+
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	for key, val := range b.cachedData {
+		if !handler([]byte(key), val, false) {
+			return
+		}
+	}
+	for key := range b.removedData {
+		if !handler([]byte(key), nil, true) {
+			return
+		}
+	}
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (b *batch) IsInterfaceNil() bool {
 	return b == nil
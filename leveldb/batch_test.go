@@ -0,0 +1,54 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch_Introspection(t *testing.T) {
+	t.Parallel()
+
+	b := leveldb.NewBatch()
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, uint64(0), b.SizeInBytes())
+
+	_ = b.Put([]byte("key1"), []byte("value1"))
+	_ = b.Put([]byte("key2"), []byte("value2"))
+	assert.Equal(t, 2, b.Len())
+	assert.Equal(t, uint64(20), b.SizeInBytes())
+
+	// the superseded entries are no longer accounted
+	_ = b.Put([]byte("key1"), []byte("v"))
+	_ = b.Delete([]byte("key2"))
+	_ = b.Delete([]byte("key3"))
+	assert.Equal(t, 3, b.Len())
+	assert.Equal(t, uint64(13), b.SizeInBytes())
+
+	written := make(map[string][]byte)
+	removed := make([]string, 0)
+	b.Range(func(key []byte, val []byte, isRemoved bool) bool {
+		if isRemoved {
+			removed = append(removed, string(key))
+			return true
+		}
+
+		written[string(key)] = val
+		return true
+	})
+	assert.Equal(t, map[string][]byte{"key1": []byte("v")}, written)
+	assert.ElementsMatch(t, []string{"key2", "key3"}, removed)
+
+	numCalls := 0
+	b.Range(func(key []byte, val []byte, isRemoved bool) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+	b.Range(nil)
+
+	b.Reset()
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, uint64(0), b.SizeInBytes())
+}
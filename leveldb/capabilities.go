@@ -0,0 +1,179 @@
+package leveldb
+
+import (
+	"errors"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var _ types.PersisterView = (*snapshotView)(nil)
+var _ types.Iterator = (*dbIterator)(nil)
+
+// snapshotView is a read only view of a leveldb snapshot, which does not include the batch pending at the time it was
+// taken, so the batch has to be written beforehand
+type snapshotView struct {
+	snapshot *leveldb.Snapshot
+	path     string
+}
+
+// Get gets the value associated to the key
+func (sv *snapshotView) Get(key []byte) ([]byte, error) {
+	data, err := sv.snapshot.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, common.NewKeyNotFoundError(key, "", sv.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Has returns nil if the given key is present in the view
+func (sv *snapshotView) Has(key []byte) error {
+	has, err := sv.snapshot.Has(key, nil)
+	if err != nil {
+		return err
+	}
+	if has {
+		return nil
+	}
+
+	return common.NewKeyNotFoundError(key, "", sv.path)
+}
+
+// RangeKeys calls the handler function for each (key, value) pair of the view, in ascending order of the keys. If the
+// handler returns true, the iteration will continue, otherwise will stop
+func (sv *snapshotView) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	iterator := sv.snapshot.NewIterator(nil, nil)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		key := make([]byte, len(iterator.Key()))
+		copy(key, iterator.Key())
+		val := make([]byte, len(iterator.Value()))
+		copy(val, iterator.Value())
+
+		if !handler(key, val) {
+			return
+		}
+	}
+}
+
+// Release releases the underlying snapshot
+func (sv *snapshotView) Release() {
+	sv.snapshot.Release()
+}
+
+func (bldb *baseLevelDb) snapshotView() (types.PersisterView, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return nil, bldb.errClosed()
+	}
+
+	snapshot, err := db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshotView{
+		snapshot: snapshot,
+		path:     bldb.path,
+	}, nil
+}
+
+func releaseView(view types.PersisterView) {
+	if view != nil {
+		view.Release()
+	}
+}
+
+func (bldb *baseLevelDb) compact() error {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return bldb.errClosed()
+	}
+
+	return db.CompactRange(util.Range{})
+}
+
+// getMany reads the keys one by one, as leveldb does not provide batched reads, leaving out the missing ones
+func getMany(get func(key []byte) ([]byte, error), keys [][]byte) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		val, err := get(key)
+		if errors.Is(err, common.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values[string(key)] = val
+	}
+
+	return values, nil
+}
+
+// dbIterator adapts the leveldb iterators to types.Iterator
+type dbIterator struct {
+	iterator iterator.Iterator
+}
+
+// NewIterator returns an iterator over the pairs of the storage medium, the pending batch not being visible
+func (bldb *baseLevelDb) NewIterator() (types.Iterator, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return nil, bldb.errClosed()
+	}
+
+	return &dbIterator{
+		iterator: db.NewIterator(nil, nil),
+	}, nil
+}
+
+// Seek moves to the first pair whose key is greater than or equal to the provided one
+func (it *dbIterator) Seek(key []byte) bool {
+	return it.iterator.Seek(key)
+}
+
+// Next moves to the next pair
+func (it *dbIterator) Next() bool {
+	return it.iterator.Next()
+}
+
+// Last moves to the last pair
+func (it *dbIterator) Last() bool {
+	return it.iterator.Last()
+}
+
+// Prev moves to the previous pair
+func (it *dbIterator) Prev() bool {
+	return it.iterator.Prev()
+}
+
+// Key returns the key of the current pair
+func (it *dbIterator) Key() []byte {
+	return it.iterator.Key()
+}
+
+// Value returns the value of the current pair
+func (it *dbIterator) Value() []byte {
+	return it.iterator.Value()
+}
+
+// Close releases the underlying iterator, returning the error encountered while iterating, if any
+func (it *dbIterator) Close() error {
+	err := it.iterator.Error()
+	it.iterator.Release()
+
+	return err
+}
@@ -6,9 +6,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const resourceUnavailable = "resource temporarily unavailable"
@@ -56,6 +60,7 @@ func openOneTime(path string, options *opt.Options) (*leveldb.DB, error) {
 			"error", errOpen,
 		)
 		db, errRecover = leveldb.RecoverFile(path, options)
+		monitoring.PublishEvent(monitoring.NewCorruptionDetectedEvent(path, errOpen, errRecover == nil))
 		if errRecover != nil {
 			return nil, fmt.Errorf("%w while recovering DB %s, after the initial failure %s",
 				errRecover,
@@ -74,9 +79,36 @@ func openOneTime(path string, options *opt.Options) (*leveldb.DB, error) {
 }
 
 type baseLevelDb struct {
-	mutDb sync.RWMutex
-	path  string
-	db    *leveldb.DB
+	mutDb     sync.RWMutex
+	path      string
+	db        *leveldb.DB
+	latencies *monitoring.PersisterLatencies
+	// blockCacheEnabled is true if the leveldb block cache was enabled by WithBlockCache
+	blockCacheEnabled bool
+	// writeBatchHandler writes the batches to the database, being replaced by the tests simulating the failed writes
+	writeBatchHandler func(db *leveldb.DB, b *leveldb.Batch) error
+}
+
+func writeBatchSynced(db *leveldb.DB, b *leveldb.Batch) error {
+	wopt := &opt.WriteOptions{
+		Sync: true,
+	}
+
+	return db.Write(b, wopt)
+}
+
+// writeBatch writes the batch to the database, waiting for the disk to sync
+func (bldb *baseLevelDb) writeBatch(b *batch) error {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return bldb.errClosed()
+	}
+
+	if bldb.writeBatchHandler != nil {
+		return bldb.writeBatchHandler(db, b.batch)
+	}
+
+	return writeBatchSynced(db, b.batch)
 }
 
 func (bldb *baseLevelDb) getDbPointer() *leveldb.DB {
@@ -101,9 +133,110 @@ func (bldb *baseLevelDb) makeDbPointerNilReturningLast() *leveldb.DB {
 	return db
 }
 
+// errKeyNotFound returns common.ErrKeyNotFound, carrying the key and the path of the database
+func (bldb *baseLevelDb) errKeyNotFound(key []byte) error {
+	return common.NewKeyNotFoundError(key, "", bldb.path)
+}
+
+// errClosed returns common.ErrDBIsClosed, carrying the path of the database
+func (bldb *baseLevelDb) errClosed() error {
+	return common.WrapStorageError(common.ErrDBIsClosed, nil, "", bldb.path)
+}
+
+// stats returns the stats shared by all the leveldb persisters, read from the leveldb internals. The size in bytes is
+// the one of the table files, while the closed databases only report their type and path
+func (bldb *baseLevelDb) stats(dbType common.DBType) map[string]interface{} {
+	stats := map[string]interface{}{
+		types.StatType: string(dbType),
+		types.StatPath: bldb.path,
+	}
+
+	db := bldb.getDbPointer()
+	if db == nil {
+		stats["closed"] = true
+		return stats
+	}
+
+	dbStats := &leveldb.DBStats{}
+	err := db.Stats(dbStats)
+	if err != nil {
+		log.Debug("could not read the leveldb stats", "path", bldb.path, "error", err)
+		return stats
+	}
+
+	stats[types.StatSizeInBytes] = uint64(dbStats.LevelSizes.Sum())
+	numTables := 0
+	for _, count := range dbStats.LevelTablesCounts {
+		numTables += count
+	}
+	stats["numTables"] = numTables
+	stats["ioReadBytes"] = dbStats.IORead
+	stats["ioWriteBytes"] = dbStats.IOWrite
+	stats["numOpenedTables"] = dbStats.OpenedTablesCount
+	if bldb.blockCacheEnabled {
+		addBlockCacheStats(stats, dbStats)
+	}
+
+	return stats
+}
+
+func addBlockCacheStats(stats map[string]interface{}, dbStats *leveldb.DBStats) {
+	numHits := dbStats.BlockCache.HitCount
+	numMisses := dbStats.BlockCache.MissCount
+	hitRatio := float64(0)
+	if numHits+numMisses > 0 {
+		hitRatio = float64(numHits) / float64(numHits+numMisses)
+	}
+
+	stats["blockCacheSizeInBytes"] = dbStats.BlockCacheSize
+	stats["blockCacheHits"] = numHits
+	stats["blockCacheMisses"] = numMisses
+	stats["blockCacheHitRatio"] = hitRatio
+}
+
+// health reports the closed db as failed, and the db whose writes are paused by the compaction as degraded
+func (bldb *baseLevelDb) health() types.HealthStatus {
+	status := types.NewHealthStatus()
+
+	db := bldb.getDbPointer()
+	if db == nil {
+		status.Fail("db is closed")
+		return status
+	}
+
+	dbStats := &leveldb.DBStats{}
+	err := db.Stats(dbStats)
+	if err != nil {
+		status.Degrade("could not read the db stats: %v", err)
+		return status
+	}
+	if dbStats.WritePaused {
+		status.Degrade("writes paused until the compaction catches up")
+	}
+
+	return status
+}
+
+func addBatchStats(stats map[string]interface{}, batch types.Batcher) map[string]interface{} {
+	stats["numPendingWrites"] = batch.Len()
+	stats["pendingWritesBytes"] = batch.SizeInBytes()
+
+	return stats
+}
+
 // RangeKeys will call the handler function for each (key, value) pair
 // If the handler returns true, the iteration will continue, otherwise will stop
 func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool) {
+	bldb.rangeKeys(nil, handler)
+}
+
+// RangeKeysBetween calls the handler function for each (key, value) pair whose key is in [startKey, endKey), in
+// ascending order of the keys, a nil endKey meaning no upper bound. As for RangeKeys, the pending batch is not visible
+func (bldb *baseLevelDb) RangeKeysBetween(startKey []byte, endKey []byte, handler func(key []byte, value []byte) bool) {
+	bldb.rangeKeys(&util.Range{Start: startKey, Limit: endKey}, handler)
+}
+
+func (bldb *baseLevelDb) rangeKeys(keysRange *util.Range, handler func(key []byte, value []byte) bool) {
 	if handler == nil {
 		return
 	}
@@ -113,7 +246,7 @@ func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool)
 		return
 	}
 
-	iterator := db.NewIterator(nil, nil)
+	iterator := db.NewIterator(keysRange, nil)
 	for {
 		if !iterator.Next() {
 			break
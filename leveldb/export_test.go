@@ -0,0 +1,14 @@
+package leveldb
+
+import "github.com/syndtr/goleveldb/leveldb"
+
+// SetWriteBatchHandler replaces the function writing the batches to the database, so that the failed writes can be
+// simulated
+func (bldb *baseLevelDb) SetWriteBatchHandler(handler func(db *leveldb.DB, b *leveldb.Batch) error) {
+	bldb.writeBatchHandler = handler
+}
+
+// WriteBatchSynced writes the batch as the persisters do by default
+func WriteBatchSynced(db *leveldb.DB, b *leveldb.Batch) error {
+	return writeBatchSynced(db, b)
+}
@@ -10,21 +10,35 @@ import (
 	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
-	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/TerraDharitri/drt-go-chain-storage/logsampling"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 var _ types.Persister = (*DB)(nil)
+var _ types.Flusher = (*DB)(nil)
+var _ types.MultiPutter = (*DB)(nil)
+var _ types.RangeIterator = (*DB)(nil)
+var _ types.IterablePersister = (*DB)(nil)
+var _ types.BatchedReader = (*DB)(nil)
+var _ types.BulkWriter = (*DB)(nil)
+var _ types.SnapshotablePersister = (*DB)(nil)
+var _ types.Compactable = (*DB)(nil)
+var _ types.StatsProvider = (*DB)(nil)
+var _ types.CapabilitiesProvider = (*DB)(nil)
+var _ types.HealthChecker = (*DB)(nil)
 
 // read + write + execute for owner only
 const rwxOwner = 0700
 const mkdirAllFunction = "mkdirAll"
 const openLevelDBFunction = "openLevelDB"
 
-var log = logger.GetOrCreate("storage/leveldb")
+// the trace and debug lines can be sampled, see logsampling.Setup
+var log = logsampling.GetOrCreate("storage/leveldb")
 
 // DB holds a pointer to the leveldb database and the path to where it is stored.
 type DB struct {
@@ -34,12 +48,14 @@ type DB struct {
 	sizeBatch         int
 	batch             types.Batcher
 	mutBatch          sync.RWMutex
+	retryQueue        *batchRetryQueue
+	writeAround       *writeAround
 	cancel            context.CancelFunc
 }
 
 // NewDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
-func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *DB, err error) {
+func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int, opts ...Option) (s *DB, err error) {
 	constructorName := "NewDB"
 
 	sw := core.NewStopWatch()
@@ -56,9 +72,10 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 		return nil, common.ErrInvalidNumOpenFiles
 	}
 
+	o := newOptions(opts)
 	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
+		// the internal cache is disabled, unless enabled by WithBlockCache
+		BlockCacheCapacity:     o.blockCacheCapacityOption(),
 		OpenFilesCacheCapacity: maxOpenFiles,
 	}
 
@@ -70,8 +87,10 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 	sw.Stop(openLevelDBFunction)
 
 	bldb := &baseLevelDb{
-		db:   db,
-		path: path,
+		db:                db,
+		path:              path,
+		latencies:         monitoring.LatenciesForPersister(path),
+		blockCacheEnabled: o.isBlockCacheEnabled(),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -80,10 +99,12 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 		maxBatchSize:      maxBatchSize,
 		batchDelaySeconds: batchDelaySeconds,
 		sizeBatch:         0,
+		retryQueue:        newBatchRetryQueue(path, o),
 		cancel:            cancel,
 	}
 
 	dbStore.batch = dbStore.createBatch()
+	dbStore.writeAround = newWriteAround(o, path, dbStore.writeBatch, dbStore.retryQueue)
 
 	go dbStore.batchTimeoutHandle(ctx)
 
@@ -112,16 +133,11 @@ func (s *DB) batchTimeoutHandle(ctx context.Context) {
 		select {
 		case <-timer.C:
 			s.mutBatch.Lock()
-			err := s.putBatch(s.batch)
+			err := s.writeBatchNoLock(false)
+			s.mutBatch.Unlock()
 			if err != nil {
 				log.Warn("leveldb putBatch", "error", err.Error())
-				s.mutBatch.Unlock()
-				continue
 			}
-
-			s.batch.Reset()
-			s.sizeBatch = 0
-			s.mutBatch.Unlock()
 		case <-ctx.Done():
 			log.Debug("closing the timed batch handler", "path", s.path)
 			return
@@ -133,25 +149,72 @@ func (s *DB) updateBatchWithIncrement() error {
 	s.mutBatch.Lock()
 	defer s.mutBatch.Unlock()
 
-	s.sizeBatch++
+	return s.increaseBatchSizeNoLock(1)
+}
+
+func (s *DB) increaseBatchSizeNoLock(increment int) error {
+	s.sizeBatch += increment
 	if s.sizeBatch < s.maxBatchSize {
 		return nil
 	}
 
-	err := s.putBatch(s.batch)
+	err := s.writeBatchNoLock(false)
 	if err != nil {
 		log.Warn("leveldb putBatch", "error", err.Error())
 		return err
 	}
 
-	s.batch.Reset()
+	return nil
+}
+
+// writeBatchNoLock writes the batches queued for retry, then the current batch, which is queued in turn if it can not
+// be written, so that its writes are not lost. Unless forced, the queued batches are only retried once their backoff
+// elapsed, the current batch being queued without being written meanwhile. If the retry queue is full, the current
+// batch is kept, to be written along with the next writes. The values being written around are written first
+func (s *DB) writeBatchNoLock(force bool) error {
+	dbBatch, ok := s.batch.(*batch)
+	if !ok {
+		return common.ErrInvalidBatch
+	}
+
+	s.writeAround.wait()
+
+	err := s.retryQueue.retry(s.writeBatch, force)
+	if err == nil {
+		err = s.putBatch(dbBatch)
+	}
+	if err == nil {
+		s.batch.Reset()
+		s.sizeBatch = 0
+		return nil
+	}
+	if dbBatch.Len() == 0 {
+		return err
+	}
+
+	if !s.retryQueue.add(dbBatch, err) {
+		return s.retryQueue.errFull(err)
+	}
+	s.batch = s.createBatch()
 	s.sizeBatch = 0
 
-	return nil
+	return err
 }
 
-// Put adds the value to the (key, val) storage medium
+// Put adds the value to the (key, val) storage medium. The large values are written around the batch, if enabled by
+// WithWriteAround
 func (s *DB) Put(key, val []byte) error {
+	defer s.latencies.ObserveSince(monitoring.PutOperation, time.Now())
+
+	if s.writeAround.isLarge(val) {
+		s.mutBatch.Lock()
+		isReserved := !s.isBatchedNoLock(key) && s.writeAround.reserve(key, val)
+		s.mutBatch.Unlock()
+		if isReserved {
+			return s.writeAround.put(key, val)
+		}
+	}
+
 	s.mutBatch.RLock()
 	err := s.batch.Put(key, val)
 	s.mutBatch.RUnlock()
@@ -163,25 +226,43 @@ func (s *DB) Put(key, val []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
+// MultiPut adds all the provided values to the batch at once, writing the batch to the storage medium if it gets full
+func (s *DB) MultiPut(data map[string][]byte) error {
+	defer s.latencies.ObserveSince(monitoring.PutOperation, time.Now())
+
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	for key, val := range data {
+		err := s.batch.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.increaseBatchSizeNoLock(len(data))
+}
+
 // Get returns the value associated to the key
 func (s *DB) Get(key []byte) ([]byte, error) {
+	defer s.latencies.ObserveSince(monitoring.GetOperation, time.Now())
+
 	db := s.getDbPointer()
 	if db == nil {
-		return nil, common.ErrDBIsClosed
+		return nil, s.errClosed()
 	}
 
-	if s.batch.IsRemoved(key) {
-		return nil, common.ErrKeyNotFound
+	data, removed := s.getFromBatches(key)
+	if removed {
+		return nil, s.errKeyNotFound(key)
 	}
-
-	data := s.batch.Get(key)
 	if data != nil {
 		return data, nil
 	}
 
 	data, err := db.Get(key, nil)
 	if err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, s.errKeyNotFound(key)
 	}
 	if err != nil {
 		return nil, err
@@ -192,16 +273,17 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 
 // Has returns nil if the given key is present in the persistence medium
 func (s *DB) Has(key []byte) error {
+	defer s.latencies.ObserveSince(monitoring.HasOperation, time.Now())
+
 	db := s.getDbPointer()
 	if db == nil {
-		return common.ErrDBIsClosed
+		return s.errClosed()
 	}
 
-	if s.batch.IsRemoved(key) {
-		return common.ErrKeyNotFound
+	data, removed := s.getFromBatches(key)
+	if removed {
+		return s.errKeyNotFound(key)
 	}
-
-	data := s.batch.Get(key)
 	if data != nil {
 		return nil
 	}
@@ -215,7 +297,41 @@ func (s *DB) Has(key []byte) error {
 		return nil
 	}
 
-	return common.ErrKeyNotFound
+	return s.errKeyNotFound(key)
+}
+
+// getFromBatches looks the key up in the current batch, then in the values being written around, then in the batches
+// queued for retry, newest first
+func (s *DB) getFromBatches(key []byte) (val []byte, removed bool) {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	if s.batch.IsRemoved(key) {
+		return nil, true
+	}
+
+	val = s.batch.Get(key)
+	if val != nil {
+		return val, false
+	}
+	val = s.writeAround.get(key)
+	if val != nil {
+		return val, false
+	}
+
+	val, removed, _ = s.retryQueue.get(key)
+
+	return val, removed
+}
+
+// isBatchedNoLock returns true if a write of the key waits in the current batch or in the batches queued for retry
+func (s *DB) isBatchedNoLock(key []byte) bool {
+	if s.batch.IsRemoved(key) || s.batch.Get(key) != nil {
+		return true
+	}
+	_, _, found := s.retryQueue.get(key)
+
+	return found
 }
 
 // CreateBatch returns a batcher to be used for batch writing data to the database
@@ -225,27 +341,32 @@ func (s *DB) createBatch() types.Batcher {
 
 // putBatch writes the Batch data into the database
 func (s *DB) putBatch(b types.Batcher) error {
+	defer s.latencies.ObserveSince(monitoring.FlushOperation, time.Now())
+
 	dbBatch, ok := b.(*batch)
 	if !ok {
 		return common.ErrInvalidBatch
 	}
 
-	wopt := &opt.WriteOptions{
-		Sync: true,
-	}
+	return s.writeBatch(dbBatch)
+}
 
-	db := s.getDbPointer()
-	if db == nil {
-		return common.ErrDBIsClosed
-	}
+// Flush writes the batches queued for retry, then the pending batch, to the storage medium, without waiting for the
+// batch delay or the retry backoff
+func (s *DB) Flush() error {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
 
-	return db.Write(dbBatch.batch, wopt)
+	return s.writeBatchNoLock(true)
 }
 
 // Close closes the files/resources associated to the storage medium
 func (s *DB) Close() error {
 	s.mutBatch.Lock()
-	_ = s.putBatch(s.batch)
+	err := s.writeBatchNoLock(true)
+	if err != nil {
+		s.retryQueue.drop(err)
+	}
 	s.sizeBatch = 0
 	s.mutBatch.Unlock()
 
@@ -260,6 +381,8 @@ func (s *DB) Close() error {
 
 // Remove removes the data associated to the given key
 func (s *DB) Remove(key []byte) error {
+	defer s.latencies.ObserveSince(monitoring.RemoveOperation, time.Now())
+
 	s.mutBatch.Lock()
 	_ = s.batch.Delete(key)
 	s.mutBatch.Unlock()
@@ -267,11 +390,54 @@ func (s *DB) Remove(key []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
+// GetMany returns the values of the provided keys, the missing keys being left out
+func (s *DB) GetMany(keys [][]byte) (map[string][]byte, error) {
+	return getMany(s.Get, keys)
+}
+
+// MultiRemove marks all the provided keys as removed in the batch at once, writing the batch to the storage medium if
+// it gets full
+func (s *DB) MultiRemove(keys [][]byte) error {
+	defer s.latencies.ObserveSince(monitoring.RemoveOperation, time.Now())
+
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	for _, key := range keys {
+		_ = s.batch.Delete(key)
+	}
+
+	return s.increaseBatchSizeNoLock(len(keys))
+}
+
+// TakeSnapshot writes the pending batch, then returns a view of the current contents of the storage medium, which has
+// to be released after use
+func (s *DB) TakeSnapshot() (types.PersisterView, error) {
+	err := s.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.snapshotView()
+}
+
+// ReleaseSnapshot releases the view returned by TakeSnapshot
+func (s *DB) ReleaseSnapshot(view types.PersisterView) {
+	releaseView(view)
+}
+
+// Compact compacts the whole storage medium, reclaiming the space held by the removed and overwritten values
+func (s *DB) Compact() error {
+	return s.compact()
+}
+
 // Destroy removes the storage medium stored data
 func (s *DB) Destroy() error {
 	s.mutBatch.Lock()
+	s.writeAround.wait()
 	s.batch.Reset()
 	s.sizeBatch = 0
+	_ = s.retryQueue.clear()
 	s.mutBatch.Unlock()
 
 	s.cancel()
@@ -283,12 +449,36 @@ func (s *DB) Destroy() error {
 		}
 	}
 
-	return os.RemoveAll(s.path)
+	return layout.RemoveDir(s.path)
 }
 
 // DestroyClosed removes the already closed storage medium stored data
 func (s *DB) DestroyClosed() error {
-	return os.RemoveAll(s.path)
+	return layout.RemoveDir(s.path)
+}
+
+// Stats returns the key stats of the persister, including the writes pending in the batch
+func (s *DB) Stats() map[string]interface{} {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	stats := s.retryQueue.addStats(addBatchStats(s.stats(common.LvlDB), s.batch))
+
+	return s.writeAround.addStats(stats)
+}
+
+// Capabilities returns the optional features of the persister: the writes are batched before reaching the disk
+func (s *DB) Capabilities() types.PersisterCapabilities {
+	return types.PersisterCapabilities{
+		SupportsSnapshots: true,
+		SupportsIteration: true,
+		Durability:        types.BufferedDurability,
+	}
+}
+
+// Health returns the health of the persister
+func (s *DB) Health() types.HealthStatus {
+	return s.retryQueue.addHealth(s.health())
 }
 
 // IsInterfaceNil returns true if there is no value under the interface
@@ -0,0 +1,151 @@
+package leveldb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+var _ types.Persister = (*ReadOnlyDB)(nil)
+var _ types.RangeIterator = (*ReadOnlyDB)(nil)
+var _ types.IterablePersister = (*ReadOnlyDB)(nil)
+var _ types.StatsProvider = (*ReadOnlyDB)(nil)
+var _ types.CapabilitiesProvider = (*ReadOnlyDB)(nil)
+var _ types.HealthChecker = (*ReadOnlyDB)(nil)
+
+// ReadOnlyDB is a leveldb persister opened in read only mode, which rejects the writes, the removals and the destroys.
+// It can be used to inspect a database without altering it
+type ReadOnlyDB struct {
+	*baseLevelDb
+}
+
+// NewReadOnlyDB opens the existing leveldb database from the provided path in read only mode
+func NewReadOnlyDB(path string, maxOpenFiles int, opts ...Option) (*ReadOnlyDB, error) {
+	if maxOpenFiles < 1 {
+		return nil, common.ErrInvalidNumOpenFiles
+	}
+
+	o := newOptions(opts)
+	options := &opt.Options{
+		// the internal cache is disabled, unless enabled by WithBlockCache
+		BlockCacheCapacity:     o.blockCacheCapacityOption(),
+		OpenFilesCacheCapacity: maxOpenFiles,
+		ReadOnly:               true,
+		ErrorIfMissing:         true,
+	}
+
+	// the corrupted databases are not recovered, as the recovery would alter the files
+	db, err := leveldb.OpenFile(path, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w for path %s", err, path)
+	}
+
+	log.Debug("opened read only level db persister", "path", path, "created pointer", fmt.Sprintf("%p", db))
+
+	return &ReadOnlyDB{
+		baseLevelDb: &baseLevelDb{
+			db:                db,
+			path:              path,
+			latencies:         monitoring.LatenciesForPersister(path),
+			blockCacheEnabled: o.isBlockCacheEnabled(),
+		},
+	}, nil
+}
+
+// Put returns ErrDBIsReadOnly
+func (s *ReadOnlyDB) Put(_, _ []byte) error {
+	return common.ErrDBIsReadOnly
+}
+
+// Get returns the value associated to the key
+func (s *ReadOnlyDB) Get(key []byte) ([]byte, error) {
+	defer s.latencies.ObserveSince(monitoring.GetOperation, time.Now())
+
+	db := s.getDbPointer()
+	if db == nil {
+		return nil, s.errClosed()
+	}
+
+	data, err := db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, s.errKeyNotFound(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (s *ReadOnlyDB) Has(key []byte) error {
+	defer s.latencies.ObserveSince(monitoring.HasOperation, time.Now())
+
+	db := s.getDbPointer()
+	if db == nil {
+		return s.errClosed()
+	}
+
+	has, err := db.Has(key, nil)
+	if err != nil {
+		return err
+	}
+	if has {
+		return nil
+	}
+
+	return s.errKeyNotFound(key)
+}
+
+// Close closes the files/resources associated to the storage medium
+func (s *ReadOnlyDB) Close() error {
+	db := s.makeDbPointerNilReturningLast()
+	if db != nil {
+		return db.Close()
+	}
+
+	return nil
+}
+
+// Remove returns ErrDBIsReadOnly
+func (s *ReadOnlyDB) Remove(_ []byte) error {
+	return common.ErrDBIsReadOnly
+}
+
+// Destroy returns ErrDBIsReadOnly
+func (s *ReadOnlyDB) Destroy() error {
+	return common.ErrDBIsReadOnly
+}
+
+// DestroyClosed returns ErrDBIsReadOnly
+func (s *ReadOnlyDB) DestroyClosed() error {
+	return common.ErrDBIsReadOnly
+}
+
+// Stats returns the key stats of the persister
+func (s *ReadOnlyDB) Stats() map[string]interface{} {
+	return s.stats(common.LvlDBReadOnly)
+}
+
+// Capabilities returns the optional features of the persister: no snapshots, as its contents never change
+func (s *ReadOnlyDB) Capabilities() types.PersisterCapabilities {
+	return types.PersisterCapabilities{
+		SupportsIteration: true,
+		Durability:        types.SyncedDurability,
+	}
+}
+
+// Health returns the health of the persister
+func (s *ReadOnlyDB) Health() types.HealthStatus {
+	return s.health()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (s *ReadOnlyDB) IsInterfaceNil() bool {
+	return s == nil
+}
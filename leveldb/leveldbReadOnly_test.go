@@ -0,0 +1,73 @@
+package leveldb_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReadOnlyDB(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of open files should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb, err := leveldb.NewReadOnlyDB(t.TempDir(), 0)
+		assert.Nil(t, ldb)
+		assert.Equal(t, common.ErrInvalidNumOpenFiles, err)
+	})
+	t.Run("missing database should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb, err := leveldb.NewReadOnlyDB(filepath.Join(t.TempDir(), "missing"), 10)
+		assert.Nil(t, ldb)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestReadOnlyDB_ShouldReadButNotWrite(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir()
+	key, val := []byte("key"), []byte("value")
+	writableDB, err := leveldb.NewSerialDB(path, 1, 1, 10)
+	require.Nil(t, err)
+	assert.Equal(t, types.BufferedDurability, writableDB.Capabilities().Durability)
+	require.Nil(t, writableDB.Put(key, val))
+	require.Nil(t, writableDB.Close())
+
+	ldb, err := leveldb.NewReadOnlyDB(path, 10)
+	require.Nil(t, err)
+
+	v, err := ldb.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, v)
+	assert.Nil(t, ldb.Has(key))
+	assert.True(t, errors.Is(ldb.Has([]byte("missing")), common.ErrKeyNotFound))
+
+	numKeys := 0
+	ldb.RangeKeys(func(_ []byte, _ []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 1, numKeys)
+	assert.Equal(t, types.PersisterCapabilities{
+		SupportsIteration: true,
+		Durability:        types.SyncedDurability,
+	}, ldb.Capabilities())
+
+	assert.Equal(t, common.ErrDBIsReadOnly, ldb.Put(key, val))
+	assert.Equal(t, common.ErrDBIsReadOnly, ldb.Remove(key))
+	assert.Equal(t, common.ErrDBIsReadOnly, ldb.Destroy())
+
+	assert.Nil(t, ldb.Close())
+	_, err = ldb.Get(key)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+	assert.Equal(t, common.ErrDBIsReadOnly, ldb.DestroyClosed())
+}
@@ -12,12 +12,26 @@ import (
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-core/core/closing"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 var _ types.Persister = (*SerialDB)(nil)
+var _ types.Flusher = (*SerialDB)(nil)
+var _ types.MultiPutter = (*SerialDB)(nil)
+var _ types.RangeIterator = (*SerialDB)(nil)
+var _ types.IterablePersister = (*SerialDB)(nil)
+var _ types.PersisterWithContext = (*SerialDB)(nil)
+var _ types.BatchedReader = (*SerialDB)(nil)
+var _ types.BulkWriter = (*SerialDB)(nil)
+var _ types.SnapshotablePersister = (*SerialDB)(nil)
+var _ types.Compactable = (*SerialDB)(nil)
+var _ types.StatsProvider = (*SerialDB)(nil)
+var _ types.CapabilitiesProvider = (*SerialDB)(nil)
+var _ types.HealthChecker = (*SerialDB)(nil)
 
 // SerialDB holds a pointer to the leveldb database and the path to where it is stored.
 type SerialDB struct {
@@ -27,14 +41,21 @@ type SerialDB struct {
 	sizeBatch         int
 	batch             types.Batcher
 	mutBatch          sync.RWMutex
+	mutFlush          sync.Mutex
+	retryQueue        *batchRetryQueue
+	writeAround       *writeAround
 	dbAccess          chan serialQueryer
+	readAccess        chan serialQueryer
+	numReaders        int
+	numWaitingReads   atomic.Int64
+	numWaitingWrites  atomic.Int64
 	cancel            context.CancelFunc
 	closer            core.SafeCloser
 }
 
 // NewSerialDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
-func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *SerialDB, err error) {
+func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int, opts ...Option) (s *SerialDB, err error) {
 	constructorName := "NewSerialDB"
 
 	sw := core.NewStopWatch()
@@ -51,9 +72,10 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 		return nil, common.ErrInvalidNumOpenFiles
 	}
 
+	o := newOptions(opts)
 	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
+		// the internal cache is disabled, unless enabled by WithBlockCache
+		BlockCacheCapacity:     o.blockCacheCapacityOption(),
 		OpenFilesCacheCapacity: maxOpenFiles,
 	}
 
@@ -65,8 +87,10 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 	sw.Stop(openLevelDBFunction)
 
 	bldb := &baseLevelDb{
-		db:   db,
-		path: path,
+		db:                db,
+		path:              path,
+		latencies:         monitoring.LatenciesForPersister(path),
+		blockCacheEnabled: o.isBlockCacheEnabled(),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -75,15 +99,26 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 		maxBatchSize:      maxBatchSize,
 		batchDelaySeconds: batchDelaySeconds,
 		sizeBatch:         0,
+		retryQueue:        newBatchRetryQueue(path, o),
 		dbAccess:          make(chan serialQueryer),
 		cancel:            cancel,
 		closer:            closing.NewSafeChanCloser(),
 	}
 
 	dbStore.batch = NewBatch()
+	dbStore.writeAround = newWriteAround(o, path, dbStore.writeBatchSerially, dbStore.retryQueue)
+	// without a pool of readers, the reads are served by the process loop, along with the writes
+	dbStore.readAccess = dbStore.dbAccess
+	if o.numReaders > 0 {
+		dbStore.numReaders = o.numReaders
+		dbStore.readAccess = make(chan serialQueryer)
+	}
 
 	go dbStore.batchTimeoutHandle(ctx)
 	go dbStore.processLoop(ctx)
+	for i := 0; i < dbStore.numReaders; i++ {
+		go dbStore.readLoop(ctx)
+	}
 
 	runtime.SetFinalizer(dbStore, func(db *SerialDB) {
 		_ = db.Close()
@@ -109,7 +144,7 @@ func (s *SerialDB) batchTimeoutHandle(ctx context.Context) {
 
 		select {
 		case <-timer.C:
-			err := s.putBatch()
+			err := s.putBatch(false)
 			if err != nil {
 				log.Warn("leveldb serial putBatch", "error", err.Error())
 				continue
@@ -122,23 +157,42 @@ func (s *SerialDB) batchTimeoutHandle(ctx context.Context) {
 }
 
 func (s *SerialDB) updateBatchWithIncrement() error {
+	return s.increaseBatchSize(1)
+}
+
+func (s *SerialDB) increaseBatchSize(increment int) error {
 	s.mutBatch.Lock()
-	s.sizeBatch++
+	s.sizeBatch += increment
 	if s.sizeBatch < s.maxBatchSize {
 		s.mutBatch.Unlock()
 		return nil
 	}
 	s.mutBatch.Unlock()
 
-	err := s.putBatch()
+	err := s.putBatch(false)
 
 	return err
 }
 
-// Put adds the value to the (key, val) storage medium
+// Put adds the value to the (key, val) storage medium. The large values are written around the batch, if enabled by
+// WithWriteAround
 func (s *SerialDB) Put(key, val []byte) error {
+	defer s.latencies.ObserveSince(monitoring.PutOperation, time.Now())
+
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return s.errClosed()
+	}
+
+	if s.writeAround.isLarge(val) {
+		// no flush is in progress, as the batch being flushed is not visible meanwhile
+		s.mutFlush.Lock()
+		s.mutBatch.Lock()
+		isReserved := !s.isBatchedNoLock(key) && s.writeAround.reserve(key, val)
+		s.mutBatch.Unlock()
+		s.mutFlush.Unlock()
+		if isReserved {
+			return s.writeAround.put(key, val)
+		}
 	}
 
 	s.mutBatch.RLock()
@@ -151,43 +205,87 @@ func (s *SerialDB) Put(key, val []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
-// Get returns the value associated to the key
-func (s *SerialDB) Get(key []byte) ([]byte, error) {
+// MultiPut adds all the provided values to the batch at once, writing the batch to the storage medium if it gets full
+func (s *SerialDB) MultiPut(data map[string][]byte) error {
+	defer s.latencies.ObserveSince(monitoring.PutOperation, time.Now())
+
 	if s.isClosed() {
-		return nil, common.ErrDBIsClosed
+		return s.errClosed()
 	}
 
 	s.mutBatch.RLock()
-	if s.batch.IsRemoved(key) {
-		s.mutBatch.RUnlock()
-		return nil, common.ErrKeyNotFound
+	for key, val := range data {
+		err := s.batch.Put([]byte(key), val)
+		if err != nil {
+			s.mutBatch.RUnlock()
+			return err
+		}
 	}
-
-	data := s.batch.Get(key)
 	s.mutBatch.RUnlock()
 
+	return s.increaseBatchSize(len(data))
+}
+
+// PutCtx adds the value to the batch, unless the context is done. Writing a full batch to the storage medium is not
+// interrupted by the context, as the batch would be lost otherwise
+func (s *SerialDB) PutCtx(ctx context.Context, key, val []byte) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return s.Put(key, val)
+}
+
+// Get returns the value associated to the key
+func (s *SerialDB) Get(key []byte) ([]byte, error) {
+	return s.GetCtx(context.Background(), key)
+}
+
+// GetCtx returns the value associated to the key, giving up once the context is done, be it while waiting for the
+// serial access to the storage medium or for the result
+func (s *SerialDB) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	defer s.latencies.ObserveSince(monitoring.GetOperation, time.Now())
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	if s.isClosed() {
+		return nil, s.errClosed()
+	}
+
+	data, removed := s.getFromBatches(key)
+	if removed {
+		return nil, s.errKeyNotFound(key)
+	}
 	if data != nil {
 		return data, nil
 	}
 
-	ch := make(chan *pairResult)
+	// the result channel is buffered, so that the serial access go routine is not blocked by an abandoned request
+	ch := make(chan *pairResult, 1)
 	req := &getAct{
 		key:     key,
 		resChan: ch,
 	}
 
-	err := s.tryWriteInDbAccessChan(req)
+	err = s.tryWriteInDbAccessChan(ctx, s.readAccess, &s.numWaitingReads, req)
 	if err != nil {
 		return nil, err
 	}
-	result := <-ch
-	close(ch)
+	var result *pairResult
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
 	if result.err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, s.errKeyNotFound(key)
 	}
 	if result.err != nil {
-		return nil, result.err
+		return nil, common.WrapStorageError(result.err, key, "", s.path)
 	}
 
 	return result.value, nil
@@ -195,50 +293,111 @@ func (s *SerialDB) Get(key []byte) ([]byte, error) {
 
 // Has returns nil if the given key is present in the persistence medium
 func (s *SerialDB) Has(key []byte) error {
+	return s.HasCtx(context.Background(), key)
+}
+
+// HasCtx returns nil if the given key is present in the persistence medium, giving up once the context is done, be it
+// while waiting for the serial access to the storage medium or for the result
+func (s *SerialDB) HasCtx(ctx context.Context, key []byte) error {
+	defer s.latencies.ObserveSince(monitoring.HasOperation, time.Now())
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return s.errClosed()
 	}
 
-	s.mutBatch.RLock()
-	if s.batch.IsRemoved(key) {
-		s.mutBatch.RUnlock()
-		return common.ErrKeyNotFound
+	data, removed := s.getFromBatches(key)
+	if removed {
+		return s.errKeyNotFound(key)
 	}
-
-	data := s.batch.Get(key)
-	s.mutBatch.RUnlock()
-
 	if data != nil {
 		return nil
 	}
 
-	ch := make(chan error)
+	ch := make(chan error, 1)
 	req := &hasAct{
 		key:     key,
 		resChan: ch,
 	}
 
-	err := s.tryWriteInDbAccessChan(req)
+	err = s.tryWriteInDbAccessChan(ctx, s.readAccess, &s.numWaitingReads, req)
 	if err != nil {
 		return err
 	}
-	result := <-ch
-	close(ch)
+	select {
+	case err = <-ch:
+		return common.WrapStorageError(err, key, "", s.path)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	return result
+// getFromBatches looks the key up in the current batch, then in the values being written around, then in the batches
+// queued for retry, newest first
+func (s *SerialDB) getFromBatches(key []byte) (val []byte, removed bool) {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	if s.batch.IsRemoved(key) {
+		return nil, true
+	}
+
+	val = s.batch.Get(key)
+	if val != nil {
+		return val, false
+	}
+	val = s.writeAround.get(key)
+	if val != nil {
+		return val, false
+	}
+
+	val, removed, _ = s.retryQueue.get(key)
+
+	return val, removed
+}
+
+// isBatchedNoLock returns true if a write of the key waits in the current batch or in the batches queued for retry
+func (s *SerialDB) isBatchedNoLock(key []byte) bool {
+	if s.batch.IsRemoved(key) || s.batch.Get(key) != nil {
+		return true
+	}
+	_, _, found := s.retryQueue.get(key)
+
+	return found
 }
 
-func (s *SerialDB) tryWriteInDbAccessChan(req serialQueryer) error {
+// tryWriteInDbAccessChan hands the request to the go routines accessing the database, counting it as waiting meanwhile
+func (s *SerialDB) tryWriteInDbAccessChan(ctx context.Context, access chan serialQueryer, numWaiting *atomic.Int64, req serialQueryer) error {
+	numWaiting.Add(1)
+	defer numWaiting.Add(-1)
+
 	select {
-	case s.dbAccess <- req:
+	case access <- req:
 		return nil
 	case <-s.closer.ChanClose():
-		return common.ErrDBIsClosed
+		return s.errClosed()
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// putBatch writes the Batch data into the database
-func (s *SerialDB) putBatch() error {
+// putBatch writes the batches queued for retry, then the current batch, into the database. The current batch is queued
+// in turn if it can not be written, so that its writes are not lost. Unless forced, the queued batches are only retried
+// once their backoff elapsed, the current batch being queued without being written meanwhile. If the retry queue is
+// full, the writes of the current batch are moved back into the new batch, to be written along with the next writes.
+// The flushes are serialized, so that the batches reach the database in the order they were filled, after the values
+// being written around
+func (s *SerialDB) putBatch(force bool) error {
+	defer s.latencies.ObserveSince(monitoring.FlushOperation, time.Now())
+
+	s.mutFlush.Lock()
+	defer s.mutFlush.Unlock()
+
+	s.writeAround.wait()
+
 	s.mutBatch.Lock()
 	dbBatch, ok := s.batch.(*batch)
 	if !ok {
@@ -249,13 +408,37 @@ func (s *SerialDB) putBatch() error {
 	s.batch = NewBatch()
 	s.mutBatch.Unlock()
 
+	err := s.retryQueue.retry(s.writeBatchSerially, force)
+	if err == nil {
+		err = s.writeBatchSerially(dbBatch)
+	}
+	if err == nil || dbBatch.Len() == 0 {
+		return err
+	}
+	if s.retryQueue.add(dbBatch, err) {
+		return err
+	}
+
+	s.mutBatch.Lock()
+	newBatch, ok := s.batch.(*batch)
+	if ok {
+		newBatch.mergeOlder(dbBatch)
+		s.sizeBatch += dbBatch.Len()
+	}
+	s.mutBatch.Unlock()
+
+	return s.retryQueue.errFull(err)
+}
+
+// writeBatchSerially writes the batch through the go routine serializing the access to the database
+func (s *SerialDB) writeBatchSerially(dbBatch *batch) error {
 	ch := make(chan error)
 	req := &putBatchAct{
 		batch:   dbBatch,
 		resChan: ch,
 	}
 
-	err := s.tryWriteInDbAccessChan(req)
+	err := s.tryWriteInDbAccessChan(context.Background(), s.dbAccess, &s.numWaitingWrites, req)
 	if err != nil {
 		return err
 	}
@@ -265,6 +448,12 @@ func (s *SerialDB) putBatch() error {
 	return result
 }
 
+// Flush writes the batches queued for retry, then the pending batch, to the storage medium, without waiting for the
+// batch delay or the retry backoff
+func (s *SerialDB) Flush() error {
+	return s.putBatch(true)
+}
+
 func (s *SerialDB) isClosed() bool {
 	db := s.getDbPointer()
 
@@ -282,8 +471,10 @@ func (s *SerialDB) Close() error {
 
 // Remove removes the data associated to the given key
 func (s *SerialDB) Remove(key []byte) error {
+	defer s.latencies.ObserveSince(monitoring.RemoveOperation, time.Now())
+
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return s.errClosed()
 	}
 
 	s.mutBatch.Lock()
@@ -293,6 +484,60 @@ func (s *SerialDB) Remove(key []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
+// RemoveCtx marks the key as removed in the batch, unless the context is done
+func (s *SerialDB) RemoveCtx(ctx context.Context, key []byte) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return s.Remove(key)
+}
+
+// GetMany returns the values of the provided keys, the missing keys being left out
+func (s *SerialDB) GetMany(keys [][]byte) (map[string][]byte, error) {
+	return getMany(s.Get, keys)
+}
+
+// MultiRemove marks all the provided keys as removed in the batch at once, writing the batch to the storage medium if
+// it gets full
+func (s *SerialDB) MultiRemove(keys [][]byte) error {
+	defer s.latencies.ObserveSince(monitoring.RemoveOperation, time.Now())
+
+	if s.isClosed() {
+		return s.errClosed()
+	}
+
+	s.mutBatch.Lock()
+	for _, key := range keys {
+		_ = s.batch.Delete(key)
+	}
+	s.mutBatch.Unlock()
+
+	return s.increaseBatchSize(len(keys))
+}
+
+// TakeSnapshot writes the pending batch, then returns a view of the current contents of the storage medium, which has
+// to be released after use
+func (s *SerialDB) TakeSnapshot() (types.PersisterView, error) {
+	err := s.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.snapshotView()
+}
+
+// ReleaseSnapshot releases the view returned by TakeSnapshot
+func (s *SerialDB) ReleaseSnapshot(view types.PersisterView) {
+	releaseView(view)
+}
+
+// Compact compacts the whole storage medium, reclaiming the space held by the removed and overwritten values
+func (s *SerialDB) Compact() error {
+	return s.compact()
+}
+
 // Destroy removes the storage medium stored data
 func (s *SerialDB) Destroy() error {
 	log.Debug("serialDB.Destroy", "path", s.path)
@@ -303,7 +548,7 @@ func (s *SerialDB) Destroy() error {
 
 	err := s.doClose()
 	if err == nil {
-		return os.RemoveAll(s.path)
+		return layout.RemoveDir(s.path)
 	}
 
 	return err
@@ -311,7 +556,7 @@ func (s *SerialDB) Destroy() error {
 
 // DestroyClosed removes the already closed storage medium stored data
 func (s *SerialDB) DestroyClosed() error {
-	err := os.RemoveAll(s.path)
+	err := layout.RemoveDir(s.path)
 	if err != nil {
 		log.Error("error destroy closed", "error", err, "path", s.path)
 	}
@@ -322,7 +567,10 @@ func (s *SerialDB) DestroyClosed() error {
 // must be called under mutex protection
 // TODO: re-use this function in leveldb.go as well
 func (s *SerialDB) doClose() error {
-	_ = s.putBatch()
+	err := s.putBatch(true)
+	if err != nil {
+		s.retryQueue.drop(err)
+	}
 	s.cancel()
 
 	db := s.makeDbPointerNilReturningLast()
@@ -345,6 +593,46 @@ func (s *SerialDB) processLoop(ctx context.Context) {
 	}
 }
 
+// readLoop serves the reads of the pool of readers, concurrently with the process loop serving the writes
+func (s *SerialDB) readLoop(ctx context.Context) {
+	for {
+		select {
+		case queryer := <-s.readAccess:
+			queryer.request(s)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stats returns the key stats of the persister, including the writes pending in the batch and the number of the
+// requests waiting for the access to the storage medium
+func (s *SerialDB) Stats() map[string]interface{} {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	stats := s.retryQueue.addStats(addBatchStats(s.stats(common.LvlDBSerial), s.batch))
+	stats["numReaders"] = s.numReaders
+	stats["readQueueDepth"] = s.numWaitingReads.Load()
+	stats["writeQueueDepth"] = s.numWaitingWrites.Load()
+
+	return s.writeAround.addStats(stats)
+}
+
+// Capabilities returns the optional features of the persister: the writes are batched before reaching the disk
+func (s *SerialDB) Capabilities() types.PersisterCapabilities {
+	return types.PersisterCapabilities{
+		SupportsSnapshots: true,
+		SupportsIteration: true,
+		Durability:        types.BufferedDurability,
+	}
+}
+
+// Health returns the health of the persister
+func (s *SerialDB) Health() types.HealthStatus {
+	return s.retryQueue.addHealth(s.health())
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (s *SerialDB) IsInterfaceNil() bool {
 	return s == nil
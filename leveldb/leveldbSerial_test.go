@@ -1,6 +1,8 @@
 package leveldb_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
@@ -9,8 +11,10 @@ import (
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
 )
 
 func createSerialLevelDb(tb testing.TB, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (p *leveldb.SerialDB) {
@@ -29,6 +33,28 @@ func TestSerialDB_PutNoError(t *testing.T) {
 	assert.Nil(t, err, "error saving in DB")
 }
 
+func TestSerialDB_MultiPut(t *testing.T) {
+	data := map[string][]byte{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+		"key3": []byte("value3"),
+	}
+	ldb := createSerialLevelDb(t, 10, 2, 10)
+
+	err := ldb.MultiPut(data)
+	assert.Nil(t, err)
+
+	for key, val := range data {
+		v, errGet := ldb.Get([]byte(key))
+		assert.Nil(t, errGet)
+		assert.Equal(t, val, v)
+	}
+
+	_ = ldb.Close()
+	err = ldb.MultiPut(data)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+}
+
 func TestSerialDB_GetErrorAfterPutBeforeTimeout(t *testing.T) {
 	key, val := []byte("key"), []byte("value")
 	ldb := createSerialLevelDb(t, 1, 100, 10)
@@ -81,16 +107,16 @@ func testSerialDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *le
 	closeHandler(ldb)
 
 	_, err := ldb.Get([]byte("key1"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	err = ldb.Has([]byte("key2"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	err = ldb.Remove([]byte("key3"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	err = ldb.Put([]byte("key4"), []byte("val"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	ldb.RangeKeys(func(key []byte, value []byte) bool {
 		require.Fail(t, "should have not called range")
@@ -120,7 +146,7 @@ func TestSerialDB_RemoveBeforeTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -133,7 +159,7 @@ func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestSerialDB_GetPresent(t *testing.T) {
@@ -173,7 +199,7 @@ func TestSerialDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestSerialDB_RemovePresent(t *testing.T) {
@@ -185,7 +211,7 @@ func TestSerialDB_RemovePresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestSerialDB_RemoveNotPresent(t *testing.T) {
@@ -222,6 +248,31 @@ func TestSerialDB_Destroy(t *testing.T) {
 	assert.Nil(t, err, "no error expected but got %s", err)
 }
 
+func TestSerialDB_ShouldMeasureTheOperationsLatencies(t *testing.T) {
+	path := t.TempDir()
+	ldb, err := leveldb.NewSerialDB(path, 10, 1, 10)
+	require.Nil(t, err)
+
+	key := []byte("key")
+	_ = ldb.Put(key, []byte("value"))
+	_, _ = ldb.Get(key)
+	_ = ldb.Has(key)
+	_ = ldb.Remove(key)
+	_ = ldb.Close()
+
+	latencies, found := monitoring.GetPersisterLatencies(path)
+	require.True(t, found)
+	for _, operation := range []monitoring.PersisterOperation{
+		monitoring.PutOperation,
+		monitoring.GetOperation,
+		monitoring.HasOperation,
+		monitoring.RemoveOperation,
+		monitoring.FlushOperation,
+	} {
+		assert.True(t, latencies[operation].Count > 0, operation)
+	}
+}
+
 func TestSerialDB_SpecialValueTest(t *testing.T) {
 	t.Parallel()
 
@@ -245,7 +296,7 @@ func TestSerialDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
@@ -358,3 +409,123 @@ func TestSerialDB_ConcurrentOperations(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSerialDB_FlushShouldWriteThePendingBatch(t *testing.T) {
+	ldb := createSerialLevelDb(t, 100, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("key"), []byte("value"))
+	err := ldb.Flush()
+	assert.Nil(t, err)
+
+	numKeys := 0
+	ldb.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 1, numKeys)
+}
+
+func TestSerialDB_ContextAwareOperations(t *testing.T) {
+	key, val := []byte("key"), []byte("value")
+	ldb := createSerialLevelDb(t, 10, 10, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	ctx := context.Background()
+	require.Nil(t, ldb.PutCtx(ctx, key, val))
+	require.Nil(t, ldb.Flush())
+	v, err := ldb.GetCtx(ctx, key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, v)
+	assert.Nil(t, ldb.HasCtx(ctx, key))
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, ldb.PutCtx(cancelledCtx, []byte("other"), val))
+	assert.Equal(t, context.Canceled, ldb.RemoveCtx(cancelledCtx, key))
+	_, err = ldb.GetCtx(cancelledCtx, key)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, ldb.HasCtx(cancelledCtx, key))
+
+	assert.Nil(t, ldb.Has(key))
+	assert.True(t, errors.Is(ldb.Has([]byte("other")), common.ErrKeyNotFound))
+	require.Nil(t, ldb.RemoveCtx(ctx, key))
+	assert.True(t, errors.Is(ldb.HasCtx(ctx, key), common.ErrKeyNotFound))
+}
+
+func TestSerialDB_NumReaders(t *testing.T) {
+	key, val := []byte("key"), []byte("value")
+
+	// createBlockedWriter returns a serial db whose next batch write blocks until the returned channel is closed
+	createBlockedWriter := func(t *testing.T, opts ...leveldb.Option) (*leveldb.SerialDB, chan struct{}) {
+		ldb, err := leveldb.NewSerialDB(t.TempDir(), 100, 1, 10, opts...)
+		require.Nil(t, err)
+		require.Nil(t, ldb.Put(key, val))
+
+		unblock := make(chan struct{})
+		blocked := make(chan struct{})
+		once := sync.Once{}
+		ldb.SetWriteBatchHandler(func(db *goleveldb.DB, b *goleveldb.Batch) error {
+			once.Do(func() {
+				close(blocked)
+			})
+			<-unblock
+			return leveldb.WriteBatchSynced(db, b)
+		})
+		go func() {
+			_ = ldb.Put([]byte("other"), val)
+		}()
+		<-blocked
+
+		return ldb, unblock
+	}
+
+	t.Run("reads should wait for the writes by default", func(t *testing.T) {
+		ldb, unblock := createBlockedWriter(t)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		chResult := make(chan error, 1)
+		go func() {
+			_, errGet := ldb.Get(key)
+			chResult <- errGet
+		}()
+
+		require.Eventually(t, func() bool {
+			return ldb.Stats()["readQueueDepth"] == int64(1)
+		}, time.Second, time.Millisecond*10)
+		assert.Equal(t, 0, ldb.Stats()["numReaders"])
+
+		close(unblock)
+		assert.Nil(t, <-chResult)
+		assert.Equal(t, int64(0), ldb.Stats()["readQueueDepth"])
+	})
+
+	t.Run("reads should not wait for the writes with a pool of readers", func(t *testing.T) {
+		ldb, unblock := createBlockedWriter(t, leveldb.WithNumReaders(4))
+		defer func() {
+			close(unblock)
+			_ = ldb.Close()
+		}()
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				v, errGet := ldb.Get(key)
+				assert.Nil(t, errGet)
+				assert.Equal(t, val, v)
+				assert.Nil(t, ldb.Has(key))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 4, ldb.Stats()["numReaders"])
+	})
+}
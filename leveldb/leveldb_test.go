@@ -2,6 +2,7 @@ package leveldb_test
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -92,6 +95,25 @@ func TestDB_PutNoError(t *testing.T) {
 	assert.Nil(t, err, "error saving in DB")
 }
 
+func TestDB_MultiPut(t *testing.T) {
+	data := map[string][]byte{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+		"key3": []byte("value3"),
+	}
+	ldb := createLevelDb(t, 10, 2, 10)
+
+	err := ldb.MultiPut(data)
+	assert.Nil(t, err)
+
+	for key, val := range data {
+		v, errGet := ldb.Get([]byte(key))
+		assert.Nil(t, errGet)
+		assert.Equal(t, val, v)
+	}
+	_ = ldb.Close()
+}
+
 func TestDB_GetErrorAfterPutBeforeTimeout(t *testing.T) {
 	key, val := []byte("key"), []byte("value")
 	ldb := createLevelDb(t, 1, 100, 10)
@@ -141,7 +163,7 @@ func TestDB_RemoveBeforeTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -156,7 +178,7 @@ func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestDB_GetPresent(t *testing.T) {
@@ -202,7 +224,7 @@ func TestDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestDB_RemovePresent(t *testing.T) {
@@ -220,7 +242,7 @@ func TestDB_RemovePresent(t *testing.T) {
 	err = ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestDB_RemoveNotPresent(t *testing.T) {
@@ -282,6 +304,32 @@ func TestDB_RangeKeys(t *testing.T) {
 	assert.Equal(t, keysVals, recovered)
 }
 
+func TestDB_RangeKeysBetween(t *testing.T) {
+	ldb := createLevelDb(t, 1, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	for _, key := range []string{"key3", "key1", "key5", "key2", "key4"} {
+		_ = ldb.Put([]byte(key), []byte("value"+key[3:]))
+	}
+	_ = ldb.Flush()
+
+	recovered := make([]string, 0)
+	ldb.RangeKeysBetween([]byte("key2"), []byte("key5"), func(key []byte, val []byte) bool {
+		recovered = append(recovered, string(key)+"="+string(val))
+		return true
+	})
+	assert.Equal(t, []string{"key2=value2", "key3=value3", "key4=value4"}, recovered)
+
+	recovered = make([]string, 0)
+	ldb.RangeKeysBetween([]byte("key4"), nil, func(key []byte, val []byte) bool {
+		recovered = append(recovered, string(key))
+		return len(recovered) < 1
+	})
+	assert.Equal(t, []string{"key4"}, recovered)
+}
+
 func TestDB_PutGetLargeValue(t *testing.T) {
 	t.Parallel()
 
@@ -336,13 +384,13 @@ func testDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *leveldb.
 	closeHandler(ldb)
 
 	err := ldb.Put([]byte("key1"), []byte("val1"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	_, err = ldb.Get([]byte("key2"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	err = ldb.Has([]byte("key3"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.True(t, errors.Is(err, common.ErrDBIsClosed))
 
 	ldb.RangeKeys(func(key []byte, value []byte) bool {
 		require.Fail(t, "should have not called range")
@@ -350,7 +398,7 @@ func testDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *leveldb.
 	})
 
 	err = ldb.Remove([]byte("key4"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.True(t, errors.Is(err, common.ErrDBIsClosed))
 }
 
 func TestDB_SpecialValueTest(t *testing.T) {
@@ -376,7 +424,7 @@ func TestDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
@@ -452,3 +500,248 @@ func TestDB_ConcurrentOperations(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestDB_FlushShouldWriteThePendingBatch(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("key"), []byte("value"))
+	numKeys := 0
+	ldb.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 0, numKeys)
+
+	err := ldb.Flush()
+	assert.Nil(t, err)
+	ldb.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 1, numKeys)
+}
+
+func TestDB_Capabilities(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.MultiPut(map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2"), "key3": []byte("value3")})
+	values, err := ldb.GetMany([][]byte{[]byte("key1"), []byte("key2"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}, values)
+
+	// taking the view writes the pending batch, so that it is visible in the view
+	view, err := ldb.TakeSnapshot()
+	require.Nil(t, err)
+	defer ldb.ReleaseSnapshot(view)
+
+	assert.Nil(t, ldb.MultiRemove([][]byte{[]byte("key1"), []byte("key2")}))
+	assert.True(t, errors.Is(ldb.Has([]byte("key1")), common.ErrKeyNotFound))
+	assert.Nil(t, ldb.Flush())
+	assert.Nil(t, ldb.Compact())
+
+	val, err := view.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	_, err = view.Get([]byte("missing"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	keys := make([]string, 0)
+	view.RangeKeys(func(key []byte, val []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, []string{"key1", "key2", "key3"}, keys)
+
+	_ = ldb.Close()
+	_, err = ldb.TakeSnapshot()
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+	assert.True(t, errors.Is(ldb.Compact(), common.ErrDBIsClosed))
+}
+
+func TestDB_NewIterator(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.MultiPut(map[string][]byte{"b": []byte("2"), "d": []byte("4"), "a": []byte("1")})
+	require.Nil(t, ldb.Flush())
+
+	it, err := ldb.NewIterator()
+	require.Nil(t, err)
+	keys := make([]string, 0)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.Equal(t, []string{"a", "b", "d"}, keys)
+
+	assert.True(t, it.Seek([]byte("c")))
+	assert.Equal(t, []byte("d"), it.Key())
+	assert.Equal(t, []byte("4"), it.Value())
+	assert.False(t, it.Seek([]byte("e")))
+
+	keys = make([]string, 0)
+	for ok := it.Last(); ok; ok = it.Prev() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.Equal(t, []string{"d", "b", "a"}, keys)
+	assert.Nil(t, it.Close())
+
+	_ = ldb.Close()
+	_, err = ldb.NewIterator()
+	assert.True(t, common.IsClosed(err))
+}
+
+func TestDB_SeekLastWithPrefix(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.MultiPut(map[string][]byte{
+		"block-1":  []byte("1"),
+		"block-2":  []byte("2"),
+		"block-3":  []byte("3"),
+		"header-1": []byte("h1"),
+		"\xff\xff": []byte("ff"),
+	})
+	require.Nil(t, ldb.Flush())
+
+	it, err := ldb.NewIterator()
+	require.Nil(t, err)
+	defer func() {
+		_ = it.Close()
+	}()
+
+	assert.True(t, types.SeekLastWithPrefix(it, []byte("block-")))
+	assert.Equal(t, []byte("block-3"), it.Key())
+	assert.True(t, types.SeekLastWithPrefix(it, []byte("header-")))
+	assert.Equal(t, []byte("h1"), it.Value())
+	assert.True(t, types.SeekLastWithPrefix(it, []byte("\xff")))
+	assert.Equal(t, []byte("ff"), it.Value())
+	assert.False(t, types.SeekLastWithPrefix(it, []byte("body-")))
+	assert.False(t, types.SeekLastWithPrefix(it, []byte("zzz")))
+}
+
+func TestDB_Stats(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+
+	_ = ldb.Put([]byte("key"), []byte("value"))
+	stats := ldb.Stats()
+	assert.Equal(t, string(common.LvlDB), stats[types.StatType])
+	assert.Equal(t, 1, stats["numPendingWrites"])
+	assert.Equal(t, uint64(8), stats["pendingWritesBytes"])
+
+	assert.Nil(t, ldb.Flush())
+	assert.Nil(t, ldb.Compact())
+	stats = ldb.Stats()
+	assert.Equal(t, 0, stats["numPendingWrites"])
+	assert.Positive(t, stats[types.StatSizeInBytes])
+
+	_ = ldb.Close()
+	stats = ldb.Stats()
+	assert.Equal(t, true, stats["closed"])
+	assert.NotContains(t, stats, types.StatSizeInBytes)
+}
+
+func TestDB_BlockCache(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		ldb := createLevelDb(t, 100, 100, 10)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		assert.NotContains(t, ldb.Stats(), "blockCacheHitRatio")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		ldb, err := leveldb.NewDB(t.TempDir(), 100, 100, 10, leveldb.WithBlockCache(1024*1024))
+		require.Nil(t, err)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		_ = ldb.Put([]byte("key"), []byte("value"))
+		require.Nil(t, ldb.Flush())
+		require.Nil(t, ldb.Compact())
+
+		for i := 0; i < 10; i++ {
+			val, errGet := ldb.Get([]byte("key"))
+			require.Nil(t, errGet)
+			require.Equal(t, []byte("value"), val)
+		}
+
+		stats := ldb.Stats()
+		assert.Positive(t, stats["blockCacheHits"])
+		assert.Positive(t, stats["blockCacheSizeInBytes"])
+		assert.Greater(t, stats["blockCacheHitRatio"], 0.5)
+	})
+}
+
+func TestDB_Health(t *testing.T) {
+	ldb := createLevelDb(t, 100, 100, 10)
+
+	status := ldb.Health()
+	assert.True(t, status.IsOK())
+
+	_ = ldb.Close()
+	status = ldb.Health()
+	assert.Equal(t, types.HealthFailed, status.State)
+	assert.Equal(t, []string{"db is closed"}, status.Reasons)
+}
+
+func TestBatchedMemDbMock_ShouldMatchTheBatchingOfTheDB(t *testing.T) {
+	maxBatchSize := 4
+	ldb := createLevelDb(t, 100, maxBatchSize, 10)
+	mock := testscommon.NewBatchedMemDbMock(maxBatchSize)
+	persisters := []interface {
+		types.Persister
+		types.Flusher
+	}{ldb, mock}
+
+	rangedKeys := func(persister types.Persister) []string {
+		keys := make([]string, 0)
+		persister.RangeKeys(func(key []byte, _ []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		return keys
+	}
+	assertSameView := func(keys ...string) {
+		for _, key := range keys {
+			dbVal, dbErr := ldb.Get([]byte(key))
+			mockVal, mockErr := mock.Get([]byte(key))
+			assert.Equal(t, dbVal, mockVal, key)
+			assert.Equal(t, errors.Is(dbErr, common.ErrKeyNotFound), errors.Is(mockErr, common.ErrKeyNotFound), key)
+			assert.Equal(t, ldb.Has([]byte(key)) == nil, mock.Has([]byte(key)) == nil, key)
+		}
+		assert.Equal(t, rangedKeys(ldb), rangedKeys(mock))
+	}
+
+	for _, persister := range persisters {
+		_ = persister.Put([]byte("a"), []byte("a1"))
+		_ = persister.Put([]byte("b"), []byte("b1"))
+		_ = persister.Remove([]byte("a"))
+	}
+	assertSameView("a", "b", "c")
+	assert.Empty(t, rangedKeys(mock))
+
+	for _, persister := range persisters {
+		_ = persister.Put([]byte("c"), []byte("c1"))
+	}
+	assertSameView("a", "b", "c")
+	assert.Equal(t, []string{"b", "c"}, rangedKeys(mock))
+
+	for _, persister := range persisters {
+		_ = persister.Remove([]byte("b"))
+		_ = persister.Put([]byte("a"), []byte("a2"))
+		require.Nil(t, persister.Flush())
+	}
+	assertSameView("a", "b", "c")
+	assert.Equal(t, []string{"a", "c"}, rangedKeys(mock))
+}
@@ -0,0 +1,89 @@
+package leveldb
+
+import "time"
+
+// Option customizes the leveldb persisters created by NewDB, NewSerialDB and NewReadOnlyDB
+type Option func(options *options)
+
+type options struct {
+	blockCacheCapacity   int
+	maxNumBatchesToRetry int
+	initialRetryBackoff  time.Duration
+	maxRetryBackoff      time.Duration
+	numReaders           int
+	writeAroundThreshold int
+	writeAroundAsync     bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		maxNumBatchesToRetry: defaultMaxNumBatchesToRetry,
+		initialRetryBackoff:  defaultInitialRetryBackoff,
+		maxRetryBackoff:      defaultMaxRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithBlockCache enables the block cache of leveldb, holding up to capacityInBytes bytes of the recently read blocks,
+// for the persisters not fronted by a cache of their own. The block cache is disabled by default, or if the capacity
+// is not positive
+func WithBlockCache(capacityInBytes int) Option {
+	return func(options *options) {
+		options.blockCacheCapacity = capacityInBytes
+	}
+}
+
+// WithRetryQueue customizes the queue of the batches whose writes failed, holding up to maxNumBatches batches to be
+// retried, with a backoff doubling from initialBackoff up to maxBackoff between the failed attempts. Once the queue is
+// full, the writes filling a new batch return common.ErrRetryQueueFull. The non positive values keep the defaults of
+// 10 batches, retried after a backoff doubling from one second up to one minute
+func WithRetryQueue(maxNumBatches int, initialBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(options *options) {
+		if maxNumBatches > 0 {
+			options.maxNumBatchesToRetry = maxNumBatches
+		}
+		if initialBackoff > 0 {
+			options.initialRetryBackoff = initialBackoff
+		}
+		if maxBackoff > 0 {
+			options.maxRetryBackoff = maxBackoff
+		}
+	}
+}
+
+// WithNumReaders makes the SerialDB serve its reads from a pool of numReaders go routines, concurrently between them
+// and with the writes, which stay serialized. By default, or if numReaders is not positive, the reads are serialized
+// along with the writes. Ignored by the other persisters
+func WithNumReaders(numReaders int) Option {
+	return func(options *options) {
+		options.numReaders = numReaders
+	}
+}
+
+// WithWriteAround makes Put write the values at least thresholdInBytes large straight to the database, bypassing the
+// batch, asynchronously if so requested, as the large values parked in the batch inflate the heap and make the flushes
+// spiky. By default, or if thresholdInBytes is not positive, all the values are batched. Ignored by the read only
+// persisters
+func WithWriteAround(thresholdInBytes int, async bool) Option {
+	return func(options *options) {
+		options.writeAroundThreshold = thresholdInBytes
+		options.writeAroundAsync = async
+	}
+}
+
+func (o *options) isBlockCacheEnabled() bool {
+	return o.blockCacheCapacity > 0
+}
+
+// blockCacheCapacityOption returns the block cache capacity passed to leveldb, where -1 disables the cache
+func (o *options) blockCacheCapacityOption() int {
+	if o.isBlockCacheEnabled() {
+		return o.blockCacheCapacity
+	}
+
+	return -1
+}
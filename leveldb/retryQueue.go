@@ -0,0 +1,195 @@
+package leveldb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const defaultMaxNumBatchesToRetry = 10
+const defaultInitialRetryBackoff = time.Second
+const defaultMaxRetryBackoff = time.Minute
+
+// batchRetryQueue holds the batches whose writes failed, oldest first, so that the acknowledged writes are not lost on
+// transient disk errors. The batches are retried in order, with an exponential backoff between the failed attempts,
+// and remain visible to the reads until written. A newer batch is never written while older ones are still queued, as
+// it would be overwritten by them afterwards
+type batchRetryQueue struct {
+	mut             sync.RWMutex
+	path            string
+	batches         []*batch
+	maxNumBatches   int
+	initialBackoff  time.Duration
+	maxBackoff      time.Duration
+	backoff         time.Duration
+	nextAttempt     time.Time
+	lastErr         error
+	numFailedWrites uint64
+}
+
+func newBatchRetryQueue(path string, o *options) *batchRetryQueue {
+	return &batchRetryQueue{
+		path:           path,
+		batches:        make([]*batch, 0),
+		maxNumBatches:  o.maxNumBatchesToRetry,
+		initialBackoff: o.initialRetryBackoff,
+		maxBackoff:     o.maxRetryBackoff,
+		backoff:        o.initialRetryBackoff,
+	}
+}
+
+// retry writes the queued batches, in order, stopping at the first failure. Unless forced, nothing is attempted before
+// the backoff elapsed since the previous failure. Returns nil only if the queue was emptied
+func (q *batchRetryQueue) retry(write func(b *batch) error, force bool) error {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if len(q.batches) == 0 {
+		return nil
+	}
+	if !force && time.Now().Before(q.nextAttempt) {
+		return fmt.Errorf("%w, %d batches waiting to be retried", q.lastErr, len(q.batches))
+	}
+
+	for len(q.batches) > 0 {
+		err := write(q.batches[0])
+		if err != nil {
+			q.onFailureNoLock(err)
+			return fmt.Errorf("%w, %d batches waiting to be retried", err, len(q.batches))
+		}
+
+		q.batches[0] = nil
+		q.batches = q.batches[1:]
+	}
+
+	log.Debug("batchRetryQueue: the failed batches were written", "path", q.path)
+	q.backoff = q.initialBackoff
+	q.nextAttempt = time.Time{}
+	q.lastErr = nil
+
+	return nil
+}
+
+// add queues the batch whose write failed, returning false if the queue is full
+func (q *batchRetryQueue) add(b *batch, err error) bool {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if len(q.batches) >= q.maxNumBatches {
+		q.numFailedWrites++
+		log.Error("batchRetryQueue: the failed batch could not be queued", "path", q.path,
+			"num queued batches", len(q.batches), "error", err)
+		monitoring.PublishEvent(monitoring.NewBatchWriteFailedEvent(q.path, err, len(q.batches), false))
+		return false
+	}
+
+	q.batches = append(q.batches, b)
+	q.onFailureNoLock(err)
+
+	return true
+}
+
+func (q *batchRetryQueue) onFailureNoLock(err error) {
+	q.numFailedWrites++
+	q.lastErr = err
+	q.nextAttempt = time.Now().Add(q.backoff)
+	log.Warn("batchRetryQueue: batch write failed, will retry", "path", q.path,
+		"num queued batches", len(q.batches), "retry in", q.backoff, "error", err)
+	monitoring.PublishEvent(monitoring.NewBatchWriteFailedEvent(q.path, err, len(q.batches), true))
+
+	q.backoff *= 2
+	if q.backoff > q.maxBackoff {
+		q.backoff = q.maxBackoff
+	}
+}
+
+// get looks the key up in the queued batches, newest first. Returns found as false if none of them holds the key,
+// removed as true if the newest one holding it marks it for removal
+func (q *batchRetryQueue) get(key []byte) (val []byte, removed bool, found bool) {
+	q.mut.RLock()
+	defer q.mut.RUnlock()
+
+	for i := len(q.batches) - 1; i >= 0; i-- {
+		if q.batches[i].IsRemoved(key) {
+			return nil, true, true
+		}
+
+		val = q.batches[i].Get(key)
+		if val != nil {
+			return val, false, true
+		}
+	}
+
+	return nil, false, false
+}
+
+func (q *batchRetryQueue) len() int {
+	q.mut.RLock()
+	defer q.mut.RUnlock()
+
+	return len(q.batches)
+}
+
+// clear drops the queued batches, returning the number of the writes dropped
+func (q *batchRetryQueue) clear() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	numWrites := q.numWritesNoLock()
+	q.batches = make([]*batch, 0)
+	q.backoff = q.initialBackoff
+	q.nextAttempt = time.Time{}
+	q.lastErr = nil
+
+	return numWrites
+}
+
+func (q *batchRetryQueue) numWritesNoLock() int {
+	numWrites := 0
+	for _, b := range q.batches {
+		numWrites += b.Len()
+	}
+
+	return numWrites
+}
+
+// drop clears the queue of a persister being closed, reporting the writes that could not be retried as lost
+func (q *batchRetryQueue) drop(err error) {
+	numQueued := q.len()
+	numLost := q.clear()
+	if numLost == 0 {
+		return
+	}
+
+	log.Error("batchRetryQueue: writes lost while closing", "path", q.path, "num lost writes", numLost, "error", err)
+	monitoring.PublishEvent(monitoring.NewBatchWriteFailedEvent(q.path, err, numQueued, false))
+}
+
+func (q *batchRetryQueue) addStats(stats map[string]interface{}) map[string]interface{} {
+	q.mut.RLock()
+	defer q.mut.RUnlock()
+
+	stats["numBatchesToRetry"] = len(q.batches)
+	stats["numWritesToRetry"] = q.numWritesNoLock()
+	stats["numFailedBatchWrites"] = q.numFailedWrites
+
+	return stats
+}
+
+// addHealth degrades the status while there are batches waiting to be retried
+func (q *batchRetryQueue) addHealth(status types.HealthStatus) types.HealthStatus {
+	numBatches := q.len()
+	if numBatches > 0 {
+		status.Degrade("%d batches waiting to be retried after failed writes", numBatches)
+	}
+
+	return status
+}
+
+func (q *batchRetryQueue) errFull(err error) error {
+	return fmt.Errorf("%w: %d batches already queued, after %v", common.ErrRetryQueueFull, q.len(), err)
+}
@@ -0,0 +1,148 @@
+package leveldb_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+)
+
+var errDiskFailure = errors.New("disk failure")
+
+type persisterWithFailingWrites interface {
+	types.Persister
+	types.Flusher
+	types.StatsProvider
+	types.HealthChecker
+	SetWriteBatchHandler(handler func(db *goleveldb.DB, b *goleveldb.Batch) error)
+}
+
+type persisterConstructor func(path string, batchDelaySeconds int, maxBatchSize int, opts ...leveldb.Option) (persisterWithFailingWrites, error)
+
+var persisterConstructors = map[string]persisterConstructor{
+	"DB": func(path string, batchDelaySeconds int, maxBatchSize int, opts ...leveldb.Option) (persisterWithFailingWrites, error) {
+		return leveldb.NewDB(path, batchDelaySeconds, maxBatchSize, 10, opts...)
+	},
+	"SerialDB": func(path string, batchDelaySeconds int, maxBatchSize int, opts ...leveldb.Option) (persisterWithFailingWrites, error) {
+		return leveldb.NewSerialDB(path, batchDelaySeconds, maxBatchSize, 10, opts...)
+	},
+}
+
+// createPersisterWithFailingWrites creates a persister whose writes fail while the returned flag is set
+func createPersisterWithFailingWrites(t *testing.T, constructor persisterConstructor, path string, batchDelaySeconds int, opts ...leveldb.Option) (persisterWithFailingWrites, *atomic.Bool) {
+	persister, err := constructor(path, batchDelaySeconds, 1, opts...)
+	require.Nil(t, err)
+
+	failing := &atomic.Bool{}
+	failing.Store(true)
+	persister.SetWriteBatchHandler(func(db *goleveldb.DB, b *goleveldb.Batch) error {
+		if failing.Load() {
+			return errDiskFailure
+		}
+
+		return leveldb.WriteBatchSynced(db, b)
+	})
+
+	return persister, failing
+}
+
+func requireStoredValues(t *testing.T, constructor persisterConstructor, path string, expected map[string]string) {
+	persister, err := constructor(path, 10, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = persister.Close()
+	}()
+
+	for key, val := range expected {
+		stored, errGet := persister.Get([]byte(key))
+		require.Nil(t, errGet, key)
+		assert.Equal(t, val, string(stored))
+	}
+}
+
+func TestRetryQueue_FailedBatchesShouldBeRetriedInOrder(t *testing.T) {
+	for name, constructor := range persisterConstructors {
+		t.Run(name, func(t *testing.T) {
+			path := t.TempDir()
+			persister, failing := createPersisterWithFailingWrites(t, constructor, path, 100)
+
+			err := persister.Put([]byte("a"), []byte("1"))
+			assert.True(t, errors.Is(err, errDiskFailure))
+			err = persister.Put([]byte("a"), []byte("2"))
+			assert.True(t, errors.Is(err, errDiskFailure))
+			err = persister.Remove([]byte("b"))
+			assert.True(t, errors.Is(err, errDiskFailure))
+
+			val, err := persister.Get([]byte("a"))
+			require.Nil(t, err)
+			assert.Equal(t, []byte("2"), val)
+			assert.True(t, common.IsNotFound(persister.Has([]byte("b"))))
+
+			stats := persister.Stats()
+			assert.Equal(t, 3, stats["numBatchesToRetry"])
+			assert.Equal(t, 3, stats["numWritesToRetry"])
+			assert.Equal(t, types.HealthDegraded, persister.Health().State)
+
+			failing.Store(false)
+			require.Nil(t, persister.Flush())
+			stats = persister.Stats()
+			assert.Equal(t, 0, stats["numBatchesToRetry"])
+			assert.Equal(t, types.HealthOK, persister.Health().State)
+			require.Nil(t, persister.Close())
+
+			requireStoredValues(t, constructor, path, map[string]string{"a": "2"})
+		})
+	}
+}
+
+func TestRetryQueue_FullQueueShouldKeepTheWritesInTheBatch(t *testing.T) {
+	for name, constructor := range persisterConstructors {
+		t.Run(name, func(t *testing.T) {
+			path := t.TempDir()
+			persister, failing := createPersisterWithFailingWrites(t, constructor, path, 100, leveldb.WithRetryQueue(1, time.Hour, time.Hour))
+
+			err := persister.Put([]byte("a"), []byte("1"))
+			assert.True(t, errors.Is(err, errDiskFailure))
+			err = persister.Put([]byte("b"), []byte("2"))
+			assert.True(t, errors.Is(err, common.ErrRetryQueueFull))
+			assert.Equal(t, 1, persister.Stats()["numBatchesToRetry"])
+
+			val, err := persister.Get([]byte("b"))
+			require.Nil(t, err)
+			assert.Equal(t, []byte("2"), val)
+
+			failing.Store(false)
+			require.Nil(t, persister.Close())
+
+			requireStoredValues(t, constructor, path, map[string]string{"a": "1", "b": "2"})
+		})
+	}
+}
+
+func TestRetryQueue_ShouldRetryAfterTheBackoff(t *testing.T) {
+	for name, constructor := range persisterConstructors {
+		t.Run(name, func(t *testing.T) {
+			path := t.TempDir()
+			persister, failing := createPersisterWithFailingWrites(t, constructor, path, 1, leveldb.WithRetryQueue(10, time.Millisecond, time.Millisecond))
+
+			_ = persister.Put([]byte("a"), []byte("1"))
+			assert.Equal(t, 1, persister.Stats()["numBatchesToRetry"])
+			assert.Equal(t, uint64(1), persister.Stats()["numFailedBatchWrites"])
+
+			failing.Store(false)
+			require.Eventually(t, func() bool {
+				return persister.Stats()["numBatchesToRetry"] == 0
+			}, 5*time.Second, 50*time.Millisecond)
+			require.Nil(t, persister.Close())
+
+			requireStoredValues(t, constructor, path, map[string]string{"a": "1"})
+		})
+	}
+}
@@ -2,7 +2,6 @@ package leveldb
 
 import (
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 type putBatchAct struct {
@@ -34,16 +33,7 @@ func (p *putBatchAct) request(s *SerialDB) {
 }
 
 func (p *putBatchAct) doPutRequest(s *SerialDB) error {
-	db := s.getDbPointer()
-	if db == nil {
-		return common.ErrDBIsClosed
-	}
-
-	wopt := &opt.WriteOptions{
-		Sync: true,
-	}
-
-	return db.Write(p.batch.batch, wopt)
+	return s.writeBatch(p.batch)
 }
 
 func (g *getAct) request(s *SerialDB) {
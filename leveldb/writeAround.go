@@ -0,0 +1,153 @@
+package leveldb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxNumAsyncWritesAround bounds the asynchronous writes in progress, the large values written past it being written
+// synchronously, so that the values waiting to be written do not inflate the heap in turn
+const maxNumAsyncWritesAround = 16
+
+// writeAround writes the values at least threshold bytes large straight to the database, instead of parking them in
+// the batch until flushed, as they would inflate the heap and make the flushes spiky. The values are written
+// synchronously by Put, or asynchronously if so configured, in which case they are served by the reads until written.
+// A value is batched as usual if an older write of its key is still batched, and the batches wait for the values
+// written around before them, so that the writes of a key reach the database in order. The failed asynchronous writes
+// are handed to the retry queue
+type writeAround struct {
+	threshold  int
+	async      bool
+	path       string
+	write      func(b *batch) error
+	retryQueue *batchRetryQueue
+	asyncSlots chan struct{}
+
+	mutPending sync.Mutex
+	pending    map[string][]byte
+	wgPending  sync.WaitGroup
+
+	numWritten      atomic.Uint64
+	numFailedWrites atomic.Uint64
+}
+
+func newWriteAround(o *options, path string, write func(b *batch) error, retryQueue *batchRetryQueue) *writeAround {
+	return &writeAround{
+		threshold:  o.writeAroundThreshold,
+		async:      o.writeAroundAsync,
+		path:       path,
+		write:      write,
+		retryQueue: retryQueue,
+		asyncSlots: make(chan struct{}, maxNumAsyncWritesAround),
+		pending:    make(map[string][]byte),
+	}
+}
+
+func (wa *writeAround) isLarge(val []byte) bool {
+	return wa.threshold > 0 && len(val) >= wa.threshold
+}
+
+// reserve registers the write of the key, returning false if a previous write around of the key is still in progress.
+// The caller should hold the lock of the batches, having checked that the key is not batched, and should call put
+// afterwards on success
+func (wa *writeAround) reserve(key []byte, val []byte) bool {
+	wa.mutPending.Lock()
+	defer wa.mutPending.Unlock()
+
+	_, isPending := wa.pending[string(key)]
+	if isPending {
+		return false
+	}
+
+	wa.pending[string(key)] = val
+	wa.wgPending.Add(1)
+
+	return true
+}
+
+// put writes the reserved value, in the background if asynchronous writes are enabled and a slot is available
+func (wa *writeAround) put(key []byte, val []byte) error {
+	if wa.async {
+		select {
+		case wa.asyncSlots <- struct{}{}:
+			go wa.putAsync(key, val)
+			return nil
+		default:
+		}
+	}
+
+	defer wa.release(key)
+
+	err := wa.writeValue(key, val)
+	if err != nil {
+		wa.numFailedWrites.Add(1)
+		return err
+	}
+
+	return nil
+}
+
+func (wa *writeAround) putAsync(key []byte, val []byte) {
+	defer func() {
+		<-wa.asyncSlots
+	}()
+	defer wa.release(key)
+
+	err := wa.writeValue(key, val)
+	if err == nil {
+		return
+	}
+
+	b := NewBatch()
+	_ = b.Put(key, val)
+	// the value is released after being queued, so that it is served by the reads meanwhile
+	if wa.retryQueue.add(b, err) {
+		log.Debug("writeAround: the failed asynchronous write was queued for retry", "path", wa.path, "error", err)
+	}
+	wa.numFailedWrites.Add(1)
+}
+
+func (wa *writeAround) writeValue(key []byte, val []byte) error {
+	b := NewBatch()
+	_ = b.Put(key, val)
+	err := wa.write(b)
+	if err == nil {
+		wa.numWritten.Add(1)
+	}
+
+	return err
+}
+
+func (wa *writeAround) release(key []byte) {
+	wa.mutPending.Lock()
+	delete(wa.pending, string(key))
+	wa.mutPending.Unlock()
+
+	wa.wgPending.Done()
+}
+
+// get returns the value of the key being written around, if any
+func (wa *writeAround) get(key []byte) []byte {
+	wa.mutPending.Lock()
+	defer wa.mutPending.Unlock()
+
+	return wa.pending[string(key)]
+}
+
+// wait waits for the writes around in progress. The caller should prevent new reservations meanwhile
+func (wa *writeAround) wait() {
+	wa.wgPending.Wait()
+}
+
+func (wa *writeAround) addStats(stats map[string]interface{}) map[string]interface{} {
+	wa.mutPending.Lock()
+	numPending := len(wa.pending)
+	wa.mutPending.Unlock()
+
+	stats["writeAroundThresholdInBytes"] = wa.threshold
+	stats["numWritesAround"] = wa.numWritten.Load()
+	stats["numFailedWritesAround"] = wa.numFailedWrites.Load()
+	stats["numPendingWritesAround"] = numPending
+
+	return stats
+}
@@ -0,0 +1,129 @@
+package leveldb_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+)
+
+func countPairs(persister persisterWithFailingWrites) int {
+	numPairs := 0
+	persister.RangeKeys(func(_ []byte, _ []byte) bool {
+		numPairs++
+		return true
+	})
+
+	return numPairs
+}
+
+func TestWriteAround(t *testing.T) {
+	t.Parallel()
+
+	largeValue := bytes.Repeat([]byte("a"), 100)
+	for name, constructor := range persisterConstructors {
+		constructor := constructor
+		t.Run(name+" should write the large values straight to the database", func(t *testing.T) {
+			t.Parallel()
+
+			persister, err := constructor(t.TempDir(), 100, 100, leveldb.WithWriteAround(100, false))
+			require.Nil(t, err)
+			defer func() {
+				_ = persister.Close()
+			}()
+
+			require.Nil(t, persister.Put([]byte("small"), largeValue[:99]))
+			require.Nil(t, persister.Put([]byte("large"), largeValue))
+
+			// only the large value reached the database, the small one waiting in the batch
+			assert.Equal(t, 1, countPairs(persister))
+			stats := persister.Stats()
+			assert.Equal(t, 1, stats["numPendingWrites"])
+			assert.Equal(t, uint64(1), stats["numWritesAround"])
+			val, err := persister.Get([]byte("large"))
+			assert.Nil(t, err)
+			assert.Equal(t, largeValue, val)
+		})
+		t.Run(name+" should batch the large values of the batched keys", func(t *testing.T) {
+			t.Parallel()
+
+			persister, err := constructor(t.TempDir(), 100, 100, leveldb.WithWriteAround(100, false))
+			require.Nil(t, err)
+			defer func() {
+				_ = persister.Close()
+			}()
+
+			require.Nil(t, persister.Put([]byte("key"), []byte("small")))
+			require.Nil(t, persister.Put([]byte("key"), largeValue))
+			assert.Equal(t, uint64(0), persister.Stats()["numWritesAround"])
+
+			// otherwise, the older batched value would overwrite the newer one once flushed
+			require.Nil(t, persister.Flush())
+			val, err := persister.Get([]byte("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, largeValue, val)
+		})
+		t.Run(name+" should serve the values written asynchronously meanwhile", func(t *testing.T) {
+			t.Parallel()
+
+			persister, err := constructor(t.TempDir(), 100, 100, leveldb.WithWriteAround(100, true))
+			require.Nil(t, err)
+			defer func() {
+				_ = persister.Close()
+			}()
+
+			release := make(chan struct{})
+			persister.SetWriteBatchHandler(func(db *goleveldb.DB, b *goleveldb.Batch) error {
+				<-release
+				return leveldb.WriteBatchSynced(db, b)
+			})
+
+			require.Nil(t, persister.Put([]byte("large"), largeValue))
+			assert.Equal(t, 1, persister.Stats()["numPendingWritesAround"])
+			val, err := persister.Get([]byte("large"))
+			assert.Nil(t, err)
+			assert.Equal(t, largeValue, val)
+			assert.Nil(t, persister.Has([]byte("large")))
+
+			// the flushes wait for the values written around
+			flushed := make(chan error)
+			go func() {
+				flushed <- persister.Flush()
+			}()
+			select {
+			case <-flushed:
+				assert.Fail(t, "the flush should have waited for the asynchronous write")
+			case <-time.After(100 * time.Millisecond):
+			}
+			close(release)
+			assert.Nil(t, <-flushed)
+			assert.Equal(t, 1, countPairs(persister))
+			assert.Equal(t, 0, persister.Stats()["numPendingWritesAround"])
+		})
+		t.Run(name+" should queue the failed asynchronous writes for retry", func(t *testing.T) {
+			t.Parallel()
+
+			persister, failing := createPersisterWithFailingWrites(t, constructor, t.TempDir(), 100, leveldb.WithWriteAround(100, true))
+			defer func() {
+				_ = persister.Close()
+			}()
+
+			require.Nil(t, persister.Put([]byte("large"), largeValue))
+			require.Eventually(t, func() bool {
+				return persister.Stats()["numFailedWritesAround"] == uint64(1)
+			}, time.Second, 10*time.Millisecond)
+			assert.Equal(t, 1, persister.Stats()["numBatchesToRetry"])
+			val, err := persister.Get([]byte("large"))
+			assert.Nil(t, err)
+			assert.Equal(t, largeValue, val)
+
+			failing.Store(false)
+			require.Nil(t, persister.Flush())
+			assert.Equal(t, 1, countPairs(persister))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package logsampling
+
+import (
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// NewSampledLogger -
+func NewSampledLogger(inner logger.Logger, clock types.Clock, config Config) logger.Logger {
+	sl := newSampledLogger(inner, clock)
+	sl.setConfig(config)
+
+	return sl
+}
+
+// NumDroppedBy -
+func NumDroppedBy(log logger.Logger) uint64 {
+	return log.(*sampledLogger).numDropped.Load()
+}
@@ -0,0 +1,199 @@
+package logsampling
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ logger.Logger = (*sampledLogger)(nil)
+
+// Config holds the sampling settings of a logger. Only the trace and debug lines are sampled, the others being
+// always logged
+type Config struct {
+	// OneInN keeps one in N of the lines, the first one included. 0 and 1 keep all the lines
+	OneInN uint64
+	// MaxLinesPerSecond caps the lines kept in each second, after the OneInN sampling. 0 leaves them uncapped
+	MaxLinesPerSecond uint64
+}
+
+func (config Config) isEnabled() bool {
+	return config.OneInN > 1 || config.MaxLinesPerSecond > 0
+}
+
+var (
+	mutLoggers sync.Mutex
+	loggers    = make(map[string]*sampledLogger)
+)
+
+// GetOrCreate returns the logger with the provided name, whose trace and debug lines are sampled as set up by Setup.
+// Until then, all the lines are logged
+func GetOrCreate(name string) logger.Logger {
+	mutLoggers.Lock()
+	defer mutLoggers.Unlock()
+
+	return getOrCreateNoLock(name)
+}
+
+func getOrCreateNoLock(name string) *sampledLogger {
+	sl, ok := loggers[name]
+	if !ok {
+		sl = newSampledLogger(logger.GetOrCreate(name), &systemClock{})
+		loggers[name] = sl
+	}
+
+	return sl
+}
+
+// Setup sets the sampling of the logger with the provided name, created by GetOrCreate before or after the call, so
+// that the operators can keep the per operation lines at debug level without the logs growing without bounds
+func Setup(name string, config Config) error {
+	if len(name) == 0 {
+		return fmt.Errorf("%w: the logger name should not be empty", common.ErrInvalidConfig)
+	}
+
+	mutLoggers.Lock()
+	defer mutLoggers.Unlock()
+
+	getOrCreateNoLock(name).setConfig(config)
+
+	return nil
+}
+
+// Disable stops the sampling of the logger with the provided name, which logs all its lines afterwards
+func Disable(name string) {
+	mutLoggers.Lock()
+	defer mutLoggers.Unlock()
+
+	sl, ok := loggers[name]
+	if ok {
+		sl.setConfig(Config{})
+	}
+}
+
+// NumDropped returns the number of the lines dropped by the sampling of the logger with the provided name
+func NumDropped(name string) uint64 {
+	mutLoggers.Lock()
+	defer mutLoggers.Unlock()
+
+	sl, ok := loggers[name]
+	if !ok {
+		return 0
+	}
+
+	return sl.numDropped.Load()
+}
+
+// sampler decides which lines are kept. It is replaced, not modified, when the config changes, so that the loggers
+// read it without locking. The limits are approximate under concurrent logging
+type sampler struct {
+	config         Config
+	numLines       atomic.Uint64
+	currentSecond  atomic.Int64
+	numInTheSecond atomic.Uint64
+}
+
+func (s *sampler) keep(now time.Time) bool {
+	if s.config.OneInN > 1 && (s.numLines.Add(1)-1)%s.config.OneInN != 0 {
+		return false
+	}
+	if s.config.MaxLinesPerSecond == 0 {
+		return true
+	}
+
+	second := now.Unix()
+	if s.currentSecond.Load() != second && s.currentSecond.Swap(second) != second {
+		s.numInTheSecond.Store(0)
+	}
+
+	return s.numInTheSecond.Add(1) <= s.config.MaxLinesPerSecond
+}
+
+// sampledLogger wraps a logger, sampling its trace and debug lines
+type sampledLogger struct {
+	logger.Logger
+	clock      types.Clock
+	sampler    atomic.Pointer[sampler]
+	numDropped atomic.Uint64
+}
+
+func newSampledLogger(inner logger.Logger, clock types.Clock) *sampledLogger {
+	return &sampledLogger{
+		Logger: inner,
+		clock:  clock,
+	}
+}
+
+func (sl *sampledLogger) setConfig(config Config) {
+	if !config.isEnabled() {
+		sl.sampler.Store(nil)
+		return
+	}
+
+	sl.sampler.Store(&sampler{
+		config: config,
+	})
+}
+
+// shouldLog returns false if the line is below the level of the logger, or dropped by the sampling. The lines below
+// the level are not counted by the sampling
+func (sl *sampledLogger) shouldLog(logLevel logger.LogLevel) bool {
+	if logLevel < sl.Logger.GetLevel() {
+		return false
+	}
+	if logLevel > logger.LogDebug {
+		return true
+	}
+
+	s := sl.sampler.Load()
+	if s == nil || s.keep(sl.clock.Now()) {
+		return true
+	}
+
+	sl.numDropped.Add(1)
+	return false
+}
+
+// Trace logs the line, unless dropped by the sampling
+func (sl *sampledLogger) Trace(message string, args ...interface{}) {
+	if sl.shouldLog(logger.LogTrace) {
+		sl.Logger.Trace(message, args...)
+	}
+}
+
+// Debug logs the line, unless dropped by the sampling
+func (sl *sampledLogger) Debug(message string, args ...interface{}) {
+	if sl.shouldLog(logger.LogDebug) {
+		sl.Logger.Debug(message, args...)
+	}
+}
+
+// Log logs the line, unless dropped by the sampling
+func (sl *sampledLogger) Log(logLevel logger.LogLevel, message string, args ...interface{}) {
+	if sl.shouldLog(logLevel) {
+		sl.Logger.Log(logLevel, message, args...)
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sl *sampledLogger) IsInterfaceNil() bool {
+	return sl == nil
+}
+
+// systemClock is the clock of the loggers, relying on the system time
+type systemClock struct{}
+
+// Now returns the system time
+func (sc *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sc *systemClock) IsInterfaceNil() bool {
+	return sc == nil
+}
@@ -0,0 +1,105 @@
+package logsampling_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/logsampling"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCountingLogger(counts map[logger.LogLevel]int) *testscommon.LoggerStub {
+	return &testscommon.LoggerStub{
+		LogCalled: func(logLevel logger.LogLevel, message string, args ...interface{}) {
+			counts[logLevel]++
+		},
+	}
+}
+
+func TestSampledLogger_OneInN(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[logger.LogLevel]int)
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	log := logsampling.NewSampledLogger(createCountingLogger(counts), clock, logsampling.Config{OneInN: 10})
+
+	for i := 0; i < 100; i++ {
+		log.Trace("trace")
+		log.Debug("debug")
+		log.Log(logger.LogDebug, "debug")
+		log.Info("info")
+		log.Warn("warn")
+	}
+
+	// the trace and debug lines share the same counter
+	assert.Equal(t, 10, counts[logger.LogTrace])
+	assert.Equal(t, 20, counts[logger.LogDebug])
+	assert.Equal(t, 100, counts[logger.LogInfo])
+	assert.Equal(t, 100, counts[logger.LogWarning])
+	assert.Equal(t, uint64(270), logsampling.NumDroppedBy(log))
+}
+
+func TestSampledLogger_MaxLinesPerSecond(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[logger.LogLevel]int)
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	log := logsampling.NewSampledLogger(createCountingLogger(counts), clock, logsampling.Config{MaxLinesPerSecond: 5})
+
+	for i := 0; i < 100; i++ {
+		log.Debug("debug")
+	}
+	assert.Equal(t, 5, counts[logger.LogDebug])
+
+	clock.Advance(time.Second)
+	for i := 0; i < 100; i++ {
+		log.Debug("debug")
+	}
+	assert.Equal(t, 10, counts[logger.LogDebug])
+	assert.Equal(t, uint64(190), logsampling.NumDroppedBy(log))
+}
+
+func TestSampledLogger_NotSampled(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[logger.LogLevel]int)
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	log := logsampling.NewSampledLogger(createCountingLogger(counts), clock, logsampling.Config{OneInN: 1})
+
+	for i := 0; i < 100; i++ {
+		log.Trace("trace")
+	}
+	assert.Equal(t, 100, counts[logger.LogTrace])
+	assert.Equal(t, uint64(0), logsampling.NumDroppedBy(log))
+}
+
+func TestSetup(t *testing.T) {
+	t.Parallel()
+
+	err := logsampling.Setup("", logsampling.Config{OneInN: 2})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	name := "logsampling/test/setup"
+	log := logsampling.GetOrCreate(name)
+	assert.True(t, log == logsampling.GetOrCreate(name))
+	log.SetLevel(logger.LogTrace)
+	defer log.SetLevel(logger.LogInfo)
+
+	err = logsampling.Setup(name, logsampling.Config{OneInN: 4})
+	assert.Nil(t, err)
+	for i := 0; i < 8; i++ {
+		log.Trace("trace")
+	}
+	assert.Equal(t, uint64(6), logsampling.NumDropped(name))
+
+	logsampling.Disable(name)
+	for i := 0; i < 8; i++ {
+		log.Trace("trace")
+	}
+	assert.Equal(t, uint64(6), logsampling.NumDropped(name))
+	assert.Equal(t, uint64(0), logsampling.NumDropped("logsampling/test/missing"))
+}
@@ -7,6 +7,7 @@ import (
 
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var log = logger.GetOrCreate("storage/lrucache/capacity")
@@ -20,6 +21,8 @@ type capacityLRU struct {
 	//TODO investigate if we can replace this list with a binary tree. Check also the other implementation lruCache
 	evictList *list.List
 	items     map[interface{}]*list.Element
+	// onEvicted, if set, is called (under the cache's lock) for each item evicted to make room for new ones
+	onEvicted func(key interface{}, value interface{}, reason types.EvictionReason)
 }
 
 // entry is used to hold a value in the evictList
@@ -46,6 +49,23 @@ func NewCapacityLRU(size int, byteCapacity int64) (*capacityLRU, error) {
 	return c, nil
 }
 
+// NewCapacityLRUWithEviction constructs an CapacityLRU of the given size with a byte size capacity and eviction function.
+// The eviction function is called under the cache's lock, hence it must not call back into the cache
+func NewCapacityLRUWithEviction(
+	size int,
+	byteCapacity int64,
+	onEvicted func(key interface{}, value interface{}, reason types.EvictionReason),
+) (*capacityLRU, error) {
+	c, err := NewCapacityLRU(size, byteCapacity)
+	if err != nil {
+		return nil, err
+	}
+
+	c.onEvicted = onEvicted
+
+	return c, nil
+}
+
 // Purge is used to completely clear the cache.
 func (c *capacityLRU) Purge() {
 	c.lock.Lock()
@@ -94,6 +114,7 @@ func (c *capacityLRU) AddSizedAndReturnEvicted(key, value interface{}, sizeInByt
 
 	evictedValues := make(map[interface{}]interface{})
 	for c.shouldEvict() {
+		reason := c.evictionReason()
 		evicted := c.evictList.Back()
 		if evicted == nil {
 			continue
@@ -106,6 +127,7 @@ func (c *capacityLRU) AddSizedAndReturnEvicted(key, value interface{}, sizeInByt
 		}
 
 		evictedValues[evictedEntry.key] = evictedEntry.value
+		c.notifyEvicted(evictedEntry, reason)
 	}
 
 	return evictedValues
@@ -202,6 +224,21 @@ func (c *capacityLRU) Peek(key interface{}) (interface{}, bool) {
 	return nil, ok
 }
 
+// PeekSized returns the key value and its size in bytes, without updating the "recently used"-ness of the key.
+func (c *capacityLRU) PeekSized(key interface{}) (interface{}, int64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ent, ok := c.items[key]
+	if !ok {
+		return nil, 0, false
+	}
+
+	e := ent.Value.(*entry)
+
+	return e.value, e.size, true
+}
+
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
 func (c *capacityLRU) Remove(key interface{}) bool {
@@ -246,10 +283,17 @@ func (c *capacityLRU) SizeInBytesContained() uint64 {
 }
 
 // removeOldest removes the oldest item from the cache.
-func (c *capacityLRU) removeOldest() {
+func (c *capacityLRU) removeOldest(reason types.EvictionReason) {
 	ent := c.evictList.Back()
 	if ent != nil {
 		c.removeElement(ent)
+		c.notifyEvicted(ent.Value.(*entry), reason)
+	}
+}
+
+func (c *capacityLRU) notifyEvicted(e *entry, reason types.EvictionReason) {
+	if c.onEvicted != nil {
+		c.onEvicted(e.key, e.value, reason)
 	}
 }
 
@@ -284,10 +328,19 @@ func (c *capacityLRU) shouldEvict() bool {
 	return c.evictList.Len() > c.size || c.currentCapacityInBytes > c.maxCapacityInBytes
 }
 
+// evictionReason must be called only when shouldEvict returns true
+func (c *capacityLRU) evictionReason() types.EvictionReason {
+	if c.evictList.Len() > c.size {
+		return types.EvictionReasonCapacity
+	}
+
+	return types.EvictionReasonSize
+}
+
 func (c *capacityLRU) evictIfNeeded() bool {
 	evicted := false
 	for c.shouldEvict() {
-		c.removeOldest()
+		c.removeOldest(c.evictionReason())
 		evicted = true
 	}
 
@@ -312,6 +312,25 @@ func TestCapacityLRUCache_PeekShouldWork(t *testing.T) {
 	assert.True(t, c.evictList.Front().Value.(*entry).key == key2)
 }
 
+func TestCapacityLRUCache_PeekSizedShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, _ := NewCapacityLRU(100000, 1000)
+	c.AddSized("key1", "val1", 7)
+	c.AddSized("key2", "val2", 3)
+
+	val, size, found := c.PeekSized("key1")
+	assert.True(t, found)
+	assert.Equal(t, "val1", val)
+	assert.Equal(t, int64(7), size)
+	assert.True(t, c.evictList.Front().Value.(*entry).key == "key2")
+
+	val, size, found = c.PeekSized("missing")
+	assert.False(t, found)
+	assert.Nil(t, val)
+	assert.Zero(t, size)
+}
+
 //------- Remove
 
 func TestCapacityLRUCache_RemoveNotFoundShouldWork(t *testing.T) {
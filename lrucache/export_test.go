@@ -1,5 +1,9 @@
 package lrucache
 
-func (c *lruCache) AddedDataHandlers() map[string]func(key []byte, value interface{}) {
-	return c.mapDataHandlers
+func (c *lruCache) AddedDataHandlers() []string {
+	return c.addedDataHandlers.HandlerIDs()
+}
+
+func (c *lruCache) EvictionHandlers() []string {
+	return c.evictionHandlers.HandlerIDs()
 }
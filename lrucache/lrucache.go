@@ -4,12 +4,17 @@ import (
 	"sync"
 
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
 	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	lru "github.com/hashicorp/golang-lru"
 )
 
 var _ types.Cacher = (*lruCache)(nil)
+var _ types.EvictionNotifier = (*lruCache)(nil)
+var _ types.StatsProvider = (*lruCache)(nil)
+var _ types.Pinner = (*lruCache)(nil)
 
 var log = logger.GetOrCreate("storage/lrucache")
 
@@ -17,74 +22,130 @@ var log = logger.GetOrCreate("storage/lrucache")
 type lruCache struct {
 	cache   types.SizedLRUCacheHandler
 	maxsize int
-
-	mutAddedDataHandlers sync.RWMutex
-	mapDataHandlers      map[string]func(key []byte, value interface{})
+	// maxSizeInBytes is zero for the simple LRU caches, which do not account the sizes in bytes
+	maxSizeInBytes int64
+
+	// mutWrite serializes the operations changing the contents, so that the pinned entries and the LRU ordering are
+	// kept in sync. mutPinned only guards the pinned entries and is never held while calling the underlying cache
+	mutWrite       sync.Mutex
+	mutPinned      sync.RWMutex
+	pinned         map[string]*pinnedEntry
+	pinnedBytes    int64
+	maxPinnedItems int
+	maxPinnedBytes int64
+	// isRemovingExplicitly is guarded by mutWrite. It tells apart the explicit removals, which the underlying
+	// simple LRU cache reports through its eviction function as well
+	isRemovingExplicitly bool
+
+	addedDataHandlers *dispatch.Dispatcher
+	evictionHandlers  *dispatch.Dispatcher
 }
 
 // NewCache creates a new LRU cache instance
 func NewCache(size int) (*lruCache, error) {
-	cache, err := lru.New(size)
-	if err != nil {
-		return nil, err
-	}
-
-	c := createLRUCache(size, cache)
-
-	return c, nil
+	return NewCacheWithEviction(size, nil)
 }
 
-// NewCacheWithEviction creates a new sized LRU cache instance with eviction function
+// NewCacheWithEviction creates a new sized LRU cache instance with eviction function.
+// The eviction function is called synchronously, hence it must not change the contents of the cache.
+// Unlike the registered eviction handlers, it is also called for the explicitly removed items
 func NewCacheWithEviction(size int, onEvicted func(key interface{}, value interface{})) (*lruCache, error) {
-	cache, err := lru.NewWithEvict(size, onEvicted)
+	// the simple LRU caches do not account the sizes in bytes, so only the number of pinned entries is bounded
+	c := newLRUCache(size, 0)
+
+	cache, err := lru.NewWithEvict(size, func(key interface{}, value interface{}) {
+		if onEvicted != nil {
+			onEvicted(key, value)
+		}
+		c.onSimpleCacheEvicted(key, value)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	c := createLRUCache(size, cache)
+	c.cache = &simpleLRUCacheAdapter{
+		LRUCacheHandler: cache,
+	}
 
 	return c, nil
 }
 
-func createLRUCache(size int, cache *lru.Cache) *lruCache {
-	c := &lruCache{
-		cache: &simpleLRUCacheAdapter{
-			LRUCacheHandler: cache,
-		},
-		maxsize:              size,
-		mutAddedDataHandlers: sync.RWMutex{},
-		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
+func newLRUCache(size int, sizeInBytes int64) *lruCache {
+	return &lruCache{
+		maxsize:           size,
+		pinned:            make(map[string]*pinnedEntry),
+		maxPinnedItems:    int(maxPinned(int64(size))),
+		maxPinnedBytes:    maxPinned(sizeInBytes),
+		addedDataHandlers: dispatch.NewDefaultDispatcher("lrucache"),
+		evictionHandlers:  dispatch.NewEvictionDispatcher("lrucache eviction"),
 	}
-	return c
 }
 
 // NewCacheWithSizeInBytes creates a new sized LRU cache instance
 func NewCacheWithSizeInBytes(size int, sizeInBytes int64) (*lruCache, error) {
-	cache, err := capacity.NewCapacityLRU(size, sizeInBytes)
+	c := newLRUCache(size, sizeInBytes)
+	c.maxSizeInBytes = sizeInBytes
+
+	cache, err := capacity.NewCapacityLRUWithEviction(size, sizeInBytes, c.callEvictionHandlers)
 	if err != nil {
 		return nil, err
 	}
 
-	c := &lruCache{
-		cache:                cache,
-		maxsize:              size,
-		mutAddedDataHandlers: sync.RWMutex{},
-		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
-	}
+	c.cache = cache
 
 	return c, nil
 }
 
-// Clear is used to completely clear the cache.
+// Clear is used to completely clear the cache, pinned entries included.
 func (c *lruCache) Clear() {
-	c.cache.Purge()
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	c.removeExplicitly(c.cache.Purge)
+
+	c.mutPinned.Lock()
+	c.pinned = make(map[string]*pinnedEntry)
+	c.pinnedBytes = 0
+	c.mutPinned.Unlock()
+}
+
+// Shrink evicts the given percentage of the unpinned entries, the least recently used first, notifying the eviction
+// handlers. It returns the number of evicted entries
+func (c *lruCache) Shrink(percentage uint32) int {
+	if percentage > 100 {
+		percentage = 100
+	}
+
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	keys := c.cache.Keys()
+	numToEvict := len(keys) * int(percentage) / 100
+	evictedItems := make([]dispatch.EvictedItem, 0, numToEvict)
+	for _, key := range keys[:numToEvict] {
+		value, _ := c.cache.Peek(key)
+		c.removeExplicitly(func() {
+			c.cache.Remove(key)
+		})
+
+		keyAsString, ok := key.(string)
+		if ok {
+			evictedItems = append(evictedItems, dispatch.EvictedItem{Key: []byte(keyAsString), Value: value})
+		}
+	}
+	c.evictionHandlers.DispatchEvictions(evictedItems, types.EvictionReasonMemoryPressure)
+
+	return numToEvict
 }
 
 // Put adds a value to the cache.  Returns true if an eviction occurred.
+// A pinned entry stays pinned, unless its new size does not fit in the capacity reserved for the pinned entries.
 func (c *lruCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
-	evicted = c.cache.AddSized(string(key), value, int64(sizeInBytes))
+	c.mutWrite.Lock()
+	evicted = c.putNoLock(string(key), value, int64(sizeInBytes))
+	c.mutWrite.Unlock()
 
-	c.callAddedDataHandlers(key, value)
+	c.addedDataHandlers.Dispatch(key, value)
 
 	return evicted
 }
@@ -96,32 +157,80 @@ func (c *lruCache) RegisterHandler(handler func(key []byte, value interface{}),
 		return
 	}
 
-	c.mutAddedDataHandlers.Lock()
-	c.mapDataHandlers[id] = handler
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataHandlers.Register(id, handler)
 }
 
 // UnRegisterHandler removes the handler from the list
 func (c *lruCache) UnRegisterHandler(id string) {
-	c.mutAddedDataHandlers.Lock()
-	delete(c.mapDataHandlers, id)
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataHandlers.Unregister(id)
+}
+
+// RegisterEvictionHandler registers a new handler to be called whenever an item is evicted to make room for new ones
+func (c *lruCache) RegisterEvictionHandler(handler types.EvictedItemHandler, id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a cacher object")
+		return
+	}
+
+	c.evictionHandlers.RegisterEvictionHandler(id, handler)
+}
+
+// UnRegisterEvictionHandler removes the eviction handler from the list
+func (c *lruCache) UnRegisterEvictionHandler(id string) {
+	c.evictionHandlers.Unregister(id)
+}
+
+// removeExplicitly must be called under mutWrite
+func (c *lruCache) removeExplicitly(remove func()) {
+	c.isRemovingExplicitly = true
+	remove()
+	c.isRemovingExplicitly = false
+}
+
+// onSimpleCacheEvicted is called under mutWrite, since all the operations which can remove items are serialized
+func (c *lruCache) onSimpleCacheEvicted(key interface{}, value interface{}) {
+	if c.isRemovingExplicitly {
+		return
+	}
+
+	c.callEvictionHandlers(key, value, types.EvictionReasonCapacity)
+}
+
+func (c *lruCache) callEvictionHandlers(key interface{}, value interface{}, reason types.EvictionReason) {
+	keyAsString, ok := key.(string)
+	if !ok {
+		return
+	}
+
+	c.evictionHandlers.DispatchEvictions([]dispatch.EvictedItem{{Key: []byte(keyAsString), Value: value}}, reason)
 }
 
 // Get looks up a key's value from the cache.
 func (c *lruCache) Get(key []byte) (value interface{}, ok bool) {
+	entry, isPinned := c.getPinned(string(key))
+	if isPinned {
+		return entry.value, true
+	}
+
 	return c.cache.Get(string(key))
 }
 
 // Has checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *lruCache) Has(key []byte) bool {
-	return c.cache.Contains(string(key))
+	_, isPinned := c.getPinned(string(key))
+
+	return isPinned || c.cache.Contains(string(key))
 }
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *lruCache) Peek(key []byte) (value interface{}, ok bool) {
+	entry, isPinned := c.getPinned(string(key))
+	if isPinned {
+		return entry.value, true
+	}
+
 	v, ok := c.cache.Peek(string(key))
 
 	if !ok {
@@ -135,48 +244,63 @@ func (c *lruCache) Peek(key []byte) (value interface{}, ok bool) {
 // recent-ness or deleting it for being stale,  and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (c *lruCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
-	has, _ = c.cache.AddSizedIfMissing(string(key), value, int64(sizeInBytes))
+	c.mutWrite.Lock()
+	_, has = c.getPinned(string(key))
+	if !has {
+		has, _ = c.cache.AddSizedIfMissing(string(key), value, int64(sizeInBytes))
+	}
+	c.mutWrite.Unlock()
 
 	if !has {
-		c.callAddedDataHandlers(key, value)
+		c.addedDataHandlers.Dispatch(key, value)
 	}
 
 	return has, !has
 }
 
-func (c *lruCache) callAddedDataHandlers(key []byte, value interface{}) {
-	c.mutAddedDataHandlers.RLock()
-	for _, handler := range c.mapDataHandlers {
-		go handler(key, value)
-	}
-	c.mutAddedDataHandlers.RUnlock()
-}
-
-// Remove removes the provided key from the cache.
+// Remove removes the provided key from the cache, even if it is pinned.
 func (c *lruCache) Remove(key []byte) {
-	c.cache.Remove(string(key))
+	c.mutWrite.Lock()
+	c.removePinned(string(key))
+	c.removeExplicitly(func() {
+		c.cache.Remove(string(key))
+	})
+	c.mutWrite.Unlock()
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache, from oldest to newest. The pinned keys, which are never evicted,
+// are placed at the end, in no particular order.
 func (c *lruCache) Keys() [][]byte {
 	res := c.cache.Keys()
-	r := make([][]byte, len(res))
+
+	c.mutPinned.RLock()
+	defer c.mutPinned.RUnlock()
+
+	r := make([][]byte, len(res), len(res)+len(c.pinned))
 
 	for i := 0; i < len(res); i++ {
 		r[i] = []byte(res[i].(string))
 	}
+	for key := range c.pinned {
+		r = append(r, []byte(key))
+	}
 
 	return r
 }
 
 // Len returns the number of items in the cache.
 func (c *lruCache) Len() int {
-	return c.cache.Len()
+	return c.cache.Len() + c.NumPinned()
 }
 
 // SizeInBytesContained returns the size in bytes of all contained elements
 func (c *lruCache) SizeInBytesContained() uint64 {
-	return c.cache.SizeInBytesContained()
+	numBytes := c.cache.SizeInBytesContained()
+
+	c.mutPinned.RLock()
+	defer c.mutPinned.RUnlock()
+
+	return numBytes + uint64(c.pinnedBytes)
 }
 
 // MaxSize returns the maximum number of items which can be stored in cache.
@@ -189,6 +313,27 @@ func (c *lruCache) Close() error {
 	return nil
 }
 
+// Stats returns the key stats of the cache
+func (c *lruCache) Stats() map[string]interface{} {
+	cacheType := common.LRUCache
+	if c.maxSizeInBytes > 0 {
+		cacheType = common.SizeLRUCache
+	}
+
+	stats := types.CacheStats(string(cacheType), c)
+	c.mutPinned.RLock()
+	stats["numPinned"] = len(c.pinned)
+	stats["maxNumPinned"] = c.maxPinnedItems
+	if c.maxSizeInBytes > 0 {
+		stats["maxSizeInBytes"] = c.maxSizeInBytes
+		stats["pinnedBytes"] = c.pinnedBytes
+		stats["maxPinnedBytes"] = c.maxPinnedBytes
+	}
+	c.mutPinned.RUnlock()
+
+	return stats
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (c *lruCache) IsInterfaceNil() bool {
 	return c == nil
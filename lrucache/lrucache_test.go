@@ -2,7 +2,10 @@ package lrucache_test
 
 import (
 	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -10,12 +13,16 @@ import (
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var timeoutWaitForWaitGroups = time.Second * 2
 
+var updateGolden = flag.Bool("update", false, "rewrite the golden states of the caches")
+
 //------- NewCache
 
 func TestNewCache_BadSizeShouldErr(t *testing.T) {
@@ -459,3 +466,472 @@ func TestLruCache_LenDuringEviction(t *testing.T) {
 		assert.Fail(t, "test failed, deadlock occurred")
 	}
 }
+
+//------- eviction handlers
+
+func TestLRUCache_RegisterEvictionHandlerNilHandlerShouldIgnore(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	c.RegisterEvictionHandler(nil, "")
+	c.Put([]byte("key"), "value", 0)
+	c.UnRegisterEvictionHandler("")
+}
+
+func TestLRUCache_EvictionHandlerShouldBeCalledOnlyOnEviction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("simple cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(2)
+		testEvictionHandler(t, c, types.EvictionReasonCapacity, 0)
+	})
+	t.Run("simple cache with eviction function", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithEviction(2, func(_ interface{}, _ interface{}) {})
+		testEvictionHandler(t, c, types.EvictionReasonCapacity, 0)
+	})
+	t.Run("sized cache, evicted because of the number of items", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(2, 1000)
+		testEvictionHandler(t, c, types.EvictionReasonCapacity, 1)
+	})
+	t.Run("sized cache, evicted because of the size", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 25)
+		testEvictionHandler(t, c, types.EvictionReasonSize, 10)
+	})
+}
+
+func testEvictionHandler(t *testing.T, c types.Cacher, expectedReason types.EvictionReason, itemSize int) {
+	notifier, ok := c.(types.EvictionNotifier)
+	require.True(t, ok)
+
+	chEvicted := make(chan string, 10)
+	notifier.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		assert.Equal(t, expectedReason, reason)
+		chEvicted <- fmt.Sprintf("%s:%v", key, value)
+	}, "id")
+
+	c.Put([]byte("key0"), "value0", itemSize)
+	c.Put([]byte("key1"), "value1", itemSize)
+	c.Put([]byte("key2"), "value2", itemSize)
+
+	select {
+	case item := <-chEvicted:
+		assert.Equal(t, "key0:value0", item)
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "eviction handler should have been called")
+		return
+	}
+
+	// explicit removals are not notified
+	c.Remove([]byte("key1"))
+	c.Clear()
+	select {
+	case item := <-chEvicted:
+		assert.Fail(t, "eviction handler should not have been called", item)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	notifier.UnRegisterEvictionHandler("id")
+}
+
+func TestLRUCache_PanickingEvictionHandlerShouldNotAffectTheOthers(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(2)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		panic("eviction handler failure")
+	}, "panicking")
+	chEvicted := make(chan string, 10)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		chEvicted <- string(key)
+	}, "healthy")
+
+	for i := 0; i < 4; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+	// the evictions done at once are notified in one go
+	assert.Equal(t, 1, c.Shrink(50))
+
+	evictedKeys := make([]string, 0, 3)
+	for len(evictedKeys) < 3 {
+		select {
+		case key := <-chEvicted:
+			evictedKeys = append(evictedKeys, key)
+		case <-time.After(timeoutWaitForWaitGroups):
+			assert.Fail(t, "healthy eviction handler should have been called")
+			return
+		}
+	}
+	assert.ElementsMatch(t, []string{"key0", "key1", "key2"}, evictedKeys)
+}
+
+func TestLRUCache_SlowEvictionHandlerShouldNotLoseEvictions(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	chUnblock := make(chan struct{})
+	// more evictions than the calls queued for an added data handler
+	numPuts := 5000
+	chEvicted := make(chan string, numPuts)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		<-chUnblock
+		chEvicted <- fmt.Sprintf("%s:%s", key, reason)
+	}, "slow")
+
+	// the handler is stalled during the whole burst of evictions, which does not block the cache
+	for i := 0; i < numPuts; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+	assert.Equal(t, 10, c.Shrink(100))
+	close(chUnblock)
+
+	for i := 0; i < numPuts; i++ {
+		expectedReason := types.EvictionReasonCapacity
+		if i >= numPuts-10 {
+			expectedReason = types.EvictionReasonMemoryPressure
+		}
+
+		select {
+		case evicted := <-chEvicted:
+			assert.Equal(t, fmt.Sprintf("key%d:%s", i, expectedReason), evicted)
+		case <-time.After(timeoutWaitForWaitGroups):
+			assert.Fail(t, "eviction lost", "num evictions received", i)
+			return
+		}
+	}
+	assert.Equal(t, []string{"slow"}, c.EvictionHandlers())
+}
+
+func TestLRUCache_ShrinkShouldEvictTheOldestUnpinnedEntries(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+	chEvicted := make(chan string, 10)
+	c.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		assert.Equal(t, types.EvictionReasonMemoryPressure, reason)
+		chEvicted <- string(key)
+	}, "id")
+
+	for i := 0; i < 5; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 10)
+	}
+	require.Nil(t, c.Pin([]byte("key0")))
+
+	assert.Equal(t, 2, c.Shrink(50))
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, uint64(30), c.SizeInBytesContained())
+	assert.True(t, c.Has([]byte("key0")), "the pinned entries should not be evicted")
+	assert.False(t, c.Has([]byte("key1")))
+	assert.False(t, c.Has([]byte("key2")))
+
+	evicted := make([]string, 0, 2)
+	for len(evicted) < 2 {
+		select {
+		case key := <-chEvicted:
+			evicted = append(evicted, key)
+		case <-time.After(timeoutWaitForWaitGroups):
+			require.Fail(t, "eviction handler should have been called")
+		}
+	}
+	assert.ElementsMatch(t, []string{"key1", "key2"}, evicted)
+
+	assert.Equal(t, 0, c.Shrink(0))
+	assert.Equal(t, 2, c.Shrink(200))
+	assert.Equal(t, 1, c.Len())
+}
+
+//------- Pin
+
+func TestLRUCache_PinMissingKeyShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	err := c.Pin([]byte("missing"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.Zero(t, c.NumPinned())
+}
+
+func TestLRUCache_PinnedEntryShouldNotBeEvicted(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(8)
+	pinnedKey := []byte("pinned")
+	c.Put(pinnedKey, "value", 0)
+
+	err := c.Pin(pinnedKey)
+	assert.Nil(t, err)
+	err = c.Pin(pinnedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, c.NumPinned())
+
+	for i := 0; i < 20; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	value, ok := c.Get(pinnedKey)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+	assert.True(t, c.Has(pinnedKey))
+	assert.Equal(t, 9, c.Len())
+	assert.Len(t, c.Keys(), 9)
+
+	c.Unpin(pinnedKey)
+	assert.Zero(t, c.NumPinned())
+	for i := 20; i < 28; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+	assert.False(t, c.Has(pinnedKey))
+	assert.Equal(t, 8, c.Len())
+}
+
+func TestLRUCache_PinShouldBeBoundedByNumberOfEntries(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(8)
+	for i := 0; i < 3; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	assert.Nil(t, c.Pin([]byte("key0")))
+	assert.Nil(t, c.Pin([]byte("key1")))
+	err := c.Pin([]byte("key2"))
+	assert.True(t, errors.Is(err, common.ErrPinningCapacityReached))
+	assert.Equal(t, 2, c.NumPinned())
+}
+
+func TestLRUCache_PinShouldBeBoundedBySizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(100, 100)
+	c.Put([]byte("a"), "a", 20)
+	c.Put([]byte("b"), "b", 10)
+
+	assert.Nil(t, c.Pin([]byte("a")))
+	err := c.Pin([]byte("b"))
+	assert.True(t, errors.Is(err, common.ErrPinningCapacityReached))
+	assert.Equal(t, uint64(30), c.SizeInBytesContained())
+}
+
+func TestLRUCache_SetPinningLimits(t *testing.T) {
+	t.Parallel()
+
+	t.Run("limits above the capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 100)
+		err := c.SetPinningLimits(11, 200)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		assert.Contains(t, err.Error(), "maxNumPinned")
+		assert.Contains(t, err.Error(), "maxPinnedBytes")
+		assert.True(t, errors.Is(c.SetPinningLimits(-1, 0), common.ErrInvalidConfig))
+
+		simple, _ := lrucache.NewCache(10)
+		assert.Nil(t, simple.SetPinningLimits(10, 200), "the limit in bytes should be ignored")
+	})
+	t.Run("raised limits should allow pinning more", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 100)
+		c.Put([]byte("a"), "a", 40)
+		c.Put([]byte("b"), "b", 40)
+		assert.True(t, errors.Is(c.Pin([]byte("a")), common.ErrPinningCapacityReached))
+
+		require.Nil(t, c.SetPinningLimits(10, 100))
+		assert.Nil(t, c.Pin([]byte("a")))
+		assert.Nil(t, c.Pin([]byte("b")))
+
+		stats := c.Stats()
+		assert.Equal(t, 2, stats["numPinned"])
+		assert.Equal(t, int64(80), stats["pinnedBytes"])
+		assert.Equal(t, int64(100), stats["maxPinnedBytes"])
+		assert.Equal(t, 10, stats["maxNumPinned"])
+	})
+	t.Run("lowered limits should keep the pinned entries", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(8)
+		for i := 0; i < 3; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+		require.Nil(t, c.Pin([]byte("key0")))
+
+		require.Nil(t, c.SetPinningLimits(0, 0))
+		assert.Equal(t, 1, c.NumPinned())
+		assert.True(t, errors.Is(c.Pin([]byte("key1")), common.ErrPinningCapacityReached))
+
+		c.Unpin([]byte("key0"))
+		assert.Zero(t, c.NumPinned())
+	})
+}
+
+func TestLRUCache_PutOnPinnedEntry(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(100, 100)
+	key := []byte("key")
+	c.Put(key, "value1", 10)
+	_ = c.Pin(key)
+
+	c.Put(key, "value2", 20)
+	assert.Equal(t, 1, c.NumPinned())
+	value, _ := c.Peek(key)
+	assert.Equal(t, "value2", value)
+	assert.Equal(t, uint64(20), c.SizeInBytesContained())
+
+	has, added := c.HasOrAdd(key, "value3", 5)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	// the new size does not fit in the pinning capacity, so the entry is unpinned
+	c.Put(key, "value4", 30)
+	assert.Zero(t, c.NumPinned())
+	value, _ = c.Get(key)
+	assert.Equal(t, "value4", value)
+	assert.Equal(t, uint64(30), c.SizeInBytesContained())
+}
+
+func TestLRUCache_RemoveAndClearShouldDropPinnedEntries(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(100, 100)
+	c.Put([]byte("a"), "a", 10)
+	c.Put([]byte("b"), "b", 10)
+	c.Put([]byte("c"), "c", 10)
+	_ = c.Pin([]byte("a"))
+	_ = c.Pin([]byte("b"))
+
+	c.Remove([]byte("a"))
+	assert.False(t, c.Has([]byte("a")))
+	assert.Equal(t, 1, c.NumPinned())
+	assert.Equal(t, 2, c.Len())
+
+	c.Clear()
+	assert.Zero(t, c.NumPinned())
+	assert.Zero(t, c.Len())
+	assert.Zero(t, c.SizeInBytesContained())
+}
+
+func TestLRUCache_PinConcurrentOperationsShouldNotPanic(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(100, 10000)
+	numOperations := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			key := []byte(fmt.Sprintf("key%d", idx%50))
+			switch idx % 6 {
+			case 0:
+				c.Put(key, idx, 10)
+			case 1:
+				_ = c.Pin(key)
+			case 2:
+				c.Unpin(key)
+			case 3:
+				_, _ = c.Get(key)
+			case 4:
+				c.Remove(key)
+			case 5:
+				_ = c.Len()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(t, c.NumPinned() <= 25)
+}
+
+func TestLRUCache_Stress(t *testing.T) {
+	t.Parallel()
+
+	args := testscommon.ArgsCacheStress{
+		NumGoroutines:       8,
+		NumOpsPerGoroutine:  2000,
+		NumKeysPerGoroutine: 20,
+		OperationWeights: map[testscommon.CacheOperation]int{
+			testscommon.CachePut:    5,
+			testscommon.CacheGet:    10,
+			testscommon.CacheRemove: 2,
+			testscommon.CacheKeys:   1,
+		},
+		Seed: 7,
+	}
+
+	t.Run("without evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := lrucache.NewCache(1000)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 1000
+		stressArgs.ExpectNoEvictions = true
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Equal(t, 0, report.NumEvictions)
+	})
+	t.Run("with evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := lrucache.NewCache(40)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 40
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Greater(t, report.NumEvictions, 0)
+	})
+}
+
+func TestLRUCache_EvictionOrderShouldMatchTheGoldenState(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(5)
+	for i := 0; i < 8; i++ {
+		_ = c.Put([]byte(fmt.Sprintf("key%d", i)), make([]byte, i), i)
+		if i == 4 {
+			// key3 becomes the newest, so key5 evicts key0, key6 evicts key1 and key7 evicts key2
+			_, _ = c.Get([]byte("key3"))
+		}
+	}
+
+	state := testscommon.CaptureCacheState(c, true)
+	err := testscommon.CompareWithGoldenFile(state, "testdata/evictionOrder.golden", *updateGolden)
+	assert.Nil(t, err)
+
+	_, _ = c.Get([]byte("key4"))
+	state = testscommon.CaptureCacheState(c, true)
+	err = testscommon.CompareWithGoldenFile(state, "testdata/evictionOrder.golden", false)
+	assert.True(t, errors.Is(err, testscommon.ErrGoldenStateMismatch))
+	assert.Contains(t, err.Error(), "-key4 size=4")
+	assert.Contains(t, err.Error(), "+key4 size=4")
+}
+
+func TestCaptureCacheState_UndefinedOrderShouldSortTheKeys(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(5)
+	_ = c.Put([]byte("b"), []byte("value"), 5)
+	_ = c.Put([]byte{0, 1}, "not a byte slice", 5)
+	_ = c.Put([]byte("a"), []byte("v"), 1)
+
+	state := testscommon.CaptureCacheState(c, false)
+	expected := "len: 3\nsizeInBytes: 0\nmaxSize: 5\norderDefined: false\n0x0001\na size=1\nb size=5\n"
+	assert.Equal(t, expected, state.String())
+	assert.Empty(t, testscommon.DiffCacheState(expected, state))
+	assert.Equal(t, "-a size=2\n+a size=1\n", testscommon.DiffCacheState(strings.Replace(expected, "a size=1", "a size=2", 1), state))
+}
@@ -0,0 +1,223 @@
+package lrucache
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// maxPinnedRatio is the fraction (1 / maxPinnedRatio) of the cache's capacity which can be taken by pinned entries
+const maxPinnedRatio = 4
+
+// pinnedEntry holds a value which has been taken out of the LRU ordering, so it cannot be evicted
+type pinnedEntry struct {
+	value interface{}
+	size  int64
+}
+
+type sizedPeeker interface {
+	PeekSized(key interface{}) (interface{}, int64, bool)
+}
+
+func maxPinned(capacity int64) int64 {
+	if capacity <= 0 {
+		return 0
+	}
+
+	return core.MaxInt64(1, capacity/maxPinnedRatio)
+}
+
+// Pin protects an existing entry from eviction until it is unpinned or explicitly removed. The pinned entries are
+// limited, by default to a quarter of the cache's capacity, both in number and in bytes, so that they cannot starve the
+// cache. The pinned bytes are not accounted by the eviction of the unpinned entries.
+// Pinning an already pinned key has no effect. For caches created with an eviction function, pinning an entry
+// calls that function, as any other removal from the LRU ordering does
+func (c *lruCache) Pin(key []byte) error {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	_, isPinned := c.getPinned(string(key))
+	if isPinned {
+		return nil
+	}
+
+	peeker, ok := c.cache.(sizedPeeker)
+	if !ok {
+		return fmt.Errorf("%w: underlying cache does not support pinning", common.ErrNotSupportedCacheType)
+	}
+
+	value, size, ok := peeker.PeekSized(string(key))
+	if !ok {
+		return common.ErrKeyNotFound
+	}
+
+	err := c.checkPinningCapacity(nil, size)
+	if err != nil {
+		return err
+	}
+
+	// the entry is pinned before being taken out of the LRU ordering, so that readers always find it
+	c.setPinned(string(key), &pinnedEntry{
+		value: value,
+		size:  size,
+	})
+	c.removeExplicitly(func() {
+		c.cache.Remove(string(key))
+	})
+
+	return nil
+}
+
+// Unpin places a pinned entry back in the LRU ordering, as the most recently used one. Unpinning a key which is not
+// pinned has no effect
+func (c *lruCache) Unpin(key []byte) {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	entry, isPinned := c.getPinned(string(key))
+	if !isPinned {
+		return
+	}
+
+	c.cache.AddSized(string(key), entry.value, entry.size)
+	c.removePinned(string(key))
+}
+
+// SetPinningLimits replaces the limits of the pinned entries, which default to a quarter of the cache's capacity, both
+// in number and in bytes. The limits can not exceed the capacity of the cache. Lowering them below the already pinned
+// entries does not unpin any of them, but prevents pinning others until enough are unpinned. The limit in bytes is
+// ignored by the caches not accounting the sizes in bytes
+func (c *lruCache) SetPinningLimits(maxNumPinned int, maxPinnedBytes int64) error {
+	var validator common.ConfigValidator
+	validator.Check(maxNumPinned >= 0 && maxNumPinned <= c.maxsize, common.ErrInvalidConfig,
+		"maxNumPinned %d should be in [0, %d]", maxNumPinned, c.maxsize)
+	if c.maxSizeInBytes > 0 {
+		validator.Check(maxPinnedBytes >= 0 && maxPinnedBytes <= c.maxSizeInBytes, common.ErrInvalidConfig,
+			"maxPinnedBytes %d should be in [0, %d]", maxPinnedBytes, c.maxSizeInBytes)
+	}
+	err := validator.Err()
+	if err != nil {
+		return err
+	}
+
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+	c.mutPinned.Lock()
+	defer c.mutPinned.Unlock()
+
+	c.maxPinnedItems = maxNumPinned
+	if c.maxSizeInBytes > 0 {
+		c.maxPinnedBytes = maxPinnedBytes
+	}
+
+	return nil
+}
+
+// NumPinned returns the number of pinned entries
+func (c *lruCache) NumPinned() int {
+	c.mutPinned.RLock()
+	defer c.mutPinned.RUnlock()
+
+	return len(c.pinned)
+}
+
+// putNoLock must be called under mutWrite. A pinned entry stays pinned, unless its new size does not fit in
+// the capacity reserved for the pinned entries
+func (c *lruCache) putNoLock(key string, value interface{}, sizeInBytes int64) bool {
+	previous, isPinned := c.getPinned(key)
+	if !isPinned {
+		return c.cache.AddSized(key, value, sizeInBytes)
+	}
+
+	size := c.pinnedSize(sizeInBytes)
+	err := c.checkPinningCapacity(previous, size)
+	if err == nil {
+		c.setPinned(key, &pinnedEntry{
+			value: value,
+			size:  size,
+		})
+
+		return false
+	}
+
+	log.Debug("lruCache.Put: entry has been unpinned", "key", []byte(key), "error", err)
+
+	evicted := c.cache.AddSized(key, value, sizeInBytes)
+	c.removePinned(key)
+
+	return evicted
+}
+
+// pinnedSize returns the size accounted for a pinned entry, which is 0 for the caches not accounting sizes in bytes
+func (c *lruCache) pinnedSize(sizeInBytes int64) int64 {
+	if c.maxSizeInBytes == 0 || sizeInBytes < 0 {
+		return 0
+	}
+
+	return sizeInBytes
+}
+
+// checkPinningCapacity verifies whether an entry of the given size fits among the pinned entries, considering that
+// it replaces the previous pinned entry, if any
+func (c *lruCache) checkPinningCapacity(previous *pinnedEntry, size int64) error {
+	c.mutPinned.RLock()
+	defer c.mutPinned.RUnlock()
+
+	numPinned := len(c.pinned)
+	numBytes := c.pinnedBytes + size
+	if previous == nil {
+		numPinned++
+	} else {
+		numBytes -= previous.size
+	}
+
+	fitsNumItems := numPinned <= c.maxPinnedItems
+	fitsNumBytes := numBytes <= c.maxPinnedBytes
+	if fitsNumItems && fitsNumBytes {
+		return nil
+	}
+
+	return fmt.Errorf("%w: pinned %d entries having %d bytes, limits are %d entries and %d bytes",
+		common.ErrPinningCapacityReached,
+		len(c.pinned),
+		c.pinnedBytes,
+		c.maxPinnedItems,
+		c.maxPinnedBytes,
+	)
+}
+
+func (c *lruCache) getPinned(key string) (*pinnedEntry, bool) {
+	c.mutPinned.RLock()
+	defer c.mutPinned.RUnlock()
+
+	entry, isPinned := c.pinned[key]
+
+	return entry, isPinned
+}
+
+func (c *lruCache) setPinned(key string, entry *pinnedEntry) {
+	c.mutPinned.Lock()
+	defer c.mutPinned.Unlock()
+
+	previous, isPinned := c.pinned[key]
+	if isPinned {
+		c.pinnedBytes -= previous.size
+	}
+
+	c.pinned[key] = entry
+	c.pinnedBytes += entry.size
+}
+
+func (c *lruCache) removePinned(key string) {
+	c.mutPinned.Lock()
+	defer c.mutPinned.Unlock()
+
+	entry, isPinned := c.pinned[key]
+	if !isPinned {
+		return
+	}
+
+	delete(c.pinned, key)
+	c.pinnedBytes -= entry.size
+}
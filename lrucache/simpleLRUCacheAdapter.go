@@ -19,6 +19,13 @@ func (slca *simpleLRUCacheAdapter) AddSizedIfMissing(key, value interface{}, _ i
 	return slca.ContainsOrAdd(key, value)
 }
 
+// PeekSized calls the Peek method and returns 0 as size in bytes
+func (slca *simpleLRUCacheAdapter) PeekSized(key interface{}) (interface{}, int64, bool) {
+	value, ok := slca.Peek(key)
+
+	return value, 0, ok
+}
+
 // SizeInBytesContained returns 0
 func (slca *simpleLRUCacheAdapter) SizeInBytesContained() uint64 {
 	return 0
@@ -0,0 +1,93 @@
+package memorydb
+
+import (
+	"sync"
+)
+
+type pendingWrite struct {
+	val     []byte
+	removed bool
+}
+
+// pendingBatch holds the writes of a memorydb created in batch mode, until they are flushed
+type pendingBatch struct {
+	mut          sync.Mutex
+	writes       map[string]pendingWrite
+	maxBatchSize int
+}
+
+func newPendingBatch(maxBatchSize int) *pendingBatch {
+	return &pendingBatch{
+		writes:       make(map[string]pendingWrite),
+		maxBatchSize: maxBatchSize,
+	}
+}
+
+// get returns the pending write of the key, if any
+func (batch *pendingBatch) get(key string) (pendingWrite, bool) {
+	batch.mut.Lock()
+	defer batch.mut.Unlock()
+
+	write, ok := batch.writes[key]
+	return write, ok
+}
+
+// isFullNoLock returns true if the pending writes should be flushed
+func (batch *pendingBatch) isFullNoLock() bool {
+	return batch.maxBatchSize > 0 && len(batch.writes) >= batch.maxBatchSize
+}
+
+// Flush writes the pending batch of a memorydb created in batch mode, making its writes visible to RangeKeys and
+// Snapshot. It does nothing if the memorydb was not created in batch mode
+func (s *DB) Flush() error {
+	if s.batch == nil {
+		return nil
+	}
+
+	s.batch.mut.Lock()
+	defer s.batch.mut.Unlock()
+
+	s.flushNoLock()
+
+	return nil
+}
+
+func (s *DB) flushNoLock() {
+	for key, write := range s.batch.writes {
+		sh := s.shardOf(key)
+
+		sh.lock()
+		if write.removed {
+			sh.removeNoLock(key)
+		} else {
+			sh.putNoLock(key, write.val)
+		}
+		sh.unlock()
+	}
+
+	s.batch.writes = make(map[string]pendingWrite)
+}
+
+// DiscardPendingBatch drops the writes not flushed yet, as a crash of a leveldb persister would. It does nothing if
+// the memorydb was not created in batch mode
+func (s *DB) DiscardPendingBatch() {
+	if s.batch == nil {
+		return
+	}
+
+	s.batch.mut.Lock()
+	s.batch.writes = make(map[string]pendingWrite)
+	s.batch.mut.Unlock()
+}
+
+func (s *DB) addToBatch(writes map[string]pendingWrite) {
+	s.batch.mut.Lock()
+	defer s.batch.mut.Unlock()
+
+	for key, write := range writes {
+		s.batch.writes[key] = write
+	}
+	if s.batch.isFullNoLock() {
+		s.flushNoLock()
+	}
+}
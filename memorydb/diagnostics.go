@@ -0,0 +1,54 @@
+package memorydb
+
+import (
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// Diagnostics holds a summary of the contents of a memorydb
+type Diagnostics struct {
+	Name             string
+	NumEntries       int
+	NumBytes         uint64
+	NumShards        int
+	LockFreeReads    bool
+	NumPendingWrites int
+}
+
+// Diagnostics returns a summary of the contents. The number of entries and bytes do not include the pending writes
+func (s *DB) Diagnostics() Diagnostics {
+	numPendingWrites := 0
+	if s.batch != nil {
+		s.batch.mut.Lock()
+		numPendingWrites = len(s.batch.writes)
+		s.batch.mut.Unlock()
+	}
+
+	return Diagnostics{
+		Name:             s.options.monitoringName,
+		NumEntries:       s.Len(),
+		NumBytes:         s.SizeInBytes(),
+		NumShards:        len(s.shards),
+		LockFreeReads:    s.options.lockFreeReads,
+		NumPendingWrites: numPendingWrites,
+	}
+}
+
+// DiagnosticsSnapshot returns the Diagnostics, exposed by the monitoring debug handler
+func (s *DB) DiagnosticsSnapshot() interface{} {
+	return s.Diagnostics()
+}
+
+// Stats returns the key stats of the persister, as summarized by Diagnostics
+func (s *DB) Stats() map[string]interface{} {
+	diagnostics := s.Diagnostics()
+
+	return map[string]interface{}{
+		types.StatType:        string(common.MemoryDB),
+		types.StatNumItems:    diagnostics.NumEntries,
+		types.StatSizeInBytes: diagnostics.NumBytes,
+		"numShards":           diagnostics.NumShards,
+		"lockFreeReads":       diagnostics.LockFreeReads,
+		"numPendingWrites":    diagnostics.NumPendingWrites,
+	}
+}
@@ -0,0 +1,155 @@
+package memorydb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// dumpMagic starts the dump files, followed by the (key, value) pairs, each one written as the big endian uint32
+// length of the key, the key, the big endian uint32 length of the value and the value
+var dumpMagic = []byte("MEMDB\x01")
+
+const lengthPrefixSize = 4
+
+// DumpToFile writes the contents to the provided file, replacing it, in ascending order of the keys. The file is
+// written from a view, so the writers are not blocked meanwhile. In batch mode, the pending writes are not dumped
+func (s *DB) DumpToFile(path string) error {
+	view := s.View()
+	keys := make([]string, 0, view.Len())
+	view.RangeKeys(func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	sort.Strings(keys)
+
+	// the dump is written to a temporary file first, so that a failed dump does not leave a truncated file behind
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = writeDump(file, view, keys)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func writeDump(file *os.File, view *View, keys []string) error {
+	writer := bufio.NewWriter(file)
+	_, err := writer.Write(dumpMagic)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		val := view.shardOf(key)[key]
+		err = writeLengthPrefixed(writer, []byte(key))
+		if err != nil {
+			return err
+		}
+		err = writeLengthPrefixed(writer, val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+func writeLengthPrefixed(writer io.Writer, data []byte) error {
+	prefix := make([]byte, lengthPrefixSize)
+	binary.BigEndian.PutUint32(prefix, uint32(len(data)))
+	_, err := writer.Write(prefix)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
+
+// LoadFromFile replaces the contents with the ones dumped to the provided file by DumpToFile. The contents are left
+// untouched if the file can not be read entirely. A bounded memorydb evicts the entries exceeding its bounds
+func (s *DB) LoadFromFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	snapshot, err := readDump(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("%w while loading %s", err, path)
+	}
+
+	s.RestoreFrom(snapshot)
+
+	return nil
+}
+
+func readDump(reader io.Reader) (map[string][]byte, error) {
+	magic := make([]byte, len(dumpMagic))
+	_, err := io.ReadFull(reader, magic)
+	if err != nil || !bytes.Equal(magic, dumpMagic) {
+		return nil, fmt.Errorf("%w: missing header", common.ErrInvalidDumpFormat)
+	}
+
+	snapshot := make(map[string][]byte)
+	for {
+		key, err := readLengthPrefixed(reader)
+		if errors.Is(err, io.EOF) {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := readLengthPrefixed(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = fmt.Errorf("%w: missing value", common.ErrInvalidDumpFormat)
+			}
+			return nil, err
+		}
+
+		snapshot[string(key)] = val
+	}
+}
+
+// readLengthPrefixed returns io.EOF only if the reader is exhausted before the length prefix
+func readLengthPrefixed(reader io.Reader) ([]byte, error) {
+	prefix := make([]byte, lengthPrefixSize)
+	_, err := io.ReadFull(reader, prefix)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: truncated length", common.ErrInvalidDumpFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(prefix))
+	_, err = io.ReadFull(reader, data)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: truncated data", common.ErrInvalidDumpFormat)
+	}
+
+	return data, err
+}
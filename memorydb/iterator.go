@@ -0,0 +1,91 @@
+package memorydb
+
+import (
+	"sort"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.IterablePersister = (*DB)(nil)
+var _ types.Iterator = (*iterator)(nil)
+
+// iterator iterates over the entries of the memorydb sorted when it was created, not affected by the later writes
+type iterator struct {
+	keys   []string
+	values [][]byte
+	// pos is the index of the current entry, -1 before the first one
+	pos int
+}
+
+// NewIterator returns an iterator over the current entries of the memorydb. In batch mode, the pending writes are not
+// visible
+func (s *DB) NewIterator() (types.Iterator, error) {
+	keys, values := s.sortedEntries(func(_ string) bool {
+		return true
+	})
+
+	return &iterator{
+		keys:   keys,
+		values: values,
+		pos:    -1,
+	}, nil
+}
+
+// Seek moves to the first entry whose key is greater than or equal to the provided one
+func (it *iterator) Seek(key []byte) bool {
+	it.pos = sort.SearchStrings(it.keys, string(key))
+
+	return it.isValid()
+}
+
+// Next moves to the next entry
+func (it *iterator) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+
+	return it.isValid()
+}
+
+// Last moves to the last entry
+func (it *iterator) Last() bool {
+	it.pos = len(it.keys) - 1
+
+	return it.isValid()
+}
+
+// Prev moves to the previous entry, or to the last one once the iterator moved past it
+func (it *iterator) Prev() bool {
+	if it.pos >= 0 {
+		it.pos--
+	}
+
+	return it.isValid()
+}
+
+// Key returns the key of the current entry, nil if there is none
+func (it *iterator) Key() []byte {
+	if !it.isValid() {
+		return nil
+	}
+
+	return []byte(it.keys[it.pos])
+}
+
+// Value returns the value of the current entry, nil if there is none
+func (it *iterator) Value() []byte {
+	if !it.isValid() {
+		return nil
+	}
+
+	return it.values[it.pos]
+}
+
+// Close does nothing, as the iterator holds no resources
+func (it *iterator) Close() error {
+	return nil
+}
+
+func (it *iterator) isValid() bool {
+	return it.pos >= 0 && it.pos < len(it.keys)
+}
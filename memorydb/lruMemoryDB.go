@@ -7,11 +7,12 @@ import (
 )
 
 var _ types.Persister = (*lruDB)(nil)
+var _ types.CapabilitiesProvider = (*lruDB)(nil)
 
 // lruDB represents the memory database storage. It holds a LRU of key value pairs
 // and a mutex to handle concurrent accesses to the map
 type lruDB struct {
-	cacher types.Cacher
+	cacher *types.TypedCacher[[]byte, []byte]
 }
 
 // NewlruDB creates a lruDB according to size
@@ -21,7 +22,12 @@ func NewlruDB(size uint32) (*lruDB, error) {
 		return nil, err
 	}
 
-	return &lruDB{cacher: cacher}, nil
+	typedCacher, err := types.NewTypedCacher[[]byte, []byte](cacher)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lruDB{cacher: typedCacher}, nil
 }
 
 // Put adds the value to the (key, val) storage medium
@@ -34,14 +40,10 @@ func (l *lruDB) Put(key, val []byte) error {
 func (l *lruDB) Get(key []byte) ([]byte, error) {
 	val, ok := l.cacher.Get(key)
 	if !ok {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewKeyNotFoundError(key, "", "")
 	}
 
-	mrsVal, ok := val.([]byte)
-	if !ok {
-		return nil, common.ErrKeyNotFound
-	}
-	return mrsVal, nil
+	return val, nil
 }
 
 // Has returns true if the given key is present in the persistence medium, false otherwise
@@ -50,7 +52,7 @@ func (l *lruDB) Has(key []byte) error {
 	if has {
 		return nil
 	}
-	return common.ErrKeyNotFound
+	return common.NewKeyNotFoundError(key, "", "")
 }
 
 // Close closes the files/resources associated to the storage medium
@@ -89,18 +91,20 @@ func (l *lruDB) RangeKeys(handler func(key []byte, value []byte) bool) {
 			continue
 		}
 
-		vBuff, ok := v.([]byte)
-		if !ok {
-			continue
-		}
-
-		shouldContinue := handler(k, vBuff)
+		shouldContinue := handler(k, v)
 		if !shouldContinue {
 			return
 		}
 	}
 }
 
+// Capabilities returns the optional features of the persister, whose contents are lost when the process stops
+func (l *lruDB) Capabilities() types.PersisterCapabilities {
+	return types.PersisterCapabilities{
+		Durability: types.VolatileDurability,
+	}
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (l *lruDB) IsInterfaceNil() bool {
 	return l == nil
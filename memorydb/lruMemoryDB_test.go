@@ -1,6 +1,7 @@
 package memorydb_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
@@ -75,7 +76,7 @@ func TestLruDB_HasNotPresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestLruDB_DeletePresent(t *testing.T) {
@@ -94,7 +95,7 @@ func TestLruDB_DeletePresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestLruDB_DeleteNotPresent(t *testing.T) {
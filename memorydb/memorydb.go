@@ -1,50 +1,125 @@
 package memorydb
 
 import (
-	"encoding/base64"
-	"errors"
-	"fmt"
-	"sync"
+	"sort"
+	"strings"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var _ types.Persister = (*DB)(nil)
+var _ types.MultiPutter = (*DB)(nil)
+var _ types.Flusher = (*DB)(nil)
+var _ types.RangeIterator = (*DB)(nil)
+var _ types.SizedCache = (*DB)(nil)
+var _ types.DiagnosticsProvider = (*DB)(nil)
+var _ types.StatsProvider = (*DB)(nil)
+var _ types.BatchedReader = (*DB)(nil)
+var _ types.BulkWriter = (*DB)(nil)
+var _ types.SnapshotablePersister = (*DB)(nil)
+var _ types.CapabilitiesProvider = (*DB)(nil)
 
-// DB represents the memory database storage. It holds a map of key value pairs
-// and a mutex to handle concurrent accesses to the map
+// DB represents the memory database storage. It holds the key value pairs in shards selected by the hash of the
+// keys, each one guarded by its own mutex, so that the concurrent accesses to different keys do not contend. With
+// WithLockFreeReads, Get and Has load an atomic snapshot of the shard instead of locking it.
+// In batch mode, the writes are held in a pending batch until flushed, as the leveldb persisters do: they are read
+// through by Get and Has, but are not visible to RangeKeys and Snapshot until flushed
 type DB struct {
-	db   map[string][]byte
-	mutx sync.RWMutex
+	options *options
+	shards  []*shard
+	batch   *pendingBatch
 }
 
-// New creates a new memorydb object
-func New() *DB {
-	return &DB{
-		db:   make(map[string][]byte),
-		mutx: sync.RWMutex{},
+// New creates a new memorydb object. By default, the number of entries is unbounded
+func New(opts ...Option) *DB {
+	o := newOptions(opts)
+	numShards := o.getNumShards()
+
+	db := &DB{
+		options: o,
+		shards:  make([]*shard, numShards),
+	}
+	// the bounds are split over the shards, rounding up
+	maxEntriesPerShard := (o.maxEntries + numShards - 1) / numShards
+	maxBytesPerShard := (o.maxBytes + uint64(numShards) - 1) / uint64(numShards)
+	for i := range db.shards {
+		db.shards[i] = newShard(maxEntriesPerShard, maxBytesPerShard, o.evictionPolicy, o.lockFreeReads)
+	}
+	if o.batchMode {
+		db.batch = newPendingBatch(o.maxBatchSize)
 	}
+	if len(o.monitoringName) > 0 {
+		monitoring.MonitorNewDB(string(common.MemoryDB), o.monitoringName)
+		monitoring.RegisterDiagnosticsProvider(o.monitoringName, db)
+	}
+
+	return db
+}
+
+func (s *DB) shardOf(key string) *shard {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+
+	return s.shards[fnv32(key)%uint32(len(s.shards))]
+}
+
+// fnv32 computes the FNV-1a hash of the key, without allocating
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+
+	return hash
 }
 
 // Put adds the value to the (key, val) storage medium
 func (s *DB) Put(key, val []byte) error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
+	if s.batch != nil {
+		s.addToBatch(map[string]pendingWrite{string(key): {val: val}})
+		return nil
+	}
+
+	sh := s.shardOf(string(key))
 
-	s.db[string(key)] = val
+	sh.lock()
+	sh.putNoLock(string(key), val)
+	sh.unlock()
 
 	return nil
 }
 
-// Get gets the value associated to the key, or reports an error
-func (s *DB) Get(key []byte) ([]byte, error) {
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+// MultiPut adds all the provided values to the (key, val) storage medium
+func (s *DB) MultiPut(data map[string][]byte) error {
+	if s.batch != nil {
+		writes := make(map[string]pendingWrite, len(data))
+		for key, val := range data {
+			writes[key] = pendingWrite{val: val}
+		}
+		s.addToBatch(writes)
+		return nil
+	}
 
-	val, ok := s.db[string(key)]
+	for key, val := range data {
+		sh := s.shardOf(key)
 
+		sh.lock()
+		sh.putNoLock(key, val)
+		sh.unlock()
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key, or reports an error
+func (s *DB) Get(key []byte) ([]byte, error) {
+	val, ok := s.get(string(key))
 	if !ok {
-		return nil, fmt.Errorf("key: %s not found", base64.StdEncoding.EncodeToString(key))
+		return nil, common.NewKeyNotFoundError(key, "", "")
 	}
 
 	return val, nil
@@ -52,66 +127,323 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 
 // Has returns true if the given key is present in the persistence medium, false otherwise
 func (s *DB) Has(key []byte) error {
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+	if !s.has(string(key)) {
+		return common.NewKeyNotFoundError(key, "", "")
+	}
 
-	_, ok := s.db[string(key)]
+	return nil
+}
 
-	if !ok {
-		return errors.New("key not found")
+// get reads through the pending batch, if any, before reading from the shards
+func (s *DB) get(key string) ([]byte, bool) {
+	if s.batch != nil {
+		write, isPending := s.batch.get(key)
+		if isPending {
+			return write.val, !write.removed
+		}
 	}
-	return nil
+
+	return s.shardOf(key).get(key)
+}
+
+// has does not mark the key as recently used, unlike get
+func (s *DB) has(key string) bool {
+	if s.batch != nil {
+		write, isPending := s.batch.get(key)
+		if isPending {
+			return !write.removed
+		}
+	}
+
+	return s.shardOf(key).has(key)
 }
 
-// Close closes the files/resources associated to the storage medium
+// Close flushes the pending batch, if any, and stops the monitoring of the memorydb
 func (s *DB) Close() error {
-	// nothing to do
+	_ = s.Flush()
+	if len(s.options.monitoringName) > 0 {
+		monitoring.DeregisterDiagnosticsProvider(s.options.monitoringName, s)
+	}
+
 	return nil
 }
 
 // Remove removes the data associated to the given key
 func (s *DB) Remove(key []byte) error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
+	if s.batch != nil {
+		s.addToBatch(map[string]pendingWrite{string(key): {removed: true}})
+		return nil
+	}
 
-	delete(s.db, string(key))
+	sh := s.shardOf(string(key))
+
+	sh.lock()
+	sh.removeNoLock(string(key))
+	sh.unlock()
 
 	return nil
 }
 
 // Destroy removes the storage medium stored data
 func (s *DB) Destroy() error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
+	s.DiscardPendingBatch()
 
-	s.db = make(map[string][]byte)
+	s.lockAll()
+	defer s.unlockAll()
+
+	for _, sh := range s.shards {
+		sh.resetNoLock()
+	}
 
 	return nil
 }
 
+// Len returns the number of entries. In batch mode, the pending writes are not counted
+func (s *DB) Len() int {
+	numEntries := 0
+	for _, sh := range s.shards {
+		sh.mut.RLock()
+		numEntries += len(sh.entries)
+		sh.mut.RUnlock()
+	}
+
+	return numEntries
+}
+
+// SizeInBytes returns the size of the keys and values. In batch mode, the pending writes are not counted
+func (s *DB) SizeInBytes() uint64 {
+	numBytes := uint64(0)
+	for _, sh := range s.shards {
+		sh.mut.RLock()
+		numBytes += sh.numBytes
+		sh.mut.RUnlock()
+	}
+
+	return numBytes
+}
+
+// SizeInBytesContained returns the size of the keys and values, so that the memorydb can be registered in a
+// monitoring.StatsReporter
+func (s *DB) SizeInBytesContained() uint64 {
+	return s.SizeInBytes()
+}
+
+// lockAll locks the shards, always in the same order
+func (s *DB) lockAll() {
+	for _, sh := range s.shards {
+		sh.lock()
+	}
+}
+
+func (s *DB) unlockAll() {
+	for _, sh := range s.shards {
+		sh.unlock()
+	}
+}
+
+func (s *DB) rLockAll() {
+	for _, sh := range s.shards {
+		sh.mut.RLock()
+	}
+}
+
+func (s *DB) rUnlockAll() {
+	for _, sh := range s.shards {
+		sh.mut.RUnlock()
+	}
+}
+
+// Snapshot returns a copy of the contents. The values are shared with the memorydb, so they should not be modified
+func (s *DB) Snapshot() map[string][]byte {
+	s.rLockAll()
+	defer s.rUnlockAll()
+
+	snapshot := make(map[string][]byte)
+	for _, sh := range s.shards {
+		for key, val := range sh.entries {
+			snapshot[key] = val
+		}
+	}
+
+	return snapshot
+}
+
+// RestoreFrom replaces the contents with the ones of the provided snapshot, which is not retained. A bounded memorydb
+// evicts the snapshot entries exceeding its bounds
+func (s *DB) RestoreFrom(snapshot map[string][]byte) {
+	s.lockAll()
+	defer s.unlockAll()
+
+	for _, sh := range s.shards {
+		sh.resetNoLock()
+	}
+	for key, val := range snapshot {
+		s.shardOf(key).putNoLock(key, val)
+	}
+}
+
+// Clone returns a new memorydb, created with the same options, holding the current contents. The pending batch, if
+// any, is not cloned
+func (s *DB) Clone() *DB {
+	clone := New(s.options.asOptions()...)
+	clone.RestoreFrom(s.Snapshot())
+
+	return clone
+}
+
 // RangeKeys will iterate over all contained (key, value) pairs calling the provided handler
 func (s *DB) RangeKeys(handler func(key []byte, value []byte) bool) {
 	if handler == nil {
 		return
 	}
 
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+	for _, sh := range s.shards {
+		if !sh.rangeKeys(handler) {
+			return
+		}
+	}
+}
+
+// RangeKeysWithPrefix iterates, in ascending order of the keys, over the (key, value) pairs whose keys start with the
+// provided prefix, as the leveldb iterators do
+func (s *DB) RangeKeysWithPrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	s.RangeKeysPage(prefix, 0, 0, handler)
+}
+
+// RangeKeysPage iterates as RangeKeysWithPrefix does, skipping the first offset pairs and stopping after limit pairs.
+// A zero limit does not stop the iteration
+func (s *DB) RangeKeysPage(prefix []byte, offset int, limit int, handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	keys, values := s.sortedEntriesWithPrefix(string(prefix))
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(keys)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
 
-	for k, v := range s.db {
-		shouldContinue := handler([]byte(k), v)
+	// the handler is called outside the lock, so that it is allowed to write
+	for i := offset; i < end; i++ {
+		shouldContinue := handler([]byte(keys[i]), values[i])
 		if !shouldContinue {
 			return
 		}
 	}
 }
 
+// RangeKeysBetween iterates, in ascending order of the keys, over the (key, value) pairs whose keys are in
+// [startKey, endKey), a nil endKey meaning no upper bound, as the leveldb iterators do
+func (s *DB) RangeKeysBetween(startKey []byte, endKey []byte, handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	keys, values := s.sortedEntries(func(key string) bool {
+		return key >= string(startKey) && (endKey == nil || key < string(endKey))
+	})
+	for i := range keys {
+		shouldContinue := handler([]byte(keys[i]), values[i])
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+func (s *DB) sortedEntriesWithPrefix(prefix string) ([]string, [][]byte) {
+	return s.sortedEntries(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// sortedEntries returns the entries whose keys are selected by the filter, sorted by key
+func (s *DB) sortedEntries(filter func(key string) bool) ([]string, [][]byte) {
+	s.rLockAll()
+	defer s.rUnlockAll()
+
+	keys := make([]string, 0)
+	for _, sh := range s.shards {
+		for key := range sh.entries {
+			if filter(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = s.shardOf(key).entries[key]
+	}
+
+	return keys, values
+}
+
 // DestroyClosed removes the storage medium stored data
 func (s *DB) DestroyClosed() error {
 	return s.Destroy()
 }
 
+// Capabilities returns the optional features of the persister, whose contents are lost when the process stops
+func (s *DB) Capabilities() types.PersisterCapabilities {
+	return types.PersisterCapabilities{
+		SupportsSnapshots: true,
+		SupportsIteration: true,
+		Durability:        types.VolatileDurability,
+	}
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (s *DB) IsInterfaceNil() bool {
 	return s == nil
 }
+
+// GetMany returns the values of the provided keys, the missing keys being left out
+func (s *DB) GetMany(keys [][]byte) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		val, ok := s.get(string(key))
+		if ok {
+			values[string(key)] = val
+		}
+	}
+
+	return values, nil
+}
+
+// MultiRemove removes the data associated to all the provided keys
+func (s *DB) MultiRemove(keys [][]byte) error {
+	if s.batch != nil {
+		writes := make(map[string]pendingWrite, len(keys))
+		for _, key := range keys {
+			writes[string(key)] = pendingWrite{removed: true}
+		}
+		s.addToBatch(writes)
+		return nil
+	}
+
+	for _, key := range keys {
+		sh := s.shardOf(string(key))
+
+		sh.lock()
+		sh.removeNoLock(string(key))
+		sh.unlock()
+	}
+
+	return nil
+}
+
+// TakeSnapshot returns the View of the current contents, which holds only the flushed writes in batch mode
+func (s *DB) TakeSnapshot() (types.PersisterView, error) {
+	return s.View(), nil
+}
+
+// ReleaseSnapshot releases the view returned by TakeSnapshot
+func (s *DB) ReleaseSnapshot(view types.PersisterView) {
+	if view != nil {
+		view.Release()
+	}
+}
@@ -1,9 +1,16 @@
 package memorydb_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -120,3 +127,479 @@ func Test_RangeKeys(t *testing.T) {
 
 	assert.Equal(t, keysVals, recovered)
 }
+
+func TestMultiPut(t *testing.T) {
+	mdb := memorydb.New()
+
+	err := mdb.MultiPut(map[string][]byte{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+	})
+	assert.Nil(t, err)
+
+	v, err := mdb.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), v)
+	v, err = mdb.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), v)
+}
+
+func TestBoundedDB_ShouldEvict(t *testing.T) {
+	t.Parallel()
+
+	t.Run("max entries with LRU eviction", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithMaxEntries(2))
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+		_, _ = mdb.Get([]byte("key1"))
+		_ = mdb.Put([]byte("key3"), []byte("value3"))
+
+		assert.Nil(t, mdb.Has([]byte("key1")))
+		assert.NotNil(t, mdb.Has([]byte("key2")))
+		assert.Nil(t, mdb.Has([]byte("key3")))
+	})
+	t.Run("max entries with FIFO eviction", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithMaxEntries(2), memorydb.WithEvictionPolicy(memorydb.FIFOEviction))
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+		_, _ = mdb.Get([]byte("key1"))
+		_ = mdb.Put([]byte("key1"), []byte("updated"))
+		_ = mdb.Put([]byte("key3"), []byte("value3"))
+
+		assert.NotNil(t, mdb.Has([]byte("key1")))
+		assert.Nil(t, mdb.Has([]byte("key2")))
+		assert.Nil(t, mdb.Has([]byte("key3")))
+	})
+	t.Run("max bytes", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithMaxBytes(20))
+		_ = mdb.MultiPut(map[string][]byte{"key1": []byte("value1")})
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+		assert.Nil(t, mdb.Has([]byte("key1")))
+		assert.Nil(t, mdb.Has([]byte("key2")))
+
+		_ = mdb.Remove([]byte("key2"))
+		_ = mdb.Put([]byte("key3"), []byte("a larger value"))
+		assert.NotNil(t, mdb.Has([]byte("key1")))
+		assert.Nil(t, mdb.Has([]byte("key3")))
+
+		// the last written value is kept, even if it exceeds the bound by itself
+		_ = mdb.Put([]byte("key4"), []byte("a value exceeding the bound"))
+		assert.NotNil(t, mdb.Has([]byte("key3")))
+		assert.Nil(t, mdb.Has([]byte("key4")))
+
+		_ = mdb.Destroy()
+		assert.NotNil(t, mdb.Has([]byte("key4")))
+		_ = mdb.Put([]byte("key5"), []byte("value5"))
+		assert.Nil(t, mdb.Has([]byte("key5")))
+	})
+}
+
+func TestDB_SnapshotAndRestore(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+	snapshot := mdb.Snapshot()
+	clone := mdb.Clone()
+	_ = mdb.Put([]byte("key3"), []byte("value3"))
+	_ = mdb.Remove([]byte("key1"))
+	assert.Equal(t, map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}, snapshot)
+
+	mdb.RestoreFrom(snapshot)
+	assert.Equal(t, snapshot, mdb.Snapshot())
+	assert.Equal(t, snapshot, clone.Snapshot())
+
+	_ = clone.Put([]byte("key4"), []byte("value4"))
+	assert.NotNil(t, mdb.Has([]byte("key4")))
+
+	bounded := memorydb.New(memorydb.WithMaxEntries(1))
+	bounded.RestoreFrom(snapshot)
+	assert.Len(t, bounded.Snapshot(), 1)
+	assert.Len(t, bounded.Clone().Snapshot(), 1)
+}
+
+func TestDB_RangeKeysWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.MultiPut(map[string][]byte{
+		"a3": []byte("v3"),
+		"a1": []byte("v1"),
+		"b1": []byte("v4"),
+		"a2": []byte("v2"),
+	})
+
+	collect := func(iterate func(handler func(key []byte, value []byte) bool)) []string {
+		keys := make([]string, 0)
+		iterate(func(key []byte, value []byte) bool {
+			keys = append(keys, string(key)+"="+string(value))
+			return true
+		})
+		return keys
+	}
+
+	keys := collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysWithPrefix([]byte("a"), handler)
+	})
+	assert.Equal(t, []string{"a1=v1", "a2=v2", "a3=v3"}, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysWithPrefix(nil, handler)
+	})
+	assert.Equal(t, []string{"a1=v1", "a2=v2", "a3=v3", "b1=v4"}, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysPage([]byte("a"), 1, 1, handler)
+	})
+	assert.Equal(t, []string{"a2=v2"}, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysPage(nil, 2, 10, handler)
+	})
+	assert.Equal(t, []string{"a3=v3", "b1=v4"}, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysPage(nil, 5, 0, handler)
+	})
+	assert.Empty(t, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysBetween([]byte("a2"), []byte("b1"), handler)
+	})
+	assert.Equal(t, []string{"a2=v2", "a3=v3"}, keys)
+
+	keys = collect(func(handler func(key []byte, value []byte) bool) {
+		mdb.RangeKeysBetween([]byte("a3"), nil, handler)
+	})
+	assert.Equal(t, []string{"a3=v3", "b1=v4"}, keys)
+
+	numCalls := 0
+	mdb.RangeKeysWithPrefix(nil, func(key []byte, value []byte) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestDB_ShardedConcurrentAccesses(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New(memorydb.WithNumShards(4))
+	numWorkers := 8
+	numKeysPerWorker := 100
+
+	wg := sync.WaitGroup{}
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func(worker int) {
+			defer wg.Done()
+
+			for j := 0; j < numKeysPerWorker; j++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", worker, j))
+				_ = mdb.Put(key, key)
+				val, err := mdb.Get(key)
+				assert.Nil(t, err)
+				assert.Equal(t, key, val)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, mdb.Snapshot(), numWorkers*numKeysPerWorker)
+
+	// the bounds of a sharded memorydb are split over the shards
+	bounded := memorydb.New(memorydb.WithNumShards(4), memorydb.WithMaxEntries(8))
+	for i := 0; i < 100; i++ {
+		_ = bounded.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value"))
+	}
+	assert.Len(t, bounded.Snapshot(), 8)
+}
+
+func TestDB_LockFreeReads(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads see the writes", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithLockFreeReads())
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.MultiPut(map[string][]byte{"key2": []byte("value2")})
+		val, err := mdb.Get([]byte("key1"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value1"), val)
+		assert.Nil(t, mdb.Has([]byte("key2")))
+
+		view := mdb.View()
+		_ = mdb.Remove([]byte("key1"))
+		assert.True(t, common.IsNotFound(mdb.Has([]byte("key1"))))
+		assert.Nil(t, view.Has([]byte("key1")))
+
+		_ = mdb.Destroy()
+		assert.True(t, common.IsNotFound(mdb.Has([]byte("key2"))))
+		assert.Equal(t, true, mdb.Stats()["lockFreeReads"])
+
+		clone := mdb.Clone()
+		assert.Equal(t, true, clone.Stats()["lockFreeReads"])
+	})
+	t.Run("the reads of a bounded memorydb do not refresh the keys", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithLockFreeReads(), memorydb.WithMaxEntries(2))
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+		_, _ = mdb.Get([]byte("key1"))
+		_ = mdb.Put([]byte("key3"), []byte("value3"))
+		assert.True(t, common.IsNotFound(mdb.Has([]byte("key1"))))
+		assert.Nil(t, mdb.Has([]byte("key2")))
+	})
+	t.Run("concurrent accesses", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithLockFreeReads(), memorydb.WithNumShards(4))
+		numWorkers := 8
+		numKeysPerWorker := 100
+
+		wg := sync.WaitGroup{}
+		wg.Add(numWorkers)
+		for i := 0; i < numWorkers; i++ {
+			go func(worker int) {
+				defer wg.Done()
+
+				for j := 0; j < numKeysPerWorker; j++ {
+					key := []byte(fmt.Sprintf("key-%d-%d", worker, j))
+					_ = mdb.Put(key, key)
+					val, err := mdb.Get(key)
+					assert.Nil(t, err)
+					assert.Equal(t, key, val)
+					_, _ = mdb.Get([]byte(fmt.Sprintf("key-%d-%d", (worker+1)%numWorkers, j)))
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, numWorkers*numKeysPerWorker, mdb.Len())
+	})
+}
+
+func TestDB_BatchMode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("explicit flush", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithBatchMode(0))
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.MultiPut(map[string][]byte{"key2": []byte("value2")})
+
+		// the pending writes are read through, but not ranged over
+		val, err := mdb.Get([]byte("key1"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value1"), val)
+		assert.Nil(t, mdb.Has([]byte("key2")))
+		assert.Empty(t, mdb.Snapshot())
+
+		mdb.Flush()
+		assert.Len(t, mdb.Snapshot(), 2)
+
+		_ = mdb.Remove([]byte("key1"))
+		assert.NotNil(t, mdb.Has([]byte("key1")))
+		_, err = mdb.Get([]byte("key1"))
+		assert.NotNil(t, err)
+		assert.Len(t, mdb.Snapshot(), 2)
+
+		mdb.DiscardPendingBatch()
+		assert.Nil(t, mdb.Has([]byte("key1")))
+
+		_ = mdb.Remove([]byte("key1"))
+		assert.Nil(t, mdb.Close())
+		assert.Equal(t, map[string][]byte{"key2": []byte("value2")}, mdb.Snapshot())
+	})
+	t.Run("flush when full", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New(memorydb.WithBatchMode(2))
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		assert.Empty(t, mdb.Snapshot())
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+		assert.Len(t, mdb.Snapshot(), 2)
+	})
+}
+
+func TestDB_View(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	for i := 0; i < 100; i++ {
+		_ = mdb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+	}
+
+	view := mdb.View()
+	_ = mdb.Put([]byte("key0"), []byte("changed"))
+	_ = mdb.Put([]byte("new key"), []byte("value"))
+	_ = mdb.Remove([]byte("key1"))
+
+	assert.Equal(t, 100, view.Len())
+	val, err := view.Get([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.Nil(t, view.Has([]byte("key1")))
+	assert.NotNil(t, view.Has([]byte("new key")))
+
+	val, _ = mdb.Get([]byte("key0"))
+	assert.Equal(t, []byte("changed"), val)
+	assert.NotNil(t, mdb.Has([]byte("key1")))
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = mdb.Put([]byte(fmt.Sprintf("key%d", i%200)), []byte("other"))
+			if i%100 == 0 {
+				_ = mdb.View()
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		numEntries := 0
+		view.RangeKeys(func(key []byte, value []byte) bool {
+			numEntries++
+			return true
+		})
+		assert.Equal(t, 100, numEntries)
+	}()
+	wg.Wait()
+}
+
+func TestDB_LenAndSizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	name := "TestDB_LenAndSizeInBytes"
+	mdb := memorydb.New(memorydb.WithMonitoring(name), memorydb.WithBatchMode(0))
+	assert.Contains(t, monitoring.ListDiagnosticsProviders(), name)
+
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+	assert.Equal(t, 0, mdb.Len())
+	assert.Equal(t, 2, mdb.Diagnostics().NumPendingWrites)
+
+	mdb.Flush()
+	assert.Equal(t, 2, mdb.Len())
+	assert.Equal(t, uint64(20), mdb.SizeInBytes())
+
+	_ = mdb.Put([]byte("key1"), []byte("v"))
+	_ = mdb.Remove([]byte("key2"))
+	_ = mdb.Close()
+	assert.Equal(t, 1, mdb.Len())
+	assert.Equal(t, uint64(5), mdb.SizeInBytes())
+	assert.NotContains(t, monitoring.ListDiagnosticsProviders(), name)
+}
+
+func TestDB_DumpAndLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "dump")
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte{})
+	_ = mdb.Put([]byte{}, []byte("empty key"))
+	err := mdb.DumpToFile(path)
+	assert.Nil(t, err)
+
+	loaded := memorydb.New()
+	_ = loaded.Put([]byte("other"), []byte("value"))
+	err = loaded.LoadFromFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, mdb.Snapshot(), loaded.Snapshot())
+
+	content, _ := os.ReadFile(path)
+	err = os.WriteFile(path, content[:len(content)-3], 0644)
+	assert.Nil(t, err)
+	err = loaded.LoadFromFile(path)
+	assert.True(t, errors.Is(err, common.ErrInvalidDumpFormat))
+	assert.Equal(t, 3, loaded.Len())
+
+	err = os.WriteFile(path, []byte("not a dump"), 0644)
+	assert.Nil(t, err)
+	err = loaded.LoadFromFile(path)
+	assert.True(t, errors.Is(err, common.ErrInvalidDumpFormat))
+}
+
+func TestDB_Capabilities(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New(memorydb.WithBatchMode(0))
+	_ = mdb.MultiPut(map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2"), "key3": []byte("value3")})
+
+	values, err := mdb.GetMany([][]byte{[]byte("key1"), []byte("key2"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}, values)
+
+	mdb.Flush()
+	view, err := mdb.TakeSnapshot()
+	assert.Nil(t, err)
+	defer mdb.ReleaseSnapshot(view)
+
+	assert.Nil(t, mdb.MultiRemove([][]byte{[]byte("key1"), []byte("key2")}))
+	assert.NotNil(t, mdb.Has([]byte("key1")))
+	mdb.Flush()
+	assert.Equal(t, map[string][]byte{"key3": []byte("value3")}, mdb.Snapshot())
+
+	// the view is not affected by the removals
+	assert.Nil(t, view.Has([]byte("key1")))
+	numKeys := 0
+	view.RangeKeys(func(key []byte, val []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 3, numKeys)
+}
+
+func TestDB_NewIterator(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.MultiPut(map[string][]byte{"b": []byte("2"), "d": []byte("4"), "a": []byte("1")})
+
+	it, err := mdb.NewIterator()
+	assert.Nil(t, err)
+	_ = mdb.Put([]byte("c"), []byte("3"))
+
+	keys := make([]string, 0)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.Equal(t, []string{"a", "b", "d"}, keys)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Key())
+
+	assert.True(t, it.Seek([]byte("c")))
+	assert.Equal(t, []byte("d"), it.Key())
+	assert.Equal(t, []byte("4"), it.Value())
+	assert.True(t, it.Seek([]byte("a")))
+	assert.True(t, it.Next())
+	assert.Equal(t, []byte("b"), it.Key())
+	assert.False(t, it.Seek([]byte("e")))
+	assert.True(t, it.Prev())
+	assert.Equal(t, []byte("d"), it.Key())
+
+	keys = make([]string, 0)
+	for ok := it.Last(); ok; ok = it.Prev() {
+		keys = append(keys, string(it.Key()))
+	}
+	assert.Equal(t, []string{"d", "b", "a"}, keys)
+	assert.False(t, it.Prev())
+	assert.Nil(t, it.Key())
+	assert.Nil(t, it.Close())
+}
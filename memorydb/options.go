@@ -0,0 +1,141 @@
+package memorydb
+
+// EvictionPolicy selects the entries evicted from a bounded memorydb
+type EvictionPolicy string
+
+const (
+	// LRUEviction evicts the least recently read or written entries first
+	LRUEviction EvictionPolicy = "LRU"
+	// FIFOEviction evicts the oldest added entries first
+	FIFOEviction EvictionPolicy = "FIFO"
+)
+
+// DefaultNumShards is the number of shards of the unbounded memorydb created without a specific number of shards. The
+// bounded ones default to a single shard, so that the eviction order is kept across all the keys
+const DefaultNumShards = 16
+
+// Option customizes the memorydb created by New
+type Option func(options *options)
+
+type options struct {
+	batchMode      bool
+	maxBatchSize   int
+	numShards      int
+	maxEntries     int
+	maxBytes       uint64
+	evictionPolicy EvictionPolicy
+	lockFreeReads  bool
+	monitoringName string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		evictionPolicy: LRUEviction,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+func (o *options) isBounded() bool {
+	return o.maxEntries > 0 || o.maxBytes > 0
+}
+
+func (o *options) getNumShards() int {
+	if o.numShards > 0 {
+		return o.numShards
+	}
+	if o.isBounded() {
+		return 1
+	}
+
+	return DefaultNumShards
+}
+
+// asOptions returns the options creating a memorydb identical to the one created with these options, except for the
+// monitoring, so that the copies do not replace the monitored memorydb
+func (o *options) asOptions() []Option {
+	return []Option{
+		WithNumShards(o.numShards),
+		WithMaxEntries(o.maxEntries),
+		WithMaxBytes(o.maxBytes),
+		WithEvictionPolicy(o.evictionPolicy),
+		withBatchMode(o.batchMode, o.maxBatchSize),
+		withLockFreeReads(o.lockFreeReads),
+	}
+}
+
+func withLockFreeReads(lockFreeReads bool) Option {
+	return func(options *options) {
+		options.lockFreeReads = lockFreeReads
+	}
+}
+
+func withBatchMode(batchMode bool, maxBatchSize int) Option {
+	return func(options *options) {
+		options.batchMode = batchMode
+		options.maxBatchSize = maxBatchSize
+	}
+}
+
+// WithBatchMode makes the memorydb hold the writes in a pending batch, flushed by Flush, by Close or, if maxBatchSize
+// is positive, as soon as it holds maxBatchSize writes, so that the batching behavior of the leveldb persisters can be
+// exercised without a disk
+func WithBatchMode(maxBatchSize int) Option {
+	return withBatchMode(true, maxBatchSize)
+}
+
+// WithNumShards sets the number of shards the keys are spread over by their hash. The bounds of a sharded memorydb
+// apply to each shard, split evenly, so the eviction order is only kept among the keys of the same shard
+func WithNumShards(numShards int) Option {
+	return func(options *options) {
+		if numShards > 0 {
+			options.numShards = numShards
+		}
+	}
+}
+
+// WithMaxEntries bounds the memorydb to the provided number of entries, the exceeding ones being evicted. A zero
+// value leaves the number of entries unbounded
+func WithMaxEntries(maxEntries int) Option {
+	return func(options *options) {
+		if maxEntries > 0 {
+			options.maxEntries = maxEntries
+		}
+	}
+}
+
+// WithMaxBytes bounds the memorydb to the provided size of its keys and values, the exceeding entries being evicted.
+// A zero value leaves the size unbounded
+func WithMaxBytes(maxBytes uint64) Option {
+	return func(options *options) {
+		options.maxBytes = maxBytes
+	}
+}
+
+// WithEvictionPolicy sets the policy of a bounded memorydb, LRUEviction being the default one
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(options *options) {
+		if policy == LRUEviction || policy == FIFOEviction {
+			options.evictionPolicy = policy
+		}
+	}
+}
+
+// WithLockFreeReads makes Get and Has read an atomic snapshot of the shard of the key, published by each write, instead
+// of locking the shard. As each write copies the entries of its shard, it suits the read mostly workloads, with enough
+// shards to keep them small. The reads of a bounded memorydb do not mark the entries as recently used, so the LRU
+// eviction considers only the writes
+func WithLockFreeReads() Option {
+	return withLockFreeReads(true)
+}
+
+// WithMonitoring registers the memorydb as a monitored persister under the provided name, making its number of entries
+// and size available to the monitoring debug handler, until closed
+func WithMonitoring(name string) Option {
+	return func(options *options) {
+		options.monitoringName = name
+	}
+}
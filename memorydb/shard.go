@@ -0,0 +1,216 @@
+package memorydb
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// shard holds the entries of the keys hashed to it. The bounded shards keep their keys in eviction order, the next
+// evicted one at the back
+type shard struct {
+	mut      sync.RWMutex
+	entries  map[string][]byte
+	numBytes uint64
+	// frozen is set while the entries map is shared with a view, so that the next write copies it first
+	frozen bool
+	// lockFreeReads makes the writers publish the entries map, frozen, on unlock, so that the reads load it without
+	// locking. Each write copies the entries of the shard, as the published map is shared with the readers
+	lockFreeReads bool
+	published     atomic.Pointer[map[string][]byte]
+
+	maxEntries     int
+	maxBytes       uint64
+	evictionPolicy EvictionPolicy
+	order          *list.List
+	elements       map[string]*list.Element
+}
+
+func newShard(maxEntries int, maxBytes uint64, evictionPolicy EvictionPolicy, lockFreeReads bool) *shard {
+	sh := &shard{
+		maxEntries:     maxEntries,
+		maxBytes:       maxBytes,
+		evictionPolicy: evictionPolicy,
+		lockFreeReads:  lockFreeReads,
+	}
+	sh.resetNoLock()
+	sh.publishNoLock()
+
+	return sh
+}
+
+// lock takes the write lock of the shard, to be released by unlock
+func (sh *shard) lock() {
+	sh.mut.Lock()
+}
+
+// unlock publishes the written entries to the lock free readers, if enabled, then releases the write lock
+func (sh *shard) unlock() {
+	sh.publishNoLock()
+	sh.mut.Unlock()
+}
+
+func (sh *shard) publishNoLock() {
+	if !sh.lockFreeReads {
+		return
+	}
+
+	entries := sh.freezeNoLock()
+	sh.published.Store(&entries)
+}
+
+func (sh *shard) isBounded() bool {
+	return sh.maxEntries > 0 || sh.maxBytes > 0
+}
+
+func (sh *shard) resetNoLock() {
+	sh.entries = make(map[string][]byte)
+	sh.frozen = false
+	sh.numBytes = 0
+	if sh.isBounded() {
+		sh.order = list.New()
+		sh.elements = make(map[string]*list.Element)
+	}
+}
+
+// putNoLock adds the value, then evicts the entries exceeding the bounds, sparing the added one, so that the last
+// written value is always stored
+func (sh *shard) putNoLock(key string, val []byte) {
+	sh.copyOnWriteNoLock()
+
+	oldVal, exists := sh.entries[key]
+	if exists {
+		sh.numBytes -= entrySize(key, oldVal)
+	}
+	sh.entries[key] = val
+	sh.numBytes += entrySize(key, val)
+
+	if sh.order == nil {
+		return
+	}
+	if !exists {
+		sh.elements[key] = sh.order.PushFront(key)
+	} else if sh.evictionPolicy == LRUEviction {
+		sh.order.MoveToFront(sh.elements[key])
+	}
+
+	sh.evictNoLock(key)
+}
+
+func (sh *shard) evictNoLock(spared string) {
+	for sh.isOverBounds() {
+		back := sh.order.Back()
+		if back.Value.(string) == spared {
+			back = back.Prev()
+		}
+		if back == nil {
+			return
+		}
+		sh.removeNoLock(back.Value.(string))
+	}
+}
+
+func (sh *shard) isOverBounds() bool {
+	if sh.maxEntries > 0 && len(sh.entries) > sh.maxEntries {
+		return true
+	}
+
+	return sh.maxBytes > 0 && sh.numBytes > sh.maxBytes
+}
+
+// freezeNoLock returns the entries map, to be shared with a view until the next write
+func (sh *shard) freezeNoLock() map[string][]byte {
+	sh.frozen = true
+
+	return sh.entries
+}
+
+func (sh *shard) copyOnWriteNoLock() {
+	if !sh.frozen {
+		return
+	}
+
+	entries := make(map[string][]byte, len(sh.entries))
+	for key, val := range sh.entries {
+		entries[key] = val
+	}
+	sh.entries = entries
+	sh.frozen = false
+}
+
+func entrySize(key string, val []byte) uint64 {
+	return uint64(len(key) + len(val))
+}
+
+// get does not mark the key as recently used if the reads are lock free
+func (sh *shard) get(key string) ([]byte, bool) {
+	if sh.lockFreeReads {
+		val, ok := (*sh.published.Load())[key]
+		return val, ok
+	}
+	if sh.order != nil && sh.evictionPolicy == LRUEviction {
+		return sh.getAndTouch(key)
+	}
+
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+
+	val, ok := sh.entries[key]
+	return val, ok
+}
+
+// getAndTouch gets the value, marking it as the most recently used one
+func (sh *shard) getAndTouch(key string) ([]byte, bool) {
+	sh.mut.Lock()
+	defer sh.mut.Unlock()
+
+	val, ok := sh.entries[key]
+	if ok {
+		sh.order.MoveToFront(sh.elements[key])
+	}
+
+	return val, ok
+}
+
+func (sh *shard) has(key string) bool {
+	if sh.lockFreeReads {
+		_, ok := (*sh.published.Load())[key]
+		return ok
+	}
+
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+
+	_, ok := sh.entries[key]
+	return ok
+}
+
+func (sh *shard) removeNoLock(key string) {
+	val, ok := sh.entries[key]
+	if !ok {
+		return
+	}
+
+	sh.copyOnWriteNoLock()
+	delete(sh.entries, key)
+	sh.numBytes -= entrySize(key, val)
+	if sh.order != nil {
+		sh.order.Remove(sh.elements[key])
+		delete(sh.elements, key)
+	}
+}
+
+// rangeKeys returns false if the handler stopped the iteration
+func (sh *shard) rangeKeys(handler func(key []byte, value []byte) bool) bool {
+	sh.mut.RLock()
+	defer sh.mut.RUnlock()
+
+	for k, v := range sh.entries {
+		shouldContinue := handler([]byte(k), v)
+		if !shouldContinue {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package memorydb
+
+import (
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.PersisterView = (*View)(nil)
+
+// View is a read only, point in time view of a memorydb, not affected by the writes done after it was taken
+type View struct {
+	shards []map[string][]byte
+}
+
+// View returns a view of the current contents of the memorydb. Taking it does not copy the contents: the shards
+// are copied by the first write touching them afterwards, so that the view stays stable while the writers continue.
+// In batch mode, the view holds only the flushed writes
+func (s *DB) View() *View {
+	s.lockAll()
+	defer s.unlockAll()
+
+	view := &View{
+		shards: make([]map[string][]byte, len(s.shards)),
+	}
+	for i, sh := range s.shards {
+		view.shards[i] = sh.freezeNoLock()
+	}
+
+	return view
+}
+
+func (v *View) shardOf(key string) map[string][]byte {
+	if len(v.shards) == 1 {
+		return v.shards[0]
+	}
+
+	return v.shards[fnv32(key)%uint32(len(v.shards))]
+}
+
+// Get gets the value associated to the key, or reports an error
+func (v *View) Get(key []byte) ([]byte, error) {
+	val, ok := v.shardOf(string(key))[string(key)]
+	if !ok {
+		return nil, common.NewKeyNotFoundError(key, "", "")
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the given key is present in the view
+func (v *View) Has(key []byte) error {
+	_, ok := v.shardOf(string(key))[string(key)]
+	if !ok {
+		return common.NewKeyNotFoundError(key, "", "")
+	}
+
+	return nil
+}
+
+// Len returns the number of entries in the view
+func (v *View) Len() int {
+	numEntries := 0
+	for _, entries := range v.shards {
+		numEntries += len(entries)
+	}
+
+	return numEntries
+}
+
+// RangeKeys will iterate over all the (key, value) pairs of the view calling the provided handler
+func (v *View) RangeKeys(handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	for _, entries := range v.shards {
+		for key, val := range entries {
+			shouldContinue := handler([]byte(key), val)
+			if !shouldContinue {
+				return
+			}
+		}
+	}
+}
+
+// Release does nothing, as the view holds no resources besides the memory released once it is no longer referenced
+func (v *View) Release() {
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (v *View) IsInterfaceNil() bool {
+	return v == nil
+}
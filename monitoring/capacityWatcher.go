@@ -0,0 +1,221 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// CapacityDimension names the capacity of a cache compared against its watermark
+type CapacityDimension string
+
+const (
+	// CountDimension is the number of items, compared against the maximum size of the cache
+	CountDimension CapacityDimension = "count"
+	// BytesDimension is the size in bytes of the items, compared against the size monitored when the cache was created
+	BytesDimension CapacityDimension = "bytes"
+)
+
+// DefaultCapacityWatermarkPercentage is the watermark of the caches registered without a specific one
+const DefaultCapacityWatermarkPercentage = 85
+
+// CapacityWatermark holds the percentages of the capacities above which a cache is reported. A zero percentage
+// disables the reporting of the corresponding dimension
+type CapacityWatermark struct {
+	CountPercentage uint32
+	BytesPercentage uint32
+}
+
+func (watermark CapacityWatermark) percentage(dimension CapacityDimension) uint32 {
+	if dimension == CountDimension {
+		return watermark.CountPercentage
+	}
+
+	return watermark.BytesPercentage
+}
+
+func (watermark CapacityWatermark) validate() error {
+	if watermark.CountPercentage > 100 || watermark.BytesPercentage > 100 {
+		return fmt.Errorf("%w: the watermark percentages should not exceed 100", common.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// ArgsCapacityWatcher holds the arguments needed to create a CapacityWatcher
+type ArgsCapacityWatcher struct {
+	SamplingInterval time.Duration
+	// DefaultWatermark applies to the caches registered without a specific watermark. If not provided, both
+	// percentages default to DefaultCapacityWatermarkPercentage
+	DefaultWatermark *CapacityWatermark
+}
+
+type watchedCache struct {
+	cache     types.BoundedCache
+	watermark CapacityWatermark
+	exceeded  map[CapacityDimension]bool
+}
+
+// CapacityWatcher samples the usage of the registered caches at a fixed interval, publishing a CapacityWatermarkEvent
+// and logging whenever a cache crosses its capacity watermark, either way, so that the evictions can be anticipated.
+// The sampling go routine is stopped by calling Close
+type CapacityWatcher struct {
+	samplingInterval time.Duration
+	defaultWatermark CapacityWatermark
+	cancelFunc       func()
+
+	mutCaches sync.Mutex
+	caches    map[string]*watchedCache
+}
+
+// NewCapacityWatcher creates a new capacity watcher and starts its sampling go routine
+func NewCapacityWatcher(args ArgsCapacityWatcher) (*CapacityWatcher, error) {
+	if args.SamplingInterval <= 0 {
+		return nil, fmt.Errorf("%w: SamplingInterval should be positive", common.ErrInvalidConfig)
+	}
+
+	defaultWatermark := CapacityWatermark{
+		CountPercentage: DefaultCapacityWatermarkPercentage,
+		BytesPercentage: DefaultCapacityWatermarkPercentage,
+	}
+	if args.DefaultWatermark != nil {
+		err := args.DefaultWatermark.validate()
+		if err != nil {
+			return nil, err
+		}
+		defaultWatermark = *args.DefaultWatermark
+	}
+
+	cw := &CapacityWatcher{
+		samplingInterval: args.SamplingInterval,
+		defaultWatermark: defaultWatermark,
+		caches:           make(map[string]*watchedCache),
+	}
+
+	var ctx context.Context
+	ctx, cw.cancelFunc = context.WithCancel(context.Background())
+	go cw.startSampling(ctx)
+
+	return cw, nil
+}
+
+// Register adds a cache to be watched with the default watermark, under the name it was monitored with, its bytes
+// capacity being the size monitored when it was created. A cache registered later under the same name replaces the
+// previous one
+func (cw *CapacityWatcher) Register(name string, cache types.BoundedCache) {
+	_ = cw.RegisterWithWatermark(name, cache, cw.defaultWatermark)
+}
+
+// RegisterWithWatermark adds a cache to be watched with the provided watermark
+func (cw *CapacityWatcher) RegisterWithWatermark(name string, cache types.BoundedCache, watermark CapacityWatermark) error {
+	if check.IfNil(cache) {
+		return common.ErrNilCacher
+	}
+	err := watermark.validate()
+	if err != nil {
+		return err
+	}
+
+	cw.mutCaches.Lock()
+	cw.caches[name] = &watchedCache{
+		cache:     cache,
+		watermark: watermark,
+		exceeded:  make(map[CapacityDimension]bool),
+	}
+	cw.mutCaches.Unlock()
+
+	return nil
+}
+
+// Unregister removes the cache registered under the provided name
+func (cw *CapacityWatcher) Unregister(name string) {
+	cw.mutCaches.Lock()
+	delete(cw.caches, name)
+	cw.mutCaches.Unlock()
+}
+
+func (cw *CapacityWatcher) startSampling(ctx context.Context) {
+	timer := time.NewTimer(cw.samplingInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(cw.samplingInterval)
+
+		select {
+		case <-timer.C:
+			cw.checkCapacities()
+		case <-ctx.Done():
+			log.Debug("closing CapacityWatcher's sampling go routine...")
+			return
+		}
+	}
+}
+
+// checkCapacities compares the usage of the registered caches against their watermarks, returning the number of
+// published events
+func (cw *CapacityWatcher) checkCapacities() int {
+	cw.mutCaches.Lock()
+	defer cw.mutCaches.Unlock()
+
+	names := make([]string, 0, len(cw.caches))
+	for name := range cw.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	numEvents := 0
+	for _, name := range names {
+		watched := cw.caches[name]
+		numEvents += watched.check(name, CountDimension, uint64(watched.cache.Len()), uint64(watched.cache.MaxSize()))
+
+		bytesCapacity, ok := registry.capacityOf(name)
+		if ok {
+			numEvents += watched.check(name, BytesDimension, watched.cache.SizeInBytesContained(), bytesCapacity)
+		}
+	}
+
+	return numEvents
+}
+
+// check returns 1 if the usage crossed the watermark since the previous check, 0 otherwise
+func (watched *watchedCache) check(name string, dimension CapacityDimension, used uint64, capacity uint64) int {
+	percentage := watched.watermark.percentage(dimension)
+	if percentage == 0 || capacity == 0 {
+		return 0
+	}
+
+	exceeded := used*100 > capacity*uint64(percentage)
+	if exceeded == watched.exceeded[dimension] {
+		return 0
+	}
+	watched.exceeded[dimension] = exceeded
+
+	if exceeded {
+		log.Warn("CapacityWatcher: cache above its capacity watermark",
+			"name", name, "dimension", dimension, "used", used, "capacity", capacity, "watermark", percentage)
+	} else {
+		log.Info("CapacityWatcher: cache back below its capacity watermark",
+			"name", name, "dimension", dimension, "used", used, "capacity", capacity, "watermark", percentage)
+	}
+	PublishEvent(NewCapacityWatermarkEvent(name, dimension, used, capacity, exceeded))
+
+	return 1
+}
+
+// Close stops the sampling go routine. It is safe to call it multiple times
+func (cw *CapacityWatcher) Close() error {
+	cw.cancelFunc()
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cw *CapacityWatcher) IsInterfaceNil() bool {
+	return cw == nil
+}
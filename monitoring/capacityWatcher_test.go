@@ -0,0 +1,115 @@
+package monitoring
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type boundedCacheStub struct {
+	numItems int64
+	numBytes uint64
+	maxSize  int
+}
+
+// Len -
+func (stub *boundedCacheStub) Len() int {
+	return int(atomic.LoadInt64(&stub.numItems))
+}
+
+// SizeInBytesContained -
+func (stub *boundedCacheStub) SizeInBytesContained() uint64 {
+	return atomic.LoadUint64(&stub.numBytes)
+}
+
+// MaxSize -
+func (stub *boundedCacheStub) MaxSize() int {
+	return stub.maxSize
+}
+
+// IsInterfaceNil -
+func (stub *boundedCacheStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestNewCapacityWatcher(t *testing.T) {
+	t.Parallel()
+
+	watcher, err := NewCapacityWatcher(ArgsCapacityWatcher{})
+	assert.Nil(t, watcher)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	watcher, err = NewCapacityWatcher(ArgsCapacityWatcher{
+		SamplingInterval: time.Hour,
+		DefaultWatermark: &CapacityWatermark{CountPercentage: 101},
+	})
+	assert.Nil(t, watcher)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	watcher, err = NewCapacityWatcher(ArgsCapacityWatcher{SamplingInterval: time.Hour})
+	require.Nil(t, err)
+	assert.False(t, watcher.IsInterfaceNil())
+	assert.Equal(t, common.ErrNilCacher, watcher.RegisterWithWatermark("nil", nil, CapacityWatermark{}))
+	assert.True(t, errors.Is(watcher.RegisterWithWatermark("cache", &boundedCacheStub{}, CapacityWatermark{BytesPercentage: 200}), common.ErrInvalidConfig))
+	assert.Nil(t, watcher.Close())
+	assert.Nil(t, watcher.Close())
+}
+
+func TestCapacityWatcher_ShouldReportTheCrossedWatermarks(t *testing.T) {
+	t.Parallel()
+
+	name := t.Name()
+	MonitorNewCache(name, 1000)
+	defer MonitorClosedCache(name, 1000)
+
+	chEvents := make(chan *CapacityWatermarkEvent, 10)
+	GlobalEventBus().Subscribe(name, func(event Event) {
+		watermarkEvent, ok := event.(*CapacityWatermarkEvent)
+		if ok && watermarkEvent.Name == name {
+			chEvents <- watermarkEvent
+		}
+	})
+	defer GlobalEventBus().Unsubscribe(name)
+
+	watcher, _ := NewCapacityWatcher(ArgsCapacityWatcher{SamplingInterval: time.Hour})
+	defer func() {
+		_ = watcher.Close()
+	}()
+	cache := &boundedCacheStub{maxSize: 100}
+	watcher.Register(name, cache)
+	assert.Equal(t, 0, watcher.checkCapacities())
+
+	atomic.StoreInt64(&cache.numItems, 86)
+	atomic.StoreUint64(&cache.numBytes, 850)
+	assert.Equal(t, 1, watcher.checkCapacities())
+	assert.Equal(t, 0, watcher.checkCapacities())
+
+	atomic.StoreUint64(&cache.numBytes, 900)
+	assert.Equal(t, 1, watcher.checkCapacities())
+	atomic.StoreInt64(&cache.numItems, 10)
+	assert.Equal(t, 1, watcher.checkCapacities())
+
+	// the handlers are called on their own go routines, so the events might be received in any order
+	received := make(map[CapacityWatermarkEvent]int)
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-chEvents:
+			received[CapacityWatermarkEvent{Dimension: event.Dimension, Exceeded: event.Exceeded}]++
+		case <-time.After(time.Second):
+			require.Fail(t, "the crossed watermarks should have been published")
+		}
+	}
+	assert.Equal(t, map[CapacityWatermarkEvent]int{
+		{Dimension: CountDimension, Exceeded: true}:  1,
+		{Dimension: CountDimension, Exceeded: false}: 1,
+		{Dimension: BytesDimension, Exceeded: true}:  1,
+	}, received)
+
+	watcher.Unregister(name)
+	assert.Equal(t, 0, watcher.checkCapacities())
+}
@@ -0,0 +1,170 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type diagnosticsProviders struct {
+	mut       sync.RWMutex
+	providers map[string]types.DiagnosticsProvider
+}
+
+var providers = &diagnosticsProviders{
+	providers: make(map[string]types.DiagnosticsProvider),
+}
+
+// RegisterDiagnosticsProvider makes the diagnostics of the provided component available to the debug handler, under
+// the provided name. A provider registered later under the same name replaces the previous one
+func RegisterDiagnosticsProvider(name string, provider types.DiagnosticsProvider) {
+	if check.IfNil(provider) {
+		return
+	}
+
+	providers.mut.Lock()
+	providers.providers[name] = provider
+	providers.mut.Unlock()
+}
+
+// DeregisterDiagnosticsProvider removes the provider registered under the provided name, if it was not replaced since
+func DeregisterDiagnosticsProvider(name string, provider types.DiagnosticsProvider) {
+	providers.mut.Lock()
+	defer providers.mut.Unlock()
+
+	if providers.providers[name] == provider {
+		delete(providers.providers, name)
+	}
+}
+
+// ListDiagnosticsProviders returns the sorted names of the registered diagnostics providers
+func ListDiagnosticsProviders() []string {
+	providers.mut.RLock()
+	defer providers.mut.RUnlock()
+
+	names := make([]string, 0, len(providers.providers))
+	for name := range providers.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (dp *diagnosticsProviders) snapshot() map[string]interface{} {
+	dp.mut.RLock()
+	registered := make(map[string]types.DiagnosticsProvider, len(dp.providers))
+	for name, provider := range dp.providers {
+		registered[name] = provider
+	}
+	dp.mut.RUnlock()
+
+	// the snapshots are taken outside the lock, as the providers might be slow
+	snapshots := make(map[string]interface{}, len(registered))
+	for name, provider := range registered {
+		snapshots[name] = provider.DiagnosticsSnapshot()
+	}
+
+	return snapshots
+}
+
+func (dp *diagnosticsProviders) snapshotOf(name string) (interface{}, bool) {
+	dp.mut.RLock()
+	provider, ok := dp.providers[name]
+	dp.mut.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return provider.DiagnosticsSnapshot(), true
+}
+
+// DebugReport holds the storage internals exposed by the debug handler
+type DebugReport struct {
+	Caches      []CacheInfo                                       `json:"caches"`
+	DBs         []DBInfo                                          `json:"dbs"`
+	Latencies   map[string]map[PersisterOperation]LatencySnapshot `json:"latencies"`
+	HitRatios   HitRatioSummary                                   `json:"hitRatios"`
+	Diagnostics map[string]interface{}                            `json:"diagnostics"`
+	Stats       map[string]map[string]interface{}                 `json:"stats"`
+	Health      types.HealthStatus                                `json:"health"`
+}
+
+// debugHandler serves the DebugReport as JSON
+type debugHandler struct{}
+
+// NewDebugHandler creates a http.Handler serving the monitored caches, the opened persisters, their latencies, the
+// diagnostics, the stats and the health of the registered providers as JSON. It is meant to be mounted by the node under
+// /debug/storage. The "section" query parameter (caches, dbs, latencies, hitratios, diagnostics, stats or health) restricts the response to one part of the
+// report, while the "name" query parameter restricts the diagnostics to the provider registered under that name
+func NewDebugHandler() http.Handler {
+	return &debugHandler{}
+}
+
+// ServeHTTP writes the requested part of the report
+func (handler *debugHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := request.URL.Query()
+	name := query.Get("name")
+	if len(name) > 0 {
+		snapshot, ok := providers.snapshotOf(name)
+		if !ok {
+			http.Error(writer, "unknown diagnostics provider", http.StatusNotFound)
+			return
+		}
+		writeJSON(writer, snapshot)
+		return
+	}
+
+	var response interface{}
+	switch query.Get("section") {
+	case "":
+		response = DebugReport{
+			Caches:      ListCaches(),
+			DBs:         ListDBs(),
+			Latencies:   AllPersisterLatencies(),
+			HitRatios:   HitRatioReport(),
+			Diagnostics: providers.snapshot(),
+			Stats:       CollectStats(),
+			Health:      CollectHealth(),
+		}
+	case "caches":
+		response = ListCaches()
+	case "dbs":
+		response = ListDBs()
+	case "latencies":
+		response = AllPersisterLatencies()
+	case "hitratios":
+		response = HitRatioReport()
+	case "diagnostics":
+		response = providers.snapshot()
+	case "stats":
+		response = CollectStats()
+	case "health":
+		response = CollectHealth()
+	default:
+		http.Error(writer, "unknown section", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(writer, response)
+}
+
+func writeJSON(writer http.ResponseWriter, response interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(response)
+	if err != nil {
+		log.Debug("debugHandler.ServeHTTP: could not write the response", "error", err)
+	}
+}
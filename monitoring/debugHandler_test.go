@@ -0,0 +1,100 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type diagnosticsProviderStub struct {
+	snapshot interface{}
+}
+
+// DiagnosticsSnapshot -
+func (stub *diagnosticsProviderStub) DiagnosticsSnapshot() interface{} {
+	return stub.snapshot
+}
+
+// IsInterfaceNil -
+func (stub *diagnosticsProviderStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func serveDebugRequest(method string, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	NewDebugHandler().ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+
+	return recorder
+}
+
+func TestDebugHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	cacheName := "TestDebugHandler_ServeHTTP"
+	MonitorNewCache(cacheName, 64)
+	defer MonitorClosedCache(cacheName, 64)
+	MonitorNewDB("MemoryDB", "TestDebugHandler_ServeHTTP/db")
+
+	provider := &diagnosticsProviderStub{snapshot: map[string]int{"numTxs": 7}}
+	RegisterDiagnosticsProvider(cacheName, provider)
+	defer DeregisterDiagnosticsProvider(cacheName, provider)
+	assert.Contains(t, ListDiagnosticsProviders(), cacheName)
+
+	t.Run("full report", func(t *testing.T) {
+		recorder := serveDebugRequest(http.MethodGet, "/debug/storage")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+		report := DebugReport{}
+		require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+		assert.Contains(t, report.Caches, CacheInfo{Name: cacheName, SizeInBytes: 64, NumInstances: 1})
+		assert.Contains(t, report.DBs, DBInfo{Type: "MemoryDB", Path: "TestDebugHandler_ServeHTTP/db", NumOpenings: 1})
+		assert.Equal(t, map[string]interface{}{"numTxs": float64(7)}, report.Diagnostics[cacheName])
+	})
+	t.Run("one section", func(t *testing.T) {
+		recorder := serveDebugRequest(http.MethodGet, "/debug/storage?section=caches")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		caches := make([]CacheInfo, 0)
+		require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &caches))
+		assert.Contains(t, caches, CacheInfo{Name: cacheName, SizeInBytes: 64, NumInstances: 1})
+	})
+	t.Run("one provider", func(t *testing.T) {
+		recorder := serveDebugRequest(http.MethodGet, "/debug/storage?name="+cacheName)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"numTxs": 7}`, recorder.Body.String())
+
+		recorder = serveDebugRequest(http.MethodGet, "/debug/storage?name=missing")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+	t.Run("invalid requests", func(t *testing.T) {
+		recorder := serveDebugRequest(http.MethodGet, "/debug/storage?section=unknown")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		recorder = serveDebugRequest(http.MethodPost, "/debug/storage")
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	})
+}
+
+func TestDeregisterDiagnosticsProvider_ShouldKeepTheReplacingProvider(t *testing.T) {
+	t.Parallel()
+
+	name := "TestDeregisterDiagnosticsProvider_ShouldKeepTheReplacingProvider"
+	first := &diagnosticsProviderStub{snapshot: 1}
+	second := &diagnosticsProviderStub{snapshot: 2}
+	RegisterDiagnosticsProvider(name, first)
+	RegisterDiagnosticsProvider(name, second)
+
+	DeregisterDiagnosticsProvider(name, first)
+	snapshot, found := providers.snapshotOf(name)
+	assert.True(t, found)
+	assert.Equal(t, 2, snapshot)
+
+	DeregisterDiagnosticsProvider(name, second)
+	_, found = providers.snapshotOf(name)
+	assert.False(t, found)
+}
@@ -0,0 +1,246 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType names a kind of storage event
+type EventType string
+
+const (
+	// CacheCreatedEventType is published when a monitored cache is created
+	CacheCreatedEventType EventType = "CacheCreated"
+	// EvictionStormEventType is published when a large part of a cache is evicted at once
+	EvictionStormEventType EventType = "EvictionStorm"
+	// DBReopenedEventType is published when a persister is opened at a path already opened before
+	DBReopenedEventType EventType = "DBReopened"
+	// CorruptionDetectedEventType is published when corrupted stored data is detected
+	CorruptionDetectedEventType EventType = "CorruptionDetected"
+	// CapacityWatermarkEventType is published when the usage of a cache crosses its capacity watermark, either way
+	CapacityWatermarkEventType EventType = "CapacityWatermark"
+	// EpochRotatedEventType is published when an epoch aware storer moves its active epochs window
+	EpochRotatedEventType EventType = "EpochRotated"
+	// BatchWriteFailedEventType is published when a persister fails to write a batch to the disk
+	BatchWriteFailedEventType EventType = "BatchWriteFailed"
+)
+
+// Event is a storage event published on the event bus
+type Event interface {
+	Type() EventType
+	Timestamp() time.Time
+}
+
+type baseEvent struct {
+	timestamp time.Time
+}
+
+// Timestamp returns the time the event was created
+func (event *baseEvent) Timestamp() time.Time {
+	return event.timestamp
+}
+
+func newBaseEvent() baseEvent {
+	return baseEvent{timestamp: time.Now()}
+}
+
+// CacheCreatedEvent signals the creation of a monitored cache
+type CacheCreatedEvent struct {
+	baseEvent
+	Name        string
+	SizeInBytes uint64
+}
+
+// NewCacheCreatedEvent creates a new CacheCreatedEvent
+func NewCacheCreatedEvent(name string, sizeInBytes uint64) *CacheCreatedEvent {
+	return &CacheCreatedEvent{baseEvent: newBaseEvent(), Name: name, SizeInBytes: sizeInBytes}
+}
+
+// Type returns CacheCreatedEventType
+func (event *CacheCreatedEvent) Type() EventType {
+	return CacheCreatedEventType
+}
+
+// EvictionStormEvent signals that a large part of a cache was evicted at once
+type EvictionStormEvent struct {
+	baseEvent
+	Name       string
+	NumEvicted int
+	Duration   time.Duration
+	Reason     string
+}
+
+// NewEvictionStormEvent creates a new EvictionStormEvent
+func NewEvictionStormEvent(name string, numEvicted int, duration time.Duration, reason string) *EvictionStormEvent {
+	return &EvictionStormEvent{baseEvent: newBaseEvent(), Name: name, NumEvicted: numEvicted, Duration: duration, Reason: reason}
+}
+
+// Type returns EvictionStormEventType
+func (event *EvictionStormEvent) Type() EventType {
+	return EvictionStormEventType
+}
+
+// DBReopenedEvent signals that a persister was opened at a path already opened before
+type DBReopenedEvent struct {
+	baseEvent
+	DBType      string
+	Path        string
+	NumOpenings int
+}
+
+// NewDBReopenedEvent creates a new DBReopenedEvent
+func NewDBReopenedEvent(dbType string, path string, numOpenings int) *DBReopenedEvent {
+	return &DBReopenedEvent{baseEvent: newBaseEvent(), DBType: dbType, Path: path, NumOpenings: numOpenings}
+}
+
+// Type returns DBReopenedEventType
+func (event *DBReopenedEvent) Type() EventType {
+	return DBReopenedEventType
+}
+
+// CorruptionDetectedEvent signals corrupted stored data. Path is empty if the component detecting the corruption is
+// not aware of the persister path
+type CorruptionDetectedEvent struct {
+	baseEvent
+	Path      string
+	Err       error
+	Recovered bool
+}
+
+// NewCorruptionDetectedEvent creates a new CorruptionDetectedEvent
+func NewCorruptionDetectedEvent(path string, err error, recovered bool) *CorruptionDetectedEvent {
+	return &CorruptionDetectedEvent{baseEvent: newBaseEvent(), Path: path, Err: err, Recovered: recovered}
+}
+
+// Type returns CorruptionDetectedEventType
+func (event *CorruptionDetectedEvent) Type() EventType {
+	return CorruptionDetectedEventType
+}
+
+// CapacityWatermarkEvent signals that the usage of a cache crossed its capacity watermark: Exceeded is true when the
+// usage went above the watermark and false when it recovered
+type CapacityWatermarkEvent struct {
+	baseEvent
+	Name      string
+	Dimension CapacityDimension
+	Used      uint64
+	Capacity  uint64
+	Exceeded  bool
+}
+
+// NewCapacityWatermarkEvent creates a new CapacityWatermarkEvent
+func NewCapacityWatermarkEvent(name string, dimension CapacityDimension, used uint64, capacity uint64, exceeded bool) *CapacityWatermarkEvent {
+	return &CapacityWatermarkEvent{baseEvent: newBaseEvent(), Name: name, Dimension: dimension, Used: used, Capacity: capacity, Exceeded: exceeded}
+}
+
+// Type returns CapacityWatermarkEventType
+func (event *CapacityWatermarkEvent) Type() EventType {
+	return CapacityWatermarkEventType
+}
+
+// EpochRotatedEvent signals that an epoch aware storer moved its active epochs window to end at Epoch. Path is the
+// path template of the storer and RetiredEpochs holds the epochs left out of the window
+type EpochRotatedEvent struct {
+	baseEvent
+	Path          string
+	Epoch         uint32
+	RetiredEpochs []uint32
+}
+
+// NewEpochRotatedEvent creates a new EpochRotatedEvent
+func NewEpochRotatedEvent(path string, epoch uint32, retiredEpochs []uint32) *EpochRotatedEvent {
+	return &EpochRotatedEvent{baseEvent: newBaseEvent(), Path: path, Epoch: epoch, RetiredEpochs: retiredEpochs}
+}
+
+// Type returns EpochRotatedEventType
+func (event *EpochRotatedEvent) Type() EventType {
+	return EpochRotatedEventType
+}
+
+// BatchWriteFailedEvent signals that a persister failed to write a batch to the disk. Queued is true if the batch was
+// kept to be retried and false if its writes could not be kept, NumQueuedBatches being the number of the batches
+// waiting to be retried
+type BatchWriteFailedEvent struct {
+	baseEvent
+	Path             string
+	Err              error
+	NumQueuedBatches int
+	Queued           bool
+}
+
+// NewBatchWriteFailedEvent creates a new BatchWriteFailedEvent
+func NewBatchWriteFailedEvent(path string, err error, numQueuedBatches int, queued bool) *BatchWriteFailedEvent {
+	return &BatchWriteFailedEvent{baseEvent: newBaseEvent(), Path: path, Err: err, NumQueuedBatches: numQueuedBatches, Queued: queued}
+}
+
+// Type returns BatchWriteFailedEventType
+func (event *BatchWriteFailedEvent) Type() EventType {
+	return BatchWriteFailedEventType
+}
+
+// EventHandler is called with each published event. The handlers are called on their own go routines, so the
+// publishing components are never blocked by them
+type EventHandler func(event Event)
+
+// EventBus dispatches the published events to the subscribed handlers
+type EventBus struct {
+	mutHandlers sync.RWMutex
+	handlers    map[string]EventHandler
+}
+
+// NewEventBus creates a new event bus, for the components publishing their events on their own bus instead of the
+// global one
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[string]EventHandler),
+	}
+}
+
+// Subscribe registers the handler under the provided id, replacing the one already registered under the same id
+func (bus *EventBus) Subscribe(id string, handler EventHandler) {
+	if handler == nil {
+		log.Error("attempt to subscribe a nil event handler", "id", id)
+		return
+	}
+
+	bus.mutHandlers.Lock()
+	bus.handlers[id] = handler
+	bus.mutHandlers.Unlock()
+}
+
+// Unsubscribe removes the handler registered under the provided id
+func (bus *EventBus) Unsubscribe(id string) {
+	bus.mutHandlers.Lock()
+	delete(bus.handlers, id)
+	bus.mutHandlers.Unlock()
+}
+
+// Publish dispatches the event to all the subscribed handlers
+func (bus *EventBus) Publish(event Event) {
+	if event == nil {
+		return
+	}
+
+	bus.mutHandlers.RLock()
+	for _, handler := range bus.handlers {
+		go handler(event)
+	}
+	bus.mutHandlers.RUnlock()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (bus *EventBus) IsInterfaceNil() bool {
+	return bus == nil
+}
+
+var globalEventBus = NewEventBus()
+
+// GlobalEventBus returns the event bus the storage components publish their events on
+func GlobalEventBus() *EventBus {
+	return globalEventBus
+}
+
+// PublishEvent publishes the event on the global event bus
+func PublishEvent(event Event) {
+	globalEventBus.Publish(event)
+}
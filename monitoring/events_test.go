@@ -0,0 +1,85 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForEvent(t *testing.T, chEvents chan Event) Event {
+	select {
+	case event := <-chEvents:
+		return event
+	case <-time.After(time.Second):
+		require.Fail(t, "the event should have been published")
+		return nil
+	}
+}
+
+func TestEventBus_PublishShouldCallTheSubscribedHandlers(t *testing.T) {
+	t.Parallel()
+
+	bus := NewEventBus()
+	assert.False(t, bus.IsInterfaceNil())
+	bus.Subscribe("nil", nil)
+	bus.Publish(nil)
+
+	chEvents := make(chan Event, 10)
+	bus.Subscribe("id", func(event Event) {
+		chEvents <- event
+	})
+
+	errCorruption := errors.New("corruption")
+	bus.Publish(NewCorruptionDetectedEvent("path", errCorruption, true))
+	event := waitForEvent(t, chEvents)
+	assert.Equal(t, CorruptionDetectedEventType, event.Type())
+	assert.False(t, event.Timestamp().IsZero())
+	corruption, ok := event.(*CorruptionDetectedEvent)
+	require.True(t, ok)
+	assert.Equal(t, "path", corruption.Path)
+	assert.Equal(t, errCorruption, corruption.Err)
+	assert.True(t, corruption.Recovered)
+
+	bus.Unsubscribe("id")
+	bus.Publish(NewEvictionStormEvent("cache", 10, time.Second, "size"))
+	select {
+	case event = <-chEvents:
+		assert.Fail(t, "the unsubscribed handler should not have been called", event.Type())
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestGlobalEventBus_ShouldReceiveTheMonitoredEvents(t *testing.T) {
+	t.Parallel()
+
+	name := "TestGlobalEventBus_ShouldReceiveTheMonitoredEvents"
+	chEvents := make(chan Event, 100)
+	GlobalEventBus().Subscribe(name, func(event Event) {
+		switch typedEvent := event.(type) {
+		case *CacheCreatedEvent:
+			if typedEvent.Name == name {
+				chEvents <- event
+			}
+		case *DBReopenedEvent:
+			if typedEvent.Path == name {
+				chEvents <- event
+			}
+		}
+	})
+	defer GlobalEventBus().Unsubscribe(name)
+
+	MonitorNewCache(name, 10)
+	defer MonitorClosedCache(name, 10)
+	event := waitForEvent(t, chEvents)
+	assert.Equal(t, CacheCreatedEventType, event.Type())
+	assert.Equal(t, uint64(10), event.(*CacheCreatedEvent).SizeInBytes)
+
+	MonitorNewDB("LvlDBSerial", name)
+	MonitorNewDB("LvlDBSerial", name)
+	event = waitForEvent(t, chEvents)
+	assert.Equal(t, DBReopenedEventType, event.Type())
+	assert.Equal(t, 2, event.(*DBReopenedEvent).NumOpenings)
+}
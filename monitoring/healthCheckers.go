@@ -0,0 +1,85 @@
+package monitoring
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type healthCheckers struct {
+	mut      sync.RWMutex
+	checkers map[string]types.HealthChecker
+}
+
+var registeredHealthCheckers = &healthCheckers{
+	checkers: make(map[string]types.HealthChecker),
+}
+
+// RegisterHealthChecker makes the health of the provided cache, persister or storer part of CollectHealth, under the
+// provided name. A checker registered later under the same name replaces the previous one
+func RegisterHealthChecker(name string, checker types.HealthChecker) {
+	if check.IfNil(checker) {
+		return
+	}
+
+	registeredHealthCheckers.mut.Lock()
+	registeredHealthCheckers.checkers[name] = checker
+	registeredHealthCheckers.mut.Unlock()
+}
+
+// DeregisterHealthChecker removes the checker registered under the provided name, if it was not replaced since
+func DeregisterHealthChecker(name string, checker types.HealthChecker) {
+	registeredHealthCheckers.mut.Lock()
+	defer registeredHealthCheckers.mut.Unlock()
+
+	if registeredHealthCheckers.checkers[name] == checker {
+		delete(registeredHealthCheckers.checkers, name)
+	}
+}
+
+// CollectHealth returns the health of all the registered checkers, as the components of an overall status as bad as
+// the worst of them
+func CollectHealth() types.HealthStatus {
+	registeredHealthCheckers.mut.RLock()
+	registered := make(map[string]types.HealthChecker, len(registeredHealthCheckers.checkers))
+	for name, checker := range registeredHealthCheckers.checkers {
+		registered[name] = checker
+	}
+	registeredHealthCheckers.mut.RUnlock()
+
+	// the health is checked outside the lock, as the checkers might be slow
+	status := types.NewHealthStatus()
+	for name, checker := range registered {
+		status.AddComponent(name, checker.Health())
+	}
+
+	return status
+}
+
+// healthHandler serves the collected health as JSON
+type healthHandler struct{}
+
+// NewHealthHandler creates a http.Handler serving the health of the registered checkers as JSON, meant to be used by
+// the node's health endpoint. The status code is 503 if any checker failed, 200 otherwise, degraded included
+func NewHealthHandler() http.Handler {
+	return &healthHandler{}
+}
+
+// ServeHTTP writes the collected health
+func (handler *healthHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := CollectHealth()
+	if status.State == types.HealthFailed {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	writeJSON(writer, status)
+}
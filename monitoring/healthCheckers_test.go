@@ -0,0 +1,79 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type healthCheckerStub struct {
+	status types.HealthStatus
+}
+
+// Health -
+func (stub *healthCheckerStub) Health() types.HealthStatus {
+	return stub.status
+}
+
+// IsInterfaceNil -
+func (stub *healthCheckerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func serveHealthRequest(method string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	NewHealthHandler().ServeHTTP(recorder, httptest.NewRequest(method, "/health", nil))
+
+	return recorder
+}
+
+func TestHealthCheckers(t *testing.T) {
+	t.Parallel()
+
+	name := "TestHealthCheckers"
+	degraded := types.NewHealthStatus()
+	degraded.Degrade("slow")
+	failed := types.NewHealthStatus()
+	failed.Fail("db is closed")
+	first := &healthCheckerStub{status: degraded}
+	second := &healthCheckerStub{status: failed}
+
+	RegisterHealthChecker(name, nil)
+	assert.NotContains(t, CollectHealth().Components, name)
+
+	RegisterHealthChecker(name, first)
+	status := CollectHealth()
+	assert.Equal(t, types.HealthDegraded, status.Components[name].State)
+	assert.Contains(t, status.Reasons, name+" is Degraded")
+
+	recorder := serveHealthRequest(http.MethodGet)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	RegisterHealthChecker(name, second)
+	recorder = serveHealthRequest(http.MethodGet)
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	served := types.HealthStatus{}
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &served))
+	assert.Equal(t, types.HealthFailed, served.State)
+	assert.Equal(t, []string{"db is closed"}, served.Components[name].Reasons)
+
+	recorder = serveDebugRequest(http.MethodGet, "/debug/storage?section=health")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	served = types.HealthStatus{}
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &served))
+	assert.Equal(t, types.HealthFailed, served.Components[name].State)
+
+	DeregisterHealthChecker(name, first)
+	assert.Contains(t, CollectHealth().Components, name)
+	DeregisterHealthChecker(name, second)
+	assert.NotContains(t, CollectHealth().Components, name)
+
+	recorder = serveHealthRequest(http.MethodPost)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -0,0 +1,186 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHitRatioWindow is the default duration over which HitRatioReport aggregates the hits & misses
+	DefaultHitRatioWindow = 5 * time.Minute
+	// maxHitSamplesPerCache bounds the memory used by the caches reporting their stats very often
+	maxHitSamplesPerCache = 1024
+)
+
+type hitStats struct {
+	numHits   uint64
+	numMisses uint64
+}
+
+type hitSample struct {
+	timestamp time.Time
+	hitStats
+}
+
+// CacheHitRatio holds the hits & misses of a cache over the hit ratio window
+type CacheHitRatio struct {
+	Name      string
+	NumHits   uint64
+	NumMisses uint64
+	HitRatio  float64
+}
+
+// HitRatioSummary holds the hits & misses of all the caches reporting their stats, over the hit ratio window
+type HitRatioSummary struct {
+	Window    time.Duration
+	NumHits   uint64
+	NumMisses uint64
+	HitRatio  float64
+	// Caches holds the per cache breakdown, sorted by name
+	Caches []CacheHitRatio
+}
+
+// hitsRegistry holds, for each cache, the cumulated hits & misses reported within the window, along with the last
+// ones reported before it, used as baseline
+type hitsRegistry struct {
+	mut     sync.RWMutex
+	window  time.Duration
+	samples map[string][]hitSample
+	now     func() time.Time
+}
+
+var cacheHits = newHitsRegistry()
+
+func newHitsRegistry() *hitsRegistry {
+	return &hitsRegistry{
+		window:  DefaultHitRatioWindow,
+		samples: make(map[string][]hitSample),
+		now:     time.Now,
+	}
+}
+
+func (hr *hitsRegistry) record(name string, numHits uint64, numMisses uint64) {
+	hr.mut.Lock()
+	defer hr.mut.Unlock()
+
+	now := hr.now()
+	samples := append(hr.samples[name], hitSample{
+		timestamp: now,
+		hitStats:  hitStats{numHits: numHits, numMisses: numMisses},
+	})
+	hr.samples[name] = prunedSamples(samples, now.Add(-hr.window))
+}
+
+// prunedSamples drops the samples older than the baseline, the last one reported before the window start
+func prunedSamples(samples []hitSample, windowStart time.Time) []hitSample {
+	numOutside := 0
+	for numOutside < len(samples) && samples[numOutside].timestamp.Before(windowStart) {
+		numOutside++
+	}
+	if numOutside > 1 {
+		samples = samples[numOutside-1:]
+	}
+	if len(samples) > maxHitSamplesPerCache {
+		samples = samples[len(samples)-maxHitSamplesPerCache:]
+	}
+
+	return samples
+}
+
+// get returns the last cumulated hits & misses reported for the provided cache
+func (hr *hitsRegistry) get(name string) (hitStats, bool) {
+	hr.mut.RLock()
+	defer hr.mut.RUnlock()
+
+	samples := hr.samples[name]
+	if len(samples) == 0 {
+		return hitStats{}, false
+	}
+
+	return samples[len(samples)-1].hitStats, true
+}
+
+func (hr *hitsRegistry) setWindow(window time.Duration) {
+	hr.mut.Lock()
+	hr.window = window
+	hr.mut.Unlock()
+}
+
+func (hr *hitsRegistry) report() HitRatioSummary {
+	hr.mut.RLock()
+	defer hr.mut.RUnlock()
+
+	windowStart := hr.now().Add(-hr.window)
+	summary := HitRatioSummary{
+		Window: hr.window,
+		Caches: make([]CacheHitRatio, 0, len(hr.samples)),
+	}
+	for name, samples := range hr.samples {
+		delta := hitsInWindow(samples, windowStart)
+		summary.NumHits += delta.numHits
+		summary.NumMisses += delta.numMisses
+		summary.Caches = append(summary.Caches, CacheHitRatio{
+			Name:      name,
+			NumHits:   delta.numHits,
+			NumMisses: delta.numMisses,
+			HitRatio:  computeHitRatio(delta.numHits, delta.numMisses),
+		})
+	}
+	summary.HitRatio = computeHitRatio(summary.NumHits, summary.NumMisses)
+	sort.Slice(summary.Caches, func(i, j int) bool {
+		return summary.Caches[i].Name < summary.Caches[j].Name
+	})
+
+	return summary
+}
+
+// hitsInWindow returns the difference between the last sample and the baseline one. Without a baseline, the counters
+// are assumed to start from zero, the cache being created within the window
+func hitsInWindow(samples []hitSample, windowStart time.Time) hitStats {
+	last := samples[len(samples)-1]
+	if last.timestamp.Before(windowStart) {
+		return hitStats{}
+	}
+
+	baseline := hitStats{}
+	for _, sample := range samples {
+		if !sample.timestamp.Before(windowStart) {
+			break
+		}
+		baseline = sample.hitStats
+	}
+	if last.numHits < baseline.numHits || last.numMisses < baseline.numMisses {
+		// the counters were reset, as a new cache was created under the same name
+		return last.hitStats
+	}
+
+	return hitStats{
+		numHits:   last.numHits - baseline.numHits,
+		numMisses: last.numMisses - baseline.numMisses,
+	}
+}
+
+func computeHitRatio(numHits uint64, numMisses uint64) float64 {
+	numLookups := numHits + numMisses
+	if numLookups == 0 {
+		return 0
+	}
+
+	return float64(numHits) / float64(numLookups)
+}
+
+// SetHitRatioWindow sets the duration over which HitRatioReport aggregates the hits & misses
+func SetHitRatioWindow(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+
+	cacheHits.setWindow(window)
+}
+
+// HitRatioReport aggregates the hits & misses reported through MonitorCacheStats by all the caches, over the hit
+// ratio window, along with the per cache breakdown
+func HitRatioReport() HitRatioSummary {
+	return cacheHits.report()
+}
@@ -0,0 +1,66 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHitsRegistry_ReportShouldAggregateOverTheWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	registry := newHitsRegistry()
+	registry.window = time.Minute
+	registry.now = func() time.Time {
+		return now
+	}
+
+	registry.record("a", 10, 10)
+	registry.record("b", 5, 0)
+	now = now.Add(45 * time.Second)
+	registry.record("a", 40, 20)
+	now = now.Add(30 * time.Second)
+	registry.record("a", 100, 20)
+
+	summary := registry.report()
+	assert.Equal(t, time.Minute, summary.Window)
+	assert.Equal(t, []CacheHitRatio{
+		{Name: "a", NumHits: 90, NumMisses: 10, HitRatio: 0.9},
+		{Name: "b"},
+	}, summary.Caches)
+	assert.Equal(t, uint64(90), summary.NumHits)
+	assert.Equal(t, 0.9, summary.HitRatio)
+
+	// the counters of a cache recreated under the same name start from zero
+	registry.record("b", 1, 3)
+	summary = registry.report()
+	assert.Equal(t, CacheHitRatio{Name: "b", NumHits: 1, NumMisses: 3, HitRatio: 0.25}, summary.Caches[1])
+	assert.Equal(t, 91.0/104.0, summary.HitRatio)
+
+	// the samples older than the baseline are dropped
+	now = now.Add(31 * time.Second)
+	registry.record("a", 110, 20)
+	assert.Len(t, registry.samples["a"], 3)
+	assert.Equal(t, CacheHitRatio{Name: "a", NumHits: 70, NumMisses: 0, HitRatio: 1}, registry.report().Caches[0])
+
+	stats, found := registry.get("a")
+	assert.True(t, found)
+	assert.Equal(t, hitStats{numHits: 110, numMisses: 20}, stats)
+}
+
+func TestHitRatioReport(t *testing.T) {
+	t.Parallel()
+
+	name := "TestHitRatioReport"
+	MonitorCacheStats(name, 3, 1)
+
+	for _, cache := range HitRatioReport().Caches {
+		if cache.Name == name {
+			assert.Equal(t, CacheHitRatio{Name: name, NumHits: 3, NumMisses: 1, HitRatio: 0.75}, cache)
+			return
+		}
+	}
+	assert.Fail(t, "the cache should have been reported")
+}
@@ -0,0 +1,241 @@
+package monitoring
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PersisterOperation names an operation whose latency is measured
+type PersisterOperation string
+
+const (
+	// GetOperation is the lookup of a value
+	GetOperation PersisterOperation = "Get"
+	// PutOperation is the write of one or several values, in the pending batch
+	PutOperation PersisterOperation = "Put"
+	// HasOperation is the lookup of a key
+	HasOperation PersisterOperation = "Has"
+	// RemoveOperation is the removal of a key
+	RemoveOperation PersisterOperation = "Remove"
+	// FlushOperation is the write of the pending batch to the disk
+	FlushOperation PersisterOperation = "flush"
+)
+
+var persisterOperations = []PersisterOperation{GetOperation, PutOperation, HasOperation, RemoveOperation, FlushOperation}
+
+const (
+	// minLatencyBucket is the upper bound of the first bucket, the following ones doubling it
+	minLatencyBucket  = time.Microsecond
+	numLatencyBuckets = 26
+)
+
+// LatencyBucket holds the number of observations not exceeding UpperBound, nor the upper bound of the previous bucket
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// LatencySnapshot holds the state of a latency histogram at a moment in time
+type LatencySnapshot struct {
+	Count   uint64
+	Sum     time.Duration
+	Max     time.Duration
+	Buckets []LatencyBucket
+}
+
+// Quantile returns an upper bound of the provided quantile (e.g. 0.99) of the observations, the upper bound of the
+// bucket holding it. The observations above the last bucket are bounded by the maximum one
+func (snapshot LatencySnapshot) Quantile(quantile float64) time.Duration {
+	if snapshot.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(quantile * float64(snapshot.Count)))
+	if rank == 0 {
+		rank = 1
+	}
+	cumulated := uint64(0)
+	for _, bucket := range snapshot.Buckets {
+		cumulated += bucket.Count
+		if cumulated >= rank {
+			if bucket.UpperBound > snapshot.Max {
+				return snapshot.Max
+			}
+			return bucket.UpperBound
+		}
+	}
+
+	return snapshot.Max
+}
+
+// LatencyHistogram counts the observed latencies in exponential buckets, from 1µs up to about 33s. It is safe for
+// concurrent use and does not lock
+type LatencyHistogram struct {
+	// the last bucket holds the observations above the upper bound of the previous ones
+	buckets  [numLatencyBuckets + 1]uint64
+	count    uint64
+	sumNanos uint64
+	maxNanos uint64
+}
+
+// Observe adds a latency to the histogram
+func (histogram *LatencyHistogram) Observe(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+
+	atomic.AddUint64(&histogram.buckets[latencyBucketIndex(latency)], 1)
+	atomic.AddUint64(&histogram.count, 1)
+	atomic.AddUint64(&histogram.sumNanos, uint64(latency))
+	for {
+		currentMax := atomic.LoadUint64(&histogram.maxNanos)
+		if uint64(latency) <= currentMax || atomic.CompareAndSwapUint64(&histogram.maxNanos, currentMax, uint64(latency)) {
+			return
+		}
+	}
+}
+
+// Snapshot returns the current state of the histogram. As the counters are read one by one, the concurrent
+// observations might be partially reflected
+func (histogram *LatencyHistogram) Snapshot() LatencySnapshot {
+	snapshot := LatencySnapshot{
+		Count:   atomic.LoadUint64(&histogram.count),
+		Sum:     time.Duration(atomic.LoadUint64(&histogram.sumNanos)),
+		Max:     time.Duration(atomic.LoadUint64(&histogram.maxNanos)),
+		Buckets: make([]LatencyBucket, len(histogram.buckets)),
+	}
+	for i := range histogram.buckets {
+		snapshot.Buckets[i] = LatencyBucket{
+			UpperBound: latencyBucketUpperBound(i),
+			Count:      atomic.LoadUint64(&histogram.buckets[i]),
+		}
+	}
+
+	return snapshot
+}
+
+func latencyBucketIndex(latency time.Duration) int {
+	for i := 0; i < numLatencyBuckets; i++ {
+		if latency <= latencyBucketUpperBound(i) {
+			return i
+		}
+	}
+
+	return numLatencyBuckets
+}
+
+func latencyBucketUpperBound(index int) time.Duration {
+	if index >= numLatencyBuckets {
+		return time.Duration(1<<63 - 1)
+	}
+
+	return minLatencyBucket << uint(index)
+}
+
+// PersisterLatencies holds the latency histograms of the operations of a persister
+type PersisterLatencies struct {
+	histograms map[PersisterOperation]*LatencyHistogram
+}
+
+func newPersisterLatencies() *PersisterLatencies {
+	latencies := &PersisterLatencies{
+		histograms: make(map[PersisterOperation]*LatencyHistogram, len(persisterOperations)),
+	}
+	for _, operation := range persisterOperations {
+		latencies.histograms[operation] = &LatencyHistogram{}
+	}
+
+	return latencies
+}
+
+// ObserveSince adds the time elapsed since start to the histogram of the provided operation. It is meant to be
+// deferred at the beginning of the measured operation
+func (latencies *PersisterLatencies) ObserveSince(operation PersisterOperation, start time.Time) {
+	if latencies == nil {
+		return
+	}
+
+	histogram, ok := latencies.histograms[operation]
+	if ok {
+		histogram.Observe(time.Since(start))
+	}
+}
+
+// Snapshot returns the current state of the histograms of the operations observed at least once
+func (latencies *PersisterLatencies) Snapshot() map[PersisterOperation]LatencySnapshot {
+	snapshots := make(map[PersisterOperation]LatencySnapshot, len(latencies.histograms))
+	for operation, histogram := range latencies.histograms {
+		snapshot := histogram.Snapshot()
+		if snapshot.Count > 0 {
+			snapshots[operation] = snapshot
+		}
+	}
+
+	return snapshots
+}
+
+type latenciesRegistry struct {
+	mut        sync.RWMutex
+	persisters map[string]*PersisterLatencies
+}
+
+var persistersLatencies = &latenciesRegistry{
+	persisters: make(map[string]*PersisterLatencies),
+}
+
+// LatenciesForPersister returns the latency histograms of the persister stored at the provided path, creating them
+// if needed. The histograms outlive the persister, so that a reopened persister keeps accumulating in the same ones
+func LatenciesForPersister(path string) *PersisterLatencies {
+	persistersLatencies.mut.Lock()
+	defer persistersLatencies.mut.Unlock()
+
+	latencies, ok := persistersLatencies.persisters[path]
+	if !ok {
+		latencies = newPersisterLatencies()
+		persistersLatencies.persisters[path] = latencies
+	}
+
+	return latencies
+}
+
+// GetPersisterLatencies returns the latency snapshots of the persister stored at the provided path
+func GetPersisterLatencies(path string) (map[PersisterOperation]LatencySnapshot, bool) {
+	persistersLatencies.mut.RLock()
+	latencies, ok := persistersLatencies.persisters[path]
+	persistersLatencies.mut.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return latencies.Snapshot(), true
+}
+
+// ListPersistersWithLatencies returns the sorted paths of the persisters having latency histograms
+func ListPersistersWithLatencies() []string {
+	persistersLatencies.mut.RLock()
+	defer persistersLatencies.mut.RUnlock()
+
+	paths := make([]string, 0, len(persistersLatencies.persisters))
+	for path := range persistersLatencies.persisters {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// AllPersisterLatencies returns the latency snapshots of all the persisters, by path
+func AllPersisterLatencies() map[string]map[PersisterOperation]LatencySnapshot {
+	all := make(map[string]map[PersisterOperation]LatencySnapshot)
+	for _, path := range ListPersistersWithLatencies() {
+		snapshots, ok := GetPersisterLatencies(path)
+		if ok {
+			all[path] = snapshots
+		}
+	}
+
+	return all
+}
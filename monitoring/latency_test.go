@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyHistogram_Observe(t *testing.T) {
+	t.Parallel()
+
+	histogram := &LatencyHistogram{}
+	assert.Equal(t, time.Duration(0), histogram.Snapshot().Quantile(0.99))
+
+	for i := 0; i < 98; i++ {
+		histogram.Observe(3 * time.Microsecond)
+	}
+	histogram.Observe(time.Millisecond)
+	histogram.Observe(time.Minute)
+	histogram.Observe(-time.Second)
+
+	snapshot := histogram.Snapshot()
+	assert.Equal(t, uint64(101), snapshot.Count)
+	assert.Equal(t, 98*3*time.Microsecond+time.Millisecond+time.Minute, snapshot.Sum)
+	assert.Equal(t, time.Minute, snapshot.Max)
+	assert.Len(t, snapshot.Buckets, numLatencyBuckets+1)
+	assert.Equal(t, uint64(1), snapshot.Buckets[0].Count)
+	assert.Equal(t, uint64(98), snapshot.Buckets[2].Count)
+	assert.Equal(t, uint64(1), snapshot.Buckets[numLatencyBuckets].Count)
+
+	assert.Equal(t, 4*time.Microsecond, snapshot.Quantile(0.5))
+	assert.Equal(t, 1024*time.Microsecond, snapshot.Quantile(0.99))
+	assert.Equal(t, time.Minute, snapshot.Quantile(1))
+}
+
+func TestLatencyHistogram_ConcurrentObservations(t *testing.T) {
+	t.Parallel()
+
+	histogram := &LatencyHistogram{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				histogram.Observe(time.Duration(i*j) * time.Microsecond)
+				_ = histogram.Snapshot()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := histogram.Snapshot()
+	assert.Equal(t, uint64(1000), snapshot.Count)
+	assert.Equal(t, 891*time.Microsecond, snapshot.Max)
+}
+
+func TestLatenciesForPersister_ShouldShareTheHistogramsOfTheSamePath(t *testing.T) {
+	t.Parallel()
+
+	path := "TestLatenciesForPersister_ShouldShareTheHistogramsOfTheSamePath"
+	_, found := GetPersisterLatencies(path)
+	assert.False(t, found)
+
+	latencies := LatenciesForPersister(path)
+	assert.True(t, latencies == LatenciesForPersister(path))
+	latencies.ObserveSince(GetOperation, time.Now().Add(-time.Millisecond))
+	latencies.ObserveSince("unknown", time.Now())
+
+	var nilLatencies *PersisterLatencies
+	nilLatencies.ObserveSince(GetOperation, time.Now())
+
+	snapshots, found := GetPersisterLatencies(path)
+	require.True(t, found)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, uint64(1), snapshots[GetOperation].Count)
+	assert.True(t, snapshots[GetOperation].Max >= time.Millisecond)
+
+	assert.Contains(t, ListPersistersWithLatencies(), path)
+	assert.Equal(t, snapshots, AllPersisterLatencies()[path])
+}
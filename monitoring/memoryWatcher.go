@@ -0,0 +1,251 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const statmPath = "/proc/self/statm"
+
+// MemoryUsage holds a sample of the memory used by the process
+type MemoryUsage struct {
+	// RSSBytes is the resident set size of the process, zero if it can not be read on the current platform
+	RSSBytes uint64
+	// HeapBytes is the size of the in use Go heap spans
+	HeapBytes uint64
+}
+
+// InUseBytes returns the memory compared against the watermarks: the largest of the RSS and the Go heap
+func (usage MemoryUsage) InUseBytes() uint64 {
+	if usage.RSSBytes > usage.HeapBytes {
+		return usage.RSSBytes
+	}
+
+	return usage.HeapBytes
+}
+
+// MemorySampler returns the memory currently used by the process
+type MemorySampler func() MemoryUsage
+
+// MemoryWatermark asks the registered caches to release ShrinkPercentage of their contents, whenever the memory in
+// use exceeds ThresholdInBytes
+type MemoryWatermark struct {
+	ThresholdInBytes uint64
+	ShrinkPercentage uint32
+}
+
+// ArgsMemoryWatcher holds the arguments needed to create a MemoryWatcher
+type ArgsMemoryWatcher struct {
+	SamplingInterval time.Duration
+	// Watermarks should hold at least one watermark. When several ones are exceeded, the highest one is applied
+	Watermarks []MemoryWatermark
+	// Sampler is optional, the process RSS and Go heap being sampled if not provided
+	Sampler MemorySampler
+}
+
+// MemoryWatcher samples the memory used by the process at a fixed interval, asking the registered caches to shrink
+// whenever a watermark is exceeded. The sampling go routine is stopped by calling Close
+type MemoryWatcher struct {
+	watermarks       []MemoryWatermark
+	sampler          MemorySampler
+	samplingInterval time.Duration
+	cancelFunc       func()
+
+	mutShrinkables sync.RWMutex
+	shrinkables    map[string]types.Shrinkable
+}
+
+// NewMemoryWatcher creates a new memory watcher and starts its sampling go routine
+func NewMemoryWatcher(args ArgsMemoryWatcher) (*MemoryWatcher, error) {
+	err := checkArgsMemoryWatcher(args)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarks := make([]MemoryWatermark, len(args.Watermarks))
+	copy(watermarks, args.Watermarks)
+	sort.Slice(watermarks, func(i, j int) bool {
+		return watermarks[i].ThresholdInBytes > watermarks[j].ThresholdInBytes
+	})
+
+	sampler := args.Sampler
+	if sampler == nil {
+		sampler = sampleProcessMemory
+	}
+
+	mw := &MemoryWatcher{
+		watermarks:       watermarks,
+		sampler:          sampler,
+		samplingInterval: args.SamplingInterval,
+		shrinkables:      make(map[string]types.Shrinkable),
+	}
+
+	var ctx context.Context
+	ctx, mw.cancelFunc = context.WithCancel(context.Background())
+	go mw.startSampling(ctx)
+
+	return mw, nil
+}
+
+func checkArgsMemoryWatcher(args ArgsMemoryWatcher) error {
+	if args.SamplingInterval <= 0 {
+		return fmt.Errorf("%w: SamplingInterval should be positive", common.ErrInvalidConfig)
+	}
+	if len(args.Watermarks) == 0 {
+		return fmt.Errorf("%w: at least one watermark is required", common.ErrInvalidConfig)
+	}
+	for i, watermark := range args.Watermarks {
+		if watermark.ThresholdInBytes == 0 {
+			return fmt.Errorf("%w: Watermarks[%d].ThresholdInBytes should be positive", common.ErrInvalidConfig, i)
+		}
+		if watermark.ShrinkPercentage == 0 || watermark.ShrinkPercentage > 100 {
+			return fmt.Errorf("%w: Watermarks[%d].ShrinkPercentage should be between 1 and 100", common.ErrInvalidConfig, i)
+		}
+	}
+
+	return nil
+}
+
+// Register adds a cache to be shrunk under memory pressure. A cache registered later under the same name replaces
+// the previous one
+func (mw *MemoryWatcher) Register(name string, shrinkable types.Shrinkable) {
+	if check.IfNil(shrinkable) {
+		return
+	}
+
+	mw.mutShrinkables.Lock()
+	mw.shrinkables[name] = shrinkable
+	mw.mutShrinkables.Unlock()
+}
+
+// Unregister removes the cache registered under the provided name
+func (mw *MemoryWatcher) Unregister(name string) {
+	mw.mutShrinkables.Lock()
+	delete(mw.shrinkables, name)
+	mw.mutShrinkables.Unlock()
+}
+
+func (mw *MemoryWatcher) startSampling(ctx context.Context) {
+	timer := time.NewTimer(mw.samplingInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(mw.samplingInterval)
+
+		select {
+		case <-timer.C:
+			mw.checkMemory()
+		case <-ctx.Done():
+			log.Debug("closing MemoryWatcher's sampling go routine...")
+			return
+		}
+	}
+}
+
+// checkMemory samples the memory and, if a watermark is exceeded, shrinks the registered caches. It returns the
+// total number of evicted items
+func (mw *MemoryWatcher) checkMemory() int {
+	usage := mw.sampler()
+	inUse := usage.InUseBytes()
+
+	watermark, exceeded := mw.exceededWatermark(inUse)
+	if !exceeded {
+		return 0
+	}
+
+	mw.mutShrinkables.RLock()
+	names := make([]string, 0, len(mw.shrinkables))
+	shrinkables := make(map[string]types.Shrinkable, len(mw.shrinkables))
+	for name, shrinkable := range mw.shrinkables {
+		names = append(names, name)
+		shrinkables[name] = shrinkable
+	}
+	mw.mutShrinkables.RUnlock()
+	sort.Strings(names)
+
+	numEvicted := 0
+	for _, name := range names {
+		start := time.Now()
+		numEvictedFromCache := shrinkables[name].Shrink(watermark.ShrinkPercentage)
+		numEvicted += numEvictedFromCache
+		log.Debug("MemoryWatcher: cache shrunk", "name", name, "evicted", numEvictedFromCache)
+		if numEvictedFromCache > 0 {
+			PublishEvent(NewEvictionStormEvent(name, numEvictedFromCache, time.Since(start), string(types.EvictionReasonMemoryPressure)))
+		}
+	}
+
+	log.Warn("MemoryWatcher: memory watermark exceeded, caches shrunk",
+		"rss", core.ConvertBytes(usage.RSSBytes),
+		"heap", core.ConvertBytes(usage.HeapBytes),
+		"watermark", core.ConvertBytes(watermark.ThresholdInBytes),
+		"shrink percentage", watermark.ShrinkPercentage,
+		"num caches", len(names),
+		"evicted", numEvicted,
+	)
+
+	return numEvicted
+}
+
+// exceededWatermark returns the highest watermark exceeded by the provided memory in use
+func (mw *MemoryWatcher) exceededWatermark(inUse uint64) (MemoryWatermark, bool) {
+	for _, watermark := range mw.watermarks {
+		if inUse > watermark.ThresholdInBytes {
+			return watermark, true
+		}
+	}
+
+	return MemoryWatermark{}, false
+}
+
+// Close stops the sampling go routine. It is safe to call it multiple times
+func (mw *MemoryWatcher) Close() error {
+	mw.cancelFunc()
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (mw *MemoryWatcher) IsInterfaceNil() bool {
+	return mw == nil
+}
+
+func sampleProcessMemory() MemoryUsage {
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+
+	return MemoryUsage{
+		RSSBytes:  readRSS(),
+		HeapBytes: memStats.HeapInuse,
+	}
+}
+
+// readRSS reads the resident set size from the proc filesystem, returning zero if it is not available
+func readRSS() uint64 {
+	content, err := os.ReadFile(statmPath)
+	if err != nil {
+		return 0
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) < 2 {
+		return 0
+	}
+	numPages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return numPages * uint64(os.Getpagesize())
+}
@@ -0,0 +1,139 @@
+package monitoring
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shrinkableStub struct {
+	mut         sync.Mutex
+	percentages []uint32
+}
+
+// Shrink -
+func (stub *shrinkableStub) Shrink(percentage uint32) int {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	stub.percentages = append(stub.percentages, percentage)
+	return int(percentage)
+}
+
+func (stub *shrinkableStub) getPercentages() []uint32 {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	return append([]uint32(nil), stub.percentages...)
+}
+
+// IsInterfaceNil -
+func (stub *shrinkableStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func createArgsMemoryWatcher(usage *MemoryUsage) ArgsMemoryWatcher {
+	return ArgsMemoryWatcher{
+		SamplingInterval: time.Hour,
+		Watermarks: []MemoryWatermark{
+			{ThresholdInBytes: 1000, ShrinkPercentage: 10},
+			{ThresholdInBytes: 2000, ShrinkPercentage: 50},
+		},
+		Sampler: func() MemoryUsage {
+			return *usage
+		},
+	}
+}
+
+func TestNewMemoryWatcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid args should error", func(t *testing.T) {
+		t.Parallel()
+
+		usage := &MemoryUsage{}
+		changes := []func(args *ArgsMemoryWatcher){
+			func(args *ArgsMemoryWatcher) { args.SamplingInterval = 0 },
+			func(args *ArgsMemoryWatcher) { args.Watermarks = nil },
+			func(args *ArgsMemoryWatcher) { args.Watermarks[0].ThresholdInBytes = 0 },
+			func(args *ArgsMemoryWatcher) { args.Watermarks[1].ShrinkPercentage = 0 },
+			func(args *ArgsMemoryWatcher) { args.Watermarks[1].ShrinkPercentage = 101 },
+		}
+		for i, change := range changes {
+			args := createArgsMemoryWatcher(usage)
+			change(&args)
+			watcher, err := NewMemoryWatcher(args)
+			assert.Nil(t, watcher, i)
+			assert.True(t, errors.Is(err, common.ErrInvalidConfig), i)
+		}
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		watcher, err := NewMemoryWatcher(createArgsMemoryWatcher(&MemoryUsage{}))
+		require.Nil(t, err)
+		assert.False(t, watcher.IsInterfaceNil())
+		assert.Nil(t, watcher.Close())
+		assert.Nil(t, watcher.Close())
+	})
+}
+
+func TestMemoryWatcher_CheckMemoryShouldApplyTheHighestExceededWatermark(t *testing.T) {
+	t.Parallel()
+
+	usage := &MemoryUsage{}
+	watcher, err := NewMemoryWatcher(createArgsMemoryWatcher(usage))
+	require.Nil(t, err)
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	first, second := &shrinkableStub{}, &shrinkableStub{}
+	watcher.Register("first", first)
+	watcher.Register("second", second)
+	watcher.Register("nil", nil)
+
+	usage.HeapBytes = 1000
+	assert.Equal(t, 0, watcher.checkMemory())
+
+	usage.RSSBytes = 1500
+	assert.Equal(t, 20, watcher.checkMemory())
+
+	usage.HeapBytes = 2500
+	assert.Equal(t, 100, watcher.checkMemory())
+
+	watcher.Unregister("second")
+	assert.Equal(t, 50, watcher.checkMemory())
+
+	assert.Equal(t, []uint32{10, 50, 50}, first.getPercentages())
+	assert.Equal(t, []uint32{10, 50}, second.getPercentages())
+}
+
+func TestMemoryWatcher_ShouldSamplePeriodically(t *testing.T) {
+	t.Parallel()
+
+	args := createArgsMemoryWatcher(&MemoryUsage{RSSBytes: 5000})
+	args.SamplingInterval = time.Millisecond
+	watcher, err := NewMemoryWatcher(args)
+	require.Nil(t, err)
+
+	shrinkable := &shrinkableStub{}
+	watcher.Register("cache", shrinkable)
+	assert.Eventually(t, func() bool {
+		return len(shrinkable.getPercentages()) >= 2
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, watcher.Close())
+}
+
+func TestSampleProcessMemory(t *testing.T) {
+	t.Parallel()
+
+	usage := sampleProcessMemory()
+	assert.True(t, usage.HeapBytes > 0)
+	assert.True(t, usage.InUseBytes() >= usage.HeapBytes)
+}
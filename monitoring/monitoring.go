@@ -1,6 +1,8 @@
 package monitoring
 
 import (
+	"fmt"
+
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
@@ -10,8 +12,67 @@ var log = logger.GetOrCreate("storage")
 
 var cumulatedSizeInBytes atomic.Counter
 
-// MonitorNewCache adds the size in the global cumulated size variable
+// MonitorNewCache registers the cache and adds its size in the global cumulated size variable
 func MonitorNewCache(tag string, sizeInBytes uint64) {
+	registry.register(tag, sizeInBytes)
 	cumulatedSizeInBytes.Add(int64(sizeInBytes))
+	PublishEvent(NewCacheCreatedEvent(tag, sizeInBytes))
 	log.Debug("MonitorNewCache", "name", tag, "capacity", core.ConvertBytes(sizeInBytes), "cumulated", core.ConvertBytes(cumulatedSizeInBytes.GetUint64()))
 }
+
+// MonitorClosedCache deregisters the cache and subtracts its size from the global cumulated size variable. It is called
+// by the caches monitoring themselves when closed, and should be called by the owners of the other monitored caches
+func MonitorClosedCache(tag string, sizeInBytes uint64) {
+	if !registry.deregister(tag, sizeInBytes) {
+		return
+	}
+
+	cumulatedSizeInBytes.Add(-int64(sizeInBytes))
+	log.Debug("MonitorClosedCache", "name", tag, "capacity", core.ConvertBytes(sizeInBytes), "cumulated", core.ConvertBytes(cumulatedSizeInBytes.GetUint64()))
+}
+
+// CumulatedSizeInBytes returns the cumulated size of the live monitored caches
+func CumulatedSizeInBytes() uint64 {
+	return cumulatedSizeInBytes.GetUint64()
+}
+
+// MonitorNewDB registers and logs the opening of a persister, publishing a DBReopenedEvent if its path was opened before
+func MonitorNewDB(dbType string, path string) {
+	numOpenings := openedDBs.register(dbType, path)
+	if numOpenings > 1 {
+		PublishEvent(NewDBReopenedEvent(dbType, path, numOpenings))
+	}
+	log.Debug("MonitorNewDB", "type", dbType, "path", path)
+}
+
+// MonitorCacheStats logs the number of hits & misses of a cache, along with its hit rate and any other provided stats.
+// The hits & misses are kept, to be included in the reports of the StatsReporter and in the HitRatioReport
+func MonitorCacheStats(tag string, numHits uint64, numMisses uint64, otherStats ...interface{}) {
+	cacheHits.record(tag, numHits, numMisses)
+
+	args := []interface{}{"name", tag, "hits", numHits, "misses", numMisses, "hit rate", formatHitRate(numHits, numMisses)}
+	args = append(args, otherStats...)
+	log.Debug("MonitorCacheStats", args...)
+}
+
+func formatHitRate(numHits uint64, numMisses uint64) string {
+	return fmt.Sprintf("%.2f", computeHitRatio(numHits, numMisses))
+}
+
+// GlobalMonitor forwards the metrics to the package level monitoring functions
+type GlobalMonitor struct{}
+
+// MonitorNewCache calls the package level MonitorNewCache
+func (monitor *GlobalMonitor) MonitorNewCache(name string, sizeInBytes uint64) {
+	MonitorNewCache(name, sizeInBytes)
+}
+
+// MonitorNewDB calls the package level MonitorNewDB
+func (monitor *GlobalMonitor) MonitorNewDB(dbType string, path string) {
+	MonitorNewDB(dbType, path)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (monitor *GlobalMonitor) IsInterfaceNil() bool {
+	return monitor == nil
+}
@@ -0,0 +1,154 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+)
+
+// CacheInfo describes a live monitored cache
+type CacheInfo struct {
+	Name        string
+	SizeInBytes uint64
+	// NumInstances is greater than 1 when several live caches were registered under the same name
+	NumInstances int
+}
+
+type cacheRegistry struct {
+	mut    sync.RWMutex
+	caches map[string]*CacheInfo
+}
+
+var registry = &cacheRegistry{
+	caches: make(map[string]*CacheInfo),
+}
+
+func (cr *cacheRegistry) register(name string, sizeInBytes uint64) {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	info, ok := cr.caches[name]
+	if !ok {
+		cr.caches[name] = &CacheInfo{
+			Name:         name,
+			SizeInBytes:  sizeInBytes,
+			NumInstances: 1,
+		}
+		return
+	}
+
+	info.SizeInBytes += sizeInBytes
+	info.NumInstances++
+	log.Warn("MonitorNewCache: duplicate cache name", "name", name, "num instances", info.NumInstances)
+}
+
+// deregister returns false if there is no live cache registered under the provided name
+func (cr *cacheRegistry) deregister(name string, sizeInBytes uint64) bool {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	info, ok := cr.caches[name]
+	if !ok {
+		log.Warn("MonitorClosedCache: unknown cache name", "name", name)
+		return false
+	}
+
+	info.NumInstances--
+	if info.NumInstances == 0 {
+		delete(cr.caches, name)
+		return true
+	}
+
+	if info.SizeInBytes >= sizeInBytes {
+		info.SizeInBytes -= sizeInBytes
+	} else {
+		info.SizeInBytes = 0
+	}
+
+	return true
+}
+
+func (cr *cacheRegistry) list() []CacheInfo {
+	cr.mut.RLock()
+	defer cr.mut.RUnlock()
+
+	caches := make([]CacheInfo, 0, len(cr.caches))
+	for _, info := range cr.caches {
+		caches = append(caches, *info)
+	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].Name < caches[j].Name
+	})
+
+	return caches
+}
+
+// capacityOf returns the size in bytes of the live caches registered under the provided name
+func (cr *cacheRegistry) capacityOf(name string) (uint64, bool) {
+	cr.mut.RLock()
+	defer cr.mut.RUnlock()
+
+	info, ok := cr.caches[name]
+	if !ok {
+		return 0, false
+	}
+
+	return info.SizeInBytes, true
+}
+
+// ListCaches returns the live monitored caches, sorted by name
+func ListCaches() []CacheInfo {
+	return registry.list()
+}
+
+// DBInfo describes a persister opened since the node started
+type DBInfo struct {
+	Type string
+	Path string
+	// NumOpenings is greater than 1 when the persister of the same path was opened several times
+	NumOpenings int
+}
+
+type dbRegistry struct {
+	mut sync.RWMutex
+	dbs map[string]*DBInfo
+}
+
+var openedDBs = &dbRegistry{
+	dbs: make(map[string]*DBInfo),
+}
+
+// register returns the number of openings of the path, this one included
+func (dr *dbRegistry) register(dbType string, path string) int {
+	dr.mut.Lock()
+	defer dr.mut.Unlock()
+
+	info, ok := dr.dbs[path]
+	if !ok {
+		info = &DBInfo{Path: path}
+		dr.dbs[path] = info
+	}
+	info.Type = dbType
+	info.NumOpenings++
+
+	return info.NumOpenings
+}
+
+func (dr *dbRegistry) list() []DBInfo {
+	dr.mut.RLock()
+	defer dr.mut.RUnlock()
+
+	dbs := make([]DBInfo, 0, len(dr.dbs))
+	for _, info := range dr.dbs {
+		dbs = append(dbs, *info)
+	}
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].Path < dbs[j].Path
+	})
+
+	return dbs
+}
+
+// ListDBs returns the persisters opened since the node started, sorted by path
+func ListDBs() []DBInfo {
+	return openedDBs.list()
+}
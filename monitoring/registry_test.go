@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func findCache(name string) (CacheInfo, bool) {
+	for _, info := range ListCaches() {
+		if info.Name == name {
+			return info, true
+		}
+	}
+
+	return CacheInfo{}, false
+}
+
+func TestMonitorNewCache_ShouldRegisterTheLiveCaches(t *testing.T) {
+	t.Parallel()
+
+	name := "TestMonitorNewCache_ShouldRegisterTheLiveCaches"
+	MonitorNewCache(name, 100)
+	info, found := findCache(name)
+	assert.True(t, found)
+	assert.Equal(t, CacheInfo{Name: name, SizeInBytes: 100, NumInstances: 1}, info)
+
+	MonitorNewCache(name, 50)
+	info, _ = findCache(name)
+	assert.Equal(t, CacheInfo{Name: name, SizeInBytes: 150, NumInstances: 2}, info)
+
+	MonitorClosedCache(name, 50)
+	info, _ = findCache(name)
+	assert.Equal(t, CacheInfo{Name: name, SizeInBytes: 100, NumInstances: 1}, info)
+
+	MonitorClosedCache(name, 100)
+	_, found = findCache(name)
+	assert.False(t, found)
+}
+
+func TestMonitorClosedCache_UnknownCacheShouldNotChangeTheCumulatedSize(t *testing.T) {
+	t.Parallel()
+
+	registry := &cacheRegistry{caches: make(map[string]*CacheInfo)}
+	assert.False(t, registry.deregister("unknown", 100))
+
+	registry.register("a", 10)
+	registry.register("b", 20)
+	assert.True(t, registry.deregister("a", 10))
+	assert.Equal(t, []CacheInfo{{Name: "b", SizeInBytes: 20, NumInstances: 1}}, registry.list())
+}
@@ -0,0 +1,72 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+type statsProviders struct {
+	mut       sync.RWMutex
+	providers map[string]types.StatsProvider
+}
+
+var registeredStatsProviders = &statsProviders{
+	providers: make(map[string]types.StatsProvider),
+}
+
+// RegisterStatsProvider makes the stats of the provided cache, persister or storer available to CollectStats and to
+// the debug handler, under the provided name. A provider registered later under the same name replaces the previous one
+func RegisterStatsProvider(name string, provider types.StatsProvider) {
+	if check.IfNil(provider) {
+		return
+	}
+
+	registeredStatsProviders.mut.Lock()
+	registeredStatsProviders.providers[name] = provider
+	registeredStatsProviders.mut.Unlock()
+}
+
+// DeregisterStatsProvider removes the provider registered under the provided name, if it was not replaced since
+func DeregisterStatsProvider(name string, provider types.StatsProvider) {
+	registeredStatsProviders.mut.Lock()
+	defer registeredStatsProviders.mut.Unlock()
+
+	if registeredStatsProviders.providers[name] == provider {
+		delete(registeredStatsProviders.providers, name)
+	}
+}
+
+// ListStatsProviders returns the sorted names of the registered stats providers
+func ListStatsProviders() []string {
+	registeredStatsProviders.mut.RLock()
+	defer registeredStatsProviders.mut.RUnlock()
+
+	names := make([]string, 0, len(registeredStatsProviders.providers))
+	for name := range registeredStatsProviders.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CollectStats returns the stats of all the registered providers, keyed by the names they were registered under
+func CollectStats() map[string]map[string]interface{} {
+	registeredStatsProviders.mut.RLock()
+	registered := make(map[string]types.StatsProvider, len(registeredStatsProviders.providers))
+	for name, provider := range registeredStatsProviders.providers {
+		registered[name] = provider
+	}
+	registeredStatsProviders.mut.RUnlock()
+
+	// the stats are collected outside the lock, as the providers might be slow
+	stats := make(map[string]map[string]interface{}, len(registered))
+	for name, provider := range registered {
+		stats[name] = provider.Stats()
+	}
+
+	return stats
+}
@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statsProviderStub struct {
+	stats map[string]interface{}
+}
+
+// Stats -
+func (stub *statsProviderStub) Stats() map[string]interface{} {
+	return stub.stats
+}
+
+// IsInterfaceNil -
+func (stub *statsProviderStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestStatsProviders(t *testing.T) {
+	t.Parallel()
+
+	name := "TestStatsProviders"
+	first := &statsProviderStub{stats: map[string]interface{}{"numItems": 1}}
+	second := &statsProviderStub{stats: map[string]interface{}{"numItems": 2}}
+	RegisterStatsProvider(name, nil)
+	assert.NotContains(t, ListStatsProviders(), name)
+
+	RegisterStatsProvider(name, first)
+	RegisterStatsProvider(name, second)
+	assert.Contains(t, ListStatsProviders(), name)
+	assert.Equal(t, second.stats, CollectStats()[name])
+
+	recorder := serveDebugRequest(http.MethodGet, "/debug/storage?section=stats")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	stats := make(map[string]map[string]interface{})
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &stats))
+	assert.Equal(t, map[string]interface{}{"numItems": float64(2)}, stats[name])
+
+	DeregisterStatsProvider(name, first)
+	assert.Contains(t, CollectStats(), name)
+	DeregisterStatsProvider(name, second)
+	assert.NotContains(t, CollectStats(), name)
+}
@@ -0,0 +1,195 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ArgsStatsReporter holds the arguments needed to create a StatsReporter
+type ArgsStatsReporter struct {
+	ReportInterval time.Duration
+	// DisabledUnits holds the names of the caches and the paths of the persisters not to be reported
+	DisabledUnits []string
+}
+
+// StatsReporter logs, at a fixed interval, one line per registered cache or persister with its key stats. The
+// reporting go routine is stopped by calling Close
+type StatsReporter struct {
+	reportInterval time.Duration
+	cancelFunc     func()
+
+	mut        sync.RWMutex
+	caches     map[string]types.SizedCache
+	persisters map[string]struct{}
+	disabled   map[string]struct{}
+}
+
+// NewStatsReporter creates a new stats reporter and starts its reporting go routine
+func NewStatsReporter(args ArgsStatsReporter) (*StatsReporter, error) {
+	if args.ReportInterval <= 0 {
+		return nil, fmt.Errorf("%w: ReportInterval should be positive", common.ErrInvalidConfig)
+	}
+
+	sr := &StatsReporter{
+		reportInterval: args.ReportInterval,
+		caches:         make(map[string]types.SizedCache),
+		persisters:     make(map[string]struct{}),
+		disabled:       make(map[string]struct{}, len(args.DisabledUnits)),
+	}
+	for _, name := range args.DisabledUnits {
+		sr.disabled[name] = struct{}{}
+	}
+
+	var ctx context.Context
+	ctx, sr.cancelFunc = context.WithCancel(context.Background())
+	go sr.startReporting(ctx)
+
+	return sr, nil
+}
+
+// RegisterCache adds a cache to be reported under the provided name, the one used when monitoring it. A cache
+// registered later under the same name replaces the previous one
+func (sr *StatsReporter) RegisterCache(name string, cache types.SizedCache) {
+	if check.IfNil(cache) {
+		return
+	}
+
+	sr.mut.Lock()
+	sr.caches[name] = cache
+	sr.mut.Unlock()
+}
+
+// RegisterPersister adds the persister stored at the provided path to be reported, along with its operation counts
+func (sr *StatsReporter) RegisterPersister(path string) {
+	sr.mut.Lock()
+	sr.persisters[path] = struct{}{}
+	sr.mut.Unlock()
+}
+
+// Unregister removes the cache or the persister registered under the provided name
+func (sr *StatsReporter) Unregister(name string) {
+	sr.mut.Lock()
+	delete(sr.caches, name)
+	delete(sr.persisters, name)
+	sr.mut.Unlock()
+}
+
+// SetUnitEnabled enables or disables the reporting of the cache or the persister registered under the provided name.
+// The setting is kept if the unit is registered later
+func (sr *StatsReporter) SetUnitEnabled(name string, enabled bool) {
+	sr.mut.Lock()
+	defer sr.mut.Unlock()
+
+	if enabled {
+		delete(sr.disabled, name)
+		return
+	}
+	sr.disabled[name] = struct{}{}
+}
+
+func (sr *StatsReporter) startReporting(ctx context.Context) {
+	timer := time.NewTimer(sr.reportInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(sr.reportInterval)
+
+		select {
+		case <-timer.C:
+			sr.report()
+		case <-ctx.Done():
+			log.Debug("closing StatsReporter's reporting go routine...")
+			return
+		}
+	}
+}
+
+// report logs the stats of the enabled units, returning the number of logged lines
+func (sr *StatsReporter) report() int {
+	caches, persisters := sr.enabledUnits()
+
+	for _, name := range sortedKeys(caches) {
+		sr.reportCache(name, caches[name])
+	}
+	for _, path := range persisters {
+		sr.reportPersister(path)
+	}
+
+	return len(caches) + len(persisters)
+}
+
+func (sr *StatsReporter) enabledUnits() (map[string]types.SizedCache, []string) {
+	sr.mut.RLock()
+	defer sr.mut.RUnlock()
+
+	caches := make(map[string]types.SizedCache, len(sr.caches))
+	for name, cache := range sr.caches {
+		if !sr.isDisabled(name) {
+			caches[name] = cache
+		}
+	}
+	persisters := make([]string, 0, len(sr.persisters))
+	for path := range sr.persisters {
+		if !sr.isDisabled(path) {
+			persisters = append(persisters, path)
+		}
+	}
+	sort.Strings(persisters)
+
+	return caches, persisters
+}
+
+func (sr *StatsReporter) isDisabled(name string) bool {
+	_, disabled := sr.disabled[name]
+	return disabled
+}
+
+func (sr *StatsReporter) reportCache(name string, cache types.SizedCache) {
+	args := []interface{}{"name", name, "len", cache.Len(), "bytes", core.ConvertBytes(cache.SizeInBytesContained())}
+	stats, ok := cacheHits.get(name)
+	if ok {
+		args = append(args, "hits", stats.numHits, "misses", stats.numMisses, "hit rate", formatHitRate(stats.numHits, stats.numMisses))
+	}
+
+	log.Info("cache stats", args...)
+}
+
+func (sr *StatsReporter) reportPersister(path string) {
+	args := []interface{}{"path", path}
+	snapshots, _ := GetPersisterLatencies(path)
+	for _, operation := range persisterOperations {
+		args = append(args, string(operation)+" count", snapshots[operation].Count)
+	}
+
+	log.Info("persister stats", args...)
+}
+
+// Close stops the reporting go routine. It is safe to call it multiple times
+func (sr *StatsReporter) Close() error {
+	sr.cancelFunc()
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sr *StatsReporter) IsInterfaceNil() bool {
+	return sr == nil
+}
+
+func sortedKeys(caches map[string]types.SizedCache) []string {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
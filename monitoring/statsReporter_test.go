@@ -0,0 +1,64 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStatsReporter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid interval should error", func(t *testing.T) {
+		t.Parallel()
+
+		reporter, err := NewStatsReporter(ArgsStatsReporter{})
+		assert.Nil(t, reporter)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		reporter, err := NewStatsReporter(ArgsStatsReporter{ReportInterval: time.Hour})
+		require.Nil(t, err)
+		assert.False(t, reporter.IsInterfaceNil())
+		assert.Nil(t, reporter.Close())
+		assert.Nil(t, reporter.Close())
+	})
+}
+
+func TestStatsReporter_ReportShouldSkipTheDisabledUnits(t *testing.T) {
+	t.Parallel()
+
+	reporter, err := NewStatsReporter(ArgsStatsReporter{
+		ReportInterval: time.Hour,
+		DisabledUnits:  []string{"disabled"},
+	})
+	require.Nil(t, err)
+	defer func() {
+		_ = reporter.Close()
+	}()
+
+	cache := &testscommon.AdaptedSizedLruCacheStub{
+		LenCalled: func() int {
+			return 3
+		},
+	}
+	reporter.RegisterCache("cache", cache)
+	reporter.RegisterCache("disabled", cache)
+	reporter.RegisterCache("nil", nil)
+	reporter.RegisterPersister(t.TempDir())
+	assert.Equal(t, 2, reporter.report())
+
+	reporter.SetUnitEnabled("disabled", true)
+	reporter.SetUnitEnabled("cache", false)
+	assert.Equal(t, 2, reporter.report())
+
+	reporter.Unregister("disabled")
+	assert.Equal(t, 1, reporter.report())
+}
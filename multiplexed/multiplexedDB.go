@@ -0,0 +1,254 @@
+package multiplexed
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.PersisterFactory = (*MultiplexedDB)(nil)
+var _ types.StatsProvider = (*MultiplexedDB)(nil)
+
+var log = logger.GetOrCreate("storage/multiplexed")
+
+// multiplexedType is the type reported in the stats of the multiplexed db and of its namespaces
+const multiplexedType = "Multiplexed"
+
+// clearChunkSize is the number of keys removed at once while clearing a namespace
+const clearChunkSize = 1024
+
+// MultiplexedDB exposes several named namespaces, the logical persisters, backed by one physical persister, so that
+// the nodes holding dozens of small storage units do not open as many databases, each with its files and go routines.
+// The keys of each namespace are prefixed by its length prefixed name, hence the prefixes of two namespaces never
+// overlap. The physical persister should support the range iteration, used to iterate over and clear a namespace
+type MultiplexedDB struct {
+	persister     types.Persister
+	rangeIterator types.RangeIterator
+
+	mut        sync.Mutex
+	namespaces map[string]*Namespace
+	isClosed   bool
+}
+
+// NewMultiplexedDB creates a new multiplexed db over the provided physical persister, which it owns from now on
+func NewMultiplexedDB(persister types.Persister) (*MultiplexedDB, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+	rangeIterator, ok := persister.(types.RangeIterator)
+	if !ok {
+		return nil, fmt.Errorf("%w: the multiplexed persister should support the range iteration", common.ErrInvalidConfig)
+	}
+
+	return &MultiplexedDB{
+		persister:     persister,
+		rangeIterator: rangeIterator,
+		namespaces:    make(map[string]*Namespace),
+	}, nil
+}
+
+// namespacePrefix returns the prefix of the keys of the namespace: its name, preceded by its length as an uvarint
+func namespacePrefix(name string) []byte {
+	prefix := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(name)), uint64(len(name)))
+
+	return append(prefix, name...)
+}
+
+// prefixUpperBound returns the smallest key greater than all the keys starting with the prefix, nil if there is none
+func prefixUpperBound(prefix []byte) []byte {
+	upperBound := append([]byte{}, prefix...)
+	for i := len(upperBound) - 1; i >= 0; i-- {
+		if upperBound[i] < 0xff {
+			upperBound[i]++
+			return upperBound[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// OpenNamespace returns the persister of the namespace, which can not be opened again until closed
+func (mdb *MultiplexedDB) OpenNamespace(name string) (*Namespace, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("%w: empty namespace name", common.ErrInvalidConfig)
+	}
+
+	mdb.mut.Lock()
+	defer mdb.mut.Unlock()
+
+	if mdb.isClosed {
+		return nil, common.ErrDBIsClosed
+	}
+	_, ok := mdb.namespaces[name]
+	if ok {
+		return nil, fmt.Errorf("%w: namespace %q is already opened", common.ErrInvalidConfig, name)
+	}
+
+	namespace := newNamespace(mdb, name)
+	mdb.namespaces[name] = namespace
+	log.Debug("MultiplexedDB: opened namespace", "name", name)
+
+	return namespace, nil
+}
+
+// Create opens the namespace named by the provided path, so that the multiplexed db stands in for the persister
+// factory of the storage units
+func (mdb *MultiplexedDB) Create(path string) (types.Persister, error) {
+	return mdb.OpenNamespace(path)
+}
+
+// release forgets the closed namespace, so that it can be opened again
+func (mdb *MultiplexedDB) release(namespace *Namespace) {
+	mdb.mut.Lock()
+	defer mdb.mut.Unlock()
+
+	if mdb.namespaces[namespace.name] == namespace {
+		delete(mdb.namespaces, namespace.name)
+	}
+}
+
+// Namespaces returns the sorted names of the opened namespaces
+func (mdb *MultiplexedDB) Namespaces() []string {
+	mdb.mut.Lock()
+	names := make([]string, 0, len(mdb.namespaces))
+	for name := range mdb.namespaces {
+		names = append(names, name)
+	}
+	mdb.mut.Unlock()
+
+	sort.Strings(names)
+
+	return names
+}
+
+// rangePrefix iterates, in ascending order, over the (key, value) pairs whose keys start with the prefix and are in
+// [prefix+startKey, prefix+endKey), a nil endKey meaning the end of the prefix. The prefix is stripped off the keys
+func (mdb *MultiplexedDB) rangePrefix(prefix []byte, startKey []byte, endKey []byte, handler func(key []byte, val []byte) bool) {
+	start := append(append(make([]byte, 0, len(prefix)+len(startKey)), prefix...), startKey...)
+	end := prefixUpperBound(prefix)
+	if endKey != nil {
+		end = append(append(make([]byte, 0, len(prefix)+len(endKey)), prefix...), endKey...)
+	}
+
+	mdb.rangeIterator.RangeKeysBetween(start, end, func(key []byte, val []byte) bool {
+		return handler(key[len(prefix):], val)
+	})
+}
+
+// ClearNamespace removes all the keys of the namespace, opened or not
+func (mdb *MultiplexedDB) ClearNamespace(name string) error {
+	mdb.mut.Lock()
+	isClosed := mdb.isClosed
+	mdb.mut.Unlock()
+	if isClosed {
+		return common.ErrDBIsClosed
+	}
+
+	return mdb.clearPrefix(namespacePrefix(name))
+}
+
+// clearPrefix removes the keys starting with the prefix, one chunk at a time. The pending writes are flushed first, as
+// the persisters delaying the writes in batches do not iterate over them. Each chunk starts after the last key of the
+// previous one, as these persisters still iterate over the removed keys
+func (mdb *MultiplexedDB) clearPrefix(prefix []byte) error {
+	err := mdb.flush()
+	if err != nil {
+		return err
+	}
+
+	startKey := make([]byte, 0)
+	for {
+		keys := make([][]byte, 0, clearChunkSize)
+		mdb.rangePrefix(prefix, startKey, nil, func(key []byte, _ []byte) bool {
+			keys = append(keys, append(append(make([]byte, 0, len(prefix)+len(key)), prefix...), key...))
+			return len(keys) < clearChunkSize
+		})
+		if len(keys) == 0 {
+			break
+		}
+
+		err = mdb.removeKeys(keys)
+		if err != nil {
+			return err
+		}
+
+		lastKey := keys[len(keys)-1][len(prefix):]
+		startKey = append(append(make([]byte, 0, len(lastKey)+1), lastKey...), 0)
+	}
+
+	return mdb.flush()
+}
+
+func (mdb *MultiplexedDB) flush() error {
+	flusher, ok := mdb.persister.(types.Flusher)
+	if ok {
+		return flusher.Flush()
+	}
+
+	return nil
+}
+
+func (mdb *MultiplexedDB) removeKeys(keys [][]byte) error {
+	bulkWriter, ok := mdb.persister.(types.BulkWriter)
+	if ok {
+		return bulkWriter.MultiRemove(keys)
+	}
+
+	for _, key := range keys {
+		err := mdb.persister.Remove(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats returns the stats of the physical persister, along with the ones of each opened namespace
+func (mdb *MultiplexedDB) Stats() map[string]interface{} {
+	mdb.mut.Lock()
+	namespacesStats := make(map[string]interface{}, len(mdb.namespaces))
+	for name, namespace := range mdb.namespaces {
+		namespacesStats[name] = namespace.Stats()
+	}
+	mdb.mut.Unlock()
+
+	return map[string]interface{}{
+		types.StatType:  multiplexedType,
+		"numNamespaces": len(namespacesStats),
+		"namespaces":    namespacesStats,
+		"db":            types.StatsOf(mdb.persister),
+	}
+}
+
+// Close closes the opened namespaces, then the physical persister
+func (mdb *MultiplexedDB) Close() error {
+	mdb.mut.Lock()
+	if mdb.isClosed {
+		mdb.mut.Unlock()
+		return nil
+	}
+	mdb.isClosed = true
+	namespaces := make([]*Namespace, 0, len(mdb.namespaces))
+	for _, namespace := range mdb.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	mdb.mut.Unlock()
+
+	for _, namespace := range namespaces {
+		_ = namespace.Close()
+	}
+
+	return mdb.persister.Close()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (mdb *MultiplexedDB) IsInterfaceNil() bool {
+	return mdb == nil
+}
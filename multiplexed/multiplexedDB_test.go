@@ -0,0 +1,192 @@
+package multiplexed_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/multiplexed"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rangedKeys(persister types.Persister) []string {
+	keys := make([]string, 0)
+	persister.RangeKeys(func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+
+	return keys
+}
+
+func TestNewMultiplexedDB(t *testing.T) {
+	t.Parallel()
+
+	mdb, err := multiplexed.NewMultiplexedDB(nil)
+	assert.Nil(t, mdb)
+	assert.Equal(t, common.ErrNilPersister, err)
+
+	mdb, err = multiplexed.NewMultiplexedDB(&testscommon.PersisterStub{})
+	assert.Nil(t, mdb)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	mdb, err = multiplexed.NewMultiplexedDB(memorydb.New())
+	require.Nil(t, err)
+	assert.False(t, mdb.IsInterfaceNil())
+	assert.Empty(t, mdb.Namespaces())
+}
+
+func TestMultiplexedDB_NamespacesShouldBeIsolated(t *testing.T) {
+	t.Parallel()
+
+	physical := memorydb.New()
+	mdb, _ := multiplexed.NewMultiplexedDB(physical)
+	// the names prefixing one another, or ending with 0xff, should not leak into each other
+	names := []string{"a", "ab", "b", "a\xff"}
+	namespaces := make([]*multiplexed.Namespace, 0, len(names))
+	for _, name := range names {
+		namespace, err := mdb.OpenNamespace(name)
+		require.Nil(t, err)
+		namespaces = append(namespaces, namespace)
+
+		require.Nil(t, namespace.Put([]byte("key"), []byte("value of "+name)))
+		require.Nil(t, namespace.MultiPut(map[string][]byte{"k1": []byte(name), "k2": []byte(name)}))
+	}
+	assert.Equal(t, []string{"a", "ab", "a\xff", "b"}, mdb.Namespaces())
+	assert.Equal(t, 12, physical.Len())
+
+	for i, namespace := range namespaces {
+		val, err := namespace.Get([]byte("key"))
+		require.Nil(t, err)
+		assert.Equal(t, "value of "+names[i], string(val))
+		assert.Equal(t, []string{"k1", "k2", "key"}, rangedKeys(namespace))
+	}
+
+	require.Nil(t, namespaces[0].Remove([]byte("key")))
+	assert.NotNil(t, namespaces[0].Has([]byte("key")))
+	assert.Nil(t, namespaces[1].Has([]byte("key")))
+	require.Nil(t, namespaces[0].MultiRemove([][]byte{[]byte("k1")}))
+	assert.Equal(t, []string{"k2"}, rangedKeys(namespaces[0]))
+
+	keys := make([]string, 0)
+	namespaces[1].RangeKeysBetween([]byte("k2"), nil, func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, []string{"k2", "key"}, keys)
+
+	_, err := mdb.OpenNamespace("a")
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	_, err = mdb.OpenNamespace("")
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	persister, err := mdb.Create("db/Epoch_0/Shard_0/Blocks")
+	require.Nil(t, err)
+	assert.Empty(t, rangedKeys(persister))
+}
+
+func TestNamespace_Clear(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should remove all the keys of the namespace only", func(t *testing.T) {
+		t.Parallel()
+
+		physical := memorydb.New()
+		mdb, _ := multiplexed.NewMultiplexedDB(physical)
+		blocks, _ := mdb.OpenNamespace("Blocks")
+		headers, _ := mdb.OpenNamespace("Headers")
+		// more keys than removed at once
+		for i := 0; i < 2500; i++ {
+			require.Nil(t, blocks.Put([]byte(fmt.Sprintf("key%d", i)), []byte("block")))
+		}
+		require.Nil(t, headers.Put([]byte("key0"), []byte("header")))
+
+		require.Nil(t, blocks.Clear())
+		assert.Empty(t, rangedKeys(blocks))
+		assert.Equal(t, 1, physical.Len())
+		assert.Nil(t, headers.Has([]byte("key0")))
+
+		require.Nil(t, headers.Close())
+		require.Nil(t, mdb.ClearNamespace("Headers"))
+		assert.Zero(t, physical.Len())
+	})
+	t.Run("should not iterate again over the removals pending in the batch of a leveldb", func(t *testing.T) {
+		t.Parallel()
+
+		physical, err := leveldb.NewDB(t.TempDir(), 10, 100000, 10)
+		require.Nil(t, err)
+		mdb, _ := multiplexed.NewMultiplexedDB(physical)
+		blocks, _ := mdb.OpenNamespace("Blocks")
+		headers, _ := mdb.OpenNamespace("Headers")
+		for i := 0; i < 2500; i++ {
+			require.Nil(t, blocks.Put([]byte(fmt.Sprintf("key%d", i)), []byte("block")))
+		}
+		require.Nil(t, blocks.Flush())
+		require.Nil(t, headers.Put([]byte("key0"), []byte("header")))
+
+		require.Nil(t, blocks.Clear())
+		assert.Empty(t, rangedKeys(blocks))
+		assert.NotNil(t, blocks.Has([]byte("key2499")))
+		assert.Equal(t, []string{"key0"}, rangedKeys(headers))
+
+		require.Nil(t, headers.Destroy())
+		assert.Equal(t, []string{"Blocks"}, mdb.Namespaces())
+		assert.Nil(t, mdb.Close())
+	})
+	t.Run("should remove the keys still pending in the batch of a leveldb", func(t *testing.T) {
+		t.Parallel()
+
+		physical, err := leveldb.NewDB(t.TempDir(), 10, 100000, 10)
+		require.Nil(t, err)
+		mdb, _ := multiplexed.NewMultiplexedDB(physical)
+		blocks, _ := mdb.OpenNamespace("Blocks")
+		require.Nil(t, blocks.Put([]byte("key"), []byte("block")))
+
+		require.Nil(t, blocks.Clear())
+		require.Nil(t, blocks.Flush())
+		assert.NotNil(t, blocks.Has([]byte("key")))
+		assert.Empty(t, rangedKeys(blocks))
+		assert.Nil(t, mdb.Close())
+	})
+}
+
+func TestMultiplexedDB_CloseAndStats(t *testing.T) {
+	t.Parallel()
+
+	mdb, _ := multiplexed.NewMultiplexedDB(memorydb.New())
+	blocks, _ := mdb.OpenNamespace("Blocks")
+	require.Nil(t, blocks.Put([]byte("key"), []byte("value")))
+	_, _ = blocks.Get([]byte("key"))
+	_, _ = blocks.Get([]byte("missing"))
+
+	stats := mdb.Stats()
+	assert.Equal(t, 1, stats["numNamespaces"])
+	blocksStats := stats["namespaces"].(map[string]interface{})["Blocks"].(map[string]interface{})
+	assert.Equal(t, uint64(1), blocksStats["numPuts"])
+	assert.Equal(t, uint64(2), blocksStats["numGets"])
+	assert.Equal(t, uint64(1), blocksStats["numMisses"])
+	assert.Equal(t, uint64(8), blocksStats["numBytesWritten"])
+
+	require.Nil(t, blocks.Close())
+	err := blocks.Put([]byte("key"), []byte("value"))
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+	assert.Empty(t, mdb.Namespaces())
+
+	// the closed namespace can be opened again, holding its keys
+	blocks, err = mdb.OpenNamespace("Blocks")
+	require.Nil(t, err)
+	assert.Nil(t, blocks.Has([]byte("key")))
+
+	require.Nil(t, mdb.Close())
+	_, err = blocks.Get([]byte("key"))
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+	_, err = mdb.OpenNamespace("Headers")
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+	assert.True(t, errors.Is(blocks.DestroyClosed(), common.ErrDBIsClosed))
+	assert.Nil(t, mdb.Close())
+}
@@ -0,0 +1,238 @@
+package multiplexed
+
+import (
+	"sync/atomic"
+
+	coreAtomic "github.com/TerraDharitri/drt-go-chain-core/core/atomic"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*Namespace)(nil)
+var _ types.BulkWriter = (*Namespace)(nil)
+var _ types.Flusher = (*Namespace)(nil)
+var _ types.RangeIterator = (*Namespace)(nil)
+var _ types.StatsProvider = (*Namespace)(nil)
+var _ types.CapabilitiesProvider = (*Namespace)(nil)
+var _ types.HealthChecker = (*Namespace)(nil)
+
+// Namespace is a logical persister of a MultiplexedDB: it prefixes its keys before forwarding the calls to the
+// physical persister, until it is closed
+type Namespace struct {
+	mdb      *MultiplexedDB
+	name     string
+	prefix   []byte
+	isClosed coreAtomic.Flag
+
+	numPuts    atomic.Uint64
+	numGets    atomic.Uint64
+	numMisses  atomic.Uint64
+	numRemoves atomic.Uint64
+	numClears  atomic.Uint64
+	numBytes   atomic.Uint64
+}
+
+func newNamespace(mdb *MultiplexedDB, name string) *Namespace {
+	return &Namespace{
+		mdb:    mdb,
+		name:   name,
+		prefix: namespacePrefix(name),
+	}
+}
+
+// Name returns the name of the namespace
+func (ns *Namespace) Name() string {
+	return ns.name
+}
+
+// errClosed returns common.ErrDBIsClosed, carrying the name of the namespace
+func (ns *Namespace) errClosed() error {
+	return common.WrapStorageError(common.ErrDBIsClosed, nil, "", ns.name)
+}
+
+func (ns *Namespace) prefixed(key []byte) []byte {
+	return append(append(make([]byte, 0, len(ns.prefix)+len(key)), ns.prefix...), key...)
+}
+
+// Put adds the value to the namespace
+func (ns *Namespace) Put(key, val []byte) error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	ns.numPuts.Add(1)
+	ns.numBytes.Add(uint64(len(key) + len(val)))
+
+	return ns.mdb.persister.Put(ns.prefixed(key), val)
+}
+
+// MultiPut adds all the provided values to the namespace, in one go if the physical persister supports it
+func (ns *Namespace) MultiPut(data map[string][]byte) error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	prefixedData := make(map[string][]byte, len(data))
+	for key, val := range data {
+		prefixedData[string(ns.prefixed([]byte(key)))] = val
+		ns.numBytes.Add(uint64(len(key) + len(val)))
+	}
+	ns.numPuts.Add(uint64(len(data)))
+
+	multiPutter, ok := ns.mdb.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(prefixedData)
+	}
+
+	for key, val := range prefixedData {
+		err := ns.mdb.persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key from the namespace
+func (ns *Namespace) Get(key []byte) ([]byte, error) {
+	if ns.isClosed.IsSet() {
+		return nil, ns.errClosed()
+	}
+
+	ns.numGets.Add(1)
+	val, err := ns.mdb.persister.Get(ns.prefixed(key))
+	if err != nil {
+		ns.numMisses.Add(1)
+	}
+
+	return val, err
+}
+
+// Has returns nil if the given key is present in the namespace
+func (ns *Namespace) Has(key []byte) error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	return ns.mdb.persister.Has(ns.prefixed(key))
+}
+
+// Remove removes the data associated to the given key from the namespace
+func (ns *Namespace) Remove(key []byte) error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	ns.numRemoves.Add(1)
+
+	return ns.mdb.persister.Remove(ns.prefixed(key))
+}
+
+// MultiRemove removes the data associated to all the provided keys from the namespace
+func (ns *Namespace) MultiRemove(keys [][]byte) error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	prefixedKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		prefixedKeys = append(prefixedKeys, ns.prefixed(key))
+	}
+	ns.numRemoves.Add(uint64(len(keys)))
+
+	return ns.mdb.removeKeys(prefixedKeys)
+}
+
+// RangeKeys iterates, in ascending order of the keys, over the (key, value) pairs of the namespace. As for the
+// physical persister, the writes pending in its batch might not be visible
+func (ns *Namespace) RangeKeys(handler func(key []byte, val []byte) bool) {
+	ns.RangeKeysBetween(nil, nil, handler)
+}
+
+// RangeKeysBetween iterates, in ascending order, over the (key, value) pairs of the namespace whose keys are in
+// [startKey, endKey), a nil endKey meaning no upper bound
+func (ns *Namespace) RangeKeysBetween(startKey []byte, endKey []byte, handler func(key []byte, val []byte) bool) {
+	if handler == nil || ns.isClosed.IsSet() {
+		return
+	}
+
+	ns.mdb.rangePrefix(ns.prefix, startKey, endKey, handler)
+}
+
+// Flush writes the writes pending in the batch of the physical persister, shared by all the namespaces, if any
+func (ns *Namespace) Flush() error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	return ns.mdb.flush()
+}
+
+// Clear removes all the keys of the namespace, leaving the other namespaces untouched
+func (ns *Namespace) Clear() error {
+	if ns.isClosed.IsSet() {
+		return ns.errClosed()
+	}
+
+	ns.numClears.Add(1)
+
+	return ns.mdb.clearPrefix(ns.prefix)
+}
+
+// Close closes the namespace, which can be opened again afterwards. The physical persister is closed along with the
+// multiplexed db only
+func (ns *Namespace) Close() error {
+	if ns.isClosed.SetReturningPrevious() {
+		return nil
+	}
+
+	ns.mdb.release(ns)
+	log.Debug("MultiplexedDB: closed namespace", "name", ns.name)
+
+	return nil
+}
+
+// Destroy removes all the keys of the namespace, then closes it
+func (ns *Namespace) Destroy() error {
+	err := ns.Clear()
+	if err != nil {
+		return err
+	}
+
+	return ns.Close()
+}
+
+// DestroyClosed removes all the keys of the closed namespace, as long as the multiplexed db is opened
+func (ns *Namespace) DestroyClosed() error {
+	return ns.mdb.ClearNamespace(ns.name)
+}
+
+// Stats returns the counters of the operations done through the namespace, since it was opened
+func (ns *Namespace) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		types.StatType:    multiplexedType,
+		"name":            ns.name,
+		"numPuts":         ns.numPuts.Load(),
+		"numGets":         ns.numGets.Load(),
+		"numMisses":       ns.numMisses.Load(),
+		"numRemoves":      ns.numRemoves.Load(),
+		"numClears":       ns.numClears.Load(),
+		"numBytesWritten": ns.numBytes.Load(),
+	}
+}
+
+// Capabilities returns the durability of the physical persister, its snapshots and iterators not being forwarded
+func (ns *Namespace) Capabilities() types.PersisterCapabilities {
+	return types.ForwardedCapabilities(ns.mdb.persister)
+}
+
+// Health returns the health of the physical persister
+func (ns *Namespace) Health() types.HealthStatus {
+	return types.ForwardedHealth(ns.mdb.persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ns *Namespace) IsInterfaceNil() bool {
+	return ns == nil
+}
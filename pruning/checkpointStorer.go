@@ -0,0 +1,286 @@
+package pruning
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// checkpointMarkerPrefix starts the keys of the markers persisted for each checkpoint, holding the keys flagged as
+// belonging to it, each one prefixed by its big endian uint32 length
+var checkpointMarkerPrefix = []byte("__checkpoint__")
+
+const keyLengthPrefixSize = 4
+
+// CheckpointStorer is a pruning storer tailored for the trie data, able to flag sets of keys as belonging to
+// checkpoints. The keys of a checkpoint are protected: they are carried over to the current epoch instead of being
+// pruned along with their epoch, and Remove leaves them untouched, until the whole checkpoint is removed.
+// The checkpoints are persisted as markers, carried over as well, so that they survive the restarts
+type CheckpointStorer struct {
+	*PruningStorer
+
+	mutCheckpoints sync.RWMutex
+	checkpoints    map[string]map[string]struct{}
+	numReferences  map[string]int
+}
+
+// NewCheckpointStorer creates a new checkpoint storer, loading the checkpoints persisted in the active epochs
+func NewCheckpointStorer(args ArgsPruningStorer) (*CheckpointStorer, error) {
+	ps, err := newPruningStorer(args)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &CheckpointStorer{
+		PruningStorer: ps,
+		checkpoints:   make(map[string]map[string]struct{}),
+		numReferences: make(map[string]int),
+	}
+	ps.beforeRetire = cs.carryOverProtected
+
+	err = cs.loadMarkers()
+	if err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	ps.subscribe(args.EpochNotifier)
+
+	return cs, nil
+}
+
+func (cs *CheckpointStorer) loadMarkers() error {
+	var err error
+	cs.PruningStorer.RangeKeys(func(key []byte, val []byte) bool {
+		if !bytes.HasPrefix(key, checkpointMarkerPrefix) {
+			return true
+		}
+
+		var keys [][]byte
+		keys, err = decodeMarker(val)
+		if err != nil {
+			err = fmt.Errorf("%w for the marker of checkpoint %s", err, key[len(checkpointMarkerPrefix):])
+			return false
+		}
+
+		cs.addKeysNoLock(string(key[len(checkpointMarkerPrefix):]), keys)
+		return true
+	})
+
+	return err
+}
+
+func markerKey(checkpoint string) []byte {
+	return append(append([]byte{}, checkpointMarkerPrefix...), checkpoint...)
+}
+
+func encodeMarker(keys map[string]struct{}) []byte {
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	buff := make([]byte, 0)
+	prefix := make([]byte, keyLengthPrefixSize)
+	for _, key := range sortedKeys {
+		binary.BigEndian.PutUint32(prefix, uint32(len(key)))
+		buff = append(buff, prefix...)
+		buff = append(buff, key...)
+	}
+
+	return buff
+}
+
+func decodeMarker(buff []byte) ([][]byte, error) {
+	keys := make([][]byte, 0)
+	for len(buff) > 0 {
+		if len(buff) < keyLengthPrefixSize {
+			return nil, fmt.Errorf("%w: truncated key length", common.ErrInvalidValueLength)
+		}
+		keyLength := int(binary.BigEndian.Uint32(buff))
+		buff = buff[keyLengthPrefixSize:]
+		if len(buff) < keyLength {
+			return nil, fmt.Errorf("%w: truncated key", common.ErrInvalidValueLength)
+		}
+
+		keys = append(keys, buff[:keyLength])
+		buff = buff[keyLength:]
+	}
+
+	return keys, nil
+}
+
+func (cs *CheckpointStorer) addKeysNoLock(checkpoint string, keys [][]byte) {
+	checkpointKeys, ok := cs.checkpoints[checkpoint]
+	if !ok {
+		checkpointKeys = make(map[string]struct{})
+		cs.checkpoints[checkpoint] = checkpointKeys
+	}
+
+	for _, key := range keys {
+		_, isFlagged := checkpointKeys[string(key)]
+		if isFlagged {
+			continue
+		}
+
+		checkpointKeys[string(key)] = struct{}{}
+		cs.numReferences[string(key)]++
+	}
+}
+
+// AddToCheckpoint flags the provided keys as belonging to the checkpoint, creating it if needed, and persists its
+// marker in the current epoch
+func (cs *CheckpointStorer) AddToCheckpoint(checkpoint string, keys [][]byte) error {
+	cs.mut.RLock()
+	defer cs.mut.RUnlock()
+
+	cs.mutCheckpoints.Lock()
+	defer cs.mutCheckpoints.Unlock()
+
+	persister, ok := cs.persisters[cs.currentEpoch]
+	if !ok {
+		return common.WrapStorageError(common.ErrDBIsClosed, nil, cs.unitName(), "")
+	}
+
+	cs.addKeysNoLock(checkpoint, keys)
+
+	return persister.Put(markerKey(checkpoint), encodeMarker(cs.checkpoints[checkpoint]))
+}
+
+// RemoveCheckpoint removes the checkpoint along with its keys, except for the ones belonging to other checkpoints as
+// well. No other operation is served meanwhile. The marker is removed first, so that an interrupted removal leaves
+// unreferenced keys behind, instead of a checkpoint missing some of its keys
+func (cs *CheckpointStorer) RemoveCheckpoint(checkpoint string) error {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	cs.mutCheckpoints.Lock()
+	defer cs.mutCheckpoints.Unlock()
+
+	checkpointKeys, ok := cs.checkpoints[checkpoint]
+	if !ok {
+		return fmt.Errorf("%w: %s", common.ErrUnknownCheckpoint, checkpoint)
+	}
+
+	err := cs.removeNoLock(markerKey(checkpoint))
+	if err != nil {
+		return err
+	}
+	delete(cs.checkpoints, checkpoint)
+
+	for key := range checkpointKeys {
+		cs.numReferences[key]--
+		if cs.numReferences[key] > 0 {
+			continue
+		}
+
+		delete(cs.numReferences, key)
+		err = cs.removeNoLock([]byte(key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checkpoints returns the sorted names of the checkpoints
+func (cs *CheckpointStorer) Checkpoints() []string {
+	cs.mutCheckpoints.RLock()
+	defer cs.mutCheckpoints.RUnlock()
+
+	names := make([]string, 0, len(cs.checkpoints))
+	for name := range cs.checkpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsProtected returns true if the key belongs to at least one checkpoint
+func (cs *CheckpointStorer) IsProtected(key []byte) bool {
+	cs.mutCheckpoints.RLock()
+	defer cs.mutCheckpoints.RUnlock()
+
+	return cs.numReferences[string(key)] > 0
+}
+
+// Remove removes the data associated to the given key from both cache and the persisters of all the active epochs,
+// unless the key belongs to a checkpoint
+func (cs *CheckpointStorer) Remove(key []byte) error {
+	if cs.IsProtected(key) {
+		log.Trace("CheckpointStorer.Remove: key belongs to a checkpoint", "key", key)
+		return nil
+	}
+
+	return cs.PruningStorer.Remove(key)
+}
+
+// RemoveFromCurrentEpoch removes the data associated to the given key from both cache and the persister of the
+// current epoch, unless the key belongs to a checkpoint
+func (cs *CheckpointStorer) RemoveFromCurrentEpoch(key []byte) error {
+	if cs.IsProtected(key) {
+		log.Trace("CheckpointStorer.RemoveFromCurrentEpoch: key belongs to a checkpoint", "key", key)
+		return nil
+	}
+
+	return cs.PruningStorer.RemoveFromCurrentEpoch(key)
+}
+
+// RangeKeys iterates over the (key, value) pairs of the persisters of the active epochs, the newest first, skipping
+// the checkpoint markers
+func (cs *CheckpointStorer) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	cs.PruningStorer.RangeKeys(func(key []byte, val []byte) bool {
+		if bytes.HasPrefix(key, checkpointMarkerPrefix) {
+			return true
+		}
+
+		return handler(key, val)
+	})
+}
+
+// carryOverProtected copies the protected keys from the retired persister to the current one, unless already there,
+// and rewrites the markers in the current one
+func (cs *CheckpointStorer) carryOverProtected(retired types.Persister, current types.Persister) error {
+	cs.mutCheckpoints.RLock()
+	defer cs.mutCheckpoints.RUnlock()
+
+	for checkpoint, checkpointKeys := range cs.checkpoints {
+		err := current.Put(markerKey(checkpoint), encodeMarker(checkpointKeys))
+		if err != nil {
+			return err
+		}
+	}
+
+	numCarried := 0
+	for key := range cs.numReferences {
+		key := []byte(key)
+		if current.Has(key) == nil {
+			continue
+		}
+		val, err := retired.Get(key)
+		if err != nil {
+			continue
+		}
+
+		cs.addToFilterNoLock(cs.currentEpoch, key)
+		err = current.Put(key, val)
+		if err != nil {
+			return err
+		}
+		numCarried++
+	}
+	log.Debug("CheckpointStorer: carried over the protected keys", "num keys", numCarried)
+
+	return nil
+}
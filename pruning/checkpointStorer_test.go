@@ -0,0 +1,83 @@
+package pruning_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/pruning"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointStorer_ProtectedKeysShouldSurviveThePruning(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string]*memorydb.DB)
+	args := createArgsPruningStorer(created)
+	args.NumActiveEpochs = 1
+	cs, err := pruning.NewCheckpointStorer(args)
+	require.Nil(t, err)
+
+	_ = cs.Put([]byte("node1"), []byte("value1"))
+	_ = cs.Put([]byte("node2"), []byte("value2"))
+	_ = cs.Put([]byte("node3"), []byte("value3"))
+	err = cs.AddToCheckpoint("checkpoint1", [][]byte{[]byte("node1"), []byte("node2")})
+	assert.Nil(t, err)
+	err = cs.AddToCheckpoint("checkpoint2", [][]byte{[]byte("node2")})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"checkpoint1", "checkpoint2"}, cs.Checkpoints())
+
+	// the trie pruning does not remove the protected keys
+	_ = cs.Remove([]byte("node1"))
+	assert.True(t, cs.IsProtected([]byte("node1")))
+	assert.Nil(t, cs.Has([]byte("node1")))
+
+	err = cs.ChangeEpoch(2)
+	assert.Nil(t, err)
+	cs.ClearCache()
+	assert.Nil(t, created["Epoch_2/Blocks"].Has([]byte("node1")))
+	assert.Nil(t, created["Epoch_2/Blocks"].Has([]byte("node2")))
+	assert.NotNil(t, cs.Has([]byte("node3")))
+
+	numPairs := 0
+	cs.RangeKeys(func(key []byte, val []byte) bool {
+		numPairs++
+		return true
+	})
+	assert.Equal(t, 2, numPairs)
+
+	err = cs.RemoveCheckpoint("checkpoint1")
+	assert.Nil(t, err)
+	assert.NotNil(t, cs.Has([]byte("node1")))
+	assert.Nil(t, cs.Has([]byte("node2")))
+	assert.Equal(t, []string{"checkpoint2"}, cs.Checkpoints())
+
+	err = cs.RemoveCheckpoint("checkpoint1")
+	assert.True(t, errors.Is(err, common.ErrUnknownCheckpoint))
+}
+
+func TestCheckpointStorer_ShouldReloadTheCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	persister := memorydb.New()
+	args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+	args.NumActiveEpochs = 1
+	args.PersisterFactory = &testscommon.PersisterFactoryStub{
+		CreateCalled: func(path string) (types.Persister, error) {
+			return persister, nil
+		},
+	}
+	cs, _ := pruning.NewCheckpointStorer(args)
+	_ = cs.Put([]byte("node"), []byte("value"))
+	_ = cs.AddToCheckpoint("checkpoint", [][]byte{[]byte("node")})
+	_ = cs.Close()
+
+	cs, err := pruning.NewCheckpointStorer(args)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"checkpoint"}, cs.Checkpoints())
+	assert.True(t, cs.IsProtected([]byte("node")))
+}
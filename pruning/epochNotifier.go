@@ -0,0 +1,91 @@
+package pruning
+
+import (
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.EpochNotifier = (*EpochNotifier)(nil)
+
+// EpochNotifier notifies the registered subscribers, in their registration order, each time a newer epoch is
+// confirmed. The subscribers are called outside the lock, so that they are allowed to unregister themselves
+type EpochNotifier struct {
+	mut          sync.RWMutex
+	currentEpoch uint32
+	handlers     []types.EpochSubscriber
+}
+
+// NewEpochNotifier creates a new epoch notifier, starting at the provided epoch
+func NewEpochNotifier(startEpoch uint32) *EpochNotifier {
+	return &EpochNotifier{
+		currentEpoch: startEpoch,
+		handlers:     make([]types.EpochSubscriber, 0),
+	}
+}
+
+// RegisterNotifyHandler adds a subscriber, notifying it right away of the current epoch
+func (en *EpochNotifier) RegisterNotifyHandler(handler types.EpochSubscriber) {
+	if check.IfNil(handler) {
+		return
+	}
+
+	en.mut.Lock()
+	en.handlers = append(en.handlers, handler)
+	epoch := en.currentEpoch
+	en.mut.Unlock()
+
+	handler.EpochConfirmed(epoch)
+}
+
+// UnregisterNotifyHandler removes a subscriber, which is no longer notified
+func (en *EpochNotifier) UnregisterNotifyHandler(handler types.EpochSubscriber) {
+	en.mut.Lock()
+	defer en.mut.Unlock()
+
+	for i, registered := range en.handlers {
+		if registered == handler {
+			en.handlers = append(en.handlers[:i:i], en.handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// CheckEpoch confirms the provided epoch, notifying the subscribers if it is newer than the current one
+func (en *EpochNotifier) CheckEpoch(epoch uint32) {
+	en.mut.Lock()
+	if epoch <= en.currentEpoch {
+		en.mut.Unlock()
+		return
+	}
+	en.currentEpoch = epoch
+	handlers := append([]types.EpochSubscriber{}, en.handlers...)
+	en.mut.Unlock()
+
+	log.Debug("EpochNotifier: confirmed a new epoch", "epoch", epoch, "num subscribers", len(handlers))
+	for _, handler := range handlers {
+		handler.EpochConfirmed(epoch)
+	}
+}
+
+// CurrentEpoch returns the last confirmed epoch
+func (en *EpochNotifier) CurrentEpoch() uint32 {
+	en.mut.RLock()
+	defer en.mut.RUnlock()
+
+	return en.currentEpoch
+}
+
+// NumSubscribers returns the number of registered subscribers
+func (en *EpochNotifier) NumSubscribers() int {
+	en.mut.RLock()
+	defer en.mut.RUnlock()
+
+	return len(en.handlers)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (en *EpochNotifier) IsInterfaceNil() bool {
+	return en == nil
+}
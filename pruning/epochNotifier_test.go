@@ -0,0 +1,105 @@
+package pruning_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/pruning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type epochSubscriberStub struct {
+	epochs []uint32
+}
+
+func (stub *epochSubscriberStub) EpochConfirmed(epoch uint32) {
+	stub.epochs = append(stub.epochs, epoch)
+}
+
+func (stub *epochSubscriberStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestEpochNotifier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should notify the newer epochs only", func(t *testing.T) {
+		t.Parallel()
+
+		en := pruning.NewEpochNotifier(2)
+		subscriber := &epochSubscriberStub{}
+		en.RegisterNotifyHandler(subscriber)
+		en.RegisterNotifyHandler(nil)
+		assert.Equal(t, 1, en.NumSubscribers())
+
+		en.CheckEpoch(2)
+		en.CheckEpoch(3)
+		en.CheckEpoch(1)
+		en.CheckEpoch(5)
+		assert.Equal(t, []uint32{2, 3, 5}, subscriber.epochs)
+		assert.Equal(t, uint32(5), en.CurrentEpoch())
+
+		en.UnregisterNotifyHandler(subscriber)
+		en.CheckEpoch(6)
+		assert.Equal(t, []uint32{2, 3, 5}, subscriber.epochs)
+		assert.Equal(t, 0, en.NumSubscribers())
+	})
+	t.Run("should rotate the subscribed pruning storer", func(t *testing.T) {
+		t.Parallel()
+
+		name := "TestEpochNotifier_PruningStorer"
+		chEvents := make(chan *monitoring.EpochRotatedEvent, 10)
+		monitoring.GlobalEventBus().Subscribe(name, func(event monitoring.Event) {
+			rotated, ok := event.(*monitoring.EpochRotatedEvent)
+			if ok && rotated.Path == name+"/Epoch_{epoch}" {
+				chEvents <- rotated
+			}
+		})
+		defer monitoring.GlobalEventBus().Unsubscribe(name)
+
+		en := pruning.NewEpochNotifier(1)
+		created := make(map[string]*memorydb.DB)
+		args := createArgsPruningStorer(created)
+		args.PathTemplate = name + "/Epoch_{epoch}"
+		args.EpochNotifier = en
+		ps, err := pruning.NewPruningStorer(args)
+		require.Nil(t, err)
+		require.Nil(t, ps.Put([]byte("key"), []byte("value")))
+
+		en.CheckEpoch(3)
+		assert.Equal(t, []uint32{2, 3}, ps.ActiveEpochs())
+		assert.Equal(t, 0, created[name+"/Epoch_1"].Len())
+
+		select {
+		case event := <-chEvents:
+			assert.Equal(t, uint32(3), event.Epoch)
+			assert.Equal(t, []uint32{0, 1}, event.RetiredEpochs)
+		case <-time.After(time.Second):
+			assert.Fail(t, "the epoch rotated event should have been published")
+		}
+
+		require.Nil(t, ps.Close())
+		assert.Equal(t, 0, en.NumSubscribers())
+		en.CheckEpoch(4)
+		assert.Equal(t, []uint32{2, 3}, ps.ActiveEpochs())
+		assert.Nil(t, created[name+"/Epoch_4"])
+	})
+	t.Run("should catch up with the current epoch on subscription", func(t *testing.T) {
+		t.Parallel()
+
+		en := pruning.NewEpochNotifier(4)
+		args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+		args.EpochNotifier = en
+		cs, err := pruning.NewCheckpointStorer(args)
+		require.Nil(t, err)
+		defer func() {
+			_ = cs.Close()
+		}()
+
+		assert.Equal(t, []uint32{3, 4}, cs.ActiveEpochs())
+		assert.Equal(t, 1, en.NumSubscribers())
+	})
+}
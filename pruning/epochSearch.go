@@ -0,0 +1,94 @@
+package pruning
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// SearchOrder selects the order the epochs are searched in, which also decides the epoch serving a key held by
+// several epochs
+type SearchOrder string
+
+const (
+	// LatestFirst searches the newest epochs first, serving the last written value of a key
+	LatestFirst SearchOrder = "LatestFirst"
+	// OldestFirst searches the oldest epochs first, serving the first written value of a key
+	OldestFirst SearchOrder = "OldestFirst"
+)
+
+func newEpochHints(size int) (*types.TypedCacher[[]byte, uint32], error) {
+	if size < 0 {
+		return nil, fmt.Errorf("%w: EpochHintsCacheSize should not be negative", common.ErrInvalidConfig)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	cacher, err := lrucache.NewCache(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTypedCacher[[]byte, uint32](cacher)
+}
+
+// searchEpochsNoLock returns the epochs to be searched for the key, in the configured order, the one remembered as
+// holding the key first. The archived epochs, older than the active ones, are included if requested
+func (ps *PruningStorer) searchEpochsNoLock(key []byte, includeArchived bool) []uint32 {
+	epochs := ps.activeEpochs()
+	if includeArchived {
+		for epoch := int64(epochs[len(epochs)-1]) - 1; epoch >= 0; epoch-- {
+			epochs = append(epochs, uint32(epoch))
+		}
+	}
+	if ps.searchOrder == OldestFirst {
+		for i, j := 0, len(epochs)-1; i < j; i, j = i+1, j-1 {
+			epochs[i], epochs[j] = epochs[j], epochs[i]
+		}
+	}
+
+	hinted, ok := ps.hintedEpoch(key)
+	if !ok {
+		return epochs
+	}
+	for i, epoch := range epochs {
+		if epoch == hinted {
+			copy(epochs[1:i+1], epochs[:i])
+			epochs[0] = hinted
+			break
+		}
+	}
+
+	return epochs
+}
+
+func (ps *PruningStorer) hintedEpoch(key []byte) (uint32, bool) {
+	if ps.epochHints == nil {
+		return 0, false
+	}
+
+	return ps.epochHints.Get(key)
+}
+
+// rememberEpoch records the epoch which served the key, to be searched first by the next lookups
+func (ps *PruningStorer) rememberEpoch(key []byte, epoch uint32) {
+	if ps.epochHints != nil {
+		ps.epochHints.Put(key, epoch, 4)
+	}
+}
+
+// forgetEpoch drops the epoch recorded for the key, as a write or a removal may move the key to another epoch
+func (ps *PruningStorer) forgetEpoch(key []byte) {
+	if ps.epochHints != nil {
+		ps.epochHints.Remove(key)
+	}
+}
+
+func (ps *PruningStorer) clearEpochHints() {
+	if ps.epochHints != nil {
+		ps.epochHints.Clear()
+	}
+}
@@ -0,0 +1,794 @@
+package pruning
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/data"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.StorerWithPutInEpoch = (*PruningStorer)(nil)
+var _ types.EpochSubscriber = (*PruningStorer)(nil)
+
+var log = logger.GetOrCreate("storage/pruning")
+
+// ArgsPruningStorer holds the arguments needed to create a PruningStorer
+type ArgsPruningStorer struct {
+	Cacher           types.Cacher
+	PersisterFactory types.PersisterFactory
+	// PathTemplate is the path of the persisters, containing the common.EpochPlaceholder, e.g. "db/Epoch_{epoch}/Blocks"
+	PathTemplate string
+	// NumActiveEpochs is the number of the last epochs whose persisters are kept, the older ones being destroyed
+	NumActiveEpochs uint32
+	// StartEpoch is the epoch the writes are routed to on creation
+	StartEpoch uint32
+	// FullArchive makes the persisters of the epochs left out of the active window be closed instead of destroyed, to
+	// be reopened read only by the historical queries
+	FullArchive bool
+	// ArchivePathTemplate, if set in full archive mode, is the path the closed persisters are moved to, containing the
+	// common.EpochPlaceholder
+	ArchivePathTemplate string
+	// ArchivePersisterFactory opens, in full archive mode, the persisters of the archived epochs. It should create
+	// read only persisters
+	ArchivePersisterFactory types.PersisterFactory
+	// EpochNotifier, if set, moves the active epochs window each time a newer epoch is confirmed
+	EpochNotifier types.EpochNotifier
+	// BloomFilter, if set, sizes the bloom filter kept for each active epoch, holding the keys of its persister, so that
+	// the reads skip the persisters surely not holding the searched key
+	BloomFilter *bloom.Config
+	// SearchOrder is the order the epochs are searched in, LatestFirst if not set
+	SearchOrder SearchOrder
+	// EpochHintsCacheSize, if positive, is the number of keys whose serving epoch is remembered, to be searched first
+	// by the next lookups of the same keys
+	EpochHintsCacheSize int
+}
+
+// PruningStorer is a storer holding one persister per epoch, in front of which sits a cache. The writes go to the
+// persister of the current epoch, while the reads search backwards through the persisters of the last NumActiveEpochs
+// epochs. The persisters of the epochs left out of this window, as the epoch changes, are destroyed, bounding the
+// disk usage. In full archive mode, they are closed instead, optionally moved to the archive path, and reopened read
+// only, on demand, by GetFromEpoch and SearchFirst
+type PruningStorer struct {
+	mut              sync.RWMutex
+	cacher           *types.TypedCacher[[]byte, []byte]
+	persisterFactory types.PersisterFactory
+	pathTemplate     string
+	numActiveEpochs  uint32
+	currentEpoch     uint32
+	epochForPut      uint32
+	persisters       map[uint32]types.Persister
+	bloomConfig      *bloom.Config
+	filters          map[uint32]*bloom.Filter
+	searchOrder      SearchOrder
+	epochHints       *types.TypedCacher[[]byte, uint32]
+
+	fullArchive             bool
+	archivePathTemplate     string
+	archivePersisterFactory types.PersisterFactory
+	mutArchived             sync.Mutex
+	archived                map[uint32]types.Persister
+
+	epochNotifier types.EpochNotifier
+
+	// beforeRetire, if set, is called under the write lock with the persister of an epoch about to be retired, along
+	// with the persister of the current epoch, so that the data to be kept can be carried over
+	beforeRetire func(retired types.Persister, current types.Persister) error
+}
+
+// NewPruningStorer creates a new pruning storer, opening the persisters of the active epochs ending at the start epoch.
+// If an epoch notifier is provided, the storer subscribes to it, catching up with its current epoch
+func NewPruningStorer(args ArgsPruningStorer) (*PruningStorer, error) {
+	ps, err := newPruningStorer(args)
+	if err != nil {
+		return nil, err
+	}
+	ps.subscribe(args.EpochNotifier)
+
+	return ps, nil
+}
+
+func newPruningStorer(args ArgsPruningStorer) (*PruningStorer, error) {
+	cacher, err := types.NewTypedCacher[[]byte, []byte](args.Cacher)
+	if err != nil {
+		return nil, err
+	}
+	if check.IfNil(args.PersisterFactory) {
+		return nil, common.ErrNilPersisterFactory
+	}
+	if !strings.Contains(args.PathTemplate, common.EpochPlaceholder) {
+		return nil, fmt.Errorf("%w: PathTemplate should contain %s", common.ErrInvalidConfig, common.EpochPlaceholder)
+	}
+	if args.NumActiveEpochs == 0 {
+		return nil, fmt.Errorf("%w: NumActiveEpochs should be positive", common.ErrInvalidConfig)
+	}
+	if len(args.SearchOrder) == 0 {
+		args.SearchOrder = LatestFirst
+	}
+	if args.SearchOrder != LatestFirst && args.SearchOrder != OldestFirst {
+		return nil, fmt.Errorf("%w: unknown SearchOrder %s", common.ErrInvalidConfig, args.SearchOrder)
+	}
+	epochHints, err := newEpochHints(args.EpochHintsCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	if args.FullArchive {
+		if check.IfNil(args.ArchivePersisterFactory) {
+			return nil, fmt.Errorf("%w for the archived epochs", common.ErrNilPersisterFactory)
+		}
+		if len(args.ArchivePathTemplate) > 0 && !strings.Contains(args.ArchivePathTemplate, common.EpochPlaceholder) {
+			return nil, fmt.Errorf("%w: ArchivePathTemplate should contain %s", common.ErrInvalidConfig, common.EpochPlaceholder)
+		}
+	}
+
+	ps := &PruningStorer{
+		cacher:           cacher,
+		persisterFactory: args.PersisterFactory,
+		pathTemplate:     args.PathTemplate,
+		numActiveEpochs:  args.NumActiveEpochs,
+		currentEpoch:     args.StartEpoch,
+		epochForPut:      args.StartEpoch,
+		persisters:       make(map[uint32]types.Persister),
+		bloomConfig:      args.BloomFilter,
+		filters:          make(map[uint32]*bloom.Filter),
+		searchOrder:      args.SearchOrder,
+		epochHints:       epochHints,
+
+		fullArchive:             args.FullArchive,
+		archivePathTemplate:     args.ArchivePathTemplate,
+		archivePersisterFactory: args.ArchivePersisterFactory,
+		archived:                make(map[uint32]types.Persister),
+	}
+
+	for _, epoch := range ps.activeEpochs() {
+		err := ps.openNoLock(epoch)
+		if err != nil {
+			_ = ps.closePersistersNoLock()
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+// subscribe registers the storer to the epoch notifier, if any, once fully set up, as it is notified right away
+func (ps *PruningStorer) subscribe(epochNotifier types.EpochNotifier) {
+	if check.IfNil(epochNotifier) {
+		return
+	}
+
+	ps.epochNotifier = epochNotifier
+	epochNotifier.RegisterNotifyHandler(ps)
+}
+
+// EpochConfirmed is called by the epoch notifier, moving the active epochs window when a newer epoch is confirmed
+func (ps *PruningStorer) EpochConfirmed(epoch uint32) {
+	ps.mut.RLock()
+	isNewer := epoch > ps.currentEpoch
+	ps.mut.RUnlock()
+	if !isNewer {
+		return
+	}
+
+	err := ps.ChangeEpoch(epoch)
+	if err != nil {
+		log.Error("PruningStorer: could not change the epoch", "path", ps.pathTemplate, "epoch", epoch, "error", err)
+	}
+}
+
+// PathForEpoch returns the path of the persister of the provided epoch
+func (ps *PruningStorer) PathForEpoch(epoch uint32) string {
+	return strings.ReplaceAll(ps.pathTemplate, common.EpochPlaceholder, strconv.FormatUint(uint64(epoch), 10))
+}
+
+// ArchivePathForEpoch returns the path of the persister of the provided epoch, once archived
+func (ps *PruningStorer) ArchivePathForEpoch(epoch uint32) string {
+	if len(ps.archivePathTemplate) == 0 {
+		return ps.PathForEpoch(epoch)
+	}
+
+	return strings.ReplaceAll(ps.archivePathTemplate, common.EpochPlaceholder, strconv.FormatUint(uint64(epoch), 10))
+}
+
+// activeEpochs returns the epochs of the window ending at the current epoch, the newest first
+func (ps *PruningStorer) activeEpochs() []uint32 {
+	epochs := make([]uint32, 0, ps.numActiveEpochs)
+	for i := uint32(0); i < ps.numActiveEpochs && i <= ps.currentEpoch; i++ {
+		epochs = append(epochs, ps.currentEpoch-i)
+	}
+
+	return epochs
+}
+
+// activePersisters returns the open persisters of the active epochs, the newest first
+func (ps *PruningStorer) activePersisters() []types.Persister {
+	persisters := make([]types.Persister, 0, ps.numActiveEpochs)
+	for _, epoch := range ps.activeEpochs() {
+		persister, ok := ps.persisters[epoch]
+		if ok {
+			persisters = append(persisters, persister)
+		}
+	}
+
+	return persisters
+}
+
+func (ps *PruningStorer) isActive(epoch uint32) bool {
+	return epoch <= ps.currentEpoch && ps.currentEpoch-epoch < ps.numActiveEpochs
+}
+
+func (ps *PruningStorer) openNoLock(epoch uint32) error {
+	_, ok := ps.persisters[epoch]
+	if ok {
+		return nil
+	}
+
+	persister, err := ps.persisterFactory.Create(ps.PathForEpoch(epoch))
+	if err != nil {
+		return fmt.Errorf("%w while opening the persister of epoch %d", err, epoch)
+	}
+	if ps.bloomConfig != nil {
+		filter, errFilter := newBloomFilter(*ps.bloomConfig, persister)
+		if errFilter != nil {
+			_ = persister.Close()
+			return errFilter
+		}
+		ps.filters[epoch] = filter
+	}
+	ps.persisters[epoch] = persister
+
+	return nil
+}
+
+func newBloomFilter(config bloom.Config, persister types.Persister) (*bloom.Filter, error) {
+	filter, err := bloom.NewFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	persister.RangeKeys(func(key []byte, _ []byte) bool {
+		filter.Add(key)
+		return true
+	})
+
+	return filter, nil
+}
+
+// mayContainNoLock returns false if the persister of the epoch surely does not hold the key. The archived epochs have
+// no bloom filter
+func (ps *PruningStorer) mayContainNoLock(epoch uint32, key []byte) bool {
+	filter, ok := ps.filters[epoch]
+
+	return !ok || filter.MayContain(key)
+}
+
+func (ps *PruningStorer) addToFilterNoLock(epoch uint32, key []byte) {
+	filter, ok := ps.filters[epoch]
+	if ok {
+		filter.Add(key)
+	}
+}
+
+// ChangeEpoch moves the active epochs window so that it ends at the provided epoch, the writes being routed to it from
+// now on. The persisters of the epochs left out of the window are destroyed or, in full archive mode, archived
+func (ps *PruningStorer) ChangeEpoch(epoch uint32) error {
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+
+	if epoch < ps.currentEpoch {
+		return fmt.Errorf("%w: epoch %d is before the current epoch %d", common.ErrInvalidEpoch, epoch, ps.currentEpoch)
+	}
+
+	previousEpoch := ps.currentEpoch
+	ps.currentEpoch = epoch
+	ps.epochForPut = epoch
+	err := ps.openNoLock(epoch)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+	retiredEpochs := make([]uint32, 0)
+	for oldEpoch, persister := range ps.persisters {
+		if ps.isActive(oldEpoch) {
+			continue
+		}
+		if ps.beforeRetire != nil {
+			// the persister is kept open on failure, so that the carrying over is retried on the next epoch change
+			errCarry := ps.beforeRetire(persister, ps.persisters[ps.currentEpoch])
+			if errCarry != nil {
+				errs = append(errs, fmt.Errorf("%w while retiring the persister of epoch %d", errCarry, oldEpoch))
+				continue
+			}
+		}
+
+		delete(ps.persisters, oldEpoch)
+		delete(ps.filters, oldEpoch)
+		retiredEpochs = append(retiredEpochs, oldEpoch)
+		errRetire := ps.retire(oldEpoch, persister)
+		if errRetire != nil {
+			errs = append(errs, errRetire)
+		}
+	}
+
+	if epoch != previousEpoch {
+		sort.Slice(retiredEpochs, func(i, j int) bool {
+			return retiredEpochs[i] < retiredEpochs[j]
+		})
+		monitoring.PublishEvent(monitoring.NewEpochRotatedEvent(ps.pathTemplate, epoch, retiredEpochs))
+	}
+
+	return errors.Join(errs...)
+}
+
+// retire destroys the persister of an epoch left out of the active window or, in full archive mode, archives it. The
+// volatile persisters are archived by keeping them open
+func (ps *PruningStorer) retire(epoch uint32, persister types.Persister) error {
+	if !ps.fullArchive {
+		err := persister.Destroy()
+		if err != nil {
+			return fmt.Errorf("%w while destroying the persister of epoch %d", err, epoch)
+		}
+
+		log.Debug("PruningStorer: destroyed the persister of an old epoch", "path", ps.PathForEpoch(epoch))
+		return nil
+	}
+
+	if types.CapabilitiesOf(persister).Durability == types.VolatileDurability {
+		// a volatile persister has nothing on disk to move to the archive, so it is kept open to serve the archived epoch
+		ps.mutArchived.Lock()
+		ps.archived[epoch] = persister
+		ps.mutArchived.Unlock()
+
+		log.Debug("PruningStorer: kept the volatile persister of an old epoch as archived", "epoch", epoch)
+		return nil
+	}
+
+	err := persister.Close()
+	if err != nil {
+		return fmt.Errorf("%w while closing the persister of epoch %d", err, epoch)
+	}
+
+	path, archivePath := ps.PathForEpoch(epoch), ps.ArchivePathForEpoch(epoch)
+	if path != archivePath {
+		err = os.MkdirAll(filepath.Dir(archivePath), os.ModePerm)
+		if err == nil {
+			err = os.Rename(path, archivePath)
+		}
+		if err != nil {
+			return fmt.Errorf("%w while moving the persister of epoch %d to the archive", err, epoch)
+		}
+	}
+
+	log.Debug("PruningStorer: archived the persister of an old epoch", "path", archivePath)
+	return nil
+}
+
+// archivedPersister returns the read only persister of an archived epoch, opening it on first use
+func (ps *PruningStorer) archivedPersister(epoch uint32) (types.Persister, error) {
+	ps.mutArchived.Lock()
+	defer ps.mutArchived.Unlock()
+
+	persister, ok := ps.archived[epoch]
+	if ok {
+		return persister, nil
+	}
+
+	persister, err := ps.archivePersisterFactory.Create(ps.ArchivePathForEpoch(epoch))
+	if err != nil {
+		return nil, fmt.Errorf("%w while opening the archived persister of epoch %d", err, epoch)
+	}
+	ps.archived[epoch] = persister
+
+	return persister, nil
+}
+
+// persisterForRead returns the persister of an active epoch or, in full archive mode, of an archived one
+func (ps *PruningStorer) persisterForRead(epoch uint32) (types.Persister, error) {
+	persister, ok := ps.persisters[epoch]
+	if ok {
+		return persister, nil
+	}
+	if ps.fullArchive && epoch < ps.currentEpoch {
+		return ps.archivedPersister(epoch)
+	}
+
+	return nil, fmt.Errorf("%w: epoch %d is not active", common.ErrInvalidEpoch, epoch)
+}
+
+// SetEpochForPutOperation routes the writes to the provided epoch, which should be an active one, until the next
+// epoch change
+func (ps *PruningStorer) SetEpochForPutOperation(epoch uint32) {
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+
+	if !ps.isActive(epoch) {
+		log.Warn("PruningStorer.SetEpochForPutOperation: epoch is not active", "epoch", epoch, "current epoch", ps.currentEpoch)
+		return
+	}
+
+	ps.epochForPut = epoch
+}
+
+// Put adds the data to both cache and the persister of the current epoch
+func (ps *PruningStorer) Put(key, data []byte) error {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	return ps.putInEpochNoLock(key, data, ps.epochForPut)
+}
+
+// PutInEpoch adds the data to both cache and the persister of the provided epoch, which should be an active one
+func (ps *PruningStorer) PutInEpoch(key, data []byte, epoch uint32) error {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	return ps.putInEpochNoLock(key, data, epoch)
+}
+
+func (ps *PruningStorer) putInEpochNoLock(key, data []byte, epoch uint32) error {
+	persister, ok := ps.persisters[epoch]
+	if !ok {
+		return fmt.Errorf("%w: epoch %d is not active", common.ErrInvalidEpoch, epoch)
+	}
+
+	ps.cacher.Put(key, data, len(data))
+	ps.forgetEpoch(key)
+	ps.addToFilterNoLock(epoch, key)
+	err := persister.Put(key, data)
+	if err != nil {
+		ps.cacher.Remove(key)
+		return err
+	}
+
+	return nil
+}
+
+// Get searches the key in the cache, then in the persisters of the active epochs, in the configured order. The value
+// found in a persister is added to the cache
+func (ps *PruningStorer) Get(key []byte) ([]byte, error) {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	val, ok := ps.getFromCache(key)
+	if ok {
+		return val, nil
+	}
+
+	for _, epoch := range ps.searchEpochsNoLock(key, false) {
+		persister, isOpen := ps.persisters[epoch]
+		if !isOpen || !ps.mayContainNoLock(epoch, key) {
+			continue
+		}
+
+		val, err := persister.Get(key)
+		if err == nil {
+			ps.cacher.Put(key, val, len(val))
+			ps.rememberEpoch(key, epoch)
+			return val, nil
+		}
+	}
+	ps.forgetEpoch(key)
+
+	return nil, ps.errKeyNotFound(key, fmt.Sprintf("in the last %d epochs", ps.numActiveEpochs))
+}
+
+// unitName returns the last element of the path template, naming the unit in the errors
+func (ps *PruningStorer) unitName() string {
+	return filepath.Base(ps.pathTemplate)
+}
+
+func (ps *PruningStorer) errKeyNotFound(key []byte, searched string) error {
+	return common.WrapStorageError(fmt.Errorf("%w %s", common.ErrKeyNotFound, searched), key, ps.unitName(), "")
+}
+
+func (ps *PruningStorer) getFromCache(key []byte) ([]byte, bool) {
+	return ps.cacher.Get(key)
+}
+
+// SearchFirst returns the value of the key from the first epoch holding it, in the configured order. In full archive
+// mode, the archived epochs are searched as well, as being older than the active ones
+func (ps *PruningStorer) SearchFirst(key []byte) ([]byte, error) {
+	if !ps.fullArchive {
+		return ps.Get(key)
+	}
+
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	val, ok := ps.getFromCache(key)
+	if ok {
+		return val, nil
+	}
+
+	for _, epoch := range ps.searchEpochsNoLock(key, true) {
+		persister, errOpen := ps.persisterForRead(epoch)
+		if errOpen != nil {
+			log.Trace("PruningStorer.SearchFirst: epoch not available", "epoch", epoch, "error", errOpen)
+			continue
+		}
+		if !ps.mayContainNoLock(epoch, key) {
+			continue
+		}
+
+		val, errGet := persister.Get(key)
+		if errGet == nil {
+			ps.cacher.Put(key, val, len(val))
+			ps.rememberEpoch(key, epoch)
+			return val, nil
+		}
+	}
+	ps.forgetEpoch(key)
+
+	return nil, ps.errKeyNotFound(key, "in any epoch")
+}
+
+// GetFromEpoch searches the key in the cache, then in the persister of the provided epoch, which should be an active
+// one, unless in full archive mode. The other epochs are not searched
+func (ps *PruningStorer) GetFromEpoch(key []byte, epoch uint32) ([]byte, error) {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	persister, err := ps.persisterForRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.getFromPersisterNoLock(key, epoch, persister)
+}
+
+func (ps *PruningStorer) getFromPersisterNoLock(key []byte, epoch uint32, persister types.Persister) ([]byte, error) {
+	val, ok := ps.getFromCache(key)
+	if ok {
+		return val, nil
+	}
+	if !ps.mayContainNoLock(epoch, key) {
+		return nil, ps.errKeyNotFound(key, fmt.Sprintf("in epoch %d", epoch))
+	}
+
+	val, err := persister.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	ps.cacher.Put(key, val, len(val))
+
+	return val, nil
+}
+
+// GetBulkFromEpoch returns the (key, value) pairs found in the cache or in the persister of the provided epoch,
+// skipping the missing keys. The persister is resolved once for all the keys, an epoch which is neither active nor,
+// in full archive mode, archived being reported as an error instead of yielding no pairs
+func (ps *PruningStorer) GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]data.KeyValuePair, error) {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	persister, err := ps.persisterForRead(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]data.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		val, errGet := ps.getFromPersisterNoLock(key, epoch, persister)
+		if errGet != nil {
+			log.Trace("PruningStorer.GetBulkFromEpoch: key not found", "key", key, "epoch", epoch, "error", errGet)
+			continue
+		}
+
+		results = append(results, data.KeyValuePair{Key: key, Value: val})
+	}
+
+	return results, nil
+}
+
+// Has checks if the key is in the cache or in the persister of any active epoch
+func (ps *PruningStorer) Has(key []byte) error {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	if ps.cacher.Has(key) {
+		return nil
+	}
+
+	for _, epoch := range ps.searchEpochsNoLock(key, false) {
+		persister, isOpen := ps.persisters[epoch]
+		if !isOpen || !ps.mayContainNoLock(epoch, key) {
+			continue
+		}
+
+		err := persister.Has(key)
+		if err == nil {
+			ps.rememberEpoch(key, epoch)
+			return nil
+		}
+	}
+	ps.forgetEpoch(key)
+
+	return ps.errKeyNotFound(key, fmt.Sprintf("in the last %d epochs", ps.numActiveEpochs))
+}
+
+// RemoveFromCurrentEpoch removes the data associated to the given key from both cache and the persister of the
+// current epoch
+func (ps *PruningStorer) RemoveFromCurrentEpoch(key []byte) error {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	ps.cacher.Remove(key)
+	ps.forgetEpoch(key)
+	persister, ok := ps.persisters[ps.currentEpoch]
+	if !ok {
+		return common.WrapStorageError(common.ErrDBIsClosed, key, ps.unitName(), "")
+	}
+
+	return persister.Remove(key)
+}
+
+// Remove removes the data associated to the given key from both cache and the persisters of all the active epochs
+func (ps *PruningStorer) Remove(key []byte) error {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	return ps.removeNoLock(key)
+}
+
+func (ps *PruningStorer) removeNoLock(key []byte) error {
+	ps.cacher.Remove(key)
+	ps.forgetEpoch(key)
+
+	errs := make([]error, 0)
+	for _, persister := range ps.activePersisters() {
+		err := persister.Remove(key)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetOldestEpoch returns the oldest active epoch or, in full archive mode, the first epoch
+func (ps *PruningStorer) GetOldestEpoch() (uint32, error) {
+	if ps.fullArchive {
+		return 0, nil
+	}
+
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	epochs := ps.activeEpochs()
+
+	return epochs[len(epochs)-1], nil
+}
+
+// RangeKeys iterates over the (key, value) pairs of the persisters of the active epochs, the newest first. A key found
+// in several epochs is only provided with its newest value
+func (ps *PruningStorer) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	seen := make(map[string]struct{})
+	for _, persister := range ps.activePersisters() {
+		shouldContinue := true
+		persister.RangeKeys(func(key []byte, val []byte) bool {
+			_, isDuplicate := seen[string(key)]
+			if isDuplicate {
+				return true
+			}
+			seen[string(key)] = struct{}{}
+
+			shouldContinue = handler(key, val)
+			return shouldContinue
+		})
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// ActiveEpochs returns the sorted epochs whose persisters are open
+func (ps *PruningStorer) ActiveEpochs() []uint32 {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	epochs := ps.activeEpochs()
+	sort.Slice(epochs, func(i, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	return epochs
+}
+
+// ClearCache cleans up the entire cache, along with the remembered epochs of the keys
+func (ps *PruningStorer) ClearCache() {
+	ps.cacher.Clear()
+	ps.clearEpochHints()
+}
+
+// DestroyUnit cleans up the cache and destroys the persisters of all the active epochs. The archived epochs are left
+// untouched
+func (ps *PruningStorer) DestroyUnit() error {
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+
+	ps.cacher.Clear()
+	ps.clearEpochHints()
+
+	errs := ps.closeArchivedNoLock()
+	for epoch, persister := range ps.persisters {
+		err := persister.Destroy()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w while destroying the persister of epoch %d", err, epoch))
+		}
+	}
+	ps.persisters = make(map[uint32]types.Persister)
+	ps.filters = make(map[uint32]*bloom.Filter)
+
+	return errors.Join(errs...)
+}
+
+// Close unsubscribes from the epoch notifier, if any, clears the cache and closes the persisters of all the active
+// epochs, along with the opened archived ones
+func (ps *PruningStorer) Close() error {
+	if !check.IfNil(ps.epochNotifier) {
+		ps.epochNotifier.UnregisterNotifyHandler(ps)
+	}
+
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+
+	ps.cacher.Clear()
+	ps.clearEpochHints()
+	errs := ps.closeArchivedNoLock()
+
+	return errors.Join(append(errs, ps.closePersistersNoLock())...)
+}
+
+func (ps *PruningStorer) closeArchivedNoLock() []error {
+	ps.mutArchived.Lock()
+	defer ps.mutArchived.Unlock()
+
+	errs := make([]error, 0)
+	for epoch, persister := range ps.archived {
+		err := persister.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w while closing the archived persister of epoch %d", err, epoch))
+		}
+	}
+	ps.archived = make(map[uint32]types.Persister)
+
+	return errs
+}
+
+func (ps *PruningStorer) closePersistersNoLock() error {
+	errs := make([]error, 0)
+	for epoch, persister := range ps.persisters {
+		err := persister.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w while closing the persister of epoch %d", err, epoch))
+		}
+	}
+	ps.persisters = make(map[uint32]types.Persister)
+	ps.filters = make(map[uint32]*bloom.Filter)
+
+	return errors.Join(errs...)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ps *PruningStorer) IsInterfaceNil() bool {
+	return ps == nil
+}
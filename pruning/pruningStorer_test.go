@@ -0,0 +1,400 @@
+package pruning_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/pruning"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgsPruningStorer(created map[string]*memorydb.DB) pruning.ArgsPruningStorer {
+	cache, _ := lrucache.NewCache(10)
+
+	return pruning.ArgsPruningStorer{
+		Cacher: cache,
+		PersisterFactory: &testscommon.PersisterFactoryStub{
+			CreateCalled: func(path string) (types.Persister, error) {
+				created[path] = memorydb.New()
+				return created[path], nil
+			},
+		},
+		PathTemplate:    "Epoch_{epoch}/Blocks",
+		NumActiveEpochs: 2,
+		StartEpoch:      1,
+	}
+}
+
+func TestNewPruningStorer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil cacher should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+		args.Cacher = nil
+		ps, err := pruning.NewPruningStorer(args)
+		assert.Nil(t, ps)
+		assert.Equal(t, common.ErrNilCacher, err)
+	})
+	t.Run("nil persister factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+		args.PersisterFactory = nil
+		ps, err := pruning.NewPruningStorer(args)
+		assert.Nil(t, ps)
+		assert.Equal(t, common.ErrNilPersisterFactory, err)
+	})
+	t.Run("invalid config should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+		args.PathTemplate = "Blocks"
+		_, err := pruning.NewPruningStorer(args)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+		args = createArgsPruningStorer(make(map[string]*memorydb.DB))
+		args.NumActiveEpochs = 0
+		_, err = pruning.NewPruningStorer(args)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("should open the persisters of the active epochs", func(t *testing.T) {
+		t.Parallel()
+
+		created := make(map[string]*memorydb.DB)
+		ps, err := pruning.NewPruningStorer(createArgsPruningStorer(created))
+		require.Nil(t, err)
+		assert.Equal(t, []uint32{0, 1}, ps.ActiveEpochs())
+		assert.Len(t, created, 2)
+		assert.Contains(t, created, "Epoch_0/Blocks")
+		assert.Contains(t, created, "Epoch_1/Blocks")
+	})
+}
+
+func TestPruningStorer_PutAndGet(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string]*memorydb.DB)
+	ps, _ := pruning.NewPruningStorer(createArgsPruningStorer(created))
+
+	err := ps.Put([]byte("key1"), []byte("value1"))
+	assert.Nil(t, err)
+	assert.Nil(t, created["Epoch_1/Blocks"].Has([]byte("key1")))
+
+	err = ps.PutInEpoch([]byte("key0"), []byte("value0"), 0)
+	assert.Nil(t, err)
+	assert.Nil(t, created["Epoch_0/Blocks"].Has([]byte("key0")))
+
+	err = ps.PutInEpoch([]byte("key"), []byte("value"), 2)
+	assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+
+	ps.ClearCache()
+	val, err := ps.Get([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+	_, err = ps.GetFromEpoch([]byte("key1"), 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, ps.Has([]byte("key1")))
+
+	_, err = ps.Get([]byte("missing"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+}
+
+func TestPruningStorer_EpochAddressedOperations(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string]*memorydb.DB)
+	ps, _ := pruning.NewPruningStorer(createArgsPruningStorer(created))
+	_ = ps.PutInEpoch([]byte("key0"), []byte("value0"), 0)
+	_ = ps.PutInEpoch([]byte("key1"), []byte("value1"), 1)
+
+	t.Run("GetFromEpoch should not search the other epochs", func(t *testing.T) {
+		ps.ClearCache()
+		_, err := ps.GetFromEpoch([]byte("key0"), 1)
+		assert.NotNil(t, err)
+		val, err := ps.GetFromEpoch([]byte("key0"), 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value0"), val)
+	})
+	t.Run("GetFromEpoch should error for an inactive epoch, even if cached", func(t *testing.T) {
+		_, _ = ps.Get([]byte("key1"))
+		_, err := ps.GetFromEpoch([]byte("key1"), 2)
+		assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+	})
+	t.Run("GetBulkFromEpoch should skip the missing keys", func(t *testing.T) {
+		ps.ClearCache()
+		pairs, err := ps.GetBulkFromEpoch([][]byte{[]byte("key0"), []byte("key1"), []byte("missing")}, 1)
+		assert.Nil(t, err)
+		require.Equal(t, 1, len(pairs))
+		assert.Equal(t, []byte("key1"), pairs[0].Key)
+		assert.Equal(t, []byte("value1"), pairs[0].Value)
+	})
+	t.Run("GetBulkFromEpoch should error for an inactive epoch", func(t *testing.T) {
+		pairs, err := ps.GetBulkFromEpoch([][]byte{[]byte("key1")}, 5)
+		assert.Nil(t, pairs)
+		assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+	})
+}
+
+func TestPruningStorer_ChangeEpochShouldDestroyTheOldEpochs(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string]*memorydb.DB)
+	ps, _ := pruning.NewPruningStorer(createArgsPruningStorer(created))
+	_ = ps.PutInEpoch([]byte("key0"), []byte("value0"), 0)
+	_ = ps.Put([]byte("key1"), []byte("value1"))
+
+	err := ps.ChangeEpoch(0)
+	assert.True(t, errors.Is(err, common.ErrInvalidEpoch))
+
+	err = ps.ChangeEpoch(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{1, 2}, ps.ActiveEpochs())
+	assert.Equal(t, 0, created["Epoch_0/Blocks"].Len())
+	oldest, _ := ps.GetOldestEpoch()
+	assert.Equal(t, uint32(1), oldest)
+
+	_ = ps.Put([]byte("key1"), []byte("newer value1"))
+	assert.Nil(t, created["Epoch_2/Blocks"].Has([]byte("key1")))
+
+	ps.ClearCache()
+	_, err = ps.Get([]byte("key0"))
+	assert.NotNil(t, err)
+	val, _ := ps.Get([]byte("key1"))
+	assert.Equal(t, []byte("newer value1"), val)
+
+	pairs := make(map[string]string)
+	ps.RangeKeys(func(key []byte, val []byte) bool {
+		pairs[string(key)] = string(val)
+		return true
+	})
+	assert.Equal(t, map[string]string{"key1": "newer value1"}, pairs)
+
+	err = ps.Remove([]byte("key1"))
+	assert.Nil(t, err)
+	assert.NotNil(t, ps.Has([]byte("key1")))
+	assert.Nil(t, ps.Close())
+}
+
+func TestPruningStorer_FullArchive(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	argDB := factory.ArgDB{
+		DBType:            common.LvlDBSerial,
+		BatchDelaySeconds: 1,
+		MaxBatchSize:      1,
+		MaxOpenFiles:      10,
+	}
+	readOnlyArgDB := argDB
+	readOnlyArgDB.DBType = common.LvlDBReadOnly
+	cache, _ := lrucache.NewCache(10)
+	args := pruning.ArgsPruningStorer{
+		Cacher:                  cache,
+		PersisterFactory:        factory.NewPersisterFactory(argDB),
+		PathTemplate:            filepath.Join(dir, "Epoch_{epoch}", "Blocks"),
+		NumActiveEpochs:         1,
+		FullArchive:             true,
+		ArchivePathTemplate:     filepath.Join(dir, "archive", "Epoch_{epoch}", "Blocks"),
+		ArchivePersisterFactory: factory.NewPersisterFactory(readOnlyArgDB),
+	}
+	ps, err := pruning.NewPruningStorer(args)
+	require.Nil(t, err)
+
+	_ = ps.Put([]byte("key0"), []byte("value0"))
+	err = ps.ChangeEpoch(1)
+	require.Nil(t, err)
+	_ = ps.Put([]byte("key1"), []byte("value1"))
+	err = ps.ChangeEpoch(2)
+	require.Nil(t, err)
+
+	_, err = os.Stat(ps.PathForEpoch(0))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(ps.ArchivePathForEpoch(0))
+	assert.Nil(t, err)
+
+	ps.ClearCache()
+	_, err = ps.Get([]byte("key0"))
+	assert.NotNil(t, err)
+	val, err := ps.GetFromEpoch([]byte("key1"), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	val, err = ps.SearchFirst([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+	oldest, _ := ps.GetOldestEpoch()
+	assert.Equal(t, uint32(0), oldest)
+
+	assert.Nil(t, ps.Close())
+}
+
+func TestPruningStorer_FullArchiveShouldKeepTheVolatilePersistersOpen(t *testing.T) {
+	t.Parallel()
+
+	args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+	args.NumActiveEpochs = 1
+	args.StartEpoch = 0
+	args.FullArchive = true
+	args.ArchivePathTemplate = "archive/Epoch_{epoch}/Blocks"
+	args.ArchivePersisterFactory = &testscommon.PersisterFactoryStub{
+		CreateCalled: func(path string) (types.Persister, error) {
+			assert.Fail(t, "should not have opened the archived persister "+path)
+			return nil, errors.New("unexpected call")
+		},
+	}
+	ps, err := pruning.NewPruningStorer(args)
+	require.Nil(t, err)
+
+	_ = ps.Put([]byte("key0"), []byte("value0"))
+	err = ps.ChangeEpoch(1)
+	require.Nil(t, err)
+
+	ps.ClearCache()
+	val, err := ps.GetFromEpoch([]byte("key0"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+	val, err = ps.SearchFirst([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+
+	assert.Nil(t, ps.Close())
+}
+
+func TestPruningStorer_SearchOrderAndEpochHints(t *testing.T) {
+	t.Parallel()
+
+	numReads := make(map[string]int)
+	args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+	args.PersisterFactory = &testscommon.PersisterFactoryStub{
+		CreateCalled: func(path string) (types.Persister, error) {
+			mdb := memorydb.New()
+			switch path {
+			case "Epoch_0/Blocks":
+				_ = mdb.Put([]byte("old"), []byte("old value"))
+				_ = mdb.Put([]byte("dup"), []byte("first value"))
+			case "Epoch_2/Blocks":
+				_ = mdb.Put([]byte("dup"), []byte("last value"))
+			}
+
+			return &testscommon.PersisterStub{
+				PutCalled: mdb.Put,
+				GetCalled: func(key []byte) ([]byte, error) {
+					numReads[path]++
+					return mdb.Get(key)
+				},
+				HasCalled: func(key []byte) error {
+					numReads[path]++
+					return mdb.Has(key)
+				},
+				RemoveCalled:    mdb.Remove,
+				RangeKeysCalled: mdb.RangeKeys,
+			}, nil
+		},
+	}
+	args.NumActiveEpochs = 3
+	args.StartEpoch = 2
+	args.Cacher, _ = lrucache.NewCache(1)
+	args.EpochHintsCacheSize = 10
+	ps, err := pruning.NewPruningStorer(args)
+	require.Nil(t, err)
+
+	val, err := ps.Get([]byte("old"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("old value"), val)
+	val, err = ps.Get([]byte("dup"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("last value"), val)
+	assert.Equal(t, map[string]int{"Epoch_0/Blocks": 1, "Epoch_1/Blocks": 1, "Epoch_2/Blocks": 2}, numReads)
+
+	// the value of "old" was evicted from the cache, its epoch being remembered
+	val, err = ps.Get([]byte("old"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("old value"), val)
+	assert.Nil(t, ps.Has([]byte("dup")))
+	assert.Equal(t, map[string]int{"Epoch_0/Blocks": 2, "Epoch_1/Blocks": 1, "Epoch_2/Blocks": 3}, numReads)
+
+	// a removal forgets the epoch of the key
+	require.Nil(t, ps.Remove([]byte("old")))
+	assert.True(t, errors.Is(ps.Has([]byte("old")), common.ErrKeyNotFound))
+	assert.Equal(t, map[string]int{"Epoch_0/Blocks": 3, "Epoch_1/Blocks": 2, "Epoch_2/Blocks": 4}, numReads)
+	_ = ps.Close()
+
+	args.SearchOrder = pruning.OldestFirst
+	args.EpochHintsCacheSize = 0
+	ps, err = pruning.NewPruningStorer(args)
+	require.Nil(t, err)
+	val, err = ps.Get([]byte("dup"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("first value"), val)
+	_ = ps.Close()
+
+	args.SearchOrder = "NewestFirst"
+	ps, err = pruning.NewPruningStorer(args)
+	assert.Nil(t, ps)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	args.SearchOrder = pruning.LatestFirst
+	args.EpochHintsCacheSize = -1
+	ps, err = pruning.NewPruningStorer(args)
+	assert.Nil(t, ps)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestPruningStorer_BloomFilterShouldSkipThePersistersNotHoldingTheKey(t *testing.T) {
+	t.Parallel()
+
+	numReads := make(map[string]int)
+	args := createArgsPruningStorer(make(map[string]*memorydb.DB))
+	args.PersisterFactory = &testscommon.PersisterFactoryStub{
+		CreateCalled: func(path string) (types.Persister, error) {
+			mdb := memorydb.New()
+			if path == "Epoch_0/Blocks" {
+				_ = mdb.Put([]byte("key0"), []byte("value0"))
+			}
+
+			return &testscommon.PersisterStub{
+				PutCalled: mdb.Put,
+				GetCalled: func(key []byte) ([]byte, error) {
+					numReads[path]++
+					return mdb.Get(key)
+				},
+				HasCalled: func(key []byte) error {
+					numReads[path]++
+					return mdb.Has(key)
+				},
+				RangeKeysCalled: mdb.RangeKeys,
+			}, nil
+		},
+	}
+	args.BloomFilter = &bloom.Config{ExpectedNumKeys: 100, FalsePositiveRate: 0.001}
+	ps, err := pruning.NewPruningStorer(args)
+	require.Nil(t, err)
+
+	_ = ps.Put([]byte("key1"), []byte("value1"))
+	ps.ClearCache()
+
+	val, err := ps.Get([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value0"), val)
+	assert.Nil(t, ps.Has([]byte("key1")))
+	_, err = ps.GetFromEpoch([]byte("key1"), 0)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.True(t, errors.Is(ps.Has([]byte("missing")), common.ErrKeyNotFound))
+	assert.Equal(t, map[string]int{"Epoch_0/Blocks": 1, "Epoch_1/Blocks": 1}, numReads)
+
+	args.BloomFilter = &bloom.Config{}
+	ps, err = pruning.NewPruningStorer(args)
+	assert.Nil(t, ps)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// Action is what is done with the directories of the epochs retired by a policy
+type Action string
+
+const (
+	// DeleteAction removes the retired directories
+	DeleteAction Action = "Delete"
+	// ArchiveAction moves the retired directories to the archive layout, where they are laid out the same way
+	ArchiveAction Action = "Archive"
+)
+
+// Policy holds the retention rules of a storage unit, enforced separately in each shard directory. An epoch is retired
+// as soon as any of the enabled rules says so, the latest epoch holding the unit being always kept
+type Policy struct {
+	// KeepLastEpochs, if positive, is the number of the latest epochs kept
+	KeepLastEpochs uint32
+	// MaxTotalBytes, if positive, caps the size of the unit, the oldest epochs being retired first
+	MaxTotalBytes uint64
+	// MaxAge, if positive, retires the epochs whose data was last modified longer ago
+	MaxAge time.Duration
+	// Action is what is done with the retired epochs, DeleteAction if not set
+	Action Action
+}
+
+func (policy Policy) validate() error {
+	if policy.KeepLastEpochs == 0 && policy.MaxTotalBytes == 0 && policy.MaxAge <= 0 {
+		return fmt.Errorf("%w: the retention policy should enable at least one rule", common.ErrInvalidConfig)
+	}
+	if policy.MaxAge < 0 {
+		return fmt.Errorf("%w: MaxAge should not be negative", common.ErrInvalidConfig)
+	}
+	if policy.action() != DeleteAction && policy.action() != ArchiveAction {
+		return fmt.Errorf("%w: unknown retention action %s", common.ErrInvalidConfig, policy.Action)
+	}
+
+	return nil
+}
+
+func (policy Policy) action() Action {
+	if len(policy.Action) == 0 {
+		return DeleteAction
+	}
+
+	return policy.Action
+}
+
+// unitEpoch describes the directory of a storage unit in an epoch
+type unitEpoch struct {
+	epoch        uint32
+	path         string
+	sizeInBytes  uint64
+	lastModified time.Time
+}
+
+// retirementReasons returns, for each epoch of the unit sorted newest first, why it should be retired, an empty
+// string meaning that it is kept. Once an epoch does not fit the bytes cap, all the older ones are retired as well
+func (policy Policy) retirementReasons(epochs []unitEpoch, now time.Time) []string {
+	reasons := make([]string, len(epochs))
+	keptBytes := uint64(0)
+	capReached := false
+	for i, unit := range epochs {
+		switch {
+		case i == 0:
+		case policy.KeepLastEpochs > 0 && i >= int(policy.KeepLastEpochs):
+			reasons[i] = fmt.Sprintf("outside the last %d epochs", policy.KeepLastEpochs)
+		case policy.MaxAge > 0 && now.Sub(unit.lastModified) > policy.MaxAge:
+			reasons[i] = fmt.Sprintf("last modified more than %s ago", policy.MaxAge)
+		case policy.MaxTotalBytes > 0 && (capReached || keptBytes+unit.sizeInBytes > policy.MaxTotalBytes):
+			reasons[i] = fmt.Sprintf("above the cap of %d bytes", policy.MaxTotalBytes)
+			capReached = true
+		}
+
+		if len(reasons[i]) == 0 {
+			keptBytes += unit.sizeInBytes
+		}
+	}
+
+	return reasons
+}
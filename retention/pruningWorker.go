@@ -0,0 +1,354 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// ArgsPruningWorker holds the arguments needed to create a PruningWorker
+type ArgsPruningWorker struct {
+	Engine *RetentionEngine
+	// Interval is the time between the end of a pruning run and the start of the next one
+	Interval time.Duration
+	// MinDelayBetweenRetirements, if positive, is the minimum time between two retired epochs
+	MinDelayBetweenRetirements time.Duration
+	// MaxBytesPerSecond, if positive, caps the rate the bytes are reclaimed at, the worker pausing after each retired
+	// epoch for as long as its size requires
+	MaxBytesPerSecond uint64
+	// ProgressFilePath, if set, is the file the progress of the run is saved to after each retired epoch, so that a
+	// run interrupted by a restart is resumed instead of being planned again
+	ProgressFilePath string
+}
+
+// Progress describes the pruning run in progress or, once finished, the last one
+type Progress struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Resumed is true if the run was interrupted, then resumed from the progress file
+	Resumed            bool   `json:"resumed"`
+	NumEpochsPlanned   int    `json:"numEpochsPlanned"`
+	NumEpochsProcessed int    `json:"numEpochsProcessed"`
+	NumEpochsFailed    int    `json:"numEpochsFailed"`
+	NumBytesReclaimed  uint64 `json:"numBytesReclaimed"`
+	// Pending lists the epochs of the run not processed yet, oldest first
+	Pending   []RetiredEpoch `json:"pending"`
+	LastError string         `json:"lastError,omitempty"`
+}
+
+// IsFinished returns true if all the epochs of the run were processed
+func (progress Progress) IsFinished() bool {
+	return !progress.FinishedAt.IsZero()
+}
+
+func (progress Progress) clone() Progress {
+	progress.Pending = append([]RetiredEpoch{}, progress.Pending...)
+
+	return progress
+}
+
+// PruningWorker enforces the retention policies of a RetentionEngine in the background, one epoch at a time, pausing
+// between the retired epochs so that the disk is not saturated by the deletions. Each run retires the epochs planned
+// when it started, its progress being saved after each one, so that the run interrupted by a cancellation or a
+// restart is resumed by the next one
+type PruningWorker struct {
+	engine            *RetentionEngine
+	interval          time.Duration
+	minDelay          time.Duration
+	maxBytesPerSecond uint64
+	progressFilePath  string
+
+	mutRun sync.Mutex
+
+	mutProgress sync.RWMutex
+	progress    Progress
+
+	mutState   sync.Mutex
+	cancelFunc func()
+	chDone     chan struct{}
+}
+
+// NewPruningWorker creates a new pruning worker, loading the progress of the interrupted run, if any
+func NewPruningWorker(args ArgsPruningWorker) (*PruningWorker, error) {
+	var validator common.ConfigValidator
+	validator.Check(!check.IfNil(args.Engine), common.ErrInvalidConfig, "nil Engine")
+	validator.Check(args.Interval > 0, common.ErrInvalidConfig, "Interval should be positive")
+	validator.Check(args.MinDelayBetweenRetirements >= 0, common.ErrInvalidConfig, "MinDelayBetweenRetirements should not be negative")
+	err := validator.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	pw := &PruningWorker{
+		engine:            args.Engine,
+		interval:          args.Interval,
+		minDelay:          args.MinDelayBetweenRetirements,
+		maxBytesPerSecond: args.MaxBytesPerSecond,
+		progressFilePath:  args.ProgressFilePath,
+	}
+	if len(pw.progressFilePath) > 0 {
+		pw.progress, err = loadProgress(pw.progressFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("%w while loading the pruning progress from %s", err, pw.progressFilePath)
+		}
+	}
+
+	return pw, nil
+}
+
+func loadProgress(path string) (Progress, error) {
+	progress := Progress{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return progress, err
+	}
+
+	err = json.Unmarshal(content, &progress)
+
+	return progress, err
+}
+
+// saveProgress writes the progress file through a temporary one, so that a crash does not leave it half written
+func (pw *PruningWorker) saveProgress(progress Progress) {
+	if len(pw.progressFilePath) == 0 {
+		return
+	}
+
+	content, err := json.MarshalIndent(progress, "", "  ")
+	if err == nil {
+		tmpPath := pw.progressFilePath + ".tmp"
+		err = os.WriteFile(tmpPath, content, 0644)
+		if err == nil {
+			err = os.Rename(tmpPath, pw.progressFilePath)
+		}
+	}
+	if err != nil {
+		log.Warn("PruningWorker: could not save the progress", "path", pw.progressFilePath, "error", err)
+	}
+}
+
+// Start starts the pruning runs in the background, the first one right away, until the context is cancelled or
+// Close is called
+func (pw *PruningWorker) Start(ctx context.Context) error {
+	pw.mutState.Lock()
+	defer pw.mutState.Unlock()
+
+	if pw.chDone != nil {
+		return fmt.Errorf("%w: the pruning worker", common.ErrAlreadyStarted)
+	}
+
+	ctx, pw.cancelFunc = context.WithCancel(ctx)
+	pw.chDone = make(chan struct{})
+	go pw.runPeriodically(ctx, pw.chDone)
+
+	return nil
+}
+
+func (pw *PruningWorker) runPeriodically(ctx context.Context, chDone chan struct{}) {
+	defer close(chDone)
+
+	timer := time.NewTimer(pw.interval)
+	defer timer.Stop()
+
+	for {
+		_, err := pw.RunOnce(ctx)
+		if ctx.Err() != nil {
+			log.Debug("closing PruningWorker's go routine...")
+			return
+		}
+		if err != nil {
+			log.Error("PruningWorker: pruning run failed", "error", err)
+		}
+
+		timer.Reset(pw.interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			log.Debug("closing PruningWorker's go routine...")
+			return
+		}
+	}
+}
+
+// RunOnce resumes the interrupted run, if any, or plans a new one, then retires its epochs at the configured pace,
+// returning its progress. On cancellation, the epoch being retired is completed, the remaining ones being left for the
+// next run
+func (pw *PruningWorker) RunOnce(ctx context.Context) (Progress, error) {
+	pw.mutRun.Lock()
+	defer pw.mutRun.Unlock()
+
+	err := pw.startRun()
+	if err != nil {
+		return pw.Progress(), err
+	}
+
+	for {
+		retired, ok := pw.nextPending()
+		if !ok {
+			break
+		}
+		if ctx.Err() != nil {
+			return pw.Progress(), ctx.Err()
+		}
+
+		err = pw.retire(retired)
+		pw.recordRetirement(retired, err)
+		if err != nil {
+			log.Warn("PruningWorker: could not retire epoch", "path", retired.Path, "error", err)
+		}
+
+		_, hasNext := pw.nextPending()
+		if hasNext && !pw.pause(ctx, retired.SizeInBytes) {
+			return pw.Progress(), ctx.Err()
+		}
+	}
+
+	progress := pw.finishRun()
+	log.Info("PruningWorker: finished the pruning run", "num epochs", progress.NumEpochsProcessed,
+		"num failed", progress.NumEpochsFailed, "num bytes reclaimed", progress.NumBytesReclaimed,
+		"resumed", progress.Resumed, "duration", progress.FinishedAt.Sub(progress.StartedAt))
+
+	return progress, nil
+}
+
+// startRun plans a new run, unless the previous one was interrupted before finishing
+func (pw *PruningWorker) startRun() error {
+	pw.mutProgress.Lock()
+	defer pw.mutProgress.Unlock()
+
+	if !pw.progress.StartedAt.IsZero() && !pw.progress.IsFinished() {
+		if !pw.progress.Resumed {
+			log.Info("PruningWorker: resuming the interrupted pruning run", "num pending", len(pw.progress.Pending))
+		}
+		pw.progress.Resumed = true
+		return nil
+	}
+
+	plan, err := pw.engine.Plan()
+	if err != nil {
+		return err
+	}
+
+	pw.progress = Progress{
+		StartedAt:        time.Now(),
+		NumEpochsPlanned: len(plan.Retired),
+		Pending:          plan.Retired,
+	}
+	pw.saveProgress(pw.progress)
+
+	return nil
+}
+
+func (pw *PruningWorker) nextPending() (RetiredEpoch, bool) {
+	pw.mutProgress.RLock()
+	defer pw.mutProgress.RUnlock()
+
+	if len(pw.progress.Pending) == 0 {
+		return RetiredEpoch{}, false
+	}
+
+	return pw.progress.Pending[0], true
+}
+
+// retire retires the epoch through the engine. An archived epoch whose directory is missing was moved before the
+// restart, the progress not being saved yet, while an interrupted deletion is completed by the engine
+func (pw *PruningWorker) retire(retired RetiredEpoch) error {
+	if retired.Action == ArchiveAction {
+		_, err := os.Stat(retired.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	return pw.engine.retireOne(retired)
+}
+
+func (pw *PruningWorker) recordRetirement(retired RetiredEpoch, err error) {
+	pw.mutProgress.Lock()
+	defer pw.mutProgress.Unlock()
+
+	pw.progress.Pending = pw.progress.Pending[1:]
+	pw.progress.NumEpochsProcessed++
+	if err != nil {
+		pw.progress.NumEpochsFailed++
+		pw.progress.LastError = err.Error()
+	} else {
+		pw.progress.NumBytesReclaimed += retired.SizeInBytes
+	}
+	pw.saveProgress(pw.progress)
+}
+
+func (pw *PruningWorker) finishRun() Progress {
+	pw.mutProgress.Lock()
+	defer pw.mutProgress.Unlock()
+
+	pw.progress.FinishedAt = time.Now()
+	pw.saveProgress(pw.progress)
+
+	return pw.progress.clone()
+}
+
+// pause waits, after an epoch of the provided size was retired, as long as the rate limits require, returning false
+// if the context was cancelled meanwhile
+func (pw *PruningWorker) pause(ctx context.Context, numBytes uint64) bool {
+	delay := pw.minDelay
+	if pw.maxBytesPerSecond > 0 {
+		bytesDelay := time.Duration(float64(numBytes) / float64(pw.maxBytesPerSecond) * float64(time.Second))
+		if bytesDelay > delay {
+			delay = bytesDelay
+		}
+	}
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Progress returns the progress of the run in progress or, once finished, of the last one
+func (pw *PruningWorker) Progress() Progress {
+	pw.mutProgress.RLock()
+	defer pw.mutProgress.RUnlock()
+
+	return pw.progress.clone()
+}
+
+// Close stops the background runs, waiting for the epoch being retired, if any. The interrupted run is resumed once
+// started again
+func (pw *PruningWorker) Close() error {
+	pw.mutState.Lock()
+	defer pw.mutState.Unlock()
+
+	if pw.chDone == nil {
+		return nil
+	}
+
+	pw.cancelFunc()
+	<-pw.chDone
+	pw.chDone = nil
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pw *PruningWorker) IsInterfaceNil() bool {
+	return pw == nil
+}
@@ -0,0 +1,174 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/TerraDharitri/drt-go-chain-storage/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createEngineToTest(t *testing.T, epochs ...uint32) (*retention.RetentionEngine, *layout.DirectoryLayout) {
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	createUnitDirs(t, dl, "Blocks", epochs...)
+	re, _ := retention.NewRetentionEngine(retention.ArgsRetentionEngine{Layout: dl})
+	require.Nil(t, re.SetPolicy("Blocks", retention.Policy{KeepLastEpochs: 2}))
+
+	return re, dl
+}
+
+func TestNewPruningWorker(t *testing.T) {
+	t.Parallel()
+
+	pw, err := retention.NewPruningWorker(retention.ArgsPruningWorker{MinDelayBetweenRetirements: -1})
+	assert.Nil(t, pw)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "Engine")
+	assert.Contains(t, err.Error(), "Interval")
+	assert.Contains(t, err.Error(), "MinDelayBetweenRetirements")
+
+	re, _ := createEngineToTest(t)
+	progressFilePath := filepath.Join(t.TempDir(), "progress.json")
+	require.Nil(t, os.WriteFile(progressFilePath, []byte("{"), 0644))
+	pw, err = retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:           re,
+		Interval:         time.Hour,
+		ProgressFilePath: progressFilePath,
+	})
+	assert.Nil(t, pw)
+	assert.NotNil(t, err)
+
+	pw, err = retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:           re,
+		Interval:         time.Hour,
+		ProgressFilePath: filepath.Join(t.TempDir(), "progress.json"),
+	})
+	require.Nil(t, err)
+	assert.False(t, pw.IsInterfaceNil())
+	assert.False(t, pw.Progress().IsFinished())
+}
+
+func TestPruningWorker_RunOnce(t *testing.T) {
+	t.Parallel()
+
+	re, dl := createEngineToTest(t, 1, 2, 3, 4, 5)
+	progressFilePath := filepath.Join(t.TempDir(), "progress.json")
+	pw, _ := retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:            re,
+		Interval:          time.Hour,
+		MaxBytesPerSecond: 2000,
+		ProgressFilePath:  progressFilePath,
+	})
+
+	start := time.Now()
+	progress, err := pw.RunOnce(context.Background())
+	require.Nil(t, err)
+	// each retired epoch holds 100 bytes, paced at 2000 bytes per second
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.True(t, progress.IsFinished())
+	assert.False(t, progress.Resumed)
+	assert.Equal(t, 3, progress.NumEpochsPlanned)
+	assert.Equal(t, 3, progress.NumEpochsProcessed)
+	assert.Zero(t, progress.NumEpochsFailed)
+	assert.Equal(t, uint64(300), progress.NumBytesReclaimed)
+	assert.Empty(t, progress.Pending)
+	for _, epoch := range []uint32{1, 2, 3} {
+		assert.NoDirExists(t, dl.UnitPath(epoch, 0, "Blocks"))
+	}
+	assert.DirExists(t, dl.UnitPath(4, 0, "Blocks"))
+
+	// a finished run is not resumed, the next one being planned again
+	pw, _ = retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:           re,
+		Interval:         time.Hour,
+		ProgressFilePath: progressFilePath,
+	})
+	assert.Equal(t, uint64(300), pw.Progress().NumBytesReclaimed)
+	progress, err = pw.RunOnce(context.Background())
+	require.Nil(t, err)
+	assert.Zero(t, progress.NumEpochsPlanned)
+	assert.False(t, progress.Resumed)
+}
+
+func TestPruningWorker_CancelAndResume(t *testing.T) {
+	t.Parallel()
+
+	re, dl := createEngineToTest(t, 1, 2, 3, 4, 5)
+	progressFilePath := filepath.Join(t.TempDir(), "progress.json")
+	pw, _ := retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:                     re,
+		Interval:                   time.Hour,
+		MinDelayBetweenRetirements: time.Hour,
+		ProgressFilePath:           progressFilePath,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for pw.Progress().NumEpochsProcessed == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	progress, err := pw.RunOnce(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, progress.IsFinished())
+	assert.Equal(t, 1, progress.NumEpochsProcessed)
+	assert.Equal(t, uint64(100), progress.NumBytesReclaimed)
+	require.Len(t, progress.Pending, 2)
+	assert.NoDirExists(t, dl.UnitPath(1, 0, "Blocks"))
+	assert.DirExists(t, dl.UnitPath(2, 0, "Blocks"))
+
+	// after a restart, the interrupted run retires the epochs planned when it started, even if newer ones were added
+	createUnitDirs(t, dl, "Blocks", 6)
+	pw, _ = retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:           re,
+		Interval:         time.Hour,
+		ProgressFilePath: progressFilePath,
+	})
+	assert.Len(t, pw.Progress().Pending, 2)
+	progress, err = pw.RunOnce(context.Background())
+	require.Nil(t, err)
+	assert.True(t, progress.IsFinished())
+	assert.True(t, progress.Resumed)
+	assert.Equal(t, 3, progress.NumEpochsPlanned)
+	assert.Equal(t, 3, progress.NumEpochsProcessed)
+	assert.Equal(t, uint64(300), progress.NumBytesReclaimed)
+	assert.NoDirExists(t, dl.UnitPath(3, 0, "Blocks"))
+	assert.DirExists(t, dl.UnitPath(4, 0, "Blocks"))
+
+	progress, err = pw.RunOnce(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, 1, progress.NumEpochsPlanned)
+	assert.NoDirExists(t, dl.UnitPath(4, 0, "Blocks"))
+}
+
+func TestPruningWorker_StartAndClose(t *testing.T) {
+	t.Parallel()
+
+	re, dl := createEngineToTest(t, 1, 2, 3)
+	pw, _ := retention.NewPruningWorker(retention.ArgsPruningWorker{
+		Engine:   re,
+		Interval: time.Hour,
+	})
+	require.Nil(t, pw.Start(context.Background()))
+	err := pw.Start(context.Background())
+	assert.True(t, errors.Is(err, common.ErrAlreadyStarted))
+
+	require.Eventually(t, func() bool {
+		return pw.Progress().IsFinished()
+	}, time.Second, time.Millisecond)
+	assert.NoDirExists(t, dl.UnitPath(1, 0, "Blocks"))
+	assert.Equal(t, 1, pw.Progress().NumEpochsProcessed)
+
+	assert.Nil(t, pw.Close())
+	assert.Nil(t, pw.Close())
+	assert.Nil(t, pw.Start(context.Background()))
+	assert.Nil(t, pw.Close())
+}
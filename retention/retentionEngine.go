@@ -0,0 +1,318 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+)
+
+var log = logger.GetOrCreate("storage/retention")
+
+// ArgsRetentionEngine holds the arguments needed to create a RetentionEngine
+type ArgsRetentionEngine struct {
+	// Layout is the directory layout the policies are enforced on
+	Layout *layout.DirectoryLayout
+	// ArchiveLayout is the directory layout the epochs retired by the ArchiveAction policies are moved to. It is
+	// required by these policies only
+	ArchiveLayout *layout.DirectoryLayout
+}
+
+// RetiredEpoch describes the directory of a storage unit in an epoch, retired by its policy
+type RetiredEpoch struct {
+	UnitName    string `json:"unitName"`
+	ShardID     uint32 `json:"shardID"`
+	Epoch       uint32 `json:"epoch"`
+	Path        string `json:"path"`
+	SizeInBytes uint64 `json:"sizeInBytes"`
+	Action      Action `json:"action"`
+	// ArchivePath is the directory the retired one is moved to, empty for the deleted ones
+	ArchivePath string `json:"archivePath,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+// Report lists the epochs retired by the policies, sorted by unit name, shard ID and epoch. In a dry run, nothing is
+// retired, the report listing what would have been
+type Report struct {
+	DryRun        bool
+	Retired       []RetiredEpoch
+	NumBytesFreed uint64
+}
+
+// RetentionEngine enforces, on the directories of a layout, the retention policies registered per storage unit,
+// deleting or archiving the directories of the epochs to be retired, so that the operators do not have to script it.
+// The directories opened by the running storers should be protected by the policies, e.g. by keeping at least as many
+// epochs as the storers do
+type RetentionEngine struct {
+	layout        *layout.DirectoryLayout
+	archiveLayout *layout.DirectoryLayout
+	now           func() time.Time
+
+	mut      sync.Mutex
+	policies map[string]Policy
+}
+
+// NewRetentionEngine creates a new retention engine, without any policy
+func NewRetentionEngine(args ArgsRetentionEngine) (*RetentionEngine, error) {
+	if check.IfNil(args.Layout) {
+		return nil, fmt.Errorf("%w: nil Layout", common.ErrInvalidConfig)
+	}
+
+	return &RetentionEngine{
+		layout:        args.Layout,
+		archiveLayout: args.ArchiveLayout,
+		now:           time.Now,
+		policies:      make(map[string]Policy),
+	}, nil
+}
+
+// SetPolicy sets the retention policy of the storage unit, replacing the previous one
+func (re *RetentionEngine) SetPolicy(unitName string, policy Policy) error {
+	if len(unitName) == 0 || filepath.Base(unitName) != unitName {
+		return fmt.Errorf("%w: invalid unit name %q", common.ErrInvalidConfig, unitName)
+	}
+	err := policy.validate()
+	if err != nil {
+		return err
+	}
+	if policy.action() == ArchiveAction && check.IfNil(re.archiveLayout) {
+		return fmt.Errorf("%w: the archive action requires an ArchiveLayout", common.ErrInvalidConfig)
+	}
+
+	re.mut.Lock()
+	re.policies[unitName] = policy
+	re.mut.Unlock()
+
+	return nil
+}
+
+// RemovePolicy removes the retention policy of the storage unit, whose epochs are no longer retired
+func (re *RetentionEngine) RemovePolicy(unitName string) {
+	re.mut.Lock()
+	delete(re.policies, unitName)
+	re.mut.Unlock()
+}
+
+// Plan returns the report of a dry run, listing the epochs the policies would retire, without retiring them
+func (re *RetentionEngine) Plan() (*Report, error) {
+	re.mut.Lock()
+	defer re.mut.Unlock()
+
+	return re.planNoLock()
+}
+
+// Enforce retires the epochs as required by the policies, returning the report of the retired ones. A failed
+// retirement does not stop the others, the errors being returned together
+func (re *RetentionEngine) Enforce() (*Report, error) {
+	re.mut.Lock()
+	defer re.mut.Unlock()
+
+	plan, err := re.planNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Retired: make([]RetiredEpoch, 0, len(plan.Retired)),
+	}
+	errs := make([]error, 0)
+	for _, retired := range plan.Retired {
+		err = re.retireNoLock(retired)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		report.Retired = append(report.Retired, retired)
+		report.NumBytesFreed += retired.SizeInBytes
+	}
+	log.Info("RetentionEngine: enforced the retention policies", "num retired", len(report.Retired), "num bytes freed", report.NumBytesFreed)
+
+	return report, errors.Join(errs...)
+}
+
+// retireOne retires a single epoch planned before, used by the PruningWorker to pace the retirements
+func (re *RetentionEngine) retireOne(retired RetiredEpoch) error {
+	re.mut.Lock()
+	defer re.mut.Unlock()
+
+	return re.retireNoLock(retired)
+}
+
+func (re *RetentionEngine) retireNoLock(retired RetiredEpoch) error {
+	err := retire(retired)
+	if err != nil {
+		return fmt.Errorf("%w while retiring %s", err, retired.Path)
+	}
+
+	log.Debug("RetentionEngine: retired epoch", "path", retired.Path, "action", retired.Action, "reason", retired.Reason)
+	re.removeIfEmpty(retired.ShardID, retired.Epoch)
+
+	return nil
+}
+
+func (re *RetentionEngine) planNoLock() (*Report, error) {
+	unitNames := make([]string, 0, len(re.policies))
+	for unitName := range re.policies {
+		unitNames = append(unitNames, unitName)
+	}
+	sort.Strings(unitNames)
+
+	report := &Report{
+		DryRun:  true,
+		Retired: make([]RetiredEpoch, 0),
+	}
+	now := re.now()
+	for _, unitName := range unitNames {
+		policy := re.policies[unitName]
+		unitEpochsPerShard, err := re.listUnitEpochs(unitName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, shardID := range sortedShardIDs(unitEpochsPerShard) {
+			unitEpochs := unitEpochsPerShard[shardID]
+			reasons := policy.retirementReasons(unitEpochs, now)
+			// the epochs are sorted newest first, while the report lists them oldest first
+			for i := len(unitEpochs) - 1; i >= 0; i-- {
+				if len(reasons[i]) == 0 {
+					continue
+				}
+
+				retired := RetiredEpoch{
+					UnitName:    unitName,
+					ShardID:     shardID,
+					Epoch:       unitEpochs[i].epoch,
+					Path:        unitEpochs[i].path,
+					SizeInBytes: unitEpochs[i].sizeInBytes,
+					Action:      policy.action(),
+					Reason:      reasons[i],
+				}
+				if retired.Action == ArchiveAction {
+					retired.ArchivePath = re.archiveLayout.UnitPath(retired.Epoch, shardID, unitName)
+				}
+				report.Retired = append(report.Retired, retired)
+				report.NumBytesFreed += retired.SizeInBytes
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// listUnitEpochs returns, for each shard, the directories of the unit, sorted newest first
+func (re *RetentionEngine) listUnitEpochs(unitName string) (map[uint32][]unitEpoch, error) {
+	epochs, err := re.layout.ListEpochs()
+	if err != nil {
+		return nil, err
+	}
+
+	unitEpochsPerShard := make(map[uint32][]unitEpoch)
+	for i := len(epochs) - 1; i >= 0; i-- {
+		shardIDs, errList := re.layout.ListShards(epochs[i])
+		if errList != nil {
+			return nil, errList
+		}
+
+		for _, shardID := range shardIDs {
+			path := re.layout.UnitPath(epochs[i], shardID, unitName)
+			info, errStat := os.Stat(path)
+			if errors.Is(errStat, os.ErrNotExist) || (errStat == nil && !info.IsDir()) {
+				continue
+			}
+			if errStat != nil {
+				return nil, errStat
+			}
+
+			unit, errUsage := diskUsage(path, info.ModTime())
+			if errUsage != nil {
+				return nil, errUsage
+			}
+			unit.epoch = epochs[i]
+			unitEpochsPerShard[shardID] = append(unitEpochsPerShard[shardID], unit)
+		}
+	}
+
+	return unitEpochsPerShard, nil
+}
+
+// diskUsage returns the size of the files of the directory and the time the newest one was last modified
+func diskUsage(path string, dirModTime time.Time) (unitEpoch, error) {
+	unit := unitEpoch{
+		path:         path,
+		lastModified: dirModTime,
+	}
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		unit.sizeInBytes += uint64(info.Size())
+		if info.ModTime().After(unit.lastModified) {
+			unit.lastModified = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return unit, err
+}
+
+func sortedShardIDs(unitEpochsPerShard map[uint32][]unitEpoch) []uint32 {
+	shardIDs := make([]uint32, 0, len(unitEpochsPerShard))
+	for shardID := range unitEpochsPerShard {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool {
+		return shardIDs[i] < shardIDs[j]
+	})
+
+	return shardIDs
+}
+
+func retire(retired RetiredEpoch) error {
+	if retired.Action == DeleteAction {
+		return layout.RemoveDir(retired.Path)
+	}
+
+	_, err := os.Stat(retired.ArchivePath)
+	if err == nil {
+		return fmt.Errorf("%w: %s is already archived", common.ErrInvalidConfig, retired.ArchivePath)
+	}
+	err = os.MkdirAll(filepath.Dir(retired.ArchivePath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(retired.Path, retired.ArchivePath)
+}
+
+// removeIfEmpty removes the shard directory, then the epoch one, left empty by the retirements
+func (re *RetentionEngine) removeIfEmpty(shardID uint32, epoch uint32) {
+	for _, path := range []string{re.layout.ShardPath(epoch, shardID), re.layout.EpochPath(epoch)} {
+		entries, err := os.ReadDir(path)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+
+		_ = os.Remove(path)
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (re *RetentionEngine) IsInterfaceNil() bool {
+	return re == nil
+}
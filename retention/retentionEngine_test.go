@@ -0,0 +1,120 @@
+package retention_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/layout"
+	"github.com/TerraDharitri/drt-go-chain-storage/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnitDirs(t *testing.T, dl *layout.DirectoryLayout, unitName string, epochs ...uint32) {
+	for _, epoch := range epochs {
+		path := dl.UnitPath(epoch, 0, unitName)
+		require.Nil(t, os.MkdirAll(path, os.ModePerm))
+		require.Nil(t, os.WriteFile(filepath.Join(path, "000001.ldb"), make([]byte, 100), os.ModePerm))
+	}
+}
+
+func retiredEpochs(report *retention.Report) map[string][]uint32 {
+	retired := make(map[string][]uint32)
+	for _, epoch := range report.Retired {
+		retired[epoch.UnitName] = append(retired[epoch.UnitName], epoch.Epoch)
+	}
+
+	return retired
+}
+
+func TestNewRetentionEngine(t *testing.T) {
+	t.Parallel()
+
+	re, err := retention.NewRetentionEngine(retention.ArgsRetentionEngine{})
+	assert.Nil(t, re)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	re, err = retention.NewRetentionEngine(retention.ArgsRetentionEngine{Layout: dl})
+	require.Nil(t, err)
+
+	err = re.SetPolicy("Blocks", retention.Policy{})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	err = re.SetPolicy("Blocks", retention.Policy{KeepLastEpochs: 2, Action: "Compress"})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	err = re.SetPolicy("Blocks", retention.Policy{KeepLastEpochs: 2, Action: retention.ArchiveAction})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	err = re.SetPolicy("../Blocks", retention.Policy{KeepLastEpochs: 2})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Nil(t, re.SetPolicy("Blocks", retention.Policy{KeepLastEpochs: 2}))
+}
+
+func TestRetentionEngine_PlanAndEnforce(t *testing.T) {
+	t.Parallel()
+
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	archiveLayout, _ := layout.NewDirectoryLayout(t.TempDir())
+	createUnitDirs(t, dl, "Blocks", 1, 2, 3, 4, 5)
+	createUnitDirs(t, dl, "Transactions", 1, 2, 3, 4, 5)
+	createUnitDirs(t, dl, "Receipts", 1, 5)
+	re, _ := retention.NewRetentionEngine(retention.ArgsRetentionEngine{Layout: dl, ArchiveLayout: archiveLayout})
+	require.Nil(t, re.SetPolicy("Blocks", retention.Policy{KeepLastEpochs: 3}))
+	require.Nil(t, re.SetPolicy("Transactions", retention.Policy{MaxTotalBytes: 250, Action: retention.ArchiveAction}))
+
+	plan, err := re.Plan()
+	require.Nil(t, err)
+	assert.True(t, plan.DryRun)
+	expectedRetired := map[string][]uint32{
+		"Blocks":       {1, 2},
+		"Transactions": {1, 2, 3},
+	}
+	assert.Equal(t, expectedRetired, retiredEpochs(plan))
+	assert.Equal(t, uint64(500), plan.NumBytesFreed)
+	assert.Equal(t, archiveLayout.UnitPath(1, 0, "Transactions"), plan.Retired[2].ArchivePath)
+	assert.DirExists(t, dl.UnitPath(1, 0, "Blocks"))
+
+	report, err := re.Enforce()
+	require.Nil(t, err)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, expectedRetired, retiredEpochs(report))
+	assert.Equal(t, uint64(500), report.NumBytesFreed)
+	assert.NoDirExists(t, dl.UnitPath(2, 0, "Blocks"))
+	assert.NoDirExists(t, dl.EpochPath(2))
+	assert.DirExists(t, dl.UnitPath(3, 0, "Blocks"))
+	assert.FileExists(t, filepath.Join(archiveLayout.UnitPath(3, 0, "Transactions"), "000001.ldb"))
+	assert.DirExists(t, dl.UnitPath(1, 0, "Receipts"))
+
+	plan, err = re.Plan()
+	require.Nil(t, err)
+	assert.Empty(t, plan.Retired)
+}
+
+func TestRetentionEngine_MaxAgeShouldKeepTheLatestEpoch(t *testing.T) {
+	t.Parallel()
+
+	dl, _ := layout.NewDirectoryLayout(t.TempDir())
+	createUnitDirs(t, dl, "Blocks", 1, 2, 3)
+	old := time.Now().Add(-48 * time.Hour)
+	for _, epoch := range []uint32{1, 3} {
+		path := dl.UnitPath(epoch, 0, "Blocks")
+		require.Nil(t, os.Chtimes(filepath.Join(path, "000001.ldb"), old, old))
+		require.Nil(t, os.Chtimes(path, old, old))
+	}
+
+	re, _ := retention.NewRetentionEngine(retention.ArgsRetentionEngine{Layout: dl})
+	require.Nil(t, re.SetPolicy("Blocks", retention.Policy{MaxAge: 24 * time.Hour}))
+	report, err := re.Enforce()
+	require.Nil(t, err)
+	require.Len(t, report.Retired, 1)
+	assert.Equal(t, uint32(1), report.Retired[0].Epoch)
+	assert.Equal(t, retention.DeleteAction, report.Retired[0].Action)
+	assert.DirExists(t, dl.UnitPath(3, 0, "Blocks"))
+
+	re.RemovePolicy("Blocks")
+	plan, _ := re.Plan()
+	assert.Empty(t, plan.Retired)
+}
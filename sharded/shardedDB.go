@@ -5,10 +5,14 @@ import (
 	"fmt"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var _ types.Persister = (*shardedPersister)(nil)
+var _ types.StatsProvider = (*shardedPersister)(nil)
+var _ types.CapabilitiesProvider = (*shardedPersister)(nil)
+var _ types.HealthChecker = (*shardedPersister)(nil)
 
 // ErrInvalidPath signals that an invalid path has been provided
 var ErrInvalidPath = errors.New("invalid path")
@@ -123,6 +127,49 @@ func (s *shardedPersister) RangeKeys(handler func(key []byte, val []byte) bool)
 	}
 }
 
+// Stats returns the key stats of the persister, along with the stats of each shard, keyed by the shard ID
+func (s *shardedPersister) Stats() map[string]interface{} {
+	shardsStats := make(map[string]interface{}, len(s.persisters))
+	for shardID, persister := range s.persisters {
+		shardsStats[fmt.Sprintf("%d", shardID)] = types.StatsOf(persister)
+	}
+
+	return map[string]interface{}{
+		types.StatType: string(common.ShardedDB),
+		"numShards":    len(s.persisters),
+		"shards":       shardsStats,
+	}
+}
+
+// Capabilities returns the optional features shared by all the shards, the durability being the weakest one. The
+// snapshots and the iteration are not supported across the shards
+func (s *shardedPersister) Capabilities() types.PersisterCapabilities {
+	capabilities := types.PersisterCapabilities{
+		SupportsTTL: len(s.persisters) > 0,
+		Durability:  types.SyncedDurability,
+	}
+	if len(s.persisters) == 0 {
+		capabilities.Durability = types.UnknownDurability
+	}
+	for _, persister := range s.persisters {
+		shardCapabilities := types.CapabilitiesOf(persister)
+		capabilities.SupportsTTL = capabilities.SupportsTTL && shardCapabilities.SupportsTTL
+		capabilities.Durability = types.WeakestDurability(capabilities.Durability, shardCapabilities.Durability)
+	}
+
+	return capabilities
+}
+
+// Health returns the health of the shards, the persister being as bad as its worst shard
+func (s *shardedPersister) Health() types.HealthStatus {
+	status := types.NewHealthStatus()
+	for shardID, persister := range s.persisters {
+		status.AddComponentHealth(fmt.Sprintf("shard %d", shardID), persister)
+	}
+
+	return status
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (s *shardedPersister) IsInterfaceNil() bool {
 	return s == nil
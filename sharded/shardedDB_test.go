@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
 	"github.com/TerraDharitri/drt-go-chain-storage/sharded"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -111,3 +113,28 @@ func TestShardedPersister_Operations(t *testing.T) {
 	require.Nil(t, err)
 
 }
+
+func TestShardedPersister_CapabilitiesShouldReportTheWeakestDurability(t *testing.T) {
+	t.Parallel()
+
+	idProvider, err := sharded.NewShardIDProvider(2)
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	persisterCreator := &testscommon.PersisterCreatorStub{
+		CreateBasePersisterCalled: func(path string) (types.Persister, error) {
+			if path == dir+"/0" {
+				return memorydb.New(), nil
+			}
+
+			return leveldb.NewSerialDB(path, 2, _1Mil, 10)
+		},
+	}
+	db, err := sharded.NewShardedPersister(dir, persisterCreator, idProvider)
+	require.Nil(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assert.Equal(t, types.PersisterCapabilities{Durability: types.VolatileDurability}, db.Capabilities())
+}
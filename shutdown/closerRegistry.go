@@ -0,0 +1,241 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var log = logger.GetOrCreate("storage/shutdown")
+
+// CloserFunc adapts a function, e.g. the one stopping a background go routine, to io.Closer
+type CloserFunc func() error
+
+// Close calls the function
+func (f CloserFunc) Close() error {
+	if f == nil {
+		return nil
+	}
+
+	return f()
+}
+
+// ArgsCloserRegistry holds the arguments needed to create a CloserRegistry
+type ArgsCloserRegistry struct {
+	// CloseTimeout bounds the time given to each component to drain and close, the deadline of the context provided to
+	// CloseAll bounding the whole shutdown
+	CloseTimeout time.Duration
+}
+
+// CloserRegistry closes, on shutdown, the registered caches, persisters and background go routines in dependency
+// order: a component is closed only after all the components depending on it, e.g. a batch writer before the
+// persister it writes to. The components are drained before being closed, by flushing the ones implementing
+// types.Flusher. A component may only depend on the ones registered before it, so the dependencies can not form
+// cycles and the components are closed in the reverse order of their registration
+type CloserRegistry struct {
+	closeTimeout time.Duration
+
+	mut        sync.Mutex
+	components []*component
+	byName     map[string]*component
+	isClosed   bool
+}
+
+type component struct {
+	name       string
+	closer     io.Closer
+	dependsOn  []string
+	dependents map[string]struct{}
+}
+
+// NewCloserRegistry creates a new closer registry
+func NewCloserRegistry(args ArgsCloserRegistry) (*CloserRegistry, error) {
+	if args.CloseTimeout <= 0 {
+		return nil, fmt.Errorf("%w: CloseTimeout should be positive", common.ErrInvalidConfig)
+	}
+
+	return &CloserRegistry{
+		closeTimeout: args.CloseTimeout,
+		byName:       make(map[string]*component),
+	}, nil
+}
+
+// Register adds the component to be closed under the provided name, after all the components registered later and
+// depending on it. The components it depends on have to be already registered
+func (cr *CloserRegistry) Register(name string, closer io.Closer, dependsOn ...string) error {
+	if check.IfNilReflect(closer) {
+		return fmt.Errorf("%w for %s", common.ErrNilCloser, name)
+	}
+	if len(name) == 0 {
+		return fmt.Errorf("%w: empty closer name", common.ErrInvalidConfig)
+	}
+
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	if cr.isClosed {
+		return fmt.Errorf("%w while registering %s", common.ErrRegistryClosed, name)
+	}
+	_, exists := cr.byName[name]
+	if exists {
+		return fmt.Errorf("%w: %s", common.ErrCloserAlreadyRegistered, name)
+	}
+	for _, dependency := range dependsOn {
+		_, exists = cr.byName[dependency]
+		if !exists {
+			return fmt.Errorf("%w: %s, a dependency of %s", common.ErrUnknownCloser, dependency, name)
+		}
+	}
+
+	comp := &component{
+		name:       name,
+		closer:     closer,
+		dependsOn:  append([]string(nil), dependsOn...),
+		dependents: make(map[string]struct{}),
+	}
+	for _, dependency := range dependsOn {
+		cr.byName[dependency].dependents[name] = struct{}{}
+	}
+	cr.components = append(cr.components, comp)
+	cr.byName[name] = comp
+
+	return nil
+}
+
+// Deregister removes the component registered under the provided name, e.g. as it was closed by its owner. A
+// component other registered components depend on can not be deregistered
+func (cr *CloserRegistry) Deregister(name string) error {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	comp, exists := cr.byName[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", common.ErrUnknownCloser, name)
+	}
+	if len(comp.dependents) > 0 {
+		return fmt.Errorf("%w: %s is a dependency of %d components", common.ErrCloserHasDependents, name, len(comp.dependents))
+	}
+
+	for _, dependency := range comp.dependsOn {
+		delete(cr.byName[dependency].dependents, name)
+	}
+	delete(cr.byName, name)
+	for i, registered := range cr.components {
+		if registered == comp {
+			cr.components = append(cr.components[:i], cr.components[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of registered components
+func (cr *CloserRegistry) Len() int {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	return len(cr.components)
+}
+
+// CloseAll drains and closes all the registered components, the dependents first, returning the aggregated errors.
+// Each component is given at most the configured close timeout, within the deadline of the provided context. The
+// dependencies of a component not closed in time are left open, as closing them could race with its pending work. The
+// registry accepts no components afterwards, and calling CloseAll again does nothing
+func (cr *CloserRegistry) CloseAll(ctx context.Context) error {
+	cr.mut.Lock()
+	if cr.isClosed {
+		cr.mut.Unlock()
+		return nil
+	}
+	cr.isClosed = true
+	components := cr.components
+	cr.mut.Unlock()
+
+	// the name of the dependent left open, for each component which should not be closed
+	blockedBy := make(map[string]string)
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		comp := components[i]
+
+		dependent, isBlocked := blockedBy[comp.name]
+		if isBlocked {
+			errs = append(errs, fmt.Errorf("%w: %s left open, as its dependent %s was not closed", common.ErrCloseTimeout, comp.name, dependent))
+			cr.blockDependencies(blockedBy, comp, dependent)
+			continue
+		}
+
+		err := cr.closeComponent(ctx, comp)
+		if err == nil {
+			continue
+		}
+
+		errs = append(errs, err)
+		if errors.Is(err, common.ErrCloseTimeout) {
+			cr.blockDependencies(blockedBy, comp, comp.name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (cr *CloserRegistry) blockDependencies(blockedBy map[string]string, comp *component, dependent string) {
+	for _, dependency := range comp.dependsOn {
+		_, isBlocked := blockedBy[dependency]
+		if !isBlocked {
+			blockedBy[dependency] = dependent
+		}
+	}
+}
+
+// closeComponent drains and closes the component, waiting for it at most the close timeout, within the deadline of
+// the provided context
+func (cr *CloserRegistry) closeComponent(ctx context.Context, comp *component) error {
+	ctx, cancel := context.WithTimeout(ctx, cr.closeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- drainAndClose(comp.closer)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%w while closing %s", err, comp.name)
+		}
+
+		log.Debug("CloserRegistry: closed component", "name", comp.name, "duration", time.Since(start))
+		return nil
+	case <-ctx.Done():
+		log.Warn("CloserRegistry: component not closed in time", "name", comp.name, "error", ctx.Err())
+		return fmt.Errorf("%w: %s, %v", common.ErrCloseTimeout, comp.name, ctx.Err())
+	}
+}
+
+func drainAndClose(closer io.Closer) error {
+	var errFlush error
+	flusher, ok := closer.(types.Flusher)
+	if ok {
+		errFlush = flusher.Flush()
+		if errFlush != nil {
+			errFlush = fmt.Errorf("%w while draining", errFlush)
+		}
+	}
+
+	return errors.Join(errFlush, closer.Close())
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cr *CloserRegistry) IsInterfaceNil() bool {
+	return cr == nil
+}
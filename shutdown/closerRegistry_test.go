@@ -0,0 +1,245 @@
+package shutdown_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/shutdown"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCloserRegistry(t *testing.T, closeTimeout time.Duration) *shutdown.CloserRegistry {
+	registry, err := shutdown.NewCloserRegistry(shutdown.ArgsCloserRegistry{CloseTimeout: closeTimeout})
+	require.Nil(t, err)
+
+	return registry
+}
+
+// closeRecorder records the order in which the closers are called
+type closeRecorder struct {
+	mut    sync.Mutex
+	closed []string
+}
+
+func (cr *closeRecorder) closer(name string, err error) shutdown.CloserFunc {
+	return func() error {
+		cr.mut.Lock()
+		cr.closed = append(cr.closed, name)
+		cr.mut.Unlock()
+
+		return err
+	}
+}
+
+func (cr *closeRecorder) closedNames() []string {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	return append([]string(nil), cr.closed...)
+}
+
+func TestNewCloserRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry, err := shutdown.NewCloserRegistry(shutdown.ArgsCloserRegistry{})
+	assert.Nil(t, registry)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	registry, err = shutdown.NewCloserRegistry(shutdown.ArgsCloserRegistry{CloseTimeout: time.Second})
+	assert.Nil(t, err)
+	assert.False(t, registry.IsInterfaceNil())
+}
+
+func TestCloserRegistry_Register(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil closer should error", func(t *testing.T) {
+		t.Parallel()
+
+		registry := createCloserRegistry(t, time.Second)
+		var nilDB *memorydb.DB
+		err := registry.Register("db", nilDB)
+		assert.True(t, errors.Is(err, common.ErrNilCloser))
+		err = registry.Register("db", nil)
+		assert.True(t, errors.Is(err, common.ErrNilCloser))
+	})
+	t.Run("empty name should error", func(t *testing.T) {
+		t.Parallel()
+
+		registry := createCloserRegistry(t, time.Second)
+		err := registry.Register("", memorydb.New())
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+	t.Run("duplicate name should error", func(t *testing.T) {
+		t.Parallel()
+
+		registry := createCloserRegistry(t, time.Second)
+		assert.Nil(t, registry.Register("db", memorydb.New()))
+		err := registry.Register("db", memorydb.New())
+		assert.True(t, errors.Is(err, common.ErrCloserAlreadyRegistered))
+		assert.Equal(t, 1, registry.Len())
+	})
+	t.Run("unknown dependency should error", func(t *testing.T) {
+		t.Parallel()
+
+		registry := createCloserRegistry(t, time.Second)
+		err := registry.Register("writer", memorydb.New(), "db")
+		assert.True(t, errors.Is(err, common.ErrUnknownCloser))
+		assert.Equal(t, 0, registry.Len())
+	})
+	t.Run("closed registry should error", func(t *testing.T) {
+		t.Parallel()
+
+		registry := createCloserRegistry(t, time.Second)
+		assert.Nil(t, registry.CloseAll(context.Background()))
+		err := registry.Register("db", memorydb.New())
+		assert.True(t, errors.Is(err, common.ErrRegistryClosed))
+	})
+}
+
+func TestCloserRegistry_Deregister(t *testing.T) {
+	t.Parallel()
+
+	recorder := &closeRecorder{}
+	registry := createCloserRegistry(t, time.Second)
+	require.Nil(t, registry.Register("db", recorder.closer("db", nil)))
+	require.Nil(t, registry.Register("writer", recorder.closer("writer", nil), "db"))
+
+	err := registry.Deregister("missing")
+	assert.True(t, errors.Is(err, common.ErrUnknownCloser))
+	err = registry.Deregister("db")
+	assert.True(t, errors.Is(err, common.ErrCloserHasDependents))
+
+	assert.Nil(t, registry.Deregister("writer"))
+	assert.Nil(t, registry.Deregister("db"))
+	assert.Equal(t, 0, registry.Len())
+
+	assert.Nil(t, registry.CloseAll(context.Background()))
+	assert.Empty(t, recorder.closedNames())
+}
+
+func TestCloserRegistry_CloseAllShouldCloseTheDependentsFirst(t *testing.T) {
+	t.Parallel()
+
+	recorder := &closeRecorder{}
+	registry := createCloserRegistry(t, time.Second)
+	require.Nil(t, registry.Register("db", recorder.closer("db", nil)))
+	require.Nil(t, registry.Register("cache", recorder.closer("cache", nil)))
+	require.Nil(t, registry.Register("writer", recorder.closer("writer", nil), "db"))
+	require.Nil(t, registry.Register("unit", recorder.closer("unit", nil), "cache", "db"))
+
+	err := registry.CloseAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"unit", "writer", "cache", "db"}, recorder.closedNames())
+
+	assert.Nil(t, registry.CloseAll(context.Background()))
+	assert.Equal(t, 4, len(recorder.closedNames()))
+}
+
+func TestCloserRegistry_CloseAllShouldDrainTheFlushers(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New(memorydb.WithBatchMode(10))
+	_ = mdb.Put([]byte("key"), []byte("value"))
+	require.Equal(t, 1, mdb.Diagnostics().NumPendingWrites)
+
+	registry := createCloserRegistry(t, time.Second)
+	require.Nil(t, registry.Register("db", mdb))
+
+	err := registry.CloseAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, mdb.Diagnostics().NumPendingWrites)
+}
+
+func TestCloserRegistry_CloseAllShouldFlushBeforeClosing(t *testing.T) {
+	t.Parallel()
+
+	stub := testscommon.NewRecordingPersisterStub(memorydb.New(memorydb.WithBatchMode(10)))
+	require.Nil(t, stub.Put([]byte("key"), []byte("value")))
+
+	registry := createCloserRegistry(t, time.Second)
+	require.Nil(t, registry.Register("db", stub))
+	require.Nil(t, registry.CloseAll(context.Background()))
+
+	assert.Nil(t, stub.AssertHappenedBefore(testscommon.CallWithKey("Put", []byte("key")), testscommon.CallTo("Flush")))
+	assert.Nil(t, stub.AssertHappenedBefore(testscommon.CallTo("Flush"), testscommon.CallTo("Close")))
+	assert.Nil(t, stub.AssertNoCallsAfter(testscommon.WriteCall(), testscommon.CallTo("Close")))
+
+	_ = stub.Put([]byte("late"), []byte("value"))
+	err := stub.AssertNoCallsAfter(testscommon.WriteCall(), testscommon.CallTo("Close"))
+	assert.True(t, errors.Is(err, testscommon.ErrCallOrderViolated))
+	assert.Contains(t, err.Error(), "Put(late) was received after Close()")
+	err = stub.AssertHappenedBefore(testscommon.CallTo("Put"), testscommon.CallTo("Close"))
+	assert.True(t, errors.Is(err, testscommon.ErrCallOrderViolated))
+	err = stub.AssertHappenedBefore(testscommon.CallTo("Destroy"), testscommon.CallTo("Close"))
+	assert.True(t, errors.Is(err, testscommon.ErrCallOrderViolated))
+}
+
+func TestCloserRegistry_CloseAllShouldAggregateTheErrors(t *testing.T) {
+	t.Parallel()
+
+	errDB := errors.New("db error")
+	errCache := errors.New("cache error")
+	recorder := &closeRecorder{}
+	registry := createCloserRegistry(t, time.Second)
+	require.Nil(t, registry.Register("db", recorder.closer("db", errDB)))
+	require.Nil(t, registry.Register("cache", recorder.closer("cache", errCache)))
+	require.Nil(t, registry.Register("unit", recorder.closer("unit", nil), "cache", "db"))
+
+	err := registry.CloseAll(context.Background())
+	assert.True(t, errors.Is(err, errDB))
+	assert.True(t, errors.Is(err, errCache))
+	assert.Contains(t, err.Error(), "while closing db")
+	assert.Equal(t, []string{"unit", "cache", "db"}, recorder.closedNames())
+}
+
+func TestCloserRegistry_CloseAllShouldLeaveOpenTheDependenciesOfTheComponentsNotClosedInTime(t *testing.T) {
+	t.Parallel()
+
+	recorder := &closeRecorder{}
+	release := make(chan struct{})
+	defer close(release)
+
+	registry := createCloserRegistry(t, 50*time.Millisecond)
+	require.Nil(t, registry.Register("db", recorder.closer("db", nil)))
+	require.Nil(t, registry.Register("index", recorder.closer("index", nil), "db"))
+	require.Nil(t, registry.Register("cache", recorder.closer("cache", nil)))
+	require.Nil(t, registry.Register("writer", shutdown.CloserFunc(func() error {
+		<-release
+		return nil
+	}), "index"))
+
+	err := registry.CloseAll(context.Background())
+	assert.True(t, errors.Is(err, common.ErrCloseTimeout))
+	assert.Contains(t, err.Error(), "index left open, as its dependent writer was not closed")
+	assert.Contains(t, err.Error(), "db left open, as its dependent writer was not closed")
+	assert.Equal(t, []string{"cache"}, recorder.closedNames())
+}
+
+func TestCloserRegistry_CloseAllShouldStopAtTheContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	registry := createCloserRegistry(t, time.Minute)
+	require.Nil(t, registry.Register("writer", shutdown.CloserFunc(func() error {
+		<-release
+		return nil
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := registry.CloseAll(ctx)
+	assert.True(t, errors.Is(err, common.ErrCloseTimeout))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Persister = (*gatedPersister)(nil)
+var _ types.MultiPutter = (*gatedPersister)(nil)
+var _ types.CapabilitiesProvider = (*gatedPersister)(nil)
+var _ types.HealthChecker = (*gatedPersister)(nil)
+
+// gatedPersister holds the gate for reading while writing to the wrapped persister, so that the writes are quiesced
+// while the gate is held for writing, during a snapshot
+type gatedPersister struct {
+	types.Persister
+	gate *sync.RWMutex
+}
+
+// Put adds the value to the wrapped persister, waiting for the ongoing snapshot, if any
+func (gp *gatedPersister) Put(key, val []byte) error {
+	gp.gate.RLock()
+	defer gp.gate.RUnlock()
+
+	return gp.Persister.Put(key, val)
+}
+
+// MultiPut adds all the provided values to the wrapped persister, in one go if it supports it
+func (gp *gatedPersister) MultiPut(data map[string][]byte) error {
+	gp.gate.RLock()
+	defer gp.gate.RUnlock()
+
+	multiPutter, ok := gp.Persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	for key, val := range data {
+		err := gp.Persister.Put([]byte(key), val)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the data associated to the given key from the wrapped persister, waiting for the ongoing snapshot,
+// if any
+func (gp *gatedPersister) Remove(key []byte) error {
+	gp.gate.RLock()
+	defer gp.gate.RUnlock()
+
+	return gp.Persister.Remove(key)
+}
+
+// Capabilities returns the capabilities of the wrapped persister, as the gate only delays its writes
+func (gp *gatedPersister) Capabilities() types.PersisterCapabilities {
+	return types.CapabilitiesOf(gp.Persister)
+}
+
+// Health returns the health of the wrapped persister
+func (gp *gatedPersister) Health() types.HealthStatus {
+	return types.ForwardedHealth(gp.Persister)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (gp *gatedPersister) IsInterfaceNil() bool {
+	return gp == nil
+}
@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest file, written in the directory of each snapshot
+const ManifestFileName = "manifest.json"
+
+// ManifestEntry describes the snapshot of one of the registered persisters
+type ManifestEntry struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	NumKeys  int    `json:"numKeys"`
+	NumBytes uint64 `json:"numBytes"`
+}
+
+// Manifest describes a consistent point in time snapshot of the registered persisters
+type Manifest struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Entries are sorted by name
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// Entry returns the entry of the persister registered under the provided name
+func (manifest *Manifest) Entry(name string) (ManifestEntry, bool) {
+	for _, entry := range manifest.Entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+
+	return ManifestEntry{}, false
+}
+
+func (manifest *Manifest) save(dir string) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, ManifestFileName), content, 0644)
+}
+
+// LoadManifest reads the manifest of the snapshot saved in the provided directory
+func LoadManifest(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = json.Unmarshal(content, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,248 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/decorators"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var log = logger.GetOrCreate("storage/snapshot")
+
+// ArgsSnapshotManager holds the arguments needed to create a SnapshotManager
+type ArgsSnapshotManager struct {
+	// PersisterFactory creates the persisters the snapshots are copied to
+	PersisterFactory types.PersisterFactory
+	// BasePath is the directory holding the snapshots, each one in a directory named after its ID
+	BasePath string
+}
+
+// SnapshotManager takes consistent point in time snapshots across a set of registered persisters: the writes to all
+// of them are quiesced, their pending batches are flushed and their snapshots are taken, then each one is copied to a
+// snapshot persister. The writes are resumed as soon as the snapshots are taken if all the persisters support them
+// natively, after the copy otherwise. A manifest describing the snapshot is written along with it
+type SnapshotManager struct {
+	persisterFactory types.PersisterFactory
+	basePath         string
+
+	// gate is held for reading by the writes to the registered persisters, and for writing during the snapshots
+	gate          sync.RWMutex
+	mutPersisters sync.Mutex
+	persisters    map[string]types.Persister
+	now           func() time.Time
+}
+
+// NewSnapshotManager creates a new snapshot manager
+func NewSnapshotManager(args ArgsSnapshotManager) (*SnapshotManager, error) {
+	if check.IfNil(args.PersisterFactory) {
+		return nil, common.ErrNilPersisterFactory
+	}
+	if len(args.BasePath) == 0 {
+		return nil, fmt.Errorf("%w: empty BasePath", common.ErrInvalidConfig)
+	}
+
+	return &SnapshotManager{
+		persisterFactory: args.PersisterFactory,
+		basePath:         args.BasePath,
+		persisters:       make(map[string]types.Persister),
+		now:              time.Now,
+	}, nil
+}
+
+// Register adds the persister to the snapshots, under the provided name, returning the persister its owner should
+// write through from now on, so that its writes are quiesced during the snapshots. A persister registered later under
+// the same name replaces the previous one
+func (sm *SnapshotManager) Register(name string, persister types.Persister) (types.Persister, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+	if len(name) == 0 || name == ManifestFileName || filepath.Base(name) != name {
+		return nil, fmt.Errorf("%w: invalid persister name %q", common.ErrInvalidConfig, name)
+	}
+
+	sm.mutPersisters.Lock()
+	sm.persisters[name] = persister
+	sm.mutPersisters.Unlock()
+
+	return &gatedPersister{
+		Persister: persister,
+		gate:      &sm.gate,
+	}, nil
+}
+
+// Unregister removes the persister registered under the provided name from the snapshots
+func (sm *SnapshotManager) Unregister(name string) {
+	sm.mutPersisters.Lock()
+	delete(sm.persisters, name)
+	sm.mutPersisters.Unlock()
+}
+
+// SnapshotPath returns the directory of the snapshot with the provided ID
+func (sm *SnapshotManager) SnapshotPath(id string) string {
+	return filepath.Join(sm.basePath, id)
+}
+
+// TakeSnapshot copies all the registered persisters to the directory of the snapshot with the provided ID, which
+// should not exist yet, returning its manifest. The writes to the registered persisters are blocked meanwhile, unless
+// all of them support the snapshots natively
+func (sm *SnapshotManager) TakeSnapshot(id string) (*Manifest, error) {
+	if len(id) == 0 || filepath.Base(id) != id {
+		return nil, fmt.Errorf("%w: invalid snapshot ID %q", common.ErrInvalidConfig, id)
+	}
+	dir := sm.SnapshotPath(id)
+	_, err := os.Stat(dir)
+	if err == nil {
+		return nil, fmt.Errorf("%w: snapshot %s already exists", common.ErrInvalidConfig, id)
+	}
+
+	sm.mutPersisters.Lock()
+	defer sm.mutPersisters.Unlock()
+
+	names := make([]string, 0, len(sm.persisters))
+	for name := range sm.persisters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// quiesce the writes
+	sm.gate.Lock()
+	views, err := sm.takeSnapshotsNoGate(names)
+	if err != nil {
+		sm.gate.Unlock()
+		return nil, err
+	}
+	defer views.release()
+	if views.allNative {
+		// the writes are resumed while copying, as the views are not affected by them
+		sm.gate.Unlock()
+	} else {
+		// the views of the persisters without snapshots read their live contents, so the writes stay quiesced
+		defer sm.gate.Unlock()
+	}
+
+	manifest := &Manifest{
+		ID:        id,
+		CreatedAt: sm.now(),
+		Entries:   make([]ManifestEntry, 0, len(names)),
+	}
+	for i, name := range names {
+		entry, errCopy := sm.copyView(name, views.views[i], filepath.Join(dir, name))
+		if errCopy != nil {
+			_ = os.RemoveAll(dir)
+			return nil, errCopy
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err == nil {
+		err = manifest.save(dir)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	log.Debug("SnapshotManager: took snapshot", "id", id, "num persisters", len(names))
+
+	return manifest, nil
+}
+
+// snapshotViews holds the views of the registered persisters, in the order of their names
+type snapshotViews struct {
+	persisters []types.SnapshotablePersister
+	views      []types.PersisterView
+	allNative  bool
+}
+
+func (sv *snapshotViews) release() {
+	for i, view := range sv.views {
+		sv.persisters[i].ReleaseSnapshot(view)
+	}
+}
+
+// takeSnapshotsNoGate flushes the pending batches, then takes the snapshots of all the registered persisters. It must
+// be called with the writes quiesced, so that the snapshots are consistent with each other
+func (sm *SnapshotManager) takeSnapshotsNoGate(names []string) (*snapshotViews, error) {
+	for _, name := range names {
+		flusher, ok := sm.persisters[name].(types.Flusher)
+		if !ok {
+			continue
+		}
+
+		err := flusher.Flush()
+		if err != nil {
+			return nil, fmt.Errorf("%w while flushing %s", err, name)
+		}
+	}
+
+	views := &snapshotViews{
+		persisters: make([]types.SnapshotablePersister, 0, len(names)),
+		views:      make([]types.PersisterView, 0, len(names)),
+		allNative:  true,
+	}
+	for _, name := range names {
+		views.allNative = views.allNative && types.CapabilitiesOf(sm.persisters[name]).SupportsSnapshots
+
+		persister, err := decorators.AsSnapshotablePersister(sm.persisters[name])
+		if err != nil {
+			views.release()
+			return nil, err
+		}
+		view, err := persister.TakeSnapshot()
+		if err != nil {
+			views.release()
+			return nil, fmt.Errorf("%w while taking the snapshot of %s", err, name)
+		}
+
+		views.persisters = append(views.persisters, persister)
+		views.views = append(views.views, view)
+	}
+
+	return views, nil
+}
+
+func (sm *SnapshotManager) copyView(name string, view types.PersisterView, path string) (ManifestEntry, error) {
+	destination, err := sm.persisterFactory.Create(path)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("%w while creating the snapshot of %s", err, name)
+	}
+
+	entry := ManifestEntry{
+		Name: name,
+		Path: path,
+	}
+	var errPut error
+	view.RangeKeys(func(key []byte, val []byte) bool {
+		errPut = destination.Put(key, val)
+		if errPut != nil {
+			return false
+		}
+
+		entry.NumKeys++
+		entry.NumBytes += uint64(len(key) + len(val))
+		return true
+	})
+
+	errClose := destination.Close()
+	err = errors.Join(errPut, errClose)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("%w while writing the snapshot of %s", err, name)
+	}
+
+	return entry, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sm *SnapshotManager) IsInterfaceNil() bool {
+	return sm == nil
+}
@@ -0,0 +1,133 @@
+package snapshot_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/factory"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/snapshot"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgDB() factory.ArgDB {
+	return factory.ArgDB{
+		DBType: common.LvlDBSerial,
+		// the writes stay in the pending batch, unless flushed
+		BatchDelaySeconds: 100,
+		MaxBatchSize:      100,
+		MaxOpenFiles:      10,
+	}
+}
+
+func TestNewSnapshotManager(t *testing.T) {
+	t.Parallel()
+
+	sm, err := snapshot.NewSnapshotManager(snapshot.ArgsSnapshotManager{BasePath: "snapshots"})
+	assert.Nil(t, sm)
+	assert.Equal(t, common.ErrNilPersisterFactory, err)
+
+	sm, err = snapshot.NewSnapshotManager(snapshot.ArgsSnapshotManager{PersisterFactory: &testscommon.PersisterFactoryStub{}})
+	assert.Nil(t, sm)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	sm, err = snapshot.NewSnapshotManager(snapshot.ArgsSnapshotManager{
+		PersisterFactory: &testscommon.PersisterFactoryStub{},
+		BasePath:         "snapshots",
+	})
+	assert.Nil(t, err)
+	assert.False(t, sm.IsInterfaceNil())
+
+	_, err = sm.Register("../escape", memorydb.New())
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	_, err = sm.Register("name", nil)
+	assert.Equal(t, common.ErrNilPersister, err)
+}
+
+func TestSnapshotManager_TakeSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sm, _ := snapshot.NewSnapshotManager(snapshot.ArgsSnapshotManager{
+		PersisterFactory: factory.NewPersisterFactory(createArgDB()),
+		BasePath:         filepath.Join(dir, "snapshots"),
+	})
+
+	blocks, err := factory.NewPersisterFactory(createArgDB()).Create(filepath.Join(dir, "Blocks"))
+	require.Nil(t, err)
+	gatedBlocks, _ := sm.Register("Blocks", blocks)
+	gatedAccounts, _ := sm.Register("Accounts", memorydb.New())
+	_ = gatedBlocks.Put([]byte("block"), []byte("header"))
+	_ = gatedAccounts.Put([]byte("account"), []byte("balance"))
+
+	// the writes done concurrently are either fully in the snapshot, or fully out of it
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			_ = gatedBlocks.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+			_ = gatedAccounts.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		}
+	}()
+	manifest, err := sm.TakeSnapshot("snapshot1")
+	wg.Wait()
+	require.Nil(t, err)
+
+	require.Len(t, manifest.Entries, 2)
+	assert.Equal(t, "Accounts", manifest.Entries[0].Name)
+	accountsEntry, _ := manifest.Entry("Accounts")
+	blocksEntry, _ := manifest.Entry("Blocks")
+	assert.Equal(t, accountsEntry.NumKeys, blocksEntry.NumKeys)
+	assert.GreaterOrEqual(t, blocksEntry.NumKeys, 1)
+
+	loaded, err := snapshot.LoadManifest(sm.SnapshotPath("snapshot1"))
+	require.Nil(t, err)
+	assert.Equal(t, manifest.Entries, loaded.Entries)
+
+	readOnlyArgDB := createArgDB()
+	readOnlyArgDB.DBType = common.LvlDBReadOnly
+	snapshotBlocks, err := factory.NewPersisterFactory(readOnlyArgDB).Create(blocksEntry.Path)
+	require.Nil(t, err)
+	val, err := snapshotBlocks.Get([]byte("block"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("header"), val)
+	_ = snapshotBlocks.Close()
+
+	_, err = sm.TakeSnapshot("snapshot1")
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	_ = blocks.Close()
+}
+
+func TestSnapshotManager_TakeSnapshotOfPersistersWithoutNativeSnapshots(t *testing.T) {
+	t.Parallel()
+
+	sm, _ := snapshot.NewSnapshotManager(snapshot.ArgsSnapshotManager{
+		PersisterFactory: &testscommon.PersisterFactoryStub{
+			CreateCalled: func(path string) (types.Persister, error) {
+				return memorydb.New(), nil
+			},
+		},
+		BasePath: filepath.Join(t.TempDir(), "snapshots"),
+	})
+
+	gatedMock, _ := sm.Register("Mock", testscommon.NewMemDbMock())
+	gatedMemDB, _ := sm.Register("MemDB", memorydb.New())
+	_ = gatedMock.Put([]byte("key1"), []byte("value1"))
+	_ = gatedMock.Put([]byte("key2"), []byte("value2"))
+	_ = gatedMemDB.Put([]byte("key"), []byte("value"))
+
+	manifest, err := sm.TakeSnapshot("snapshot1")
+	require.Nil(t, err)
+	mockEntry, _ := manifest.Entry("Mock")
+	assert.Equal(t, 2, mockEntry.NumKeys)
+	memDBEntry, _ := manifest.Entry("MemDB")
+	assert.Equal(t, 1, memDBEntry.NumKeys)
+}
@@ -0,0 +1,46 @@
+package storageCacherAdapter
+
+import (
+	"bytes"
+)
+
+// CheckAndHealCounters counts the values persisted in the db, healing the number of values and bytes in storage,
+// maintained incrementally, if they diverged. The db operations are blocked meanwhile. It returns true if the counters
+// diverged
+func (c *storageCacherAdapter) CheckAndHealCounters() bool {
+	c.dbLock.Lock()
+	defer c.dbLock.Unlock()
+
+	if c.dbIsClosed.IsSet() {
+		return false
+	}
+
+	numValues := 0
+	numBytes := uint64(0)
+	c.db.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.Equal(key, numValuesInStorageKey) {
+			numValues++
+			numBytes += uint64(len(value))
+		}
+		return true
+	})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if numValues == c.numValuesInStorage && numBytes == c.numBytesInStorage {
+		return false
+	}
+
+	log.Warn("storageCacherAdapter: the counters diverged from the db",
+		"name", c.name,
+		"num values", c.numValuesInStorage,
+		"counted values", numValues,
+		"num bytes", c.numBytesInStorage,
+		"counted bytes", numBytes,
+	)
+	c.numValuesInStorage = numValues
+	c.numBytesInStorage = numBytes
+
+	return true
+}
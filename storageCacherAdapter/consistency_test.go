@@ -0,0 +1,31 @@
+package storageCacherAdapter
+
+import (
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageCacherAdapter_CheckAndHealCounters(t *testing.T) {
+	t.Parallel()
+
+	cacher, _ := capacity.NewCapacityLRU(10, 1000)
+	db := storageMock.NewMemDbMock()
+	_ = db.Put([]byte("a"), []byte("value a"))
+	sca, err := NewStorageCacherAdapter(cacher, db, trieFactory.NewTrieNodeFactory(), &storageMock.MarshalizerMock{})
+	require.Nil(t, err)
+
+	assert.False(t, sca.CheckAndHealCounters())
+
+	// written behind the adapter's back
+	_ = db.Put([]byte("b"), []byte("value b"))
+	assert.Equal(t, 1, sca.Len())
+	assert.True(t, sca.CheckAndHealCounters())
+	assert.Equal(t, 2, sca.Len())
+	assert.Equal(t, uint64(14), sca.SizeInBytesPersisted())
+	assert.False(t, sca.CheckAndHealCounters())
+}
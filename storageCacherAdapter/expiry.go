@@ -0,0 +1,61 @@
+package storageCacherAdapter
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// expiryHeaderLength is the length of the expiry timestamp (unix nanoseconds) prepended to the persisted values,
+// when a TTL is set
+const expiryHeaderLength = 8
+
+type systemClock struct{}
+
+// Now returns the current system time
+func (sc *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sc *systemClock) IsInterfaceNil() bool {
+	return sc == nil
+}
+
+// encodeStoredValue prepends the expiry timestamp to the value, if a TTL is set
+func (c *storageCacherAdapter) encodeStoredValue(value []byte) []byte {
+	if c.ttl == 0 {
+		return value
+	}
+
+	storedValue := make([]byte, expiryHeaderLength+len(value))
+	expiry := c.clock.Now().Add(c.ttl).UnixNano()
+	binary.BigEndian.PutUint64(storedValue, uint64(expiry))
+	copy(storedValue[expiryHeaderLength:], value)
+
+	return storedValue
+}
+
+// decodeStoredValue returns the value without its expiry timestamp and whether it has expired. The values too short
+// to hold the timestamp are reported as expired
+func (c *storageCacherAdapter) decodeStoredValue(storedValue []byte) ([]byte, bool) {
+	if c.ttl == 0 {
+		return storedValue, false
+	}
+	if len(storedValue) < expiryHeaderLength {
+		return nil, true
+	}
+
+	expiry := int64(binary.BigEndian.Uint64(storedValue))
+	isExpired := c.clock.Now().UnixNano() >= expiry
+
+	return storedValue[expiryHeaderLength:], isExpired
+}
+
+// storedSize returns the size in db of a value with the provided size
+func (c *storageCacherAdapter) storedSize(sizeInBytes int) int {
+	if c.ttl == 0 {
+		return sizeInBytes
+	}
+
+	return sizeInBytes + expiryHeaderLength
+}
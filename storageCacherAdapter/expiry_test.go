@@ -0,0 +1,109 @@
+package storageCacherAdapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgsWithTTL(t *testing.T, clock *storageMock.ClockMock) ArgsStorageCacherAdapter {
+	cacher, err := capacity.NewCapacityLRU(1, 1000)
+	require.Nil(t, err)
+
+	return ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                storageMock.NewMemDbMock(),
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		// the cleanup is called explicitly by the tests
+		CleanupInterval:       time.Hour,
+		MaxRemovalsPerCleanup: 10,
+		TTL:                   time.Minute,
+		Clock:                 clock,
+	}
+}
+
+func TestNewStorageCacherAdapterWithArgs_InvalidTTLConfigShouldErr(t *testing.T) {
+	t.Parallel()
+
+	args := createArgsWithTTL(t, storageMock.NewClockMock(time.Now()))
+	args.TTL = -time.Second
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	args.TTL = time.Second
+	args.CleanupInterval = 0
+	sca, err = NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestStorageCacherAdapter_ExpiredValuesShouldBeRemovedOnGet(t *testing.T) {
+	t.Parallel()
+
+	clock := storageMock.NewClockMock(time.Now())
+	args := createArgsWithTTL(t, clock)
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	require.Nil(t, err)
+	defer func() {
+		_ = sca.Close()
+	}()
+
+	_ = sca.Put([]byte("a"), []byte("value a"), 7)
+	_ = sca.Put([]byte("b"), []byte("value b"), 7)
+	require.Nil(t, args.DB.Has([]byte("a")))
+	storedValue, _ := args.DB.Get([]byte("a"))
+	assert.Equal(t, uint64(len(storedValue)), sca.SizeInBytesPersisted())
+
+	clock.Advance(time.Second * 59)
+	val, ok := sca.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.NotNil(t, val)
+
+	clock.Advance(time.Second)
+	val, ok = sca.Get([]byte("a"))
+	assert.False(t, ok)
+	assert.Nil(t, val)
+	assert.NotNil(t, args.DB.Has([]byte("a")))
+	assert.Equal(t, 1, sca.Len())
+	assert.Zero(t, sca.SizeInBytesPersisted())
+}
+
+func TestStorageCacherAdapter_ExpiredValuesShouldBeRemovedByCleanup(t *testing.T) {
+	t.Parallel()
+
+	clock := storageMock.NewClockMock(time.Now())
+	args := createArgsWithTTL(t, clock)
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	require.Nil(t, err)
+	defer func() {
+		_ = sca.Close()
+	}()
+
+	_ = sca.Put([]byte("a"), []byte("value a"), 7)
+	_ = sca.Put([]byte("b"), []byte("value b"), 7)
+	clock.Advance(time.Second * 30)
+	_ = sca.Put([]byte("c"), []byte("value c"), 7)
+	require.Equal(t, 3, sca.Len())
+
+	assert.Zero(t, sca.cleanupStaleEntries(10))
+
+	clock.Advance(time.Second * 30)
+	assert.Equal(t, 1, sca.cleanupStaleEntries(10))
+	assert.NotNil(t, args.DB.Has([]byte("a")))
+	assert.Nil(t, args.DB.Has([]byte("b")))
+	assert.Equal(t, 2, sca.Len())
+
+	clock.Advance(time.Second * 30)
+	assert.Equal(t, 1, sca.cleanupStaleEntries(10))
+	assert.Equal(t, 1, sca.Len())
+	assert.Zero(t, sca.SizeInBytesPersisted())
+}
@@ -0,0 +1,73 @@
+package storageCacherAdapter
+
+import (
+	"sort"
+	"sync"
+)
+
+type keyLatch struct {
+	sync.Mutex
+	numUsers int
+}
+
+// keyLatches serializes the db operations on the same key, while letting the ones on different keys run concurrently.
+// The latches are created on demand and released as soon as they are no longer used
+type keyLatches struct {
+	mut     sync.Mutex
+	latches map[string]*keyLatch
+}
+
+func newKeyLatches() *keyLatches {
+	return &keyLatches{
+		latches: make(map[string]*keyLatch),
+	}
+}
+
+func (kl *keyLatches) lock(key string) {
+	kl.mut.Lock()
+	latch, ok := kl.latches[key]
+	if !ok {
+		latch = &keyLatch{}
+		kl.latches[key] = latch
+	}
+	latch.numUsers++
+	kl.mut.Unlock()
+
+	latch.Lock()
+}
+
+func (kl *keyLatches) unlock(key string) {
+	kl.mut.Lock()
+	defer kl.mut.Unlock()
+
+	latch, ok := kl.latches[key]
+	if !ok {
+		return
+	}
+
+	latch.numUsers--
+	if latch.numUsers == 0 {
+		delete(kl.latches, key)
+	}
+	latch.Unlock()
+}
+
+// lockAll locks the latches of the provided keys, in order, so that it does not deadlock with other callers.
+// It should not be called while already holding a latch. Returns the sorted keys, to be passed to unlockAll
+func (kl *keyLatches) lockAll(keys []string) []string {
+	sortedKeys := make([]string, len(keys))
+	copy(sortedKeys, keys)
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		kl.lock(key)
+	}
+
+	return sortedKeys
+}
+
+func (kl *keyLatches) unlockAll(keys []string) {
+	for _, key := range keys {
+		kl.unlock(key)
+	}
+}
@@ -0,0 +1,85 @@
+package storageCacherAdapter
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyLatches_SameKeyShouldBeSerialized(t *testing.T) {
+	t.Parallel()
+
+	kl := newKeyLatches()
+	kl.lock("a")
+
+	chLocked := make(chan struct{})
+	go func() {
+		kl.lock("a")
+		close(chLocked)
+		kl.unlock("a")
+	}()
+
+	select {
+	case <-chLocked:
+		assert.Fail(t, "the latch should have been held")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	kl.unlock("a")
+	select {
+	case <-chLocked:
+	case <-time.After(time.Second * 2):
+		assert.Fail(t, "the latch should have been released")
+	}
+}
+
+func TestKeyLatches_DifferentKeysShouldNotBlock(t *testing.T) {
+	t.Parallel()
+
+	kl := newKeyLatches()
+	kl.lock("a")
+	defer kl.unlock("a")
+
+	chLocked := make(chan struct{})
+	go func() {
+		kl.lock("b")
+		kl.unlock("b")
+		close(chLocked)
+	}()
+
+	select {
+	case <-chLocked:
+	case <-time.After(time.Second * 2):
+		assert.Fail(t, "different keys should not block each other")
+	}
+}
+
+func TestKeyLatches_UnusedLatchesShouldBeReleased(t *testing.T) {
+	t.Parallel()
+
+	kl := newKeyLatches()
+	wg := sync.WaitGroup{}
+	keys := []string{"c", "a", "b"}
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			lockedKeys := kl.lockAll(keys)
+			kl.unlockAll(lockedKeys)
+		}()
+		go func(idx int) {
+			defer wg.Done()
+
+			key := keys[idx%len(keys)]
+			kl.lock(key)
+			kl.unlock(key)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"c", "a", "b"}, keys)
+	assert.Zero(t, len(kl.latches))
+}
@@ -0,0 +1,104 @@
+package storageCacherAdapter
+
+import (
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+)
+
+// PrefetchHintProvider returns the keys worth prefetching after the value of the provided key was read from the db,
+// e.g. the keys of the child nodes of a trie node. It can be implemented by the stored data factories as well
+type PrefetchHintProvider interface {
+	RelatedKeys(key []byte, value interface{}) [][]byte
+	IsInterfaceNil() bool
+}
+
+// hintProviderFor returns the configured hint provider or, otherwise, the factory of the key, if it provides hints
+func (c *storageCacherAdapter) hintProviderFor(key []byte) PrefetchHintProvider {
+	if !check.IfNil(c.prefetchHintProvider) {
+		return c.prefetchHintProvider
+	}
+
+	hintProvider, ok := c.storedDataFactory.factoryFor(key).(PrefetchHintProvider)
+	if !ok || check.IfNil(hintProvider) {
+		return nil
+	}
+
+	return hintProvider
+}
+
+// prefetchRelated moves the values related to the one read from the db to the cacher, in the background, so that a
+// traversal finds the next values in memory. The prefetch is dropped if too many are in progress. The values read by
+// the prefetch do not trigger further prefetches
+func (c *storageCacherAdapter) prefetchRelated(key []byte, value interface{}) {
+	if c.prefetchSlots == nil {
+		return
+	}
+	hintProvider := c.hintProviderFor(key)
+	if hintProvider == nil {
+		return
+	}
+
+	relatedKeys := hintProvider.RelatedKeys(key, value)
+	if len(relatedKeys) == 0 {
+		return
+	}
+	if len(relatedKeys) > c.maxKeysPerPrefetch {
+		relatedKeys = relatedKeys[:c.maxKeysPerPrefetch]
+	}
+
+	select {
+	case c.prefetchSlots <- struct{}{}:
+		go c.prefetch(relatedKeys)
+	default:
+		c.numDroppedPrefetches.Increment()
+	}
+}
+
+func (c *storageCacherAdapter) prefetch(keys [][]byte) {
+	defer func() {
+		<-c.prefetchSlots
+	}()
+
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	for _, key := range keys {
+		if c.dbIsClosed.IsSet() {
+			return
+		}
+
+		c.latches.lock(string(key))
+		toPersist, isLoaded := c.loadIntoCacher(key)
+		c.latches.unlock(string(key))
+
+		c.persist(toPersist)
+		if isLoaded {
+			c.numPrefetched.Increment()
+		}
+	}
+}
+
+// loadIntoCacher moves the value of the key from the db to the cacher, unless it is already in memory. The expired
+// values are left to Get and to the cleanup. The caller should hold dbLock and the key's latch
+func (c *storageCacherAdapter) loadIntoCacher(key []byte) (map[string]*pendingValue, bool) {
+	if c.isInMemory(key) {
+		return nil, false
+	}
+
+	storedValue, err := c.db.Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	valBytes, isExpired := c.decodeStoredValue(storedValue)
+	if isExpired {
+		return nil, false
+	}
+
+	val, err := c.getData(key, valBytes)
+	if err != nil {
+		log.Debug("storageCacherAdapter: could not prefetch the value", "key", key, "error", err)
+		return nil, false
+	}
+
+	return c.moveToCacher(key, val, len(valBytes)), true
+}
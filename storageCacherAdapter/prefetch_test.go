@@ -0,0 +1,229 @@
+package storageCacherAdapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type prefetchHintProviderStub struct {
+	RelatedKeysCalled func(key []byte, value interface{}) [][]byte
+}
+
+func (stub *prefetchHintProviderStub) RelatedKeys(key []byte, value interface{}) [][]byte {
+	if stub.RelatedKeysCalled != nil {
+		return stub.RelatedKeysCalled(key, value)
+	}
+
+	return nil
+}
+
+func (stub *prefetchHintProviderStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+// hintingFactory is a stored data factory providing the prefetch hints as well
+type hintingFactory struct {
+	types.StoredDataFactory
+	prefetchHintProviderStub
+}
+
+func (factory *hintingFactory) IsInterfaceNil() bool {
+	return factory == nil
+}
+
+func childrenOf(key []byte, _ interface{}) [][]byte {
+	return [][]byte{[]byte(string(key) + "0"), []byte(string(key) + "1"), []byte(string(key) + "2")}
+}
+
+func createPrefetchingAdapter(t *testing.T, db types.Persister, args ArgsStorageCacherAdapter) (*storageCacherAdapter, types.AdaptedSizedLRUCache) {
+	cacher, _ := capacity.NewCapacityLRU(10, 1000)
+	args.Cacher = cacher
+	args.DB = db
+	if args.StoredDataFactory == nil {
+		args.StoredDataFactory = trieFactory.NewTrieNodeFactory()
+	}
+	args.Marshalizer = &storageMock.MarshalizerMock{}
+
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	require.Nil(t, err)
+
+	return sca, cacher
+}
+
+func createDbWithTreeToTest() types.Persister {
+	db := storageMock.NewMemDbMock()
+	for _, key := range []string{"r", "r0", "r1", "r2", "r00"} {
+		_ = db.Put([]byte(key), []byte("value "+key))
+	}
+
+	return db
+}
+
+func TestNewStorageCacherAdapterWithArgs_InvalidPrefetchArgsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	createArgs := func() ArgsStorageCacherAdapter {
+		cacher, _ := capacity.NewCapacityLRU(10, 1000)
+		return ArgsStorageCacherAdapter{
+			Cacher:            cacher,
+			DB:                storageMock.NewMemDbMock(),
+			StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+			Marshalizer:       &storageMock.MarshalizerMock{},
+		}
+	}
+
+	args := createArgs()
+	args.MaxConcurrentPrefetches = -1
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "MaxConcurrentPrefetches")
+
+	args = createArgs()
+	args.MaxConcurrentPrefetches = 1
+	sca, err = NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "MaxKeysPerPrefetch")
+
+	args = createArgs()
+	args.PrefetchHintProvider = &prefetchHintProviderStub{}
+	sca, err = NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "prefetch hint provider")
+}
+
+func TestStorageCacherAdapter_GetWithPrefetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should move the related values to the cacher", func(t *testing.T) {
+		t.Parallel()
+
+		db := createDbWithTreeToTest()
+		sca, cacher := createPrefetchingAdapter(t, db, ArgsStorageCacherAdapter{
+			MaxConcurrentPrefetches: 1,
+			MaxKeysPerPrefetch:      10,
+			PrefetchHintProvider:    &prefetchHintProviderStub{RelatedKeysCalled: childrenOf},
+		})
+
+		_, ok := sca.Get([]byte("r"))
+		require.True(t, ok)
+		require.Eventually(t, func() bool {
+			return sca.Counters().NumPrefetched == 3
+		}, time.Second, time.Millisecond)
+
+		for _, key := range []string{"r0", "r1", "r2"} {
+			assert.True(t, cacher.Contains(key))
+			assert.NotNil(t, db.Has([]byte(key)))
+		}
+		// the prefetched values do not trigger further prefetches
+		assert.False(t, cacher.Contains("r00"))
+		assert.Equal(t, 5, sca.Len())
+
+		_, ok = sca.Get([]byte("r1"))
+		require.True(t, ok)
+		assert.Equal(t, uint64(1), sca.Counters().NumCacheHits)
+	})
+	t.Run("should prefetch at most MaxKeysPerPrefetch values, skipping the missing ones", func(t *testing.T) {
+		t.Parallel()
+
+		sca, cacher := createPrefetchingAdapter(t, createDbWithTreeToTest(), ArgsStorageCacherAdapter{
+			MaxConcurrentPrefetches: 1,
+			MaxKeysPerPrefetch:      2,
+			PrefetchHintProvider:    &prefetchHintProviderStub{RelatedKeysCalled: childrenOf},
+		})
+
+		_, ok := sca.Get([]byte("r0"))
+		require.True(t, ok)
+		require.Eventually(t, func() bool {
+			return sca.Counters().NumPrefetched == 1
+		}, time.Second, time.Millisecond)
+
+		assert.True(t, cacher.Contains("r00"))
+		assert.Equal(t, 5, sca.Len())
+	})
+	t.Run("should ask the stored data factory if no hint provider is set", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &hintingFactory{
+			StoredDataFactory:        trieFactory.NewTrieNodeFactory(),
+			prefetchHintProviderStub: prefetchHintProviderStub{RelatedKeysCalled: childrenOf},
+		}
+		sca, cacher := createPrefetchingAdapter(t, createDbWithTreeToTest(), ArgsStorageCacherAdapter{
+			StoredDataFactory:       factory,
+			MaxConcurrentPrefetches: 1,
+			MaxKeysPerPrefetch:      10,
+		})
+
+		_, ok := sca.Get([]byte("r"))
+		require.True(t, ok)
+		require.Eventually(t, func() bool {
+			return cacher.Contains("r2")
+		}, time.Second, time.Millisecond)
+	})
+	t.Run("disabled should not prefetch", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &hintingFactory{
+			StoredDataFactory:        trieFactory.NewTrieNodeFactory(),
+			prefetchHintProviderStub: prefetchHintProviderStub{RelatedKeysCalled: childrenOf},
+		}
+		sca, cacher := createPrefetchingAdapter(t, createDbWithTreeToTest(), ArgsStorageCacherAdapter{
+			StoredDataFactory: factory,
+		})
+
+		_, ok := sca.Get([]byte("r"))
+		require.True(t, ok)
+		time.Sleep(10 * time.Millisecond)
+		assert.Zero(t, cacher.Len())
+		assert.Zero(t, sca.Counters().NumPrefetched)
+	})
+	t.Run("should drop the prefetches past MaxConcurrentPrefetches", func(t *testing.T) {
+		t.Parallel()
+
+		db := createDbWithTreeToTest()
+		chPrefetchStarted := make(chan struct{}, 1)
+		chUnblock := make(chan struct{})
+		persister := &storageMock.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				if string(key) == "r0" {
+					chPrefetchStarted <- struct{}{}
+					<-chUnblock
+				}
+				return db.Get(key)
+			},
+			HasCalled:    db.Has,
+			PutCalled:    db.Put,
+			RemoveCalled: db.Remove,
+		}
+		sca, _ := createPrefetchingAdapter(t, persister, ArgsStorageCacherAdapter{
+			MaxConcurrentPrefetches: 1,
+			MaxKeysPerPrefetch:      1,
+			PrefetchHintProvider:    &prefetchHintProviderStub{RelatedKeysCalled: childrenOf},
+		})
+
+		_, ok := sca.Get([]byte("r"))
+		require.True(t, ok)
+		<-chPrefetchStarted
+
+		_, ok = sca.Get([]byte("r1"))
+		require.True(t, ok)
+		assert.Equal(t, uint64(1), sca.Counters().NumDroppedPrefetches)
+		assert.Equal(t, uint64(1), sca.Stats()["numDroppedPrefetches"])
+
+		close(chUnblock)
+		require.Eventually(t, func() bool {
+			return sca.Counters().NumPrefetched == 1
+		}, time.Second, time.Millisecond)
+	})
+}
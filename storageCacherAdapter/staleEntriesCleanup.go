@@ -0,0 +1,99 @@
+package storageCacherAdapter
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// LivenessPredicate tells whether a persisted entry is still referenced by its owner
+type LivenessPredicate func(key []byte, value []byte) bool
+
+func (c *storageCacherAdapter) cleanupStaleEntriesPeriodically(ctx context.Context, interval time.Duration, maxNumRemovals int) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			numRemoved := c.cleanupStaleEntries(maxNumRemovals)
+			log.Trace("storageCacherAdapter: removed stale entries", "name", c.name, "num removed", numRemoved)
+			timer.Reset(interval)
+		case <-ctx.Done():
+			log.Debug("storageCacherAdapter: closing the stale entries cleanup", "name", c.name)
+			return
+		}
+	}
+}
+
+// cleanupStaleEntries removes at most maxNumRemovals stale entries from the db. Each candidate is checked once again,
+// under its key's latch, right before being removed
+func (c *storageCacherAdapter) cleanupStaleEntries(maxNumRemovals int) int {
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	if c.dbIsClosed.IsSet() {
+		return 0
+	}
+
+	candidates := c.findStaleEntries(maxNumRemovals)
+
+	numRemoved := 0
+	for _, key := range candidates {
+		if c.removeIfStale(key) {
+			numRemoved++
+		}
+	}
+
+	return numRemoved
+}
+
+func (c *storageCacherAdapter) removeIfStale(key []byte) bool {
+	c.latches.lock(string(key))
+	defer c.latches.unlock(string(key))
+
+	value, err := c.db.Get(key)
+	if err != nil || !c.isStale(key, value) {
+		return false
+	}
+
+	err = c.db.Remove(key)
+	if err != nil {
+		log.Warn("could not remove stale entry from db", "error", err)
+		return false
+	}
+
+	c.lock.Lock()
+	c.decreaseValuesInStorageNoLock(len(value))
+	c.lock.Unlock()
+
+	return true
+}
+
+func (c *storageCacherAdapter) findStaleEntries(maxNumEntries int) [][]byte {
+	staleKeys := make([][]byte, 0, maxNumEntries)
+	c.db.RangeKeys(func(key []byte, value []byte) bool {
+		if c.isStale(key, value) {
+			staleKeys = append(staleKeys, key)
+		}
+
+		return len(staleKeys) < maxNumEntries
+	})
+
+	return staleKeys
+}
+
+// isStale returns true if the entry has expired, if it is superseded by a value held in memory or if it is no longer
+// referenced
+func (c *storageCacherAdapter) isStale(key []byte, storedValue []byte) bool {
+	if bytes.Equal(key, numValuesInStorageKey) {
+		return false
+	}
+
+	value, isExpired := c.decodeStoredValue(storedValue)
+	if isExpired || c.isInMemory(key) {
+		return true
+	}
+
+	return c.isLive != nil && !c.isLive(key, value)
+}
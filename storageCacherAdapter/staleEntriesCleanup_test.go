@@ -0,0 +1,118 @@
+package storageCacherAdapter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArgsWithStaleEntries(t *testing.T) ArgsStorageCacherAdapter {
+	cacher, err := capacity.NewCapacityLRU(10, 1000)
+	require.Nil(t, err)
+	// "a" is superseded by the value in the cacher
+	_ = cacher.AddSizedAndReturnEvicted("a", []byte("new value a"), 11)
+
+	db := storageMock.NewMemDbMock()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_ = db.Put([]byte(key), []byte("value "+key))
+	}
+
+	return ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		IsLive: func(key []byte, _ []byte) bool {
+			return string(key) != "b"
+		},
+	}
+}
+
+func TestNewStorageCacherAdapterWithArgs_InvalidCleanupConfigShouldErr(t *testing.T) {
+	t.Parallel()
+
+	args := createArgsWithStaleEntries(t)
+	args.CleanupInterval = -time.Second
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	args.CleanupInterval = time.Second
+	args.MaxRemovalsPerCleanup = 0
+	sca, err = NewStorageCacherAdapterWithArgs(args)
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestStorageCacherAdapter_CleanupStaleEntries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should remove the superseded and not referenced entries", func(t *testing.T) {
+		t.Parallel()
+
+		args := createArgsWithStaleEntries(t)
+		sca, err := NewStorageCacherAdapterWithArgs(args)
+		require.Nil(t, err)
+		require.Equal(t, 5, sca.Len())
+
+		numRemoved := sca.cleanupStaleEntries(10)
+		assert.Equal(t, 2, numRemoved)
+		assert.Equal(t, 3, sca.Len())
+		assert.NotNil(t, args.DB.Has([]byte("a")))
+		assert.NotNil(t, args.DB.Has([]byte("b")))
+		assert.Nil(t, args.DB.Has([]byte("c")))
+		assert.Nil(t, args.DB.Has([]byte("d")))
+
+		val, ok := sca.Get([]byte("a"))
+		assert.True(t, ok)
+		assert.Equal(t, []byte("new value a"), val)
+	})
+	t.Run("should remove at most the given number of entries", func(t *testing.T) {
+		t.Parallel()
+
+		sca, err := NewStorageCacherAdapterWithArgs(createArgsWithStaleEntries(t))
+		require.Nil(t, err)
+
+		assert.Equal(t, 1, sca.cleanupStaleEntries(1))
+		assert.Equal(t, 1, sca.cleanupStaleEntries(1))
+		assert.Equal(t, 0, sca.cleanupStaleEntries(1))
+	})
+	t.Run("closed db should not be cleaned", func(t *testing.T) {
+		t.Parallel()
+
+		sca, err := NewStorageCacherAdapterWithArgs(createArgsWithStaleEntries(t))
+		require.Nil(t, err)
+
+		_ = sca.Close()
+		assert.Equal(t, 0, sca.cleanupStaleEntries(10))
+	})
+}
+
+func TestStorageCacherAdapter_CleanupStaleEntriesPeriodically(t *testing.T) {
+	t.Parallel()
+
+	args := createArgsWithStaleEntries(t)
+	args.CleanupInterval = time.Millisecond * 10
+	args.MaxRemovalsPerCleanup = 1
+	sca, err := NewStorageCacherAdapterWithArgs(args)
+	require.Nil(t, err)
+
+	timeout := time.After(time.Second * 2)
+	for sca.Len() > 3 {
+		select {
+		case <-timeout:
+			require.Fail(t, "stale entries should have been removed")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	assert.Equal(t, 3, sca.Len())
+	assert.Nil(t, sca.Close())
+}
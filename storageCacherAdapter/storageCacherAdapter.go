@@ -1,57 +1,311 @@
 package storageCacherAdapter
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
 	"math"
 	"sync"
+	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-core/marshal"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var log = logger.GetOrCreate("storageCacherAdapter")
 
+// numValuesInStorageKey is the reserved key under which the number of persisted values and their size in bytes
+// are saved on Close
+var numValuesInStorageKey = []byte("storageCacherAdapter_numValuesInStorage")
+
+const numValuesInStorageLength = 16
+
+// iterateKeysChunkSize is the number of db keys copied at once while iterating over the keys
+const iterateKeysChunkSize = 1024
+
+// StorageCountRestoreMode specifies how the number of values already present in the storage is restored on startup
+type StorageCountRestoreMode uint8
+
+const (
+	// RestoreCountByRangeKeys counts the persisted values one by one, which is exact, but slow for large storages
+	RestoreCountByRangeKeys StorageCountRestoreMode = iota
+	// RestoreCountByCounterKey reads the count saved on Close under a reserved key. The key is removed on startup,
+	// so after an unclean shutdown it is missing and the values are counted one by one
+	RestoreCountByCounterKey
+)
+
+// StorageCacherAdapterStats holds the counters of a storageCacherAdapter, accumulated since its creation
+type StorageCacherAdapterStats struct {
+	NumCacheHits       uint64
+	NumDBHits          uint64
+	NumMisses          uint64
+	NumPersistFailures uint64
+	NumBytesPersisted  uint64
+	// NumPrefetched and NumDroppedPrefetches count the values prefetched into the cacher and the prefetches dropped
+	// as too many were in progress
+	NumPrefetched        uint64
+	NumDroppedPrefetches uint64
+}
+
+// ArgsStorageCacherAdapter holds the arguments needed to create a storageCacherAdapter
+type ArgsStorageCacherAdapter struct {
+	// Name is optional, being only used when reporting the stats
+	Name              string
+	Cacher            types.AdaptedSizedLRUCache
+	DB                types.Persister
+	StoredDataFactory types.StoredDataFactory
+	Marshalizer       marshal.Marshalizer
+	// StoredDataFactoriesByPrefix and StoredDataFactorySelector are optional, allowing the adapter to hold objects of
+	// different types. The selector is asked first, then the factory with the longest prefix matching the key is used,
+	// StoredDataFactory being the default one
+	StoredDataFactoriesByPrefix map[string]types.StoredDataFactory
+	StoredDataFactorySelector   StoredDataFactorySelector
+	// CodecRegistry, if provided, encodes the persisted values with its current format version and decodes them with
+	// the codec of the version they were written with, instead of the marshalizer & the stored data factories, so
+	// that the values written in an older format are still read
+	CodecRegistry *types.CodecRegistry
+	// RestoreMode specifies how the number of values already present in the storage is restored
+	RestoreMode StorageCountRestoreMode
+	// PromoteOnRead, if set, moves the values found only in the storage back to the cacher, on Get
+	PromoteOnRead bool
+	// CleanupInterval, if not zero, enables the periodic removal of the stale db entries: the ones superseded by
+	// values in the cacher and, if IsLive is provided, the ones it reports as no longer referenced
+	CleanupInterval       time.Duration
+	MaxRemovalsPerCleanup int
+	IsLive                LivenessPredicate
+	// TTL, if not zero, makes the persisted values expire after the given duration. The expired values are removed
+	// on Get and by the periodic cleanup, which should be enabled as well. The TTL should not be toggled for an
+	// existing storage, as the expiry is saved along with each value
+	TTL time.Duration
+	// Clock decides the expiry of the persisted values. It is optional, the system clock being used if not provided
+	Clock types.Clock
+	// CloseTimeout, if not zero, limits the time Close waits for the values being persisted to be flushed. After the
+	// timeout, the db is force closed
+	CloseTimeout time.Duration
+	// MaxConcurrentPrefetches, if not zero, enables the prefetch of the values related to the ones read from the db,
+	// which are moved to the cacher in the background. The related keys are provided by PrefetchHintProvider or,
+	// if not set, by the stored data factory of the read key, if it implements PrefetchHintProvider. The prefetches past
+	// MaxConcurrentPrefetches are dropped, and at most MaxKeysPerPrefetch keys are prefetched after each read
+	MaxConcurrentPrefetches int
+	MaxKeysPerPrefetch      int
+	PrefetchHintProvider    PrefetchHintProvider
+}
+
+type pendingValue struct {
+	data []byte
+}
+
+// storageCacherAdapter does not hold its lock during the db operations, so that the slow disk reads or writes do not
+// block the operations served from memory: the lock only guards the cacher, the values being persisted & the counters.
+// The db operations on the same key are serialized by per-key latches, while dbLock guards the db against being
+// closed in the middle of an operation, unless force closed. The locks are always acquired in this order: dbLock, latches, lock
 type storageCacherAdapter struct {
 	cacher     types.AdaptedSizedLRUCache
 	db         types.Persister
 	lock       sync.RWMutex
-	dbIsClosed bool
+	dbLock     sync.RWMutex
+	dbIsClosed atomic.Flag
+	latches    *keyLatches
+	// evicted values, not yet persisted, which are still served from memory
+	pending map[string]*pendingValue
 
-	storedDataFactory  types.StoredDataFactory
+	storedDataFactory  *storedDataFactoryRouter
 	marshalizer        marshal.Marshalizer
+	codecRegistry      *types.CodecRegistry
 	numValuesInStorage int
+	numBytesInStorage  uint64
+	restoreMode        StorageCountRestoreMode
+	promoteOnRead      bool
+
+	name               string
+	numCacheHits       atomic.Counter
+	numDBHits          atomic.Counter
+	numMisses          atomic.Counter
+	numPersistFailures atomic.Counter
+	numBytesPersisted  atomic.Counter
+
+	isLive        LivenessPredicate
+	cancelCleanup context.CancelFunc
+	ttl           time.Duration
+	clock         types.Clock
+	closeTimeout  time.Duration
+
+	prefetchHintProvider PrefetchHintProvider
+	prefetchSlots        chan struct{}
+	maxKeysPerPrefetch   int
+	numPrefetched        atomic.Counter
+	numDroppedPrefetches atomic.Counter
 }
 
-// NewStorageCacherAdapter creates a new storageCacherAdapter
+// NewStorageCacherAdapter creates a new storageCacherAdapter. The number of values already present in the storage
+// is restored by counting them one by one
 func NewStorageCacherAdapter(
 	cacher types.AdaptedSizedLRUCache,
 	db types.Persister,
 	storedDataFactory types.StoredDataFactory,
 	marshalizer marshal.Marshalizer,
 ) (*storageCacherAdapter, error) {
-	if check.IfNil(cacher) {
+	return NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: storedDataFactory,
+		Marshalizer:       marshalizer,
+		RestoreMode:       RestoreCountByRangeKeys,
+	})
+}
+
+// NewStorageCacherAdapterWithArgs creates a new storageCacherAdapter from the provided arguments
+func NewStorageCacherAdapterWithArgs(args ArgsStorageCacherAdapter) (*storageCacherAdapter, error) {
+	if check.IfNil(args.Cacher) {
 		return nil, common.ErrNilCacher
 	}
-	if check.IfNil(db) {
+	if check.IfNil(args.DB) {
 		return nil, common.ErrNilPersister
 	}
-	if check.IfNil(marshalizer) {
+	if check.IfNil(args.Marshalizer) {
 		return nil, common.ErrNilMarshalizer
 	}
-	if check.IfNil(storedDataFactory) {
-		return nil, common.ErrNilStoredDataFactory
+
+	storedDataFactory, err := newStoredDataFactoryRouter(
+		args.StoredDataFactory,
+		args.StoredDataFactoriesByPrefix,
+		args.StoredDataFactorySelector,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.RestoreMode > RestoreCountByCounterKey {
+		return nil, fmt.Errorf("%w: unknown storage count restore mode %d", common.ErrInvalidConfig, args.RestoreMode)
+	}
+	if args.CleanupInterval < 0 {
+		return nil, fmt.Errorf("%w: negative cleanup interval", common.ErrInvalidConfig)
+	}
+	if args.CleanupInterval > 0 && args.MaxRemovalsPerCleanup < 1 {
+		return nil, fmt.Errorf("%w: MaxRemovalsPerCleanup must be positive", common.ErrInvalidConfig)
+	}
+	if args.TTL < 0 {
+		return nil, fmt.Errorf("%w: negative TTL", common.ErrInvalidConfig)
+	}
+	if args.TTL > 0 && args.CleanupInterval == 0 {
+		return nil, fmt.Errorf("%w: the TTL requires a cleanup interval", common.ErrInvalidConfig)
+	}
+	if args.CloseTimeout < 0 {
+		return nil, fmt.Errorf("%w: negative close timeout", common.ErrInvalidConfig)
+	}
+	if args.MaxConcurrentPrefetches < 0 {
+		return nil, fmt.Errorf("%w: negative MaxConcurrentPrefetches", common.ErrInvalidConfig)
+	}
+	if args.MaxConcurrentPrefetches > 0 && args.MaxKeysPerPrefetch < 1 {
+		return nil, fmt.Errorf("%w: MaxKeysPerPrefetch must be positive", common.ErrInvalidConfig)
+	}
+	if !check.IfNil(args.PrefetchHintProvider) && args.MaxConcurrentPrefetches == 0 {
+		return nil, fmt.Errorf("%w: the prefetch hint provider requires MaxConcurrentPrefetches", common.ErrInvalidConfig)
 	}
 
-	return &storageCacherAdapter{
-		cacher:             cacher,
-		db:                 db,
+	sca := &storageCacherAdapter{
+		cacher:             args.Cacher,
+		db:                 args.DB,
 		lock:               sync.RWMutex{},
+		latches:            newKeyLatches(),
+		pending:            make(map[string]*pendingValue),
 		storedDataFactory:  storedDataFactory,
-		marshalizer:        marshalizer,
+		marshalizer:        args.Marshalizer,
+		codecRegistry:      args.CodecRegistry,
 		numValuesInStorage: 0,
-	}, nil
+		restoreMode:        args.RestoreMode,
+		promoteOnRead:      args.PromoteOnRead,
+		name:               args.Name,
+		isLive:             args.IsLive,
+		ttl:                args.TTL,
+		clock:              args.Clock,
+		closeTimeout:       args.CloseTimeout,
+
+		prefetchHintProvider: args.PrefetchHintProvider,
+		maxKeysPerPrefetch:   args.MaxKeysPerPrefetch,
+	}
+	if args.MaxConcurrentPrefetches > 0 {
+		sca.prefetchSlots = make(chan struct{}, args.MaxConcurrentPrefetches)
+	}
+	if check.IfNil(sca.clock) {
+		sca.clock = &systemClock{}
+	}
+	sca.restoreNumValuesInStorage()
+
+	if args.CleanupInterval > 0 {
+		var ctx context.Context
+		ctx, sca.cancelCleanup = context.WithCancel(context.Background())
+		go sca.cleanupStaleEntriesPeriodically(ctx, args.CleanupInterval, args.MaxRemovalsPerCleanup)
+	}
+
+	return sca, nil
+}
+
+func (c *storageCacherAdapter) restoreNumValuesInStorage() {
+	if c.restoreMode == RestoreCountByCounterKey && c.restoreSavedNumValuesInStorage() {
+		return
+	}
+
+	c.db.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.Equal(key, numValuesInStorageKey) {
+			c.numValuesInStorage++
+			c.numBytesInStorage += uint64(len(value))
+		}
+		return true
+	})
+	log.Debug("storageCacherAdapter: counted the values in storage",
+		"num values", c.numValuesInStorage,
+		"num bytes", c.numBytesInStorage,
+	)
+}
+
+// restoreSavedNumValuesInStorage removes the saved counter in any case, so that it is neither reported as a value,
+// nor trusted after an unclean shutdown
+func (c *storageCacherAdapter) restoreSavedNumValuesInStorage() bool {
+	savedCount, err := c.db.Get(numValuesInStorageKey)
+	if err != nil {
+		return false
+	}
+
+	err = c.db.Remove(numValuesInStorageKey)
+	if err != nil {
+		log.Warn("could not remove the saved number of values in storage", "error", err)
+		return false
+	}
+	if len(savedCount) != numValuesInStorageLength {
+		log.Warn("invalid saved number of values in storage", "length", len(savedCount))
+		return false
+	}
+
+	c.numValuesInStorage = int(binary.BigEndian.Uint64(savedCount[:8]))
+	c.numBytesInStorage = binary.BigEndian.Uint64(savedCount[8:])
+	log.Debug("storageCacherAdapter: restored the saved number of values in storage",
+		"num values", c.numValuesInStorage,
+		"num bytes", c.numBytesInStorage,
+	)
+
+	return true
+}
+
+func (c *storageCacherAdapter) saveNumValuesInStorage() {
+	if c.restoreMode != RestoreCountByCounterKey {
+		return
+	}
+
+	savedCount := make([]byte, numValuesInStorageLength)
+	binary.BigEndian.PutUint64(savedCount[:8], uint64(c.numValuesInStorage))
+	binary.BigEndian.PutUint64(savedCount[8:], c.numBytesInStorage)
+	err := c.db.Put(numValuesInStorageKey, savedCount)
+	if err != nil {
+		log.Warn("could not save the number of values in storage", "error", err)
+	}
 }
 
 // Clear clears the cache
@@ -64,15 +318,28 @@ func (c *storageCacherAdapter) Clear() {
 
 // Put adds the given value in the cacher. If the cacher is full, the evicted values will be persisted to the db
 func (c *storageCacherAdapter) Put(key []byte, value interface{}, sizeInBytes int) bool {
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	evictedValues, toPersist := c.addNoLock(string(key), value, int64(sizeInBytes))
+	c.lock.Unlock()
 
-	evictedValues := c.cacher.AddSizedAndReturnEvicted(string(key), value, int64(sizeInBytes))
+	c.persist(toPersist)
 
-	if c.dbIsClosed {
-		return len(evictedValues) != 0
+	return len(evictedValues) != 0
+}
+
+// addNoLock adds the value in the cacher and marks the evicted values as pending. The evicted values are returned,
+// along with the pending ones, which should be passed to persist after releasing the lock
+func (c *storageCacherAdapter) addNoLock(key string, value interface{}, sizeInBytes int64) (map[interface{}]interface{}, map[string]*pendingValue) {
+	evictedValues := c.cacher.AddSizedAndReturnEvicted(key, value, sizeInBytes)
+
+	if c.dbIsClosed.IsSet() {
+		return evictedValues, nil
 	}
 
+	toPersist := make(map[string]*pendingValue, len(evictedValues))
 	for evictedKey, evictedVal := range evictedValues {
 		evictedKeyStr, ok := evictedKey.(string)
 		if !ok {
@@ -80,30 +347,111 @@ func (c *storageCacherAdapter) Put(key []byte, value interface{}, sizeInBytes in
 			continue
 		}
 
-		evictedValBytes := getBytes(evictedVal, c.marshalizer)
+		evictedValBytes := c.getBytes(evictedVal)
 		if len(evictedValBytes) == 0 {
 			continue
 		}
 
-		err := c.db.Put([]byte(evictedKeyStr), evictedValBytes)
+		pv := &pendingValue{data: evictedValBytes}
+		c.pending[evictedKeyStr] = pv
+		toPersist[evictedKeyStr] = pv
+	}
+
+	return evictedValues, toPersist
+}
+
+// persist writes the pending values to the db, unless they have been superseded or removed in the meantime.
+// The caller should hold dbLock, but neither the lock, nor any latch
+func (c *storageCacherAdapter) persist(toPersist map[string]*pendingValue) {
+	if len(toPersist) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(toPersist))
+	for key := range toPersist {
+		keys = append(keys, key)
+	}
+	lockedKeys := c.latches.lockAll(keys)
+	defer c.latches.unlockAll(lockedKeys)
+
+	c.lock.RLock()
+	data := make(map[string][]byte, len(toPersist))
+	for key, pv := range toPersist {
+		if c.pending[key] == pv {
+			data[key] = c.encodeStoredValue(pv.data)
+		}
+	}
+	c.lock.RUnlock()
+
+	persisted := c.write(data)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, pv := range toPersist {
+		if c.pending[key] == pv {
+			delete(c.pending, key)
+		}
+	}
+	if c.dbIsClosed.IsSet() {
+		// force closed in the meantime
+		return
+	}
+	for _, val := range persisted {
+		c.numValuesInStorage++
+		c.numBytesInStorage += uint64(len(val))
+	}
+}
+
+// write saves the values in a single batch, if the db supports it, otherwise one by one. Returns the saved values
+func (c *storageCacherAdapter) write(data map[string][]byte) map[string][]byte {
+	multiPutter, ok := c.db.(types.MultiPutter)
+	if ok && len(data) > 1 {
+		err := multiPutter.MultiPut(data)
+		if err != nil {
+			log.Error("could not save batch to db", "num values", len(data), "error", err)
+			c.numPersistFailures.Add(int64(len(data)))
+			return nil
+		}
+
+		for _, val := range data {
+			c.numBytesPersisted.Add(int64(len(val)))
+		}
+		return data
+	}
+
+	persisted := make(map[string][]byte, len(data))
+	for key, val := range data {
+		err := c.db.Put([]byte(key), val)
 		if err != nil {
 			log.Error("could not save to db", "error", err)
+			c.numPersistFailures.Increment()
 			continue
 		}
 
-		c.numValuesInStorage++
+		persisted[key] = val
+		c.numBytesPersisted.Add(int64(len(val)))
 	}
 
-	return len(evictedValues) != 0
+	return persisted
 }
 
-func getBytes(data interface{}, marshalizer marshal.Marshalizer) []byte {
+func (c *storageCacherAdapter) getBytes(data interface{}) []byte {
+	if c.codecRegistry != nil {
+		encoded, err := c.codecRegistry.Encode(data)
+		if err != nil {
+			log.Error("could not encode value", "error", err)
+			return nil
+		}
+		return encoded
+	}
+
 	evictedVal, ok := data.(types.SerializedStoredData)
 	if ok {
 		return evictedVal.GetSerialized()
 	}
 
-	evictedValBytes, err := marshalizer.Marshal(data)
+	evictedValBytes, err := c.marshalizer.Marshal(data)
 	if err != nil {
 		log.Error("could not marshal value", "error", err)
 		return nil
@@ -112,36 +460,194 @@ func getBytes(data interface{}, marshalizer marshal.Marshalizer) []byte {
 	return evictedValBytes
 }
 
-// Get returns the value at the given key
+// Get returns the value at the given key. If so configured, the values found only in the db are moved to the cacher,
+// and the related values are prefetched
 func (c *storageCacherAdapter) Get(key []byte) (interface{}, bool) {
+	val, ok := c.getFromCacher(key)
+	if ok {
+		return val, true
+	}
+
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	val, valBytes, ok := c.getFromDB(key)
+	if ok && valBytes != nil && c.promoteOnRead {
+		c.promote(key, val, len(valBytes))
+	}
+	if ok && valBytes != nil {
+		c.prefetchRelated(key, val)
+	}
+
+	return val, ok
+}
+
+func (c *storageCacherAdapter) getFromCacher(key []byte) (interface{}, bool) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 
 	val, ok := c.cacher.Get(string(key))
 	if ok {
-		return val, true
+		c.numCacheHits.Increment()
+	}
+
+	return val, ok
+}
+
+// getFromDB returns the value at the given key, along with its serialized form, if it was found in the db.
+// The cacher and the pending values are checked once again, under the key's latch, as the value might have been
+// promoted or evicted in the meantime. The caller should hold dbLock
+func (c *storageCacherAdapter) getFromDB(key []byte) (interface{}, []byte, bool) {
+	if c.dbIsClosed.IsSet() {
+		c.numMisses.Increment()
+		return nil, nil, false
 	}
 
-	if c.dbIsClosed {
-		return nil, false
+	// the pending values are served without waiting for them to be persisted
+	_, pendingBytes, _ := c.getFromMemory(key)
+	if pendingBytes != nil {
+		return c.unmarshalDBValue(key, pendingBytes, nil)
 	}
 
-	valBytes, err := c.db.Get(key)
+	c.latches.lock(string(key))
+	defer c.latches.unlock(string(key))
+
+	val, pendingBytes, ok := c.getFromMemory(key)
+	if ok {
+		c.numCacheHits.Increment()
+		return val, nil, true
+	}
+	if pendingBytes != nil {
+		return c.unmarshalDBValue(key, pendingBytes, nil)
+	}
+
+	storedValue, err := c.db.Get(key)
 	if err != nil {
-		return nil, false
+		c.numMisses.Increment()
+		return nil, nil, false
+	}
+
+	valBytes, isExpired := c.decodeStoredValue(storedValue)
+	if isExpired {
+		c.removeExpired(key, len(storedValue))
+		c.numMisses.Increment()
+		return nil, nil, false
+	}
+
+	return c.unmarshalDBValue(key, valBytes, valBytes)
+}
+
+// removeExpired removes the expired value from the db. The caller should hold dbLock and the key's latch
+func (c *storageCacherAdapter) removeExpired(key []byte, storedSize int) {
+	err := c.db.Remove(key)
+	if err != nil {
+		log.Warn("could not remove expired value from db", "error", err)
+		return
+	}
+
+	c.lock.Lock()
+	c.decreaseValuesInStorageNoLock(storedSize)
+	c.lock.Unlock()
+}
+
+// getFromMemory returns the value found in the cacher or, otherwise, the serialized pending value, if any
+func (c *storageCacherAdapter) getFromMemory(key []byte) (interface{}, []byte, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	val, ok := c.cacher.Get(string(key))
+	if ok {
+		return val, nil, true
+	}
+
+	pv, ok := c.pending[string(key)]
+	if ok {
+		return nil, pv.data, false
 	}
 
-	storedData, err := c.getData(valBytes)
+	return nil, nil, false
+}
+
+func (c *storageCacherAdapter) unmarshalDBValue(key []byte, serializedData []byte, valBytes []byte) (interface{}, []byte, bool) {
+	storedData, err := c.getData(key, serializedData)
 	if err != nil {
 		log.Error("could not get data", "error", err)
-		return nil, false
+		c.numMisses.Increment()
+		return nil, nil, false
+	}
+
+	c.numDBHits.Increment()
+	return storedData, valBytes, true
+}
+
+// promote moves a value from the db to the cacher, unless it has been changed in the meantime.
+// The caller should hold dbLock, but neither the lock, nor any latch
+func (c *storageCacherAdapter) promote(key []byte, value interface{}, sizeInBytes int) {
+	if c.dbIsClosed.IsSet() {
+		return
+	}
+
+	c.latches.lock(string(key))
+	toPersist := c.moveToCacher(key, value, sizeInBytes)
+	c.latches.unlock(string(key))
+
+	c.persist(toPersist)
+}
+
+// moveToCacher adds the value to the cacher & removes it from the db. The caller should hold the key's latch
+func (c *storageCacherAdapter) moveToCacher(key []byte, value interface{}, sizeInBytes int) map[string]*pendingValue {
+	if c.db.Has(key) != nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	_, isPending := c.pending[string(key)]
+	if isPending || c.cacher.Contains(string(key)) {
+		c.lock.Unlock()
+		return nil
+	}
+
+	evictedValues, toPersist := c.addNoLock(string(key), value, int64(sizeInBytes))
+	_, isEvictedRightAway := evictedValues[string(key)]
+	if isEvictedRightAway {
+		// the value will be persisted once again, over itself
+		c.decreaseValuesInStorageNoLock(c.storedSize(sizeInBytes))
 	}
+	c.lock.Unlock()
 
-	return storedData, true
+	if isEvictedRightAway {
+		return toPersist
+	}
+
+	err := c.db.Remove(key)
+	if err != nil {
+		log.Warn("could not remove the promoted value from db", "error", err)
+		return toPersist
+	}
+
+	c.lock.Lock()
+	c.decreaseValuesInStorageNoLock(c.storedSize(sizeInBytes))
+	c.lock.Unlock()
+
+	return toPersist
 }
 
-func (c *storageCacherAdapter) getData(serializedData []byte) (interface{}, error) {
-	storedData := c.storedDataFactory.CreateEmpty()
+func (c *storageCacherAdapter) decreaseValuesInStorageNoLock(sizeInBytes int) {
+	c.numValuesInStorage--
+	if uint64(sizeInBytes) > c.numBytesInStorage {
+		c.numBytesInStorage = 0
+		return
+	}
+
+	c.numBytesInStorage -= uint64(sizeInBytes)
+}
+
+func (c *storageCacherAdapter) getData(key []byte, serializedData []byte) (interface{}, error) {
+	if c.codecRegistry != nil {
+		return c.codecRegistry.Decode(serializedData)
+	}
+
+	storedData := c.storedDataFactory.factoryFor(key).CreateEmpty()
 	data, ok := storedData.(types.SerializedStoredData)
 	if ok {
 		data.SetSerialized(serializedData)
@@ -158,22 +664,37 @@ func (c *storageCacherAdapter) getData(serializedData []byte) (interface{}, erro
 
 // Has checks if the given key is present in the storageUnit
 func (c *storageCacherAdapter) Has(key []byte) bool {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
-
-	isPresent := c.cacher.Contains(string(key))
-	if isPresent {
+	if c.isInMemory(key) {
 		return true
 	}
 
-	if c.dbIsClosed {
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	if c.dbIsClosed.IsSet() {
 		return false
 	}
 
+	c.latches.lock(string(key))
+	defer c.latches.unlock(string(key))
+
+	if c.isInMemory(key) {
+		return true
+	}
+
 	err := c.db.Has(key)
 	return err == nil
 }
 
+func (c *storageCacherAdapter) isInMemory(key []byte) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	_, isPending := c.pending[string(key)]
+
+	return isPending || c.cacher.Contains(string(key))
+}
+
 // Peek returns the value at the given key by searching only in cacher
 func (c *storageCacherAdapter) Peek(key []byte) (interface{}, bool) {
 	c.lock.RLock()
@@ -197,46 +718,147 @@ func (c *storageCacherAdapter) HasOrAdd(key []byte, value interface{}, sizeInByt
 // Remove deletes the given key from the storageUnit
 func (c *storageCacherAdapter) Remove(key []byte) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
-
 	removed := c.cacher.Remove(string(key))
-	if removed || c.dbIsClosed {
+	c.lock.Unlock()
+	if removed {
 		return
 	}
 
-	err := c.db.Remove(key)
-	if err == nil {
-		c.numValuesInStorage--
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	if c.dbIsClosed.IsSet() {
+		return
 	}
+
+	c.latches.lock(string(key))
+	defer c.latches.unlock(string(key))
+
+	c.lock.Lock()
+	// the pending value, if any, is not persisted anymore
+	delete(c.pending, string(key))
+	c.lock.Unlock()
+
+	val, err := c.db.Get(key)
+	if err != nil {
+		return
+	}
+
+	err = c.db.Remove(key)
+	if err != nil {
+		return
+	}
+
+	c.lock.Lock()
+	c.decreaseValuesInStorageNoLock(len(val))
+	c.lock.Unlock()
 }
 
 // Keys returns all the keys present in the storageUnit
 func (c *storageCacherAdapter) Keys() [][]byte {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	storedKeys := make([][]byte, 0)
+	c.IterateKeys(func(key []byte) bool {
+		storedKeys = append(storedKeys, key)
+		return true
+	})
 
+	return storedKeys
+}
+
+// IterateKeys calls the handler for each key present in the storageUnit, without gathering all the keys in memory:
+// first the keys in the cacher & the pending ones, then the ones in the db. The iteration stops when the handler
+// returns false. The db keys are copied in chunks, if the db supports the range iteration, and no lock is held while
+// the handler is called, so it can call back into the storageUnit
+func (c *storageCacherAdapter) IterateKeys(handler func(key []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	c.lock.RLock()
 	cacherKeys := c.cacher.Keys()
-	storedKeys := make([][]byte, 0, len(cacherKeys))
+	pendingKeys := make(map[string]struct{}, len(c.pending))
+	for key := range c.pending {
+		pendingKeys[key] = struct{}{}
+	}
+	c.lock.RUnlock()
+
 	for i := range cacherKeys {
 		key, ok := cacherKeys[i].(string)
 		if !ok {
 			continue
 		}
 
-		storedKeys = append(storedKeys, []byte(key))
+		if !handler([]byte(key)) {
+			return
+		}
+	}
+	for key := range pendingKeys {
+		if !handler([]byte(key)) {
+			return
+		}
 	}
 
-	if c.dbIsClosed {
-		return storedKeys
+	startKey := make([]byte, 0)
+	for {
+		keys, nextStartKey := c.dbKeysChunk(startKey, pendingKeys)
+		for _, key := range keys {
+			if !handler(key) {
+				return
+			}
+		}
+
+		if nextStartKey == nil {
+			return
+		}
+		startKey = nextStartKey
 	}
+}
 
-	getKeys := func(key []byte, _ []byte) bool {
-		storedKeys = append(storedKeys, key)
-		return true
+// dbKeysChunk copies, under the db read lock, the db keys starting from startKey, at most iterateKeysChunkSize of
+// them, along with the key to resume from, nil if there are no more keys. The db keys are copied at once if the db
+// does not support the range iteration
+func (c *storageCacherAdapter) dbKeysChunk(startKey []byte, pendingKeys map[string]struct{}) ([][]byte, []byte) {
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+
+	if c.dbIsClosed.IsSet() {
+		return nil, nil
 	}
 
-	c.db.RangeKeys(getKeys)
-	return storedKeys
+	keys := make([][]byte, 0)
+	appendKey := func(key []byte) {
+		_, isPending := pendingKeys[string(key)]
+		if isPending || bytes.Equal(key, numValuesInStorageKey) {
+			return
+		}
+
+		keys = append(keys, append([]byte{}, key...))
+	}
+
+	rangeIterator, ok := c.db.(types.RangeIterator)
+	if !ok {
+		c.db.RangeKeys(func(key []byte, _ []byte) bool {
+			appendKey(key)
+			return true
+		})
+
+		return keys, nil
+	}
+
+	numScanned := 0
+	var lastKey []byte
+	rangeIterator.RangeKeysBetween(startKey, nil, func(key []byte, _ []byte) bool {
+		appendKey(key)
+		numScanned++
+		lastKey = append(lastKey[:0], key...)
+
+		return numScanned < iterateKeysChunkSize
+	})
+	if numScanned < iterateKeysChunkSize {
+		return keys, nil
+	}
+
+	return keys, append(lastKey, 0)
 }
 
 // Len returns the number of elements from the storageUnit
@@ -245,7 +867,7 @@ func (c *storageCacherAdapter) Len() int {
 	defer c.lock.RUnlock()
 
 	cacheLen := c.cacher.Len()
-	return cacheLen + c.numValuesInStorage
+	return cacheLen + len(c.pending) + c.numValuesInStorage
 }
 
 // SizeInBytesContained returns the number of bytes stored in the cache
@@ -256,6 +878,15 @@ func (c *storageCacherAdapter) SizeInBytesContained() uint64 {
 	return c.cacher.SizeInBytesContained()
 }
 
+// SizeInBytesPersisted returns the number of bytes of the values persisted in the storage. Just like the number of
+// persisted values, it is maintained incrementally, so it is approximate if the same key is persisted more than once
+func (c *storageCacherAdapter) SizeInBytesPersisted() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.numBytesInStorage
+}
+
 // MaxSize returns MaxInt64
 func (c *storageCacherAdapter) MaxSize() int {
 	return math.MaxInt64
@@ -269,16 +900,140 @@ func (c *storageCacherAdapter) RegisterHandler(_ func(_ []byte, _ interface{}),
 func (c *storageCacherAdapter) UnRegisterHandler(_ string) {
 }
 
-// Close closes the underlying db
+// Close closes the underlying db, after the values being persisted are flushed. If they are not flushed within the
+// configured close timeout, the db is force closed and ErrCloseTimeout is returned. If so configured, the number of
+// values in storage is saved beforehand
 func (c *storageCacherAdapter) Close() error {
+	if c.cancelCleanup != nil {
+		c.cancelCleanup()
+	}
+
+	if c.closeTimeout == 0 {
+		return c.closeGracefully()
+	}
+
+	chClosed := make(chan error, 1)
+	go func() {
+		chClosed <- c.closeGracefully()
+	}()
+
+	select {
+	case err := <-chClosed:
+		return err
+	case <-time.After(c.closeTimeout):
+	}
+
+	log.Warn("storageCacherAdapter: timeout while flushing the values being persisted, force closing the db",
+		"name", c.name,
+		"timeout", c.closeTimeout,
+	)
+	err := c.ForceClose()
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w after %v", common.ErrCloseTimeout, c.closeTimeout)
+}
+
+// closeGracefully waits for the ongoing db operations to finish, then closes the db
+func (c *storageCacherAdapter) closeGracefully() error {
+	c.dbLock.Lock()
+	defer c.dbLock.Unlock()
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.dbIsClosed = true
+	if c.dbIsClosed.IsSet() {
+		return nil
+	}
+
+	c.saveNumValuesInStorage()
+	c.monitorStats()
+
+	return c.closeNoLock()
+}
+
+// ForceClose closes the underlying db right away, without waiting for the ongoing db operations. The values not yet
+// persisted are lost and the number of values in storage is not saved, so it will be counted on the next startup
+func (c *storageCacherAdapter) ForceClose() error {
+	if c.cancelCleanup != nil {
+		c.cancelCleanup()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.dbIsClosed.IsSet() {
+		return nil
+	}
+
+	log.Debug("storageCacherAdapter: force closing the db", "name", c.name, "num values not persisted", len(c.pending))
+	c.pending = make(map[string]*pendingValue)
+	c.monitorStats()
+
+	return c.closeNoLock()
+}
+
+func (c *storageCacherAdapter) closeNoLock() error {
+	c.dbIsClosed.SetValue(true)
 	c.numValuesInStorage = 0
+	c.numBytesInStorage = 0
+
 	return c.db.Close()
 }
 
+// Counters returns the counters of the cache & db hits, misses, persisted evicted values and prefetches
+func (c *storageCacherAdapter) Counters() StorageCacherAdapterStats {
+	return StorageCacherAdapterStats{
+		NumCacheHits:       c.numCacheHits.GetUint64(),
+		NumDBHits:          c.numDBHits.GetUint64(),
+		NumMisses:          c.numMisses.GetUint64(),
+		NumPersistFailures: c.numPersistFailures.GetUint64(),
+		NumBytesPersisted:  c.numBytesPersisted.GetUint64(),
+
+		NumPrefetched:        c.numPrefetched.GetUint64(),
+		NumDroppedPrefetches: c.numDroppedPrefetches.GetUint64(),
+	}
+}
+
+func (c *storageCacherAdapter) monitorStats() {
+	stats := c.Counters()
+	monitoring.MonitorCacheStats(c.name, stats.NumCacheHits, stats.NumDBHits+stats.NumMisses,
+		"db hits", stats.NumDBHits,
+		"persist failures", stats.NumPersistFailures,
+		"persisted", core.ConvertBytes(stats.NumBytesPersisted),
+	)
+}
+
+// Stats returns the key stats of the adapter, along with its counters. The number of items includes the values
+// persisted in the db, whose size is reported separately
+func (c *storageCacherAdapter) Stats() map[string]interface{} {
+	counters := c.Counters()
+	stats := types.CacheStats("StorageCacherAdapter", c)
+	stats["sizeInBytesPersisted"] = c.SizeInBytesPersisted()
+	stats["numCacheHits"] = counters.NumCacheHits
+	stats["numDBHits"] = counters.NumDBHits
+	stats["numMisses"] = counters.NumMisses
+	stats["numPersistFailures"] = counters.NumPersistFailures
+	stats["numBytesPersisted"] = counters.NumBytesPersisted
+	stats["numPrefetched"] = counters.NumPrefetched
+	stats["numDroppedPrefetches"] = counters.NumDroppedPrefetches
+
+	return stats
+}
+
+// Health reports the adapter as failed once its db is closed, along with the health of the db, if reported
+func (c *storageCacherAdapter) Health() types.HealthStatus {
+	status := types.NewHealthStatus()
+	if c.dbIsClosed.IsSet() {
+		status.Fail("db is closed")
+		return status
+	}
+	status.AddComponentHealth("db", c.db)
+
+	return status
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (c *storageCacherAdapter) IsInterfaceNil() bool {
 	return c == nil
@@ -1,14 +1,21 @@
 package storageCacherAdapter
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
 	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -125,6 +132,83 @@ func TestStorageCacherAdapter_Put(t *testing.T) {
 	assert.True(t, addSizedAndReturnEvictedCalled)
 }
 
+type multiPutterPersisterStub struct {
+	*storageMock.PersisterStub
+	multiPutCalled func(data map[string][]byte) error
+}
+
+func (stub *multiPutterPersisterStub) MultiPut(data map[string][]byte) error {
+	return stub.multiPutCalled(data)
+}
+
+func TestStorageCacherAdapter_PutShouldPersistEvictedValuesInBatch(t *testing.T) {
+	t.Parallel()
+
+	evictedValues := map[interface{}]interface{}{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+		"key3": []byte("value3"),
+	}
+
+	t.Run("batch should be written at once", func(t *testing.T) {
+		t.Parallel()
+
+		var persisted map[string][]byte
+		db := &multiPutterPersisterStub{
+			PersisterStub: &storageMock.PersisterStub{
+				PutCalled: func(_, _ []byte) error {
+					assert.Fail(t, "should have not called Put")
+					return nil
+				},
+			},
+			multiPutCalled: func(data map[string][]byte) error {
+				persisted = data
+				return nil
+			},
+		}
+		sca, err := NewStorageCacherAdapter(
+			&storageMock.AdaptedSizedLruCacheStub{
+				AddSizedAndReturnEvictedCalled: func(_, _ interface{}, _ int64) map[interface{}]interface{} {
+					return evictedValues
+				},
+			},
+			db,
+			trieFactory.NewTrieNodeFactory(),
+			&storageMock.MarshalizerMock{},
+		)
+		require.Nil(t, err)
+
+		evicted := sca.Put([]byte("key4"), []byte("value4"), 6)
+		assert.True(t, evicted)
+		assert.Equal(t, 3, len(persisted))
+		assert.Equal(t, 3, sca.Len())
+	})
+	t.Run("failed batch should not be counted", func(t *testing.T) {
+		t.Parallel()
+
+		db := &multiPutterPersisterStub{
+			PersisterStub: &storageMock.PersisterStub{},
+			multiPutCalled: func(_ map[string][]byte) error {
+				return fmt.Errorf("expected error")
+			},
+		}
+		sca, err := NewStorageCacherAdapter(
+			&storageMock.AdaptedSizedLruCacheStub{
+				AddSizedAndReturnEvictedCalled: func(_, _ interface{}, _ int64) map[interface{}]interface{} {
+					return evictedValues
+				},
+			},
+			db,
+			trieFactory.NewTrieNodeFactory(),
+			&storageMock.MarshalizerMock{},
+		)
+		require.Nil(t, err)
+
+		_ = sca.Put([]byte("key4"), []byte("value4"), 6)
+		assert.Equal(t, 0, sca.Len())
+	})
+}
+
 func TestStorageCacherAdapter_PutWithClosedDB(t *testing.T) {
 	t.Parallel()
 
@@ -587,6 +671,114 @@ func TestStorageCacherAdapter_KeysWithClosedDB(t *testing.T) {
 	assert.Equal(t, []byte("key2"), keys[0])
 }
 
+func TestStorageCacherAdapter_IterateKeys(t *testing.T) {
+	t.Parallel()
+
+	createAdapter := func() *storageCacherAdapter {
+		db := storageMock.NewMemDbMock()
+		_ = db.Put([]byte("key1"), []byte("val"))
+		_ = db.Put([]byte("key2"), []byte("val"))
+		_ = db.Put(numValuesInStorageKey, []byte("counter"))
+		sca, err := NewStorageCacherAdapter(
+			&storageMock.AdaptedSizedLruCacheStub{
+				KeysCalled: func() []interface{} {
+					return []interface{}{"key3", 4}
+				},
+			},
+			db,
+			trieFactory.NewTrieNodeFactory(),
+			&storageMock.MarshalizerMock{},
+		)
+		require.Nil(t, err)
+
+		return sca
+	}
+
+	t.Run("nil handler should not panic", func(t *testing.T) {
+		t.Parallel()
+
+		createAdapter().IterateKeys(nil)
+	})
+	t.Run("should iterate the cacher keys first, then the db keys", func(t *testing.T) {
+		t.Parallel()
+
+		keys := make([]string, 0)
+		createAdapter().IterateKeys(func(key []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+
+		require.Equal(t, 3, len(keys))
+		assert.Equal(t, "key3", keys[0])
+		assert.ElementsMatch(t, []string{"key1", "key2"}, keys[1:])
+	})
+	t.Run("should stop when the handler returns false", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		createAdapter().IterateKeys(func(_ []byte) bool {
+			numCalls++
+			return numCalls < 2
+		})
+
+		assert.Equal(t, 2, numCalls)
+	})
+	t.Run("closed db should iterate only the cacher keys", func(t *testing.T) {
+		t.Parallel()
+
+		sca := createAdapter()
+		_ = sca.Close()
+
+		keys := make([]string, 0)
+		sca.IterateKeys(func(key []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+
+		assert.Equal(t, []string{"key3"}, keys)
+	})
+	t.Run("handler calling back into the adapter should not deadlock with a pending db write lock", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		// more keys than copied at once
+		numKeys := 2*iterateKeysChunkSize + 10
+		for i := 0; i < numKeys; i++ {
+			_ = db.Put([]byte(fmt.Sprintf("key%d", i)), []byte("val"))
+		}
+		sca, err := NewStorageCacherAdapter(
+			&storageMock.AdaptedSizedLruCacheStub{},
+			db,
+			trieFactory.NewTrieNodeFactory(),
+			&storageMock.MarshalizerMock{},
+		)
+		require.Nil(t, err)
+
+		chDone := make(chan struct{})
+		keys := make(map[string]struct{})
+		go func() {
+			sca.IterateKeys(func(key []byte) bool {
+				if len(keys) == 0 {
+					go sca.CheckAndHealCounters()
+					// let the counters check wait for the db write lock
+					time.Sleep(10 * time.Millisecond)
+				}
+				keys[string(key)] = struct{}{}
+
+				return sca.Has(key)
+			})
+			close(chDone)
+		}()
+
+		select {
+		case <-chDone:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "IterateKeys deadlocked")
+		}
+		assert.Equal(t, numKeys, len(keys))
+	})
+}
+
 func TestStorageCacherAdapter_Len(t *testing.T) {
 	t.Parallel()
 
@@ -634,6 +826,35 @@ func TestStorageCacherAdapter_SizeInBytesContained(t *testing.T) {
 	assert.Equal(t, uint64(1000), totalSize)
 }
 
+func TestStorageCacherAdapter_SizeInBytesPersisted(t *testing.T) {
+	t.Parallel()
+
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	sca, err := NewStorageCacherAdapter(
+		cacher,
+		createDbWithValues(2),
+		trieFactory.NewTrieNodeFactory(),
+		&storageMock.MarshalizerMock{},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(6), sca.SizeInBytesPersisted())
+
+	evictedBytes := []byte("evicted value")
+	_ = sca.Put([]byte("key2"), evictedBytes, len(evictedBytes))
+	_ = sca.Put([]byte("key3"), []byte("other value"), 11)
+	persistedSize := sca.SizeInBytesPersisted()
+	assert.Greater(t, persistedSize, uint64(6))
+	assert.Equal(t, sca.Counters().NumBytesPersisted+6, persistedSize)
+
+	sca.Remove([]byte("key0"))
+	assert.Equal(t, persistedSize-3, sca.SizeInBytesPersisted())
+	sca.Remove([]byte("missing"))
+	assert.Equal(t, persistedSize-3, sca.SizeInBytesPersisted())
+
+	_ = sca.Close()
+	assert.Zero(t, sca.SizeInBytesPersisted())
+}
+
 func TestStorageCacherAdapter_MaxSize(t *testing.T) {
 	t.Parallel()
 
@@ -695,3 +916,570 @@ func TestStorageCacherAdapter_Close(t *testing.T) {
 	_ = sca.Close()
 	assert.True(t, closeCalled)
 }
+
+func TestNewStorageCacherAdapterWithArgs_InvalidRestoreModeShouldErr(t *testing.T) {
+	t.Parallel()
+
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            &storageMock.AdaptedSizedLruCacheStub{},
+		DB:                &storageMock.PersisterStub{},
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		RestoreMode:       RestoreCountByCounterKey + 1,
+	})
+	assert.True(t, check.IfNil(sca))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func createDbWithValues(numValues int) *storageMock.MemDbMock {
+	db := storageMock.NewMemDbMock()
+	for i := 0; i < numValues; i++ {
+		_ = db.Put([]byte(fmt.Sprintf("key%d", i)), []byte("val"))
+	}
+
+	return db
+}
+
+func TestStorageCacherAdapter_LenShouldCountTheValuesAlreadyInStorage(t *testing.T) {
+	t.Parallel()
+
+	db := createDbWithValues(5)
+	// a counter left behind by a previous run, with another restore mode, is ignored
+	_ = db.Put(numValuesInStorageKey, []byte{0, 0, 0, 0, 0, 0, 0, 100})
+
+	sca, err := NewStorageCacherAdapter(
+		&storageMock.AdaptedSizedLruCacheStub{},
+		db,
+		trieFactory.NewTrieNodeFactory(),
+		&storageMock.MarshalizerMock{},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, 5, sca.Len())
+	assert.Equal(t, 5, len(sca.Keys()))
+	assert.Equal(t, uint64(15), sca.SizeInBytesPersisted())
+}
+
+func TestStorageCacherAdapter_RestoreCountByCounterKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saved counter should be used and saved back on close", func(t *testing.T) {
+		t.Parallel()
+
+		db := createDbWithValues(5)
+		_ = db.Put(numValuesInStorageKey, []byte{0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 21})
+
+		sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+			Cacher:            &storageMock.AdaptedSizedLruCacheStub{},
+			DB:                db,
+			StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+			Marshalizer:       &storageMock.MarshalizerMock{},
+			RestoreMode:       RestoreCountByCounterKey,
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 7, sca.Len())
+		assert.Equal(t, uint64(21), sca.SizeInBytesPersisted())
+		assert.NotNil(t, db.Has(numValuesInStorageKey))
+
+		sca.Remove([]byte("key0"))
+		_ = sca.Close()
+
+		savedCount, err := db.Get(numValuesInStorageKey)
+		require.Nil(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 6, 0, 0, 0, 0, 0, 0, 0, 18}, savedCount)
+	})
+	t.Run("missing counter should fallback to counting", func(t *testing.T) {
+		t.Parallel()
+
+		sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+			Cacher:            &storageMock.AdaptedSizedLruCacheStub{},
+			DB:                createDbWithValues(3),
+			StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+			Marshalizer:       &storageMock.MarshalizerMock{},
+			RestoreMode:       RestoreCountByCounterKey,
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 3, sca.Len())
+	})
+	t.Run("invalid counter should fallback to counting", func(t *testing.T) {
+		t.Parallel()
+
+		db := createDbWithValues(3)
+		// the counter saved by the previous versions holds only the number of values
+		_ = db.Put(numValuesInStorageKey, []byte{0, 0, 0, 0, 0, 0, 0, 7})
+
+		sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+			Cacher:            &storageMock.AdaptedSizedLruCacheStub{},
+			DB:                db,
+			StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+			Marshalizer:       &storageMock.MarshalizerMock{},
+			RestoreMode:       RestoreCountByCounterKey,
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 3, sca.Len())
+		assert.Equal(t, uint64(9), sca.SizeInBytesPersisted())
+	})
+}
+
+func TestStorageCacherAdapter_GetWithPromoteOnRead(t *testing.T) {
+	t.Parallel()
+
+	createAdapter := func(promoteOnRead bool) (*storageCacherAdapter, types.AdaptedSizedLRUCache, types.Persister) {
+		cacher, _ := capacity.NewCapacityLRU(2, 1000)
+		db := storageMock.NewMemDbMock()
+		_ = db.Put([]byte("a"), []byte("value a"))
+
+		sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+			Cacher:            cacher,
+			DB:                db,
+			StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+			Marshalizer:       &storageMock.MarshalizerMock{},
+			PromoteOnRead:     promoteOnRead,
+		})
+		require.Nil(t, err)
+
+		return sca, cacher, db
+	}
+
+	t.Run("disabled should keep the value in db", func(t *testing.T) {
+		t.Parallel()
+
+		sca, cacher, db := createAdapter(false)
+
+		val, ok := sca.Get([]byte("a"))
+		require.True(t, ok)
+		assert.NotNil(t, val)
+		assert.False(t, cacher.Contains("a"))
+		assert.Nil(t, db.Has([]byte("a")))
+		assert.Equal(t, 1, sca.Len())
+	})
+	t.Run("enabled should move the value to the cacher", func(t *testing.T) {
+		t.Parallel()
+
+		sca, cacher, db := createAdapter(true)
+
+		val, ok := sca.Get([]byte("a"))
+		require.True(t, ok)
+		assert.NotNil(t, val)
+		assert.True(t, cacher.Contains("a"))
+		assert.NotNil(t, db.Has([]byte("a")))
+		assert.Equal(t, 1, sca.Len())
+
+		val, ok = sca.Get([]byte("a"))
+		require.True(t, ok)
+		assert.NotNil(t, val)
+	})
+	t.Run("enabled should persist the values evicted by the promotion", func(t *testing.T) {
+		t.Parallel()
+
+		sca, cacher, db := createAdapter(true)
+		_ = sca.Put([]byte("b"), []byte("value b"), 7)
+		_ = sca.Put([]byte("c"), []byte("value c"), 7)
+		require.Equal(t, 3, sca.Len())
+
+		_, ok := sca.Get([]byte("a"))
+		require.True(t, ok)
+		assert.True(t, cacher.Contains("a"))
+		assert.False(t, cacher.Contains("b"))
+		assert.NotNil(t, db.Has([]byte("a")))
+		assert.Nil(t, db.Has([]byte("b")))
+		assert.Equal(t, 3, sca.Len())
+	})
+	t.Run("missing value should not be promoted", func(t *testing.T) {
+		t.Parallel()
+
+		sca, cacher, _ := createAdapter(true)
+
+		_, ok := sca.Get([]byte("missing"))
+		assert.False(t, ok)
+		assert.Zero(t, cacher.Len())
+	})
+}
+
+func TestStorageCacherAdapter_Stats(t *testing.T) {
+	t.Parallel()
+
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	db := storageMock.NewMemDbMock()
+	_ = db.Put([]byte("a"), []byte("value a"))
+	failingKey := "fail"
+	persister := &storageMock.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if string(key) == failingKey {
+				return fmt.Errorf("expected error")
+			}
+			return db.Put(key, val)
+		},
+		GetCalled: db.Get,
+		HasCalled: db.Has,
+	}
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Name:              "test",
+		Cacher:            cacher,
+		DB:                persister,
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, StorageCacherAdapterStats{}, sca.Counters())
+
+	_ = sca.Put([]byte("b"), []byte("value b"), 7)
+	_, _ = sca.Get([]byte("b"))
+	_, _ = sca.Get([]byte("a"))
+	_, _ = sca.Get([]byte("missing"))
+
+	// "b" is evicted & persisted, then "fail" is evicted, but not persisted
+	_ = sca.Put([]byte(failingKey), []byte("value"), 5)
+	_ = sca.Put([]byte("c"), []byte("value c"), 7)
+
+	marshalledValue, _ := (&storageMock.MarshalizerMock{}).Marshal([]byte("value b"))
+	expectedStats := StorageCacherAdapterStats{
+		NumCacheHits:       1,
+		NumDBHits:          1,
+		NumMisses:          1,
+		NumPersistFailures: 1,
+		NumBytesPersisted:  uint64(len(marshalledValue)),
+	}
+	assert.Equal(t, expectedStats, sca.Counters())
+	assert.Nil(t, sca.Close())
+}
+
+func TestStorageCacherAdapter_SlowDbGetShouldNotBlockCacheHits(t *testing.T) {
+	t.Parallel()
+
+	chGetStarted := make(chan struct{})
+	chReleaseGet := make(chan struct{})
+	cacher, _ := capacity.NewCapacityLRU(10, 1000)
+	sca, err := NewStorageCacherAdapter(
+		cacher,
+		&storageMock.PersisterStub{
+			GetCalled: func(_ []byte) ([]byte, error) {
+				close(chGetStarted)
+				<-chReleaseGet
+				return nil, fmt.Errorf("not found")
+			},
+		},
+		trieFactory.NewTrieNodeFactory(),
+		&storageMock.MarshalizerMock{},
+	)
+	require.Nil(t, err)
+	_ = sca.Put([]byte("cached"), []byte("value"), 5)
+
+	chMissDone := make(chan struct{})
+	go func() {
+		_, ok := sca.Get([]byte("missing"))
+		assert.False(t, ok)
+		close(chMissDone)
+	}()
+	<-chGetStarted
+
+	chHitDone := make(chan struct{})
+	go func() {
+		_, ok := sca.Get([]byte("cached"))
+		assert.True(t, ok)
+		assert.True(t, sca.Has([]byte("cached")))
+		_ = sca.Put([]byte("other"), []byte("value"), 5)
+		assert.Equal(t, 2, sca.Len())
+		close(chHitDone)
+	}()
+
+	select {
+	case <-chHitDone:
+	case <-time.After(time.Second * 2):
+		assert.Fail(t, "the in-memory operations should not wait for the db")
+	}
+
+	close(chReleaseGet)
+	<-chMissDone
+}
+
+func TestStorageCacherAdapter_ValuesBeingPersistedShouldBeServedFromMemory(t *testing.T) {
+	t.Parallel()
+
+	chPutStarted := make(chan struct{})
+	chReleasePut := make(chan struct{})
+	db := &storageMock.PersisterStub{
+		PutCalled: func(_, _ []byte) error {
+			close(chPutStarted)
+			<-chReleasePut
+			return nil
+		},
+		GetCalled: func(_ []byte) ([]byte, error) {
+			assert.Fail(t, "should have not called Get")
+			return nil, nil
+		},
+	}
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	sca, err := NewStorageCacherAdapter(cacher, db, trieFactory.NewTrieNodeFactory(), &storageMock.MarshalizerMock{})
+	require.Nil(t, err)
+
+	_ = sca.Put([]byte("a"), []byte("value a"), 7)
+	chPutDone := make(chan struct{})
+	go func() {
+		_ = sca.Put([]byte("b"), []byte("value b"), 7)
+		close(chPutDone)
+	}()
+	<-chPutStarted
+
+	assert.True(t, sca.Has([]byte("a")))
+	assert.Equal(t, 2, sca.Len())
+	assert.Equal(t, 2, len(sca.Keys()))
+	val, ok := sca.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.NotNil(t, val)
+
+	close(chReleasePut)
+	<-chPutDone
+	assert.Equal(t, 2, sca.Len())
+	assert.Equal(t, 1, sca.numValuesInStorage)
+	assert.Zero(t, len(sca.pending))
+}
+
+type serializedStoredData struct {
+	serialized []byte
+}
+
+func (data *serializedStoredData) GetSerialized() []byte {
+	return data.serialized
+}
+
+func (data *serializedStoredData) SetSerialized(serialized []byte) {
+	data.serialized = serialized
+}
+
+type serializedStoredDataFactory struct {
+}
+
+func (factory *serializedStoredDataFactory) CreateEmpty() interface{} {
+	return &serializedStoredData{}
+}
+
+func (factory *serializedStoredDataFactory) IsInterfaceNil() bool {
+	return factory == nil
+}
+
+func TestStorageCacherAdapter_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	cacher, _ := capacity.NewCapacityLRU(5, 1000)
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher: cacher,
+		DB:     storageMock.NewMemDbMock(),
+		// the promoted values should keep their serialized form, so that they are persisted once evicted again
+		StoredDataFactory: &serializedStoredDataFactory{},
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		PromoteOnRead:     true,
+	})
+	require.Nil(t, err)
+
+	numWorkers := 10
+	numKeysPerWorker := 50
+	wg := sync.WaitGroup{}
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			for j := 0; j < numKeysPerWorker; j++ {
+				key := []byte(fmt.Sprintf("key_%d_%d", idx, j))
+				_ = sca.Put(key, []byte("value"), 5)
+				_, ok := sca.Get(key)
+				assert.True(t, ok)
+				assert.True(t, sca.Has(key))
+				if j%2 == 0 {
+					sca.Remove(key)
+					assert.False(t, sca.Has(key))
+				}
+				_ = sca.Keys()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, numWorkers*numKeysPerWorker/2, sca.Len())
+	assert.Equal(t, numWorkers*numKeysPerWorker/2, len(sca.Keys()))
+	assert.Nil(t, sca.Close())
+}
+
+// createAdapterWithBlockedPut returns an adapter with a value being persisted, until the returned channel is closed
+func createAdapterWithBlockedPut(t *testing.T, closeTimeout time.Duration) (*storageCacherAdapter, *storageMock.PersisterStub, chan struct{}) {
+	chPutStarted := make(chan struct{})
+	chReleasePut := make(chan struct{})
+	db := &storageMock.PersisterStub{
+		PutCalled: func(_, _ []byte) error {
+			close(chPutStarted)
+			<-chReleasePut
+			return nil
+		},
+	}
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		CloseTimeout:      closeTimeout,
+	})
+	require.Nil(t, err)
+
+	_ = sca.Put([]byte("a"), []byte("value a"), 7)
+	go func() {
+		_ = sca.Put([]byte("b"), []byte("value b"), 7)
+	}()
+	<-chPutStarted
+
+	return sca, db, chReleasePut
+}
+
+func TestNewStorageCacherAdapterWithArgs_NegativeCloseTimeoutShouldErr(t *testing.T) {
+	t.Parallel()
+
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            &storageMock.AdaptedSizedLruCacheStub{},
+		DB:                &storageMock.PersisterStub{},
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		CloseTimeout:      -time.Second,
+	})
+	assert.Nil(t, sca)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestStorageCacherAdapter_CloseShouldFlushTheValuesBeingPersisted(t *testing.T) {
+	t.Parallel()
+
+	sca, db, chReleasePut := createAdapterWithBlockedPut(t, 0)
+	chDbClosed := make(chan struct{})
+	db.CloseCalled = func() error {
+		close(chDbClosed)
+		return nil
+	}
+
+	chCloseDone := make(chan error)
+	go func() {
+		chCloseDone <- sca.Close()
+	}()
+
+	select {
+	case <-chDbClosed:
+		assert.Fail(t, "the db should not be closed while a value is being persisted")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	close(chReleasePut)
+	select {
+	case err := <-chCloseDone:
+		assert.Nil(t, err)
+	case <-time.After(time.Second * 2):
+		assert.Fail(t, "close should have finished")
+	}
+	assert.NotZero(t, sca.Counters().NumBytesPersisted)
+	assert.Zero(t, sca.Counters().NumPersistFailures)
+	assert.Nil(t, sca.Close())
+}
+
+func TestStorageCacherAdapter_CloseTimeoutShouldForceClose(t *testing.T) {
+	t.Parallel()
+
+	sca, db, chReleasePut := createAdapterWithBlockedPut(t, time.Millisecond*100)
+	defer close(chReleasePut)
+
+	closeCalled := false
+	db.CloseCalled = func() error {
+		closeCalled = true
+		return nil
+	}
+
+	err := sca.Close()
+	assert.True(t, errors.Is(err, common.ErrCloseTimeout))
+	assert.True(t, closeCalled)
+	assert.True(t, sca.dbIsClosed.IsSet())
+	assert.Equal(t, 1, sca.Len())
+}
+
+func TestStorageCacherAdapter_ForceClose(t *testing.T) {
+	t.Parallel()
+
+	sca, db, chReleasePut := createAdapterWithBlockedPut(t, 0)
+	numCloseCalls := 0
+	db.CloseCalled = func() error {
+		numCloseCalls++
+		return nil
+	}
+
+	assert.Nil(t, sca.ForceClose())
+	assert.Equal(t, 1, sca.Len())
+	assert.Nil(t, sca.ForceClose())
+
+	close(chReleasePut)
+	assert.Nil(t, sca.Close())
+	assert.Equal(t, 1, numCloseCalls)
+	assert.Equal(t, 1, sca.Len())
+}
+
+func TestStorageCacherAdapter_Health(t *testing.T) {
+	t.Parallel()
+
+	cacher, _ := capacity.NewCapacityLRU(10, 1000)
+	db, err := leveldb.NewDB(t.TempDir(), 100, 100, 10)
+	require.Nil(t, err)
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Name:              "test",
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+	})
+	require.Nil(t, err)
+
+	status := sca.Health()
+	assert.True(t, status.IsOK())
+	assert.True(t, status.Components["db"].IsOK())
+
+	assert.Nil(t, sca.Close())
+	status = sca.Health()
+	assert.Equal(t, types.HealthFailed, status.State)
+	assert.Equal(t, []string{"db is closed"}, status.Reasons)
+}
+
+func TestStorageCacherAdapter_WithCodecRegistry(t *testing.T) {
+	t.Parallel()
+
+	oldFactory := trieFactory.NewCountingStoredDataFactory(trieFactory.NewAccountDataFactory())
+	registry, err := types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs: map[byte]types.StoredDataCodec{
+			1: {Marshalizer: &storageMock.MarshalizerMock{}, StoredDataFactory: oldFactory},
+			2: {Marshalizer: &storageMock.MarshalizerMock{}, StoredDataFactory: trieFactory.NewAccountDataFactory()},
+		},
+		CurrentVersion: 2,
+	})
+	require.Nil(t, err)
+
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	db := storageMock.NewMemDbMock()
+	_ = db.Put([]byte("old"), append([]byte{1}, []byte(`{"Nonce":1}`)...))
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: trieFactory.NewSerializedDataFactory(),
+		Marshalizer:       &storageMock.MarshalizerMock{},
+		CodecRegistry:     registry,
+	})
+	require.Nil(t, err)
+
+	val, ok := sca.Get([]byte("old"))
+	require.True(t, ok)
+	assert.Equal(t, &trieFactory.AccountData{Nonce: 1}, val)
+	assert.Equal(t, 1, oldFactory.NumCreated())
+
+	// the evicted values are persisted with the current version
+	_ = sca.Put([]byte("a"), &trieFactory.AccountData{Nonce: 2}, 10)
+	_ = sca.Put([]byte("b"), &trieFactory.AccountData{Nonce: 3}, 10)
+	persisted, err := db.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.True(t, registry.IsCurrent(persisted))
+
+	val, ok = sca.Get([]byte("a"))
+	require.True(t, ok)
+	assert.Equal(t, &trieFactory.AccountData{Nonce: 2}, val)
+	assert.Equal(t, 1, oldFactory.NumCreated())
+}
@@ -0,0 +1,79 @@
+package storageCacherAdapter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// StoredDataFactorySelector returns the factory able to create the object stored under the provided key, or nil if
+// the key should be handled by the other factories
+type StoredDataFactorySelector func(key []byte) types.StoredDataFactory
+
+type prefixedFactory struct {
+	prefix  []byte
+	factory types.StoredDataFactory
+}
+
+// storedDataFactoryRouter chooses the factory of the object stored under a key. The selector is asked first, then
+// the factory with the longest matching prefix is used, falling back to the default factory
+type storedDataFactoryRouter struct {
+	selector       StoredDataFactorySelector
+	prefixed       []prefixedFactory
+	defaultFactory types.StoredDataFactory
+}
+
+func newStoredDataFactoryRouter(
+	defaultFactory types.StoredDataFactory,
+	factoriesByPrefix map[string]types.StoredDataFactory,
+	selector StoredDataFactorySelector,
+) (*storedDataFactoryRouter, error) {
+	if check.IfNil(defaultFactory) {
+		return nil, common.ErrNilStoredDataFactory
+	}
+
+	prefixed := make([]prefixedFactory, 0, len(factoriesByPrefix))
+	for prefix, factory := range factoriesByPrefix {
+		if len(prefix) == 0 {
+			return nil, fmt.Errorf("%w: empty stored data factory prefix", common.ErrInvalidConfig)
+		}
+		if check.IfNil(factory) {
+			return nil, fmt.Errorf("%w for prefix %s", common.ErrNilStoredDataFactory, prefix)
+		}
+
+		prefixed = append(prefixed, prefixedFactory{
+			prefix:  []byte(prefix),
+			factory: factory,
+		})
+	}
+	sort.Slice(prefixed, func(i, j int) bool {
+		return len(prefixed[i].prefix) > len(prefixed[j].prefix)
+	})
+
+	return &storedDataFactoryRouter{
+		selector:       selector,
+		prefixed:       prefixed,
+		defaultFactory: defaultFactory,
+	}, nil
+}
+
+func (router *storedDataFactoryRouter) factoryFor(key []byte) types.StoredDataFactory {
+	if router.selector != nil {
+		factory := router.selector(key)
+		if !check.IfNil(factory) {
+			return factory
+		}
+	}
+
+	for _, pf := range router.prefixed {
+		if bytes.HasPrefix(key, pf.prefix) {
+			return pf.factory
+		}
+	}
+
+	return router.defaultFactory
+}
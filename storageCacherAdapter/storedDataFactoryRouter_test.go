@@ -0,0 +1,180 @@
+package storageCacherAdapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache/capacity"
+	storageMock "github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountData struct {
+	Nonce uint64
+}
+
+type accountDataFactory struct {
+}
+
+func (factory *accountDataFactory) CreateEmpty() interface{} {
+	return &accountData{}
+}
+
+func (factory *accountDataFactory) IsInterfaceNil() bool {
+	return factory == nil
+}
+
+func TestNewStoredDataFactoryRouter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil default factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		router, err := newStoredDataFactoryRouter(nil, nil, nil)
+		assert.Nil(t, router)
+		assert.Equal(t, common.ErrNilStoredDataFactory, err)
+	})
+	t.Run("nil prefixed factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		router, err := newStoredDataFactoryRouter(
+			trieFactory.NewTrieNodeFactory(),
+			map[string]types.StoredDataFactory{"acc": nil},
+			nil,
+		)
+		assert.Nil(t, router)
+		assert.True(t, errors.Is(err, common.ErrNilStoredDataFactory))
+		assert.Contains(t, err.Error(), "acc")
+	})
+	t.Run("empty prefix should error", func(t *testing.T) {
+		t.Parallel()
+
+		router, err := newStoredDataFactoryRouter(
+			trieFactory.NewTrieNodeFactory(),
+			map[string]types.StoredDataFactory{"": &accountDataFactory{}},
+			nil,
+		)
+		assert.Nil(t, router)
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+}
+
+func TestStoredDataFactoryRouter_FactoryFor(t *testing.T) {
+	t.Parallel()
+
+	defaultFactory := trieFactory.NewTrieNodeFactory()
+	shortPrefixFactory := &accountDataFactory{}
+	longPrefixFactory := &accountDataFactory{}
+	selectedFactory := &accountDataFactory{}
+	router, err := newStoredDataFactoryRouter(
+		defaultFactory,
+		map[string]types.StoredDataFactory{
+			"acc":      shortPrefixFactory,
+			"acc_user": longPrefixFactory,
+		},
+		func(key []byte) types.StoredDataFactory {
+			if string(key) == "acc_selected" {
+				return selectedFactory
+			}
+			return nil
+		},
+	)
+	require.Nil(t, err)
+
+	assert.True(t, router.factoryFor([]byte("node")) == defaultFactory)
+	assert.True(t, router.factoryFor([]byte("acc_system")) == shortPrefixFactory)
+	assert.True(t, router.factoryFor([]byte("acc_user_1")) == longPrefixFactory)
+	assert.True(t, router.factoryFor([]byte("acc_selected")) == selectedFactory)
+}
+
+func TestStorageCacherAdapter_GetShouldCreateTheObjectsByKeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	marshalizer := &storageMock.MarshalizerMock{}
+	db := storageMock.NewMemDbMock()
+	accountBytes, _ := marshalizer.Marshal(&accountData{Nonce: 7})
+	_ = db.Put([]byte("acc_1"), accountBytes)
+	_ = db.Put([]byte("node_1"), []byte("node"))
+
+	cacher, _ := capacity.NewCapacityLRU(10, 1000)
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: trieFactory.NewTrieNodeFactory(),
+		Marshalizer:       marshalizer,
+		StoredDataFactoriesByPrefix: map[string]types.StoredDataFactory{
+			"acc": &accountDataFactory{},
+		},
+	})
+	require.Nil(t, err)
+
+	val, ok := sca.Get([]byte("acc_1"))
+	require.True(t, ok)
+	assert.Equal(t, &accountData{Nonce: 7}, val)
+
+	val, ok = sca.Get([]byte("node_1"))
+	require.True(t, ok)
+	_, isTrieNode := val.(*trieFactory.SerializedStoredDataStub)
+	assert.True(t, isTrieNode)
+}
+
+func TestStorageCacherAdapter_EvictedObjectsShouldBeRestoredByTheirFactories(t *testing.T) {
+	t.Parallel()
+
+	marshalizer := &storageMock.MarshalizerMock{}
+	db := storageMock.NewMemDbMock()
+	legacyBytes, _ := marshalizer.Marshal(map[string][]byte{"Payload": []byte("legacy")})
+	_ = db.Put([]byte("ver_legacy"), legacyBytes)
+
+	serializedFactory := trieFactory.NewCountingStoredDataFactory(trieFactory.NewSerializedDataFactory())
+	accountFactory := trieFactory.NewCountingStoredDataFactory(trieFactory.NewAccountDataFactory())
+	versionedFactory := trieFactory.NewCountingStoredDataFactory(trieFactory.NewVersionedPayloadFactory(2))
+	cacher, _ := capacity.NewCapacityLRU(1, 1000)
+	sca, err := NewStorageCacherAdapterWithArgs(ArgsStorageCacherAdapter{
+		Cacher:            cacher,
+		DB:                db,
+		StoredDataFactory: serializedFactory,
+		Marshalizer:       marshalizer,
+		StoredDataFactoriesByPrefix: map[string]types.StoredDataFactory{
+			"acc": accountFactory,
+			"ver": versionedFactory,
+		},
+	})
+	require.Nil(t, err)
+
+	// each put evicts the previous object to the db
+	_ = sca.Put([]byte("node_1"), &trieFactory.SerializedStoredData{Serialized: []byte("node bytes")}, 10)
+	_ = sca.Put([]byte("acc_1"), &trieFactory.AccountData{Nonce: 3, Balance: 100}, 10)
+	_ = sca.Put([]byte("ver_1"), &trieFactory.VersionedPayload{Version: 2, Payload: []byte("current")}, 10)
+	_ = sca.Put([]byte("other"), &trieFactory.SerializedStoredData{Serialized: []byte("other bytes")}, 10)
+
+	val, ok := sca.Get([]byte("node_1"))
+	require.True(t, ok)
+	assert.Equal(t, &trieFactory.SerializedStoredData{Serialized: []byte("node bytes")}, val)
+
+	val, ok = sca.Get([]byte("acc_1"))
+	require.True(t, ok)
+	assert.Equal(t, &trieFactory.AccountData{Nonce: 3, Balance: 100}, val)
+
+	val, ok = sca.Get([]byte("ver_1"))
+	require.True(t, ok)
+	current := val.(*trieFactory.VersionedPayload)
+	assert.Equal(t, []byte("current"), current.Payload)
+	assert.False(t, current.IsOutdated())
+
+	val, ok = sca.Get([]byte("ver_legacy"))
+	require.True(t, ok)
+	legacy := val.(*trieFactory.VersionedPayload)
+	assert.Equal(t, []byte("legacy"), legacy.Payload)
+	require.True(t, legacy.IsOutdated())
+	legacy.Migrate()
+	assert.Equal(t, uint32(2), legacy.Version)
+
+	assert.Equal(t, 1, serializedFactory.NumCreated())
+	assert.Equal(t, 1, accountFactory.NumCreated())
+	assert.Equal(t, 2, versionedFactory.NumCreated())
+}
@@ -0,0 +1,87 @@
+package storageUnit
+
+import (
+	"math/rand"
+)
+
+// HealPolicy selects how the divergences between the cache and the persister are repaired
+type HealPolicy uint8
+
+const (
+	// RepersistDivergent writes the cached values missing from the persister back to it
+	RepersistDivergent HealPolicy = iota
+	// DropDivergent removes the values missing from the persister from the cache
+	DropDivergent
+)
+
+// ConsistencyReport holds the outcome of a consistency check
+type ConsistencyReport struct {
+	NumChecked  int
+	NumDiverged int
+	NumHealed   int
+}
+
+// CheckAndHeal verifies that the sampled cached keys are present in the persister as well, as the writes go through
+// both, repairing the divergences as selected by the policy. A non positive sample size checks all the cached keys
+func (u *Unit) CheckAndHeal(sampleSize int, policy HealPolicy) ConsistencyReport {
+	keys := u.cacher.Keys()
+	if sampleSize > 0 && sampleSize < len(keys) {
+		rand.Shuffle(len(keys), func(i, j int) {
+			keys[i], keys[j] = keys[j], keys[i]
+		})
+		keys = keys[:sampleSize]
+	}
+
+	report := ConsistencyReport{}
+	for _, key := range keys {
+		diverged, healed := u.checkAndHealKey(key, policy)
+		report.NumChecked++
+		if diverged {
+			report.NumDiverged++
+		}
+		if healed {
+			report.NumHealed++
+		}
+	}
+
+	if report.NumDiverged > 0 {
+		log.Warn("storage unit: cache diverged from persister",
+			"num checked", report.NumChecked, "num diverged", report.NumDiverged, "num healed", report.NumHealed)
+	}
+
+	return report
+}
+
+func (u *Unit) checkAndHealKey(key []byte, policy HealPolicy) (bool, bool) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
+
+	// the dirty values of the write back policy are not persisted yet
+	_, isDirty := u.dirtyValue(key)
+	if isDirty {
+		return false, false
+	}
+
+	// the raw cacher is peeked, so that the values which are not byte slices are dropped as well
+	cached, ok := u.cacher.Unwrap().Peek(key)
+	if !ok || u.persister.Has(key) == nil {
+		return false, false
+	}
+	log.Debug("storage unit: cached key missing from persister", "key", key)
+
+	val, isBytes := cached.([]byte)
+	if policy == DropDivergent || !isBytes {
+		u.cacher.Remove(key)
+		return true, true
+	}
+
+	err := u.persister.Put(key, val)
+	if err != nil {
+		log.Warn("storage unit: could not re-persist cached value", "key", key, "error", err)
+		return true, false
+	}
+
+	return true, true
+}
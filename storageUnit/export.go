@@ -0,0 +1,301 @@
+package storageUnit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/golang/snappy"
+)
+
+// The archive starts with exportMagic and a flags byte telling whether the rest is compressed with the snappy framing
+// format. Then, each record is made of the recordEntry byte, the big endian uint32 lengths of the key and of the value,
+// the key, the value and the CRC-32C of the key and value. The archive ends with the recordEnd byte, the big endian
+// uint64 number of records and the CRC-32C of everything written since the flags byte
+const (
+	exportMagic       = "SUNIT\x01"
+	flagCompressed    = byte(1)
+	recordEntry       = byte(1)
+	recordEnd         = byte(0)
+	importBatchSize   = 1024
+	maxExportedLength = 1 << 30
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ExportOption customizes the archive written by Export
+type ExportOption func(options *exportOptions)
+
+type exportOptions struct {
+	compressed bool
+}
+
+// WithCompression compresses the exported archive with snappy
+func WithCompression() ExportOption {
+	return func(options *exportOptions) {
+		options.compressed = true
+	}
+}
+
+// Export streams all the persisted (key, value) pairs to the writer, as a checksummed archive to be read by Import,
+// returning the number of exported pairs. The pending writes of the persister are flushed first, the writes being
+// blocked meanwhile
+func (u *Unit) Export(w io.Writer, opts ...ExportOption) (int, error) {
+	options := &exportOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	flusher, ok := u.persister.(types.Flusher)
+	if ok {
+		err := flusher.Flush()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	flags := byte(0)
+	if options.compressed {
+		flags |= flagCompressed
+	}
+	_, err := w.Write(append([]byte(exportMagic), flags))
+	if err != nil {
+		return 0, err
+	}
+
+	var body io.Writer
+	var flushBody func() error
+	if options.compressed {
+		snappyWriter := snappy.NewBufferedWriter(w)
+		body, flushBody = snappyWriter, snappyWriter.Close
+	} else {
+		bufferedWriter := bufio.NewWriter(w)
+		body, flushBody = bufferedWriter, bufferedWriter.Flush
+	}
+
+	aw := &archiveWriter{w: body, crc: crc32.New(crcTable)}
+	numRecords := uint64(0)
+	u.persister.RangeKeys(func(key []byte, val []byte) bool {
+		aw.writeRecord(key, val)
+		numRecords++
+		return aw.err == nil
+	})
+	aw.writeEnd(numRecords)
+	if aw.err != nil {
+		return 0, aw.err
+	}
+
+	err = flushBody()
+	if err != nil {
+		return 0, err
+	}
+	log.Debug("storage unit exported", "num records", numRecords, "compressed", options.compressed)
+
+	return int(numRecords), nil
+}
+
+// Import reads an archive written by Export, persisting its (key, value) pairs, which replace the existing values of
+// the same keys, and returns the number of imported pairs. The pairs are persisted in batches, each record being checked
+// against its checksum first, so that a failed import leaves behind only some of the valid records read before the
+// failure. The writes are blocked meanwhile
+func (u *Unit) Import(r io.Reader) (int, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	header := make([]byte, len(exportMagic)+1)
+	_, err := io.ReadFull(r, header)
+	if err != nil || string(header[:len(exportMagic)]) != exportMagic {
+		return 0, fmt.Errorf("%w: missing the storage unit archive header", common.ErrInvalidDumpFormat)
+	}
+
+	var body io.Reader = bufio.NewReader(r)
+	if header[len(exportMagic)]&flagCompressed != 0 {
+		body = snappy.NewReader(r)
+	}
+
+	ar := &archiveReader{r: body, crc: crc32.New(crcTable)}
+	numImported := 0
+	numRead := uint64(0)
+	batch := make(map[string][]byte, importBatchSize)
+	for {
+		recordType, errRead := ar.readByte()
+		if errRead != nil {
+			return numImported, errRead
+		}
+		if recordType == recordEnd {
+			break
+		}
+		if recordType != recordEntry {
+			return numImported, fmt.Errorf("%w: unknown record type %d", common.ErrInvalidDumpFormat, recordType)
+		}
+
+		key, val, errRead := ar.readRecord()
+		if errRead != nil {
+			return numImported, errRead
+		}
+		numRead++
+		batch[string(key)] = val
+		if len(batch) < importBatchSize {
+			continue
+		}
+
+		err = u.importBatchNoLock(batch)
+		if err != nil {
+			return numImported, err
+		}
+		numImported += len(batch)
+		batch = make(map[string][]byte, importBatchSize)
+	}
+
+	// the last batch is persisted once the whole archive is verified, so that the small archives are imported entirely
+	// or not at all
+	err = ar.readEnd(numRead)
+	if err != nil {
+		return numImported, err
+	}
+	err = u.importBatchNoLock(batch)
+	if err != nil {
+		return numImported, err
+	}
+	numImported += len(batch)
+	log.Debug("storage unit imported", "num records", numImported)
+
+	return numImported, nil
+}
+
+// importBatchNoLock persists the batch, dropping its keys from the cache so that the imported values are read next
+func (u *Unit) importBatchNoLock(batch map[string][]byte) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	for key := range batch {
+		u.cacher.Remove([]byte(key))
+		u.unstage([]byte(key))
+	}
+
+	return u.persistBatchNoLock(batch)
+}
+
+// archiveWriter writes the body of an archive, computing its checksum. The first error stops the writing
+type archiveWriter struct {
+	w   io.Writer
+	crc hash.Hash32
+	err error
+}
+
+func (aw *archiveWriter) write(buff []byte) {
+	if aw.err != nil {
+		return
+	}
+
+	_, aw.err = aw.w.Write(buff)
+	_, _ = aw.crc.Write(buff)
+}
+
+func (aw *archiveWriter) writeRecord(key []byte, val []byte) {
+	if len(key) > maxExportedLength || len(val) > maxExportedLength {
+		aw.err = fmt.Errorf("%w: key %x or its value is too large to be exported", common.ErrInvalidValueLength, key)
+		return
+	}
+
+	buff := make([]byte, 1+8, 1+8+len(key)+len(val)+4)
+	buff[0] = recordEntry
+	binary.BigEndian.PutUint32(buff[1:], uint32(len(key)))
+	binary.BigEndian.PutUint32(buff[5:], uint32(len(val)))
+	buff = append(append(buff, key...), val...)
+	buff = binary.BigEndian.AppendUint32(buff, crc32.Checksum(buff[9:], crcTable))
+
+	aw.write(buff)
+}
+
+func (aw *archiveWriter) writeEnd(numRecords uint64) {
+	buff := make([]byte, 1+8)
+	buff[0] = recordEnd
+	binary.BigEndian.PutUint64(buff[1:], numRecords)
+	aw.write(buff)
+
+	aw.write(binary.BigEndian.AppendUint32(nil, aw.crc.Sum32()))
+}
+
+// archiveReader reads the body of an archive, computing its checksum
+type archiveReader struct {
+	r   io.Reader
+	crc hash.Hash32
+}
+
+func (ar *archiveReader) read(length int) ([]byte, error) {
+	buff := make([]byte, length)
+	_, err := io.ReadFull(ar.r, buff)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: truncated storage unit archive", common.ErrInvalidDumpFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, _ = ar.crc.Write(buff)
+
+	return buff, nil
+}
+
+func (ar *archiveReader) readByte() (byte, error) {
+	buff, err := ar.read(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return buff[0], nil
+}
+
+func (ar *archiveReader) readRecord() ([]byte, []byte, error) {
+	lengths, err := ar.read(8)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyLength, valLength := binary.BigEndian.Uint32(lengths), binary.BigEndian.Uint32(lengths[4:])
+	if keyLength > maxExportedLength || valLength > maxExportedLength {
+		return nil, nil, fmt.Errorf("%w: record of %d + %d bytes", common.ErrInvalidDumpFormat, keyLength, valLength)
+	}
+
+	record, err := ar.read(int(keyLength) + int(valLength) + 4)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload := record[:keyLength+valLength]
+	if binary.BigEndian.Uint32(record[keyLength+valLength:]) != crc32.Checksum(payload, crcTable) {
+		return nil, nil, fmt.Errorf("%w for the record of key %x", common.ErrChecksumMismatch, payload[:keyLength])
+	}
+
+	return payload[:keyLength], payload[keyLength:], nil
+}
+
+func (ar *archiveReader) readEnd(numRead uint64) error {
+	buff, err := ar.read(8)
+	if err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint64(buff) != numRead {
+		return fmt.Errorf("%w: the archive holds %d records, %d were read",
+			common.ErrInvalidDumpFormat, binary.BigEndian.Uint64(buff), numRead)
+	}
+
+	expectedChecksum := ar.crc.Sum32()
+	buff, err = ar.read(4)
+	if err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint32(buff) != expectedChecksum {
+		return fmt.Errorf("%w for the storage unit archive", common.ErrChecksumMismatch)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package storageUnit_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnitWithPairs(numPairs int) (*storageUnit.Unit, *memorydb.DB) {
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+	for i := 0; i < numPairs; i++ {
+		_ = s.Put([]byte(fmt.Sprintf("key%d", i)), bytes.Repeat([]byte{byte(i)}, i%100))
+	}
+
+	return s, mdb
+}
+
+func TestUnit_ExportAndImport(t *testing.T) {
+	t.Parallel()
+
+	for name, opts := range map[string][]storageUnit.ExportOption{
+		"plain":      nil,
+		"compressed": {storageUnit.WithCompression()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			source, sourceDB := createUnitWithPairs(3000)
+			archive := bytes.NewBuffer(nil)
+			numExported, err := source.Export(archive, opts...)
+			require.Nil(t, err)
+			assert.Equal(t, 3000, numExported)
+
+			destination, destinationDB := createUnitWithPairs(0)
+			_ = destination.Put([]byte("key1"), []byte("stale"))
+			_ = destination.Put([]byte("other"), []byte("kept"))
+			numImported, err := destination.Import(archive)
+			require.Nil(t, err)
+			assert.Equal(t, 3000, numImported)
+
+			assert.Equal(t, 3001, destinationDB.Len())
+			assert.Equal(t, sourceDB.Snapshot()["key1"], destinationDB.Snapshot()["key1"])
+			val, _ := destination.Get([]byte("key1"))
+			assert.Equal(t, []byte{1}, val)
+			val, _ = destination.Get([]byte("other"))
+			assert.Equal(t, []byte("kept"), val)
+		})
+	}
+}
+
+func TestUnit_ImportShouldRejectTheInvalidArchives(t *testing.T) {
+	t.Parallel()
+
+	source, _ := createUnitWithPairs(10)
+	archive := bytes.NewBuffer(nil)
+	_, _ = source.Export(archive)
+	valid := archive.Bytes()
+
+	destination, destinationDB := createUnitWithPairs(0)
+	_, err := destination.Import(bytes.NewReader([]byte("not an archive")))
+	assert.True(t, errors.Is(err, common.ErrInvalidDumpFormat))
+
+	_, err = destination.Import(bytes.NewReader(valid[:len(valid)-10]))
+	assert.True(t, errors.Is(err, common.ErrInvalidDumpFormat))
+
+	corrupted := append([]byte{}, valid...)
+	corrupted[20] ^= 0xFF
+	_, err = destination.Import(bytes.NewReader(corrupted))
+	assert.True(t, errors.Is(err, common.ErrChecksumMismatch))
+	assert.Equal(t, 0, destinationDB.Len())
+
+	corrupted = append([]byte{}, valid...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	numImported, err := destination.Import(bytes.NewReader(corrupted))
+	assert.True(t, errors.Is(err, common.ErrChecksumMismatch))
+	assert.Equal(t, 0, numImported)
+	assert.Equal(t, 0, destinationDB.Len())
+}
@@ -0,0 +1,63 @@
+package storageUnit
+
+import (
+	"sort"
+	"sync"
+)
+
+// numKeyStripes is the number of locks the keys are spread over by their hash
+const numKeyStripes = 256
+
+// keyLocks orders the operations on the same key, while letting the operations on the keys hashed to different
+// stripes run concurrently
+type keyLocks struct {
+	stripes [numKeyStripes]sync.Mutex
+}
+
+// stripeOf computes the FNV-1a hash of the key, without allocating
+func stripeOf(key []byte) int {
+	hash := uint32(2166136261)
+	for _, b := range key {
+		hash ^= uint32(b)
+		hash *= 16777619
+	}
+
+	return int(hash % numKeyStripes)
+}
+
+func (kl *keyLocks) lock(key []byte) {
+	kl.stripes[stripeOf(key)].Lock()
+}
+
+func (kl *keyLocks) unlock(key []byte) {
+	kl.stripes[stripeOf(key)].Unlock()
+}
+
+// lockKeys locks the stripes of all the provided keys, always in ascending order so that two calls with overlapping
+// keys do not deadlock, returning the locked stripes
+func (kl *keyLocks) lockKeys(keys []string) []int {
+	seen := make(map[int]struct{}, len(keys))
+	stripes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		stripe := stripeOf([]byte(key))
+		_, isDuplicate := seen[stripe]
+		if isDuplicate {
+			continue
+		}
+		seen[stripe] = struct{}{}
+		stripes = append(stripes, stripe)
+	}
+	sort.Ints(stripes)
+
+	for _, stripe := range stripes {
+		kl.stripes[stripe].Lock()
+	}
+
+	return stripes
+}
+
+func (kl *keyLocks) unlockStripes(stripes []int) {
+	for _, stripe := range stripes {
+		kl.stripes[stripe].Unlock()
+	}
+}
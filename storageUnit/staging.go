@@ -0,0 +1,88 @@
+package storageUnit
+
+// Stage adds data to the cache only, to be persisted later, along with the other staged values, by CommitStaged. The
+// staged values are served by Get and Has until committed or discarded, even if evicted from the cache meanwhile. A
+// dirty value of the key is superseded
+func (u *Unit) Stage(key, data []byte) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
+
+	u.mutStaged.Lock()
+	u.staged[string(key)] = data
+	u.mutStaged.Unlock()
+	u.undirty(key)
+
+	u.cacher.Put(key, data, len(data))
+}
+
+// CommitStaged persists all the staged values in one go, returning their number. On failure, the staged values are
+// kept, to be committed again, while the ones already persisted are rolled back. No other operation is served meanwhile
+func (u *Unit) CommitStaged() (int, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.mutStaged.Lock()
+	defer u.mutStaged.Unlock()
+
+	if len(u.staged) == 0 {
+		return 0, nil
+	}
+
+	err := u.persistBatchNoLock(u.staged)
+	if err != nil {
+		return 0, err
+	}
+
+	numCommitted := len(u.staged)
+	u.staged = make(map[string][]byte)
+	log.Debug("storage unit committed the staged values", "num values", numCommitted)
+
+	return numCommitted, nil
+}
+
+// DiscardStaged drops all the staged values from both the staging area and the cache, returning their number
+func (u *Unit) DiscardStaged() int {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.discardStagedNoLock()
+}
+
+func (u *Unit) discardStagedNoLock() int {
+	u.mutStaged.Lock()
+	defer u.mutStaged.Unlock()
+
+	for key := range u.staged {
+		u.cacher.Remove([]byte(key))
+	}
+	numDiscarded := len(u.staged)
+	u.staged = make(map[string][]byte)
+
+	return numDiscarded
+}
+
+// NumStaged returns the number of staged values, not committed yet
+func (u *Unit) NumStaged() int {
+	u.mutStaged.Lock()
+	defer u.mutStaged.Unlock()
+
+	return len(u.staged)
+}
+
+func (u *Unit) stagedValue(key []byte) ([]byte, bool) {
+	u.mutStaged.Lock()
+	defer u.mutStaged.Unlock()
+
+	val, ok := u.staged[string(key)]
+
+	return val, ok
+}
+
+// unstage drops the staged value of the key, superseded by a write or a removal
+func (u *Unit) unstage(key []byte) {
+	u.mutStaged.Lock()
+	delete(u.staged, string(key))
+	u.mutStaged.Unlock()
+}
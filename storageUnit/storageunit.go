@@ -1,27 +1,58 @@
 package storageUnit
 
 import (
+	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-core/data"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var _ types.Storer = (*Unit)(nil)
+var _ types.MultiPutter = (*Unit)(nil)
+var _ types.StatsProvider = (*Unit)(nil)
+var _ types.HealthChecker = (*Unit)(nil)
 
 var log = logger.GetOrCreate("storage/storageUnit")
 
 // Unit represents a storer's data bank
-// holding the cache and persistence unit
+// holding the cache and persistence unit.
+// The operations on a single key hold the read lock of the unit along with the lock of the key's stripe, so that the
+// operations on the same key are ordered, while the ones on different keys run concurrently, even when missing the
+// cache. The operations on the whole unit hold its write lock
 type Unit struct {
 	lock      sync.RWMutex
+	keyLocks  keyLocks
 	persister types.Persister
-	cacher    types.Cacher
+	cacher    *types.TypedCacher[[]byte, []byte]
+	// name, if set, is carried by the errors of the unit, telling which unit failed
+	name string
+	// bloomFilter, if enabled, holds the persisted keys, so that the reads of the missing ones skip the persister
+	bloomFilter *bloom.Filter
+	// staged holds the values written by Stage, until committed to the persister or discarded
+	mutStaged sync.Mutex
+	staged    map[string][]byte
+	// writePolicy tells how the writes are applied on the cache and the persister, see SetWritePolicy
+	writePolicy     common.WritePolicy
+	maxNumDirty     int
+	bypassThreshold int
+	flusher         *dirtyFlusher
+	// dirty holds the values written under the write back policy, until persisted
+	mutDirty         sync.Mutex
+	dirty            map[string][]byte
+	numFlushedDirty  atomic.Uint64
+	numFailedFlushes atomic.Uint64
+	lastFlushFailed  atomic.Bool
+	numCacheBypasses atomic.Uint64
 }
 
 // NewStorageUnit is the constructor for the storage unit, creating a new storage unit
@@ -30,32 +61,189 @@ func NewStorageUnit(c types.Cacher, p types.Persister) (*Unit, error) {
 	if check.IfNil(p) {
 		return nil, common.ErrNilPersister
 	}
-	if check.IfNil(c) {
-		return nil, common.ErrNilCacher
+	cacher, err := types.NewTypedCacher[[]byte, []byte](c)
+	if err != nil {
+		return nil, err
 	}
 
 	sUnit := &Unit{
-		persister: p,
-		cacher:    c,
+		persister:   p,
+		cacher:      cacher,
+		staged:      make(map[string][]byte),
+		writePolicy: common.WriteThroughPolicy,
+		dirty:       make(map[string][]byte),
 	}
 
 	return sUnit, nil
 }
 
-// Put adds data to both cache and persistence medium
-func (u *Unit) Put(key, data []byte) error {
+// SetName sets the name carried by the errors of the unit
+func (u *Unit) SetName(name string) {
+	u.lock.Lock()
+	u.name = name
+	u.lock.Unlock()
+}
+
+// EnableBloomFilter creates a bloom filter holding the persisted keys, read through RangeKeys, and keeps it updated by
+// the writes, so that Get and Has report most of the missing keys without reaching the persister
+func (u *Unit) EnableBloomFilter(config bloom.Config) error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	u.cacher.Put(key, data, len(data))
+	filter, err := newBloomFilter(config, u.persister)
+	if err != nil {
+		return err
+	}
+	u.bloomFilter = filter
+
+	return nil
+}
+
+func newBloomFilter(config bloom.Config, persister types.Persister) (*bloom.Filter, error) {
+	filter, err := bloom.NewFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	numKeys := 0
+	persister.RangeKeys(func(key []byte, _ []byte) bool {
+		filter.Add(key)
+		numKeys++
+		return true
+	})
+	log.Debug("storage unit bloom filter built", "num keys", numKeys, "num bits", filter.NumBits())
+
+	return filter, nil
+}
+
+// mayBePersisted returns false if the key is surely not held by the persister
+func (u *Unit) mayBePersisted(key []byte) bool {
+	return u.bloomFilter == nil || u.bloomFilter.MayContain(key)
+}
+
+func (u *Unit) addToBloomFilter(key []byte) {
+	if u.bloomFilter != nil {
+		u.bloomFilter.Add(key)
+	}
+}
+
+func (u *Unit) errKeyNotPersisted(key []byte) error {
+	return common.WrapStorageError(fmt.Errorf("%w: not persisted", common.ErrKeyNotFound), key, u.name, "")
+}
+
+// Put adds data to both cache and persistence medium. The value is persisted first, then cached on success only, so
+// that either both reflect the write or neither does. Under the write back policy, the value is cached and persisted
+// later. A staged value of the key is superseded
+func (u *Unit) Put(key, data []byte) error {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
+
+	u.addToBloomFilter(key)
+	if u.isWriteBack() {
+		u.unstage(key)
+		u.markDirty(key, data)
+		u.cacher.Put(key, data, len(data))
+		return nil
+	}
 
 	err := u.persister.Put(key, data)
 	if err != nil {
-		u.cacher.Remove(key)
 		return err
 	}
 
-	return err
+	u.unstage(key)
+	u.cache(key, data)
+
+	return nil
+}
+
+// MultiPut adds all the provided values to both cache and persistence medium, as a whole: the values are persisted
+// first, in one go if the persistence medium supports it, then cached once all of them are persisted. A failed write
+// rolls back the values already persisted, leaving the cache untouched
+func (u *Unit) MultiPut(data map[string][]byte) error {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	stripes := u.keyLocks.lockKeys(keys)
+	defer u.keyLocks.unlockStripes(stripes)
+
+	if u.isWriteBack() {
+		for key, val := range data {
+			u.addToBloomFilter([]byte(key))
+			u.unstage([]byte(key))
+			u.markDirty([]byte(key), val)
+			u.cacher.Put([]byte(key), val, len(val))
+		}
+		return nil
+	}
+
+	err := u.persistBatchNoLock(data)
+	if err != nil {
+		return err
+	}
+
+	for key, val := range data {
+		u.unstage([]byte(key))
+		u.cache([]byte(key), val)
+	}
+
+	return nil
+}
+
+// persistBatchNoLock writes the values to the persistence medium, in one go if it supports it. Otherwise, they are
+// written one by one, the previous values being read first, so that a failed write restores them, or removes the keys
+// which could not be read
+func (u *Unit) persistBatchNoLock(data map[string][]byte) error {
+	for key := range data {
+		u.addToBloomFilter([]byte(key))
+	}
+
+	multiPutter, ok := u.persister.(types.MultiPutter)
+	if ok {
+		return multiPutter.MultiPut(data)
+	}
+
+	previous := make(map[string][]byte, len(data))
+	written := make([]string, 0, len(data))
+	for key, val := range data {
+		old, errGet := u.persister.Get([]byte(key))
+		if errGet == nil {
+			previous[key] = old
+		}
+
+		err := u.persister.Put([]byte(key), val)
+		if err != nil {
+			return errors.Join(err, u.rollBackNoLock(written, previous))
+		}
+		written = append(written, key)
+	}
+
+	return nil
+}
+
+func (u *Unit) rollBackNoLock(written []string, previous map[string][]byte) error {
+	errs := make([]error, 0)
+	for _, key := range written {
+		var err error
+		old, existed := previous[key]
+		if existed {
+			err = u.persister.Put([]byte(key), old)
+		} else {
+			err = u.persister.Remove([]byte(key))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w while rolling back key %s", err, base64.StdEncoding.EncodeToString([]byte(key))))
+		}
+	}
+	log.Debug("storage unit rolled back a failed batch", "num keys", len(written), "num errors", len(errs))
+
+	return errors.Join(errs...)
 }
 
 // PutInEpoch will call the Put method as this storer doesn't handle epochs
@@ -68,9 +256,21 @@ func (u *Unit) GetOldestEpoch() (uint32, error) {
 	return 0, common.ErrOldestEpochNotAvailable
 }
 
-// Close will close unit
+// Close will close unit, persisting the dirty values of the write back policy first
 func (u *Unit) Close() error {
+	u.lock.Lock()
+	u.stopFlusherNoLock()
+	_, errFlush := u.flushDirtyNoLock()
+	u.lock.Unlock()
+	if errFlush != nil {
+		log.Error("storage unit closed with dirty values not persisted", "num lost", u.NumDirty(), "error", errFlush)
+	}
+
 	u.cacher.Clear()
+	numDiscarded := u.discardStagedNoLock()
+	if numDiscarded > 0 {
+		log.Warn("storage unit closed with uncommitted staged values", "num discarded", numDiscarded)
+	}
 
 	err := u.persister.Close()
 	if err != nil {
@@ -78,7 +278,7 @@ func (u *Unit) Close() error {
 		return err
 	}
 
-	return nil
+	return errFlush
 }
 
 // RangeKeys can iterate over the persisted (key, value) pairs calling the provided handler
@@ -86,35 +286,130 @@ func (u *Unit) RangeKeys(handler func(key []byte, value []byte) bool) {
 	u.persister.RangeKeys(handler)
 }
 
-// Get searches the key in the cache. In case it is not found,
-// it further searches it in the associated database.
-// In case it is found in the database, the cache is updated with the value as well.
-func (u *Unit) Get(key []byte) ([]byte, error) {
-	u.lock.Lock()
-	defer u.lock.Unlock()
+// GetRange returns, in ascending order of the keys, at most limit persisted (key, value) pairs whose keys are in
+// [startKey, endKey), a nil endKey meaning no upper bound and a zero limit meaning no limit. The dirty values and the
+// pending writes of the persister are flushed first, so that they are included. The persisters unable to iterate over a range of keys are
+// fully iterated, their pairs being sorted afterwards
+func (u *Unit) GetRange(startKey []byte, endKey []byte, limit int) ([]data.KeyValuePair, error) {
+	if endKey != nil && bytes.Compare(endKey, startKey) < 0 {
+		return nil, fmt.Errorf("%w: end key %x is before start key %x", common.ErrInvalidKeyRange, endKey, startKey)
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: limit should not be negative", common.ErrInvalidConfig)
+	}
 
-	v, ok := u.cacher.Get(key)
-	var err error
+	_, err := u.FlushDirty()
+	if err != nil {
+		return nil, err
+	}
 
-	if !ok {
-		// not found in cache
-		// search it in second persistence medium
+	u.lock.RLock()
+	defer u.lock.RUnlock()
 
-		v, err = u.persister.Get(key)
+	flusher, ok := u.persister.(types.Flusher)
+	if ok {
+		err := flusher.Flush()
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	rangeIterator, ok := u.persister.(types.RangeIterator)
+	if ok {
+		pairs := make([]data.KeyValuePair, 0)
+		rangeIterator.RangeKeysBetween(startKey, endKey, func(key []byte, val []byte) bool {
+			pairs = append(pairs, data.KeyValuePair{Key: key, Value: val})
+			return limit == 0 || len(pairs) < limit
+		})
+
+		return pairs, nil
+	}
 
-		buff, okAssertion := v.([]byte)
-		if !okAssertion {
-			return nil, fmt.Errorf("key: %s is not a byte slice", base64.StdEncoding.EncodeToString(key))
+	pairs := make([]data.KeyValuePair, 0)
+	u.persister.RangeKeys(func(key []byte, val []byte) bool {
+		if bytes.Compare(key, startKey) >= 0 && (endKey == nil || bytes.Compare(key, endKey) < 0) {
+			pairs = append(pairs, data.KeyValuePair{Key: key, Value: val})
 		}
+		return true
+	})
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].Key, pairs[j].Key) < 0
+	})
+	if limit > 0 && len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+
+	return pairs, nil
+}
 
-		// if found in persistence unit, add it in cache
-		u.cacher.Put(key, v, len(buff))
+// WarmUp pre-loads at most maxNumKeys persisted (key, value) pairs into the cache, so that the first reads after a
+// restart do not all hit the persistence medium. The persisters do not track the write order, hence the pairs are
+// loaded in the persister's iteration order. Keys already present in the cache, or holding dirty values, are left
+// untouched, as are the values bypassing the cache.
+// It returns the number of loaded pairs
+func (u *Unit) WarmUp(maxNumKeys int) int {
+	if maxNumKeys <= 0 {
+		return 0
 	}
 
-	return v.([]byte), nil
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	numLoaded := 0
+	u.persister.RangeKeys(func(key []byte, value []byte) bool {
+		_, isDirty := u.dirtyValue(key)
+		if isDirty || (u.writePolicy == common.CacheBypassPolicy && len(value) >= u.bypassThreshold) {
+			return true
+		}
+
+		_, added := u.cacher.HasOrAdd(key, value, len(value))
+		if added {
+			numLoaded++
+		}
+
+		return numLoaded < maxNumKeys
+	})
+
+	log.Debug("storage unit warm-up", "loaded", numLoaded, "max", maxNumKeys)
+
+	return numLoaded
+}
+
+// Get searches the key in the cache. In case it is not found,
+// it further searches it in the associated database.
+// In case it is found in the database, the cache is updated with the value as well.
+func (u *Unit) Get(key []byte) ([]byte, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
+
+	v, ok := u.cacher.Get(key)
+	if !ok {
+		v, ok = u.stagedValue(key)
+	}
+	if !ok {
+		v, ok = u.dirtyValue(key)
+	}
+	if ok {
+		return v, nil
+	}
+
+	// not found in cache
+	// search it in second persistence medium
+	if !u.mayBePersisted(key) {
+		return nil, u.errKeyNotPersisted(key)
+	}
+
+	v, err := u.persister.Get(key)
+	if err != nil {
+		return nil, common.WrapStorageError(err, key, u.name, "")
+	}
+
+	// if found in persistence unit, add it in cache
+	u.cache(key, v)
+
+	return v, nil
 }
 
 // GetFromEpoch will call the Get method as this storer doesn't handle epochs
@@ -145,13 +440,26 @@ func (u *Unit) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]data.KeyValuePair, e
 func (u *Unit) Has(key []byte) error {
 	u.lock.RLock()
 	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
 
 	has := u.cacher.Has(key)
 	if has {
 		return nil
 	}
+	_, isStaged := u.stagedValue(key)
+	if isStaged {
+		return nil
+	}
+	_, isDirty := u.dirtyValue(key)
+	if isDirty {
+		return nil
+	}
+	if !u.mayBePersisted(key) {
+		return u.errKeyNotPersisted(key)
+	}
 
-	return u.persister.Has(key)
+	return common.WrapStorageError(u.persister.Has(key), key, u.name, "")
 }
 
 // SearchFirst will call the Get method as this storer doesn't handle epochs
@@ -166,29 +474,88 @@ func (u *Unit) RemoveFromCurrentEpoch(key []byte) error {
 
 // Remove removes the data associated to the given key from both cache and persistence medium
 func (u *Unit) Remove(key []byte) error {
-	u.lock.Lock()
-	defer u.lock.Unlock()
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	u.keyLocks.lock(key)
+	defer u.keyLocks.unlock(key)
 
 	u.cacher.Remove(key)
+	u.unstage(key)
+	u.undirty(key)
 	err := u.persister.Remove(key)
 
 	return err
 }
 
+// RemoveFromCache removes the data associated to the given key from the cache only, leaving it in the persistence
+// medium, to be reloaded by the next Get
+func (u *Unit) RemoveFromCache(key []byte) {
+	u.lock.RLock()
+	u.keyLocks.lock(key)
+	u.cacher.Remove(key)
+	u.keyLocks.unlock(key)
+	u.lock.RUnlock()
+}
+
 // ClearCache cleans up the entire cache
 func (u *Unit) ClearCache() {
 	u.cacher.Clear()
 }
 
-// DestroyUnit cleans up the cache, and the db
+// DestroyUnit cleans up the cache, and the db, dropping the dirty values
 func (u *Unit) DestroyUnit() error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
+	u.stopFlusherNoLock()
 	u.cacher.Clear()
+	u.discardStagedNoLock()
+	u.mutDirty.Lock()
+	u.dirty = make(map[string][]byte)
+	u.mutDirty.Unlock()
+	if u.bloomFilter != nil {
+		u.bloomFilter.Reset()
+	}
+
 	return u.persister.Destroy()
 }
 
+// Stats returns the stats of the write policy, of the cacher and of the persister of the unit, if they expose their
+// stats
+func (u *Unit) Stats() map[string]interface{} {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return map[string]interface{}{
+		types.StatType:     "StorageUnit",
+		"name":             u.name,
+		"numStaged":        u.NumStaged(),
+		"writePolicy":      string(u.writePolicy),
+		"numDirty":         u.NumDirty(),
+		"numFlushedDirty":  u.numFlushedDirty.Load(),
+		"numFailedFlushes": u.numFailedFlushes.Load(),
+		"numCacheBypasses": u.numCacheBypasses.Load(),
+		"cache":            types.StatsOf(u.cacher.Unwrap()),
+		"persister":        types.StatsOf(u.persister),
+	}
+}
+
+// Health returns the health of the cacher and of the persister of the unit, if they report their health
+func (u *Unit) Health() types.HealthStatus {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	status := types.NewHealthStatus()
+	status.AddComponentHealth("cache", u.cacher.Unwrap())
+	status.AddComponentHealth("persister", u.persister)
+	numDirty := u.NumDirty()
+	if u.lastFlushFailed.Load() && numDirty > 0 {
+		status.Degrade("%d dirty values not persisted after a failed flush", numDirty)
+	}
+
+	return status
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (u *Unit) IsInterfaceNil() bool {
 	return u == nil
@@ -1,13 +1,22 @@
 package storageUnit_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-core/data"
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/leveldb"
 	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
 	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
 	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func initStorageUnit(tb testing.TB, cSize int) *storageUnit.Unit {
@@ -152,7 +161,7 @@ func TestHasNotPresent(t *testing.T) {
 	err := s.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
 }
 
 func TestHasNotPresentCache(t *testing.T) {
@@ -261,3 +270,442 @@ func TestDestroyUnitNoError(t *testing.T) {
 	err := s.DestroyUnit()
 	assert.Nil(t, err, "no error expected, but got %s", err)
 }
+
+func TestWarmUpShouldLoadPersistedPairsInCache(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	for i := 0; i < 10; i++ {
+		_ = mdb.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	cache, _ := lrucache.NewCache(100)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+
+	assert.Equal(t, 0, s.WarmUp(0))
+	assert.Equal(t, 0, cache.Len())
+
+	numLoaded := s.WarmUp(4)
+	assert.Equal(t, 4, numLoaded)
+	assert.Equal(t, 4, cache.Len())
+	for _, key := range cache.Keys() {
+		cached, _ := cache.Peek(key)
+		persisted, _ := mdb.Get(key)
+		assert.Equal(t, persisted, cached)
+	}
+
+	numLoaded = s.WarmUp(100)
+	assert.Equal(t, 6, numLoaded)
+	assert.Equal(t, 10, cache.Len())
+}
+
+func TestWarmUpShouldNotOverwriteCachedValues(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	mdb := memorydb.New()
+	_ = mdb.Put(key, []byte("persisted"))
+	cache, _ := lrucache.NewCache(10)
+	cache.Put(key, []byte("cached"), 6)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+
+	assert.Equal(t, 0, s.WarmUp(10))
+	cached, _ := cache.Get(key)
+	assert.Equal(t, []byte("cached"), cached)
+}
+
+func TestMultiPutShouldWriteThrough(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+
+	err := s.MultiPut(map[string][]byte{
+		"key1": []byte("value1"),
+		"key2": []byte("value2"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, 2, mdb.Len())
+	val, _ := s.Get([]byte("key2"))
+	assert.Equal(t, []byte("value2"), val)
+}
+
+func TestPutShouldNotCacheTheValuesFailedToPersist(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	persister := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return expectedErr
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	err := s.Put([]byte("key"), []byte("value"))
+	assert.Equal(t, expectedErr, err)
+	assert.False(t, cache.Has([]byte("key")))
+}
+
+func TestMultiPutShouldRollBackThePersistedValuesOnFailure(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("existing"), []byte("old value"))
+	persister := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if string(key) == "failing" {
+				return expectedErr
+			}
+			return mdb.Put(key, val)
+		},
+		GetCalled:    mdb.Get,
+		RemoveCalled: mdb.Remove,
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	// the keys are written in the map iteration order, so the failing one is reached at various positions
+	for i := 0; i < 10; i++ {
+		err := s.MultiPut(map[string][]byte{
+			"existing": []byte("new value"),
+			"new":      []byte("value"),
+			"failing":  []byte("value"),
+		})
+		assert.True(t, errors.Is(err, expectedErr))
+		assert.Equal(t, 0, cache.Len())
+		val, _ := mdb.Get([]byte("existing"))
+		assert.Equal(t, []byte("old value"), val)
+		assert.NotNil(t, mdb.Has([]byte("new")))
+	}
+}
+
+func TestStageShouldServeTheValuesUntilCommitted(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(1)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+
+	s.Stage([]byte("key1"), []byte("value1"))
+	s.Stage([]byte("key2"), []byte("value2"))
+	assert.Equal(t, 2, s.NumStaged())
+	assert.Equal(t, 0, mdb.Len())
+
+	// key1 was evicted from the cache, being still served from the staging area
+	val, err := s.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, s.Has([]byte("key1")))
+
+	numCommitted, err := s.CommitStaged()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, numCommitted)
+	assert.Equal(t, 0, s.NumStaged())
+	assert.Equal(t, 2, mdb.Len())
+
+	s.Stage([]byte("key3"), []byte("value3"))
+	assert.Equal(t, 1, s.DiscardStaged())
+	assert.NotNil(t, s.Has([]byte("key3")))
+	numCommitted, err = s.CommitStaged()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, numCommitted)
+}
+
+func TestCommitStagedShouldKeepTheStagedValuesOnFailure(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	mdb := memorydb.New()
+	shouldFail := true
+	persister := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if shouldFail && string(key) == "key2" {
+				return expectedErr
+			}
+			return mdb.Put(key, val)
+		},
+		GetCalled:    mdb.Get,
+		RemoveCalled: mdb.Remove,
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	s.Stage([]byte("key1"), []byte("value1"))
+	s.Stage([]byte("key2"), []byte("value2"))
+	_, err := s.CommitStaged()
+	assert.True(t, errors.Is(err, expectedErr))
+	assert.Equal(t, 2, s.NumStaged())
+	assert.Equal(t, 0, mdb.Len())
+
+	shouldFail = false
+	numCommitted, err := s.CommitStaged()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, numCommitted)
+	assert.Equal(t, 2, mdb.Len())
+}
+
+func TestRemoveFromCacheShouldKeepPersistedValue(t *testing.T) {
+	t.Parallel()
+
+	key, val := []byte("key"), []byte("value")
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+	_ = s.Put(key, val)
+
+	s.RemoveFromCache(key)
+	assert.False(t, cache.Has(key))
+	assert.Nil(t, mdb.Has(key))
+
+	got, err := s.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, got)
+	assert.True(t, cache.Has(key))
+}
+
+func TestCheckAndHealShouldRepairTheDivergences(t *testing.T) {
+	t.Parallel()
+
+	createDivergentUnit := func() (*storageUnit.Unit, *memorydb.DB) {
+		mdb := memorydb.New()
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, mdb)
+		for i := 0; i < 5; i++ {
+			_ = s.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		}
+		// lost by the persister, while still cached
+		_ = mdb.Remove([]byte("key1"))
+		_ = mdb.Remove([]byte("key3"))
+
+		return s, mdb
+	}
+
+	t.Run("repersist", func(t *testing.T) {
+		t.Parallel()
+
+		s, mdb := createDivergentUnit()
+		report := s.CheckAndHeal(0, storageUnit.RepersistDivergent)
+		assert.Equal(t, storageUnit.ConsistencyReport{NumChecked: 5, NumDiverged: 2, NumHealed: 2}, report)
+		assert.Nil(t, mdb.Has([]byte("key1")))
+		assert.Nil(t, mdb.Has([]byte("key3")))
+	})
+	t.Run("drop", func(t *testing.T) {
+		t.Parallel()
+
+		s, mdb := createDivergentUnit()
+		report := s.CheckAndHeal(0, storageUnit.DropDivergent)
+		assert.Equal(t, 2, report.NumHealed)
+		assert.NotNil(t, s.Has([]byte("key1")))
+		assert.NotNil(t, mdb.Has([]byte("key1")))
+	})
+	t.Run("sampled", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := createDivergentUnit()
+		report := s.CheckAndHeal(3, storageUnit.RepersistDivergent)
+		assert.Equal(t, 3, report.NumChecked)
+		assert.Equal(t, report.NumDiverged, report.NumHealed)
+	})
+}
+
+func TestBloomFilterShouldShortCircuitTheMissingKeys(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("persisted"), []byte("value"))
+	numPersisterReads := 0
+	persister := &testscommon.PersisterStub{
+		PutCalled: mdb.Put,
+		GetCalled: func(key []byte) ([]byte, error) {
+			numPersisterReads++
+			return mdb.Get(key)
+		},
+		HasCalled: func(key []byte) error {
+			numPersisterReads++
+			return mdb.Has(key)
+		},
+		RangeKeysCalled: mdb.RangeKeys,
+		DestroyCalled:   mdb.Destroy,
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	err := s.EnableBloomFilter(bloom.Config{})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	err = s.EnableBloomFilter(bloom.Config{ExpectedNumKeys: 100, FalsePositiveRate: 0.001})
+	require.Nil(t, err)
+
+	_, err = s.Get([]byte("missing"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.True(t, errors.Is(s.Has([]byte("missing")), common.ErrKeyNotFound))
+	assert.Equal(t, 0, numPersisterReads)
+
+	val, err := s.Get([]byte("persisted"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.Equal(t, 1, numPersisterReads)
+
+	_ = s.Put([]byte("added"), []byte("value"))
+	_ = s.MultiPut(map[string][]byte{"multi": []byte("value")})
+	s.ClearCache()
+	assert.Nil(t, s.Has([]byte("added")))
+	assert.Nil(t, s.Has([]byte("multi")))
+	// MultiPut reads the previous value as well, to be restored on failure
+	assert.Equal(t, 4, numPersisterReads)
+
+	_ = s.DestroyUnit()
+	assert.NotNil(t, s.Has([]byte("added")))
+	assert.Equal(t, 4, numPersisterReads)
+}
+
+func TestGetRange(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	for _, key := range []string{"c", "a", "e", "b", "d"} {
+		_ = mdb.Put([]byte(key), []byte("value_"+key))
+	}
+	withoutRangeIterator := &testscommon.PersisterStub{RangeKeysCalled: mdb.RangeKeys}
+
+	for name, persister := range map[string]types.Persister{"range iterator": mdb, "full iteration": withoutRangeIterator} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cache, _ := lrucache.NewCache(10)
+			s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+			keysOf := func(pairs []data.KeyValuePair) []string {
+				keys := make([]string, 0, len(pairs))
+				for _, pair := range pairs {
+					keys = append(keys, string(pair.Key))
+				}
+				return keys
+			}
+
+			pairs, err := s.GetRange([]byte("b"), []byte("e"), 0)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"b", "c", "d"}, keysOf(pairs))
+			assert.Equal(t, []byte("value_b"), pairs[0].Value)
+
+			pairs, err = s.GetRange([]byte("b"), nil, 2)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"b", "c"}, keysOf(pairs))
+
+			pairs, err = s.GetRange([]byte("x"), nil, 0)
+			assert.Nil(t, err)
+			assert.Empty(t, pairs)
+
+			_, err = s.GetRange([]byte("c"), []byte("b"), 0)
+			assert.True(t, errors.Is(err, common.ErrInvalidKeyRange))
+			_, err = s.GetRange(nil, nil, -1)
+			assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+		})
+	}
+}
+
+func TestGetShouldNotWaitForTheSlowReadsOfOtherKeys(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("slow"), []byte("value"))
+	_ = mdb.Put([]byte("fast"), []byte("value"))
+	chSlowReadStarted := make(chan struct{})
+	chReleaseSlowRead := make(chan struct{})
+	persister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			if string(key) == "slow" {
+				close(chSlowReadStarted)
+				<-chReleaseSlowRead
+			}
+			return mdb.Get(key)
+		},
+		PutCalled: mdb.Put,
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	chSlowReadDone := make(chan struct{})
+	go func() {
+		_, _ = s.Get([]byte("slow"))
+		close(chSlowReadDone)
+	}()
+	<-chSlowReadStarted
+
+	val, err := s.Get([]byte("fast"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.Nil(t, s.Put([]byte("other"), []byte("value")))
+
+	chPutDone := make(chan struct{})
+	go func() {
+		_ = s.Put([]byte("slow"), []byte("new value"))
+		close(chPutDone)
+	}()
+	select {
+	case <-chPutDone:
+		assert.Fail(t, "the write of the same key should wait for the read in progress")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(chReleaseSlowRead)
+	<-chSlowReadDone
+	<-chPutDone
+	val, _ = s.Get([]byte("slow"))
+	assert.Equal(t, []byte("new value"), val)
+}
+
+func TestStorageUnit_ErrorsShouldCarryTheUnitName(t *testing.T) {
+	t.Parallel()
+
+	s := initStorageUnit(t, 10)
+	s.SetName("Blocks")
+
+	_, err := s.Get([]byte("missing"))
+	assert.True(t, common.IsNotFound(err))
+	assert.Contains(t, err.Error(), "unit: Blocks")
+	err = s.Has([]byte("missing"))
+	assert.True(t, common.IsNotFound(err))
+	assert.Contains(t, err.Error(), "unit: Blocks")
+}
+
+func TestStorageUnit_Stats(t *testing.T) {
+	t.Parallel()
+
+	s := initStorageUnit(t, 10)
+	s.SetName("Blocks")
+	assert.Nil(t, s.Put([]byte("key"), []byte("value")))
+	s.Stage([]byte("staged"), []byte("value"))
+
+	stats := s.Stats()
+	assert.Equal(t, "Blocks", stats["name"])
+	assert.Equal(t, 1, stats["numStaged"])
+	cacheStats := stats["cache"].(map[string]interface{})
+	assert.Equal(t, string(common.LRUCache), cacheStats[types.StatType])
+	assert.Equal(t, 2, cacheStats[types.StatNumItems])
+	persisterStats := stats["persister"].(map[string]interface{})
+	assert.Equal(t, string(common.MemoryDB), persisterStats[types.StatType])
+	assert.Equal(t, 1, persisterStats[types.StatNumItems])
+}
+
+func TestStorageUnit_Health(t *testing.T) {
+	t.Parallel()
+
+	ldb, err := leveldb.NewDB(t.TempDir(), 100, 100, 10)
+	require.Nil(t, err)
+	cache, _ := lrucache.NewCache(10)
+	s, err := storageUnit.NewStorageUnit(cache, ldb)
+	require.Nil(t, err)
+
+	status := s.Health()
+	assert.True(t, status.IsOK())
+	assert.Contains(t, status.Components, "persister")
+	assert.NotContains(t, status.Components, "cache")
+
+	_ = ldb.Close()
+	status = s.Health()
+	assert.Equal(t, types.HealthFailed, status.State)
+	assert.Equal(t, []string{"persister is Failed"}, status.Reasons)
+}
@@ -0,0 +1,253 @@
+package storageUnit
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// pendingWrites holds the writes buffered by a transaction for one storer, the last write of a key replacing the
+// previous ones
+type pendingWrites struct {
+	storer  types.Storer
+	puts    map[string][]byte
+	removes map[string]struct{}
+}
+
+func (pw *pendingWrites) keys() []string {
+	keys := make([]string, 0, len(pw.puts)+len(pw.removes))
+	for key := range pw.puts {
+		keys = append(keys, key)
+	}
+	for key := range pw.removes {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Transaction is a unit of work buffering the Puts and Removes across several storers, so that they are written
+// together by Commit, each storer receiving its Puts in one go if it is a types.MultiPutter. Until committed, the
+// buffered writes are only visible through the transaction's Get, and can be dropped by Rollback. A failed Commit
+// restores the values the written keys held before it started
+type Transaction struct {
+	mut    sync.Mutex
+	writes []*pendingWrites
+	done   bool
+}
+
+// NewTransaction creates a new empty transaction
+func NewTransaction() *Transaction {
+	return &Transaction{
+		writes: make([]*pendingWrites, 0),
+	}
+}
+
+func (tx *Transaction) pendingWritesOf(storer types.Storer) *pendingWrites {
+	for _, pw := range tx.writes {
+		if pw.storer == storer {
+			return pw
+		}
+	}
+
+	pw := &pendingWrites{
+		storer:  storer,
+		puts:    make(map[string][]byte),
+		removes: make(map[string]struct{}),
+	}
+	tx.writes = append(tx.writes, pw)
+
+	return pw
+}
+
+func (tx *Transaction) checkWritable(storer types.Storer) error {
+	if check.IfNil(storer) {
+		return common.ErrNilStorer
+	}
+	if tx.done {
+		return common.ErrTransactionDone
+	}
+
+	return nil
+}
+
+// Put buffers the write of the (key, data) pair to the storer
+func (tx *Transaction) Put(storer types.Storer, key, data []byte) error {
+	tx.mut.Lock()
+	defer tx.mut.Unlock()
+
+	err := tx.checkWritable(storer)
+	if err != nil {
+		return err
+	}
+
+	pw := tx.pendingWritesOf(storer)
+	delete(pw.removes, string(key))
+	pw.puts[string(key)] = data
+
+	return nil
+}
+
+// Remove buffers the removal of the key from the storer
+func (tx *Transaction) Remove(storer types.Storer, key []byte) error {
+	tx.mut.Lock()
+	defer tx.mut.Unlock()
+
+	err := tx.checkWritable(storer)
+	if err != nil {
+		return err
+	}
+
+	pw := tx.pendingWritesOf(storer)
+	delete(pw.puts, string(key))
+	pw.removes[string(key)] = struct{}{}
+
+	return nil
+}
+
+// Get returns the value of the key as seen by the transaction: the buffered one if the key was written, otherwise the
+// one held by the storer
+func (tx *Transaction) Get(storer types.Storer, key []byte) ([]byte, error) {
+	if check.IfNil(storer) {
+		return nil, common.ErrNilStorer
+	}
+
+	tx.mut.Lock()
+	for _, pw := range tx.writes {
+		if pw.storer != storer {
+			continue
+		}
+
+		val, isPut := pw.puts[string(key)]
+		_, isRemoved := pw.removes[string(key)]
+		tx.mut.Unlock()
+		if isPut {
+			return val, nil
+		}
+		if isRemoved {
+			return nil, fmt.Errorf("%w: key %s is removed by the transaction", common.ErrKeyNotFound, base64.StdEncoding.EncodeToString(key))
+		}
+
+		return storer.Get(key)
+	}
+	tx.mut.Unlock()
+
+	return storer.Get(key)
+}
+
+// NumWrites returns the number of buffered writes, across all the storers
+func (tx *Transaction) NumWrites() int {
+	tx.mut.Lock()
+	defer tx.mut.Unlock()
+
+	numWrites := 0
+	for _, pw := range tx.writes {
+		numWrites += len(pw.puts) + len(pw.removes)
+	}
+
+	return numWrites
+}
+
+// Rollback drops the buffered writes, ending the transaction
+func (tx *Transaction) Rollback() {
+	tx.mut.Lock()
+	defer tx.mut.Unlock()
+
+	tx.writes = make([]*pendingWrites, 0)
+	tx.done = true
+}
+
+// Commit writes the buffered writes, storer by storer in the order they were first written to, ending the
+// transaction. The values of the written keys are read first, so that a failure restores them on all the storers
+// reached so far, removing the keys which could not be read
+func (tx *Transaction) Commit() error {
+	tx.mut.Lock()
+	defer tx.mut.Unlock()
+
+	if tx.done {
+		return common.ErrTransactionDone
+	}
+	tx.done = true
+
+	previous := make([]map[string][]byte, len(tx.writes))
+	for i, pw := range tx.writes {
+		previous[i] = make(map[string][]byte)
+		for _, key := range pw.keys() {
+			val, err := pw.storer.Get([]byte(key))
+			if err == nil {
+				previous[i][key] = val
+			}
+		}
+	}
+
+	for i, pw := range tx.writes {
+		err := pw.commit()
+		if err == nil {
+			continue
+		}
+
+		errRestore := tx.restore(previous[:i+1])
+		log.Warn("transaction commit failed", "num storers reached", i+1, "error", err, "restore error", errRestore)
+		return errors.Join(err, errRestore)
+	}
+
+	return nil
+}
+
+func (pw *pendingWrites) commit() error {
+	multiPutter, ok := pw.storer.(types.MultiPutter)
+	if ok && len(pw.puts) > 0 {
+		err := multiPutter.MultiPut(pw.puts)
+		if err != nil {
+			return err
+		}
+	} else {
+		for key, val := range pw.puts {
+			err := pw.storer.Put([]byte(key), val)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for key := range pw.removes {
+		err := pw.storer.Remove([]byte(key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restore writes back the previous values of the keys of the first storers
+func (tx *Transaction) restore(previous []map[string][]byte) error {
+	errs := make([]error, 0)
+	for i, values := range previous {
+		pw := tx.writes[i]
+		for _, key := range pw.keys() {
+			var err error
+			val, existed := values[key]
+			if existed {
+				err = pw.storer.Put([]byte(key), val)
+			} else {
+				err = pw.storer.Remove([]byte(key))
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w while restoring key %s", err, base64.StdEncoding.EncodeToString([]byte(key))))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tx *Transaction) IsInterfaceNil() bool {
+	return tx == nil
+}
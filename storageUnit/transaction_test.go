@@ -0,0 +1,92 @@
+package storageUnit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_CommitShouldWriteToAllTheStorers(t *testing.T) {
+	t.Parallel()
+
+	blocks := initStorageUnit(t, 10)
+	transactions := initStorageUnit(t, 10)
+	_ = transactions.Put([]byte("tx0"), []byte("old"))
+
+	tx := storageUnit.NewTransaction()
+	assert.Equal(t, common.ErrNilStorer, tx.Put(nil, []byte("key"), []byte("value")))
+	require.Nil(t, tx.Put(blocks, []byte("block"), []byte("header")))
+	require.Nil(t, tx.Put(transactions, []byte("tx1"), []byte("body1")))
+	require.Nil(t, tx.Put(transactions, []byte("tx2"), []byte("body2")))
+	require.Nil(t, tx.Remove(transactions, []byte("tx0")))
+	require.Nil(t, tx.Remove(transactions, []byte("tx2")))
+	assert.Equal(t, 4, tx.NumWrites())
+
+	val, err := tx.Get(blocks, []byte("block"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("header"), val)
+	_, err = tx.Get(transactions, []byte("tx0"))
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.NotNil(t, blocks.Has([]byte("block")))
+	assert.Nil(t, transactions.Has([]byte("tx0")))
+
+	require.Nil(t, tx.Commit())
+	val, _ = blocks.Get([]byte("block"))
+	assert.Equal(t, []byte("header"), val)
+	val, _ = transactions.Get([]byte("tx1"))
+	assert.Equal(t, []byte("body1"), val)
+	assert.NotNil(t, transactions.Has([]byte("tx0")))
+	assert.NotNil(t, transactions.Has([]byte("tx2")))
+
+	assert.Equal(t, common.ErrTransactionDone, tx.Commit())
+	assert.Equal(t, common.ErrTransactionDone, tx.Put(blocks, []byte("key"), []byte("value")))
+}
+
+func TestTransaction_RollbackShouldDropTheBufferedWrites(t *testing.T) {
+	t.Parallel()
+
+	blocks := initStorageUnit(t, 10)
+	tx := storageUnit.NewTransaction()
+	require.Nil(t, tx.Put(blocks, []byte("block"), []byte("header")))
+
+	tx.Rollback()
+	assert.Equal(t, 0, tx.NumWrites())
+	assert.Equal(t, common.ErrTransactionDone, tx.Commit())
+	assert.NotNil(t, blocks.Has([]byte("block")))
+}
+
+func TestTransaction_FailedCommitShouldRestoreThePreviousValues(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	blocks := initStorageUnit(t, 10)
+	_ = blocks.Put([]byte("block1"), []byte("old header"))
+	cache, _ := lrucache.NewCache(10)
+	mdb := memorydb.New()
+	failing, _ := storageUnit.NewStorageUnit(cache, &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return expectedErr
+		},
+		GetCalled:    mdb.Get,
+		HasCalled:    mdb.Has,
+		RemoveCalled: mdb.Remove,
+	})
+
+	tx := storageUnit.NewTransaction()
+	require.Nil(t, tx.Put(blocks, []byte("block1"), []byte("new header")))
+	require.Nil(t, tx.Put(blocks, []byte("block2"), []byte("header")))
+	require.Nil(t, tx.Put(failing, []byte("receipt"), []byte("receipt")))
+
+	err := tx.Commit()
+	assert.True(t, errors.Is(err, expectedErr))
+	val, _ := blocks.Get([]byte("block1"))
+	assert.Equal(t, []byte("old header"), val)
+	assert.NotNil(t, blocks.Has([]byte("block2")))
+}
@@ -0,0 +1,195 @@
+package storageUnit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// SetWritePolicy sets the way the writes are applied on the cache and the persister:
+//   - the write through policy, the default one, persists the values before caching them
+//   - the write back policy caches the values and marks them dirty, the dirty values being persisted in one go every
+//     FlushIntervalInSeconds, or as soon as MaxNumDirty of them are held, and by FlushDirty and Close. The dirty values
+//     are served by Get and Has until persisted, even if evicted from the cache meanwhile
+//   - the cache bypass policy persists the values like the write through one, caching only the ones smaller than
+//     BypassThresholdInBytes, so that a very large value does not evict many small ones
+//
+// The dirty values held when switching from the write back policy are persisted first
+func (u *Unit) SetWritePolicy(config common.WritePolicyConfig) error {
+	config.ApplyDefaults()
+	err := config.Validate()
+	if err != nil {
+		return err
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	_, err = u.flushDirtyNoLock()
+	if err != nil {
+		return fmt.Errorf("%w while switching the write policy to %s", err, config.Policy)
+	}
+	u.stopFlusherNoLock()
+
+	u.writePolicy = config.Policy
+	if len(u.writePolicy) == 0 {
+		u.writePolicy = common.WriteThroughPolicy
+	}
+	u.maxNumDirty = int(config.MaxNumDirty)
+	u.bypassThreshold = int(config.BypassThresholdInBytes)
+	if u.writePolicy == common.WriteBackPolicy {
+		u.flusher = newDirtyFlusher(u, time.Duration(config.FlushIntervalInSeconds)*time.Second)
+	}
+	log.Debug("storage unit write policy set", "name", u.name, "policy", u.writePolicy)
+
+	return nil
+}
+
+func (u *Unit) isWriteBack() bool {
+	return u.writePolicy == common.WriteBackPolicy
+}
+
+// cache adds the value to the cache, unless it should bypass it, in which case the previous value of the key is removed
+// from the cache
+func (u *Unit) cache(key, data []byte) {
+	if u.writePolicy == common.CacheBypassPolicy && len(data) >= u.bypassThreshold {
+		u.cacher.Remove(key)
+		u.numCacheBypasses.Add(1)
+		return
+	}
+
+	u.cacher.Put(key, data, len(data))
+}
+
+// markDirty holds the value until persisted, asking for an early flush once maxNumDirty values are held
+func (u *Unit) markDirty(key, data []byte) {
+	u.mutDirty.Lock()
+	u.dirty[string(key)] = data
+	numDirty := len(u.dirty)
+	u.mutDirty.Unlock()
+
+	if numDirty >= u.maxNumDirty && u.flusher != nil {
+		u.flusher.requestFlush()
+	}
+}
+
+func (u *Unit) dirtyValue(key []byte) ([]byte, bool) {
+	u.mutDirty.Lock()
+	defer u.mutDirty.Unlock()
+
+	val, ok := u.dirty[string(key)]
+
+	return val, ok
+}
+
+// undirty drops the dirty value of the key, superseded by a removal or a staged value
+func (u *Unit) undirty(key []byte) {
+	u.mutDirty.Lock()
+	delete(u.dirty, string(key))
+	u.mutDirty.Unlock()
+}
+
+// NumDirty returns the number of dirty values, not persisted yet
+func (u *Unit) NumDirty() int {
+	u.mutDirty.Lock()
+	defer u.mutDirty.Unlock()
+
+	return len(u.dirty)
+}
+
+// FlushDirty persists all the dirty values held under the write back policy in one go, returning their number. On
+// failure, the dirty values are kept, to be persisted by the next flush. No other operation is served meanwhile
+func (u *Unit) FlushDirty() (int, error) {
+	if u.NumDirty() == 0 {
+		return 0, nil
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.flushDirtyNoLock()
+}
+
+func (u *Unit) flushDirtyNoLock() (int, error) {
+	u.mutDirty.Lock()
+	defer u.mutDirty.Unlock()
+
+	if len(u.dirty) == 0 {
+		return 0, nil
+	}
+
+	err := u.persistBatchNoLock(u.dirty)
+	if err != nil {
+		u.numFailedFlushes.Add(1)
+		u.lastFlushFailed.Store(true)
+		return 0, err
+	}
+
+	numFlushed := len(u.dirty)
+	u.dirty = make(map[string][]byte)
+	u.numFlushedDirty.Add(uint64(numFlushed))
+	u.lastFlushFailed.Store(false)
+
+	return numFlushed, nil
+}
+
+func (u *Unit) stopFlusherNoLock() {
+	if u.flusher != nil {
+		u.flusher.close()
+		u.flusher = nil
+	}
+}
+
+// dirtyFlusher persists the dirty values of a write back unit periodically, or as soon as asked to
+type dirtyFlusher struct {
+	flushNow chan struct{}
+	closing  chan struct{}
+}
+
+func newDirtyFlusher(u *Unit, interval time.Duration) *dirtyFlusher {
+	df := &dirtyFlusher{
+		flushNow: make(chan struct{}, 1),
+		closing:  make(chan struct{}),
+	}
+	go df.run(u, interval)
+
+	return df
+}
+
+func (df *dirtyFlusher) run(u *Unit, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-df.closing:
+			return
+		case <-ticker.C:
+		case <-df.flushNow:
+		}
+
+		numFlushed, err := u.FlushDirty()
+		if err != nil {
+			log.Warn("storage unit could not persist the dirty values, will retry", "name", u.name,
+				"num dirty", u.NumDirty(), "error", err)
+			continue
+		}
+		if numFlushed > 0 {
+			log.Trace("storage unit persisted the dirty values", "name", u.name, "num values", numFlushed)
+		}
+	}
+}
+
+// requestFlush asks for a flush, unless one was already asked for
+func (df *dirtyFlusher) requestFlush() {
+	select {
+	case df.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+// close stops the flusher, without waiting for the flush in progress, if any
+func (df *dirtyFlusher) close() {
+	close(df.closing)
+}
@@ -0,0 +1,213 @@
+package storageUnit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnit_SetWritePolicyInvalidConfigShouldErr(t *testing.T) {
+	t.Parallel()
+
+	s := initStorageUnit(t, 10)
+	err := s.SetWritePolicy(common.WritePolicyConfig{Policy: "WriteAround"})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	err = s.SetWritePolicy(common.WritePolicyConfig{Policy: common.CacheBypassPolicy})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "BypassThresholdInBytes")
+	assert.Equal(t, string(common.WriteThroughPolicy), s.Stats()["writePolicy"])
+}
+
+func TestUnit_WriteBackShouldPersistTheDirtyValuesWhenFlushed(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(2)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+	err := s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy, FlushIntervalInSeconds: 3600})
+	require.Nil(t, err)
+
+	require.Nil(t, s.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, s.MultiPut(map[string][]byte{"key2": []byte("value2"), "key3": []byte("value3")}))
+	require.Nil(t, s.Put([]byte("removed"), []byte("value")))
+	require.Nil(t, s.Remove([]byte("removed")))
+	assert.Equal(t, 3, s.NumDirty())
+	assert.True(t, common.IsNotFound(mdb.Has([]byte("key1"))))
+
+	// the dirty values evicted from the cache are still served
+	val, err := s.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, s.Has([]byte("key2")))
+	assert.NotNil(t, s.Has([]byte("removed")))
+
+	numFlushed, err := s.FlushDirty()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, numFlushed)
+	assert.Equal(t, 0, s.NumDirty())
+	for _, key := range []string{"key1", "key2", "key3"} {
+		assert.Nil(t, mdb.Has([]byte(key)))
+	}
+	assert.NotNil(t, mdb.Has([]byte("removed")))
+
+	stats := s.Stats()
+	assert.Equal(t, string(common.WriteBackPolicy), stats["writePolicy"])
+	assert.Equal(t, uint64(3), stats["numFlushedDirty"])
+}
+
+func TestUnit_WriteBackShouldFlushPeriodicallyAndWhenFull(t *testing.T) {
+	t.Parallel()
+
+	t.Run("periodically", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, mdb)
+		err := s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy, FlushIntervalInSeconds: 1})
+		require.Nil(t, err)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		require.Nil(t, s.Put([]byte("key"), []byte("value")))
+		assert.Eventually(t, func() bool {
+			return mdb.Has([]byte("key")) == nil
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+	t.Run("when full", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, mdb)
+		err := s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy, FlushIntervalInSeconds: 3600, MaxNumDirty: 2})
+		require.Nil(t, err)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		require.Nil(t, s.Put([]byte("key1"), []byte("value1")))
+		require.Nil(t, s.Put([]byte("key2"), []byte("value2")))
+		assert.Eventually(t, func() bool {
+			return s.NumDirty() == 0
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Nil(t, mdb.Has([]byte("key1")))
+		assert.Nil(t, mdb.Has([]byte("key2")))
+	})
+}
+
+func TestUnit_WriteBackFailedFlushShouldKeepTheDirtyValues(t *testing.T) {
+	t.Parallel()
+
+	errPut := errors.New("put error")
+	shouldFail := true
+	mdb := memorydb.New()
+	persister := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if shouldFail {
+				return errPut
+			}
+			return mdb.Put(key, val)
+		},
+		GetCalled:    mdb.Get,
+		HasCalled:    mdb.Has,
+		RemoveCalled: mdb.Remove,
+		CloseCalled:  mdb.Close,
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+	require.Nil(t, s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy, FlushIntervalInSeconds: 3600}))
+
+	require.Nil(t, s.Put([]byte("key"), []byte("value")))
+	numFlushed, err := s.FlushDirty()
+	assert.Equal(t, 0, numFlushed)
+	assert.True(t, errors.Is(err, errPut))
+	assert.Equal(t, 1, s.NumDirty())
+	assert.Equal(t, types.HealthDegraded, s.Health().State)
+	assert.Equal(t, uint64(1), s.Stats()["numFailedFlushes"])
+
+	// switching the policy needs the dirty values to be persisted
+	err = s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteThroughPolicy})
+	assert.True(t, errors.Is(err, errPut))
+
+	shouldFail = false
+	require.Nil(t, s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteThroughPolicy}))
+	assert.Equal(t, 0, s.NumDirty())
+	assert.True(t, s.Health().IsOK())
+	assert.Nil(t, mdb.Has([]byte("key")))
+}
+
+func TestUnit_WriteBackCloseShouldPersistTheDirtyValues(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	persister := &testscommon.PersisterStub{
+		PutCalled: mdb.Put,
+		CloseCalled: func() error {
+			return nil
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+	require.Nil(t, s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy}))
+
+	require.Nil(t, s.Put([]byte("key"), []byte("value")))
+	require.Nil(t, s.Close())
+	assert.Nil(t, mdb.Has([]byte("key")))
+}
+
+func TestUnit_StageShouldSupersedeTheDirtyValue(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+	require.Nil(t, s.SetWritePolicy(common.WritePolicyConfig{Policy: common.WriteBackPolicy, FlushIntervalInSeconds: 3600}))
+
+	require.Nil(t, s.Put([]byte("key"), []byte("dirty")))
+	s.Stage([]byte("key"), []byte("staged"))
+	_, err := s.CommitStaged()
+	require.Nil(t, err)
+	_, err = s.FlushDirty()
+	require.Nil(t, err)
+
+	val, err := mdb.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("staged"), val)
+}
+
+func TestUnit_CacheBypassShouldNotCacheTheLargeValues(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+	require.Nil(t, s.SetWritePolicy(common.WritePolicyConfig{Policy: common.CacheBypassPolicy, BypassThresholdInBytes: 4}))
+
+	require.Nil(t, s.Put([]byte("small"), []byte("abc")))
+	require.Nil(t, s.Put([]byte("large"), []byte("abcd")))
+	assert.True(t, cache.Has([]byte("small")))
+	assert.False(t, cache.Has([]byte("large")))
+	assert.Nil(t, mdb.Has([]byte("large")))
+
+	// a previously cached value of the key is dropped
+	require.Nil(t, s.Put([]byte("small"), []byte("abcdef")))
+	assert.False(t, cache.Has([]byte("small")))
+
+	val, err := s.Get([]byte("large"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("abcd"), val)
+	assert.False(t, cache.Has([]byte("large")))
+	assert.Equal(t, uint64(3), s.Stats()["numCacheBypasses"])
+}
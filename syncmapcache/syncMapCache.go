@@ -0,0 +1,214 @@
+package syncmapcache
+
+import (
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Cacher = (*SyncMapCache)(nil)
+var _ types.StatsProvider = (*SyncMapCache)(nil)
+
+var log = logger.GetOrCreate("storage/syncmapcache")
+
+type cacheEntry struct {
+	value interface{}
+	size  int
+}
+
+// SyncMapCache is a cacher built on top of a sync.Map, meant for read-mostly workloads. It does not maintain any
+// usage order, so reads never take a lock. When the capacity is exceeded, arbitrary entries are evicted.
+// The number of items and the size in bytes are accounted with atomic counters, hence they are only approximate
+// while concurrent writes are in progress
+type SyncMapCache struct {
+	items    sync.Map
+	numItems atomic.Counter
+	numBytes atomic.Counter
+	maxsize  int
+
+	addedDataHandlers *dispatch.Dispatcher
+}
+
+// NewSyncMapCache creates a new sync.Map based cache instance able to hold (approximately) at most size elements
+func NewSyncMapCache(size int) (*SyncMapCache, error) {
+	if size < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	return &SyncMapCache{
+		maxsize:           size,
+		addedDataHandlers: dispatch.NewDefaultDispatcher("syncmapcache"),
+	}, nil
+}
+
+// Clear is used to completely clear the cache.
+func (smc *SyncMapCache) Clear() {
+	smc.items.Range(func(key, _ interface{}) bool {
+		smc.removeKey(key)
+		return true
+	})
+}
+
+// Put adds a value to the cache.  Returns true if an eviction occurred.
+func (smc *SyncMapCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	entry := &cacheEntry{
+		value: value,
+		size:  sizeInBytes,
+	}
+
+	previous, loaded := smc.items.Swap(string(key), entry)
+	if loaded {
+		smc.numBytes.Subtract(int64(previous.(*cacheEntry).size))
+	} else {
+		smc.numItems.Increment()
+	}
+	smc.numBytes.Add(int64(sizeInBytes))
+
+	evicted = smc.evictOverflow(string(key))
+	smc.addedDataHandlers.Dispatch(key, value)
+
+	return evicted
+}
+
+// evictOverflow removes arbitrary entries, other than the one just added, until the cache is back within capacity
+func (smc *SyncMapCache) evictOverflow(addedKey string) bool {
+	if smc.numItems.Get() <= int64(smc.maxsize) {
+		return false
+	}
+
+	evicted := false
+	smc.items.Range(func(key, _ interface{}) bool {
+		if key.(string) == addedKey {
+			return true
+		}
+
+		evicted = smc.removeKey(key) || evicted
+
+		return smc.numItems.Get() > int64(smc.maxsize)
+	})
+
+	return evicted
+}
+
+func (smc *SyncMapCache) removeKey(key interface{}) bool {
+	previous, loaded := smc.items.LoadAndDelete(key)
+	if !loaded {
+		return false
+	}
+
+	smc.numItems.Decrement()
+	smc.numBytes.Subtract(int64(previous.(*cacheEntry).size))
+
+	return true
+}
+
+// Get looks up a key's value from the cache.
+func (smc *SyncMapCache) Get(key []byte) (value interface{}, ok bool) {
+	return smc.Peek(key)
+}
+
+// Has checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (smc *SyncMapCache) Has(key []byte) bool {
+	_, ok := smc.items.Load(string(key))
+
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (smc *SyncMapCache) Peek(key []byte) (value interface{}, ok bool) {
+	entry, ok := smc.items.Load(string(key))
+	if !ok {
+		return nil, false
+	}
+
+	return entry.(*cacheEntry).value, true
+}
+
+// HasOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether the item existed before and whether it has been added.
+func (smc *SyncMapCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	entry := &cacheEntry{
+		value: value,
+		size:  sizeInBytes,
+	}
+
+	_, has = smc.items.LoadOrStore(string(key), entry)
+	if has {
+		return true, false
+	}
+
+	smc.numItems.Increment()
+	smc.numBytes.Add(int64(sizeInBytes))
+	_ = smc.evictOverflow(string(key))
+	smc.addedDataHandlers.Dispatch(key, value)
+
+	return false, true
+}
+
+// Remove removes the provided key from the cache.
+func (smc *SyncMapCache) Remove(key []byte) {
+	_ = smc.removeKey(string(key))
+}
+
+// Keys returns a slice of the keys in the cache, in no particular order
+func (smc *SyncMapCache) Keys() [][]byte {
+	keys := make([][]byte, 0, smc.Len())
+	smc.items.Range(func(key, _ interface{}) bool {
+		keys = append(keys, []byte(key.(string)))
+		return true
+	})
+
+	return keys
+}
+
+// Len returns the (approximate) number of items in the cache.
+func (smc *SyncMapCache) Len() int {
+	return int(smc.numItems.Get())
+}
+
+// SizeInBytesContained returns the (approximate) size in bytes of all contained elements
+func (smc *SyncMapCache) SizeInBytesContained() uint64 {
+	return smc.numBytes.GetUint64()
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (smc *SyncMapCache) MaxSize() int {
+	return smc.maxsize
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (smc *SyncMapCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	smc.addedDataHandlers.Register(id, handler)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (smc *SyncMapCache) UnRegisterHandler(id string) {
+	smc.addedDataHandlers.Unregister(id)
+}
+
+// Close does nothing for this cacher implementation
+func (smc *SyncMapCache) Close() error {
+	return nil
+}
+
+// Stats returns the key stats of the cache
+func (smc *SyncMapCache) Stats() map[string]interface{} {
+	return types.CacheStats(string(common.SyncMapCache), smc)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (smc *SyncMapCache) IsInterfaceNil() bool {
+	return smc == nil
+}
@@ -0,0 +1,242 @@
+package syncmapcache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/syncmapcache"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var timeoutWaitForWaitGroups = time.Second * 2
+
+func TestNewSyncMapCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid size should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := syncmapcache.NewSyncMapCache(0)
+		assert.True(t, check.IfNil(c))
+		assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := syncmapcache.NewSyncMapCache(10)
+		assert.False(t, check.IfNil(c))
+		assert.Nil(t, err)
+		assert.Equal(t, 10, c.MaxSize())
+	})
+}
+
+func TestSyncMapCache_PutGetPeekHas(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(10)
+	key, val := []byte("key"), []byte("value")
+
+	evicted := c.Put(key, val, len(val))
+	assert.False(t, evicted)
+	assert.Equal(t, 1, c.Len())
+	assert.True(t, c.Has(key))
+
+	recovered, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, val, recovered)
+
+	recovered, ok = c.Peek(key)
+	assert.True(t, ok)
+	assert.Equal(t, val, recovered)
+
+	recovered, ok = c.Get([]byte("missing"))
+	assert.False(t, ok)
+	assert.Nil(t, recovered)
+	assert.False(t, c.Has([]byte("missing")))
+}
+
+func TestSyncMapCache_PutPresentRewritesValueAndSize(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(10)
+	key := []byte("key")
+
+	c.Put(key, []byte("value1"), 6)
+	c.Put(key, []byte("value22"), 7)
+
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(7), c.SizeInBytesContained())
+	recovered, _ := c.Get(key)
+	assert.Equal(t, []byte("value22"), recovered)
+}
+
+func TestSyncMapCache_PutOverCapacityShouldEvictOtherItems(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(3)
+	c.Put([]byte("a"), "a", 1)
+	c.Put([]byte("b"), "b", 1)
+	c.Put([]byte("c"), "c", 1)
+
+	evicted := c.Put([]byte("d"), "d", 1)
+	require.True(t, evicted)
+	require.Equal(t, 3, c.Len())
+	require.Len(t, c.Keys(), 3)
+	require.Equal(t, uint64(3), c.SizeInBytesContained())
+	require.True(t, c.Has([]byte("d")))
+}
+
+func TestSyncMapCache_RemoveAndClear(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(10)
+	c.Put([]byte("a"), "a", 4)
+	c.Put([]byte("b"), "b", 5)
+
+	c.Remove([]byte("a"))
+	c.Remove([]byte("missing"))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(5), c.SizeInBytesContained())
+	assert.Equal(t, [][]byte{[]byte("b")}, c.Keys())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+	assert.Empty(t, c.Keys())
+}
+
+func TestSyncMapCache_HasOrAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(10)
+	key := []byte("key")
+
+	has, added := c.HasOrAdd(key, "value1", 1)
+	assert.False(t, has)
+	assert.True(t, added)
+
+	has, added = c.HasOrAdd(key, "value2", 1)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	recovered, _ := c.Get(key)
+	assert.Equal(t, "value1", recovered)
+	assert.Equal(t, uint64(1), c.SizeInBytesContained())
+}
+
+func TestSyncMapCache_RegisterHandlerShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(10)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	chDone := make(chan bool)
+
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		assert.Equal(t, []byte("key"), key)
+		wg.Done()
+	}, "id")
+	c.RegisterHandler(nil, "nil")
+
+	go func() {
+		wg.Wait()
+		chDone <- true
+	}()
+
+	c.Put([]byte("key"), "value", 0)
+
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "should have been called")
+		return
+	}
+
+	c.UnRegisterHandler("id")
+}
+
+func TestSyncMapCache_ConcurrentOperationsShouldKeepAccounting(t *testing.T) {
+	t.Parallel()
+
+	c, _ := syncmapcache.NewSyncMapCache(100)
+	numOperations := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			key := []byte(fmt.Sprintf("key%d", idx%250))
+			switch idx % 5 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.Get(key)
+			case 2:
+				_, _ = c.HasOrAdd(key, idx, 1)
+			case 3:
+				c.Remove(key)
+			case 4:
+				_ = c.Keys()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	// once all the writers are done, the counters match the contents
+	assert.True(t, c.Len() <= 100)
+	assert.Equal(t, len(c.Keys()), c.Len())
+	assert.Equal(t, uint64(c.Len()), c.SizeInBytesContained())
+}
+
+func TestSyncMapCache_Stress(t *testing.T) {
+	t.Parallel()
+
+	args := testscommon.ArgsCacheStress{
+		NumGoroutines:       8,
+		NumOpsPerGoroutine:  2000,
+		NumKeysPerGoroutine: 20,
+		OperationWeights: map[testscommon.CacheOperation]int{
+			testscommon.CachePut:    5,
+			testscommon.CacheGet:    10,
+			testscommon.CacheRemove: 2,
+			testscommon.CacheKeys:   1,
+		},
+		Seed: 7,
+	}
+
+	t.Run("without evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := syncmapcache.NewSyncMapCache(1000)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 1000
+		stressArgs.ExpectNoEvictions = true
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Equal(t, 0, report.NumEvictions)
+	})
+	t.Run("with evictions", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := syncmapcache.NewSyncMapCache(40)
+		require.Nil(t, err)
+
+		stressArgs := args
+		stressArgs.MaxLen = 40
+		report, err := testscommon.StressCacher(c, stressArgs)
+		require.Nil(t, err)
+		assert.Nil(t, report.Err())
+		assert.Greater(t, report.NumEvictions, 0)
+	})
+}
@@ -0,0 +1,243 @@
+package testscommon
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+type pendingEntry struct {
+	val     []byte
+	removed bool
+}
+
+// BatchedMemDbMock is an in memory persister replicating the batching of the leveldb persister: the writes and the
+// removals are held in a pending batch, consulted by Get and Has, until the batch is written, once it reaches the max
+// batch size, on Flush, on the batch delay, simulated by ElapseBatchDelay, or on Close. RangeKeys only sees the
+// written pairs, as the leveldb iteration does, and DiscardPendingBatch simulates a crash losing the pending batch
+type BatchedMemDbMock struct {
+	mut          sync.RWMutex
+	written      map[string][]byte
+	pending      map[string]pendingEntry
+	sizeBatch    int
+	maxBatchSize int
+	numWrites    int
+	isClosed     bool
+}
+
+// NewBatchedMemDbMock creates a new batched in memory persister, writing its batch once it holds maxBatchSize operations
+func NewBatchedMemDbMock(maxBatchSize int) *BatchedMemDbMock {
+	return &BatchedMemDbMock{
+		written:      make(map[string][]byte),
+		pending:      make(map[string]pendingEntry),
+		maxBatchSize: maxBatchSize,
+	}
+}
+
+// Put adds the value to the pending batch, writing the batch if it gets full
+func (mock *BatchedMemDbMock) Put(key, val []byte) error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	mock.pending[string(key)] = pendingEntry{val: val}
+
+	return mock.increaseBatchSizeNoLock(1)
+}
+
+// MultiPut adds all the values to the pending batch at once, writing the batch if it gets full
+func (mock *BatchedMemDbMock) MultiPut(data map[string][]byte) error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	for key, val := range data {
+		mock.pending[key] = pendingEntry{val: val}
+	}
+
+	return mock.increaseBatchSizeNoLock(len(data))
+}
+
+// Remove marks the key as removed in the pending batch, writing the batch if it gets full
+func (mock *BatchedMemDbMock) Remove(key []byte) error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	mock.pending[string(key)] = pendingEntry{removed: true}
+
+	return mock.increaseBatchSizeNoLock(1)
+}
+
+func (mock *BatchedMemDbMock) increaseBatchSizeNoLock(increment int) error {
+	mock.sizeBatch += increment
+	if mock.sizeBatch < mock.maxBatchSize {
+		return nil
+	}
+
+	return mock.writeBatchNoLock()
+}
+
+func (mock *BatchedMemDbMock) writeBatchNoLock() error {
+	if mock.isClosed {
+		return common.ErrDBIsClosed
+	}
+
+	for key, entry := range mock.pending {
+		if entry.removed {
+			delete(mock.written, key)
+			continue
+		}
+		mock.written[key] = entry.val
+	}
+	if len(mock.pending) > 0 {
+		mock.numWrites++
+	}
+	mock.pending = make(map[string]pendingEntry)
+	mock.sizeBatch = 0
+
+	return nil
+}
+
+// Get returns the value of the key from the pending batch, if there, from the written pairs otherwise
+func (mock *BatchedMemDbMock) Get(key []byte) ([]byte, error) {
+	mock.mut.RLock()
+	defer mock.mut.RUnlock()
+
+	if mock.isClosed {
+		return nil, common.ErrDBIsClosed
+	}
+
+	entry, isPending := mock.pending[string(key)]
+	if isPending {
+		if entry.removed {
+			return nil, common.NewKeyNotFoundError(key, "", "")
+		}
+		return entry.val, nil
+	}
+
+	val, ok := mock.written[string(key)]
+	if !ok {
+		return nil, common.NewKeyNotFoundError(key, "", "")
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the key is present in the pending batch or in the written pairs, not being marked as removed
+func (mock *BatchedMemDbMock) Has(key []byte) error {
+	_, err := mock.Get(key)
+	return err
+}
+
+// Flush writes the pending batch
+func (mock *BatchedMemDbMock) Flush() error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	return mock.writeBatchNoLock()
+}
+
+// ElapseBatchDelay writes the pending batch, as the leveldb persister does once its batch delay elapses
+func (mock *BatchedMemDbMock) ElapseBatchDelay() {
+	_ = mock.Flush()
+}
+
+// DiscardPendingBatch drops the pending batch, as lost on a crash
+func (mock *BatchedMemDbMock) DiscardPendingBatch() {
+	mock.mut.Lock()
+	mock.pending = make(map[string]pendingEntry)
+	mock.sizeBatch = 0
+	mock.mut.Unlock()
+}
+
+// NumPendingWrites returns the number of the distinct keys written or removed in the pending batch
+func (mock *BatchedMemDbMock) NumPendingWrites() int {
+	mock.mut.RLock()
+	defer mock.mut.RUnlock()
+
+	return len(mock.pending)
+}
+
+// NumBatchWrites returns the number of the non empty batches written so far
+func (mock *BatchedMemDbMock) NumBatchWrites() int {
+	mock.mut.RLock()
+	defer mock.mut.RUnlock()
+
+	return mock.numWrites
+}
+
+// IsWritten returns true if the key is present in the written pairs, regardless of the pending batch
+func (mock *BatchedMemDbMock) IsWritten(key []byte) bool {
+	mock.mut.RLock()
+	defer mock.mut.RUnlock()
+
+	_, ok := mock.written[string(key)]
+	return ok
+}
+
+// Close writes the pending batch, then closes the persister
+func (mock *BatchedMemDbMock) Close() error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	_ = mock.writeBatchNoLock()
+	mock.isClosed = true
+
+	return nil
+}
+
+// Destroy drops the pending batch and all the written pairs, closing the persister
+func (mock *BatchedMemDbMock) Destroy() error {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	mock.pending = make(map[string]pendingEntry)
+	mock.written = make(map[string][]byte)
+	mock.sizeBatch = 0
+	mock.isClosed = true
+
+	return nil
+}
+
+// DestroyClosed drops all the written pairs
+func (mock *BatchedMemDbMock) DestroyClosed() error {
+	mock.mut.Lock()
+	mock.written = make(map[string][]byte)
+	mock.mut.Unlock()
+
+	return nil
+}
+
+// RangeKeys calls the handler for each written pair, in ascending order of the keys, the pending batch not being
+// visible. If the handler returns true, the iteration will continue, otherwise will stop
+func (mock *BatchedMemDbMock) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	mock.mut.RLock()
+	if mock.isClosed {
+		mock.mut.RUnlock()
+		return
+	}
+	keys := make([]string, 0, len(mock.written))
+	for key := range mock.written {
+		keys = append(keys, key)
+	}
+	written := make(map[string][]byte, len(mock.written))
+	for key, val := range mock.written {
+		written[key] = val
+	}
+	mock.mut.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !handler([]byte(key), written[key]) {
+			return
+		}
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (mock *BatchedMemDbMock) IsInterfaceNil() bool {
+	return mock == nil
+}
@@ -0,0 +1,172 @@
+package testscommon
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ErrGoldenStateMismatch signals that the state of a cache differs from its golden representation
+var ErrGoldenStateMismatch = errors.New("cache state differs from the golden state")
+
+// CacheEntryState is the logical state of one entry of a cache
+type CacheEntryState struct {
+	Key string
+	// Size is the length of the value, if the value is a byte slice, -1 otherwise
+	Size int
+}
+
+// CacheState is the full logical state of a cache, with the entries in the order returned by Keys, from the oldest to
+// the newest, if the cache defines an order, or sorted by key otherwise
+type CacheState struct {
+	Len          int
+	SizeInBytes  uint64
+	MaxSize      int
+	OrderDefined bool
+	Entries      []CacheEntryState
+}
+
+// CaptureCacheState captures the state of the cache, without altering the recent-ness of the entries. The order of the
+// entries is kept only if orderDefined is set, e.g. for an LRU or a FIFO cache
+func CaptureCacheState(cache types.Cacher, orderDefined bool) CacheState {
+	keys := cache.Keys()
+	state := CacheState{
+		Len:          cache.Len(),
+		SizeInBytes:  cache.SizeInBytesContained(),
+		MaxSize:      cache.MaxSize(),
+		OrderDefined: orderDefined,
+		Entries:      make([]CacheEntryState, 0, len(keys)),
+	}
+
+	for _, key := range keys {
+		entry := CacheEntryState{
+			Key:  printableKey(key),
+			Size: -1,
+		}
+		value, ok := cache.Peek(key)
+		buff, isBuff := value.([]byte)
+		if ok && isBuff {
+			entry.Size = len(buff)
+		}
+		state.Entries = append(state.Entries, entry)
+	}
+
+	if !orderDefined {
+		sort.Slice(state.Entries, func(i, j int) bool {
+			return state.Entries[i].Key < state.Entries[j].Key
+		})
+	}
+
+	return state
+}
+
+// printableKey returns the key as is if printable, hex encoded otherwise
+func printableKey(key []byte) string {
+	for _, r := range string(key) {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return "0x" + hex.EncodeToString(key)
+		}
+	}
+
+	return string(key)
+}
+
+// String returns the golden representation of the state, one line per field, then one line per entry
+func (state CacheState) String() string {
+	builder := strings.Builder{}
+	_, _ = fmt.Fprintf(&builder, "len: %d\n", state.Len)
+	_, _ = fmt.Fprintf(&builder, "sizeInBytes: %d\n", state.SizeInBytes)
+	_, _ = fmt.Fprintf(&builder, "maxSize: %d\n", state.MaxSize)
+	_, _ = fmt.Fprintf(&builder, "orderDefined: %v\n", state.OrderDefined)
+	for _, entry := range state.Entries {
+		if entry.Size < 0 {
+			_, _ = fmt.Fprintf(&builder, "%s\n", entry.Key)
+			continue
+		}
+		_, _ = fmt.Fprintf(&builder, "%s size=%d\n", entry.Key, entry.Size)
+	}
+
+	return builder.String()
+}
+
+// DiffCacheState compares the state with the golden representation, returning the differing lines, prefixed with "-"
+// if only in the golden one and with "+" if only in the state, or an empty string if they are the same
+func DiffCacheState(golden string, state CacheState) string {
+	return diffLines(splitLines(golden), splitLines(state.String()))
+}
+
+// CompareWithGoldenFile compares the state of the cache with the golden representation stored in the file. If update
+// is set, the file is (re)written with the current state instead, e.g. after an intended change of the eviction order
+func CompareWithGoldenFile(state CacheState, goldenPath string, update bool) error {
+	if update {
+		err := os.MkdirAll(filepath.Dir(goldenPath), 0755)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(goldenPath, []byte(state.String()), 0644)
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		return fmt.Errorf("%w while reading the golden state, rerun with update to create it", err)
+	}
+
+	diff := DiffCacheState(string(golden), state)
+	if len(diff) > 0 {
+		return fmt.Errorf("%w of %s:\n%s", ErrGoldenStateMismatch, goldenPath, diff)
+	}
+
+	return nil
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(text) == 0 {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the line diff, based on the longest common subsequence, which suits the small states of the tests
+func diffLines(expected []string, actual []string) string {
+	lcs := make([][]int, len(expected)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+			lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+		}
+	}
+
+	builder := strings.Builder{}
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			i++
+			j++
+		case j == len(actual) || (i < len(expected) && lcs[i+1][j] >= lcs[i][j+1]):
+			_, _ = fmt.Fprintf(&builder, "-%s\n", expected[i])
+			i++
+		default:
+			_, _ = fmt.Fprintf(&builder, "+%s\n", actual[j])
+			j++
+		}
+	}
+
+	return builder.String()
+}
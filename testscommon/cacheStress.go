@@ -0,0 +1,254 @@
+package testscommon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ErrCacheInvariantViolated signals that a cache broke one of the invariants checked by StressCacher
+var ErrCacheInvariantViolated = errors.New("cache invariant violated")
+
+// maxReportedViolations bounds the violations kept in a report, as a broken cache usually breaks the invariants often
+const maxReportedViolations = 20
+
+// CacheOperation names an operation issued by StressCacher
+type CacheOperation string
+
+const (
+	// CachePut is the Put operation
+	CachePut CacheOperation = "Put"
+	// CacheGet is the Get operation
+	CacheGet CacheOperation = "Get"
+	// CacheRemove is the Remove operation
+	CacheRemove CacheOperation = "Remove"
+	// CacheKeys is the Keys operation
+	CacheKeys CacheOperation = "Keys"
+)
+
+var cacheOperations = []CacheOperation{CachePut, CacheGet, CacheRemove, CacheKeys}
+
+// ArgsCacheStress describes the load issued by StressCacher
+type ArgsCacheStress struct {
+	NumGoroutines      int
+	NumOpsPerGoroutine int
+	// NumKeysPerGoroutine is the number of the keys written and removed by each goroutine. The goroutines own disjoint
+	// sets of keys, so that each one knows the last value it wrote, but they read the keys of each other
+	NumKeysPerGoroutine int
+	// OperationWeights holds the relative frequency of each operation, e.g. {Put: 5, Get: 10, Remove: 1, Keys: 1}
+	OperationWeights map[CacheOperation]int
+	// MaxLen is the bound checked against Len and the number of the returned keys
+	MaxLen int
+	// ExpectNoEvictions should be set if the cache can hold all the keys, so that each acknowledged write, not removed
+	// afterwards, is expected to be found. Otherwise, a missing key is accepted as evicted
+	ExpectNoEvictions bool
+	// Seed initializes the random sources of the goroutines, so that each one issues the same operations on each run
+	Seed int64
+}
+
+// CacheStressReport holds the outcome of a StressCacher run
+type CacheStressReport struct {
+	NumOps        map[CacheOperation]int
+	NumEvictions  int
+	NumViolations int
+	Violations    []string
+}
+
+// Err returns ErrCacheInvariantViolated along with the first violations, or nil if the cache kept all the invariants
+func (report *CacheStressReport) Err() error {
+	if report.NumViolations == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w %d times, first ones: %v", ErrCacheInvariantViolated, report.NumViolations, report.Violations)
+}
+
+func (args ArgsCacheStress) validate() error {
+	var validator common.ConfigValidator
+	validator.Check(args.NumGoroutines > 0, common.ErrInvalidConfig, "NumGoroutines should be positive")
+	validator.Check(args.NumOpsPerGoroutine > 0, common.ErrInvalidConfig, "NumOpsPerGoroutine should be positive")
+	validator.Check(args.NumKeysPerGoroutine > 0, common.ErrInvalidConfig, "NumKeysPerGoroutine should be positive")
+	validator.Check(args.MaxLen > 0, common.ErrInvalidConfig, "MaxLen should be positive")
+
+	totalWeight := 0
+	for operation, weight := range args.OperationWeights {
+		validator.Check(isCacheOperation(operation), common.ErrInvalidConfig, "unknown operation %q", operation)
+		validator.Check(weight >= 0, common.ErrInvalidConfig, "the weight of %s should not be negative", operation)
+		totalWeight += weight
+	}
+	validator.Check(totalWeight > 0, common.ErrInvalidConfig, "at least one operation should have a positive weight")
+
+	return validator.Err()
+}
+
+func isCacheOperation(operation CacheOperation) bool {
+	for _, known := range cacheOperations {
+		if operation == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+type cacheStressRun struct {
+	cache types.Cacher
+	args  ArgsCacheStress
+
+	mut    sync.Mutex
+	report CacheStressReport
+}
+
+// StressCacher hammers the cache with the configured mix of operations from concurrent goroutines, checking that the
+// cache does not panic, that Len and Keys stay within MaxLen, and that no acknowledged write is lost: a key is read
+// back with the last value written, or not at all if it was removed or, unless ExpectNoEvictions is set, evicted
+func StressCacher(cache types.Cacher, args ArgsCacheStress) (*CacheStressReport, error) {
+	err := args.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w for the cache stress", err)
+	}
+
+	run := &cacheStressRun{
+		cache: cache,
+		args:  args,
+		report: CacheStressReport{
+			NumOps: make(map[CacheOperation]int),
+		},
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(args.NumGoroutines)
+	lastValues := make([]map[string][]byte, args.NumGoroutines)
+	for i := 0; i < args.NumGoroutines; i++ {
+		go func(goroutineIndex int) {
+			defer wg.Done()
+			lastValues[goroutineIndex] = run.hammer(goroutineIndex)
+		}(i)
+	}
+	wg.Wait()
+
+	for goroutineIndex, values := range lastValues {
+		for keyIndex := 0; keyIndex < args.NumKeysPerGoroutine; keyIndex++ {
+			run.checkKey(stressKey(goroutineIndex, keyIndex), values)
+		}
+	}
+	run.checkLen(cache.Len(), "Len, after the run")
+
+	return &run.report, nil
+}
+
+// hammer issues the operations of one goroutine, returning the last value written for each of its keys, nil if removed
+func (run *cacheStressRun) hammer(goroutineIndex int) (lastValues map[string][]byte) {
+	lastValues = make(map[string][]byte)
+	defer func() {
+		r := recover()
+		if r != nil {
+			run.violate("goroutine %d panicked: %v", goroutineIndex, r)
+		}
+	}()
+
+	rnd := rand.New(rand.NewSource(run.args.Seed + int64(goroutineIndex)))
+	numOps := make(map[CacheOperation]int)
+	numEvictions := 0
+	defer func() {
+		run.mut.Lock()
+		for operation, num := range numOps {
+			run.report.NumOps[operation] += num
+		}
+		run.report.NumEvictions += numEvictions
+		run.mut.Unlock()
+	}()
+
+	for i := 0; i < run.args.NumOpsPerGoroutine; i++ {
+		operation := run.pickOperation(rnd)
+		numOps[operation]++
+		key := stressKey(goroutineIndex, rnd.Intn(run.args.NumKeysPerGoroutine))
+
+		switch operation {
+		case CachePut:
+			value := []byte(fmt.Sprintf("%s-v%d", key, i))
+			if run.cache.Put(key, value, len(value)) {
+				numEvictions++
+			}
+			lastValues[string(key)] = value
+		case CacheGet:
+			run.checkKey(key, lastValues)
+			// reads the keys of the other goroutines too, for contention
+			_, _ = run.cache.Get(stressKey(rnd.Intn(run.args.NumGoroutines), rnd.Intn(run.args.NumKeysPerGoroutine)))
+		case CacheRemove:
+			run.cache.Remove(key)
+			lastValues[string(key)] = nil
+		case CacheKeys:
+			run.checkLen(len(run.cache.Keys()), "Keys")
+		}
+		run.checkLen(run.cache.Len(), "Len")
+	}
+
+	return lastValues
+}
+
+func (run *cacheStressRun) pickOperation(rnd *rand.Rand) CacheOperation {
+	totalWeight := 0
+	for _, operation := range cacheOperations {
+		totalWeight += run.args.OperationWeights[operation]
+	}
+
+	pick := rnd.Intn(totalWeight)
+	for _, operation := range cacheOperations {
+		pick -= run.args.OperationWeights[operation]
+		if pick < 0 {
+			return operation
+		}
+	}
+
+	return CacheGet
+}
+
+// checkKey checks the key against the last value written by its owner goroutine
+func (run *cacheStressRun) checkKey(key []byte, lastValues map[string][]byte) {
+	expected, written := lastValues[string(key)]
+	value, ok := run.cache.Get(key)
+	if !written || expected == nil {
+		if ok {
+			run.violate("key %s should be missing, found %v", key, value)
+		}
+		return
+	}
+
+	if !ok {
+		if run.args.ExpectNoEvictions {
+			run.violate("acknowledged write of key %s lost", key)
+		}
+		return
+	}
+
+	buff, isBuff := value.([]byte)
+	if !isBuff || !bytes.Equal(buff, expected) {
+		run.violate("key %s should hold %s, found %v", key, expected, value)
+	}
+}
+
+func (run *cacheStressRun) checkLen(length int, source string) {
+	if length < 0 || length > run.args.MaxLen {
+		run.violate("%s returned %d, out of [0, %d]", source, length, run.args.MaxLen)
+	}
+}
+
+func (run *cacheStressRun) violate(format string, args ...interface{}) {
+	run.mut.Lock()
+	defer run.mut.Unlock()
+
+	run.report.NumViolations++
+	if len(run.report.Violations) < maxReportedViolations {
+		run.report.Violations = append(run.report.Violations, fmt.Sprintf(format, args...))
+	}
+}
+
+func stressKey(goroutineIndex int, keyIndex int) []byte {
+	return []byte(fmt.Sprintf("g%d-k%d", goroutineIndex, keyIndex))
+}
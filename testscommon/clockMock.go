@@ -0,0 +1,39 @@
+package testscommon
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockMock is a manually driven clock
+type ClockMock struct {
+	mut sync.RWMutex
+	now time.Time
+}
+
+// NewClockMock -
+func NewClockMock(now time.Time) *ClockMock {
+	return &ClockMock{
+		now: now,
+	}
+}
+
+// Now -
+func (mock *ClockMock) Now() time.Time {
+	mock.mut.RLock()
+	defer mock.mut.RUnlock()
+
+	return mock.now
+}
+
+// Advance moves the clock forward with the provided duration
+func (mock *ClockMock) Advance(duration time.Duration) {
+	mock.mut.Lock()
+	mock.now = mock.now.Add(duration)
+	mock.mut.Unlock()
+}
+
+// IsInterfaceNil -
+func (mock *ClockMock) IsInterfaceNil() bool {
+	return mock == nil
+}
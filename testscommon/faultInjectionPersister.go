@@ -0,0 +1,257 @@
+package testscommon
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ErrInjectedFault is the default error returned by the FaultInjectionPersister
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultOperation names an operation of the FaultInjectionPersister
+type FaultOperation string
+
+const (
+	// FaultPut is the Put operation
+	FaultPut FaultOperation = "Put"
+	// FaultMultiPut is the MultiPut operation
+	FaultMultiPut FaultOperation = "MultiPut"
+	// FaultGet is the Get operation
+	FaultGet FaultOperation = "Get"
+	// FaultHas is the Has operation
+	FaultHas FaultOperation = "Has"
+	// FaultRemove is the Remove operation
+	FaultRemove FaultOperation = "Remove"
+)
+
+// LatencyDistribution returns the delay added to an operation, drawn with the provided random source
+type LatencyDistribution func(rnd *rand.Rand) time.Duration
+
+// FixedLatency always delays the operations with the provided duration
+func FixedLatency(latency time.Duration) LatencyDistribution {
+	return func(_ *rand.Rand) time.Duration {
+		return latency
+	}
+}
+
+// UniformLatency delays the operations with a duration uniformly distributed in [min, max)
+func UniformLatency(min time.Duration, max time.Duration) LatencyDistribution {
+	return func(rnd *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+
+		return min + time.Duration(rnd.Int63n(int64(max-min)))
+	}
+}
+
+// ExponentialLatency delays the operations with an exponentially distributed duration of the provided mean, so that
+// most operations are fast and a few are slow
+func ExponentialLatency(mean time.Duration) LatencyDistribution {
+	return func(rnd *rand.Rand) time.Duration {
+		return time.Duration(rnd.ExpFloat64() * float64(mean))
+	}
+}
+
+// FaultConfig describes the failures injected by the FaultInjectionPersister
+type FaultConfig struct {
+	// ErrorRates holds, for each operation, the probability in [0, 1] of a call failing with Err
+	ErrorRates map[FaultOperation]float64
+	// Err is the injected error, ErrInjectedFault if not set
+	Err error
+	// Latencies holds, for each operation, the distribution of the delay added to each call
+	Latencies map[FaultOperation]LatencyDistribution
+	// CloseAfterOps, if positive, makes all the operations fail with common.ErrDBIsClosed once that many operations
+	// were served, as if the persister was closed underneath
+	CloseAfterOps int
+	// MultiPutFailAfter, if positive, makes MultiPut write only that many pairs, in ascending order of the keys, then
+	// fail with Err, as a partially applied batch
+	MultiPutFailAfter int
+	// Seed initializes the random source, so that the failures are reproducible
+	Seed int64
+}
+
+// FaultInjectionPersister wraps a persister, injecting the configured failures and latencies in its operations
+type FaultInjectionPersister struct {
+	persister types.Persister
+
+	mut               sync.Mutex
+	config            FaultConfig
+	rnd               *rand.Rand
+	numOps            int
+	numInjectedFaults int
+}
+
+// NewFaultInjectionPersister wraps the provided persister in a FaultInjectionPersister
+func NewFaultInjectionPersister(persister types.Persister, config FaultConfig) *FaultInjectionPersister {
+	fip := &FaultInjectionPersister{
+		persister: persister,
+		rnd:       rand.New(rand.NewSource(config.Seed)),
+	}
+	fip.SetConfig(config)
+
+	return fip
+}
+
+// SetConfig replaces the injected failures, e.g. to heal the persister, and resets the number of served operations
+func (fip *FaultInjectionPersister) SetConfig(config FaultConfig) {
+	if config.Err == nil {
+		config.Err = ErrInjectedFault
+	}
+
+	fip.mut.Lock()
+	fip.config = config
+	fip.numOps = 0
+	fip.mut.Unlock()
+}
+
+// NumInjectedFaults returns the number of the operations failed on purpose
+func (fip *FaultInjectionPersister) NumInjectedFaults() int {
+	fip.mut.Lock()
+	defer fip.mut.Unlock()
+
+	return fip.numInjectedFaults
+}
+
+// inject counts the operation, delays it, then returns the error it should fail with, if any
+func (fip *FaultInjectionPersister) inject(operation FaultOperation) error {
+	fip.mut.Lock()
+	fip.numOps++
+	var latency time.Duration
+	distribution, ok := fip.config.Latencies[operation]
+	if ok {
+		latency = distribution(fip.rnd)
+	}
+	err := fip.decideErrorNoLock(operation)
+	if err != nil {
+		fip.numInjectedFaults++
+	}
+	fip.mut.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	return err
+}
+
+func (fip *FaultInjectionPersister) decideErrorNoLock(operation FaultOperation) error {
+	if fip.config.CloseAfterOps > 0 && fip.numOps > fip.config.CloseAfterOps {
+		return fmt.Errorf("%w: injected after %d operations", common.ErrDBIsClosed, fip.config.CloseAfterOps)
+	}
+
+	rate := fip.config.ErrorRates[operation]
+	if rate > 0 && fip.rnd.Float64() < rate {
+		return fmt.Errorf("%w on %s", fip.config.Err, operation)
+	}
+
+	return nil
+}
+
+// Put adds the value to the wrapped persister, unless a failure is injected
+func (fip *FaultInjectionPersister) Put(key, val []byte) error {
+	err := fip.inject(FaultPut)
+	if err != nil {
+		return err
+	}
+
+	return fip.persister.Put(key, val)
+}
+
+// MultiPut adds the values to the wrapped persister one by one, in ascending order of the keys. If so configured, only
+// the first pairs are written before failing
+func (fip *FaultInjectionPersister) MultiPut(data map[string][]byte) error {
+	err := fip.inject(FaultMultiPut)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fip.mut.Lock()
+	failAfter, errFail := fip.config.MultiPutFailAfter, fip.config.Err
+	fip.mut.Unlock()
+
+	for i, key := range keys {
+		if failAfter > 0 && i == failAfter {
+			fip.mut.Lock()
+			fip.numInjectedFaults++
+			fip.mut.Unlock()
+
+			return fmt.Errorf("%w on %s after %d of %d pairs", errFail, FaultMultiPut, i, len(keys))
+		}
+
+		err = fip.persister.Put([]byte(key), data[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get gets the value associated to the key from the wrapped persister, unless a failure is injected
+func (fip *FaultInjectionPersister) Get(key []byte) ([]byte, error) {
+	err := fip.inject(FaultGet)
+	if err != nil {
+		return nil, err
+	}
+
+	return fip.persister.Get(key)
+}
+
+// Has checks the key in the wrapped persister, unless a failure is injected
+func (fip *FaultInjectionPersister) Has(key []byte) error {
+	err := fip.inject(FaultHas)
+	if err != nil {
+		return err
+	}
+
+	return fip.persister.Has(key)
+}
+
+// Remove removes the key from the wrapped persister, unless a failure is injected
+func (fip *FaultInjectionPersister) Remove(key []byte) error {
+	err := fip.inject(FaultRemove)
+	if err != nil {
+		return err
+	}
+
+	return fip.persister.Remove(key)
+}
+
+// Close closes the wrapped persister
+func (fip *FaultInjectionPersister) Close() error {
+	return fip.persister.Close()
+}
+
+// Destroy destroys the wrapped persister
+func (fip *FaultInjectionPersister) Destroy() error {
+	return fip.persister.Destroy()
+}
+
+// DestroyClosed destroys the wrapped, already closed, persister
+func (fip *FaultInjectionPersister) DestroyClosed() error {
+	return fip.persister.DestroyClosed()
+}
+
+// RangeKeys iterates over the pairs of the wrapped persister
+func (fip *FaultInjectionPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	fip.persister.RangeKeys(handler)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (fip *FaultInjectionPersister) IsInterfaceNil() bool {
+	return fip == nil
+}
@@ -0,0 +1,65 @@
+package testscommon
+
+import logger "github.com/TerraDharitri/drt-go-chain-logger"
+
+// LoggerStub -
+type LoggerStub struct {
+	LogCalled func(logLevel logger.LogLevel, message string, args ...interface{})
+}
+
+// Trace -
+func (stub *LoggerStub) Trace(message string, args ...interface{}) {
+	stub.Log(logger.LogTrace, message, args...)
+}
+
+// Debug -
+func (stub *LoggerStub) Debug(message string, args ...interface{}) {
+	stub.Log(logger.LogDebug, message, args...)
+}
+
+// Info -
+func (stub *LoggerStub) Info(message string, args ...interface{}) {
+	stub.Log(logger.LogInfo, message, args...)
+}
+
+// Warn -
+func (stub *LoggerStub) Warn(message string, args ...interface{}) {
+	stub.Log(logger.LogWarning, message, args...)
+}
+
+// Error -
+func (stub *LoggerStub) Error(message string, args ...interface{}) {
+	stub.Log(logger.LogError, message, args...)
+}
+
+// LogIfError -
+func (stub *LoggerStub) LogIfError(err error, args ...interface{}) {
+	if err != nil {
+		stub.Log(logger.LogError, err.Error(), args...)
+	}
+}
+
+// Log -
+func (stub *LoggerStub) Log(logLevel logger.LogLevel, message string, args ...interface{}) {
+	if stub.LogCalled != nil {
+		stub.LogCalled(logLevel, message, args...)
+	}
+}
+
+// LogLine -
+func (stub *LoggerStub) LogLine(_ *logger.LogLine) {
+}
+
+// SetLevel -
+func (stub *LoggerStub) SetLevel(_ logger.LogLevel) {
+}
+
+// GetLevel -
+func (stub *LoggerStub) GetLevel() logger.LogLevel {
+	return logger.LogTrace
+}
+
+// IsInterfaceNil -
+func (stub *LoggerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
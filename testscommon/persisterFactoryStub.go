@@ -0,0 +1,22 @@
+package testscommon
+
+import "github.com/TerraDharitri/drt-go-chain-storage/types"
+
+// PersisterFactoryStub -
+type PersisterFactoryStub struct {
+	CreateCalled func(path string) (types.Persister, error)
+}
+
+// Create -
+func (stub *PersisterFactoryStub) Create(path string) (types.Persister, error) {
+	if stub.CreateCalled != nil {
+		return stub.CreateCalled(path)
+	}
+
+	return NewMemDbMock(), nil
+}
+
+// IsInterfaceNil -
+func (stub *PersisterFactoryStub) IsInterfaceNil() bool {
+	return stub == nil
+}
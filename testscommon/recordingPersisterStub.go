@@ -0,0 +1,270 @@
+package testscommon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// ErrCallOrderViolated signals that the calls recorded by the RecordingPersisterStub are not in the expected order
+var ErrCallOrderViolated = errors.New("call order violated")
+
+// RecordedCall is a call served by the RecordingPersisterStub
+type RecordedCall struct {
+	// Seq is the position of the call, starting at 0, as the timestamps of the calls close in time might be equal
+	Seq       int
+	Method    string
+	Keys      [][]byte
+	Timestamp time.Time
+	Err       error
+}
+
+// String returns the method along with the keys, e.g. Put(key)
+func (call RecordedCall) String() string {
+	keys := make([]string, 0, len(call.Keys))
+	for _, key := range call.Keys {
+		keys = append(keys, string(key))
+	}
+
+	return fmt.Sprintf("%s(%s)", call.Method, strings.Join(keys, ", "))
+}
+
+// CallMatcher selects the recorded calls an assertion is about
+type CallMatcher func(call RecordedCall) bool
+
+// CallTo matches the calls of the method, e.g. CallTo("Close")
+func CallTo(method string) CallMatcher {
+	return func(call RecordedCall) bool {
+		return call.Method == method
+	}
+}
+
+// CallWithKey matches the calls of the method having the key among their keys, e.g. CallWithKey("Put", []byte("x"))
+func CallWithKey(method string, key []byte) CallMatcher {
+	return func(call RecordedCall) bool {
+		if call.Method != method {
+			return false
+		}
+		for _, callKey := range call.Keys {
+			if bytes.Equal(callKey, key) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// WriteCall matches the calls altering the stored data: Put, MultiPut and Remove
+func WriteCall() CallMatcher {
+	return func(call RecordedCall) bool {
+		return call.Method == "Put" || call.Method == "MultiPut" || call.Method == "Remove"
+	}
+}
+
+// RecordingPersisterStub wraps a persister, recording all the calls once served by the wrapped persister, with their
+// keys, errors and timestamps, so that the tests can assert on their order, e.g. that a Put happened before Close, or
+// that nothing was written after Destroy
+type RecordingPersisterStub struct {
+	persister types.Persister
+
+	mut   sync.Mutex
+	calls []RecordedCall
+}
+
+// NewRecordingPersisterStub wraps the provided persister in a RecordingPersisterStub, or an in memory one if nil
+func NewRecordingPersisterStub(persister types.Persister) *RecordingPersisterStub {
+	if persister == nil || persister.IsInterfaceNil() {
+		persister = NewMemDbMock()
+	}
+
+	return &RecordingPersisterStub{
+		persister: persister,
+	}
+}
+
+func (stub *RecordingPersisterStub) record(method string, err error, keys ...[]byte) {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	stub.calls = append(stub.calls, RecordedCall{
+		Seq:       len(stub.calls),
+		Method:    method,
+		Keys:      keys,
+		Timestamp: time.Now(),
+		Err:       err,
+	})
+}
+
+// Calls returns a copy of the recorded calls, in the order they were received
+func (stub *RecordingPersisterStub) Calls() []RecordedCall {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	return append([]RecordedCall(nil), stub.calls...)
+}
+
+// CallsMatching returns the recorded calls selected by the matcher
+func (stub *RecordingPersisterStub) CallsMatching(matcher CallMatcher) []RecordedCall {
+	matching := make([]RecordedCall, 0)
+	for _, call := range stub.Calls() {
+		if matcher(call) {
+			matching = append(matching, call)
+		}
+	}
+
+	return matching
+}
+
+// Reset forgets the recorded calls
+func (stub *RecordingPersisterStub) Reset() {
+	stub.mut.Lock()
+	stub.calls = nil
+	stub.mut.Unlock()
+}
+
+// AssertHappenedBefore returns nil if there are calls matching both matchers and all the calls matching the first one
+// were received before the first call matching the second one, e.g. Put(x) happened before Close
+func (stub *RecordingPersisterStub) AssertHappenedBefore(first CallMatcher, second CallMatcher) error {
+	firstCalls := stub.CallsMatching(first)
+	secondCalls := stub.CallsMatching(second)
+	if len(firstCalls) == 0 {
+		return fmt.Errorf("%w: the first call was not received", ErrCallOrderViolated)
+	}
+	if len(secondCalls) == 0 {
+		return fmt.Errorf("%w: the second call was not received", ErrCallOrderViolated)
+	}
+
+	lastFirst := firstCalls[len(firstCalls)-1]
+	if lastFirst.Seq > secondCalls[0].Seq {
+		return fmt.Errorf("%w: %s was received after %s", ErrCallOrderViolated, lastFirst, secondCalls[0])
+	}
+
+	return nil
+}
+
+// AssertNoCallsAfter returns nil if no call selected by the matcher was received after the first call selected by
+// after, e.g. no writes after Destroy. The assertion holds as well if there is no call selected by after
+func (stub *RecordingPersisterStub) AssertNoCallsAfter(matcher CallMatcher, after CallMatcher) error {
+	afterCalls := stub.CallsMatching(after)
+	if len(afterCalls) == 0 {
+		return nil
+	}
+
+	for _, call := range stub.CallsMatching(matcher) {
+		if call.Seq > afterCalls[0].Seq {
+			return fmt.Errorf("%w: %s was received after %s", ErrCallOrderViolated, call, afterCalls[0])
+		}
+	}
+
+	return nil
+}
+
+// Put adds the value to the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Put(key, val []byte) error {
+	err := stub.persister.Put(key, val)
+	stub.record("Put", err, key)
+
+	return err
+}
+
+// MultiPut adds the values to the wrapped persister, in one go if it is a MultiPutter, one by one otherwise, then records
+// the call, with the keys in no particular order
+func (stub *RecordingPersisterStub) MultiPut(data map[string][]byte) error {
+	keys := make([][]byte, 0, len(data))
+	for key := range data {
+		keys = append(keys, []byte(key))
+	}
+
+	var err error
+	multiPutter, ok := stub.persister.(types.MultiPutter)
+	if ok {
+		err = multiPutter.MultiPut(data)
+	} else {
+		for key, val := range data {
+			err = stub.persister.Put([]byte(key), val)
+			if err != nil {
+				break
+			}
+		}
+	}
+	stub.record("MultiPut", err, keys...)
+
+	return err
+}
+
+// Get gets the value from the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Get(key []byte) ([]byte, error) {
+	val, err := stub.persister.Get(key)
+	stub.record("Get", err, key)
+
+	return val, err
+}
+
+// Has checks the key in the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Has(key []byte) error {
+	err := stub.persister.Has(key)
+	stub.record("Has", err, key)
+
+	return err
+}
+
+// Remove removes the key from the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Remove(key []byte) error {
+	err := stub.persister.Remove(key)
+	stub.record("Remove", err, key)
+
+	return err
+}
+
+// Flush flushes the wrapped persister, if it is a Flusher, then records the call
+func (stub *RecordingPersisterStub) Flush() error {
+	var err error
+	flusher, ok := stub.persister.(types.Flusher)
+	if ok {
+		err = flusher.Flush()
+	}
+	stub.record("Flush", err)
+
+	return err
+}
+
+// Close closes the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Close() error {
+	err := stub.persister.Close()
+	stub.record("Close", err)
+
+	return err
+}
+
+// Destroy destroys the wrapped persister, then records the call
+func (stub *RecordingPersisterStub) Destroy() error {
+	err := stub.persister.Destroy()
+	stub.record("Destroy", err)
+
+	return err
+}
+
+// DestroyClosed destroys the wrapped, already closed, persister, then records the call
+func (stub *RecordingPersisterStub) DestroyClosed() error {
+	err := stub.persister.DestroyClosed()
+	stub.record("DestroyClosed", err)
+
+	return err
+}
+
+// RangeKeys records the call, then iterates over the pairs of the wrapped persister
+func (stub *RecordingPersisterStub) RangeKeys(handler func(key []byte, val []byte) bool) {
+	stub.record("RangeKeys", nil)
+	stub.persister.RangeKeys(handler)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (stub *RecordingPersisterStub) IsInterfaceNil() bool {
+	return stub == nil
+}
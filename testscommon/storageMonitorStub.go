@@ -0,0 +1,26 @@
+package testscommon
+
+// StorageMonitorStub -
+type StorageMonitorStub struct {
+	MonitorNewCacheCalled func(name string, sizeInBytes uint64)
+	MonitorNewDBCalled    func(dbType string, path string)
+}
+
+// MonitorNewCache -
+func (stub *StorageMonitorStub) MonitorNewCache(name string, sizeInBytes uint64) {
+	if stub.MonitorNewCacheCalled != nil {
+		stub.MonitorNewCacheCalled(name, sizeInBytes)
+	}
+}
+
+// MonitorNewDB -
+func (stub *StorageMonitorStub) MonitorNewDB(dbType string, path string) {
+	if stub.MonitorNewDBCalled != nil {
+		stub.MonitorNewDBCalled(dbType, path)
+	}
+}
+
+// IsInterfaceNil -
+func (stub *StorageMonitorStub) IsInterfaceNil() bool {
+	return stub == nil
+}
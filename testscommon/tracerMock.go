@@ -0,0 +1,85 @@
+package testscommon
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// RecordedSpan -
+type RecordedSpan struct {
+	noop.Span
+	mut        sync.Mutex
+	Name       string
+	Attributes map[attribute.Key]attribute.Value
+	Errors     []error
+	Ended      bool
+}
+
+// IsRecording -
+func (span *RecordedSpan) IsRecording() bool {
+	return true
+}
+
+// SetAttributes -
+func (span *RecordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	span.mut.Lock()
+	defer span.mut.Unlock()
+
+	for _, attr := range kv {
+		span.Attributes[attr.Key] = attr.Value
+	}
+}
+
+// RecordError -
+func (span *RecordedSpan) RecordError(err error, _ ...trace.EventOption) {
+	span.mut.Lock()
+	span.Errors = append(span.Errors, err)
+	span.mut.Unlock()
+}
+
+// SetStatus -
+func (span *RecordedSpan) SetStatus(_ codes.Code, _ string) {
+}
+
+// End -
+func (span *RecordedSpan) End(_ ...trace.SpanEndOption) {
+	span.mut.Lock()
+	span.Ended = true
+	span.mut.Unlock()
+}
+
+// TracerMock records the started spans
+type TracerMock struct {
+	noop.Tracer
+	mut   sync.Mutex
+	spans []*RecordedSpan
+}
+
+// Start -
+func (mock *TracerMock) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	span := &RecordedSpan{
+		Name:       spanName,
+		Attributes: make(map[attribute.Key]attribute.Value),
+	}
+	span.SetAttributes(config.Attributes()...)
+
+	mock.mut.Lock()
+	mock.spans = append(mock.spans, span)
+	mock.mut.Unlock()
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// Spans -
+func (mock *TracerMock) Spans() []*RecordedSpan {
+	mock.mut.Lock()
+	defer mock.mut.Unlock()
+
+	return append([]*RecordedSpan(nil), mock.spans...)
+}
@@ -0,0 +1,137 @@
+package trieFactory
+
+import (
+	"sync/atomic"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+// SerializedStoredData is a stored data type holding its serialized form, so that it is not marshalled
+type SerializedStoredData struct {
+	Serialized []byte
+}
+
+// GetSerialized -
+func (s *SerializedStoredData) GetSerialized() []byte {
+	return s.Serialized
+}
+
+// SetSerialized -
+func (s *SerializedStoredData) SetSerialized(bytes []byte) {
+	s.Serialized = bytes
+}
+
+type serializedDataFactory struct {
+}
+
+// NewSerializedDataFactory creates a factory of SerializedStoredData, which, unlike the trie node stub, keeps the bytes
+func NewSerializedDataFactory() *serializedDataFactory {
+	return &serializedDataFactory{}
+}
+
+// CreateEmpty returns an empty SerializedStoredData
+func (sdf *serializedDataFactory) CreateEmpty() interface{} {
+	return &SerializedStoredData{}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sdf *serializedDataFactory) IsInterfaceNil() bool {
+	return sdf == nil
+}
+
+// AccountData is a stored data type marshalled with the marshalizer, as it does not hold its serialized form
+type AccountData struct {
+	Nonce   uint64
+	Balance uint64
+}
+
+type accountDataFactory struct {
+}
+
+// NewAccountDataFactory creates a factory of AccountData
+func NewAccountDataFactory() *accountDataFactory {
+	return &accountDataFactory{}
+}
+
+// CreateEmpty returns an empty AccountData
+func (adf *accountDataFactory) CreateEmpty() interface{} {
+	return &AccountData{}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (adf *accountDataFactory) IsInterfaceNil() bool {
+	return adf == nil
+}
+
+// VersionedPayload is a marshalled stored data type carrying the version of its format. The payloads written before
+// the versioning have the version 0, as the field is missing from their serialized form
+type VersionedPayload struct {
+	Version uint32
+	Payload []byte
+	// CurrentVersion is the version of the factory which created the object, not being stored
+	CurrentVersion uint32 `json:"-"`
+}
+
+// IsOutdated returns true if the payload was stored with an older format, so that it should be migrated
+func (vp *VersionedPayload) IsOutdated() bool {
+	return vp.Version < vp.CurrentVersion
+}
+
+// Migrate brings the payload to the current version of its format
+func (vp *VersionedPayload) Migrate() {
+	vp.Version = vp.CurrentVersion
+}
+
+type versionedPayloadFactory struct {
+	currentVersion uint32
+}
+
+// NewVersionedPayloadFactory creates a factory of VersionedPayload, currentVersion being the version of the format
+// written by now, so that the payloads loaded from the storage report if they are outdated
+func NewVersionedPayloadFactory(currentVersion uint32) *versionedPayloadFactory {
+	return &versionedPayloadFactory{
+		currentVersion: currentVersion,
+	}
+}
+
+// CreateEmpty returns an empty VersionedPayload, of the current version
+func (vpf *versionedPayloadFactory) CreateEmpty() interface{} {
+	return &VersionedPayload{
+		CurrentVersion: vpf.currentVersion,
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (vpf *versionedPayloadFactory) IsInterfaceNil() bool {
+	return vpf == nil
+}
+
+// CountingStoredDataFactory wraps a factory, counting the created objects, so that the tests can check which factory
+// the objects were routed to
+type CountingStoredDataFactory struct {
+	factory    types.StoredDataFactory
+	numCreated atomic.Int64
+}
+
+// NewCountingStoredDataFactory wraps the provided factory in a CountingStoredDataFactory
+func NewCountingStoredDataFactory(factory types.StoredDataFactory) *CountingStoredDataFactory {
+	return &CountingStoredDataFactory{
+		factory: factory,
+	}
+}
+
+// CreateEmpty counts the call, then returns an empty object created by the wrapped factory
+func (csdf *CountingStoredDataFactory) CreateEmpty() interface{} {
+	csdf.numCreated.Add(1)
+	return csdf.factory.CreateEmpty()
+}
+
+// NumCreated returns the number of the created objects
+func (csdf *CountingStoredDataFactory) NumCreated() int {
+	return int(csdf.numCreated.Load())
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (csdf *CountingStoredDataFactory) IsInterfaceNil() bool {
+	return csdf == nil
+}
@@ -7,14 +7,55 @@ import (
 	"github.com/TerraDharitri/drt-go-chain-core/data"
 )
 
+// FeeModel selects how the MempoolHostMock computes the fees
+type FeeModel string
+
+const (
+	// MovementAndProcessingFeeModel charges the gas for the movement at the gas price, and the rest of the gas limit
+	// at the gas price lowered by the gas price modifier, as the protocol does
+	MovementAndProcessingFeeModel FeeModel = "movementAndProcessing"
+	// FullGasPriceFeeModel charges the whole gas limit at the gas price
+	FullGasPriceFeeModel FeeModel = "fullGasPrice"
+	// FixedFeeModel charges the same fee for all the transactions
+	FixedFeeModel FeeModel = "fixed"
+)
+
+// MempoolHostConfig declares the fees computed by the MempoolHostMock, so that the tests of the guarded and the relayed
+// transactions, or of other fee models, do not need bespoke mocks
+type MempoolHostConfig struct {
+	MinGasLimit             uint64
+	MinGasPrice             uint64
+	GasPerDataByte          uint64
+	GasPriceModifier        float64
+	ExtraGasLimitForGuarded uint64
+	ExtraGasLimitForRelayed uint64
+	// FeeModel is the model of the fees, MovementAndProcessingFeeModel if not set
+	FeeModel FeeModel
+	// FixedFee is the fee of each transaction, for the FixedFeeModel
+	FixedFee *big.Int
+	// ExtraFeeForRelayed is added to the fee of the relayed transactions, paid by their relayers
+	ExtraFeeForRelayed *big.Int
+	// AllowInsufficientGasLimit, if set, charges the gas limit of the transactions not covering the gas for the
+	// movement, instead of panicking
+	AllowInsufficientGasLimit bool
+}
+
+// DefaultMempoolHostConfig returns the config of the mock created by NewMempoolHostMock
+func DefaultMempoolHostConfig() MempoolHostConfig {
+	return MempoolHostConfig{
+		MinGasLimit:             50_000,
+		MinGasPrice:             1_000_000_000,
+		GasPerDataByte:          1500,
+		GasPriceModifier:        0.01,
+		ExtraGasLimitForGuarded: 50_000,
+		ExtraGasLimitForRelayed: 50_000,
+		FeeModel:                MovementAndProcessingFeeModel,
+	}
+}
+
 // MempoolHostMock -
 type MempoolHostMock struct {
-	minGasLimit             uint64
-	minGasPrice             uint64
-	gasPerDataByte          uint64
-	gasPriceModifier        float64
-	extraGasLimitForGuarded uint64
-	extraGasLimitForRelayed uint64
+	config MempoolHostConfig
 
 	ComputeTxFeeCalled        func(tx data.TransactionWithFeeHandler) *big.Int
 	GetTransferredValueCalled func(tx data.TransactionHandler) *big.Int
@@ -22,13 +63,17 @@ type MempoolHostMock struct {
 
 // NewMempoolHostMock -
 func NewMempoolHostMock() *MempoolHostMock {
+	return NewMempoolHostMockWithConfig(DefaultMempoolHostConfig())
+}
+
+// NewMempoolHostMockWithConfig -
+func NewMempoolHostMockWithConfig(config MempoolHostConfig) *MempoolHostMock {
+	if len(config.FeeModel) == 0 {
+		config.FeeModel = MovementAndProcessingFeeModel
+	}
+
 	return &MempoolHostMock{
-		minGasLimit:             50_000,
-		minGasPrice:             1_000_000_000,
-		gasPerDataByte:          1500,
-		gasPriceModifier:        0.01,
-		extraGasLimitForGuarded: 50_000,
-		extraGasLimitForRelayed: 50_000,
+		config: config,
 	}
 }
 
@@ -38,22 +83,48 @@ func (mock *MempoolHostMock) ComputeTxFee(tx data.TransactionWithFeeHandler) *bi
 		return mock.ComputeTxFeeCalled(tx)
 	}
 
+	fee := mock.computeFeeOfModel(tx)
+	if isRelayed(tx) && mock.config.ExtraFeeForRelayed != nil {
+		fee.Add(fee, mock.config.ExtraFeeForRelayed)
+	}
+
+	return fee
+}
+
+func (mock *MempoolHostMock) computeFeeOfModel(tx data.TransactionWithFeeHandler) *big.Int {
+	switch mock.config.FeeModel {
+	case FixedFeeModel:
+		if mock.config.FixedFee == nil {
+			return big.NewInt(0)
+		}
+		return big.NewInt(0).Set(mock.config.FixedFee)
+	case FullGasPriceFeeModel:
+		return core.SafeMul(tx.GetGasPrice(), tx.GetGasLimit())
+	default:
+		return mock.computeMovementAndProcessingFee(tx)
+	}
+}
+
+func (mock *MempoolHostMock) computeMovementAndProcessingFee(tx data.TransactionWithFeeHandler) *big.Int {
 	dataLength := uint64(len(tx.GetData()))
 	gasPriceForMovement := tx.GetGasPrice()
-	gasPriceForProcessing := uint64(float64(gasPriceForMovement) * mock.gasPriceModifier)
+	gasPriceForProcessing := uint64(float64(gasPriceForMovement) * mock.config.GasPriceModifier)
 
-	gasLimitForMovement := mock.minGasLimit + dataLength*mock.gasPerDataByte
+	gasLimitForMovement := mock.config.MinGasLimit + dataLength*mock.config.GasPerDataByte
 
-	if txAsGuarded, ok := tx.(data.GuardedTransactionHandler); ok && len(txAsGuarded.GetGuardianAddr()) > 0 {
-		gasLimitForMovement += mock.extraGasLimitForGuarded
+	if isGuarded(tx) {
+		gasLimitForMovement += mock.config.ExtraGasLimitForGuarded
 	}
 
-	if txAsRelayed, ok := tx.(data.RelayedTransactionHandler); ok && len(txAsRelayed.GetRelayerAddr()) > 0 {
-		gasLimitForMovement += mock.extraGasLimitForRelayed
+	if isRelayed(tx) {
+		gasLimitForMovement += mock.config.ExtraGasLimitForRelayed
 	}
 
 	if tx.GetGasLimit() < gasLimitForMovement {
-		panic("tx.GetGasLimit() < gasLimitForMovement")
+		if !mock.config.AllowInsufficientGasLimit {
+			panic("tx.GetGasLimit() < gasLimitForMovement")
+		}
+		gasLimitForMovement = tx.GetGasLimit()
 	}
 
 	gasLimitForProcessing := tx.GetGasLimit() - gasLimitForMovement
@@ -63,6 +134,16 @@ func (mock *MempoolHostMock) ComputeTxFee(tx data.TransactionWithFeeHandler) *bi
 	return fee
 }
 
+func isGuarded(tx interface{}) bool {
+	txAsGuarded, ok := tx.(data.GuardedTransactionHandler)
+	return ok && len(txAsGuarded.GetGuardianAddr()) > 0
+}
+
+func isRelayed(tx interface{}) bool {
+	txAsRelayed, ok := tx.(data.RelayedTransactionHandler)
+	return ok && len(txAsRelayed.GetRelayerAddr()) > 0
+}
+
 // GetTransferredValue -
 func (mock *MempoolHostMock) GetTransferredValue(tx data.TransactionHandler) *big.Int {
 	if mock.GetTransferredValueCalled != nil {
@@ -1,7 +1,9 @@
 package txcachemocks
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/TerraDharitri/drt-go-chain-core/data"
@@ -17,41 +19,146 @@ type SelectionSessionMock struct {
 	AccountStateByAddress      map[string]*types.AccountState
 	GetAccountStateCalled      func(address []byte) (*types.AccountState, error)
 	IsIncorrectlyGuardedCalled func(tx data.TransactionHandler) bool
+
+	// the virtual time, in rounds, along with the changes scheduled for the next rounds
+	currentRound     uint64
+	scheduledChanges []scheduledChange
+	// the nonces returned by the next calls of GetAccountState, for each address
+	nonceSequences map[string][]uint64
+	// the guardians of the guarded accounts, by address
+	guardians map[string][]byte
+}
+
+// scheduledChange is a change of an account state, applied once the virtual time reaches its round
+type scheduledChange struct {
+	round   uint64
+	address string
+	nonce   *uint64
+	balance *big.Int
 }
 
 // NewSelectionSessionMock -
 func NewSelectionSessionMock() *SelectionSessionMock {
 	return &SelectionSessionMock{
 		AccountStateByAddress: make(map[string]*types.AccountState),
+		nonceSequences:        make(map[string][]uint64),
+		guardians:             make(map[string][]byte),
 	}
 }
 
+// SetGuardian marks the account as guarded by the provided guardian, so that its transactions not co-signed by the
+// guardian are reported as incorrectly guarded
+func (mock *SelectionSessionMock) SetGuardian(address []byte, guardian []byte) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	mock.guardians[string(address)] = guardian
+}
+
 // SetNonce -
 func (mock *SelectionSessionMock) SetNonce(address []byte, nonce uint64) {
 	mock.mutex.Lock()
 	defer mock.mutex.Unlock()
 
-	key := string(address)
+	mock.accountStateNoLock(string(address)).Nonce = nonce
+}
 
+// SetBalance -
+func (mock *SelectionSessionMock) SetBalance(address []byte, balance *big.Int) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	mock.accountStateNoLock(string(address)).Balance = balance
+}
+
+func (mock *SelectionSessionMock) accountStateNoLock(key string) *types.AccountState {
 	if mock.AccountStateByAddress[key] == nil {
 		mock.AccountStateByAddress[key] = newDefaultAccountState()
 	}
 
-	mock.AccountStateByAddress[key].Nonce = nonce
+	return mock.AccountStateByAddress[key]
 }
 
-// SetBalance -
-func (mock *SelectionSessionMock) SetBalance(address []byte, balance *big.Int) {
+// ScheduleNonce sets the nonce of the account once the virtual time reaches the provided round
+func (mock *SelectionSessionMock) ScheduleNonce(round uint64, address []byte, nonce uint64) {
+	mock.schedule(scheduledChange{round: round, address: string(address), nonce: &nonce})
+}
+
+// ScheduleBalance sets the balance of the account once the virtual time reaches the provided round
+func (mock *SelectionSessionMock) ScheduleBalance(round uint64, address []byte, balance *big.Int) {
+	mock.schedule(scheduledChange{round: round, address: string(address), balance: balance})
+}
+
+func (mock *SelectionSessionMock) schedule(change scheduledChange) {
 	mock.mutex.Lock()
 	defer mock.mutex.Unlock()
 
-	key := string(address)
+	mock.scheduledChanges = append(mock.scheduledChanges, change)
+	mock.applyScheduledChangesNoLock()
+}
 
-	if mock.AccountStateByAddress[key] == nil {
-		mock.AccountStateByAddress[key] = newDefaultAccountState()
+// CurrentRound returns the virtual time, in rounds
+func (mock *SelectionSessionMock) CurrentRound() uint64 {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	return mock.currentRound
+}
+
+// AdvanceRounds moves the virtual time forward, applying the changes scheduled up to the new round, in the order of
+// their rounds, then of their scheduling
+func (mock *SelectionSessionMock) AdvanceRounds(numRounds uint64) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	mock.currentRound += numRounds
+	mock.applyScheduledChangesNoLock()
+}
+
+func (mock *SelectionSessionMock) applyScheduledChangesNoLock() {
+	sort.SliceStable(mock.scheduledChanges, func(i, j int) bool {
+		return mock.scheduledChanges[i].round < mock.scheduledChanges[j].round
+	})
+
+	numApplied := 0
+	for _, change := range mock.scheduledChanges {
+		if change.round > mock.currentRound {
+			break
+		}
+
+		state := mock.accountStateNoLock(change.address)
+		if change.nonce != nil {
+			state.Nonce = *change.nonce
+		}
+		if change.balance != nil {
+			state.Balance = change.balance
+		}
+		numApplied++
 	}
+	mock.scheduledChanges = mock.scheduledChanges[numApplied:]
+}
 
-	mock.AccountStateByAddress[key].Balance = balance
+// SetNonceSequence makes the next calls of GetAccountState for the account return the provided nonces, one per call,
+// the last one being kept afterwards
+func (mock *SelectionSessionMock) SetNonceSequence(address []byte, nonces ...uint64) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	mock.nonceSequences[string(address)] = append([]uint64(nil), nonces...)
+}
+
+// ExecuteTransactions emulates the execution of the provided transactions in a block: the nonce of each sender is
+// bumped past its highest executed nonce. The balances are left unchanged
+func (mock *SelectionSessionMock) ExecuteTransactions(txs ...data.TransactionHandler) {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+
+	for _, tx := range txs {
+		state := mock.accountStateNoLock(string(tx.GetSndAddr()))
+		if tx.GetNonce() >= state.Nonce {
+			state.Nonce = tx.GetNonce() + 1
+		}
+	}
 }
 
 // GetAccountState -
@@ -65,6 +172,14 @@ func (mock *SelectionSessionMock) GetAccountState(address []byte) (*types.Accoun
 		return mock.GetAccountStateCalled(address)
 	}
 
+	sequence := mock.nonceSequences[string(address)]
+	if len(sequence) > 0 {
+		mock.accountStateNoLock(string(address)).Nonce = sequence[0]
+		if len(sequence) > 1 {
+			mock.nonceSequences[string(address)] = sequence[1:]
+		}
+	}
+
 	state, ok := mock.AccountStateByAddress[string(address)]
 	if ok {
 		return state, nil
@@ -79,7 +194,15 @@ func (mock *SelectionSessionMock) IsIncorrectlyGuarded(tx data.TransactionHandle
 		return mock.IsIncorrectlyGuardedCalled(tx)
 	}
 
-	return false
+	mock.mutex.Lock()
+	guardian, isGuardedAccount := mock.guardians[string(tx.GetSndAddr())]
+	mock.mutex.Unlock()
+	if !isGuardedAccount {
+		return false
+	}
+
+	txAsGuarded, ok := tx.(data.GuardedTransactionHandler)
+	return !ok || !bytes.Equal(txAsGuarded.GetGuardianAddr(), guardian)
 }
 
 // IsInterfaceNil -
@@ -0,0 +1,159 @@
+package txcacheworkload
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+
+	"github.com/TerraDharitri/drt-go-chain-core/data/transaction"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// GasPriceDistribution selects how the gas prices of the generated transactions are spread between the bounds
+type GasPriceDistribution string
+
+const (
+	// UniformGasPrice spreads the gas prices uniformly between the bounds
+	UniformGasPrice GasPriceDistribution = "uniform"
+	// ZipfianGasPrice makes most transactions pay the minimum gas price, and a few pay more and more, up to the maximum
+	ZipfianGasPrice GasPriceDistribution = "zipfian"
+)
+
+// the gas needed to move a transaction, as computed by the mempool host mock
+const (
+	minGasLimit    = 50000
+	gasPerDataByte = 1500
+)
+
+// numZipfianLevels is the number of distinct gas prices drawn with the zipfian distribution
+const numZipfianLevels = 100
+
+// ArgsWorkload describes a workload of transactions
+type ArgsWorkload struct {
+	NumSenders      int
+	NumTxsPerSender int
+	// NonceGapProbability is the probability in [0, 1] of a transaction skipping one nonce after the previous one of
+	// its sender, so that the transactions following the gap are not executable
+	NonceGapProbability  float64
+	MinGasPrice          uint64
+	MaxGasPrice          uint64
+	GasPriceDistribution GasPriceDistribution
+	// GasLimit is the gas limit of each transaction. If not set, each transaction gets the gas needed to move it,
+	// 50000 plus 1500 per data byte
+	GasLimit    uint64
+	MinDataSize int
+	MaxDataSize int
+	// Seed initializes the random source, so that the same arguments always generate the same transactions
+	Seed int64
+}
+
+// Transaction is a generated transaction, along with its hash
+type Transaction struct {
+	Tx   *transaction.Transaction
+	Hash []byte
+}
+
+func (args ArgsWorkload) validate() error {
+	var validator common.ConfigValidator
+	validator.Check(args.NumSenders > 0, common.ErrInvalidConfig, "NumSenders should be positive")
+	validator.Check(args.NumTxsPerSender > 0, common.ErrInvalidConfig, "NumTxsPerSender should be positive")
+	validator.Check(args.NonceGapProbability >= 0 && args.NonceGapProbability <= 1, common.ErrInvalidConfig,
+		"NonceGapProbability should be in [0, 1]")
+	validator.Check(args.MinGasPrice <= args.MaxGasPrice, common.ErrInvalidConfig,
+		"MinGasPrice should not be greater than MaxGasPrice")
+	validator.Check(args.GasPriceDistribution == UniformGasPrice || args.GasPriceDistribution == ZipfianGasPrice,
+		common.ErrInvalidConfig, "unknown GasPriceDistribution %q", args.GasPriceDistribution)
+	validator.Check(args.MinDataSize >= 0 && args.MinDataSize <= args.MaxDataSize, common.ErrInvalidConfig,
+		"the data sizes should satisfy 0 <= MinDataSize <= MaxDataSize")
+
+	return validator.Err()
+}
+
+// Generate returns the transactions of the described workload, ordered by sender, then by nonce. The nonces of each
+// sender start at 0
+func Generate(args ArgsWorkload) ([]*Transaction, error) {
+	err := args.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w for the transactions workload", err)
+	}
+	rnd := rand.New(rand.NewSource(args.Seed))
+	gasPrice := newGasPriceGenerator(args, rnd)
+
+	txs := make([]*Transaction, 0, args.NumSenders*args.NumTxsPerSender)
+	for senderIndex := 0; senderIndex < args.NumSenders; senderIndex++ {
+		sender := SenderAddress(senderIndex)
+		nonce := uint64(0)
+
+		for i := 0; i < args.NumTxsPerSender; i++ {
+			if i > 0 && rnd.Float64() < args.NonceGapProbability {
+				nonce++
+			}
+
+			dataSize := args.MinDataSize + rnd.Intn(args.MaxDataSize-args.MinDataSize+1)
+			gasLimit := args.GasLimit
+			if gasLimit == 0 {
+				gasLimit = minGasLimit + uint64(dataSize)*gasPerDataByte
+			}
+			txs = append(txs, &Transaction{
+				Tx: &transaction.Transaction{
+					SndAddr:  sender,
+					Nonce:    nonce,
+					GasLimit: gasLimit,
+					GasPrice: gasPrice(),
+					Data:     make([]byte, dataSize),
+				},
+				Hash: TxHash(sender, nonce),
+			})
+			nonce++
+		}
+	}
+
+	return txs, nil
+}
+
+func newGasPriceGenerator(args ArgsWorkload, rnd *rand.Rand) func() uint64 {
+	priceRange := args.MaxGasPrice - args.MinGasPrice
+	if priceRange == 0 {
+		return func() uint64 {
+			return args.MinGasPrice
+		}
+	}
+
+	if args.GasPriceDistribution == ZipfianGasPrice {
+		zipf := rand.NewZipf(rnd, 1.1, 1, numZipfianLevels-1)
+		return func() uint64 {
+			return args.MinGasPrice + zipf.Uint64()*priceRange/(numZipfianLevels-1)
+		}
+	}
+
+	return func() uint64 {
+		return args.MinGasPrice + uint64(rnd.Int63n(int64(priceRange)+1))
+	}
+}
+
+// Shuffle randomly reorders the transactions in place, as received from the network, using the provided seed
+func Shuffle(txs []*Transaction, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	rnd.Shuffle(len(txs), func(i, j int) {
+		txs[i], txs[j] = txs[j], txs[i]
+	})
+}
+
+// SenderAddress returns the 32 bytes address of the sender with the provided index
+func SenderAddress(index int) []byte {
+	address := make([]byte, 32)
+	binary.BigEndian.PutUint64(address, uint64(index))
+	binary.BigEndian.PutUint64(address[24:], uint64(index))
+
+	return address
+}
+
+// TxHash returns the 32 bytes hash of the transaction of the sender having the provided nonce
+func TxHash(sender []byte, nonce uint64) []byte {
+	hash := make([]byte, 32)
+	copy(hash, sender[:8])
+	binary.BigEndian.PutUint64(hash[8:], nonce)
+	copy(hash[16:], sender[24:])
+
+	return hash
+}
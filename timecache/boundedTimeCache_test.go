@@ -0,0 +1,106 @@
+package timecache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTimeCacheWithCapacity(t *testing.T) {
+	t.Parallel()
+
+	tc, err := NewTimeCacheWithCapacity(time.Second, 0)
+	assert.True(t, check.IfNil(tc))
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+
+	tc, err = NewTimeCacheWithCapacity(time.Second, 10)
+	assert.False(t, check.IfNil(tc))
+	assert.Nil(t, err)
+}
+
+func TestTimeCacheWithCapacity_ShouldEvictEntriesClosestToExpiry(t *testing.T) {
+	t.Parallel()
+
+	tc, _ := NewTimeCacheWithCapacity(time.Hour, 3)
+	evicted := make([]string, 0)
+	tc.RegisterEvictionHandler(func(key string) {
+		evicted = append(evicted, key)
+	})
+
+	_ = tc.AddWithSpan("long", time.Hour*10)
+	_ = tc.AddWithSpan("short", time.Minute)
+	_ = tc.AddWithSpan("medium", time.Hour)
+	assert.Equal(t, 3, tc.Len())
+	assert.Empty(t, evicted)
+
+	_ = tc.Add("new")
+	assert.Equal(t, 3, tc.Len())
+	assert.Equal(t, []string{"short"}, evicted)
+	assert.False(t, tc.Has("short"))
+
+	// upserting with a larger span moves the key away from its expiry
+	_ = tc.Upsert("medium", time.Hour*20)
+	_ = tc.Put("another", "value", time.Hour*2)
+	assert.Equal(t, []string{"short", "new"}, evicted)
+	assert.True(t, tc.Has("medium"))
+	assert.True(t, tc.Has("long"))
+	assert.True(t, tc.Has("another"))
+}
+
+func TestTimeCacheWithCapacity_ReplacingKeysShouldNotEvict(t *testing.T) {
+	t.Parallel()
+
+	tc, _ := NewTimeCacheWithCapacity(time.Hour, 2)
+	_ = tc.Add("a")
+	_ = tc.Add("b")
+	_ = tc.Add("a")
+	_ = tc.Put("b", "value", time.Hour)
+	_ = tc.Upsert("a", time.Hour)
+
+	assert.Equal(t, 2, tc.Len())
+	assert.True(t, tc.Has("a"))
+	assert.True(t, tc.Has("b"))
+}
+
+func TestTimeCacheWithCapacity_SweepAndClearKeepTheHeapConsistent(t *testing.T) {
+	t.Parallel()
+
+	tc, _ := NewTimeCacheWithCapacity(time.Hour, 10)
+	for i := 0; i < 10; i++ {
+		_ = tc.AddWithSpan(fmt.Sprintf("expiring%d", i), time.Millisecond)
+	}
+	time.Sleep(time.Millisecond * 10)
+	tc.Sweep()
+	require.Equal(t, 0, tc.Len())
+	require.Equal(t, 0, tc.timeCache.expiryHeap.Len())
+
+	err := tc.UpsertMultiple([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}, time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, 10, tc.Len())
+	require.Equal(t, 10, tc.timeCache.expiryHeap.Len())
+
+	tc.timeCache.clear()
+	require.Equal(t, 0, tc.Len())
+	require.Equal(t, 0, tc.timeCache.expiryHeap.Len())
+}
+
+func TestTimeCacheWithCapacity_ChurnShouldNotGrowBeyondCapacity(t *testing.T) {
+	t.Parallel()
+
+	maxEntries := 100
+	tc, _ := NewTimeCacheWithCapacity(time.Hour, maxEntries)
+	for i := 0; i < 10000; i++ {
+		_ = tc.Upsert(fmt.Sprintf("peer%d", i), time.Hour)
+	}
+
+	assert.Equal(t, maxEntries, tc.Len())
+	assert.Equal(t, maxEntries, tc.timeCache.expiryHeap.Len())
+	// the most recent keys have the furthest expiry
+	assert.True(t, tc.Has("peer9999"))
+	assert.False(t, tc.Has("peer0"))
+}
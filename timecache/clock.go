@@ -0,0 +1,16 @@
+package timecache
+
+import "time"
+
+// systemClock is the default clock of the time caches, relying on the system time
+type systemClock struct{}
+
+// Now returns the current system time
+func (sc *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sc *systemClock) IsInterfaceNil() bool {
+	return sc == nil
+}
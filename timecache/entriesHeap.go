@@ -0,0 +1,41 @@
+package timecache
+
+// entriesHeap is a min-heap of entries, ordered by their expiry time. It is only maintained by the bounded time caches
+type entriesHeap struct {
+	items []*entry
+}
+
+// Len returns the number of elements in the heap.
+func (h *entriesHeap) Len() int { return len(h.items) }
+
+// Less reports whether the element with index i should sort before the element with index j.
+func (h *entriesHeap) Less(i, j int) bool {
+	return h.items[i].expiry().Before(h.items[j].expiry())
+}
+
+// Swap swaps the elements with indexes i and j.
+func (h *entriesHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].heapIndex = i
+	h.items[j].heapIndex = j
+}
+
+// Push pushes the element x onto the heap.
+func (h *entriesHeap) Push(x interface{}) {
+	item := x.(*entry)
+	item.heapIndex = len(h.items)
+	h.items = append(h.items, item)
+}
+
+// Pop removes and returns the minimum element (according to the expiry time) from the heap.
+func (h *entriesHeap) Pop() interface{} {
+	// Standard code when storing the heap in a slice:
+	// https://pkg.go.dev/container/heap
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.heapIndex = -1
+	h.items = old[0 : n-1]
+	return item
+}
@@ -25,8 +25,5 @@ func (tc *TimeCache) Value(key string) (*entry, bool) {
 
 // NumRegisteredHandlers -
 func (tc *timeCacher) NumRegisteredHandlers() int {
-	tc.mutAddedDataHandlers.RLock()
-	defer tc.mutAddedDataHandlers.RUnlock()
-
-	return len(tc.mapDataHandlers)
+	return len(tc.addedDataHandlers.HandlerIDs())
 }
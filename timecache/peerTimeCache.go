@@ -26,7 +26,7 @@ func NewPeerTimeCache(timeCache types.TimeCacher) (*peerTimeCache, error) {
 
 // Upsert will add the pid and provided duration if not exists
 // If the record exists, will update the duration if the provided duration is larger than existing
-// Also, it will reset the contained timestamp to time.Now
+// Also, it will reset the contained timestamp to the clock's current time
 func (ptc *peerTimeCache) Upsert(pid core.PeerID, duration time.Duration) error {
 	return ptc.timeCache.Upsert(string(pid), duration)
 }
@@ -0,0 +1,77 @@
+package timecache
+
+import (
+	"context"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.TimeCacher = (*SelfSweepingTimeCache)(nil)
+
+// ArgSelfSweepingTimeCache is the argument used to create a new SelfSweepingTimeCache instance
+type ArgSelfSweepingTimeCache struct {
+	DefaultSpan   time.Duration
+	SweepInterval time.Duration
+	// Clock decides the expiry of the keys. It is optional, the system clock being used if not provided
+	Clock types.Clock
+}
+
+// SelfSweepingTimeCache is a time cache which sweeps itself, on a dedicated go routine, at a fixed interval.
+// The go routine is stopped by calling Close
+type SelfSweepingTimeCache struct {
+	*TimeCache
+	sweepInterval time.Duration
+	cancelFunc    func()
+}
+
+// NewSelfSweepingTimeCache creates a new self sweeping time cache and starts its sweep go routine
+func NewSelfSweepingTimeCache(arg ArgSelfSweepingTimeCache) (*SelfSweepingTimeCache, error) {
+	if arg.DefaultSpan < minDuration {
+		return nil, common.ErrInvalidDefaultSpan
+	}
+	if arg.SweepInterval < minDuration {
+		return nil, common.ErrInvalidSweepInterval
+	}
+
+	sstc := &SelfSweepingTimeCache{
+		TimeCache:     &TimeCache{timeCache: newTimeCacheCore(arg.DefaultSpan, clockOrDefault(arg.Clock))},
+		sweepInterval: arg.SweepInterval,
+	}
+
+	var ctx context.Context
+	ctx, sstc.cancelFunc = context.WithCancel(context.Background())
+	go sstc.startSweeping(ctx)
+
+	return sstc, nil
+}
+
+func (sstc *SelfSweepingTimeCache) startSweeping(ctx context.Context) {
+	timer := time.NewTimer(sstc.sweepInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(sstc.sweepInterval)
+
+		select {
+		case <-timer.C:
+			sstc.Sweep()
+		case <-ctx.Done():
+			log.Debug("closing SelfSweepingTimeCache's sweep go routine...")
+			return
+		}
+	}
+}
+
+// Close stops the sweep go routine. It is safe to call it multiple times
+func (sstc *SelfSweepingTimeCache) Close() error {
+	sstc.cancelFunc()
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sstc *SelfSweepingTimeCache) IsInterfaceNil() bool {
+	return sstc == nil
+}
@@ -0,0 +1,88 @@
+package timecache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func createArgSelfSweepingTimeCache() ArgSelfSweepingTimeCache {
+	return ArgSelfSweepingTimeCache{
+		DefaultSpan:   time.Second,
+		SweepInterval: time.Second,
+	}
+}
+
+func TestNewSelfSweepingTimeCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid default span should error", func(t *testing.T) {
+		t.Parallel()
+
+		arg := createArgSelfSweepingTimeCache()
+		arg.DefaultSpan = time.Millisecond
+		sstc, err := NewSelfSweepingTimeCache(arg)
+		assert.True(t, check.IfNil(sstc))
+		assert.Equal(t, common.ErrInvalidDefaultSpan, err)
+	})
+	t.Run("invalid sweep interval should error", func(t *testing.T) {
+		t.Parallel()
+
+		arg := createArgSelfSweepingTimeCache()
+		arg.SweepInterval = time.Millisecond
+		sstc, err := NewSelfSweepingTimeCache(arg)
+		assert.True(t, check.IfNil(sstc))
+		assert.Equal(t, common.ErrInvalidSweepInterval, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		sstc, err := NewSelfSweepingTimeCache(createArgSelfSweepingTimeCache())
+		assert.False(t, check.IfNil(sstc))
+		assert.Nil(t, err)
+		assert.Nil(t, sstc.Close())
+	})
+}
+
+func TestSelfSweepingTimeCache_ShouldSweepPeriodically(t *testing.T) {
+	t.Parallel()
+
+	sstc, _ := NewSelfSweepingTimeCache(createArgSelfSweepingTimeCache())
+	defer func() {
+		_ = sstc.Close()
+	}()
+
+	chEvicted := make(chan string, 1)
+	sstc.RegisterEvictionHandler(func(key string) {
+		chEvicted <- key
+	})
+
+	_ = sstc.Add("expiring")
+	_ = sstc.AddWithSpan("long", time.Hour)
+
+	select {
+	case key := <-chEvicted:
+		assert.Equal(t, "expiring", key)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the time cache should have swept itself")
+	}
+
+	assert.False(t, sstc.Has("expiring"))
+	assert.True(t, sstc.Has("long"))
+}
+
+func TestSelfSweepingTimeCache_CloseShouldStopSweeping(t *testing.T) {
+	t.Parallel()
+
+	sstc, _ := NewSelfSweepingTimeCache(createArgSelfSweepingTimeCache())
+	assert.Nil(t, sstc.Close())
+	assert.Nil(t, sstc.Close())
+
+	_ = sstc.Add("key")
+	time.Sleep(3 * time.Second)
+
+	assert.True(t, sstc.Has("key"))
+}
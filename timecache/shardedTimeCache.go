@@ -0,0 +1,161 @@
+package timecache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.TimeCacher = (*ShardedTimeCache)(nil)
+
+// ShardedTimeCache is a time cache split in segments (by key hash), each one guarded by its own lock, so that
+// concurrent operations on different keys do not contend on a single mutex
+// This data structure is concurrent safe.
+type ShardedTimeCache struct {
+	shards []*TimeCache
+}
+
+// NewShardedTimeCache creates a new sharded time cache data structure instance
+func NewShardedTimeCache(defaultSpan time.Duration, numShards int) (*ShardedTimeCache, error) {
+	if numShards < 1 {
+		return nil, fmt.Errorf("%w: provided %d", common.ErrInvalidNumberOfShards, numShards)
+	}
+
+	shards := make([]*TimeCache, numShards)
+	for i := range shards {
+		shards[i] = NewTimeCache(defaultSpan)
+	}
+
+	return &ShardedTimeCache{
+		shards: shards,
+	}, nil
+}
+
+func (stc *ShardedTimeCache) getShard(key string) *TimeCache {
+	return stc.shards[stc.getShardIndex(key)]
+}
+
+func (stc *ShardedTimeCache) getShardIndex(key string) uint32 {
+	return fnv32(key) % uint32(len(stc.shards))
+}
+
+// fnv32 implements https://en.wikipedia.org/wiki/Fowler–Noll–Vo_hash_function for 32 bits
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+	const prime32 = uint32(16777619)
+	for i := 0; i < len(key); i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+	return hash
+}
+
+// Add will store the key in the time cache
+// Double adding the key is permitted. It will replace the data, if existing. It does not trigger sweep.
+func (stc *ShardedTimeCache) Add(key string) error {
+	return stc.getShard(key).Add(key)
+}
+
+// AddWithSpan will store the key in the time cache with the provided span duration
+// Double adding the key is permitted. It will replace the data, if existing. It does not trigger sweep.
+func (stc *ShardedTimeCache) AddWithSpan(key string, duration time.Duration) error {
+	return stc.getShard(key).AddWithSpan(key, duration)
+}
+
+// Upsert will add the key and provided duration if not exists
+// If the record exists, will update the duration if the provided duration is larger than existing
+// Also, it will reset the contained timestamp to the clock's current time
+func (stc *ShardedTimeCache) Upsert(key string, duration time.Duration) error {
+	return stc.getShard(key).Upsert(key, duration)
+}
+
+// UpsertWithRemaining works as Upsert, also returning the effective remaining duration of the key, which is the
+// larger of the provided and the existing spans
+func (stc *ShardedTimeCache) UpsertWithRemaining(key string, duration time.Duration) (time.Duration, error) {
+	return stc.getShard(key).UpsertWithRemaining(key, duration)
+}
+
+// UpsertMultiple will upsert all the provided keys, taking the lock of each segment only once
+// No key is added if any of them is empty
+func (stc *ShardedTimeCache) UpsertMultiple(keys []string, duration time.Duration) error {
+	keysPerShard := make([][]string, len(stc.shards))
+	for _, key := range keys {
+		if len(key) == 0 {
+			return common.ErrEmptyKey
+		}
+
+		shardIndex := stc.getShardIndex(key)
+		keysPerShard[shardIndex] = append(keysPerShard[shardIndex], key)
+	}
+
+	for i, shardKeys := range keysPerShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+
+		err := stc.shards[i].UpsertMultiple(shardKeys, duration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Put will store the key along with the provided value, for the provided ttl
+// Double putting the key is permitted. It will replace the data, if existing. It does not trigger sweep.
+func (stc *ShardedTimeCache) Put(key string, value interface{}, ttl time.Duration) error {
+	return stc.getShard(key).Put(key, value, ttl)
+}
+
+// Get returns the value stored for the key. Keys whose ttl has elapsed are not returned, even if not yet swept
+func (stc *ShardedTimeCache) Get(key string) (interface{}, bool) {
+	return stc.getShard(key).Get(key)
+}
+
+// Sweep sweeps each segment in turn, so at most one segment is locked at a time
+func (stc *ShardedTimeCache) Sweep() {
+	for _, shard := range stc.shards {
+		shard.Sweep()
+	}
+}
+
+// RegisterEvictionHandler registers a handler to be called for each expired key removed by Sweep
+func (stc *ShardedTimeCache) RegisterEvictionHandler(handler func(key string)) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a time cache object")
+		return
+	}
+
+	for _, shard := range stc.shards {
+		shard.RegisterEvictionHandler(handler)
+	}
+}
+
+// TTL returns the duration left until the key expires. It returns false if the key is not found or expired, even if
+// not yet swept
+func (stc *ShardedTimeCache) TTL(key string) (time.Duration, bool) {
+	return stc.getShard(key).TTL(key)
+}
+
+// Has returns if the key is still found in the time cache
+func (stc *ShardedTimeCache) Has(key string) bool {
+	return stc.getShard(key).Has(key)
+}
+
+// Len returns the number of elements which are still stored in the time cache
+func (stc *ShardedTimeCache) Len() int {
+	numElements := 0
+	for _, shard := range stc.shards {
+		numElements += shard.Len()
+	}
+
+	return numElements
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (stc *ShardedTimeCache) IsInterfaceNil() bool {
+	return stc == nil
+}
@@ -0,0 +1,206 @@
+package timecache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardedTimeCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of shards should error", func(t *testing.T) {
+		t.Parallel()
+
+		stc, err := NewShardedTimeCache(time.Second, 0)
+		assert.True(t, check.IfNil(stc))
+		assert.True(t, errors.Is(err, common.ErrInvalidNumberOfShards))
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		stc, err := NewShardedTimeCache(time.Second, 4)
+		assert.False(t, check.IfNil(stc))
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(stc.shards))
+	})
+}
+
+func TestShardedTimeCache_EmptyKeyShouldErr(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 4)
+
+	assert.Equal(t, common.ErrEmptyKey, stc.Add(""))
+	assert.Equal(t, common.ErrEmptyKey, stc.AddWithSpan("", time.Second))
+	assert.Equal(t, common.ErrEmptyKey, stc.Upsert("", time.Second))
+	assert.Equal(t, 0, stc.Len())
+}
+
+func TestShardedTimeCache_AddHasLen(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 4)
+	numKeys := 100
+	for i := 0; i < numKeys; i++ {
+		err := stc.Add(fmt.Sprintf("key%d", i))
+		require.Nil(t, err)
+	}
+
+	assert.Equal(t, numKeys, stc.Len())
+	for i := 0; i < numKeys; i++ {
+		assert.True(t, stc.Has(fmt.Sprintf("key%d", i)))
+	}
+	assert.False(t, stc.Has("missing"))
+
+	numNonEmptyShards := 0
+	for _, shard := range stc.shards {
+		if shard.Len() > 0 {
+			numNonEmptyShards++
+		}
+	}
+	assert.Equal(t, 4, numNonEmptyShards)
+}
+
+func TestShardedTimeCache_UpsertMultiple(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 4)
+	err := stc.UpsertMultiple([]string{"key1", ""}, time.Second)
+	assert.Equal(t, common.ErrEmptyKey, err)
+	assert.Equal(t, 0, stc.Len())
+
+	keys := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	err = stc.UpsertMultiple(keys, time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 50, stc.Len())
+	for _, key := range keys {
+		assert.True(t, stc.Has(key))
+	}
+}
+
+func TestShardedTimeCache_PutGet(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 4)
+	for i := 0; i < 20; i++ {
+		err := stc.Put(fmt.Sprintf("key%d", i), i, time.Hour)
+		require.Nil(t, err)
+	}
+	_ = stc.Put("expired", "value", time.Millisecond)
+	time.Sleep(time.Millisecond * 10)
+
+	for i := 0; i < 20; i++ {
+		value, ok := stc.Get(fmt.Sprintf("key%d", i))
+		assert.True(t, ok)
+		assert.Equal(t, i, value)
+	}
+	_, ok := stc.Get("expired")
+	assert.False(t, ok)
+}
+
+func TestShardedTimeCache_TTLAndUpsertWithRemaining(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 4)
+	for i := 0; i < 20; i++ {
+		remaining, err := stc.UpsertWithRemaining(fmt.Sprintf("key%d", i), time.Hour)
+		require.Nil(t, err)
+		assert.Equal(t, time.Hour, remaining)
+	}
+
+	for i := 0; i < 20; i++ {
+		ttl, ok := stc.TTL(fmt.Sprintf("key%d", i))
+		assert.True(t, ok)
+		assert.True(t, ttl > 0 && ttl <= time.Hour)
+	}
+	_, ok := stc.TTL("missing")
+	assert.False(t, ok)
+}
+
+func TestShardedTimeCache_SweepShouldRemoveExpiredKeysFromAllShards(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Millisecond, 4)
+	for i := 0; i < 20; i++ {
+		_ = stc.Add(fmt.Sprintf("expiring%d", i))
+	}
+	_ = stc.AddWithSpan("long", time.Hour)
+	_ = stc.Upsert("upserted", time.Hour)
+
+	time.Sleep(time.Millisecond * 10)
+	stc.Sweep()
+
+	assert.Equal(t, 2, stc.Len())
+	assert.True(t, stc.Has("long"))
+	assert.True(t, stc.Has("upserted"))
+}
+
+func TestShardedTimeCache_SweepShouldCallEvictionHandlers(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Millisecond, 4)
+	stc.RegisterEvictionHandler(nil)
+
+	mutEvicted := sync.Mutex{}
+	evicted := make([]string, 0)
+	stc.RegisterEvictionHandler(func(key string) {
+		mutEvicted.Lock()
+		evicted = append(evicted, key)
+		mutEvicted.Unlock()
+	})
+
+	expectedEvicted := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("expiring%d", i)
+		_ = stc.Add(key)
+		expectedEvicted = append(expectedEvicted, key)
+	}
+	_ = stc.AddWithSpan("long", time.Hour)
+
+	time.Sleep(time.Millisecond * 10)
+	stc.Sweep()
+
+	mutEvicted.Lock()
+	assert.ElementsMatch(t, expectedEvicted, evicted)
+	mutEvicted.Unlock()
+}
+
+func TestShardedTimeCache_ConcurrentOperationsShouldWork(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Second, 8)
+	numOperations := 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			key := fmt.Sprintf("key%d", idx)
+			switch idx % 4 {
+			case 0:
+				_ = stc.Add(key)
+			case 1:
+				_ = stc.Upsert(key, time.Second)
+			case 2:
+				_ = stc.Has(key)
+			case 3:
+				stc.Sweep()
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, numOperations/2, stc.Len())
+}
@@ -3,7 +3,9 @@ package timecache
 import (
 	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 // TimeCache can retain an amount of string keys for a defined period of time
@@ -16,10 +18,34 @@ type TimeCache struct {
 // NewTimeCache creates a new time cache data structure instance
 func NewTimeCache(defaultSpan time.Duration) *TimeCache {
 	return &TimeCache{
-		timeCache: newTimeCacheCore(defaultSpan),
+		timeCache: newTimeCacheCore(defaultSpan, &systemClock{}),
 	}
 }
 
+// NewTimeCacheWithClock creates a new time cache data structure instance which decides the expiry of its keys
+// using the provided clock, instead of the system time
+func NewTimeCacheWithClock(defaultSpan time.Duration, clock types.Clock) (*TimeCache, error) {
+	if check.IfNil(clock) {
+		return nil, common.ErrNilClock
+	}
+
+	return &TimeCache{
+		timeCache: newTimeCacheCore(defaultSpan, clock),
+	}, nil
+}
+
+// NewTimeCacheWithCapacity creates a new time cache data structure instance holding at most maxEntries keys.
+// When the capacity is exceeded, the keys closest to their expiry are evicted (and the eviction handlers are notified)
+func NewTimeCacheWithCapacity(defaultSpan time.Duration, maxEntries int) (*TimeCache, error) {
+	if maxEntries < 1 {
+		return nil, common.ErrCacheCapacityInvalid
+	}
+
+	return &TimeCache{
+		timeCache: newBoundedTimeCacheCore(defaultSpan, maxEntries, &systemClock{}),
+	}, nil
+}
+
 // Add will store the key in the time cache
 // Double adding the key is permitted. It will replace the data, if existing. It does not trigger sweep.
 func (tc *TimeCache) Add(key string) error {
@@ -27,18 +53,7 @@ func (tc *TimeCache) Add(key string) error {
 }
 
 func (tc *TimeCache) add(key string, duration time.Duration) error {
-	if len(key) == 0 {
-		return common.ErrEmptyKey
-	}
-
-	tc.timeCache.Lock()
-	defer tc.timeCache.Unlock()
-
-	tc.timeCache.data[key] = &entry{
-		timestamp: time.Now(),
-		span:      duration,
-	}
-	return nil
+	return tc.timeCache.put(key, nil, duration)
 }
 
 // AddWithSpan will store the key in the time cache with the provided span duration
@@ -49,19 +64,54 @@ func (tc *TimeCache) AddWithSpan(key string, duration time.Duration) error {
 
 // Upsert will add the key and provided duration if not exists
 // If the record exists, will update the duration if the provided duration is larger than existing
-// Also, it will reset the contained timestamp to time.Now
+// Also, it will reset the contained timestamp to the clock's current time
 func (tc *TimeCache) Upsert(key string, duration time.Duration) error {
 	_, err := tc.timeCache.upsert(key, nil, duration)
 
 	return err
 }
 
+// UpsertWithRemaining works as Upsert, also returning the effective remaining duration of the key, which is the
+// larger of the provided and the existing spans. It returns 0 if the key was evicted because the capacity was exceeded
+func (tc *TimeCache) UpsertWithRemaining(key string, duration time.Duration) (time.Duration, error) {
+	return tc.timeCache.upsert(key, nil, duration)
+}
+
+// UpsertMultiple will upsert all the provided keys, taking the lock only once
+// No key is added if any of them is empty
+func (tc *TimeCache) UpsertMultiple(keys []string, duration time.Duration) error {
+	return tc.timeCache.upsertMultiple(keys, duration)
+}
+
+// Put will store the key along with the provided value, for the provided ttl
+// Double putting the key is permitted. It will replace the data, if existing. It does not trigger sweep.
+func (tc *TimeCache) Put(key string, value interface{}, ttl time.Duration) error {
+	return tc.timeCache.put(key, value, ttl)
+}
+
+// Get returns the value stored for the key. Keys whose ttl has elapsed are not returned, even if not yet swept
+func (tc *TimeCache) Get(key string) (interface{}, bool) {
+	return tc.timeCache.get(key)
+}
+
 // Sweep starts from the oldest element and will search each element if it is still valid to be kept. Sweep ends when
 // it finds an element that is still valid
 func (tc *TimeCache) Sweep() {
 	tc.timeCache.sweep()
 }
 
+// RegisterEvictionHandler registers a handler to be called for each expired key removed by Sweep, or for each key
+// evicted because the capacity was exceeded
+func (tc *TimeCache) RegisterEvictionHandler(handler func(key string)) {
+	tc.timeCache.registerEvictionHandler(handler)
+}
+
+// TTL returns the duration left until the key expires. It returns false if the key is not found or expired, even if
+// not yet swept
+func (tc *TimeCache) TTL(key string) (time.Duration, bool) {
+	return tc.timeCache.ttl(key)
+}
+
 // Has returns if the key is still found in the time cache
 func (tc *TimeCache) Has(key string) bool {
 	return tc.timeCache.has(key)
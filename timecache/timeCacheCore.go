@@ -1,60 +1,122 @@
 package timecache
 
 import (
+	"container/heap"
 	"sync"
 	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 type entry struct {
+	key       string
 	timestamp time.Time
 	span      time.Duration
 	value     interface{}
+	heapIndex int
+}
+
+func (e *entry) expiry() time.Time {
+	return e.timestamp.Add(e.span)
 }
 
 type timeCacheCore struct {
 	*sync.RWMutex
 	data        map[string]*entry
 	defaultSpan time.Duration
+	clock       types.Clock
+	// maxEntries bounds the number of entries, 0 meaning unbounded. When bounded, the entries closest to
+	// their expiry are evicted first, so the expiry heap is maintained along with the map
+	maxEntries int
+	expiryHeap *entriesHeap
+
+	mutEvictionHandlers sync.RWMutex
+	evictionHandlers    []func(key string)
 }
 
-func newTimeCacheCore(defaultSpan time.Duration) *timeCacheCore {
+func newTimeCacheCore(defaultSpan time.Duration, clock types.Clock) *timeCacheCore {
 	return &timeCacheCore{
 		RWMutex:     &sync.RWMutex{},
 		data:        make(map[string]*entry),
 		defaultSpan: defaultSpan,
+		clock:       clock,
 	}
 }
 
+func newBoundedTimeCacheCore(defaultSpan time.Duration, maxEntries int, clock types.Clock) *timeCacheCore {
+	tcc := newTimeCacheCore(defaultSpan, clock)
+	tcc.maxEntries = maxEntries
+	tcc.expiryHeap = &entriesHeap{}
+
+	return tcc
+}
+
+func (tcc *timeCacheCore) isBounded() bool {
+	return tcc.expiryHeap != nil
+}
+
 // upsert will add the key, value and provided duration if not exists
 // If the record exists, will update the duration if the provided duration is larger than existing
-// Also, it will reset the contained timestamp to time.Now
-// It returns if the value existed before this call. It also operates on the locker so the call is concurrent safe
-func (tcc *timeCacheCore) upsert(key string, value interface{}, duration time.Duration) (bool, error) {
+// Also, it will reset the contained timestamp to the clock's current time
+// It returns the remaining duration of the key, 0 if it was evicted right away because the capacity was exceeded.
+// It also operates on the locker so the call is concurrent safe
+func (tcc *timeCacheCore) upsert(key string, value interface{}, duration time.Duration) (time.Duration, error) {
 	if len(key) == 0 {
-		return false, common.ErrEmptyKey
+		return 0, common.ErrEmptyKey
 	}
 
 	tcc.Lock()
-	defer tcc.Unlock()
+	_ = tcc.upsertNoLock(key, value, duration)
+	evictedKeys := tcc.evictOverCapacityNoLock()
+	// the timestamp was just reset, so the whole span remains
+	remaining := time.Duration(0)
+	element, found := tcc.data[key]
+	if found {
+		remaining = element.span
+	}
+	tcc.Unlock()
 
+	tcc.callEvictionHandlers(evictedKeys)
+
+	return remaining, nil
+}
+
+// upsertMultiple will upsert all the provided keys, operating on the locker only once
+// No key is added if any of them is empty
+func (tcc *timeCacheCore) upsertMultiple(keys []string, duration time.Duration) error {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return common.ErrEmptyKey
+		}
+	}
+
+	tcc.Lock()
+	for _, key := range keys {
+		_ = tcc.upsertNoLock(key, nil, duration)
+	}
+	evictedKeys := tcc.evictOverCapacityNoLock()
+	tcc.Unlock()
+
+	tcc.callEvictionHandlers(evictedKeys)
+
+	return nil
+}
+
+func (tcc *timeCacheCore) upsertNoLock(key string, value interface{}, duration time.Duration) bool {
 	existing, found := tcc.data[key]
 	if found {
 		if existing.span < duration {
 			existing.span = duration
 		}
-		existing.timestamp = time.Now()
+		existing.timestamp = tcc.clock.Now()
+		tcc.onEntryUpdatedNoLock(existing)
 
-		return found, nil
+		return found
 	}
 
-	tcc.data[key] = &entry{
-		timestamp: time.Now(),
-		span:      duration,
-		value:     value,
-	}
-	return found, nil
+	tcc.setNoLock(key, value, duration)
+	return found
 }
 
 // put will add the key, value and provided duration, overriding values if the data already existed
@@ -65,13 +127,12 @@ func (tcc *timeCacheCore) put(key string, value interface{}, duration time.Durat
 	}
 
 	tcc.Lock()
-	defer tcc.Unlock()
+	tcc.setNoLock(key, value, duration)
+	evictedKeys := tcc.evictOverCapacityNoLock()
+	tcc.Unlock()
+
+	tcc.callEvictionHandlers(evictedKeys)
 
-	tcc.data[key] = &entry{
-		timestamp: time.Now(),
-		span:      duration,
-		value:     value,
-	}
 	return nil
 }
 
@@ -83,35 +144,162 @@ func (tcc *timeCacheCore) hasOrAdd(key string, value interface{}, duration time.
 	}
 
 	tcc.Lock()
-	defer tcc.Unlock()
-
 	_, found := tcc.data[key]
 	if found {
+		tcc.Unlock()
 		return true, false, nil
 	}
 
-	tcc.data[key] = &entry{
-		timestamp: time.Now(),
+	tcc.setNoLock(key, value, duration)
+	evictedKeys := tcc.evictOverCapacityNoLock()
+	tcc.Unlock()
+
+	tcc.callEvictionHandlers(evictedKeys)
+
+	return false, true, nil
+}
+
+// setNoLock adds a new entry, replacing the existing one, if any
+func (tcc *timeCacheCore) setNoLock(key string, value interface{}, duration time.Duration) {
+	tcc.deleteNoLock(key)
+
+	e := &entry{
+		key:       key,
+		timestamp: tcc.clock.Now(),
 		span:      duration,
 		value:     value,
 	}
-	return false, true, nil
+	tcc.data[key] = e
+
+	if tcc.isBounded() {
+		heap.Push(tcc.expiryHeap, e)
+	}
+}
+
+func (tcc *timeCacheCore) onEntryUpdatedNoLock(e *entry) {
+	if tcc.isBounded() {
+		heap.Fix(tcc.expiryHeap, e.heapIndex)
+	}
+}
+
+// deleteNoLock removes the entry, if existing
+func (tcc *timeCacheCore) deleteNoLock(key string) {
+	existing, found := tcc.data[key]
+	if !found {
+		return
+	}
+
+	delete(tcc.data, key)
+	if tcc.isBounded() {
+		heap.Remove(tcc.expiryHeap, existing.heapIndex)
+	}
+}
+
+// evictOverCapacityNoLock removes the entries closest to their expiry, until the capacity is no longer exceeded
+func (tcc *timeCacheCore) evictOverCapacityNoLock() []string {
+	if !tcc.isBounded() {
+		return nil
+	}
+
+	var evictedKeys []string
+	for len(tcc.data) > tcc.maxEntries {
+		e := heap.Pop(tcc.expiryHeap).(*entry)
+		delete(tcc.data, e.key)
+		evictedKeys = append(evictedKeys, e.key)
+	}
+
+	return evictedKeys
 }
 
 // sweep iterates over all contained elements checking if the element is still valid to be kept
-// It also operates on the locker so the call is concurrent safe
+// It also operates on the locker so the call is concurrent safe. The eviction handlers are called after the
+// sweep completes, outside the locker
 func (tcc *timeCacheCore) sweep() {
+	evictedKeys := tcc.sweepExpired()
+	tcc.callEvictionHandlers(evictedKeys)
+}
+
+func (tcc *timeCacheCore) sweepExpired() []string {
 	tcc.Lock()
 	defer tcc.Unlock()
 
+	now := tcc.clock.Now()
+	evictedKeys := make([]string, 0)
 	for key, element := range tcc.data {
-		isOldElement := time.Since(element.timestamp) > element.span
+		isOldElement := now.Sub(element.timestamp) > element.span
 		if isOldElement {
-			delete(tcc.data, key)
+			tcc.deleteNoLock(key)
+			evictedKeys = append(evictedKeys, key)
+		}
+	}
+
+	return evictedKeys
+}
+
+// registerEvictionHandler adds a handler to be called for each key removed by sweep
+func (tcc *timeCacheCore) registerEvictionHandler(handler func(key string)) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a time cache object")
+		return
+	}
+
+	tcc.mutEvictionHandlers.Lock()
+	tcc.evictionHandlers = append(tcc.evictionHandlers, handler)
+	tcc.mutEvictionHandlers.Unlock()
+}
+
+func (tcc *timeCacheCore) callEvictionHandlers(evictedKeys []string) {
+	if len(evictedKeys) == 0 {
+		return
+	}
+
+	tcc.mutEvictionHandlers.RLock()
+	defer tcc.mutEvictionHandlers.RUnlock()
+
+	for _, handler := range tcc.evictionHandlers {
+		for _, key := range evictedKeys {
+			handler(key)
 		}
 	}
 }
 
+// get returns the value stored for the key, if the key is found and its span has not elapsed (even if not yet swept)
+func (tcc *timeCacheCore) get(key string) (interface{}, bool) {
+	tcc.RLock()
+	defer tcc.RUnlock()
+
+	element, ok := tcc.data[key]
+	if !ok {
+		return nil, false
+	}
+
+	isOldElement := tcc.clock.Now().Sub(element.timestamp) > element.span
+	if isOldElement {
+		return nil, false
+	}
+
+	return element.value, true
+}
+
+// ttl returns the duration left until the key expires. It returns false if the key is not found or its span has
+// elapsed (even if not yet swept)
+func (tcc *timeCacheCore) ttl(key string) (time.Duration, bool) {
+	tcc.RLock()
+	defer tcc.RUnlock()
+
+	element, ok := tcc.data[key]
+	if !ok {
+		return 0, false
+	}
+
+	remaining := element.expiry().Sub(tcc.clock.Now())
+	if remaining < 0 {
+		return 0, false
+	}
+
+	return remaining, true
+}
+
 // has returns if the key is still found in the time cache
 func (tcc *timeCacheCore) has(key string) bool {
 	tcc.RLock()
@@ -130,10 +318,20 @@ func (tcc *timeCacheCore) len() int {
 	return len(tcc.data)
 }
 
+// remove deletes the key, if existing. It also operates on the locker so the call is concurrent safe
+func (tcc *timeCacheCore) remove(key string) {
+	tcc.Lock()
+	tcc.deleteNoLock(key)
+	tcc.Unlock()
+}
+
 // clear recreates the map, thus deleting any existing entries
 // It also operates on the locker so the call is concurrent safe
 func (tcc *timeCacheCore) clear() {
 	tcc.Lock()
 	tcc.data = make(map[string]*entry)
+	if tcc.isBounded() {
+		tcc.expiryHeap = &entriesHeap{}
+	}
 	tcc.Unlock()
 }
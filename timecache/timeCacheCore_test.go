@@ -12,7 +12,7 @@ import (
 func TestTimeCacheCore_ConcurrentOperations(t *testing.T) {
 	t.Parallel()
 
-	tcc := newTimeCacheCore(time.Second)
+	tcc := newTimeCacheCore(time.Second, &systemClock{})
 	numOperations := 1000
 	wg := &sync.WaitGroup{}
 	wg.Add(numOperations)
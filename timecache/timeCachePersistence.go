@@ -0,0 +1,211 @@
+package timecache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+const exportedSpanLength = 8
+
+// exportedEntry holds a key along with its remaining span. The values are not exported
+type exportedEntry struct {
+	Key           string        `json:"key"`
+	RemainingSpan time.Duration `json:"remainingSpan"`
+}
+
+// exportEntries returns the keys which are still valid, along with their remaining spans
+func (tcc *timeCacheCore) exportEntries() []exportedEntry {
+	tcc.RLock()
+	defer tcc.RUnlock()
+
+	now := tcc.clock.Now()
+	entries := make([]exportedEntry, 0, len(tcc.data))
+	for key, element := range tcc.data {
+		remainingSpan := element.span - now.Sub(element.timestamp)
+		if remainingSpan <= 0 {
+			continue
+		}
+
+		entries = append(entries, exportedEntry{
+			Key:           key,
+			RemainingSpan: remainingSpan,
+		})
+	}
+
+	return entries
+}
+
+// importEntries upserts the provided entries, the remaining spans counting from now
+func (tcc *timeCacheCore) importEntries(entries []exportedEntry) {
+	tcc.Lock()
+	for _, e := range entries {
+		if len(e.Key) == 0 || e.RemainingSpan <= 0 {
+			continue
+		}
+
+		_ = tcc.upsertNoLock(e.Key, nil, e.RemainingSpan)
+	}
+	evictedKeys := tcc.evictOverCapacityNoLock()
+	tcc.Unlock()
+
+	tcc.callEvictionHandlers(evictedKeys)
+}
+
+func writeEntries(writer io.Writer, entries []exportedEntry) error {
+	if writer == nil {
+		return common.ErrNilWriter
+	}
+
+	return json.NewEncoder(writer).Encode(entries)
+}
+
+func readEntries(reader io.Reader) ([]exportedEntry, error) {
+	if reader == nil {
+		return nil, common.ErrNilReader
+	}
+
+	entries := make([]exportedEntry, 0)
+	err := json.NewDecoder(reader).Decode(&entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func putEntries(persister types.Persister, entries []exportedEntry) error {
+	if check.IfNil(persister) {
+		return common.ErrNilPersister
+	}
+
+	for _, e := range entries {
+		span := make([]byte, exportedSpanLength)
+		binary.BigEndian.PutUint64(span, uint64(e.RemainingSpan))
+
+		err := persister.Put([]byte(e.Key), span)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getEntries(persister types.Persister) ([]exportedEntry, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+
+	var err error
+	entries := make([]exportedEntry, 0)
+	persister.RangeKeys(func(key []byte, val []byte) bool {
+		if len(val) != exportedSpanLength {
+			err = fmt.Errorf("%w for key %s: %d", common.ErrInvalidValueLength, key, len(val))
+			return false
+		}
+
+		entries = append(entries, exportedEntry{
+			Key:           string(key),
+			RemainingSpan: time.Duration(binary.BigEndian.Uint64(val)),
+		})
+		return true
+	})
+
+	return entries, err
+}
+
+// Export writes the keys which are still valid, along with their remaining spans, to the provided writer
+func (tc *TimeCache) Export(writer io.Writer) error {
+	return writeEntries(writer, tc.timeCache.exportEntries())
+}
+
+// Import adds the keys previously exported, their remaining spans counting from now
+func (tc *TimeCache) Import(reader io.Reader) error {
+	entries, err := readEntries(reader)
+	if err != nil {
+		return err
+	}
+
+	tc.timeCache.importEntries(entries)
+
+	return nil
+}
+
+// ExportToPersister writes the keys which are still valid, along with their remaining spans, to the provided persister
+func (tc *TimeCache) ExportToPersister(persister types.Persister) error {
+	return putEntries(persister, tc.timeCache.exportEntries())
+}
+
+// ImportFromPersister adds the keys previously exported to the provided persister, their remaining spans counting from now
+func (tc *TimeCache) ImportFromPersister(persister types.Persister) error {
+	entries, err := getEntries(persister)
+	if err != nil {
+		return err
+	}
+
+	tc.timeCache.importEntries(entries)
+
+	return nil
+}
+
+// Export writes the keys which are still valid, along with their remaining spans, to the provided writer
+func (stc *ShardedTimeCache) Export(writer io.Writer) error {
+	return writeEntries(writer, stc.exportEntries())
+}
+
+// Import adds the keys previously exported, their remaining spans counting from now
+func (stc *ShardedTimeCache) Import(reader io.Reader) error {
+	entries, err := readEntries(reader)
+	if err != nil {
+		return err
+	}
+
+	stc.importEntries(entries)
+
+	return nil
+}
+
+// ExportToPersister writes the keys which are still valid, along with their remaining spans, to the provided persister
+func (stc *ShardedTimeCache) ExportToPersister(persister types.Persister) error {
+	return putEntries(persister, stc.exportEntries())
+}
+
+// ImportFromPersister adds the keys previously exported to the provided persister, their remaining spans counting from now
+func (stc *ShardedTimeCache) ImportFromPersister(persister types.Persister) error {
+	entries, err := getEntries(persister)
+	if err != nil {
+		return err
+	}
+
+	stc.importEntries(entries)
+
+	return nil
+}
+
+func (stc *ShardedTimeCache) exportEntries() []exportedEntry {
+	entries := make([]exportedEntry, 0)
+	for _, shard := range stc.shards {
+		entries = append(entries, shard.timeCache.exportEntries()...)
+	}
+
+	return entries
+}
+
+func (stc *ShardedTimeCache) importEntries(entries []exportedEntry) {
+	entriesPerShard := make([][]exportedEntry, len(stc.shards))
+	for _, e := range entries {
+		shardIndex := stc.getShardIndex(e.Key)
+		entriesPerShard[shardIndex] = append(entriesPerShard[shardIndex], e)
+	}
+
+	for i, shardEntries := range entriesPerShard {
+		stc.shards[i].timeCache.importEntries(shardEntries)
+	}
+}
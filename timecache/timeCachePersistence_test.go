@@ -0,0 +1,144 @@
+package timecache
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeCache_ExportImport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil writer or reader should error", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		assert.Equal(t, common.ErrNilWriter, tc.Export(nil))
+		assert.Equal(t, common.ErrNilReader, tc.Import(nil))
+	})
+	t.Run("invalid data should error", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		err := tc.Import(strings.NewReader("not a json"))
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, tc.Len())
+	})
+	t.Run("should keep the remaining spans", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		_ = tc.AddWithSpan("long", time.Hour)
+		_ = tc.AddWithSpan("short", time.Minute)
+		_ = tc.AddWithSpan("expired", time.Millisecond)
+		time.Sleep(time.Millisecond * 10)
+
+		buff := &bytes.Buffer{}
+		err := tc.Export(buff)
+		require.Nil(t, err)
+
+		reloaded := NewTimeCache(time.Second)
+		err = reloaded.Import(buff)
+		require.Nil(t, err)
+
+		assert.Equal(t, 2, reloaded.Len())
+		requireSpanBetween(t, reloaded, "long", time.Hour-time.Second, time.Hour)
+		requireSpanBetween(t, reloaded, "short", time.Minute-time.Second, time.Minute)
+	})
+}
+
+func TestTimeCache_ExportImportPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		assert.Equal(t, common.ErrNilPersister, tc.ExportToPersister(nil))
+		assert.Equal(t, common.ErrNilPersister, tc.ImportFromPersister(nil))
+	})
+	t.Run("persister error should be returned", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		persister := testscommon.NewMemDbMock()
+		persister.PutCalled = func(key, val []byte) error {
+			return expectedErr
+		}
+
+		tc := NewTimeCache(time.Second)
+		_ = tc.Add("key")
+		assert.Equal(t, expectedErr, tc.ExportToPersister(persister))
+	})
+	t.Run("invalid value should error", func(t *testing.T) {
+		t.Parallel()
+
+		persister := testscommon.NewMemDbMock()
+		_ = persister.Put([]byte("key"), []byte("invalid"))
+
+		tc := NewTimeCache(time.Second)
+		err := tc.ImportFromPersister(persister)
+		assert.True(t, errors.Is(err, common.ErrInvalidValueLength))
+		assert.Equal(t, 0, tc.Len())
+	})
+	t.Run("should keep the remaining spans", func(t *testing.T) {
+		t.Parallel()
+
+		persister := testscommon.NewMemDbMock()
+		tc := NewTimeCache(time.Second)
+		_ = tc.AddWithSpan("long", time.Hour)
+		_ = tc.AddWithSpan("expired", time.Millisecond)
+		time.Sleep(time.Millisecond * 10)
+
+		err := tc.ExportToPersister(persister)
+		require.Nil(t, err)
+
+		reloaded := NewTimeCache(time.Second)
+		err = reloaded.ImportFromPersister(persister)
+		require.Nil(t, err)
+
+		assert.Equal(t, 1, reloaded.Len())
+		requireSpanBetween(t, reloaded, "long", time.Hour-time.Second, time.Hour)
+	})
+}
+
+func TestShardedTimeCache_ExportImport(t *testing.T) {
+	t.Parallel()
+
+	stc, _ := NewShardedTimeCache(time.Hour, 4)
+	_ = stc.UpsertMultiple([]string{"a", "b", "c", "d", "e"}, time.Hour)
+
+	buff := &bytes.Buffer{}
+	err := stc.Export(buff)
+	require.Nil(t, err)
+
+	persister := testscommon.NewMemDbMock()
+	err = stc.ExportToPersister(persister)
+	require.Nil(t, err)
+
+	reloaded, _ := NewShardedTimeCache(time.Hour, 8)
+	err = reloaded.Import(buff)
+	require.Nil(t, err)
+	assert.Equal(t, 5, reloaded.Len())
+
+	reloaded, _ = NewShardedTimeCache(time.Hour, 2)
+	err = reloaded.ImportFromPersister(persister)
+	require.Nil(t, err)
+	assert.Equal(t, 5, reloaded.Len())
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		assert.True(t, reloaded.Has(key))
+	}
+}
+
+func requireSpanBetween(t *testing.T, tc *TimeCache, key string, lower time.Duration, upper time.Duration) {
+	recovered, ok := tc.Value(key)
+	require.True(t, ok)
+	require.True(t, recovered.span > lower && recovered.span <= upper, "span of %s: %s", key, recovered.span)
+}
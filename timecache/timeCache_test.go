@@ -7,6 +7,7 @@ import (
 
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -228,6 +229,233 @@ func TestTimeCache_Len(t *testing.T) {
 	}
 }
 
+// ------- UpsertMultiple
+
+func TestTimeCache_UpsertMultipleEmptyKeyShouldErrAndNotAdd(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+	err := tc.UpsertMultiple([]string{"key1", "", "key2"}, time.Second)
+
+	assert.Equal(t, common.ErrEmptyKey, err)
+	assert.Equal(t, 0, tc.Len())
+}
+
+func TestTimeCache_UpsertMultipleShouldWork(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+	_ = tc.AddWithSpan("existing", time.Hour)
+
+	err := tc.UpsertMultiple([]string{"key1", "key2", "existing"}, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, tc.Len())
+
+	recovered, _ := tc.Value("key1")
+	assert.Equal(t, time.Minute, recovered.span)
+	// upsert does not lower the span of existing keys
+	recovered, _ = tc.Value("existing")
+	assert.Equal(t, time.Hour, recovered.span)
+}
+
+// ------- Put / Get
+
+func TestTimeCache_PutEmptyKeyShouldErr(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+	err := tc.Put("", "value", time.Second)
+
+	assert.Equal(t, common.ErrEmptyKey, err)
+	assert.Equal(t, 0, tc.Len())
+}
+
+func TestTimeCache_PutGetShouldWork(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+	err := tc.Put("key", "value", time.Hour)
+	assert.Nil(t, err)
+
+	value, ok := tc.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	err = tc.Put("key", "new value", time.Hour)
+	assert.Nil(t, err)
+	value, _ = tc.Get("key")
+	assert.Equal(t, "new value", value)
+
+	value, ok = tc.Get("missing")
+	assert.False(t, ok)
+	assert.Nil(t, value)
+}
+
+func TestTimeCache_GetExpiredShouldNotReturnValue(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+	_ = tc.Put("key", "value", time.Millisecond)
+	time.Sleep(time.Millisecond * 10)
+
+	value, ok := tc.Get("key")
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	tc.Sweep()
+	assert.Equal(t, 0, tc.Len())
+}
+
+// ------- RegisterEvictionHandler
+
+func TestTimeCache_RegisterEvictionHandlerNilHandlerShouldIgnore(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Millisecond)
+	tc.RegisterEvictionHandler(nil)
+	_ = tc.Add("key")
+
+	time.Sleep(time.Millisecond * 10)
+	assert.NotPanics(t, tc.Sweep)
+	assert.Equal(t, 0, tc.Len())
+}
+
+func TestTimeCache_SweepShouldCallEvictionHandlers(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Millisecond)
+	_ = tc.Add("expired1")
+	_ = tc.Add("expired2")
+	_ = tc.AddWithSpan("valid", time.Hour)
+
+	evicted1 := make([]string, 0)
+	evicted2 := make([]string, 0)
+	tc.RegisterEvictionHandler(func(key string) {
+		// the handler is called outside the time cache's lock
+		_ = tc.Has(key)
+		evicted1 = append(evicted1, key)
+	})
+	tc.RegisterEvictionHandler(func(key string) {
+		evicted2 = append(evicted2, key)
+	})
+
+	time.Sleep(time.Millisecond * 10)
+	tc.Sweep()
+
+	assert.ElementsMatch(t, []string{"expired1", "expired2"}, evicted1)
+	assert.ElementsMatch(t, []string{"expired1", "expired2"}, evicted2)
+
+	// nothing else to sweep
+	tc.Sweep()
+	assert.Equal(t, 2, len(evicted1))
+}
+
+// ------- Clock
+
+func TestNewTimeCacheWithClock_NilClockShouldErr(t *testing.T) {
+	t.Parallel()
+
+	tc, err := NewTimeCacheWithClock(time.Second, nil)
+	assert.True(t, check.IfNil(tc))
+	assert.Equal(t, common.ErrNilClock, err)
+}
+
+func TestTimeCache_WithClockShouldExpireDeterministically(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	tc, err := NewTimeCacheWithClock(time.Minute, clock)
+	require.Nil(t, err)
+
+	_ = tc.Add("default")
+	_ = tc.Put("short", "value", time.Second)
+	_ = tc.AddWithSpan("long", time.Hour)
+
+	clock.Advance(time.Second)
+	value, ok := tc.Get("short")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	clock.Advance(time.Nanosecond)
+	_, ok = tc.Get("short")
+	assert.False(t, ok)
+	tc.Sweep()
+	assert.Equal(t, []string{"default", "long"}, containedKeys(tc))
+
+	// upserting resets the timestamp to the clock's current time
+	clock.Advance(time.Second * 30)
+	_ = tc.Upsert("default", time.Minute)
+	clock.Advance(time.Second * 45)
+	tc.Sweep()
+	assert.Equal(t, []string{"default", "long"}, containedKeys(tc))
+
+	clock.Advance(time.Hour)
+	tc.Sweep()
+	assert.Equal(t, 0, tc.Len())
+}
+
+func TestTimeCache_TTLAndUpsertWithRemaining(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	tc, _ := NewTimeCacheWithClock(time.Minute, clock)
+
+	_, ok := tc.TTL("key")
+	assert.False(t, ok)
+
+	remaining, err := tc.UpsertWithRemaining("", time.Minute)
+	assert.Equal(t, common.ErrEmptyKey, err)
+	assert.Equal(t, time.Duration(0), remaining)
+
+	remaining, err = tc.UpsertWithRemaining("key", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, remaining)
+
+	clock.Advance(time.Second * 20)
+	ttl, ok := tc.TTL("key")
+	assert.True(t, ok)
+	assert.Equal(t, time.Second*40, ttl)
+
+	// the shorter span does not shorten the existing one, while the timestamp is reset
+	remaining, _ = tc.UpsertWithRemaining("key", time.Second)
+	assert.Equal(t, time.Minute, remaining)
+
+	clock.Advance(time.Minute)
+	ttl, ok = tc.TTL("key")
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), ttl)
+
+	// expired, even if not yet swept
+	clock.Advance(time.Nanosecond)
+	_, ok = tc.TTL("key")
+	assert.False(t, ok)
+	assert.True(t, tc.Has("key"))
+}
+
+func TestTimeCache_UpsertWithRemainingOfEvictedKeyShouldReturnZero(t *testing.T) {
+	t.Parallel()
+
+	tc, _ := NewTimeCacheWithCapacity(time.Minute, 1)
+	_ = tc.AddWithSpan("long", time.Hour)
+
+	// the new key is the closest to its expiry, so it is evicted right away
+	remaining, err := tc.UpsertWithRemaining("short", time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), remaining)
+	assert.False(t, tc.Has("short"))
+}
+
+func containedKeys(tc *TimeCache) []string {
+	keys := make([]string, 0)
+	for _, key := range []string{"default", "short", "long"} {
+		if tc.Has(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
 // ------- IsInterfaceNil
 
 func TestTimeCache_IsInterfaceNilNotNil(t *testing.T) {
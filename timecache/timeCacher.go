@@ -3,11 +3,13 @@ package timecache
 import (
 	"context"
 	"math"
-	"sync"
 	"time"
 
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/dispatch"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 )
 
 var log = logger.GetOrCreate("storage/timecache")
@@ -18,6 +20,9 @@ const minDuration = time.Second
 type ArgTimeCacher struct {
 	DefaultSpan time.Duration
 	CacheExpiry time.Duration
+	// Clock decides the expiry of the keys. It is optional, the system clock being used if not provided.
+	// The sweep go routine is always triggered at CacheExpiry intervals of system time
+	Clock types.Clock
 }
 
 // timeCacher implements a time cacher with automatic sweeping mechanism
@@ -26,8 +31,7 @@ type timeCacher struct {
 	cacheExpiry time.Duration
 	cancelFunc  func()
 
-	mutAddedDataHandlers sync.RWMutex
-	mapDataHandlers      map[string]func(key []byte, value interface{})
+	addedDataHandlers *dispatch.Dispatcher
 }
 
 // NewTimeCacher creates a new timeCacher
@@ -38,9 +42,9 @@ func NewTimeCacher(arg ArgTimeCacher) (*timeCacher, error) {
 	}
 
 	tc := &timeCacher{
-		timeCache:       newTimeCacheCore(arg.DefaultSpan),
-		cacheExpiry:     arg.CacheExpiry,
-		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+		timeCache:         newTimeCacheCore(arg.DefaultSpan, clockOrDefault(arg.Clock)),
+		cacheExpiry:       arg.CacheExpiry,
+		addedDataHandlers: dispatch.NewDefaultDispatcher("timecache"),
 	}
 
 	var ctx context.Context
@@ -61,6 +65,14 @@ func checkArg(arg ArgTimeCacher) error {
 	return nil
 }
 
+func clockOrDefault(clock types.Clock) types.Clock {
+	if check.IfNil(clock) {
+		return &systemClock{}
+	}
+
+	return clock
+}
+
 // startSweeping handles sweeping the time cache
 func (tc *timeCacher) startSweeping(ctx context.Context) {
 	timer := time.NewTimer(tc.cacheExpiry)
@@ -92,7 +104,7 @@ func (tc *timeCacher) Put(key []byte, value interface{}, _ int) (evicted bool) {
 		return
 	}
 
-	tc.callAddedDataHandlers(key, value)
+	tc.addedDataHandlers.Dispatch(key, value)
 
 	return false
 }
@@ -115,6 +127,12 @@ func (tc *timeCacher) Has(key []byte) bool {
 	return tc.timeCache.has(string(key))
 }
 
+// TTL returns the duration left until the key expires. It returns false if the key is not found or expired, even if
+// not yet swept
+func (tc *timeCacher) TTL(key []byte) (time.Duration, bool) {
+	return tc.timeCache.ttl(string(key))
+}
+
 // Peek returns a key's value from the cache
 func (tc *timeCacher) Peek(key []byte) (value interface{}, ok bool) {
 	return tc.Get(key)
@@ -131,7 +149,7 @@ func (tc *timeCacher) HasOrAdd(key []byte, value interface{}, _ int) (has, added
 	}
 
 	if !has {
-		tc.callAddedDataHandlers(key, value)
+		tc.addedDataHandlers.Dispatch(key, value)
 	}
 
 	return
@@ -143,10 +161,7 @@ func (tc *timeCacher) Remove(key []byte) {
 		return
 	}
 
-	tc.timeCache.Lock()
-	defer tc.timeCache.Unlock()
-
-	delete(tc.timeCache.data, string(key))
+	tc.timeCache.remove(string(key))
 }
 
 // Keys returns all keys from cache
@@ -186,24 +201,17 @@ func (tc *timeCacher) RegisterHandler(handler func(key []byte, value interface{}
 		return
 	}
 
-	tc.mutAddedDataHandlers.Lock()
-	tc.mapDataHandlers[id] = handler
-	tc.mutAddedDataHandlers.Unlock()
+	tc.addedDataHandlers.Register(id, handler)
 }
 
-// UnRegisterHandler removes the handler from the list
-func (tc *timeCacher) UnRegisterHandler(id string) {
-	tc.mutAddedDataHandlers.Lock()
-	delete(tc.mapDataHandlers, id)
-	tc.mutAddedDataHandlers.Unlock()
+// RegisterEvictionHandler registers a handler to be called for each expired key removed by the sweeping mechanism
+func (tc *timeCacher) RegisterEvictionHandler(handler func(key string)) {
+	tc.timeCache.registerEvictionHandler(handler)
 }
 
-func (tc *timeCacher) callAddedDataHandlers(key []byte, value interface{}) {
-	tc.mutAddedDataHandlers.RLock()
-	for _, handler := range tc.mapDataHandlers {
-		go handler(key, value)
-	}
-	tc.mutAddedDataHandlers.RUnlock()
+// UnRegisterHandler removes the handler from the list
+func (tc *timeCacher) UnRegisterHandler(id string) {
+	tc.addedDataHandlers.Unregister(id)
 }
 
 // Close will close the internal sweep go routine
@@ -215,6 +223,11 @@ func (tc *timeCacher) Close() error {
 	return nil
 }
 
+// Stats returns the key stats of the cache
+func (tc *timeCacher) Stats() map[string]interface{} {
+	return types.CacheStats(string(common.TimeCache), tc)
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (tc *timeCacher) IsInterfaceNil() bool {
 	return tc == nil
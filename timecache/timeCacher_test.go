@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/TerraDharitri/drt-go-chain-storage/timecache"
 	"github.com/stretchr/testify/assert"
 )
@@ -217,6 +218,83 @@ func TestTimeCacher_Evicted(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestTimeCacher_EvictionHandlerShouldBeCalledOnSweep(t *testing.T) {
+	t.Parallel()
+
+	arg := createArgTimeCacher()
+	arg.CacheExpiry = time.Second
+	arg.DefaultSpan = time.Second
+	cacher, _ := timecache.NewTimeCacher(arg)
+
+	chEvicted := make(chan string, 1)
+	cacher.RegisterEvictionHandler(func(key string) {
+		chEvicted <- key
+	})
+	cacher.Put([]byte("key"), []byte("value"), 0)
+
+	select {
+	case key := <-chEvicted:
+		assert.Equal(t, "key", key)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "eviction handler should have been called")
+	}
+
+	err := cacher.Close()
+	assert.Nil(t, err)
+}
+
+func TestTimeCacher_SweepShouldUseProvidedClock(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	arg := createArgTimeCacher()
+	arg.CacheExpiry = time.Second
+	arg.DefaultSpan = time.Hour
+	arg.Clock = clock
+	cacher, _ := timecache.NewTimeCacher(arg)
+
+	chEvicted := make(chan string, 1)
+	cacher.RegisterEvictionHandler(func(key string) {
+		chEvicted <- key
+	})
+	cacher.Put([]byte("key"), []byte("value"), 0)
+
+	// accelerated time: the span elapses without waiting for it
+	clock.Advance(2 * time.Hour)
+
+	select {
+	case key := <-chEvicted:
+		assert.Equal(t, "key", key)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "eviction handler should have been called")
+	}
+
+	err := cacher.Close()
+	assert.Nil(t, err)
+}
+
+func TestTimeCacher_TTL(t *testing.T) {
+	t.Parallel()
+
+	clock := testscommon.NewClockMock(time.Unix(1000, 0))
+	arg := createArgTimeCacher()
+	arg.DefaultSpan = time.Hour
+	arg.Clock = clock
+	cacher, _ := timecache.NewTimeCacher(arg)
+	defer func() {
+		_ = cacher.Close()
+	}()
+
+	_, ok := cacher.TTL([]byte("key"))
+	assert.False(t, ok)
+
+	cacher.Put([]byte("key"), []byte("value"), 0)
+	clock.Advance(time.Minute)
+	ttl, ok := cacher.TTL([]byte("key"))
+	assert.True(t, ok)
+	assert.Equal(t, 59*time.Minute, ttl)
+}
+
 func TestTimeCacher_Peek(t *testing.T) {
 	t.Parallel()
 
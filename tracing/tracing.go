@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Config holds the tracing settings of the storage operations
+type Config struct {
+	// Tracer creates the spans. The tracing is disabled if not provided
+	Tracer trace.Tracer
+	// SamplingRatio is the fraction of the operations being traced, between 0 and 1
+	SamplingRatio float64
+}
+
+var (
+	mutSettings sync.RWMutex
+	settings    = Config{}
+	noopSpan    = noop.Span{}
+)
+
+// Setup sets the tracer of the storage operations, along with the fraction of them being traced. The sampling applies
+// before the one of the tracer, so that the untraced operations do not pay for the span creation
+func Setup(config Config) error {
+	if config.SamplingRatio < 0 || config.SamplingRatio > 1 {
+		return fmt.Errorf("%w: SamplingRatio should be between 0 and 1", common.ErrInvalidConfig)
+	}
+
+	mutSettings.Lock()
+	settings = config
+	mutSettings.Unlock()
+
+	return nil
+}
+
+// Disable stops the tracing of the storage operations
+func Disable() {
+	mutSettings.Lock()
+	settings = Config{}
+	mutSettings.Unlock()
+}
+
+// StartSpan starts a span of the provided operation, child of the span held by the context, if any. A non recording
+// span is returned when the tracing is disabled or the operation is not sampled
+func StartSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	mutSettings.RLock()
+	current := settings
+	mutSettings.RUnlock()
+
+	if current.Tracer == nil || !isSampled(current.SamplingRatio) {
+		return ctx, noopSpan
+	}
+
+	return current.Tracer.Start(ctx, operation, trace.WithAttributes(attributes...))
+}
+
+func isSampled(ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+
+	return rand.Float64() < ratio
+}
+
+// EndSpan records the provided error, if any, then ends the span. The missing keys are not reported as errors
+func EndSpan(span trace.Span, err error) {
+	if err != nil && span.IsRecording() {
+		if errors.Is(err, common.ErrKeyNotFound) {
+			span.SetAttributes(attribute.Bool("found", false))
+		} else {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+
+	span.End()
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup_InvalidSamplingRatioShouldError(t *testing.T) {
+	err := Setup(Config{SamplingRatio: -0.1})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	err = Setup(Config{SamplingRatio: 1.1})
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+}
+
+func TestStartSpan(t *testing.T) {
+	defer Disable()
+
+	_, span := StartSpan(context.Background(), "disabled")
+	assert.False(t, span.IsRecording())
+
+	tracer := &testscommon.TracerMock{}
+	require.Nil(t, Setup(Config{Tracer: tracer, SamplingRatio: 0}))
+	_, span = StartSpan(context.Background(), "not sampled")
+	assert.False(t, span.IsRecording())
+	assert.Empty(t, tracer.Spans())
+
+	require.Nil(t, Setup(Config{Tracer: tracer, SamplingRatio: 1}))
+	_, span = StartSpan(context.Background(), "operation")
+	EndSpan(span, common.ErrKeyNotFound)
+	_, span = StartSpan(context.Background(), "failed operation")
+	EndSpan(span, common.ErrDBIsClosed)
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "operation", spans[0].Name)
+	assert.True(t, spans[0].Ended)
+	assert.Empty(t, spans[0].Errors)
+	assert.False(t, spans[0].Attributes["found"].AsBool())
+	assert.Equal(t, []error{common.ErrDBIsClosed}, spans[1].Errors)
+}
@@ -0,0 +1,184 @@
+package twolevelcache
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+)
+
+var _ types.Cacher = (*TwoLevelCache)(nil)
+var _ types.StatsProvider = (*TwoLevelCache)(nil)
+var _ types.HealthChecker = (*TwoLevelCache)(nil)
+
+const l2EvictionHandlerID = "twoLevelCache"
+
+// TwoLevelCache layers a small and fast L1 cache over a larger L2 cache. The puts are written through to both
+// levels, while the items found only in L2 are promoted to L1 on Get. L2 is the authoritative level: the number of
+// items, the size in bytes and the keys are the ones of L2. If L2 is an eviction notifier, the items it evicts
+// are (asynchronously) removed from L1 as well.
+// The promoted items are added to L1 with a zero size, so L1 should be bounded by the number of items
+type TwoLevelCache struct {
+	l1 types.Cacher
+	l2 types.Cacher
+}
+
+// NewTwoLevelCache creates a new two-level cache instance
+func NewTwoLevelCache(l1 types.Cacher, l2 types.Cacher) (*TwoLevelCache, error) {
+	if check.IfNil(l1) {
+		return nil, fmt.Errorf("%w for L1", common.ErrNilCacher)
+	}
+	if check.IfNil(l2) {
+		return nil, fmt.Errorf("%w for L2", common.ErrNilCacher)
+	}
+
+	tlc := &TwoLevelCache{
+		l1: l1,
+		l2: l2,
+	}
+
+	notifier, ok := l2.(types.EvictionNotifier)
+	if ok {
+		notifier.RegisterEvictionHandler(tlc.onL2Evicted, l2EvictionHandlerID)
+	}
+
+	return tlc, nil
+}
+
+func (tlc *TwoLevelCache) onL2Evicted(key []byte, _ interface{}, _ types.EvictionReason) {
+	tlc.l1.Remove(key)
+}
+
+// Clear is used to completely clear both levels
+func (tlc *TwoLevelCache) Clear() {
+	tlc.l1.Clear()
+	tlc.l2.Clear()
+}
+
+// Put adds a value to both levels. Returns true if an eviction occurred in L2.
+func (tlc *TwoLevelCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	evicted = tlc.l2.Put(key, value, sizeInBytes)
+	_ = tlc.l1.Put(key, value, sizeInBytes)
+
+	return evicted
+}
+
+// Get looks up a key's value, first in L1, then in L2. The values found only in L2 are promoted to L1
+func (tlc *TwoLevelCache) Get(key []byte) (value interface{}, ok bool) {
+	value, ok = tlc.l1.Get(key)
+	if ok {
+		return value, true
+	}
+
+	value, ok = tlc.l2.Get(key)
+	if ok {
+		_ = tlc.l1.Put(key, value, 0)
+	}
+
+	return value, ok
+}
+
+// Has checks if a key is in any of the levels, without updating the
+// recent-ness or deleting it for being stale.
+func (tlc *TwoLevelCache) Has(key []byte) bool {
+	return tlc.l1.Has(key) || tlc.l2.Has(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key and without promoting it to L1.
+func (tlc *TwoLevelCache) Peek(key []byte) (value interface{}, ok bool) {
+	value, ok = tlc.l1.Peek(key)
+	if ok {
+		return value, true
+	}
+
+	return tlc.l2.Peek(key)
+}
+
+// HasOrAdd checks if a key is in L2 without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value to both levels.
+// Returns whether the item existed before and whether it has been added.
+func (tlc *TwoLevelCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	has, added = tlc.l2.HasOrAdd(key, value, sizeInBytes)
+	if added {
+		_ = tlc.l1.Put(key, value, sizeInBytes)
+	}
+
+	return has, added
+}
+
+// Remove removes the provided key from both levels.
+func (tlc *TwoLevelCache) Remove(key []byte) {
+	tlc.l1.Remove(key)
+	tlc.l2.Remove(key)
+}
+
+// Keys returns the keys in L2
+func (tlc *TwoLevelCache) Keys() [][]byte {
+	return tlc.l2.Keys()
+}
+
+// Len returns the number of items in L2
+func (tlc *TwoLevelCache) Len() int {
+	return tlc.l2.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all the elements contained in L2
+func (tlc *TwoLevelCache) SizeInBytesContained() uint64 {
+	return tlc.l2.SizeInBytesContained()
+}
+
+// MaxSize returns the maximum number of items which can be stored in L2
+func (tlc *TwoLevelCache) MaxSize() int {
+	return tlc.l2.MaxSize()
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added to L2
+func (tlc *TwoLevelCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	tlc.l2.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (tlc *TwoLevelCache) UnRegisterHandler(id string) {
+	tlc.l2.UnRegisterHandler(id)
+}
+
+// Close closes both levels
+func (tlc *TwoLevelCache) Close() error {
+	notifier, ok := tlc.l2.(types.EvictionNotifier)
+	if ok {
+		notifier.UnRegisterEvictionHandler(l2EvictionHandlerID)
+	}
+
+	errL1 := tlc.l1.Close()
+	errL2 := tlc.l2.Close()
+	if errL1 != nil {
+		return errL1
+	}
+
+	return errL2
+}
+
+// Stats returns the key stats of the cache, which are the ones of L2, along with the stats of both levels
+func (tlc *TwoLevelCache) Stats() map[string]interface{} {
+	stats := types.CacheStats(string(common.TwoLevelCache), tlc)
+	stats["l1"] = types.StatsOf(tlc.l1)
+	stats["l2"] = types.StatsOf(tlc.l2)
+
+	return stats
+}
+
+// Health returns the health of both levels, the cache being as bad as the worst of them
+func (tlc *TwoLevelCache) Health() types.HealthStatus {
+	status := types.NewHealthStatus()
+	status.AddComponentHealth("l1", tlc.l1)
+	status.AddComponentHealth("l2", tlc.l2)
+
+	return status
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tlc *TwoLevelCache) IsInterfaceNil() bool {
+	return tlc == nil
+}
@@ -0,0 +1,185 @@
+package twolevelcache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/twolevelcache"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createLevels(t *testing.T, l1Size int, l2Size int) (types.Cacher, types.Cacher) {
+	l1, err := lrucache.NewCache(l1Size)
+	require.Nil(t, err)
+	l2, err := lrucache.NewCache(l2Size)
+	require.Nil(t, err)
+
+	return l1, l2
+}
+
+func TestNewTwoLevelCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil L1 should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, l2 := createLevels(t, 1, 1)
+		tlc, err := twolevelcache.NewTwoLevelCache(nil, l2)
+		assert.True(t, check.IfNil(tlc))
+		assert.True(t, errors.Is(err, common.ErrNilCacher))
+		assert.Contains(t, err.Error(), "L1")
+	})
+	t.Run("nil L2 should error", func(t *testing.T) {
+		t.Parallel()
+
+		l1, _ := createLevels(t, 1, 1)
+		tlc, err := twolevelcache.NewTwoLevelCache(l1, nil)
+		assert.True(t, check.IfNil(tlc))
+		assert.True(t, errors.Is(err, common.ErrNilCacher))
+		assert.Contains(t, err.Error(), "L2")
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		l1, l2 := createLevels(t, 2, 10)
+		tlc, err := twolevelcache.NewTwoLevelCache(l1, l2)
+		assert.False(t, check.IfNil(tlc))
+		assert.Nil(t, err)
+		assert.Equal(t, 10, tlc.MaxSize())
+	})
+}
+
+func TestTwoLevelCache_PutShouldWriteThrough(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(t, 2, 10)
+	tlc, _ := twolevelcache.NewTwoLevelCache(l1, l2)
+
+	key, val := []byte("key"), []byte("value")
+	evicted := tlc.Put(key, val, len(val))
+	assert.False(t, evicted)
+	assert.True(t, l1.Has(key))
+	assert.True(t, l2.Has(key))
+	assert.Equal(t, 1, tlc.Len())
+
+	has, added := tlc.HasOrAdd([]byte("other"), val, len(val))
+	assert.False(t, has)
+	assert.True(t, added)
+	assert.True(t, l1.Has([]byte("other")))
+	assert.True(t, l2.Has([]byte("other")))
+
+	has, added = tlc.HasOrAdd([]byte("other"), val, len(val))
+	assert.True(t, has)
+	assert.False(t, added)
+}
+
+func TestTwoLevelCache_GetShouldPromoteFromL2(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(t, 2, 10)
+	tlc, _ := twolevelcache.NewTwoLevelCache(l1, l2)
+
+	tlc.Put([]byte("a"), "a", 1)
+	tlc.Put([]byte("b"), "b", 1)
+	tlc.Put([]byte("c"), "c", 1)
+	require.False(t, l1.Has([]byte("a")))
+	require.Equal(t, 3, tlc.Len())
+
+	// peeking does not promote
+	value, ok := tlc.Peek([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.False(t, l1.Has([]byte("a")))
+	assert.True(t, tlc.Has([]byte("a")))
+
+	value, ok = tlc.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.True(t, l1.Has([]byte("a")))
+	assert.False(t, l1.Has([]byte("b")))
+
+	value, ok = tlc.Get([]byte("missing"))
+	assert.False(t, ok)
+	assert.Nil(t, value)
+	assert.False(t, tlc.Has([]byte("missing")))
+}
+
+func TestTwoLevelCache_RemoveAndClearShouldAffectBothLevels(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(t, 2, 10)
+	tlc, _ := twolevelcache.NewTwoLevelCache(l1, l2)
+
+	tlc.Put([]byte("a"), "a", 1)
+	tlc.Put([]byte("b"), "b", 1)
+
+	tlc.Remove([]byte("a"))
+	assert.False(t, l1.Has([]byte("a")))
+	assert.False(t, l2.Has([]byte("a")))
+	assert.Equal(t, [][]byte{[]byte("b")}, tlc.Keys())
+
+	tlc.Clear()
+	assert.Zero(t, l1.Len())
+	assert.Zero(t, l2.Len())
+}
+
+func TestTwoLevelCache_EvictionFromL2ShouldRemoveFromL1(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(t, 2, 2)
+	tlc, _ := twolevelcache.NewTwoLevelCache(l1, l2)
+
+	tlc.Put([]byte("a"), "a", 1)
+	tlc.Put([]byte("b"), "b", 1)
+	// refresh "a" in L1 only, so that it is still there when evicted from L2
+	_, _ = l1.Get([]byte("a"))
+	tlc.Put([]byte("c"), "c", 1)
+
+	// the eviction handlers are called on separate go routines
+	timeout := time.After(time.Second * 2)
+	for l1.Has([]byte("a")) {
+		select {
+		case <-timeout:
+			require.Fail(t, "evicted item should have been removed from L1")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+	assert.False(t, tlc.Has([]byte("a")))
+	assert.True(t, l1.Has([]byte("c")))
+}
+
+func TestTwoLevelCache_HandlersShouldBeRegisteredOnL2(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(t, 2, 10)
+	tlc, _ := twolevelcache.NewTwoLevelCache(l1, l2)
+
+	chAdded := make(chan string, 10)
+	tlc.RegisterHandler(func(key []byte, _ interface{}) {
+		chAdded <- string(key)
+	}, "id")
+
+	tlc.Put([]byte("a"), "a", 1)
+	select {
+	case key := <-chAdded:
+		assert.Equal(t, "a", key)
+	case <-time.After(time.Second * 2):
+		assert.Fail(t, "handler should have been called")
+	}
+
+	tlc.UnRegisterHandler("id")
+	tlc.Put([]byte("b"), "b", 1)
+	select {
+	case key := <-chAdded:
+		assert.Fail(t, "handler should not have been called", key)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	assert.Nil(t, tlc.Close())
+}
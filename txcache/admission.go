@@ -0,0 +1,54 @@
+package txcache
+
+// GasPriceFloorHandler computes the lowest gas price of the transactions added to the cache, given the fullness of
+// the cache, the highest of the ratios between the number of bytes, respectively transactions, and their thresholds
+type GasPriceFloorHandler func(fullness float64) uint64
+
+// SetGasPriceFloorHandler sets the handler raising the gas price floor above config.MinGasPriceForAdmission, e.g. as
+// the cache fills up. A nil handler leaves only the static floor in place
+func (cache *TxCache) SetGasPriceFloorHandler(handler GasPriceFloorHandler) {
+	cache.mutGasPriceFloorHandler.Lock()
+	cache.gasPriceFloorHandler = handler
+	cache.mutGasPriceFloorHandler.Unlock()
+}
+
+// GasPriceFloor returns the lowest gas price of the transactions currently added to the cache
+func (cache *TxCache) GasPriceFloor() uint64 {
+	floor := cache.config.MinGasPriceForAdmission
+
+	cache.mutGasPriceFloorHandler.RLock()
+	handler := cache.gasPriceFloorHandler
+	cache.mutGasPriceFloorHandler.RUnlock()
+
+	if handler != nil {
+		dynamicFloor := handler(cache.fullness())
+		if dynamicFloor > floor {
+			floor = dynamicFloor
+		}
+	}
+
+	return floor
+}
+
+func (cache *TxCache) fullness() float64 {
+	bytesFullness := float64(cache.NumBytes()) / float64(cache.config.NumBytesThreshold)
+	countFullness := float64(cache.CountTx()) / float64(cache.config.CountThreshold)
+	if bytesFullness > countFullness {
+		return bytesFullness
+	}
+
+	return countFullness
+}
+
+func (cache *TxCache) isAdmittedByGasPrice(tx *WrappedTransaction) bool {
+	floor := cache.GasPriceFloor()
+	gasPrice := tx.Tx.GetGasPrice()
+	if gasPrice >= floor {
+		return true
+	}
+
+	cache.numRejectedUnderpriced.Increment()
+	logAdd.Trace("TxCache.AddTx: underpriced transaction rejected", "tx", tx.TxHash, "gas price", gasPrice, "floor", floor)
+
+	return false
+}
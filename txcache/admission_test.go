@@ -0,0 +1,78 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithGasPriceFloorToTest(minGasPrice uint64) *TxCache {
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              4,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+		MinGasPriceForAdmission:     minGasPrice,
+	}, txcachemocks.NewMempoolHostMock())
+	if err != nil {
+		panic(err)
+	}
+
+	return cache
+}
+
+func TestTxCache_AddTxWithStaticGasPriceFloor(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithGasPriceFloorToTest(oneBillion)
+	require.Equal(t, uint64(oneBillion), cache.GasPriceFloor())
+
+	ok, added := cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1).withGasPrice(oneBillion - 1))
+	require.False(t, ok)
+	require.False(t, added)
+	require.False(t, cache.Has([]byte("hash-alice-1")))
+
+	ok, added = cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2).withGasPrice(oneBillion))
+	require.True(t, ok)
+	require.True(t, added)
+	require.Equal(t, uint64(1), cache.CountTx())
+	require.Equal(t, uint64(1), cache.Stats()["numRejectedUnderpriced"])
+}
+
+func TestTxCache_AddTxWithDynamicGasPriceFloor(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithGasPriceFloorToTest(oneBillion)
+	cache.SetGasPriceFloorHandler(func(fullness float64) uint64 {
+		if fullness >= 0.5 {
+			return 2 * oneBillion
+		}
+
+		return 0
+	})
+
+	ok, _ := cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	require.True(t, ok)
+	ok, _ = cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+	require.True(t, ok)
+
+	// the cache is half full, the floor being raised
+	require.Equal(t, uint64(2*oneBillion), cache.GasPriceFloor())
+	ok, _ = cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+	require.False(t, ok)
+	ok, _ = cache.AddTx(createTx([]byte("hash-alice-3-bis"), "alice", 3).withGasPrice(2 * oneBillion))
+	require.True(t, ok)
+	require.Equal(t, uint64(3), cache.CountTx())
+
+	// the static floor stays in place without the handler
+	cache.SetGasPriceFloorHandler(nil)
+	require.Equal(t, uint64(oneBillion), cache.GasPriceFloor())
+	ok, _ = cache.AddTx(createTx([]byte("hash-alice-4"), "alice", 4))
+	require.True(t, ok)
+	require.Equal(t, uint64(1), cache.Stats()["numRejectedUnderpriced"])
+}
@@ -2,7 +2,7 @@ package txcache
 
 import (
 	"encoding/json"
-	"fmt"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-storage/common"
 )
@@ -16,6 +16,7 @@ const maxNumItemsPerSenderLowerBound = 1
 const maxNumBytesPerSenderLowerBound = maxNumItemsPerSenderLowerBound * 1
 const maxNumBytesPerSenderUpperBound = 33_554_432 // 32 MB
 const numItemsToPreemptivelyEvictLowerBound = uint32(1)
+const numRecentSelectionsToKeepUpperBound = 1024
 
 // ConfigSourceMe holds cache configuration
 type ConfigSourceMe struct {
@@ -27,6 +28,26 @@ type ConfigSourceMe struct {
 	CountThreshold              uint32
 	CountPerSenderThreshold     uint32
 	NumItemsToPreemptivelyEvict uint32
+	// HashFilterFalsePositiveRate, if not zero, enables a bloom filter over the hashes of the transactions, so that Has
+	// answers for most of the unknown hashes without touching the maps. The rate should be in (0, 1)
+	HashFilterFalsePositiveRate float64
+	// EvictionStrategy selects which transactions are evicted first, EvictLowestPricePerUnit if not set
+	EvictionStrategy EvictionStrategy
+	// EvictionAgePenaltyWindow is the age halving the price per unit of a transaction, for EvictHybridWithAgePenalty
+	EvictionAgePenaltyWindow time.Duration
+	// NumRecentSelectionsToKeep, if not zero, is the number of the most recent selection outcomes kept by the cache,
+	// exposed by RecentSelections. It should not exceed 1024
+	NumRecentSelectionsToKeep uint32
+	// NumRemovedTxsToKeepForRollback, if not zero, is the number of the most recently removed transactions kept by the
+	// cache, so that OnRollback can add them back once their block is reverted
+	NumRemovedTxsToKeepForRollback uint32
+	// MinGasPriceForAdmission, if not zero, is the lowest gas price of the transactions added to the cache. A higher
+	// floor can be computed on the fly by the handler set with SetGasPriceFloorHandler
+	MinGasPriceForAdmission uint64
+	// PayloadArenaEnabled, if set, keeps the data payloads of the transactions in large shared chunks, instead of one
+	// heap object per payload, so that the garbage collector scans fewer objects. Only the data of the transactions
+	// handed over to the cache (see WrappedTransaction.IsOwnedByCache) is replaced by an identical copy held by the arena
+	PayloadArenaEnabled bool
 }
 
 type senderConstraints struct {
@@ -34,31 +55,32 @@ type senderConstraints struct {
 	maxNumBytes uint32
 }
 
-func (config *ConfigSourceMe) verify() error {
-	if len(config.Name) == 0 {
-		return fmt.Errorf("%w: config.Name is invalid", common.ErrInvalidConfig)
-	}
-	if config.NumChunks < numChunksLowerBound || config.NumChunks > numChunksUpperBound {
-		return fmt.Errorf("%w: config.NumChunks is invalid", common.ErrInvalidConfig)
-	}
-	if config.NumBytesPerSenderThreshold < maxNumBytesPerSenderLowerBound || config.NumBytesPerSenderThreshold > maxNumBytesPerSenderUpperBound {
-		return fmt.Errorf("%w: config.NumBytesPerSenderThreshold is invalid", common.ErrInvalidConfig)
-	}
-	if config.CountPerSenderThreshold < maxNumItemsPerSenderLowerBound {
-		return fmt.Errorf("%w: config.CountPerSenderThreshold is invalid", common.ErrInvalidConfig)
-	}
-
-	if config.NumBytesThreshold < maxNumBytesLowerBound || config.NumBytesThreshold > maxNumBytesUpperBound {
-		return fmt.Errorf("%w: config.NumBytesThreshold is invalid", common.ErrInvalidConfig)
-	}
-	if config.CountThreshold < maxNumItemsLowerBound {
-		return fmt.Errorf("%w: config.CountThreshold is invalid", common.ErrInvalidConfig)
-	}
-	if config.NumItemsToPreemptivelyEvict < numItemsToPreemptivelyEvictLowerBound {
-		return fmt.Errorf("%w: config.NumItemsToPreemptivelyEvict is invalid", common.ErrInvalidConfig)
-	}
-
-	return nil
+// Validate checks all the elements of the config, returning an aggregated error which names every invalid element
+func (config *ConfigSourceMe) Validate() error {
+	validator := common.ConfigValidator{}
+	validator.Check(len(config.Name) > 0, common.ErrInvalidConfig, "config.Name is required")
+	validator.Check(config.NumChunks >= numChunksLowerBound && config.NumChunks <= numChunksUpperBound, common.ErrInvalidConfig,
+		"config.NumChunks is %d, should be between %d and %d", config.NumChunks, numChunksLowerBound, numChunksUpperBound)
+	validator.Check(config.NumBytesPerSenderThreshold >= maxNumBytesPerSenderLowerBound && config.NumBytesPerSenderThreshold <= maxNumBytesPerSenderUpperBound, common.ErrInvalidConfig,
+		"config.NumBytesPerSenderThreshold is %d, should be between %d and %d", config.NumBytesPerSenderThreshold, maxNumBytesPerSenderLowerBound, maxNumBytesPerSenderUpperBound)
+	validator.Check(config.CountPerSenderThreshold >= maxNumItemsPerSenderLowerBound, common.ErrInvalidConfig,
+		"config.CountPerSenderThreshold is %d, minimum %d", config.CountPerSenderThreshold, maxNumItemsPerSenderLowerBound)
+	validator.Check(config.NumBytesThreshold >= maxNumBytesLowerBound && config.NumBytesThreshold <= maxNumBytesUpperBound, common.ErrInvalidConfig,
+		"config.NumBytesThreshold is %d, should be between %d and %d", config.NumBytesThreshold, maxNumBytesLowerBound, maxNumBytesUpperBound)
+	validator.Check(config.CountThreshold >= maxNumItemsLowerBound, common.ErrInvalidConfig,
+		"config.CountThreshold is %d, minimum %d", config.CountThreshold, maxNumItemsLowerBound)
+	validator.Check(config.NumItemsToPreemptivelyEvict >= numItemsToPreemptivelyEvictLowerBound, common.ErrInvalidConfig,
+		"config.NumItemsToPreemptivelyEvict is %d, minimum %d", config.NumItemsToPreemptivelyEvict, numItemsToPreemptivelyEvictLowerBound)
+	validator.Check(config.HashFilterFalsePositiveRate >= 0 && config.HashFilterFalsePositiveRate < 1, common.ErrInvalidConfig,
+		"config.HashFilterFalsePositiveRate is %v, should be 0 (disabled) or in (0, 1)", config.HashFilterFalsePositiveRate)
+	validator.Check(isKnownEvictionStrategy(config.EvictionStrategy), common.ErrInvalidConfig,
+		"config.EvictionStrategy is %q, unknown", config.EvictionStrategy)
+	validator.Check(config.EvictionStrategy != EvictHybridWithAgePenalty || config.EvictionAgePenaltyWindow > 0, common.ErrInvalidConfig,
+		"config.EvictionAgePenaltyWindow should be positive for the %s eviction strategy", EvictHybridWithAgePenalty)
+	validator.Check(config.NumRecentSelectionsToKeep <= numRecentSelectionsToKeepUpperBound, common.ErrInvalidConfig,
+		"config.NumRecentSelectionsToKeep is %d, maximum %d", config.NumRecentSelectionsToKeep, numRecentSelectionsToKeepUpperBound)
+
+	return validator.Err()
 }
 
 func (config *ConfigSourceMe) getSenderConstraints() senderConstraints {
@@ -87,24 +109,20 @@ type ConfigDestinationMe struct {
 	NumItemsToPreemptivelyEvict uint32
 }
 
-func (config *ConfigDestinationMe) verify() error {
-	if len(config.Name) == 0 {
-		return fmt.Errorf("%w: config.Name is invalid", common.ErrInvalidConfig)
-	}
-	if config.NumChunks < numChunksLowerBound || config.NumChunks > numChunksUpperBound {
-		return fmt.Errorf("%w: config.NumChunks is invalid", common.ErrInvalidConfig)
-	}
-	if config.MaxNumItems < maxNumItemsLowerBound {
-		return fmt.Errorf("%w: config.MaxNumItems is invalid", common.ErrInvalidConfig)
-	}
-	if config.MaxNumBytes < maxNumBytesLowerBound || config.MaxNumBytes > maxNumBytesUpperBound {
-		return fmt.Errorf("%w: config.MaxNumBytes is invalid", common.ErrInvalidConfig)
-	}
-	if config.NumItemsToPreemptivelyEvict < numItemsToPreemptivelyEvictLowerBound {
-		return fmt.Errorf("%w: config.NumItemsToPreemptivelyEvict is invalid", common.ErrInvalidConfig)
-	}
-
-	return nil
+// Validate checks all the elements of the config, returning an aggregated error which names every invalid element
+func (config *ConfigDestinationMe) Validate() error {
+	validator := common.ConfigValidator{}
+	validator.Check(len(config.Name) > 0, common.ErrInvalidConfig, "config.Name is required")
+	validator.Check(config.NumChunks >= numChunksLowerBound && config.NumChunks <= numChunksUpperBound, common.ErrInvalidConfig,
+		"config.NumChunks is %d, should be between %d and %d", config.NumChunks, numChunksLowerBound, numChunksUpperBound)
+	validator.Check(config.MaxNumItems >= maxNumItemsLowerBound, common.ErrInvalidConfig,
+		"config.MaxNumItems is %d, minimum %d", config.MaxNumItems, maxNumItemsLowerBound)
+	validator.Check(config.MaxNumBytes >= maxNumBytesLowerBound && config.MaxNumBytes <= maxNumBytesUpperBound, common.ErrInvalidConfig,
+		"config.MaxNumBytes is %d, should be between %d and %d", config.MaxNumBytes, maxNumBytesLowerBound, maxNumBytesUpperBound)
+	validator.Check(config.NumItemsToPreemptivelyEvict >= numItemsToPreemptivelyEvictLowerBound, common.ErrInvalidConfig,
+		"config.NumItemsToPreemptivelyEvict is %d, minimum %d", config.NumItemsToPreemptivelyEvict, numItemsToPreemptivelyEvictLowerBound)
+
+	return validator.Err()
 }
 
 // String returns a readable representation of the object
@@ -0,0 +1,53 @@
+package txcache
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSourceMe_Validate(t *testing.T) {
+	t.Parallel()
+
+	config := ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              math.MaxUint32,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+	require.Nil(t, config.Validate())
+
+	config = ConfigSourceMe{NumChunks: numChunksUpperBound + 1}
+	err := config.Validate()
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	for _, field := range []string{"Name", "NumChunks", "NumBytesPerSenderThreshold", "CountPerSenderThreshold",
+		"NumBytesThreshold", "CountThreshold", "NumItemsToPreemptivelyEvict"} {
+		require.Contains(t, err.Error(), "config."+field)
+	}
+}
+
+func TestConfigDestinationMe_Validate(t *testing.T) {
+	t.Parallel()
+
+	config := ConfigDestinationMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		MaxNumItems:                 maxNumItemsLowerBound,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+	require.Nil(t, config.Validate())
+
+	config = ConfigDestinationMe{MaxNumBytes: maxNumBytesUpperBound + 1}
+	err := config.Validate()
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	for _, field := range []string{"Name", "NumChunks", "MaxNumItems", "MaxNumBytes", "NumItemsToPreemptivelyEvict"} {
+		require.Contains(t, err.Error(), "config."+field)
+	}
+}
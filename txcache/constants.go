@@ -3,3 +3,8 @@ package txcache
 const diagnosisMaxTransactionsToDisplay = 10000
 const initialCapacityOfSelectionSlice = 30000
 const selectionLoopDurationCheckInterval = 10
+const maxNumRecentEvictions = 16
+const evictionStormMinNumPasses = 10
+const payloadArenaChunkSize = 65_536 // 64 KB, the memory a long-lived transaction can keep alive
+const minArenaPayloadSize = 64
+const maxArenaPayloadFractionOfChunk = 8
@@ -6,6 +6,8 @@ import (
 )
 
 var _ types.Cacher = (*CrossTxCache)(nil)
+var _ types.EvictionNotifier = (*CrossTxCache)(nil)
+var _ types.StatsProvider = (*CrossTxCache)(nil)
 
 // CrossTxCache holds cross-shard transactions (where destination == me)
 type CrossTxCache struct {
@@ -17,7 +19,7 @@ type CrossTxCache struct {
 func NewCrossTxCache(config ConfigDestinationMe) (*CrossTxCache, error) {
 	log.Debug("NewCrossTxCache", "config", config.String())
 
-	err := config.verify()
+	err := config.Validate()
 	if err != nil {
 		return nil, err
 	}
@@ -4,9 +4,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/bits"
 	"strings"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	logger "github.com/TerraDharitri/drt-go-chain-logger"
 )
 
@@ -21,6 +23,148 @@ type printedTransaction struct {
 	DataLength int    `json:"dataLength"`
 }
 
+// TxCacheDiagnostics holds a summary of the internal state of the cache
+type TxCacheDiagnostics struct {
+	Name              string
+	NumBytes          int
+	NumBytesThreshold uint32
+	NumTxs            uint64
+	NumSenders        uint64
+	RecentEvictions   []EvictionJournalInfo
+}
+
+// Diagnostics returns a summary of the internal state of the cache, including the most recent evictions
+func (cache *TxCache) Diagnostics() TxCacheDiagnostics {
+	cache.mutRecentEvictions.Lock()
+	recentEvictions := make([]EvictionJournalInfo, len(cache.recentEvictions))
+	copy(recentEvictions, cache.recentEvictions)
+	cache.mutRecentEvictions.Unlock()
+
+	return TxCacheDiagnostics{
+		Name:              cache.name,
+		NumBytes:          cache.NumBytes(),
+		NumBytesThreshold: cache.config.NumBytesThreshold,
+		NumTxs:            cache.CountTx(),
+		NumSenders:        cache.CountSenders(),
+		RecentEvictions:   recentEvictions,
+	}
+}
+
+// DiagnosticsSnapshot returns the Diagnostics, exposed by the monitoring debug handler
+func (cache *TxCache) DiagnosticsSnapshot() interface{} {
+	return cache.Diagnostics()
+}
+
+// SendersHistogramBucket counts the senders having between MinNumTxs and MaxNumTxs (both inclusive) transactions
+type SendersHistogramBucket struct {
+	MinNumTxs  uint64
+	MaxNumTxs  uint64
+	NumSenders uint64
+}
+
+// SendersDiagnostics describes how the transactions are distributed among the senders, and how many of them can not be
+// selected because of the gaps in the nonces of their senders
+type SendersDiagnostics struct {
+	NumSenders uint64
+	NumTxs     uint64
+	// TxsPerSenderHistogram buckets the senders by their number of transactions, the bounds of the buckets being the
+	// powers of two: 1, 2-3, 4-7 and so on
+	TxsPerSenderHistogram []SendersHistogramBucket
+	// NumSendersWithInitialGap counts the senders whose lowest nonce is higher than the nonce of their account
+	NumSendersWithInitialGap uint64
+	// NumSendersWithMiddleGap counts the senders missing a nonce between their lowest and highest nonces
+	NumSendersWithMiddleGap uint64
+	// NumTxsBehindGaps counts the transactions which can not be selected until the missing nonces arrive
+	NumTxsBehindGaps uint64
+	// NumTxsWithLowerNonce counts the transactions with nonces lower than the nonces of their accounts
+	NumTxsWithLowerNonce uint64
+	// NumDuplicateNonces counts the transactions having the same nonce as another transaction of their sender
+	NumDuplicateNonces uint64
+}
+
+// DiagnoseSenders inspects the transactions of each sender and reports their distribution and their nonce gaps. The
+// initial gaps are detected against the account nonces provided by the session; if the session is nil, the lowest
+// nonce of each sender is taken as its account nonce, thus only the middle gaps are reported.
+func (cache *TxCache) DiagnoseSenders(session SelectionSession) (*SendersDiagnostics, error) {
+	diagnostics := &SendersDiagnostics{
+		TxsPerSenderHistogram: make([]SendersHistogramBucket, 0),
+	}
+
+	for _, listForSender := range cache.getSenders() {
+		txs := listForSender.getTxs()
+		if len(txs) == 0 {
+			continue
+		}
+
+		accountNonce := txs[0].Tx.GetNonce()
+		if !check.IfNil(session) {
+			accountState, err := session.GetAccountState([]byte(listForSender.sender))
+			if err != nil {
+				return nil, err
+			}
+
+			accountNonce = accountState.Nonce
+		}
+
+		diagnostics.addSender(txs, accountNonce)
+	}
+
+	return diagnostics, nil
+}
+
+// addSender accounts the transactions of a sender, sorted by nonce
+func (diagnostics *SendersDiagnostics) addSender(txs []*WrappedTransaction, accountNonce uint64) {
+	diagnostics.NumSenders++
+	diagnostics.NumTxs += uint64(len(txs))
+	diagnostics.addToHistogram(uint64(len(txs)))
+
+	expectedNonce := accountNonce
+	hasGap := false
+	previousNonce := uint64(0)
+
+	for i, tx := range txs {
+		nonce := tx.Tx.GetNonce()
+		isDuplicate := i > 0 && nonce == previousNonce
+		previousNonce = nonce
+
+		if isDuplicate {
+			diagnostics.NumDuplicateNonces++
+		}
+		if nonce < accountNonce {
+			diagnostics.NumTxsWithLowerNonce++
+			continue
+		}
+		if !hasGap && nonce > expectedNonce {
+			hasGap = true
+			if expectedNonce == accountNonce {
+				diagnostics.NumSendersWithInitialGap++
+			} else {
+				diagnostics.NumSendersWithMiddleGap++
+			}
+		}
+		if hasGap {
+			diagnostics.NumTxsBehindGaps++
+			continue
+		}
+		if !isDuplicate {
+			expectedNonce++
+		}
+	}
+}
+
+func (diagnostics *SendersDiagnostics) addToHistogram(numTxs uint64) {
+	bucketIndex := bits.Len64(numTxs) - 1
+	for len(diagnostics.TxsPerSenderHistogram) <= bucketIndex {
+		minNumTxs := uint64(1) << len(diagnostics.TxsPerSenderHistogram)
+		diagnostics.TxsPerSenderHistogram = append(diagnostics.TxsPerSenderHistogram, SendersHistogramBucket{
+			MinNumTxs: minNumTxs,
+			MaxNumTxs: 2*minNumTxs - 1,
+		})
+	}
+
+	diagnostics.TxsPerSenderHistogram[bucketIndex].NumSenders++
+}
+
 // Diagnose checks the state of the cache for inconsistencies and displays a summary, senders and transactions.
 func (cache *TxCache) Diagnose(_ bool) {
 	cache.diagnoseCounters()
@@ -0,0 +1,101 @@
+package txcache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache_DiagnoseSenders(t *testing.T) {
+	t.Parallel()
+
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("alice-2"), "alice", 2))
+	cache.AddTx(createTx([]byte("alice-3"), "alice", 3))
+
+	cache.AddTx(createTx([]byte("bob-2"), "bob", 2))
+	cache.AddTx(createTx([]byte("bob-3"), "bob", 3))
+
+	cache.AddTx(createTx([]byte("carol-0"), "carol", 0))
+	cache.AddTx(createTx([]byte("carol-1"), "carol", 1))
+	cache.AddTx(createTx([]byte("carol-1-bis"), "carol", 1).withGasPrice(2 * oneBillion))
+	cache.AddTx(createTx([]byte("carol-3"), "carol", 3))
+	cache.AddTx(createTx([]byte("carol-4"), "carol", 4))
+
+	cache.AddTx(createTx([]byte("dave-4"), "dave", 4))
+	cache.AddTx(createTx([]byte("dave-5"), "dave", 5))
+
+	expectedHistogram := []SendersHistogramBucket{
+		{MinNumTxs: 1, MaxNumTxs: 1, NumSenders: 0},
+		{MinNumTxs: 2, MaxNumTxs: 3, NumSenders: 3},
+		{MinNumTxs: 4, MaxNumTxs: 7, NumSenders: 1},
+	}
+
+	t.Run("with session", func(t *testing.T) {
+		t.Parallel()
+
+		session := txcachemocks.NewSelectionSessionMock()
+		session.SetNonce([]byte("alice"), 1)
+		session.SetNonce([]byte("dave"), 5)
+
+		diagnostics, err := cache.DiagnoseSenders(session)
+		require.Nil(t, err)
+		require.Equal(t, &SendersDiagnostics{
+			NumSenders:               4,
+			NumTxs:                   12,
+			TxsPerSenderHistogram:    expectedHistogram,
+			NumSendersWithInitialGap: 1,
+			NumSendersWithMiddleGap:  1,
+			NumTxsBehindGaps:         4,
+			NumTxsWithLowerNonce:     1,
+			NumDuplicateNonces:       1,
+		}, diagnostics)
+	})
+
+	t.Run("without session", func(t *testing.T) {
+		t.Parallel()
+
+		diagnostics, err := cache.DiagnoseSenders(nil)
+		require.Nil(t, err)
+		require.Equal(t, &SendersDiagnostics{
+			NumSenders:               4,
+			NumTxs:                   12,
+			TxsPerSenderHistogram:    expectedHistogram,
+			NumSendersWithInitialGap: 0,
+			NumSendersWithMiddleGap:  1,
+			NumTxsBehindGaps:         2,
+			NumTxsWithLowerNonce:     0,
+			NumDuplicateNonces:       1,
+		}, diagnostics)
+	})
+
+	t.Run("with failing session", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		session := txcachemocks.NewSelectionSessionMock()
+		session.GetAccountStateCalled = func(_ []byte) (*types.AccountState, error) {
+			return nil, expectedErr
+		}
+
+		diagnostics, err := cache.DiagnoseSenders(session)
+		require.Equal(t, expectedErr, err)
+		require.Nil(t, diagnostics)
+	})
+}
+
+func TestTxCache_DiagnoseSendersOfEmptyCache(t *testing.T) {
+	t.Parallel()
+
+	cache := newUnconstrainedCacheToTest()
+
+	diagnostics, err := cache.DiagnoseSenders(txcachemocks.NewSelectionSessionMock())
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), diagnostics.NumSenders)
+	require.Empty(t, diagnostics.TxsPerSenderHistogram)
+}
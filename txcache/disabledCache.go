@@ -5,6 +5,8 @@ import (
 )
 
 var _ types.Cacher = (*DisabledCache)(nil)
+var _ types.EvictionNotifier = (*DisabledCache)(nil)
+var _ types.StatsProvider = (*DisabledCache)(nil)
 
 // DisabledCache represents a disabled cache
 type DisabledCache struct {
@@ -105,6 +107,14 @@ func (cache *DisabledCache) RegisterHandler(func(key []byte, value interface{}),
 func (cache *DisabledCache) UnRegisterHandler(string) {
 }
 
+// RegisterEvictionHandler does nothing
+func (cache *DisabledCache) RegisterEvictionHandler(types.EvictedItemHandler, string) {
+}
+
+// UnRegisterEvictionHandler does nothing
+func (cache *DisabledCache) UnRegisterEvictionHandler(string) {
+}
+
 // ImmunizeTxsAgainstEviction does nothing
 func (cache *DisabledCache) ImmunizeTxsAgainstEviction(_ [][]byte) {
 }
@@ -123,6 +133,11 @@ func (cache *DisabledCache) Close() error {
 	return nil
 }
 
+// Stats returns the stats of the disabled cache, which is always empty
+func (cache *DisabledCache) Stats() map[string]interface{} {
+	return types.CacheStats("DisabledTxCache", cache)
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (cache *DisabledCache) IsInterfaceNil() bool {
 	return cache == nil
@@ -0,0 +1,95 @@
+package txcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DumpFormat is the format of the transactions written by StreamDump
+type DumpFormat string
+
+const (
+	// DumpFormatNDJSON writes a JSON object per line, holding the summary of a transaction, as displayed by Diagnose
+	DumpFormatNDJSON DumpFormat = "ndjson"
+	// DumpFormatProtobuf writes each transaction as protobuf, prefixed by its length as an unsigned varint, the
+	// delimited format read by the protobuf tooling
+	DumpFormatProtobuf DumpFormat = "protobuf"
+)
+
+type protobufMarshaller interface {
+	Marshal() ([]byte, error)
+}
+
+// StreamDump writes the transactions of the cache to the writer, sender by sender, in the order of their nonces.
+// Only the transactions of one sender are held at a time, so that the pool is not copied in memory, while the
+// transactions added or removed meanwhile might be missed
+func (cache *TxCache) StreamDump(w io.Writer, format DumpFormat) error {
+	writeTx, err := newDumpWriter(format)
+	if err != nil {
+		return err
+	}
+
+	bufferedWriter := bufio.NewWriter(w)
+	numTxs := 0
+	for _, listForSender := range cache.getSenders() {
+		for _, tx := range listForSender.getTxs() {
+			err = writeTx(bufferedWriter, tx)
+			if err != nil {
+				return err
+			}
+			numTxs++
+		}
+	}
+
+	log.Debug("TxCache.StreamDump", "name", cache.name, "format", format, "num txs", numTxs)
+
+	return bufferedWriter.Flush()
+}
+
+func newDumpWriter(format DumpFormat) (func(w *bufio.Writer, tx *WrappedTransaction) error, error) {
+	switch format {
+	case DumpFormatNDJSON:
+		return writeTransactionAsJSONLine, nil
+	case DumpFormatProtobuf:
+		return writeTransactionAsDelimitedProtobuf, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownDumpFormat, format)
+	}
+}
+
+func writeTransactionAsJSONLine(w *bufio.Writer, tx *WrappedTransaction) error {
+	printedTxJSON, err := json.Marshal(convertWrappedTransactionToPrintedTransaction(tx))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(printedTxJSON)
+	if err != nil {
+		return err
+	}
+
+	return w.WriteByte('\n')
+}
+
+func writeTransactionAsDelimitedProtobuf(w *bufio.Writer, tx *WrappedTransaction) error {
+	marshaller, ok := tx.Tx.(protobufMarshaller)
+	if !ok {
+		return fmt.Errorf("%w: %T", errTransactionNotMarshalable, tx.Tx)
+	}
+
+	txBytes, err := marshaller.Marshal()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(binary.AppendUvarint(nil, uint64(len(txBytes))))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(txBytes)
+	return err
+}
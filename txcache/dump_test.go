@@ -0,0 +1,106 @@
+package txcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-core/data/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write(_ []byte) (int, error) {
+	return 0, w.err
+}
+
+func newCacheToDumpToTest() *TxCache {
+	cache := newUnconstrainedCacheToTest()
+	cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7).withGasPrice(2 * oneBillion))
+
+	return cache
+}
+
+func TestTxCache_StreamDump(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ndjson", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheToDumpToTest()
+		buffer := &bytes.Buffer{}
+		err := cache.StreamDump(buffer, DumpFormatNDJSON)
+		require.Nil(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+		require.Len(t, lines, 3)
+
+		nonces := make(map[string][]uint64)
+		for _, line := range lines {
+			printedTx := &printedTransaction{}
+			require.Nil(t, json.Unmarshal([]byte(line), printedTx))
+			nonces[printedTx.Sender] = append(nonces[printedTx.Sender], printedTx.Nonce)
+		}
+		require.Equal(t, map[string][]uint64{
+			"616c696365": {1, 2},
+			"626f62":     {7},
+		}, nonces)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheToDumpToTest()
+		buffer := &bytes.Buffer{}
+		err := cache.StreamDump(buffer, DumpFormatProtobuf)
+		require.Nil(t, err)
+
+		reader := bufio.NewReader(buffer)
+		gasPrices := make(map[uint64]uint64)
+		for {
+			size, errRead := binary.ReadUvarint(reader)
+			if errRead == io.EOF {
+				break
+			}
+			require.Nil(t, errRead)
+
+			txBytes := make([]byte, size)
+			_, errRead = io.ReadFull(reader, txBytes)
+			require.Nil(t, errRead)
+
+			tx := &transaction.Transaction{}
+			require.Nil(t, tx.Unmarshal(txBytes))
+			gasPrices[tx.Nonce] = tx.GasPrice
+		}
+		require.Equal(t, map[uint64]uint64{1: oneBillion, 2: oneBillion, 7: 2 * oneBillion}, gasPrices)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheToDumpToTest()
+		buffer := &bytes.Buffer{}
+		err := cache.StreamDump(buffer, "xml")
+		require.True(t, errors.Is(err, errUnknownDumpFormat))
+		require.Zero(t, buffer.Len())
+	})
+
+	t.Run("failing writer", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheToDumpToTest()
+		expectedErr := errors.New("expected error")
+		err := cache.StreamDump(&failingWriter{err: expectedErr}, DumpFormatNDJSON)
+		require.Equal(t, expectedErr, err)
+	})
+}
@@ -6,3 +6,5 @@ var errNilMempoolHost = errors.New("nil mempool host")
 var errNilSelectionSession = errors.New("nil selection session")
 var errItemAlreadyInCache = errors.New("item already in cache")
 var errEmptyBunchOfTransactions = errors.New("empty bunch of transactions")
+var errUnknownDumpFormat = errors.New("unknown dump format")
+var errTransactionNotMarshalable = errors.New("transaction can not be marshalled to protobuf")
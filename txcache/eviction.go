@@ -2,8 +2,14 @@ package txcache
 
 import (
 	"container/heap"
+	"context"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/tracing"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // evictionJournal keeps a short journal about the eviction process
@@ -13,6 +19,25 @@ type evictionJournal struct {
 	numEvictedByPass []int
 }
 
+// EvictionJournalInfo describes a past eviction
+type EvictionJournalInfo struct {
+	Time             time.Time
+	Duration         time.Duration
+	NumEvicted       int
+	NumEvictedByPass []int
+}
+
+// recordEviction keeps the journal among the most recent ones, exposed by the diagnostics
+func (cache *TxCache) recordEviction(journal EvictionJournalInfo) {
+	cache.mutRecentEvictions.Lock()
+	defer cache.mutRecentEvictions.Unlock()
+
+	if len(cache.recentEvictions) == maxNumRecentEvictions {
+		cache.recentEvictions = cache.recentEvictions[1:]
+	}
+	cache.recentEvictions = append(cache.recentEvictions, journal)
+}
+
 // doEviction does cache eviction.
 // We do not allow more evictions to start concurrently.
 func (cache *TxCache) doEviction() *evictionJournal {
@@ -43,9 +68,27 @@ func (cache *TxCache) doEviction() *evictionJournal {
 	stopWatch := core.NewStopWatch()
 	stopWatch.Start("eviction")
 
-	evictionJournal := cache.evictLeastLikelyToSelectTransactions()
+	_, span := tracing.StartSpan(context.Background(), "txcache.Eviction",
+		attribute.String("cache", cache.name),
+		attribute.String("reason", string(cache.decideEvictionReason())),
+	)
+	startTime := time.Now()
+	evictionJournal := cache.evictLeastLikelyToSelectTransactions(cache.needsEvictionForCapacity)
+	cache.rebuildHashFilter()
+	span.SetAttributes(attribute.Int("num_evicted", evictionJournal.numEvicted), attribute.Int("num_passes", len(evictionJournal.numEvictedByPass)))
+	span.End()
 
 	stopWatch.Stop("eviction")
+	cache.recordEviction(EvictionJournalInfo{
+		Time:             startTime,
+		Duration:         stopWatch.GetMeasurement("eviction"),
+		NumEvicted:       evictionJournal.numEvicted,
+		NumEvictedByPass: evictionJournal.numEvictedByPass,
+	})
+	if len(evictionJournal.numEvictedByPass) >= evictionStormMinNumPasses {
+		monitoring.PublishEvent(monitoring.NewEvictionStormEvent(cache.name, evictionJournal.numEvicted,
+			stopWatch.GetMeasurement("eviction"), string(cache.decideEvictionReason())))
+	}
 
 	logRemove.Debug(
 		"doEviction: after eviction",
@@ -59,11 +102,69 @@ func (cache *TxCache) doEviction() *evictionJournal {
 	return evictionJournal
 }
 
+// Shrink evicts the given percentage of the transactions, the least likely to be selected first, regardless of the
+// capacity. It returns the number of evicted transactions, zero if an eviction is already in progress
+func (cache *TxCache) Shrink(percentage uint32) int {
+	if percentage == 0 || cache.isEvictionInProgress.IsSet() {
+		return 0
+	}
+	if percentage > 100 {
+		percentage = 100
+	}
+
+	cache.evictionMutex.Lock()
+	defer cache.evictionMutex.Unlock()
+
+	_ = cache.isEvictionInProgress.SetReturningPrevious()
+	defer cache.isEvictionInProgress.Reset()
+
+	targetNumTxs := cache.CountTx() * uint64(100-percentage) / 100
+	_, span := tracing.StartSpan(context.Background(), "txcache.Eviction",
+		attribute.String("cache", cache.name),
+		attribute.String("reason", string(types.EvictionReasonMemoryPressure)),
+	)
+	startTime := time.Now()
+	journal := cache.evictLeastLikelyToSelectTransactions(func() (bool, types.EvictionReason) {
+		return cache.CountTx() > targetNumTxs, types.EvictionReasonMemoryPressure
+	})
+	cache.rebuildHashFilter()
+	span.SetAttributes(attribute.Int("num_evicted", journal.numEvicted), attribute.Int("num_passes", len(journal.numEvictedByPass)))
+	span.End()
+	cache.recordEviction(EvictionJournalInfo{
+		Time:             startTime,
+		Duration:         time.Since(startTime),
+		NumEvicted:       journal.numEvicted,
+		NumEvictedByPass: journal.numEvictedByPass,
+	})
+
+	logRemove.Debug("Shrink", "percentage", percentage, "evicted txs", journal.numEvicted, "num now", cache.CountTx())
+
+	return journal.numEvicted
+}
+
 func (cache *TxCache) isCapacityExceeded() bool {
 	exceeded := cache.areThereTooManyBytes() || cache.areThereTooManySenders() || cache.areThereTooManyTxs()
 	return exceeded
 }
 
+// needsEvictionForCapacity tells whether an eviction pass is needed because the capacity is exceeded, and why
+func (cache *TxCache) needsEvictionForCapacity() (bool, types.EvictionReason) {
+	if !cache.isCapacityExceeded() {
+		return false, ""
+	}
+
+	return true, cache.decideEvictionReason()
+}
+
+// decideEvictionReason tells which of the limits requires an eviction pass, the number of bytes taking precedence
+func (cache *TxCache) decideEvictionReason() types.EvictionReason {
+	if cache.areThereTooManyBytes() {
+		return types.EvictionReasonSize
+	}
+
+	return types.EvictionReasonCapacity
+}
+
 func (cache *TxCache) areThereTooManyBytes() bool {
 	numBytes := cache.NumBytes()
 	tooManyBytes := numBytes > int(cache.config.NumBytesThreshold)
@@ -83,7 +184,8 @@ func (cache *TxCache) areThereTooManyTxs() bool {
 }
 
 // Eviction tolerates concurrent transaction additions / removals.
-func (cache *TxCache) evictLeastLikelyToSelectTransactions() *evictionJournal {
+// The passes continue as long as needsEviction says so, and there are transactions left to evict.
+func (cache *TxCache) evictLeastLikelyToSelectTransactions(needsEviction func() (bool, types.EvictionReason)) *evictionJournal {
 	senders := cache.getSenders()
 	bunches := make([]bunchOfTransactions, 0, len(senders))
 
@@ -98,7 +200,7 @@ func (cache *TxCache) evictLeastLikelyToSelectTransactions() *evictionJournal {
 
 	// Heap is reused among passes.
 	// Items popped from the heap are added to "transactionsToEvict" (slice is re-created in each pass).
-	transactionsHeap := newMinTransactionsHeap(len(bunches))
+	transactionsHeap := newEvictionHeap(len(bunches), newEvictionStrategy(cache.config, time.Now()))
 	heap.Init(transactionsHeap)
 
 	// Initialize the heap with the first transaction of each bunch
@@ -112,13 +214,18 @@ func (cache *TxCache) evictLeastLikelyToSelectTransactions() *evictionJournal {
 		heap.Push(transactionsHeap, item)
 	}
 
-	for pass := 0; cache.isCapacityExceeded(); pass++ {
+	for pass := 0; ; pass++ {
+		shouldEvict, reason := needsEviction()
+		if !shouldEvict {
+			break
+		}
+
 		transactionsToEvict := make(bunchOfTransactions, 0, cache.config.NumItemsToPreemptivelyEvict)
 		transactionsToEvictHashes := make([][]byte, 0, cache.config.NumItemsToPreemptivelyEvict)
 
 		// Select transactions (sorted).
 		for transactionsHeap.Len() > 0 {
-			// Always pick the "worst" transaction.
+			// Always pick the "worst" transaction, as decided by the eviction strategy.
 			item := heap.Pop(transactionsHeap).(*transactionsHeapItem)
 
 			if len(transactionsToEvict) >= int(cache.config.NumItemsToPreemptivelyEvict) {
@@ -157,7 +264,7 @@ func (cache *TxCache) evictLeastLikelyToSelectTransactions() *evictionJournal {
 		}
 
 		// Remove those transactions from "txByHash".
-		_ = cache.txByHash.RemoveTxsBulk(transactionsToEvictHashes)
+		cache.removeEvictedTxs(transactionsToEvictHashes, reason)
 
 		journal.numEvictedByPass = append(journal.numEvictedByPass, len(transactionsToEvict))
 		journal.numEvicted += len(transactionsToEvict)
@@ -167,3 +274,14 @@ func (cache *TxCache) evictLeastLikelyToSelectTransactions() *evictionJournal {
 
 	return journal
 }
+
+// removeEvictedTxs removes the evicted transactions from "txByHash" and notifies the eviction handlers.
+// Transactions concurrently removed by other means are not notified.
+func (cache *TxCache) removeEvictedTxs(txHashes [][]byte, reason types.EvictionReason) {
+	for _, txHash := range txHashes {
+		tx, removed := cache.txByHash.removeTx(string(txHash))
+		if removed {
+			cache.callEvictionHandlers(tx, reason)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package txcache
+
+import (
+	"time"
+)
+
+// EvictionStrategy selects which transactions are evicted first
+type EvictionStrategy string
+
+const (
+	// EvictLowestPricePerUnit evicts first the transactions the least likely to be selected, those having the lowest
+	// price per unit. It is the default strategy
+	EvictLowestPricePerUnit EvictionStrategy = "LowestPricePerUnit"
+	// EvictOldestFirst evicts first the transactions received the earliest
+	EvictOldestFirst EvictionStrategy = "OldestFirst"
+	// EvictLargestFirst evicts first the largest transactions, freeing the most bytes with each eviction
+	EvictLargestFirst EvictionStrategy = "LargestFirst"
+	// EvictHybridWithAgePenalty evicts first the transactions having the lowest price per unit, lowered by their age:
+	// a transaction waiting for EvictionAgePenaltyWindow competes with half its price per unit
+	EvictHybridWithAgePenalty EvictionStrategy = "HybridWithAgePenalty"
+)
+
+func isKnownEvictionStrategy(strategy EvictionStrategy) bool {
+	switch strategy {
+	case "", EvictLowestPricePerUnit, EvictOldestFirst, EvictLargestFirst, EvictHybridWithAgePenalty:
+		return true
+	default:
+		return false
+	}
+}
+
+// evictionStrategy orders the transactions competing to be evicted
+type evictionStrategy interface {
+	// evictsBefore returns true if the transaction should be evicted before the other one
+	evictsBefore(tx *WrappedTransaction, other *WrappedTransaction) bool
+}
+
+// newEvictionStrategy creates the configured strategy. The ages are measured against the provided time, the start of
+// the eviction
+func newEvictionStrategy(config ConfigSourceMe, now time.Time) evictionStrategy {
+	switch config.EvictionStrategy {
+	case EvictOldestFirst:
+		return &oldestFirstStrategy{}
+	case EvictLargestFirst:
+		return &largestFirstStrategy{}
+	case EvictHybridWithAgePenalty:
+		return &hybridWithAgePenaltyStrategy{
+			now:              now,
+			agePenaltyWindow: config.EvictionAgePenaltyWindow,
+		}
+	default:
+		return &lowestPricePerUnitStrategy{}
+	}
+}
+
+type lowestPricePerUnitStrategy struct {
+}
+
+func (strategy *lowestPricePerUnitStrategy) evictsBefore(tx *WrappedTransaction, other *WrappedTransaction) bool {
+	return other.isTransactionMoreValuableForNetwork(tx)
+}
+
+type oldestFirstStrategy struct {
+	lowestPricePerUnitStrategy
+}
+
+func (strategy *oldestFirstStrategy) evictsBefore(tx *WrappedTransaction, other *WrappedTransaction) bool {
+	if !tx.ReceivedAt.Equal(other.ReceivedAt) {
+		return tx.ReceivedAt.Before(other.ReceivedAt)
+	}
+
+	return strategy.lowestPricePerUnitStrategy.evictsBefore(tx, other)
+}
+
+type largestFirstStrategy struct {
+	lowestPricePerUnitStrategy
+}
+
+func (strategy *largestFirstStrategy) evictsBefore(tx *WrappedTransaction, other *WrappedTransaction) bool {
+	if tx.Size != other.Size {
+		return tx.Size > other.Size
+	}
+
+	return strategy.lowestPricePerUnitStrategy.evictsBefore(tx, other)
+}
+
+type hybridWithAgePenaltyStrategy struct {
+	lowestPricePerUnitStrategy
+	now              time.Time
+	agePenaltyWindow time.Duration
+}
+
+func (strategy *hybridWithAgePenaltyStrategy) evictsBefore(tx *WrappedTransaction, other *WrappedTransaction) bool {
+	score := strategy.score(tx)
+	otherScore := strategy.score(other)
+	if score != otherScore {
+		return score < otherScore
+	}
+
+	return strategy.lowestPricePerUnitStrategy.evictsBefore(tx, other)
+}
+
+// score is the price per unit, divided by 1 + age / agePenaltyWindow
+func (strategy *hybridWithAgePenaltyStrategy) score(tx *WrappedTransaction) float64 {
+	age := strategy.now.Sub(tx.ReceivedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	return float64(tx.PricePerUnit) / (1 + float64(age)/float64(strategy.agePenaltyWindow))
+}
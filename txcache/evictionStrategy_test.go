@@ -0,0 +1,124 @@
+package txcache
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+// the gas limit covering exactly the movement of a transaction of 1000 bytes, so that its price per unit is its gas price
+const largeTxGasLimit = 50_000 + (1000-estimatedSizeOfBoundedTxFields)*1500
+
+func newEvictionConfigToTest(strategy EvictionStrategy) ConfigSourceMe {
+	return ConfigSourceMe{
+		Name:                        "untitled",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              4,
+		CountPerSenderThreshold:     math.MaxUint32,
+		EvictionEnabled:             false,
+		NumItemsToPreemptivelyEvict: 1,
+		EvictionStrategy:            strategy,
+		EvictionAgePenaltyWindow:    time.Minute,
+	}
+}
+
+func TestConfigSourceMe_ValidateEvictionStrategy(t *testing.T) {
+	t.Parallel()
+
+	config := newEvictionConfigToTest("unknown")
+	err := config.Validate()
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	require.Contains(t, err.Error(), "config.EvictionStrategy")
+
+	config = newEvictionConfigToTest(EvictHybridWithAgePenalty)
+	config.EvictionAgePenaltyWindow = 0
+	err = config.Validate()
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	require.Contains(t, err.Error(), "config.EvictionAgePenaltyWindow")
+
+	for _, strategy := range []EvictionStrategy{"", EvictLowestPricePerUnit, EvictOldestFirst, EvictLargestFirst, EvictHybridWithAgePenalty} {
+		config = newEvictionConfigToTest(strategy)
+		require.Nil(t, config.Validate())
+	}
+}
+
+func TestEvictionStrategies_evictsBefore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	host := txcachemocks.NewMempoolHostMock()
+	// cheap, recent and small
+	cheap := createTx([]byte("cheap"), "a", 1).withGasPrice(oneBillion)
+	cheap.precomputeFields(host)
+	cheap.ReceivedAt = now.Add(-time.Second)
+	// twice as expensive, old and large
+	expensive := createTx([]byte("expensive"), "b", 1).withGasPrice(2 * oneBillion).withSize(1000).withGasLimit(largeTxGasLimit)
+	expensive.precomputeFields(host)
+	expensive.ReceivedAt = now.Add(-10 * time.Minute)
+
+	config := newEvictionConfigToTest(EvictLowestPricePerUnit)
+	require.True(t, newEvictionStrategy(config, now).evictsBefore(cheap, expensive))
+	require.False(t, newEvictionStrategy(config, now).evictsBefore(expensive, cheap))
+
+	config.EvictionStrategy = EvictOldestFirst
+	require.True(t, newEvictionStrategy(config, now).evictsBefore(expensive, cheap))
+
+	config.EvictionStrategy = EvictLargestFirst
+	require.True(t, newEvictionStrategy(config, now).evictsBefore(expensive, cheap))
+
+	// after 10 windows, the expensive transaction competes with less than a fifth of its price per unit
+	config.EvictionStrategy = EvictHybridWithAgePenalty
+	require.True(t, newEvictionStrategy(config, now).evictsBefore(expensive, cheap))
+
+	// at the time of its reception, the expensive transaction is kept
+	require.True(t, newEvictionStrategy(config, expensive.ReceivedAt).evictsBefore(cheap, expensive))
+
+	// on ties, the price per unit decides
+	sameAge := createTx([]byte("sameAge"), "c", 1).withGasPrice(2 * oneBillion)
+	sameAge.precomputeFields(host)
+	sameAge.ReceivedAt = cheap.ReceivedAt
+	config.EvictionStrategy = EvictOldestFirst
+	require.True(t, newEvictionStrategy(config, now).evictsBefore(cheap, sameAge))
+}
+
+func TestTxCache_DoEvictionWithStrategies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	addTxs := func(cache *TxCache) {
+		cache.AddTx(withReceivedAt(createTx([]byte("hash-alice"), "alice", 1).withGasPrice(1*oneBillion), now.Add(-time.Second)))
+		cache.AddTx(withReceivedAt(createTx([]byte("hash-bob"), "bob", 1).withGasPrice(2*oneBillion), now.Add(-time.Hour)))
+		cache.AddTx(withReceivedAt(createTx([]byte("hash-carol"), "carol", 1).withGasPrice(3*oneBillion).withSize(1000).withGasLimit(3*oneMilion), now))
+		cache.AddTx(withReceivedAt(createTx([]byte("hash-eve"), "eve", 1).withGasPrice(4*oneBillion), now))
+		cache.AddTx(withReceivedAt(createTx([]byte("hash-dan"), "dan", 1).withGasPrice(5*oneBillion), now))
+	}
+
+	expectedEvicted := map[EvictionStrategy]string{
+		EvictLowestPricePerUnit:   "hash-alice",
+		EvictOldestFirst:          "hash-bob",
+		EvictLargestFirst:         "hash-carol",
+		EvictHybridWithAgePenalty: "hash-bob",
+	}
+	for strategy, evicted := range expectedEvicted {
+		cache, err := NewTxCache(newEvictionConfigToTest(strategy), txcachemocks.NewMempoolHostMock())
+		require.Nil(t, err)
+		addTxs(cache)
+
+		journal := cache.doEviction()
+		require.Equal(t, 1, journal.numEvicted, strategy)
+		require.False(t, cache.Has([]byte(evicted)), strategy)
+		require.Equal(t, uint64(4), cache.CountTx(), strategy)
+	}
+}
+
+func withReceivedAt(tx *WrappedTransaction, receivedAt time.Time) *WrappedTransaction {
+	tx.ReceivedAt = receivedAt
+	return tx
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,6 +47,59 @@ func TestTxCache_DoEviction_BecauseOfCount(t *testing.T) {
 	require.Equal(t, uint64(4), cache.CountTx())
 }
 
+func TestTxCache_DoEviction_ShouldNotifyEvictionHandlers(t *testing.T) {
+	config := ConfigSourceMe{
+		Name:                        "untitled",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              4,
+		CountPerSenderThreshold:     math.MaxUint32,
+		EvictionEnabled:             true,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+
+	cache, err := NewTxCache(config, txcachemocks.NewMempoolHostMock())
+	require.Nil(t, err)
+
+	type evictedTx struct {
+		hash   string
+		tx     interface{}
+		reason types.EvictionReason
+	}
+	chEvicted := make(chan evictedTx, 10)
+	cache.RegisterEvictionHandler(func(key []byte, value interface{}, reason types.EvictionReason) {
+		chEvicted <- evictedTx{hash: string(key), tx: value, reason: reason}
+	}, "id")
+
+	alice := createTx([]byte("hash-alice"), "alice", 1).withGasPrice(1 * oneBillion)
+	cache.AddTx(alice)
+	cache.AddTx(createTx([]byte("hash-bob"), "bob", 1).withGasPrice(2 * oneBillion))
+	cache.AddTx(createTx([]byte("hash-carol"), "carol", 1).withGasPrice(3 * oneBillion))
+	cache.AddTx(createTx([]byte("hash-eve"), "eve", 1).withGasPrice(4 * oneBillion))
+	cache.AddTx(createTx([]byte("hash-dan"), "dan", 1).withGasPrice(5 * oneBillion))
+
+	journal := cache.doEviction()
+	require.Equal(t, 1, journal.numEvicted)
+
+	select {
+	case evicted := <-chEvicted:
+		require.Equal(t, "hash-alice", evicted.hash)
+		require.True(t, evicted.tx == alice)
+		require.Equal(t, types.EvictionReasonCapacity, evicted.reason)
+	case <-time.After(time.Second * 2):
+		require.Fail(t, "eviction handler should have been called")
+	}
+
+	// explicit removals are not notified
+	cache.RemoveTxByHash([]byte("hash-bob"))
+	select {
+	case <-chEvicted:
+		require.Fail(t, "eviction handler should not have been called")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
 func TestTxCache_DoEviction_BecauseOfSize(t *testing.T) {
 	config := ConfigSourceMe{
 		Name:                        "untitled",
@@ -121,6 +176,65 @@ func TestTxCache_DoEviction_DoesNothingWhenAlreadyInProgress(t *testing.T) {
 	require.Equal(t, 4, int(cache.CountTx()))
 }
 
+func TestTxCache_DoEviction_ShouldKeepTheRecentJournals(t *testing.T) {
+	config := ConfigSourceMe{
+		Name:                        "TestTxCache_DoEviction_ShouldKeepTheRecentJournals",
+		NumChunks:                   1,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              4,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+	}
+
+	cache, err := NewTxCache(config, txcachemocks.NewMempoolHostMock())
+	require.Nil(t, err)
+
+	numEvictions := maxNumRecentEvictions + 3
+	for nonce := 1; nonce <= int(config.CountThreshold)+numEvictions; nonce++ {
+		cache.AddTx(createTx([]byte(fmt.Sprintf("hash-%d", nonce)), "alice", uint64(nonce)))
+		journal := cache.doEviction()
+		require.Equal(t, nonce > int(config.CountThreshold), journal != nil)
+	}
+
+	diagnostics := cache.Diagnostics()
+	require.Equal(t, config.Name, diagnostics.Name)
+	require.Equal(t, uint64(config.CountThreshold), diagnostics.NumTxs)
+	require.Len(t, diagnostics.RecentEvictions, maxNumRecentEvictions)
+	for _, journal := range diagnostics.RecentEvictions {
+		require.Equal(t, 1, journal.NumEvicted)
+		require.Equal(t, []int{1}, journal.NumEvictedByPass)
+	}
+	require.Equal(t, diagnostics, cache.DiagnosticsSnapshot())
+	require.Nil(t, cache.Close())
+}
+
+func TestTxCache_Shrink(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	evictedReasons := make(chan types.EvictionReason, 10)
+	cache.RegisterEvictionHandler(func(_ []byte, _ interface{}, reason types.EvictionReason) {
+		evictedReasons <- reason
+	}, "id")
+
+	for i := 0; i < 10; i++ {
+		sender := fmt.Sprintf("sender-%d", i)
+		cache.AddTx(createTx([]byte("hash-"+sender), sender, 1))
+	}
+
+	require.Equal(t, 0, cache.Shrink(0))
+	require.Equal(t, 4, cache.Shrink(40))
+	require.Equal(t, uint64(6), cache.CountTx())
+	require.Equal(t, types.EvictionReasonMemoryPressure, <-evictedReasons)
+	require.Len(t, cache.Diagnostics().RecentEvictions, 1)
+
+	_ = cache.isEvictionInProgress.SetReturningPrevious()
+	require.Equal(t, 0, cache.Shrink(100))
+	cache.isEvictionInProgress.Reset()
+
+	require.True(t, cache.Shrink(100) > 0)
+	require.True(t, cache.CountTx() < 6)
+}
+
 func TestBenchmarkTxCache_DoEviction(t *testing.T) {
 	config := ConfigSourceMe{
 		Name:                        "untitled",
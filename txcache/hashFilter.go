@@ -0,0 +1,77 @@
+package txcache
+
+import (
+	"sync/atomic"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/bloom"
+)
+
+// hashFilter is a bloom filter over the hashes of the cached transactions, answering Has for the unknown hashes, the
+// dominant case when deduplicating the intercepted transactions, without touching the chunks of the maps. As the bloom
+// filter can not remove keys, it is rebuilt from the cached hashes after each eviction, and once maxNumTxs hashes were
+// added since the last rebuild. The filter is sized for twice maxNumTxs, so that it holds the stale hashes as well
+type hashFilter struct {
+	config               bloom.Config
+	maxNumTxs            uint64
+	filter               atomic.Pointer[bloom.Filter]
+	numAddedSinceRebuild atomic.Uint64
+}
+
+func newHashFilter(maxNumTxs uint64, falsePositiveRate float64) (*hashFilter, error) {
+	config := bloom.Config{
+		ExpectedNumKeys:   2 * maxNumTxs,
+		FalsePositiveRate: falsePositiveRate,
+	}
+	filter, err := bloom.NewFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	hf := &hashFilter{
+		config:    config,
+		maxNumTxs: maxNumTxs,
+	}
+	hf.filter.Store(filter)
+
+	return hf, nil
+}
+
+// add adds the hash, returning true if the filter got full and should be rebuilt
+func (hf *hashFilter) add(txHash []byte) bool {
+	hf.filter.Load().Add(txHash)
+	return hf.numAddedSinceRebuild.Add(1) == hf.maxNumTxs
+}
+
+func (hf *hashFilter) mayContain(txHash []byte) bool {
+	return hf.filter.Load().MayContain(txHash)
+}
+
+// rebuild replaces the filter with a new one, holding only the provided hashes. The caller should prevent concurrent
+// additions, which could be lost
+func (hf *hashFilter) rebuild(txHashes [][]byte) {
+	filter, err := bloom.NewFilter(hf.config)
+	if err != nil {
+		log.Error("hashFilter.rebuild", "error", err)
+		return
+	}
+
+	for _, txHash := range txHashes {
+		filter.Add(txHash)
+	}
+	hf.filter.Store(filter)
+	hf.numAddedSinceRebuild.Store(0)
+}
+
+// rebuildHashFilter rebuilds the filter over the hashes of the cached transactions, if the filter is enabled
+func (cache *TxCache) rebuildHashFilter() {
+	if cache.hashFilter == nil {
+		return
+	}
+
+	cache.mutTxOperation.Lock()
+	defer cache.mutTxOperation.Unlock()
+
+	txHashes := cache.txByHash.keys()
+	cache.hashFilter.rebuild(txHashes)
+	logRemove.Debug("TxCache.rebuildHashFilter", "name", cache.name, "num hashes", len(txHashes))
+}
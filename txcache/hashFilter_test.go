@@ -0,0 +1,146 @@
+package txcache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithHashFilterToTest(countThreshold uint32, evictionEnabled bool) *TxCache {
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              countThreshold,
+		CountPerSenderThreshold:     math.MaxUint32,
+		EvictionEnabled:             evictionEnabled,
+		NumItemsToPreemptivelyEvict: 1,
+		HashFilterFalsePositiveRate: 0.01,
+	}, txcachemocks.NewMempoolHostMock())
+	if err != nil {
+		panic(fmt.Sprintf("newCacheWithHashFilterToTest(): %s", err))
+	}
+
+	return cache
+}
+
+func TestTxCache_HashFilterConfig(t *testing.T) {
+	t.Parallel()
+
+	config := ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              100,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+		HashFilterFalsePositiveRate: 1,
+	}
+	cache, err := NewTxCache(config, txcachemocks.NewMempoolHostMock())
+	require.Nil(t, cache)
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	require.Contains(t, err.Error(), "HashFilterFalsePositiveRate")
+
+	config.HashFilterFalsePositiveRate = 0
+	cache, err = NewTxCache(config, txcachemocks.NewMempoolHostMock())
+	require.Nil(t, err)
+	require.Nil(t, cache.hashFilter)
+}
+
+func TestTxCache_HasWithHashFilter(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithHashFilterToTest(1000, false)
+	for nonce := uint64(0); nonce < 100; nonce++ {
+		cache.AddTx(createTx([]byte(fmt.Sprintf("hash-alice-%d", nonce)), "alice", nonce))
+	}
+
+	for nonce := 0; nonce < 100; nonce++ {
+		require.True(t, cache.Has([]byte(fmt.Sprintf("hash-alice-%d", nonce))))
+	}
+
+	numFilteredOut := 0
+	for i := 0; i < 1000; i++ {
+		unknownHash := []byte(fmt.Sprintf("unknown-%d", i))
+		require.False(t, cache.Has(unknownHash))
+		if !cache.hashFilter.mayContain(unknownHash) {
+			numFilteredOut++
+		}
+	}
+	require.Greater(t, numFilteredOut, 950)
+
+	// the removed hashes are still in the filter, but not in the maps
+	require.True(t, cache.RemoveTxByHash([]byte("hash-alice-99")))
+	require.True(t, cache.hashFilter.mayContain([]byte("hash-alice-99")))
+	require.False(t, cache.Has([]byte("hash-alice-99")))
+
+	cache.Clear()
+	require.False(t, cache.hashFilter.mayContain([]byte("hash-alice-0")))
+	require.False(t, cache.Has([]byte("hash-alice-0")))
+}
+
+func TestTxCache_HashFilterShouldBeRebuiltAfterEviction(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithHashFilterToTest(100, true)
+	for nonce := uint64(0); nonce < 150; nonce++ {
+		cache.AddTx(createTx([]byte(fmt.Sprintf("hash-alice-%d", nonce)), "alice", nonce))
+	}
+
+	require.LessOrEqual(t, cache.CountTx(), uint64(101))
+	// the additions were counted from the last rebuild, following the last eviction
+	require.Less(t, cache.hashFilter.numAddedSinceRebuild.Load(), uint64(50))
+	for _, txHash := range cache.txByHash.keys() {
+		require.True(t, cache.Has(txHash))
+	}
+}
+
+func TestTxCache_HashFilterShouldBeRebuiltOnceFull(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithHashFilterToTest(100, false)
+	for nonce := uint64(0); nonce < 250; nonce++ {
+		txHash := []byte(fmt.Sprintf("hash-alice-%d", nonce))
+		cache.AddTx(createTx(txHash, "alice", nonce))
+		if nonce%2 == 0 {
+			cache.RemoveTxByHash(txHash)
+		}
+	}
+
+	require.Equal(t, uint64(50), cache.hashFilter.numAddedSinceRebuild.Load())
+	for _, txHash := range cache.txByHash.keys() {
+		require.True(t, cache.Has(txHash))
+	}
+}
+
+func TestTxCache_HashFilterConcurrentAddsAndRebuilds(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithHashFilterToTest(50, false)
+	wg := sync.WaitGroup{}
+	for sender := 0; sender < 8; sender++ {
+		wg.Add(1)
+		go func(sender int) {
+			defer wg.Done()
+
+			for nonce := uint64(0); nonce < 100; nonce++ {
+				txHash := []byte(fmt.Sprintf("hash-%d-%d", sender, nonce))
+				cache.AddTx(createTx(txHash, fmt.Sprintf("sender-%d", sender), nonce))
+				require.True(t, cache.Has(txHash))
+			}
+		}(sender)
+	}
+	wg.Wait()
+
+	for _, txHash := range cache.txByHash.keys() {
+		require.True(t, cache.Has(txHash))
+	}
+}
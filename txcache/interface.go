@@ -21,5 +21,11 @@ type SelectionSession interface {
 	IsInterfaceNil() bool
 }
 
+// GuardingBatchChecker can be implemented by the SelectionSession to check the guarding of many transactions at once,
+// ahead of the selection loop. The results are in the order of the transactions
+type GuardingBatchChecker interface {
+	AreIncorrectlyGuarded(txs []data.TransactionHandler) []bool
+}
+
 // ForEachTransaction is an iterator callback
 type ForEachTransaction func(txHash []byte, value *WrappedTransaction)
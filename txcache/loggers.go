@@ -1,9 +1,14 @@
 package txcache
 
-import logger "github.com/TerraDharitri/drt-go-chain-logger"
+import (
+	logger "github.com/TerraDharitri/drt-go-chain-logger"
+	"github.com/TerraDharitri/drt-go-chain-storage/logsampling"
+)
 
 var log = logger.GetOrCreate("txcache/main")
-var logAdd = logger.GetOrCreate("txcache/add")
-var logRemove = logger.GetOrCreate("txcache/remove")
-var logSelect = logger.GetOrCreate("txcache/select")
+
+// the per operation loggers can be sampled, see logsampling.Setup
+var logAdd = logsampling.GetOrCreate("txcache/add")
+var logRemove = logsampling.GetOrCreate("txcache/remove")
+var logSelect = logsampling.GetOrCreate("txcache/select")
 var logDiagnoseTransactions = logger.GetOrCreate("txcache/diagnose/transactions")
@@ -0,0 +1,173 @@
+package txcache
+
+import (
+	"sync"
+)
+
+// payloadArena holds the data payloads of the cached transactions in large chunks, instead of one heap object per
+// payload, so that the garbage collector tracks a few chunks instead of hundreds of thousands of small objects. Only the
+// transactions handed over to the cache (see WrappedTransaction.IsOwnedByCache) are moved: their payload is copied at
+// the end of the current chunk and their data is replaced by the copy, before they are visible to the readers of the
+// cache. The data of a transaction is replaced at most once, as it may be read by others once visible. A chunk is
+// released once all its transactions are removed. The released chunks are not reused, as the removed transactions may
+// still be read by their holders: they are left to the garbage collector, which frees them once no longer referenced.
+//
+// The chunks can not be compacted, as the data of the visible transactions can not be replaced again. Hence, a few
+// long-lived transactions keep their chunks alive: the bytes of these chunks which are not held by cached transactions
+// are dead bytes, counted by the cache along with its transactions. Once the dead bytes outnumber the live ones, no new
+// chunk is created, the payloads being left as they are until the mostly dead chunks are released
+type payloadArena struct {
+	mut             sync.Mutex
+	chunkSize       int
+	chunks          []*payloadChunk
+	freeIndexes     []uint32
+	currentIndex    uint32
+	numPayloads     int
+	numBytes        int
+	numPayloadsLeft int
+}
+
+// payloadChunk is a chunk of the arena, along with the number of the cached transactions whose payload it holds
+type payloadChunk struct {
+	buffer  []byte
+	numLive int
+}
+
+func newPayloadArena(chunkSize int) *payloadArena {
+	arena := &payloadArena{
+		chunkSize: chunkSize,
+	}
+	arena.currentIndex = arena.newChunkNoLock()
+
+	return arena
+}
+
+func (arena *payloadArena) newChunkNoLock() uint32 {
+	chunk := &payloadChunk{
+		buffer: make([]byte, 0, arena.chunkSize),
+	}
+
+	numFree := len(arena.freeIndexes)
+	if numFree > 0 {
+		index := arena.freeIndexes[numFree-1]
+		arena.freeIndexes = arena.freeIndexes[:numFree-1]
+		arena.chunks[index] = chunk
+		return index
+	}
+
+	arena.chunks = append(arena.chunks, chunk)
+	return uint32(len(arena.chunks) - 1)
+}
+
+// isEligible returns true for the payloads worth moving: the small ones would not save much, while the very large ones
+// would waste the end of the chunks
+func (arena *payloadArena) isEligible(payload []byte) bool {
+	return len(payload) >= minArenaPayloadSize && len(payload) <= arena.chunkSize/maxArenaPayloadFractionOfChunk
+}
+
+// add moves the payload of the transaction into the arena, returning false if the transaction is not owned by the
+// cache, its payload is not eligible, or its data was already replaced. The caller should call add before the
+// transaction is visible to the readers of the cache
+func (arena *payloadArena) add(tx *WrappedTransaction) bool {
+	if !tx.IsOwnedByCache {
+		return false
+	}
+
+	payload := tx.Tx.GetData()
+	if !arena.isEligible(payload) {
+		return false
+	}
+
+	arena.mut.Lock()
+	defer arena.mut.Unlock()
+
+	if tx.isPayloadMoved {
+		return false
+	}
+
+	chunk := arena.chunks[arena.currentIndex]
+	if len(chunk.buffer)+len(payload) > cap(chunk.buffer) {
+		if chunk.numLive > 0 && arena.numDeadBytesNoLock() > arena.numBytes {
+			// a new chunk would grow the memory retained by the mostly dead chunks
+			arena.numPayloadsLeft++
+			return false
+		}
+
+		previousIndex := arena.currentIndex
+		arena.currentIndex = arena.newChunkNoLock()
+		if chunk.numLive == 0 {
+			arena.releaseNoLock(previousIndex)
+		}
+		chunk = arena.chunks[arena.currentIndex]
+	}
+
+	offset := len(chunk.buffer)
+	chunk.buffer = append(chunk.buffer, payload...)
+	chunk.numLive++
+	// the capacity is capped, so that appending to the data of the transaction does not overwrite the next payload
+	tx.Tx.SetData(chunk.buffer[offset:len(chunk.buffer):len(chunk.buffer)])
+	tx.isPayloadMoved = true
+	tx.arenaChunk = arena.currentIndex + 1
+	arena.numPayloads++
+	arena.numBytes += len(payload)
+
+	return true
+}
+
+// remove releases the payload of the transaction removed from the cache, its data being left as it is
+func (arena *payloadArena) remove(tx *WrappedTransaction) {
+	arena.mut.Lock()
+	defer arena.mut.Unlock()
+
+	if tx.arenaChunk == 0 {
+		return
+	}
+
+	index := tx.arenaChunk - 1
+	tx.arenaChunk = 0
+	chunk := arena.chunks[index]
+	chunk.numLive--
+	arena.numPayloads--
+	arena.numBytes -= len(tx.Tx.GetData())
+	if chunk.numLive == 0 && index != arena.currentIndex {
+		arena.releaseNoLock(index)
+	}
+}
+
+func (arena *payloadArena) releaseNoLock(index uint32) {
+	arena.chunks[index] = nil
+	arena.freeIndexes = append(arena.freeIndexes, index)
+}
+
+func (arena *payloadArena) numRetainedBytesNoLock() int {
+	return (len(arena.chunks) - len(arena.freeIndexes)) * arena.chunkSize
+}
+
+// numDeadBytesNoLock returns the bytes of the retained chunks which are neither held by cached transactions, nor
+// still available at the end of the current chunk
+func (arena *payloadArena) numDeadBytesNoLock() int {
+	current := arena.chunks[arena.currentIndex]
+	numAvailableBytes := cap(current.buffer) - len(current.buffer)
+
+	return arena.numRetainedBytesNoLock() - arena.numBytes - numAvailableBytes
+}
+
+// numDeadBytes returns the bytes retained by the arena beyond the payloads of the cached transactions
+func (arena *payloadArena) numDeadBytes() int {
+	arena.mut.Lock()
+	defer arena.mut.Unlock()
+
+	return arena.numDeadBytesNoLock()
+}
+
+func (arena *payloadArena) addStats(stats map[string]interface{}) {
+	arena.mut.Lock()
+	defer arena.mut.Unlock()
+
+	stats["payloadArenaNumChunks"] = len(arena.chunks) - len(arena.freeIndexes)
+	stats["payloadArenaNumPayloads"] = arena.numPayloads
+	stats["payloadArenaNumBytes"] = arena.numBytes
+	stats["payloadArenaNumRetainedBytes"] = arena.numRetainedBytesNoLock()
+	stats["payloadArenaNumDeadBytes"] = arena.numDeadBytesNoLock()
+	stats["payloadArenaNumPayloadsLeft"] = arena.numPayloadsLeft
+}
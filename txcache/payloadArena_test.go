@@ -0,0 +1,372 @@
+package txcache
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithPayloadArenaToTest(payloadArenaEnabled bool) *TxCache {
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              math.MaxUint32,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+		PayloadArenaEnabled:         payloadArenaEnabled,
+	}, txcachemocks.NewMempoolHostMock())
+	if err != nil {
+		panic(fmt.Sprintf("newCacheWithPayloadArenaToTest(): %s", err))
+	}
+
+	return cache
+}
+
+func createPayloadToTest(size int, seed int) []byte {
+	return bytes.Repeat([]byte{byte(seed)}, size)
+}
+
+func createTxWithPayloadToTest(hash string, sender string, nonce uint64, payloadSize int, seed int) *WrappedTransaction {
+	tx := createTx([]byte(hash), sender, nonce).
+		withData(createPayloadToTest(payloadSize, seed)).
+		withGasLimit(50000 + uint64(payloadSize)*1500)
+	tx.IsOwnedByCache = true
+
+	return tx
+}
+
+func TestTxCache_PayloadArenaDisabled(t *testing.T) {
+	t.Parallel()
+
+	cache := newCacheWithPayloadArenaToTest(false)
+	require.Nil(t, cache.txByHash.arena)
+
+	tx := createTxWithPayloadToTest("hash", "alice", 1, 256, 1)
+	cache.AddTx(tx)
+	require.Equal(t, uint32(0), tx.arenaChunk)
+	require.NotContains(t, cache.Stats(), "payloadArenaNumChunks")
+}
+
+func TestTxCache_AddTxWithPayloadArena(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should move the eligible payloads only", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		small := createTxWithPayloadToTest("small", "alice", 1, minArenaPayloadSize-1, 1)
+		eligible := createTxWithPayloadToTest("eligible", "alice", 2, 256, 2)
+		huge := createTxWithPayloadToTest("huge", "alice", 3, payloadArenaChunkSize, 3)
+		cache.AddTx(small)
+		cache.AddTx(eligible)
+		cache.AddTx(huge)
+
+		require.Equal(t, uint32(0), small.arenaChunk)
+		require.Equal(t, uint32(1), eligible.arenaChunk)
+		require.Equal(t, uint32(0), huge.arenaChunk)
+		require.Equal(t, createPayloadToTest(256, 2), eligible.Tx.GetData())
+		require.Equal(t, 256, cap(eligible.Tx.GetData()))
+
+		stats := cache.Stats()
+		require.Equal(t, 1, stats["payloadArenaNumChunks"])
+		require.Equal(t, 1, stats["payloadArenaNumPayloads"])
+		require.Equal(t, 256, stats["payloadArenaNumBytes"])
+	})
+
+	t.Run("should not move the payload of a transaction not owned by the cache", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		tx := createTxWithPayloadToTest("hash", "alice", 1, 256, 1)
+		tx.IsOwnedByCache = false
+		payload := tx.Tx.GetData()
+		cache.AddTx(tx)
+
+		require.Equal(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, &payload[0], &tx.Tx.GetData()[0])
+		require.Equal(t, 0, cache.Stats()["payloadArenaNumPayloads"])
+	})
+
+	t.Run("should not move the payload of a duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		tx := createTxWithPayloadToTest("hash", "alice", 1, 256, 1)
+		duplicate := createTxWithPayloadToTest("hash", "alice", 1, 256, 1)
+		cache.AddTx(tx)
+		cache.AddTx(duplicate)
+		cache.AddTx(tx)
+
+		require.Equal(t, uint32(1), tx.arenaChunk)
+		require.Equal(t, uint32(0), duplicate.arenaChunk)
+		require.Equal(t, 1, cache.Stats()["payloadArenaNumPayloads"])
+	})
+
+	t.Run("appending to the data should not overwrite the next payload", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		first := createTxWithPayloadToTest("first", "alice", 1, 100, 1)
+		second := createTxWithPayloadToTest("second", "alice", 2, 100, 2)
+		cache.AddTx(first)
+		cache.AddTx(second)
+
+		_ = append(first.Tx.GetData(), 42)
+		require.Equal(t, createPayloadToTest(100, 2), second.Tx.GetData())
+	})
+}
+
+func TestTxCache_RemoveTxWithPayloadArena(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should release the vacated chunks, except the current one", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		payloadSize := payloadArenaChunkSize / maxArenaPayloadFractionOfChunk
+		for i := 0; i < 12; i++ {
+			tx := createTxWithPayloadToTest(fmt.Sprintf("hash-%d", i), "alice", uint64(i), payloadSize, i)
+			cache.AddTx(tx)
+		}
+		require.Equal(t, 2, cache.Stats()["payloadArenaNumChunks"])
+
+		tx, _ := cache.GetByTxHash([]byte("hash-0"))
+		require.True(t, cache.RemoveTxByHash([]byte("hash-0")))
+		require.Equal(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, createPayloadToTest(payloadSize, 0), tx.Tx.GetData())
+		require.Equal(t, 2, cache.Stats()["payloadArenaNumChunks"])
+
+		for i := 1; i < 8; i++ {
+			cache.RemoveTxByHash([]byte(fmt.Sprintf("hash-%d", i)))
+		}
+		stats := cache.Stats()
+		require.Equal(t, 1, stats["payloadArenaNumChunks"])
+		require.Equal(t, 4, stats["payloadArenaNumPayloads"])
+		require.Equal(t, 4*payloadSize, stats["payloadArenaNumBytes"])
+
+		for i := 8; i < 12; i++ {
+			cache.RemoveTxByHash([]byte(fmt.Sprintf("hash-%d", i)))
+		}
+		require.Equal(t, 1, cache.Stats()["payloadArenaNumChunks"])
+
+		// the released chunk index is reused
+		for i := 12; i < 24; i++ {
+			tx = createTxWithPayloadToTest(fmt.Sprintf("hash-%d", i), "alice", uint64(i), payloadSize, i)
+			cache.AddTx(tx)
+		}
+		require.Equal(t, 2, cache.Stats()["payloadArenaNumChunks"])
+		require.Equal(t, 2, len(cache.txByHash.arena.chunks))
+	})
+
+	t.Run("clear should release the payloads", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		tx := createTxWithPayloadToTest("hash", "alice", 1, 256, 1)
+		cache.AddTx(tx)
+		cache.Clear()
+
+		require.Equal(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, 0, cache.Stats()["payloadArenaNumPayloads"])
+
+		// the data of the transaction, visible before, is not replaced again when added back
+		payload := tx.Tx.GetData()
+		cache.AddTx(tx)
+		require.Equal(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, &payload[0], &tx.Tx.GetData()[0])
+		require.Equal(t, 0, cache.Stats()["payloadArenaNumPayloads"])
+	})
+}
+
+func TestTxCache_PayloadArenaDeadBytes(t *testing.T) {
+	t.Parallel()
+
+	payloadSize := payloadArenaChunkSize / maxArenaPayloadFractionOfChunk
+	addTxs := func(cache *TxCache, from int, to int) {
+		for i := from; i < to; i++ {
+			sender := fmt.Sprintf("sender-%d", i)
+			cache.AddTx(createTxWithPayloadToTest(fmt.Sprintf("hash-%d", i), sender, 1, payloadSize, i))
+		}
+	}
+	removeTxs := func(cache *TxCache, from int, to int) {
+		for i := from; i < to; i++ {
+			require.True(t, cache.RemoveTxByHash([]byte(fmt.Sprintf("hash-%d", i))))
+		}
+	}
+
+	t.Run("should report the bytes retained by the long-lived transactions", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		addTxs(cache, 0, 9)
+		numBytesOfTxs := int(cache.txByHash.numBytes.GetUint64())
+		require.Equal(t, numBytesOfTxs, cache.NumBytes())
+
+		// the first chunk is kept alive by its last transaction
+		removeTxs(cache, 0, 7)
+		numBytesOfTxs = int(cache.txByHash.numBytes.GetUint64())
+		stats := cache.Stats()
+		require.Equal(t, 2, stats["payloadArenaNumChunks"])
+		require.Equal(t, 2*payloadArenaChunkSize, stats["payloadArenaNumRetainedBytes"])
+		require.Equal(t, 7*payloadSize, stats["payloadArenaNumDeadBytes"])
+		require.Equal(t, numBytesOfTxs+7*payloadSize, cache.NumBytes())
+
+		removeTxs(cache, 7, 8)
+		stats = cache.Stats()
+		require.Equal(t, 1, stats["payloadArenaNumChunks"])
+		require.Equal(t, 0, stats["payloadArenaNumDeadBytes"])
+		require.Equal(t, int(cache.txByHash.numBytes.GetUint64()), cache.NumBytes())
+	})
+
+	t.Run("should not create chunks while the dead bytes outnumber the live ones", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithPayloadArenaToTest(true)
+		addTxs(cache, 0, 24)
+		removeTxs(cache, 1, 8)
+		removeTxs(cache, 9, 16)
+		require.Equal(t, 14*payloadSize, cache.Stats()["payloadArenaNumDeadBytes"])
+		require.Equal(t, 10*payloadSize, cache.Stats()["payloadArenaNumBytes"])
+
+		tx := createTxWithPayloadToTest("left", "bob", 1, payloadSize, 24)
+		payload := tx.Tx.GetData()
+		cache.AddTx(tx)
+		require.Equal(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, &payload[0], &tx.Tx.GetData()[0])
+		stats := cache.Stats()
+		require.Equal(t, 3, stats["payloadArenaNumChunks"])
+		require.Equal(t, 1, stats["payloadArenaNumPayloadsLeft"])
+
+		// once the first chunk is released, the payloads are moved again
+		removeTxs(cache, 0, 1)
+		tx = createTxWithPayloadToTest("moved", "carol", 1, payloadSize, 25)
+		cache.AddTx(tx)
+		require.NotEqual(t, uint32(0), tx.arenaChunk)
+		require.Equal(t, createPayloadToTest(payloadSize, 25), tx.Tx.GetData())
+		require.Equal(t, 3, cache.Stats()["payloadArenaNumChunks"])
+	})
+}
+
+func addTransactionsWithPayloadsToTest(cache *TxCache, from int, to int, payloadSize int) {
+	for i := from; i < to; i++ {
+		sender := fmt.Sprintf("sender-%d", i%10000)
+		tx := createTxWithPayloadToTest(fmt.Sprintf("hash-%d", i), sender, uint64(i/10000), payloadSize, i)
+		cache.AddTx(tx)
+	}
+}
+
+func reportGCMetricsToTest(b *testing.B, statsBefore *debug.GCStats) {
+	var statsAfter debug.GCStats
+	debug.ReadGCStats(&statsAfter)
+
+	numGCs := statsAfter.NumGC - statsBefore.NumGC
+	if numGCs > 0 {
+		pause := statsAfter.PauseTotal - statsBefore.PauseTotal
+		b.ReportMetric(float64(pause/time.Duration(numGCs)), "pause-ns/gc")
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	b.ReportMetric(float64(memStats.HeapObjects), "heap-objects")
+}
+
+func payloadArenaBenchmarkName(payloadArenaEnabled bool, numTxs int, payloadSize int) string {
+	if payloadArenaEnabled {
+		return fmt.Sprintf("arena enabled, %d txs, %d B", numTxs, payloadSize)
+	}
+
+	return fmt.Sprintf("arena disabled, %d txs, %d B", numTxs, payloadSize)
+}
+
+// BenchmarkTxCache_GCWithPayloadArena measures the garbage collections of pools of transactions, for a few pool and
+// payload sizes. The arena saves one heap object per moved payload, so that a forced collection, whose mark phase runs
+// concurrently, takes less time in most runs, while the stop-the-world pauses barely change. On one core, e.g.:
+//
+//	arena_disabled,_100000_txs,_128_B     20     80265026 ns/op     930948 heap-objects    31656 pause-ns/gc
+//	arena_enabled,_100000_txs,_128_B      20     68403380 ns/op     831333 heap-objects    29959 pause-ns/gc
+//	arena_disabled,_300000_txs,_128_B     20    159398240 ns/op    2731708 heap-objects    32915 pause-ns/gc
+//	arena_enabled,_300000_txs,_128_B      20    138840620 ns/op    2432883 heap-objects    30774 pause-ns/gc
+//	arena_disabled,_300000_txs,_512_B     20    267110633 ns/op    2731720 heap-objects    33810 pause-ns/gc
+//	arena_enabled,_300000_txs,_512_B      20    288352915 ns/op    2436411 heap-objects    29576 pause-ns/gc
+//	arena_disabled,_300000_txs,_2048_B    20    204925245 ns/op    2731722 heap-objects    42546 pause-ns/gc
+//	arena_enabled,_300000_txs,_2048_B     20    151531017 ns/op    2450475 heap-objects    29399 pause-ns/gc
+func BenchmarkTxCache_GCWithPayloadArena(b *testing.B) {
+	cases := []struct {
+		numTxs      int
+		payloadSize int
+	}{
+		{100000, 128},
+		{300000, 128},
+		{300000, 512},
+		{300000, 2048},
+	}
+
+	for _, c := range cases {
+		for _, payloadArenaEnabled := range []bool{false, true} {
+			b.Run(payloadArenaBenchmarkName(payloadArenaEnabled, c.numTxs, c.payloadSize), func(b *testing.B) {
+				cache := newCacheWithPayloadArenaToTest(payloadArenaEnabled)
+				addTransactionsWithPayloadsToTest(cache, 0, c.numTxs, c.payloadSize)
+				runtime.GC()
+
+				var statsBefore debug.GCStats
+				debug.ReadGCStats(&statsBefore)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					runtime.GC()
+				}
+				b.StopTimer()
+				reportGCMetricsToTest(b, &statsBefore)
+				runtime.KeepAlive(cache)
+			})
+		}
+	}
+}
+
+// BenchmarkTxCache_GCWithPayloadArenaUnderChurn measures the garbage collections of a pool of 100k transactions, while
+// the oldest 10k transactions are replaced by new ones at each iteration, so that the chunks are released, kept alive
+// by the remaining transactions, and created again. The bytes retained and dead at the end are reported along, e.g.:
+//
+//	arena_disabled,_100000_txs,_256_B    20    116083851 ns/op    931767 heap-objects    38838 pause-ns/gc
+//	arena_enabled,_100000_txs,_256_B     20     99877966 ns/op    832553 heap-objects    38542 pause-ns/gc    16384 dead-bytes    25624576 retained-bytes
+func BenchmarkTxCache_GCWithPayloadArenaUnderChurn(b *testing.B) {
+	numTxs := 100000
+	numReplacedTxs := 10000
+	payloadSize := 256
+
+	for _, payloadArenaEnabled := range []bool{false, true} {
+		b.Run(payloadArenaBenchmarkName(payloadArenaEnabled, numTxs, payloadSize), func(b *testing.B) {
+			cache := newCacheWithPayloadArenaToTest(payloadArenaEnabled)
+			addTransactionsWithPayloadsToTest(cache, 0, numTxs, payloadSize)
+			runtime.GC()
+
+			var statsBefore debug.GCStats
+			debug.ReadGCStats(&statsBefore)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				from := i * numReplacedTxs
+				for j := from; j < from+numReplacedTxs; j++ {
+					cache.RemoveTxByHash([]byte(fmt.Sprintf("hash-%d", j)))
+				}
+				addTransactionsWithPayloadsToTest(cache, numTxs+from, numTxs+from+numReplacedTxs, payloadSize)
+				runtime.GC()
+			}
+			b.StopTimer()
+			reportGCMetricsToTest(b, &statsBefore)
+
+			if payloadArenaEnabled {
+				stats := cache.Stats()
+				b.ReportMetric(float64(stats["payloadArenaNumDeadBytes"].(int)), "dead-bytes")
+				b.ReportMetric(float64(stats["payloadArenaNumRetainedBytes"].(int)), "retained-bytes")
+			}
+			runtime.KeepAlive(cache)
+		})
+	}
+}
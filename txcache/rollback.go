@@ -0,0 +1,109 @@
+package txcache
+
+import (
+	"sync"
+)
+
+// removedTxsJournal keeps the most recently removed transactions, the oldest being dropped once full. As the
+// transactions are removed once included in a block, they are the ones becoming valid again if the block is reverted
+type removedTxsJournal struct {
+	mut       sync.Mutex
+	maxNumTxs int
+	// txs are kept in the order of their removal, the oldest first
+	txs []*WrappedTransaction
+}
+
+func newRemovedTxsJournal(maxNumTxs int) *removedTxsJournal {
+	return &removedTxsJournal{
+		maxNumTxs: maxNumTxs,
+		txs:       make([]*WrappedTransaction, 0),
+	}
+}
+
+func (journal *removedTxsJournal) add(txs ...*WrappedTransaction) {
+	journal.mut.Lock()
+	defer journal.mut.Unlock()
+
+	journal.txs = append(journal.txs, txs...)
+	if len(journal.txs) > journal.maxNumTxs {
+		numDropped := len(journal.txs) - journal.maxNumTxs
+		journal.txs = append(make([]*WrappedTransaction, 0, journal.maxNumTxs), journal.txs[numDropped:]...)
+	}
+}
+
+// takeForSenders removes and returns the kept transactions of the provided senders
+func (journal *removedTxsJournal) takeForSenders(senders map[string]struct{}) []*WrappedTransaction {
+	journal.mut.Lock()
+	defer journal.mut.Unlock()
+
+	taken := make([]*WrappedTransaction, 0)
+	kept := make([]*WrappedTransaction, 0, len(journal.txs))
+	for _, tx := range journal.txs {
+		_, isRolledBack := senders[string(tx.Tx.GetSndAddr())]
+		if isRolledBack {
+			taken = append(taken, tx)
+		} else {
+			kept = append(kept, tx)
+		}
+	}
+	journal.txs = kept
+
+	return taken
+}
+
+func (journal *removedTxsJournal) len() int {
+	journal.mut.Lock()
+	defer journal.mut.Unlock()
+
+	return len(journal.txs)
+}
+
+func (journal *removedTxsJournal) clear() {
+	journal.mut.Lock()
+	journal.txs = make([]*WrappedTransaction, 0)
+	journal.mut.Unlock()
+}
+
+// keepRemovedTxs keeps the transaction removed by hash, along with the ones of the same sender, having lower nonces,
+// removed with it. Should be called before removing the latter from txByHash
+func (cache *TxCache) keepRemovedTxs(tx *WrappedTransaction, removedHashes [][]byte) {
+	removedTxs := make([]*WrappedTransaction, 0, len(removedHashes)+1)
+	for _, txHash := range removedHashes {
+		removedTx, ok := cache.txByHash.getTx(string(txHash))
+		if ok && removedTx != tx {
+			removedTxs = append(removedTxs, removedTx)
+		}
+	}
+	removedTxs = append(removedTxs, tx)
+
+	cache.removedTxs.add(removedTxs...)
+}
+
+// OnRollback should be called once the blocks holding transactions of the provided senders were reverted. The
+// transactions of these senders, removed from the cache since then, are added back, as they became valid again, while
+// the transactions of the other senders are not affected, as they would be by Clear. The cache does not hold the state
+// of the accounts, the nonces being provided by the selection session, thus nothing else has to be reset. Only the
+// most recent config.NumRemovedTxsToKeepForRollback removed transactions can be added back. It returns the number of
+// the transactions added back
+func (cache *TxCache) OnRollback(senders [][]byte) int {
+	if cache.removedTxs == nil || len(senders) == 0 {
+		return 0
+	}
+
+	rolledBackSenders := make(map[string]struct{}, len(senders))
+	for _, sender := range senders {
+		rolledBackSenders[string(sender)] = struct{}{}
+	}
+
+	numAdded := 0
+	for _, tx := range cache.removedTxs.takeForSenders(rolledBackSenders) {
+		_, added := cache.AddTx(tx)
+		if added {
+			numAdded++
+		}
+	}
+
+	log.Debug("TxCache.OnRollback", "name", cache.name, "num senders", len(senders), "num txs added back", numAdded)
+
+	return numAdded
+}
@@ -0,0 +1,106 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithRollbackToTest(numRemovedTxsToKeep uint32) *TxCache {
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                           "test",
+		NumChunks:                      16,
+		NumBytesThreshold:              maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:     maxNumBytesPerSenderUpperBound,
+		CountThreshold:                 math.MaxUint32,
+		CountPerSenderThreshold:        math.MaxUint32,
+		NumItemsToPreemptivelyEvict:    1,
+		NumRemovedTxsToKeepForRollback: numRemovedTxsToKeep,
+	}, txcachemocks.NewMempoolHostMock())
+	if err != nil {
+		panic(err)
+	}
+
+	return cache
+}
+
+func TestTxCache_OnRollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should add back the removed transactions of the rolled back senders", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithRollbackToTest(10)
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+		cache.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+		cache.AddTx(createTx([]byte("hash-bob-2"), "bob", 2))
+
+		// the transactions are removed once included in a block
+		require.True(t, cache.RemoveTxByHash([]byte("hash-alice-2")))
+		require.True(t, cache.RemoveTxByHash([]byte("hash-bob-1")))
+		require.Equal(t, uint64(2), cache.CountTx())
+		require.Equal(t, 3, cache.removedTxs.len())
+
+		numAdded := cache.OnRollback([][]byte{[]byte("alice"), []byte("carol")})
+		require.Equal(t, 2, numAdded)
+		require.Equal(t, uint64(4), cache.CountTx())
+		require.Equal(t, []string{"hash-alice-1", "hash-alice-2", "hash-alice-3"}, sendersHashesToTest(cache, "alice"))
+		require.Equal(t, []string{"hash-bob-2"}, sendersHashesToTest(cache, "bob"))
+		require.Equal(t, 1, cache.removedTxs.len())
+
+		// the transactions are added back only once
+		require.Equal(t, 0, cache.OnRollback([][]byte{[]byte("alice")}))
+	})
+
+	t.Run("should keep only the most recently removed transactions", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithRollbackToTest(2)
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+
+		require.True(t, cache.RemoveTxByHash([]byte("hash-alice-3")))
+		require.Equal(t, 2, cache.removedTxs.len())
+
+		require.Equal(t, 2, cache.OnRollback([][]byte{[]byte("alice")}))
+		require.Equal(t, []string{"hash-alice-2", "hash-alice-3"}, sendersHashesToTest(cache, "alice"))
+	})
+
+	t.Run("should forget the removed transactions on Clear", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithRollbackToTest(10)
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, cache.RemoveTxByHash([]byte("hash-alice-1")))
+
+		cache.Clear()
+		require.Equal(t, 0, cache.OnRollback([][]byte{[]byte("alice")}))
+		require.Equal(t, uint64(0), cache.CountTx())
+	})
+
+	t.Run("should do nothing if not enabled", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newCacheWithRollbackToTest(0)
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, cache.RemoveTxByHash([]byte("hash-alice-1")))
+
+		require.Nil(t, cache.removedTxs)
+		require.Equal(t, 0, cache.OnRollback([][]byte{[]byte("alice")}))
+		require.Equal(t, uint64(0), cache.CountTx())
+	})
+}
+
+func sendersHashesToTest(cache *TxCache, sender string) []string {
+	listForSender, ok := cache.txListBySender.getListForSender(sender)
+	if !ok {
+		return nil
+	}
+
+	return hashesAsStrings(listForSender.getTxsHashes())
+}
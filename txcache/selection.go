@@ -5,10 +5,31 @@ import (
 	"time"
 )
 
-func (cache *TxCache) doSelectTransactions(session SelectionSession, gasRequested uint64, maxNum int, selectionLoopMaximumDuration time.Duration) (bunchOfTransactions, uint64) {
+// SelectionOptions holds the parameters of a selection
+type SelectionOptions struct {
+	// GasRequested is the maximum of the total gas limit of the selected transactions
+	GasRequested uint64
+	// MaxNum is the maximum number of the selected transactions
+	MaxNum int
+	// MaxNumPerSender, if not zero, is the maximum number of the transactions selected from a sender, so that a sender
+	// with a long queue of high fee transactions does not take over the selection. Unlike config.CountPerSenderThreshold,
+	// it does not affect the number of the transactions held by the cache
+	MaxNumPerSender int
+	// LoopMaximumDuration is the duration after which the selection loop stops
+	LoopMaximumDuration time.Duration
+}
+
+// selectionStats counts what the selection left out, for the selection outcomes
+type selectionStats struct {
+	numSkippedSenders int
+	numSkippedTxs     int
+	timedOut          bool
+}
+
+func (cache *TxCache) doSelectTransactions(session SelectionSession, options SelectionOptions) (bunchOfTransactions, uint64, selectionStats) {
 	bunches := cache.acquireBunchesOfTransactions()
 
-	return selectTransactionsFromBunches(session, bunches, gasRequested, maxNum, selectionLoopMaximumDuration)
+	return selectTransactionsFromBunchesWithOptions(session, bunches, options)
 }
 
 func (cache *TxCache) acquireBunchesOfTransactions() []bunchOfTransactions {
@@ -24,8 +45,19 @@ func (cache *TxCache) acquireBunchesOfTransactions() []bunchOfTransactions {
 
 // Selection tolerates concurrent transaction additions / removals.
 func selectTransactionsFromBunches(session SelectionSession, bunches []bunchOfTransactions, gasRequested uint64, maxNum int, selectionLoopMaximumDuration time.Duration) (bunchOfTransactions, uint64) {
+	selectedTransactions, accumulatedGas, _ := selectTransactionsFromBunchesWithOptions(session, bunches, SelectionOptions{
+		GasRequested:        gasRequested,
+		MaxNum:              maxNum,
+		LoopMaximumDuration: selectionLoopMaximumDuration,
+	})
+	return selectedTransactions, accumulatedGas
+}
+
+func selectTransactionsFromBunchesWithOptions(session SelectionSession, bunches []bunchOfTransactions, options SelectionOptions) (bunchOfTransactions, uint64, selectionStats) {
+	stats := selectionStats{}
 	selectedTransactions := make(bunchOfTransactions, 0, initialCapacityOfSelectionSlice)
 	sessionWrapper := newSelectionSessionWrapper(session)
+	sessionWrapper.prevalidateGuarding(bunches)
 
 	// Items popped from the heap are added to "selectedTransactions".
 	transactionsHeap := newMaxTransactionsHeap(len(bunches))
@@ -51,15 +83,16 @@ func selectTransactionsFromBunches(session SelectionSession, bunches []bunchOfTr
 		item := heap.Pop(transactionsHeap).(*transactionsHeapItem)
 		gasLimit := item.currentTransaction.Tx.GetGasLimit()
 
-		if accumulatedGas+gasLimit > gasRequested {
+		if accumulatedGas+gasLimit > options.GasRequested {
 			break
 		}
-		if len(selectedTransactions) >= maxNum {
+		if len(selectedTransactions) >= options.MaxNum {
 			break
 		}
 		if len(selectedTransactions)%selectionLoopDurationCheckInterval == 0 {
-			if time.Since(selectionLoopStartTime) > selectionLoopMaximumDuration {
+			if time.Since(selectionLoopStartTime) > options.LoopMaximumDuration {
 				logSelect.Debug("TxCache.selectTransactionsFromBunches, selection loop timeout", "duration", time.Since(selectionLoopStartTime))
+				stats.timedOut = true
 				break
 			}
 		}
@@ -68,6 +101,7 @@ func selectTransactionsFromBunches(session SelectionSession, bunches []bunchOfTr
 		if shouldSkipSender {
 			// Item was popped from the heap, but not used downstream.
 			// Therefore, the sender is completely ignored (from now on) in the current selection session.
+			stats.numSkippedSenders++
 			continue
 		}
 
@@ -77,6 +111,13 @@ func selectTransactionsFromBunches(session SelectionSession, bunches []bunchOfTr
 			selectedTransaction := item.selectCurrentTransaction()
 			selectedTransactions = append(selectedTransactions, selectedTransaction)
 			sessionWrapper.accumulateConsumedBalance(selectedTransaction)
+		} else {
+			stats.numSkippedTxs++
+		}
+
+		if options.MaxNumPerSender > 0 && item.numSelectedTransactions >= options.MaxNumPerSender {
+			// The sender got its share of the selection, the rest of its transactions are left for the next ones.
+			continue
 		}
 
 		// If there are more transactions in the same bunch (same sender as the popped item),
@@ -87,7 +128,7 @@ func selectTransactionsFromBunches(session SelectionSession, bunches []bunchOfTr
 		}
 	}
 
-	return selectedTransactions, accumulatedGas
+	return selectedTransactions, accumulatedGas, stats
 }
 
 // Note (future micro-optimization): we can merge "detectSkippableSender()" and "detectSkippableTransaction()" into a single function,
@@ -0,0 +1,100 @@
+package txcache
+
+import (
+	"sync"
+	"time"
+)
+
+// SelectionOutcome describes a past selection, as offered to the block production
+type SelectionOutcome struct {
+	Time           time.Time
+	Duration       time.Duration
+	Options        SelectionOptions
+	AccumulatedGas uint64
+	TxHashes       [][]byte
+	// NumSkippedSenders counts the senders left out because of nonce gaps or insufficient balance
+	NumSkippedSenders int
+	// NumSkippedTxs counts the transactions left out because of lower or duplicate nonces, or incorrect guarding
+	NumSkippedTxs int
+	// TimedOut is true if the selection loop was stopped by its maximum duration
+	TimedOut bool
+}
+
+// selectionOutcomesRing keeps the most recent selection outcomes, overwriting the oldest one once full
+type selectionOutcomesRing struct {
+	mut      sync.Mutex
+	outcomes []SelectionOutcome
+	next     int
+	isFull   bool
+}
+
+func newSelectionOutcomesRing(capacity int) *selectionOutcomesRing {
+	return &selectionOutcomesRing{
+		outcomes: make([]SelectionOutcome, capacity),
+	}
+}
+
+func (ring *selectionOutcomesRing) add(outcome SelectionOutcome) {
+	ring.mut.Lock()
+	defer ring.mut.Unlock()
+
+	ring.outcomes[ring.next] = outcome
+	ring.next = (ring.next + 1) % len(ring.outcomes)
+	ring.isFull = ring.isFull || ring.next == 0
+}
+
+// getAll returns the kept outcomes, the oldest first
+func (ring *selectionOutcomesRing) getAll() []SelectionOutcome {
+	ring.mut.Lock()
+	defer ring.mut.Unlock()
+
+	if !ring.isFull {
+		return append([]SelectionOutcome(nil), ring.outcomes[:ring.next]...)
+	}
+
+	all := make([]SelectionOutcome, 0, len(ring.outcomes))
+	all = append(all, ring.outcomes[ring.next:]...)
+	all = append(all, ring.outcomes[:ring.next]...)
+
+	return all
+}
+
+// recordSelection keeps the outcome of the selection among the most recent ones, if enabled
+func (cache *TxCache) recordSelection(
+	startTime time.Time,
+	duration time.Duration,
+	options SelectionOptions,
+	transactions bunchOfTransactions,
+	accumulatedGas uint64,
+	stats selectionStats,
+) {
+	if cache.recentSelections == nil {
+		return
+	}
+
+	txHashes := make([][]byte, 0, len(transactions))
+	for _, tx := range transactions {
+		txHashes = append(txHashes, tx.TxHash)
+	}
+
+	cache.recentSelections.add(SelectionOutcome{
+		Time:              startTime,
+		Duration:          duration,
+		Options:           options,
+		AccumulatedGas:    accumulatedGas,
+		TxHashes:          txHashes,
+		NumSkippedSenders: stats.numSkippedSenders,
+		NumSkippedTxs:     stats.numSkippedTxs,
+		TimedOut:          stats.timedOut,
+	})
+}
+
+// RecentSelections returns the outcomes of the most recent selections, the oldest first, so that the postmortems of the
+// block production can see what the mempool offered. It returns nil if config.NumRecentSelectionsToKeep is zero
+func (cache *TxCache) RecentSelections() []SelectionOutcome {
+	if cache.recentSelections == nil {
+		return nil
+	}
+
+	return cache.recentSelections.getAll()
+}
@@ -0,0 +1,101 @@
+package txcache
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithRecentSelectionsToTest(numRecentSelectionsToKeep uint32) (*TxCache, error) {
+	return NewTxCache(ConfigSourceMe{
+		Name:                        "test",
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              math.MaxUint32,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+		NumRecentSelectionsToKeep:   numRecentSelectionsToKeep,
+	}, txcachemocks.NewMempoolHostMock())
+}
+
+func TestTxCache_RecentSelectionsConfig(t *testing.T) {
+	t.Parallel()
+
+	cache, err := newCacheWithRecentSelectionsToTest(numRecentSelectionsToKeepUpperBound + 1)
+	require.Nil(t, cache)
+	require.True(t, errors.Is(err, common.ErrInvalidConfig))
+	require.Contains(t, err.Error(), "NumRecentSelectionsToKeep")
+
+	cache, err = newCacheWithRecentSelectionsToTest(0)
+	require.Nil(t, err)
+	cache.AddTx(createTx([]byte("hash-alice-0"), "alice", 0))
+	selected, _ := cache.SelectTransactions(txcachemocks.NewSelectionSessionMock(), math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+	require.Len(t, selected, 1)
+	require.Nil(t, cache.RecentSelections())
+}
+
+func TestTxCache_RecentSelections(t *testing.T) {
+	t.Parallel()
+
+	cache, err := newCacheWithRecentSelectionsToTest(3)
+	require.Nil(t, err)
+
+	cache.AddTx(createTx([]byte("hash-alice-0"), "alice", 0))
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	// bob has an initial gap
+	cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+	// carol has a duplicated nonce
+	cache.AddTx(createTx([]byte("hash-carol-0"), "carol", 0).withGasPrice(2 * oneBillion))
+	cache.AddTx(createTx([]byte("hash-carol-0-bis"), "carol", 0))
+
+	session := txcachemocks.NewSelectionSessionMock()
+	for i := 0; i < 5; i++ {
+		_, _ = cache.SelectTransactions(session, 10_000_000_000, 10+i, selectionLoopMaximumDuration)
+	}
+
+	outcomes := cache.RecentSelections()
+	require.Len(t, outcomes, 3)
+	for i, outcome := range outcomes {
+		require.Equal(t, 12+i, outcome.Options.MaxNum)
+		require.Equal(t, uint64(10_000_000_000), outcome.Options.GasRequested)
+		require.Equal(t, uint64(150_000), outcome.AccumulatedGas)
+		require.ElementsMatch(t, [][]byte{[]byte("hash-alice-0"), []byte("hash-alice-1"), []byte("hash-carol-0")}, outcome.TxHashes)
+		require.Equal(t, 1, outcome.NumSkippedSenders)
+		require.Equal(t, 1, outcome.NumSkippedTxs)
+		require.False(t, outcome.TimedOut)
+		if i > 0 {
+			require.False(t, outcome.Time.Before(outcomes[i-1].Time))
+		}
+	}
+}
+
+func TestSelectionOutcomesRing(t *testing.T) {
+	t.Parallel()
+
+	ring := newSelectionOutcomesRing(3)
+	require.Empty(t, ring.getAll())
+
+	maxNums := func() []int {
+		result := make([]int, 0)
+		for _, outcome := range ring.getAll() {
+			result = append(result, outcome.Options.MaxNum)
+		}
+		return result
+	}
+
+	ring.add(SelectionOutcome{Options: SelectionOptions{MaxNum: 1}})
+	ring.add(SelectionOutcome{Options: SelectionOptions{MaxNum: 2}})
+	require.Equal(t, []int{1, 2}, maxNums())
+
+	ring.add(SelectionOutcome{Options: SelectionOptions{MaxNum: 3}})
+	require.Equal(t, []int{1, 2, 3}, maxNums())
+
+	ring.add(SelectionOutcome{Options: SelectionOptions{MaxNum: 4}})
+	ring.add(SelectionOutcome{Options: SelectionOptions{MaxNum: 5}})
+	require.Equal(t, []int{3, 4, 5}, maxNums())
+}
@@ -10,6 +10,14 @@ import (
 type selectionSessionWrapper struct {
 	session          SelectionSession
 	recordsByAddress map[string]*accountRecord
+	// guardingResults memoizes, for the selection run, whether the transactions of a sender, having a given guardian,
+	// are incorrectly guarded
+	guardingResults map[guardingKey]bool
+}
+
+type guardingKey struct {
+	sender   string
+	guardian string
 }
 
 type accountRecord struct {
@@ -22,6 +30,7 @@ func newSelectionSessionWrapper(session SelectionSession) *selectionSessionWrapp
 	return &selectionSessionWrapper{
 		session:          session,
 		recordsByAddress: make(map[string]*accountRecord),
+		guardingResults:  make(map[guardingKey]bool),
 	}
 }
 
@@ -101,5 +110,67 @@ func (sessionWrapper *selectionSessionWrapper) detectWillFeeExceedBalance(tx *Wr
 }
 
 func (sessionWrapper *selectionSessionWrapper) isIncorrectlyGuarded(tx data.TransactionHandler) bool {
-	return sessionWrapper.session.IsIncorrectlyGuarded(tx)
+	key := newGuardingKey(tx)
+	result, ok := sessionWrapper.guardingResults[key]
+	if ok {
+		return result
+	}
+
+	result = sessionWrapper.session.IsIncorrectlyGuarded(tx)
+	sessionWrapper.guardingResults[key] = result
+
+	return result
+}
+
+// prevalidateGuarding checks, in one batch, the guarding of one transaction for each distinct sender and guardian,
+// if the session is a GuardingBatchChecker
+func (sessionWrapper *selectionSessionWrapper) prevalidateGuarding(bunches []bunchOfTransactions) {
+	batchChecker, ok := sessionWrapper.session.(GuardingBatchChecker)
+	if !ok {
+		return
+	}
+
+	keys := make([]guardingKey, 0, len(bunches))
+	txs := make([]data.TransactionHandler, 0, len(bunches))
+	seen := make(map[guardingKey]struct{}, len(bunches))
+	for _, bunch := range bunches {
+		for _, tx := range bunch {
+			key := newGuardingKey(tx.Tx)
+			_, isSeen := seen[key]
+			if isSeen {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+			txs = append(txs, tx.Tx)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+
+	results := batchChecker.AreIncorrectlyGuarded(txs)
+	if len(results) != len(txs) {
+		logSelect.Debug("selectionSessionWrapper.prevalidateGuarding, unexpected number of results, ignored",
+			"num txs", len(txs), "num results", len(results))
+		return
+	}
+
+	for i, key := range keys {
+		sessionWrapper.guardingResults[key] = results[i]
+	}
+}
+
+func newGuardingKey(tx data.TransactionHandler) guardingKey {
+	key := guardingKey{
+		sender: string(tx.GetSndAddr()),
+	}
+
+	guardedTx, ok := tx.(data.GuardedTransactionHandler)
+	if ok {
+		key.guardian = string(guardedTx.GetGuardianAddr())
+	}
+
+	return key
 }
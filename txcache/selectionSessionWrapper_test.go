@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
+	"github.com/TerraDharitri/drt-go-chain-core/data"
 	"github.com/TerraDharitri/drt-go-chain-core/data/transaction"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
 	"github.com/stretchr/testify/require"
@@ -51,6 +52,86 @@ func TestSelectionSessionWrapper_accumulateConsumedBalance(t *testing.T) {
 	})
 }
 
+// selectionSessionWithBatchCheckerMock is a selection session checking the guarding in batches as well
+type selectionSessionWithBatchCheckerMock struct {
+	*txcachemocks.SelectionSessionMock
+	batches [][]data.TransactionHandler
+}
+
+func (mock *selectionSessionWithBatchCheckerMock) AreIncorrectlyGuarded(txs []data.TransactionHandler) []bool {
+	mock.batches = append(mock.batches, txs)
+
+	results := make([]bool, 0, len(txs))
+	for _, tx := range txs {
+		results = append(results, isIncorrectlyGuardedByHeidi(tx))
+	}
+
+	return results
+}
+
+// isIncorrectlyGuardedByHeidi considers alice guarded by heidi
+func isIncorrectlyGuardedByHeidi(tx data.TransactionHandler) bool {
+	return string(tx.GetSndAddr()) == "alice" && string(tx.(data.GuardedTransactionHandler).GetGuardianAddr()) != "heidi"
+}
+
+func TestSelectionSessionWrapper_isIncorrectlyGuarded(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should memoize the results by sender and guardian", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		session := txcachemocks.NewSelectionSessionMock()
+		session.IsIncorrectlyGuardedCalled = func(tx data.TransactionHandler) bool {
+			numCalls++
+			return isIncorrectlyGuardedByHeidi(tx)
+		}
+		sessionWrapper := newSelectionSessionWrapper(session)
+
+		require.False(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("alice-1"), "alice", 1).withGuardian([]byte("heidi")).Tx))
+		require.False(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("alice-2"), "alice", 2).withGuardian([]byte("heidi")).Tx))
+		require.True(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("alice-3"), "alice", 3).withGuardian([]byte("mallory")).Tx))
+		require.True(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("alice-4"), "alice", 4).Tx))
+		require.True(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("alice-5"), "alice", 5).Tx))
+		require.False(t, sessionWrapper.isIncorrectlyGuarded(createTx([]byte("bob-1"), "bob", 1).Tx))
+		require.Equal(t, 4, numCalls)
+	})
+
+	t.Run("should use the results checked in batch", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		session := &selectionSessionWithBatchCheckerMock{
+			SelectionSessionMock: txcachemocks.NewSelectionSessionMock(),
+		}
+		session.IsIncorrectlyGuardedCalled = func(tx data.TransactionHandler) bool {
+			numCalls++
+			return isIncorrectlyGuardedByHeidi(tx)
+		}
+		sessionWrapper := newSelectionSessionWrapper(session)
+
+		bunches := []bunchOfTransactions{
+			{
+				createTx([]byte("alice-1"), "alice", 1).withGuardian([]byte("heidi")),
+				createTx([]byte("alice-2"), "alice", 2).withGuardian([]byte("heidi")),
+				createTx([]byte("alice-3"), "alice", 3),
+			},
+			{
+				createTx([]byte("bob-1"), "bob", 1),
+				createTx([]byte("bob-2"), "bob", 2),
+			},
+		}
+		sessionWrapper.prevalidateGuarding(bunches)
+		require.Len(t, session.batches, 1)
+		require.Len(t, session.batches[0], 3)
+
+		require.False(t, sessionWrapper.isIncorrectlyGuarded(bunches[0][1].Tx))
+		require.True(t, sessionWrapper.isIncorrectlyGuarded(bunches[0][2].Tx))
+		require.False(t, sessionWrapper.isIncorrectlyGuarded(bunches[1][1].Tx))
+		require.Equal(t, 0, numCalls)
+	})
+}
+
 func TestSelectionSessionWrapper_detectWillFeeExceedBalance(t *testing.T) {
 	host := txcachemocks.NewMempoolHostMock()
 
@@ -2,6 +2,7 @@ package txcache
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math"
 	"math/big"
@@ -10,10 +11,44 @@ import (
 
 	"github.com/TerraDharitri/drt-go-chain-core/core"
 	"github.com/TerraDharitri/drt-go-chain-core/data"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcacheworkload"
+	"github.com/TerraDharitri/drt-go-chain-storage/tracing"
 	"github.com/stretchr/testify/require"
 )
 
+func TestTxCache_SelectTransactionsWithOptions_MaxNumPerSender(t *testing.T) {
+	t.Parallel()
+
+	cache := newUnconstrainedCacheToTest()
+	session := txcachemocks.NewSelectionSessionMock()
+
+	for nonce := uint64(0); nonce < 10; nonce++ {
+		cache.AddTx(createTx([]byte(fmt.Sprintf("hash-alice-%d", nonce)), "alice", nonce).withGasPrice(2 * oneBillion))
+	}
+	cache.AddTx(createTx([]byte("hash-bob-0"), "bob", 0))
+	cache.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+	cache.AddTx(createTx([]byte("hash-bob-2"), "bob", 2))
+
+	options := SelectionOptions{
+		GasRequested:        math.MaxUint64,
+		MaxNum:              math.MaxInt,
+		LoopMaximumDuration: selectionLoopMaximumDuration,
+	}
+
+	selected, _ := cache.SelectTransactionsWithOptions(context.Background(), session, options)
+	require.Len(t, selected, 13)
+
+	options.MaxNumPerSender = 2
+	selected, accumulatedGas := cache.SelectTransactionsWithOptions(context.Background(), session, options)
+	require.Equal(t, []string{"hash-alice-0", "hash-alice-1", "hash-bob-0", "hash-bob-1"}, hashesAsStrings(hashesOf(selected)))
+	require.Equal(t, uint64(200_000), accumulatedGas)
+
+	// the cap does not affect the transactions held by the cache
+	require.Equal(t, uint64(13), cache.CountTx())
+}
+
 func TestTxCache_SelectTransactions_Dummy(t *testing.T) {
 	t.Run("all having same PPU", func(t *testing.T) {
 		cache := newUnconstrainedCacheToTest()
@@ -68,6 +103,122 @@ func TestTxCache_SelectTransactions_Dummy(t *testing.T) {
 	})
 }
 
+func TestTxCache_SelectTransactions_AcrossRounds(t *testing.T) {
+	t.Run("with executed transactions and scheduled nonces", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		session := txcachemocks.NewSelectionSessionMock()
+		session.SetNonce([]byte("alice"), 1)
+		session.ScheduleNonce(1, []byte("bob"), 5)
+
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+		cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+
+		selectAndExecute := func(maxNum int) []string {
+			selected, _ := cache.SelectTransactions(session, math.MaxUint64, maxNum, selectionLoopMaximumDuration)
+			hashes := make([]string, 0, len(selected))
+			for _, tx := range selected {
+				session.ExecuteTransactions(tx.Tx)
+				cache.RemoveTxByHash(tx.TxHash)
+				hashes = append(hashes, string(tx.TxHash))
+			}
+			session.AdvanceRounds(1)
+
+			return hashes
+		}
+
+		require.Equal(t, []string{"hash-alice-1", "hash-alice-2"}, selectAndExecute(2))
+		require.Equal(t, uint64(1), session.CurrentRound())
+		require.ElementsMatch(t, []string{"hash-alice-3", "hash-bob-5"}, selectAndExecute(math.MaxInt))
+		require.Empty(t, selectAndExecute(math.MaxInt))
+
+		state, _ := session.GetAccountState([]byte("alice"))
+		require.Equal(t, uint64(4), state.Nonce)
+	})
+
+	t.Run("with a nonce sequence", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		session := txcachemocks.NewSelectionSessionMock()
+		session.SetNonceSequence([]byte("carol"), 1, 2)
+
+		cache.AddTx(createTx([]byte("hash-carol-1"), "carol", 1))
+		cache.AddTx(createTx([]byte("hash-carol-2"), "carol", 2))
+
+		selected, _ := cache.SelectTransactions(session, math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+		require.Len(t, selected, 2)
+
+		selected, _ = cache.SelectTransactions(session, math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+		require.Len(t, selected, 1)
+		require.Equal(t, "hash-carol-2", string(selected[0].TxHash))
+	})
+}
+
+func TestTxCache_SelectTransactions_WithWorkload(t *testing.T) {
+	workloadTxs, err := txcacheworkload.Generate(txcacheworkload.ArgsWorkload{
+		NumSenders:           100,
+		NumTxsPerSender:      20,
+		NonceGapProbability:  0.05,
+		MinGasPrice:          oneBillion,
+		MaxGasPrice:          4 * oneBillion,
+		GasPriceDistribution: txcacheworkload.ZipfianGasPrice,
+		MaxDataSize:          100,
+		Seed:                 42,
+	})
+	require.Nil(t, err)
+
+	// only the transactions preceding the first nonce gap of each sender are executable
+	numExecutable := 0
+	expectedNonceBySender := make(map[string]uint64)
+	for _, workloadTx := range workloadTxs {
+		sender := string(workloadTx.Tx.SndAddr)
+		expectedNonce, ok := expectedNonceBySender[sender]
+		if !ok || workloadTx.Tx.Nonce == expectedNonce {
+			expectedNonceBySender[sender] = workloadTx.Tx.Nonce + 1
+			numExecutable++
+			continue
+		}
+		expectedNonceBySender[sender] = math.MaxUint64
+	}
+
+	txcacheworkload.Shuffle(workloadTxs, 42)
+	cache := newUnconstrainedCacheToTest()
+	for _, tx := range createTxsFromWorkload(workloadTxs) {
+		cache.AddTx(tx)
+	}
+	require.Equal(t, uint64(len(workloadTxs)), cache.CountTx())
+	require.True(t, cache.areInternalMapsConsistent())
+
+	selected, _ := cache.SelectTransactions(txcachemocks.NewSelectionSessionMock(), math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+	require.Len(t, selected, numExecutable)
+}
+
+func TestTxCache_SelectTransactionsCtx_ShouldTraceTheSelection(t *testing.T) {
+	tracer := &testscommon.TracerMock{}
+	require.Nil(t, tracing.Setup(tracing.Config{Tracer: tracer, SamplingRatio: 1}))
+	defer tracing.Disable()
+
+	cache := newUnconstrainedCacheToTest()
+	session := txcachemocks.NewSelectionSessionMock()
+	session.SetNonce([]byte("alice"), 1)
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	selected, _ := cache.SelectTransactionsCtx(ctx, session, math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+	parent.End()
+	require.Len(t, selected, 2)
+
+	for _, span := range tracer.Spans() {
+		if span.Name == "txcache.SelectTransactions" && span.Attributes["cache"].AsString() == cache.name {
+			require.True(t, span.Ended)
+			require.Equal(t, int64(2), span.Attributes["num_selected"].AsInt64())
+			return
+		}
+	}
+	require.Fail(t, "the selection should have been traced")
+}
+
 func TestTxCache_SelectTransactionsWithBandwidth_Dummy(t *testing.T) {
 	t.Run("transactions with no data field", func(t *testing.T) {
 		cache := newUnconstrainedCacheToTest()
@@ -249,6 +400,41 @@ func TestTxCache_SelectTransactions_HandlesNotExecutableTransactions(t *testing.
 	})
 }
 
+func TestTxCache_SelectTransactions_GuardedAndRelayed(t *testing.T) {
+	t.Run("should skip the transactions of the guarded accounts not co-signed by the guardian", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		session := txcachemocks.NewSelectionSessionMock()
+		session.SetGuardian([]byte("alice"), []byte("guardian"))
+
+		cache.AddTx(createTx([]byte("hash-alice-0"), "alice", 0).withGuardian([]byte("guardian")).withGasLimit(100_000))
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1).withGasLimit(100_000))
+		cache.AddTx(createTx([]byte("hash-bob-0"), "bob", 0))
+
+		sorted, _ := cache.SelectTransactions(session, math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+		require.Len(t, sorted, 2)
+		require.ElementsMatch(t, []string{"hash-alice-0", "hash-bob-0"}, []string{string(sorted[0].TxHash), string(sorted[1].TxHash)})
+	})
+
+	t.Run("should charge the relayers, up to their balance", func(t *testing.T) {
+		config := txcachemocks.DefaultMempoolHostConfig()
+		config.FeeModel = txcachemocks.FixedFeeModel
+		config.FixedFee = big.NewInt(100)
+		config.ExtraFeeForRelayed = big.NewInt(50)
+		cache := newUnconstrainedCacheWithHostToTest(txcachemocks.NewMempoolHostMockWithConfig(config))
+
+		session := txcachemocks.NewSelectionSessionMock()
+		session.SetBalance([]byte("alice"), big.NewInt(0))
+		session.SetBalance([]byte("relayer"), big.NewInt(300))
+
+		for nonce := uint64(0); nonce < 3; nonce++ {
+			cache.AddTx(createTx([]byte(fmt.Sprintf("hash-alice-%d", nonce)), "alice", nonce).withRelayer([]byte("relayer")).withGasLimit(100_000))
+		}
+
+		sorted, _ := cache.SelectTransactions(session, math.MaxUint64, math.MaxInt, selectionLoopMaximumDuration)
+		require.Len(t, sorted, 2)
+	})
+}
+
 func TestTxCache_SelectTransactions_WhenTransactionsAddedInReversedNonceOrder(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 	session := txcachemocks.NewSelectionSessionMock()
@@ -0,0 +1,101 @@
+package txcache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SharedHashIndex is an index of the transaction hashes, shared by several caches (e.g. the pools of the shards), each
+// hash being owned by the cache holding the transaction. A cache using the index rejects the transactions already held
+// by another cache, instead of storing them redundantly
+type SharedHashIndex struct {
+	mut          sync.RWMutex
+	ownersByHash map[string]string
+}
+
+// NewSharedHashIndex creates an empty SharedHashIndex
+func NewSharedHashIndex() *SharedHashIndex {
+	return &SharedHashIndex{
+		ownersByHash: make(map[string]string),
+	}
+}
+
+// Owner returns the name of the cache holding the transaction
+func (index *SharedHashIndex) Owner(txHash []byte) (string, bool) {
+	index.mut.RLock()
+	defer index.mut.RUnlock()
+
+	owner, ok := index.ownersByHash[string(txHash)]
+	return owner, ok
+}
+
+// Len returns the number of the indexed hashes
+func (index *SharedHashIndex) Len() int {
+	index.mut.RLock()
+	defer index.mut.RUnlock()
+
+	return len(index.ownersByHash)
+}
+
+// tryAdd adds the hash, owned by the provided cache, returning false if it is owned by another cache
+func (index *SharedHashIndex) tryAdd(txHash string, owner string) bool {
+	index.mut.Lock()
+	defer index.mut.Unlock()
+
+	existingOwner, ok := index.ownersByHash[txHash]
+	if ok {
+		return existingOwner == owner
+	}
+
+	index.ownersByHash[txHash] = owner
+	return true
+}
+
+// remove removes the hash, if owned by the provided cache
+func (index *SharedHashIndex) remove(txHash string, owner string) {
+	index.mut.Lock()
+	defer index.mut.Unlock()
+
+	if index.ownersByHash[txHash] == owner {
+		delete(index.ownersByHash, txHash)
+	}
+}
+
+// sharedHashIndexHolder holds the SharedHashIndex used by a cache, if any
+type sharedHashIndexHolder struct {
+	owner string
+	index atomic.Pointer[SharedHashIndex]
+}
+
+func (holder *sharedHashIndexHolder) tryAdd(txHash []byte) bool {
+	index := holder.index.Load()
+	if index == nil {
+		return true
+	}
+
+	return index.tryAdd(string(txHash), holder.owner)
+}
+
+func (holder *sharedHashIndexHolder) remove(txHash string) {
+	index := holder.index.Load()
+	if index != nil {
+		index.remove(txHash, holder.owner)
+	}
+}
+
+// UseSharedHashIndex makes the cache reject the transactions held by the other caches using the same index. The
+// transactions already held by the cache are indexed as well, the ones held by another cache being kept, though. The
+// names of the caches sharing an index should be distinct
+func (cache *TxCache) UseSharedHashIndex(index *SharedHashIndex) {
+	if index == nil {
+		return
+	}
+
+	cache.mutTxOperation.Lock()
+	defer cache.mutTxOperation.Unlock()
+
+	cache.txByHash.sharedIndex.index.Store(index)
+	for _, txHash := range cache.txByHash.keys() {
+		_ = index.tryAdd(string(txHash), cache.name)
+	}
+}
@@ -0,0 +1,107 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/stretchr/testify/require"
+)
+
+func newNamedCacheToTest(name string) *TxCache {
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                        name,
+		NumChunks:                   16,
+		NumBytesThreshold:           maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:  maxNumBytesPerSenderUpperBound,
+		CountThreshold:              math.MaxUint32,
+		CountPerSenderThreshold:     math.MaxUint32,
+		NumItemsToPreemptivelyEvict: 1,
+	}, txcachemocks.NewMempoolHostMock())
+	if err != nil {
+		panic(err)
+	}
+
+	return cache
+}
+
+func TestTxCache_SharedHashIndex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should reject the transactions held by another cache", func(t *testing.T) {
+		t.Parallel()
+
+		index := NewSharedHashIndex()
+		shard0 := newNamedCacheToTest("shard-0")
+		shard1 := newNamedCacheToTest("shard-1")
+		shard0.UseSharedHashIndex(index)
+		shard1.UseSharedHashIndex(index)
+
+		ok, added := shard0.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, ok)
+		require.True(t, added)
+
+		ok, added = shard1.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, ok)
+		require.False(t, added)
+		require.Equal(t, uint64(0), shard1.CountTx())
+		require.Equal(t, uint64(1), shard1.Stats()["numRejectedHeldByOthers"])
+
+		// the owner may add the same transaction again, as a duplicate
+		ok, added = shard0.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, ok)
+		require.False(t, added)
+		require.Equal(t, uint64(1), shard0.CountTx())
+
+		owner, found := index.Owner([]byte("hash-alice-1"))
+		require.True(t, found)
+		require.Equal(t, "shard-0", owner)
+
+		// once removed, the transaction can be held by another cache
+		require.True(t, shard0.RemoveTxByHash([]byte("hash-alice-1")))
+		require.Equal(t, 0, index.Len())
+
+		_, added = shard1.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		require.True(t, added)
+		owner, _ = index.Owner([]byte("hash-alice-1"))
+		require.Equal(t, "shard-1", owner)
+	})
+
+	t.Run("should release the hashes on Clear and on the evictions by sender", func(t *testing.T) {
+		t.Parallel()
+
+		index := NewSharedHashIndex()
+		shard0 := newNamedCacheToTest("shard-0")
+		shard0.UseSharedHashIndex(index)
+
+		shard0.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		shard0.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		shard0.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+		require.Equal(t, 3, index.Len())
+
+		// alice-1 is removed along with alice-2
+		require.True(t, shard0.RemoveTxByHash([]byte("hash-alice-2")))
+		require.Equal(t, 1, index.Len())
+
+		shard0.Clear()
+		require.Equal(t, 0, index.Len())
+	})
+
+	t.Run("should index the transactions held before using the index", func(t *testing.T) {
+		t.Parallel()
+
+		index := NewSharedHashIndex()
+		shard0 := newNamedCacheToTest("shard-0")
+		shard1 := newNamedCacheToTest("shard-1")
+		shard0.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		shard1.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+
+		shard0.UseSharedHashIndex(index)
+		shard1.UseSharedHashIndex(index)
+
+		// the duplicates held before are kept, though
+		require.Equal(t, uint64(1), shard1.CountTx())
+		owner, _ := index.Owner([]byte("hash-alice-1"))
+		require.Equal(t, "shard-0", owner)
+	})
+}
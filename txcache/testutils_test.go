@@ -10,6 +10,7 @@ import (
 
 	"github.com/TerraDharitri/drt-go-chain-core/data/transaction"
 	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcachemocks"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/txcacheworkload"
 )
 
 const oneMilion = 1000000
@@ -120,6 +121,15 @@ func (listForSender *txListForSender) getTxsHashes() [][]byte {
 	return result
 }
 
+func hashesOf(transactions []*WrappedTransaction) [][]byte {
+	hashes := make([][]byte, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, tx.TxHash)
+	}
+
+	return hashes
+}
+
 func hashesAsStrings(hashes [][]byte) []string {
 	result := make([]string, len(hashes))
 	for i := 0; i < len(hashes); i++ {
@@ -236,6 +246,12 @@ func (wrappedTx *WrappedTransaction) withRelayer(relayer []byte) *WrappedTransac
 	return wrappedTx
 }
 
+func (wrappedTx *WrappedTransaction) withGuardian(guardian []byte) *WrappedTransaction {
+	tx := wrappedTx.Tx.(*transaction.Transaction)
+	tx.GuardianAddr = guardian
+	return wrappedTx
+}
+
 func createFakeSenderAddress(senderTag int) []byte {
 	bytes := make([]byte, 32)
 	binary.LittleEndian.PutUint64(bytes, uint64(senderTag))
@@ -250,3 +266,16 @@ func createFakeTxHash(fakeSenderAddress []byte, nonce int) []byte {
 	binary.LittleEndian.PutUint64(bytes[16:], uint64(nonce))
 	return bytes
 }
+
+func createTxsFromWorkload(workloadTxs []*txcacheworkload.Transaction) []*WrappedTransaction {
+	txs := make([]*WrappedTransaction, 0, len(workloadTxs))
+	for _, workloadTx := range workloadTxs {
+		txs = append(txs, &WrappedTransaction{
+			Tx:     workloadTx.Tx,
+			TxHash: workloadTx.Hash,
+			Size:   int64(len(workloadTx.Tx.Data)) + int64(estimatedSizeOfBoundedTxFields),
+		})
+	}
+
+	return txs
+}
@@ -5,13 +5,14 @@ type transactionsHeap struct {
 	less  func(i, j int) bool
 }
 
-func newMinTransactionsHeap(capacity int) *transactionsHeap {
+// newEvictionHeap creates a heap popping first the transaction the strategy evicts first
+func newEvictionHeap(capacity int, strategy evictionStrategy) *transactionsHeap {
 	h := transactionsHeap{
 		items: make([]*transactionsHeapItem, 0, capacity),
 	}
 
 	h.less = func(i, j int) bool {
-		return h.items[j].isCurrentTransactionMoreValuableForNetwork(h.items[i])
+		return strategy.evictsBefore(h.items[i].currentTransaction, h.items[j].currentTransaction)
 	}
 
 	return &h
@@ -9,6 +9,7 @@ type transactionsHeapItem struct {
 	currentTransactionNonce        uint64
 	latestSelectedTransaction      *WrappedTransaction
 	latestSelectedTransactionNonce uint64
+	numSelectedTransactions        int
 }
 
 func newTransactionsHeapItem(bunch bunchOfTransactions) (*transactionsHeapItem, error) {
@@ -32,6 +33,7 @@ func newTransactionsHeapItem(bunch bunchOfTransactions) (*transactionsHeapItem,
 func (item *transactionsHeapItem) selectCurrentTransaction() *WrappedTransaction {
 	item.latestSelectedTransaction = item.currentTransaction
 	item.latestSelectedTransactionNonce = item.currentTransactionNonce
+	item.numSelectedTransactions++
 
 	return item.currentTransaction
 }
@@ -10,23 +10,34 @@ type txByHashMap struct {
 	backingMap *maps.ConcurrentMap
 	counter    atomic.Counter
 	numBytes   atomic.Counter
+	// sharedIndex is updated along with the map, so that the hashes are released whichever the removal path
+	sharedIndex sharedHashIndexHolder
+	// arena, if not nil, holds the payloads of the transactions, updated along with the map as well
+	arena *payloadArena
 }
 
 // newTxByHashMap creates a new TxByHashMap instance
-func newTxByHashMap(nChunksHint uint32) *txByHashMap {
+func newTxByHashMap(nChunksHint uint32, owner string) *txByHashMap {
 	backingMap := maps.NewConcurrentMap(nChunksHint)
 
 	return &txByHashMap{
 		backingMap: backingMap,
+		sharedIndex: sharedHashIndexHolder{
+			owner: owner,
+		},
 	}
 }
 
 // addTx adds a transaction to the map
 func (txMap *txByHashMap) addTx(tx *WrappedTransaction) bool {
+	// the payload is moved before the transaction is visible to the readers of the map
+	isMoved := txMap.arena != nil && txMap.arena.add(tx)
 	added := txMap.backingMap.SetIfAbsent(string(tx.TxHash), tx)
 	if added {
 		txMap.counter.Increment()
 		txMap.numBytes.Add(tx.Size)
+	} else if isMoved {
+		txMap.arena.remove(tx)
 	}
 
 	return added
@@ -47,6 +58,10 @@ func (txMap *txByHashMap) removeTx(txHash string) (*WrappedTransaction, bool) {
 	if removed {
 		txMap.counter.Decrement()
 		txMap.numBytes.Subtract(tx.Size)
+		txMap.sharedIndex.remove(txHash)
+		if txMap.arena != nil {
+			txMap.arena.remove(tx)
+		}
 	}
 
 	return tx, true
@@ -86,6 +101,18 @@ func (txMap *txByHashMap) forEach(function ForEachTransaction) {
 }
 
 func (txMap *txByHashMap) clear() {
+	if txMap.sharedIndex.index.Load() != nil {
+		for _, txHash := range txMap.backingMap.Keys() {
+			txMap.sharedIndex.remove(txHash)
+		}
+	}
+
+	if txMap.arena != nil {
+		txMap.forEach(func(_ []byte, tx *WrappedTransaction) {
+			txMap.arena.remove(tx)
+		})
+	}
+
 	txMap.backingMap.Clear()
 	txMap.counter.Set(0)
 }
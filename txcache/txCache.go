@@ -1,6 +1,7 @@
 package txcache
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -8,10 +9,14 @@ import (
 	"github.com/TerraDharitri/drt-go-chain-core/core/atomic"
 	"github.com/TerraDharitri/drt-go-chain-core/core/check"
 	"github.com/TerraDharitri/drt-go-chain-storage/monitoring"
+	"github.com/TerraDharitri/drt-go-chain-storage/tracing"
 	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var _ types.Cacher = (*TxCache)(nil)
+var _ types.EvictionNotifier = (*TxCache)(nil)
+var _ types.StatsProvider = (*TxCache)(nil)
 
 // TxCache represents a cache-like structure (it has a fixed capacity and implements an eviction mechanism) for holding transactions
 type TxCache struct {
@@ -22,15 +27,32 @@ type TxCache struct {
 	host                 MempoolHost
 	evictionMutex        sync.Mutex
 	isEvictionInProgress atomic.Flag
+	isClosed             atomic.Flag
 	mutTxOperation       sync.Mutex
+	// hashFilter is nil if not enabled
+	hashFilter *hashFilter
+
+	mutEvictionHandlers sync.RWMutex
+	mapEvictionHandlers map[string]types.EvictedItemHandler
+
+	mutRecentEvictions sync.Mutex
+	recentEvictions    []EvictionJournalInfo
+	// recentSelections is nil if not enabled
+	recentSelections *selectionOutcomesRing
+	// removedTxs is nil if not enabled
+	removedTxs *removedTxsJournal
+
+	mutGasPriceFloorHandler sync.RWMutex
+	gasPriceFloorHandler    GasPriceFloorHandler
+	numRejectedUnderpriced  atomic.Counter
+	numRejectedHeldByOthers atomic.Counter
 }
 
 // NewTxCache creates a new transaction cache
 func NewTxCache(config ConfigSourceMe, host MempoolHost) (*TxCache, error) {
 	log.Debug("NewTxCache", "config", config.String())
-	monitoring.MonitorNewCache(config.Name, uint64(config.NumBytesThreshold))
 
-	err := config.verify()
+	err := config.Validate()
 	if err != nil {
 		return nil, err
 	}
@@ -45,16 +67,38 @@ func NewTxCache(config ConfigSourceMe, host MempoolHost) (*TxCache, error) {
 	txCache := &TxCache{
 		name:           config.Name,
 		txListBySender: newTxListBySenderMap(numChunks, senderConstraintsObj),
-		txByHash:       newTxByHashMap(numChunks),
+		txByHash:       newTxByHashMap(numChunks, config.Name),
 		config:         config,
 		host:           host,
+
+		mapEvictionHandlers: make(map[string]types.EvictedItemHandler),
+	}
+	if config.NumRecentSelectionsToKeep > 0 {
+		txCache.recentSelections = newSelectionOutcomesRing(int(config.NumRecentSelectionsToKeep))
+	}
+	if config.NumRemovedTxsToKeepForRollback > 0 {
+		txCache.removedTxs = newRemovedTxsJournal(int(config.NumRemovedTxsToKeepForRollback))
+	}
+	if config.PayloadArenaEnabled {
+		txCache.txByHash.arena = newPayloadArena(payloadArenaChunkSize)
+	}
+	if config.HashFilterFalsePositiveRate > 0 {
+		txCache.hashFilter, err = newHashFilter(uint64(config.CountThreshold), config.HashFilterFalsePositiveRate)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	monitoring.MonitorNewCache(config.Name, uint64(config.NumBytesThreshold))
+	monitoring.RegisterDiagnosticsProvider(config.Name, txCache)
+
 	return txCache, nil
 }
 
 // AddTx adds a transaction in the cache
 // Eviction happens if maximum capacity is reached
+// Transactions with a gas price below the admission floor are rejected, before taking any room in the cache
+// Transactions held by another cache sharing the same SharedHashIndex are not added (ok = true, added = false)
 func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
 	if tx == nil || check.IfNil(tx.Tx) {
 		return false, false
@@ -62,16 +106,34 @@ func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
 
 	logAdd.Trace("TxCache.AddTx", "tx", tx.TxHash, "nonce", tx.Tx.GetNonce(), "sender", tx.Tx.GetSndAddr())
 
+	if !cache.isAdmittedByGasPrice(tx) {
+		return false, false
+	}
+
 	tx.precomputeFields(cache.host)
+	if tx.ReceivedAt.IsZero() {
+		tx.ReceivedAt = time.Now()
+	}
 
 	if cache.config.EvictionEnabled {
 		_ = cache.doEviction()
 	}
 
 	cache.mutTxOperation.Lock()
+	if !cache.txByHash.sharedIndex.tryAdd(tx.TxHash) {
+		cache.mutTxOperation.Unlock()
+		cache.numRejectedHeldByOthers.Increment()
+		logAdd.Trace("TxCache.AddTx: transaction held by another cache", "tx", tx.TxHash)
+		return true, false
+	}
+	// the hash is added to the filter along with the maps, under the same lock, so that a rebuild does not lose it
+	shouldRebuildHashFilter := cache.hashFilter != nil && cache.hashFilter.add(tx.TxHash)
 	addedInByHash := cache.txByHash.addTx(tx)
 	addedInBySender, evicted := cache.txListBySender.addTxReturnEvicted(tx)
 	cache.mutTxOperation.Unlock()
+	if shouldRebuildHashFilter {
+		cache.rebuildHashFilter()
+	}
 	if addedInByHash != addedInBySender {
 		// This can happen  when two go-routines concur to add the same transaction:
 		// - A adds to "txByHash"
@@ -83,7 +145,7 @@ func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
 
 	if len(evicted) > 0 {
 		logRemove.Trace("TxCache.AddTx with eviction", "sender", tx.Tx.GetSndAddr(), "num evicted txs", len(evicted))
-		cache.txByHash.RemoveTxsBulk(evicted)
+		cache.removeEvictedTxs(evicted, types.EvictionReasonCapacity)
 	}
 
 	// The return value "added" is true even if transaction added, but then removed due to limits be sender.
@@ -100,11 +162,35 @@ func (cache *TxCache) GetByTxHash(txHash []byte) (*WrappedTransaction, bool) {
 // SelectTransactions selects the best transactions to be included in the next miniblock.
 // It returns up to "maxNum" transactions, with total gas <= "gasRequested".
 func (cache *TxCache) SelectTransactions(session SelectionSession, gasRequested uint64, maxNum int, selectionLoopMaximumDuration time.Duration) ([]*WrappedTransaction, uint64) {
+	return cache.SelectTransactionsCtx(context.Background(), session, gasRequested, maxNum, selectionLoopMaximumDuration)
+}
+
+// SelectTransactionsCtx selects the transactions as SelectTransactions does, the selection being traced in a span
+// child of the one held by the context
+func (cache *TxCache) SelectTransactionsCtx(ctx context.Context, session SelectionSession, gasRequested uint64, maxNum int, selectionLoopMaximumDuration time.Duration) ([]*WrappedTransaction, uint64) {
+	return cache.SelectTransactionsWithOptions(ctx, session, SelectionOptions{
+		GasRequested:        gasRequested,
+		MaxNum:              maxNum,
+		LoopMaximumDuration: selectionLoopMaximumDuration,
+	})
+}
+
+// SelectTransactionsWithOptions selects the transactions as SelectTransactionsCtx does, the selection being further
+// constrained by the options, e.g. the maximum number of transactions selected from a sender
+func (cache *TxCache) SelectTransactionsWithOptions(ctx context.Context, session SelectionSession, options SelectionOptions) ([]*WrappedTransaction, uint64) {
 	if check.IfNil(session) {
 		log.Error("TxCache.SelectTransactions", "err", errNilSelectionSession)
 		return nil, 0
 	}
 
+	_, span := tracing.StartSpan(ctx, "txcache.SelectTransactions",
+		attribute.String("cache", cache.name),
+		attribute.Int64("gas_requested", int64(options.GasRequested)),
+		attribute.Int("max_num", options.MaxNum),
+		attribute.Int("max_num_per_sender", options.MaxNumPerSender),
+	)
+	defer span.End()
+
 	stopWatch := core.NewStopWatch()
 	stopWatch.Start("selection")
 
@@ -115,9 +201,11 @@ func (cache *TxCache) SelectTransactions(session SelectionSession, gasRequested
 		"num senders", cache.CountSenders(),
 	)
 
-	transactions, accumulatedGas := cache.doSelectTransactions(session, gasRequested, maxNum, selectionLoopMaximumDuration)
+	selectionStartTime := time.Now()
+	transactions, accumulatedGas, stats := cache.doSelectTransactions(session, options)
 
 	stopWatch.Stop("selection")
+	cache.recordSelection(selectionStartTime, stopWatch.GetMeasurement("selection"), options, transactions, accumulatedGas, stats)
 
 	logSelect.Debug(
 		"TxCache.SelectTransactions: end",
@@ -126,6 +214,8 @@ func (cache *TxCache) SelectTransactions(session SelectionSession, gasRequested
 		"gas", accumulatedGas,
 	)
 
+	span.SetAttributes(attribute.Int("num_selected", len(transactions)), attribute.Int64("gas", int64(accumulatedGas)))
+
 	go cache.diagnoseCounters()
 	go displaySelectionOutcome(logSelect, "selection", transactions)
 
@@ -148,17 +238,25 @@ func (cache *TxCache) RemoveTxByHash(txHash []byte) bool {
 	}
 
 	evicted := cache.txListBySender.removeTransactionsWithLowerOrEqualNonceReturnHashes(tx)
+	if cache.removedTxs != nil {
+		cache.keepRemovedTxs(tx, evicted)
+	}
 	if len(evicted) > 0 {
-		cache.txByHash.RemoveTxsBulk(evicted)
+		cache.removeEvictedTxs(evicted, types.EvictionReasonCapacity)
 	}
 
 	logRemove.Trace("TxCache.RemoveTxByHash", "tx", txHash, "len(evicted)", len(evicted))
 	return true
 }
 
-// NumBytes gets the approximate number of bytes stored in the cache
+// NumBytes gets the approximate number of bytes stored in the cache, including the dead bytes of the payload arena
 func (cache *TxCache) NumBytes() int {
-	return int(cache.txByHash.numBytes.GetUint64())
+	numBytes := int(cache.txByHash.numBytes.GetUint64())
+	if cache.txByHash.arena != nil {
+		numBytes += cache.txByHash.arena.numDeadBytes()
+	}
+
+	return numBytes
 }
 
 // CountTx gets the number of transactions in the cache
@@ -212,6 +310,12 @@ func (cache *TxCache) Clear() {
 	cache.mutTxOperation.Lock()
 	cache.txListBySender.clear()
 	cache.txByHash.clear()
+	if cache.hashFilter != nil {
+		cache.hashFilter.rebuild(nil)
+	}
+	if cache.removedTxs != nil {
+		cache.removedTxs.clear()
+	}
 	cache.mutTxOperation.Unlock()
 }
 
@@ -231,8 +335,12 @@ func (cache *TxCache) Get(key []byte) (value interface{}, ok bool) {
 	return nil, false
 }
 
-// Has checks if a transaction exists
+// Has checks if a transaction exists. If the hash filter is enabled, most of the unknown hashes are answered by it
 func (cache *TxCache) Has(key []byte) bool {
+	if cache.hashFilter != nil && !cache.hashFilter.mayContain(key) {
+		return false
+	}
+
 	_, ok := cache.GetByTxHash(key)
 	return ok
 }
@@ -279,15 +387,63 @@ func (cache *TxCache) UnRegisterHandler(string) {
 	log.Error("TxCache.UnRegisterHandler is not implemented")
 }
 
+// RegisterEvictionHandler registers a new handler to be called whenever a transaction is evicted, either due to
+// the limits of the whole cache or due to the limits of its sender. The key is the hash of the transaction,
+// while the value is the *WrappedTransaction
+func (cache *TxCache) RegisterEvictionHandler(handler types.EvictedItemHandler, id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a cacher object")
+		return
+	}
+
+	cache.mutEvictionHandlers.Lock()
+	cache.mapEvictionHandlers[id] = handler
+	cache.mutEvictionHandlers.Unlock()
+}
+
+// UnRegisterEvictionHandler removes the eviction handler from the list
+func (cache *TxCache) UnRegisterEvictionHandler(id string) {
+	cache.mutEvictionHandlers.Lock()
+	delete(cache.mapEvictionHandlers, id)
+	cache.mutEvictionHandlers.Unlock()
+}
+
+func (cache *TxCache) callEvictionHandlers(tx *WrappedTransaction, reason types.EvictionReason) {
+	cache.mutEvictionHandlers.RLock()
+	for _, handler := range cache.mapEvictionHandlers {
+		go handler(tx.TxHash, tx, reason)
+	}
+	cache.mutEvictionHandlers.RUnlock()
+}
+
 // ImmunizeTxsAgainstEviction does nothing for this type of cache
 func (cache *TxCache) ImmunizeTxsAgainstEviction(_ [][]byte) {
 }
 
-// Close does nothing for this cacher implementation
+// Close deregisters the cache and its diagnostics from the monitoring
 func (cache *TxCache) Close() error {
+	if !cache.isClosed.SetReturningPrevious() {
+		monitoring.MonitorClosedCache(cache.name, uint64(cache.config.NumBytesThreshold))
+		monitoring.DeregisterDiagnosticsProvider(cache.name, cache)
+	}
+
 	return nil
 }
 
+// Stats returns the key stats of the cache. Unlike SizeInBytesContained, the number of items is the number of transactions
+func (cache *TxCache) Stats() map[string]interface{} {
+	stats := types.CacheStats("TxCache", cache)
+	stats[types.StatNumItems] = cache.CountTx()
+	stats["numSenders"] = cache.CountSenders()
+	stats["numRejectedUnderpriced"] = cache.numRejectedUnderpriced.GetUint64()
+	stats["numRejectedHeldByOthers"] = cache.numRejectedHeldByOthers.GetUint64()
+	if cache.txByHash.arena != nil {
+		cache.txByHash.arena.addStats(stats)
+	}
+
+	return stats
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (cache *TxCache) IsInterfaceNil() bool {
 	return cache == nil
@@ -599,8 +599,10 @@ func TestBenchmarkTxCache_addManyTransactionsWithSameNonce(t *testing.T) {
 }
 
 func newUnconstrainedCacheToTest() *TxCache {
-	host := txcachemocks.NewMempoolHostMock()
+	return newUnconstrainedCacheWithHostToTest(txcachemocks.NewMempoolHostMock())
+}
 
+func newUnconstrainedCacheWithHostToTest(host MempoolHost) *TxCache {
 	cache, err := NewTxCache(ConfigSourceMe{
 		Name:                        "test",
 		NumChunks:                   16,
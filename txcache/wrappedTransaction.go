@@ -3,6 +3,7 @@ package txcache
 import (
 	"bytes"
 	"math/big"
+	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/data"
 )
@@ -25,6 +26,19 @@ type WrappedTransaction struct {
 	PricePerUnit     uint64
 	TransferredValue *big.Int
 	FeePayer         []byte
+
+	// ReceivedAt is set by TxCache.AddTx, unless already set, and used by the eviction strategies considering the age
+	ReceivedAt time.Time
+
+	// IsOwnedByCache is set by the creator of the wrapped transaction which hands it over to the cache: the transaction
+	// is neither read nor written by its creator from TxCache.AddTx on, so that the payload arena, if enabled, can
+	// replace its data with an identical copy
+	IsOwnedByCache bool
+
+	// arenaChunk is the index, plus one, of the payload arena chunk holding the data, zero if not held by an arena.
+	// Along with isPayloadMoved, it is guarded by the mutex of the arena
+	arenaChunk     uint32
+	isPayloadMoved bool
 }
 
 // precomputeFields computes (and caches) the (average) price per gas unit.
@@ -85,6 +85,64 @@ func TestWrappedTransaction_precomputeFields(t *testing.T) {
 	})
 }
 
+func TestWrappedTransaction_precomputeFieldsWithFeeModels(t *testing.T) {
+	t.Run("full gas price", func(t *testing.T) {
+		config := txcachemocks.DefaultMempoolHostConfig()
+		config.FeeModel = txcachemocks.FullGasPriceFeeModel
+		host := txcachemocks.NewMempoolHostMockWithConfig(config)
+
+		tx := createTx([]byte("a"), "a", 1).withGasLimit(oneMilion).withGasPrice(oneBillion)
+		tx.precomputeFields(host)
+
+		require.Equal(t, "1000000000000000", tx.Fee.String())
+		require.Equal(t, oneBillion, int(tx.PricePerUnit))
+	})
+
+	t.Run("fixed fee", func(t *testing.T) {
+		config := txcachemocks.DefaultMempoolHostConfig()
+		config.FeeModel = txcachemocks.FixedFeeModel
+		config.FixedFee = big.NewInt(1000)
+		host := txcachemocks.NewMempoolHostMockWithConfig(config)
+
+		tx := createTx([]byte("a"), "a", 1).withGasLimit(oneMilion)
+		tx.precomputeFields(host)
+
+		require.Equal(t, "1000", tx.Fee.String())
+	})
+
+	t.Run("guarded and relayed, with extra fee for relayed", func(t *testing.T) {
+		config := txcachemocks.DefaultMempoolHostConfig()
+		config.ExtraFeeForRelayed = big.NewInt(7)
+		host := txcachemocks.NewMempoolHostMockWithConfig(config)
+
+		tx := createTx([]byte("a"), "a", 1).withGuardian([]byte("g")).withRelayer([]byte("b")).withGasLimit(150_000)
+		tx.precomputeFields(host)
+
+		require.Equal(t, "150000000000007", tx.Fee.String())
+		require.Equal(t, []byte("b"), tx.FeePayer)
+	})
+
+	t.Run("insufficient gas limit allowed", func(t *testing.T) {
+		config := txcachemocks.DefaultMempoolHostConfig()
+		config.AllowInsufficientGasLimit = true
+		host := txcachemocks.NewMempoolHostMockWithConfig(config)
+
+		tx := createTx([]byte("a"), "a", 1).withGuardian([]byte("g"))
+		tx.precomputeFields(host)
+
+		require.Equal(t, "50000000000000", tx.Fee.String())
+	})
+
+	t.Run("insufficient gas limit not allowed", func(t *testing.T) {
+		host := txcachemocks.NewMempoolHostMock()
+
+		tx := createTx([]byte("a"), "a", 1).withGuardian([]byte("g"))
+		require.Panics(t, func() {
+			tx.precomputeFields(host)
+		})
+	})
+}
+
 func TestWrappedTransaction_decideFeePayer(t *testing.T) {
 	host := txcachemocks.NewMempoolHostMock()
 
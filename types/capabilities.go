@@ -0,0 +1,289 @@
+package types
+
+import (
+	"bytes"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+)
+
+// BatchedReader is implemented by the persisters able to read several keys in one go
+type BatchedReader interface {
+	// GetMany returns the values of the provided keys, the missing keys being left out
+	GetMany(keys [][]byte) (map[string][]byte, error)
+}
+
+// BulkWriter is implemented by the persisters able to write and remove several keys in one go
+type BulkWriter interface {
+	MultiPutter
+	// MultiRemove removes the data associated to all the provided keys
+	MultiRemove(keys [][]byte) error
+}
+
+// PersisterView is a read only, point in time view of the contents of a persister
+type PersisterView interface {
+	// Get gets the value associated to the key
+	Get(key []byte) ([]byte, error)
+	// Has returns nil if the given key is present in the view
+	Has(key []byte) error
+	// RangeKeys iterates over the (key, value) pairs of the view
+	RangeKeys(handler func(key []byte, val []byte) bool)
+	// Release frees the resources held by the view, which should not be used afterwards
+	Release()
+}
+
+// Snapshotter is implemented by the persisters able to provide point in time views of their contents
+type Snapshotter interface {
+	// TakeSnapshot returns a view of the current contents, not affected by the writes done after it was taken
+	TakeSnapshot() (PersisterView, error)
+	// ReleaseSnapshot releases the view returned by TakeSnapshot, which should not be used afterwards
+	ReleaseSnapshot(view PersisterView)
+}
+
+// SnapshotablePersister is a persister able to provide point in time views of its contents
+type SnapshotablePersister interface {
+	Persister
+	Snapshotter
+}
+
+// Iterator iterates over the (key, value) pairs of a persister, in ascending order of the keys, or in descending order
+// with Last and Prev. It starts before the first pair, so Next, Seek or Last has to be called before reading one. The
+// returned key and value are valid until the iterator is moved, and should not be modified
+type Iterator interface {
+	// Seek moves to the first pair whose key is greater than or equal to the provided one, returning false if none is
+	Seek(key []byte) bool
+	// Next moves to the next pair, returning false once the pairs are exhausted
+	Next() bool
+	// Last moves to the last pair, returning false if there is none
+	Last() bool
+	// Prev moves to the previous pair, returning false once the pairs are exhausted. Once the iterator moved past the
+	// last pair, Prev moves to the last pair
+	Prev() bool
+	// Key returns the key of the current pair
+	Key() []byte
+	// Value returns the value of the current pair
+	Value() []byte
+	// Close releases the iterator, returning the error encountered while iterating, if any
+	Close() error
+}
+
+// Iterable is implemented by the persisters able to iterate over their keys, in both orders
+type Iterable interface {
+	// NewIterator returns an iterator over the persisted pairs, which has to be closed after use
+	NewIterator() (Iterator, error)
+}
+
+// IterablePersister is a persister able to iterate over its keys, in both orders
+type IterablePersister interface {
+	Persister
+	Iterable
+}
+
+// SeekLastWithPrefix moves the iterator to the last pair whose key has the provided prefix, returning false if none
+// has, without going through the pairs having the prefix, e.g. to find the latest entry of keys suffixed by a nonce
+func SeekLastWithPrefix(it Iterator, prefix []byte) bool {
+	upperBound := prefixSuccessor(prefix)
+
+	var found bool
+	if upperBound != nil && it.Seek(upperBound) {
+		found = it.Prev()
+	} else {
+		found = it.Last()
+	}
+
+	return found && bytes.HasPrefix(it.Key(), prefix)
+}
+
+// prefixSuccessor returns the smallest key greater than all the keys having the prefix, nil if there is none, as the
+// prefix is empty or made of 0xff bytes only
+func prefixSuccessor(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			successor := make([]byte, i+1)
+			copy(successor, prefix)
+			successor[i]++
+
+			return successor
+		}
+	}
+
+	return nil
+}
+
+// Compactable is implemented by the persisters whose storage medium can be compacted on demand
+type Compactable interface {
+	// Compact compacts the storage medium, reclaiming the space held by the removed and overwritten values
+	Compact() error
+}
+
+// AsIterable returns the persister as an IterablePersister, if it supports it
+func AsIterable(p Persister) (IterablePersister, bool) {
+	if check.IfNil(p) {
+		return nil, false
+	}
+
+	iterable, ok := p.(IterablePersister)
+	return iterable, ok
+}
+
+// AsBatchedReader returns the persister as a BatchedReader, if it supports it
+func AsBatchedReader(p Persister) (BatchedReader, bool) {
+	if check.IfNil(p) {
+		return nil, false
+	}
+
+	batchedReader, ok := p.(BatchedReader)
+	return batchedReader, ok
+}
+
+// AsBulkWriter returns the persister as a BulkWriter, if it supports it
+func AsBulkWriter(p Persister) (BulkWriter, bool) {
+	if check.IfNil(p) {
+		return nil, false
+	}
+
+	bulkWriter, ok := p.(BulkWriter)
+	return bulkWriter, ok
+}
+
+// AsSnapshotter returns the persister as a Snapshotter, if it supports it
+func AsSnapshotter(p Persister) (Snapshotter, bool) {
+	if check.IfNil(p) {
+		return nil, false
+	}
+
+	snapshotter, ok := p.(Snapshotter)
+	return snapshotter, ok
+}
+
+// AsCompactable returns the persister as a Compactable, if it supports it
+func AsCompactable(p Persister) (Compactable, bool) {
+	if check.IfNil(p) {
+		return nil, false
+	}
+
+	compactable, ok := p.(Compactable)
+	return compactable, ok
+}
+
+// GetMany returns the values of the provided keys, the missing keys being left out. The keys are read in one go if
+// the persister is a BatchedReader, one by one otherwise
+func GetMany(p Persister, keys [][]byte) (map[string][]byte, error) {
+	batchedReader, ok := AsBatchedReader(p)
+	if ok {
+		return batchedReader.GetMany(keys)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		val, err := p.Get(key)
+		if err == nil {
+			values[string(key)] = val
+			continue
+		}
+		// the persisters report the missing keys with various errors, so only the errors of the present keys are returned
+		if p.Has(key) != nil {
+			continue
+		}
+
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// RemoveMany removes the data associated to all the provided keys, in one go if the persister is a BulkWriter, one by
+// one otherwise
+func RemoveMany(p Persister, keys [][]byte) error {
+	bulkWriter, ok := AsBulkWriter(p)
+	if ok {
+		return bulkWriter.MultiRemove(keys)
+	}
+
+	for _, key := range keys {
+		err := p.Remove(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DurabilityClass tells how the writes acknowledged by a persister survive a crash of the process
+type DurabilityClass string
+
+const (
+	// UnknownDurability is reported by the persisters not describing their durability
+	UnknownDurability DurabilityClass = "Unknown"
+	// VolatileDurability is reported by the persisters holding their data in memory only, lost when the process stops
+	VolatileDurability DurabilityClass = "Volatile"
+	// BufferedDurability is reported by the persisters buffering the writes in memory before writing them to disk, so
+	// the writes not yet flushed are lost on a crash
+	BufferedDurability DurabilityClass = "Buffered"
+	// SyncedDurability is reported by the persisters whose acknowledged writes are already on disk
+	SyncedDurability DurabilityClass = "Synced"
+)
+
+var durabilityRanks = map[DurabilityClass]int{
+	UnknownDurability:  0,
+	VolatileDurability: 1,
+	BufferedDurability: 2,
+	SyncedDurability:   3,
+}
+
+// WeakestDurability returns the weakest of the provided durability classes, the unknown one being the weakest
+func WeakestDurability(first DurabilityClass, second DurabilityClass) DurabilityClass {
+	if durabilityRanks[second] < durabilityRanks[first] {
+		return second
+	}
+
+	return first
+}
+
+// PersisterCapabilities describes the optional features of a persister, so that the higher level components select
+// their strategies at runtime instead of assuming them for each engine
+type PersisterCapabilities struct {
+	SupportsSnapshots bool
+	SupportsTTL       bool
+	SupportsIteration bool
+	Durability        DurabilityClass
+}
+
+// CapabilitiesProvider is implemented by the persisters and the decorators describing their capabilities
+type CapabilitiesProvider interface {
+	// Capabilities returns the optional features supported by the persister
+	Capabilities() PersisterCapabilities
+}
+
+// CapabilitiesOf returns the capabilities of the persister. For the persisters not implementing CapabilitiesProvider,
+// the capabilities are inferred from the implemented interfaces, with an unknown durability
+func CapabilitiesOf(p Persister) PersisterCapabilities {
+	if check.IfNil(p) {
+		return PersisterCapabilities{Durability: UnknownDurability}
+	}
+
+	provider, ok := p.(CapabilitiesProvider)
+	if ok {
+		return provider.Capabilities()
+	}
+
+	_, supportsSnapshots := AsSnapshotter(p)
+	_, supportsIteration := AsIterable(p)
+
+	return PersisterCapabilities{
+		SupportsSnapshots: supportsSnapshots,
+		SupportsIteration: supportsIteration,
+		Durability:        UnknownDurability,
+	}
+}
+
+// ForwardedCapabilities returns the capabilities kept by a decorator forwarding only the basic operations to the
+// provided persister: its durability and expiry of the entries, but neither its snapshots nor its iteration
+func ForwardedCapabilities(p Persister) PersisterCapabilities {
+	capabilities := CapabilitiesOf(p)
+
+	return PersisterCapabilities{
+		SupportsTTL: capabilities.SupportsTTL,
+		Durability:  capabilities.Durability,
+	}
+}
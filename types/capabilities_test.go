@@ -0,0 +1,165 @@
+package types_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesDetection(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_, ok := types.AsIterable(mdb)
+	assert.True(t, ok)
+	_, ok = types.AsBatchedReader(mdb)
+	assert.True(t, ok)
+	_, ok = types.AsBulkWriter(mdb)
+	assert.True(t, ok)
+	_, ok = types.AsSnapshotter(mdb)
+	assert.True(t, ok)
+
+	stub := &testscommon.PersisterStub{}
+	_, ok = types.AsIterable(stub)
+	assert.False(t, ok)
+	_, ok = types.AsBatchedReader(stub)
+	assert.False(t, ok)
+	_, ok = types.AsBulkWriter(stub)
+	assert.False(t, ok)
+	_, ok = types.AsSnapshotter(stub)
+	assert.False(t, ok)
+	_, ok = types.AsCompactable(stub)
+	assert.False(t, ok)
+
+	var nilDB *memorydb.DB
+	_, ok = types.AsBatchedReader(nilDB)
+	assert.False(t, ok)
+}
+
+func TestSeekLastWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.MultiPut(map[string][]byte{
+		"a":       []byte("a"),
+		"nonce-1": []byte("1"),
+		"nonce-9": []byte("9"),
+		"round-4": []byte("4"),
+	})
+
+	it, err := mdb.NewIterator()
+	assert.Nil(t, err)
+
+	assert.True(t, types.SeekLastWithPrefix(it, []byte("nonce-")))
+	assert.Equal(t, []byte("9"), it.Value())
+	assert.True(t, types.SeekLastWithPrefix(it, []byte("round-")))
+	assert.Equal(t, []byte("4"), it.Value())
+	assert.True(t, types.SeekLastWithPrefix(it, nil))
+	assert.Equal(t, []byte("round-4"), it.Key())
+	assert.False(t, types.SeekLastWithPrefix(it, []byte("epoch-")))
+	assert.False(t, types.SeekLastWithPrefix(it, []byte("\xff")))
+	assert.Nil(t, it.Close())
+}
+
+func TestGetManyAndRemoveMany(t *testing.T) {
+	t.Parallel()
+
+	t.Run("native support", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		_ = mdb.Put([]byte("key1"), []byte("value1"))
+		_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+		values, err := types.GetMany(mdb, [][]byte{[]byte("key1"), []byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"key1": []byte("value1")}, values)
+
+		assert.Nil(t, types.RemoveMany(mdb, [][]byte{[]byte("key1"), []byte("key2")}))
+		assert.Equal(t, 0, mdb.Len())
+	})
+	t.Run("fallback", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		removed := make([]string, 0)
+		stub := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				switch string(key) {
+				case "key1":
+					return []byte("value1"), nil
+				case "failing":
+					return nil, expectedErr
+				default:
+					return nil, errors.New("key not found")
+				}
+			},
+			HasCalled: func(key []byte) error {
+				if string(key) == "missing" {
+					return errors.New("key not found")
+				}
+				return nil
+			},
+			RemoveCalled: func(key []byte) error {
+				removed = append(removed, string(key))
+				return nil
+			},
+		}
+
+		values, err := types.GetMany(stub, [][]byte{[]byte("key1"), []byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"key1": []byte("value1")}, values)
+		_, err = types.GetMany(stub, [][]byte{[]byte("key1"), []byte("failing")})
+		assert.True(t, errors.Is(err, expectedErr))
+
+		assert.Nil(t, types.RemoveMany(stub, [][]byte{[]byte("key1"), []byte("key2")}))
+		assert.Equal(t, []string{"key1", "key2"}, removed)
+	})
+}
+
+func TestCapabilitiesOf(t *testing.T) {
+	t.Parallel()
+
+	t.Run("provider should report its capabilities", func(t *testing.T) {
+		t.Parallel()
+
+		capabilities := types.CapabilitiesOf(memorydb.New())
+		assert.Equal(t, types.PersisterCapabilities{
+			SupportsSnapshots: true,
+			SupportsIteration: true,
+			Durability:        types.VolatileDurability,
+		}, capabilities)
+	})
+	t.Run("other persisters should have their capabilities inferred", func(t *testing.T) {
+		t.Parallel()
+
+		capabilities := types.CapabilitiesOf(&testscommon.PersisterStub{})
+		assert.Equal(t, types.PersisterCapabilities{Durability: types.UnknownDurability}, capabilities)
+	})
+	t.Run("nil persister should report no capability", func(t *testing.T) {
+		t.Parallel()
+
+		var nilDB *memorydb.DB
+		capabilities := types.CapabilitiesOf(nilDB)
+		assert.Equal(t, types.PersisterCapabilities{Durability: types.UnknownDurability}, capabilities)
+	})
+	t.Run("forwarded capabilities should keep only the durability and the expiry", func(t *testing.T) {
+		t.Parallel()
+
+		capabilities := types.ForwardedCapabilities(memorydb.New())
+		assert.Equal(t, types.PersisterCapabilities{Durability: types.VolatileDurability}, capabilities)
+	})
+}
+
+func TestWeakestDurability(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, types.BufferedDurability, types.WeakestDurability(types.SyncedDurability, types.BufferedDurability))
+	assert.Equal(t, types.VolatileDurability, types.WeakestDurability(types.VolatileDurability, types.BufferedDurability))
+	assert.Equal(t, types.UnknownDurability, types.WeakestDurability(types.SyncedDurability, types.UnknownDurability))
+	assert.Equal(t, types.SyncedDurability, types.WeakestDurability(types.SyncedDurability, types.SyncedDurability))
+}
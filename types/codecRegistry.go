@@ -0,0 +1,192 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/marshal"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+const codecVersionLength = 1
+
+// StoredDataCodec is the pair of a marshalizer and a factory of the empty objects it unmarshals into. The objects
+// holding their serialized form, implementing SerializedStoredData, are not marshalled
+type StoredDataCodec struct {
+	Marshalizer       marshal.Marshalizer
+	StoredDataFactory StoredDataFactory
+}
+
+func (codec StoredDataCodec) check() error {
+	if check.IfNil(codec.Marshalizer) {
+		return common.ErrNilMarshalizer
+	}
+	if check.IfNil(codec.StoredDataFactory) {
+		return common.ErrNilStoredDataFactory
+	}
+
+	return nil
+}
+
+func (codec StoredDataCodec) encode(value interface{}) ([]byte, error) {
+	serializedData, ok := value.(SerializedStoredData)
+	if ok {
+		return serializedData.GetSerialized(), nil
+	}
+
+	return codec.Marshalizer.Marshal(value)
+}
+
+func (codec StoredDataCodec) decode(buff []byte) (interface{}, error) {
+	storedData := codec.StoredDataFactory.CreateEmpty()
+	serializedData, ok := storedData.(SerializedStoredData)
+	if ok {
+		serializedData.SetSerialized(buff)
+		return serializedData, nil
+	}
+
+	err := codec.Marshalizer.Unmarshal(storedData, buff)
+	if err != nil {
+		return nil, err
+	}
+
+	return storedData, nil
+}
+
+// ArgsCodecRegistry holds the arguments needed to create a CodecRegistry
+type ArgsCodecRegistry struct {
+	// Codecs maps each format version to its codec, the current version included
+	Codecs         map[byte]StoredDataCodec
+	CurrentVersion byte
+	// LegacyCodec, if provided, decodes the values written before the registry, which do not start with a version
+	// byte. The legacy values whose first byte is a registered version can not be told apart from the versioned ones,
+	// hence the versions should be chosen among the bytes the legacy values never start with
+	LegacyCodec *StoredDataCodec
+}
+
+// CodecRegistry encodes the stored objects with the codec of the current format version, prepending the version byte,
+// and decodes them with the codec of the version they were written with, so that the serialization of the stored
+// objects is upgraded gradually: the values are rewritten in the current format once written again
+type CodecRegistry struct {
+	codecs         map[byte]StoredDataCodec
+	currentVersion byte
+	legacyCodec    *StoredDataCodec
+}
+
+// NewCodecRegistry creates a new CodecRegistry
+func NewCodecRegistry(args ArgsCodecRegistry) (*CodecRegistry, error) {
+	_, ok := args.Codecs[args.CurrentVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: no codec for the current version %d", common.ErrInvalidConfig, args.CurrentVersion)
+	}
+	for version, codec := range args.Codecs {
+		err := codec.check()
+		if err != nil {
+			return nil, fmt.Errorf("%w for the codec of version %d", err, version)
+		}
+	}
+	if args.LegacyCodec != nil {
+		err := args.LegacyCodec.check()
+		if err != nil {
+			return nil, fmt.Errorf("%w for the legacy codec", err)
+		}
+	}
+
+	codecs := make(map[byte]StoredDataCodec, len(args.Codecs))
+	for version, codec := range args.Codecs {
+		codecs[version] = codec
+	}
+
+	return &CodecRegistry{
+		codecs:         codecs,
+		currentVersion: args.CurrentVersion,
+		legacyCodec:    args.LegacyCodec,
+	}, nil
+}
+
+// CurrentVersion returns the format version the values are encoded with
+func (registry *CodecRegistry) CurrentVersion() byte {
+	return registry.currentVersion
+}
+
+// Encode encodes the value with the codec of the current version, prepending the version byte
+func (registry *CodecRegistry) Encode(value interface{}) ([]byte, error) {
+	payload, err := registry.codecs[registry.currentVersion].encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, codecVersionLength, codecVersionLength+len(payload))
+	encoded[0] = registry.currentVersion
+
+	return append(encoded, payload...), nil
+}
+
+// Decode decodes the value with the codec of the version it was written with or, if it does not start with a
+// registered version, with the legacy codec, if any
+func (registry *CodecRegistry) Decode(buff []byte) (interface{}, error) {
+	if len(buff) < codecVersionLength {
+		if registry.legacyCodec != nil {
+			return registry.legacyCodec.decode(buff)
+		}
+		return nil, fmt.Errorf("%w: %d bytes, missing the format version", common.ErrInvalidValueLength, len(buff))
+	}
+
+	codec, ok := registry.codecs[buff[0]]
+	if ok {
+		return codec.decode(buff[codecVersionLength:])
+	}
+	if registry.legacyCodec != nil {
+		return registry.legacyCodec.decode(buff)
+	}
+
+	return nil, fmt.Errorf("%w: no codec for version %d", common.ErrUnsupportedFormatVersion, buff[0])
+}
+
+// IsCurrent returns true if the encoded value was written with the current version
+func (registry *CodecRegistry) IsCurrent(buff []byte) bool {
+	return len(buff) >= codecVersionLength && buff[0] == registry.currentVersion
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (registry *CodecRegistry) IsInterfaceNil() bool {
+	return registry == nil
+}
+
+// RegistryCodec is the Codec of the typed storers based on a CodecRegistry, so that the storage units read the values
+// written with the older versions while writing the new ones
+type RegistryCodec[V any] struct {
+	registry *CodecRegistry
+}
+
+// NewRegistryCodec creates a Codec based on the provided registry, whose factories should create values of type V
+func NewRegistryCodec[V any](registry *CodecRegistry) (*RegistryCodec[V], error) {
+	if check.IfNil(registry) {
+		return nil, fmt.Errorf("%w: nil codec registry", common.ErrInvalidConfig)
+	}
+
+	return &RegistryCodec[V]{
+		registry: registry,
+	}, nil
+}
+
+// Encode encodes the value with the current version
+func (rc *RegistryCodec[V]) Encode(value V) ([]byte, error) {
+	return rc.registry.Encode(value)
+}
+
+// Decode decodes the value with the codec of its version, which should create a value of type V
+func (rc *RegistryCodec[V]) Decode(buff []byte) (V, error) {
+	var zero V
+	decoded, err := rc.registry.Decode(buff)
+	if err != nil {
+		return zero, err
+	}
+
+	value, ok := decoded.(V)
+	if !ok {
+		return zero, fmt.Errorf("%w: decoded a %T, expected a %T", common.ErrWrongTypeAssertion, decoded, zero)
+	}
+
+	return value, nil
+}
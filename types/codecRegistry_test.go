@@ -0,0 +1,195 @@
+package types_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon/trieFactory"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAccountDataCodec() (types.StoredDataCodec, *trieFactory.CountingStoredDataFactory) {
+	factory := trieFactory.NewCountingStoredDataFactory(trieFactory.NewAccountDataFactory())
+
+	return types.StoredDataCodec{
+		Marshalizer:       &testscommon.MarshalizerMock{},
+		StoredDataFactory: factory,
+	}, factory
+}
+
+func TestNewCodecRegistry(t *testing.T) {
+	t.Parallel()
+
+	codec, _ := createAccountDataCodec()
+
+	registry, err := types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{1: codec},
+		CurrentVersion: 2,
+	})
+	assert.Nil(t, registry)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	registry, err = types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{1: {StoredDataFactory: codec.StoredDataFactory}, 2: codec},
+		CurrentVersion: 2,
+	})
+	assert.Nil(t, registry)
+	assert.True(t, errors.Is(err, common.ErrNilMarshalizer))
+	assert.Contains(t, err.Error(), "version 1")
+
+	registry, err = types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{2: codec},
+		CurrentVersion: 2,
+		LegacyCodec:    &types.StoredDataCodec{Marshalizer: codec.Marshalizer},
+	})
+	assert.Nil(t, registry)
+	assert.True(t, errors.Is(err, common.ErrNilStoredDataFactory))
+	assert.Contains(t, err.Error(), "legacy codec")
+
+	registry, err = types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{2: codec},
+		CurrentVersion: 2,
+	})
+	assert.Nil(t, err)
+	assert.False(t, registry.IsInterfaceNil())
+	assert.Equal(t, byte(2), registry.CurrentVersion())
+}
+
+func TestCodecRegistry_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	oldCodec, oldFactory := createAccountDataCodec()
+	currentCodec, currentFactory := createAccountDataCodec()
+	serializedCodec := types.StoredDataCodec{
+		Marshalizer:       &testscommon.MarshalizerMock{},
+		StoredDataFactory: trieFactory.NewSerializedDataFactory(),
+	}
+	registry, err := types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{1: oldCodec, 2: currentCodec, 3: serializedCodec},
+		CurrentVersion: 2,
+	})
+	require.Nil(t, err)
+
+	t.Run("should write the current version", func(t *testing.T) {
+		account := &trieFactory.AccountData{Nonce: 1, Balance: 10}
+		encoded, errEncode := registry.Encode(account)
+		require.Nil(t, errEncode)
+		assert.Equal(t, byte(2), encoded[0])
+		assert.True(t, registry.IsCurrent(encoded))
+
+		decoded, errDecode := registry.Decode(encoded)
+		require.Nil(t, errDecode)
+		assert.Equal(t, account, decoded)
+		assert.Equal(t, 1, currentFactory.NumCreated())
+	})
+	t.Run("should read the older versions with their codec", func(t *testing.T) {
+		encoded := append([]byte{1}, []byte(`{"Nonce":7}`)...)
+		assert.False(t, registry.IsCurrent(encoded))
+
+		decoded, errDecode := registry.Decode(encoded)
+		require.Nil(t, errDecode)
+		assert.Equal(t, &trieFactory.AccountData{Nonce: 7}, decoded)
+		assert.Equal(t, 1, oldFactory.NumCreated())
+
+		decoded, errDecode = registry.Decode([]byte("\x03serialized"))
+		require.Nil(t, errDecode)
+		assert.Equal(t, []byte("serialized"), decoded.(types.SerializedStoredData).GetSerialized())
+	})
+	t.Run("unknown version should error", func(t *testing.T) {
+		_, errDecode := registry.Decode([]byte(`{"Nonce":7}`))
+		assert.True(t, errors.Is(errDecode, common.ErrUnsupportedFormatVersion))
+
+		_, errDecode = registry.Decode(nil)
+		assert.True(t, errors.Is(errDecode, common.ErrInvalidValueLength))
+	})
+	t.Run("encode error should be returned", func(t *testing.T) {
+		failingRegistry, _ := types.NewCodecRegistry(types.ArgsCodecRegistry{
+			Codecs: map[byte]types.StoredDataCodec{1: {
+				Marshalizer:       &testscommon.MarshalizerMock{Fail: true},
+				StoredDataFactory: trieFactory.NewAccountDataFactory(),
+			}},
+			CurrentVersion: 1,
+		})
+		encoded, errEncode := failingRegistry.Encode(&trieFactory.AccountData{})
+		assert.Nil(t, encoded)
+		assert.NotNil(t, errEncode)
+	})
+}
+
+func TestCodecRegistry_DecodeLegacyValues(t *testing.T) {
+	t.Parallel()
+
+	legacyCodec, legacyFactory := createAccountDataCodec()
+	currentCodec, _ := createAccountDataCodec()
+	registry, err := types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs:         map[byte]types.StoredDataCodec{1: currentCodec},
+		CurrentVersion: 1,
+		LegacyCodec:    &legacyCodec,
+	})
+	require.Nil(t, err)
+
+	decoded, err := registry.Decode([]byte(`{"Nonce":3}`))
+	require.Nil(t, err)
+	assert.Equal(t, &trieFactory.AccountData{Nonce: 3}, decoded)
+	assert.Equal(t, 1, legacyFactory.NumCreated())
+
+	_, err = registry.Decode(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, legacyFactory.NumCreated())
+}
+
+func TestRegistryCodec(t *testing.T) {
+	t.Parallel()
+
+	codec, err := types.NewRegistryCodec[*trieFactory.AccountData](nil)
+	assert.Nil(t, codec)
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	oldCodec, oldFactory := createAccountDataCodec()
+	currentCodec, currentFactory := createAccountDataCodec()
+	registry, _ := types.NewCodecRegistry(types.ArgsCodecRegistry{
+		Codecs: map[byte]types.StoredDataCodec{
+			1: oldCodec,
+			2: currentCodec,
+			3: {Marshalizer: &testscommon.MarshalizerMock{}, StoredDataFactory: trieFactory.NewSerializedDataFactory()},
+		},
+		CurrentVersion: 2,
+	})
+	codec, err = types.NewRegistryCodec[*trieFactory.AccountData](registry)
+	require.Nil(t, err)
+
+	cacher, _ := lrucache.NewCache(10)
+	storer, _ := storageUnit.NewStorageUnit(cacher, memorydb.New())
+	typedStorer, err := types.NewTypedStorer[string, *trieFactory.AccountData](types.ArgsTypedStorer[*trieFactory.AccountData]{
+		Storer: storer,
+		Codec:  codec,
+	})
+	require.Nil(t, err)
+
+	// a value written in the old format is still read, the new values being written in the current one
+	require.Nil(t, storer.Put([]byte("old"), append([]byte{1}, []byte(`{"Nonce":1}`)...)))
+	value, err := typedStorer.Get("old")
+	require.Nil(t, err)
+	assert.Equal(t, uint64(1), value.Nonce)
+	assert.Equal(t, 1, oldFactory.NumCreated())
+
+	require.Nil(t, typedStorer.Put("old", value))
+	buff, _ := storer.Get([]byte("old"))
+	assert.True(t, registry.IsCurrent(buff))
+	value, err = typedStorer.Get("old")
+	require.Nil(t, err)
+	assert.Equal(t, uint64(1), value.Nonce)
+	assert.Equal(t, 1, currentFactory.NumCreated())
+
+	// a value of another type is reported
+	require.Nil(t, storer.Put([]byte("other"), []byte("\x03serialized")))
+	_, err = typedStorer.Get("other")
+	assert.True(t, errors.Is(err, common.ErrWrongTypeAssertion))
+}
@@ -0,0 +1,16 @@
+package types
+
+// EvictionReason describes why an item has been evicted from a cache
+type EvictionReason string
+
+const (
+	// EvictionReasonCapacity signals an item evicted because the maximum number of items has been exceeded
+	EvictionReasonCapacity EvictionReason = "capacity"
+	// EvictionReasonSize signals an item evicted because the maximum size in bytes has been exceeded
+	EvictionReasonSize EvictionReason = "size"
+	// EvictionReasonMemoryPressure signals an item evicted because the cache was asked to shrink, the process using
+	// too much memory
+	EvictionReasonMemoryPressure EvictionReason = "memory pressure"
+	// EvictionReasonExpired signals an item evicted because its time to live elapsed
+	EvictionReasonExpired EvictionReason = "expired"
+)
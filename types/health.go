@@ -0,0 +1,118 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthState is the overall state of a storage component
+type HealthState string
+
+const (
+	// HealthOK is the state of a component working as expected
+	HealthOK HealthState = "OK"
+	// HealthDegraded is the state of a component still serving the requests, but slower or with reduced guarantees
+	HealthDegraded HealthState = "Degraded"
+	// HealthFailed is the state of a component no longer able to serve the requests
+	HealthFailed HealthState = "Failed"
+)
+
+var healthStateRanks = map[HealthState]int{
+	HealthOK:       0,
+	HealthDegraded: 1,
+	HealthFailed:   2,
+}
+
+// WorstHealthState returns the worst of the provided states
+func WorstHealthState(first HealthState, second HealthState) HealthState {
+	if healthStateRanks[second] > healthStateRanks[first] {
+		return second
+	}
+
+	return first
+}
+
+// HealthStatus is the JSON serializable health of a storage component, along with the reasons of its state and the
+// health of its own components, e.g. the cache and the persister of a storage unit
+type HealthStatus struct {
+	State      HealthState             `json:"state"`
+	Reasons    []string                `json:"reasons,omitempty"`
+	CheckedAt  time.Time               `json:"checkedAt"`
+	Components map[string]HealthStatus `json:"components,omitempty"`
+}
+
+// HealthChecker defines a storage component reporting its health, so that the health of the persisters, the caches
+// and the storage units is checked uniformly
+type HealthChecker interface {
+	Health() HealthStatus
+	IsInterfaceNil() bool
+}
+
+// NewHealthStatus returns an OK status, checked now
+func NewHealthStatus() HealthStatus {
+	return HealthStatus{
+		State:     HealthOK,
+		CheckedAt: time.Now(),
+	}
+}
+
+// Degrade marks the status as degraded, unless already failed, for the provided reason
+func (hs *HealthStatus) Degrade(format string, args ...interface{}) {
+	hs.worsen(HealthDegraded, fmt.Sprintf(format, args...))
+}
+
+// Fail marks the status as failed, for the provided reason
+func (hs *HealthStatus) Fail(format string, args ...interface{}) {
+	hs.worsen(HealthFailed, fmt.Sprintf(format, args...))
+}
+
+func (hs *HealthStatus) worsen(state HealthState, reason string) {
+	hs.State = WorstHealthState(hs.State, state)
+	hs.Reasons = append(hs.Reasons, reason)
+}
+
+// AddComponent adds the health of a component, the status getting as bad as the one of the component
+func (hs *HealthStatus) AddComponent(name string, component HealthStatus) {
+	if hs.Components == nil {
+		hs.Components = make(map[string]HealthStatus)
+	}
+	hs.Components[name] = component
+
+	if component.State != HealthOK {
+		hs.worsen(component.State, fmt.Sprintf("%s is %s", name, component.State))
+	}
+}
+
+// AddComponentHealth adds the health of the provided component to the status, if the component is a HealthChecker
+func (hs *HealthStatus) AddComponentHealth(name string, component interface{}) {
+	componentHealth, ok := HealthOf(component)
+	if ok {
+		hs.AddComponent(name, componentHealth)
+	}
+}
+
+// IsOK returns true if the component works as expected
+func (hs HealthStatus) IsOK() bool {
+	return hs.State == HealthOK
+}
+
+// HealthOf returns the health of the provided component and true, or false if it is not a HealthChecker
+func HealthOf(component interface{}) (HealthStatus, bool) {
+	checker, ok := component.(HealthChecker)
+	if !ok || checker.IsInterfaceNil() {
+		return HealthStatus{}, false
+	}
+
+	return checker.Health(), true
+}
+
+// ForwardedHealth returns the health of the component wrapped by a decorator, or an OK status if the wrapped component
+// does not report its health
+func ForwardedHealth(component interface{}) HealthStatus {
+	status, ok := HealthOf(component)
+	if !ok {
+		return NewHealthStatus()
+	}
+
+	return status
+}
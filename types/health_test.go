@@ -0,0 +1,89 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type healthCheckerStub struct {
+	status types.HealthStatus
+}
+
+// Health -
+func (stub *healthCheckerStub) Health() types.HealthStatus {
+	return stub.status
+}
+
+// IsInterfaceNil -
+func (stub *healthCheckerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestHealthStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new status should be OK", func(t *testing.T) {
+		t.Parallel()
+
+		status := types.NewHealthStatus()
+		assert.True(t, status.IsOK())
+		assert.Empty(t, status.Reasons)
+		assert.False(t, status.CheckedAt.IsZero())
+	})
+	t.Run("failed should not be degraded back", func(t *testing.T) {
+		t.Parallel()
+
+		status := types.NewHealthStatus()
+		status.Degrade("slow writes: %d", 3)
+		assert.Equal(t, types.HealthDegraded, status.State)
+		status.Fail("db is closed")
+		status.Degrade("slow reads")
+		assert.Equal(t, types.HealthFailed, status.State)
+		assert.Equal(t, []string{"slow writes: 3", "db is closed", "slow reads"}, status.Reasons)
+	})
+	t.Run("components should worsen the status", func(t *testing.T) {
+		t.Parallel()
+
+		degraded := types.NewHealthStatus()
+		degraded.Degrade("slow")
+		status := types.NewHealthStatus()
+		status.AddComponent("cache", types.NewHealthStatus())
+		assert.True(t, status.IsOK())
+		status.AddComponent("persister", degraded)
+		assert.Equal(t, types.HealthDegraded, status.State)
+		assert.Equal(t, []string{"persister is Degraded"}, status.Reasons)
+		assert.Len(t, status.Components, 2)
+
+		status.AddComponentHealth("db", memorydb.New())
+		assert.Len(t, status.Components, 2)
+	})
+}
+
+func TestHealthOf(t *testing.T) {
+	t.Parallel()
+
+	failed := types.NewHealthStatus()
+	failed.Fail("db is closed")
+	status, ok := types.HealthOf(&healthCheckerStub{status: failed})
+	assert.True(t, ok)
+	assert.Equal(t, failed, status)
+	assert.Equal(t, failed, types.ForwardedHealth(&healthCheckerStub{status: failed}))
+
+	var nilStub *healthCheckerStub
+	_, ok = types.HealthOf(nilStub)
+	assert.False(t, ok)
+	_, ok = types.HealthOf(memorydb.New())
+	assert.False(t, ok)
+	assert.True(t, types.ForwardedHealth(memorydb.New()).IsOK())
+}
+
+func TestWorstHealthState(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, types.HealthDegraded, types.WorstHealthState(types.HealthOK, types.HealthDegraded))
+	assert.Equal(t, types.HealthFailed, types.WorstHealthState(types.HealthFailed, types.HealthDegraded))
+	assert.Equal(t, types.HealthOK, types.WorstHealthState(types.HealthOK, types.HealthOK))
+}
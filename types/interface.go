@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"time"
 
 	"github.com/TerraDharitri/drt-go-chain-core/data"
@@ -27,6 +28,39 @@ type Persister interface {
 	IsInterfaceNil() bool
 }
 
+// PersisterWithContext is implemented by the persisters whose operations honor a context, failing with its error once
+// it is done, and propagating it to the wrapped persisters. Any persister is adapted by decorators.AsPersisterWithContext
+type PersisterWithContext interface {
+	Persister
+	// PutCtx adds the value to the (key, val) persistence medium
+	PutCtx(ctx context.Context, key, val []byte) error
+	// GetCtx gets the value associated to the key
+	GetCtx(ctx context.Context, key []byte) ([]byte, error)
+	// HasCtx returns nil if the given key is present in the persistence medium
+	HasCtx(ctx context.Context, key []byte) error
+	// RemoveCtx removes the data associated to the given key
+	RemoveCtx(ctx context.Context, key []byte) error
+}
+
+// Flusher is implemented by the persisters delaying the writes in batches, able to write them on demand
+type Flusher interface {
+	// Flush writes the pending writes to the storage medium
+	Flush() error
+}
+
+// MultiPutter is implemented by the persisters able to write several (key, value) pairs in one go
+type MultiPutter interface {
+	// MultiPut adds all the provided values to the (key, val) persistence medium
+	MultiPut(data map[string][]byte) error
+}
+
+// RangeIterator is implemented by the persisters able to iterate over a range of keys, in ascending order
+type RangeIterator interface {
+	// RangeKeysBetween iterates over the (key, value) pairs whose keys are in [startKey, endKey), a nil endKey meaning
+	// no upper bound
+	RangeKeysBetween(startKey []byte, endKey []byte, handler func(key []byte, val []byte) bool)
+}
+
 // Batcher allows to batch the data first then write the batch to the persister in one go
 type Batcher interface {
 	// Put inserts one entry - key, value pair - into the batch
@@ -39,6 +73,13 @@ type Batcher interface {
 	Reset()
 	// IsRemoved returns true if the provided key is marked for deletion
 	IsRemoved(key []byte) bool
+	// Len returns the number of distinct keys written or marked for deletion in the batch
+	Len() int
+	// SizeInBytes returns the accumulated size of the keys and values of the batch entries
+	SizeInBytes() uint64
+	// Range calls the handler for each entry of the batch, in no particular order, the removed flag marking the keys
+	// marked for deletion. The iteration stops when the handler returns false
+	Range(handler func(key []byte, val []byte, removed bool) bool)
 	// IsInterfaceNil returns true if there is no value under the interface
 	IsInterfaceNil() bool
 }
@@ -151,6 +192,25 @@ type ShardCoordinator interface {
 // ForEachItem is an iterator callback
 type ForEachItem func(key []byte, value interface{})
 
+// EvictedItemHandler is a callback for the items evicted from a cache
+type EvictedItemHandler func(key []byte, value interface{}, reason EvictionReason)
+
+// EvictionNotifier defines a cache which notifies the registered handlers, on separate go routines,
+// about the items it evicts in order to make room for new ones. Explicit removals are not notified
+type EvictionNotifier interface {
+	RegisterEvictionHandler(handler EvictedItemHandler, id string)
+	UnRegisterEvictionHandler(id string)
+	IsInterfaceNil() bool
+}
+
+// Pinner defines a cache whose entries can be protected from eviction, until they are unpinned or explicitly removed
+type Pinner interface {
+	Pin(key []byte) error
+	Unpin(key []byte)
+	NumPinned() int
+	IsInterfaceNil() bool
+}
+
 // LRUCacheHandler is the interface for LRU cache.
 type LRUCacheHandler interface {
 	Add(key, value interface{}) bool
@@ -187,6 +247,12 @@ type TimeCacher interface {
 	IsInterfaceNil() bool
 }
 
+// Clock defines the source of the current time used by the time caches to decide the expiry of their entries
+type Clock interface {
+	Now() time.Time
+	IsInterfaceNil() bool
+}
+
 // EvictionHandler defines a component which can be registered on TimeCacher
 type EvictionHandler interface {
 	Evicted(key []byte)
@@ -230,3 +296,51 @@ type PersisterCreator interface {
 	CreateBasePersister(path string) (Persister, error)
 	IsInterfaceNil() bool
 }
+
+// Shrinkable defines the behavior of a cache able to release a part of its contents when the memory is scarce
+type Shrinkable interface {
+	// Shrink evicts the given percentage of the contents, returning the number of evicted items
+	Shrink(percentage uint32) int
+	IsInterfaceNil() bool
+}
+
+// DiagnosticsProvider defines the behavior of a component exposing a summary of its internal state
+type DiagnosticsProvider interface {
+	// DiagnosticsSnapshot returns a JSON serializable summary of the internal state
+	DiagnosticsSnapshot() interface{}
+	IsInterfaceNil() bool
+}
+
+// StorageMonitor defines the behavior of a component receiving the metrics of the created storage components
+type StorageMonitor interface {
+	MonitorNewCache(name string, sizeInBytes uint64)
+	MonitorNewDB(dbType string, path string)
+	IsInterfaceNil() bool
+}
+
+// SizedCache defines the cache metrics logged by the stats reporter
+type SizedCache interface {
+	Len() int
+	SizeInBytesContained() uint64
+	IsInterfaceNil() bool
+}
+
+// BoundedCache defines the cache metrics compared against its capacity by the capacity watcher
+type BoundedCache interface {
+	SizedCache
+	MaxSize() int
+}
+
+// EpochSubscriber defines the behavior of a component notified of the confirmed epochs
+type EpochSubscriber interface {
+	EpochConfirmed(epoch uint32)
+	IsInterfaceNil() bool
+}
+
+// EpochNotifier defines the behavior of a component notifying its subscribers of the confirmed epochs
+type EpochNotifier interface {
+	// RegisterNotifyHandler adds a subscriber, notifying it right away of the current epoch
+	RegisterNotifyHandler(handler EpochSubscriber)
+	UnregisterNotifyHandler(handler EpochSubscriber)
+	IsInterfaceNil() bool
+}
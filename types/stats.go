@@ -0,0 +1,42 @@
+package types
+
+// Names of the stats shared by the StatsProvider implementations
+const (
+	// StatType is the type of the component, e.g. the cache or the persister type
+	StatType = "type"
+	// StatNumItems is the number of items held by the component
+	StatNumItems = "numItems"
+	// StatSizeInBytes is the size in bytes of the items held by the component
+	StatSizeInBytes = "sizeInBytes"
+	// StatCapacity is the maximum number of items the component can hold
+	StatCapacity = "capacity"
+	// StatPath is the path of the persister
+	StatPath = "path"
+)
+
+// StatsProvider defines a storage component exposing its key stats as a JSON serializable map keyed by the stat names,
+// so that the stats of the caches, the persisters and their adapters are collected uniformly
+type StatsProvider interface {
+	Stats() map[string]interface{}
+	IsInterfaceNil() bool
+}
+
+// CacheStats returns the stats shared by all the caches, to be completed with the ones specific to each cache
+func CacheStats(cacheType string, cache BoundedCache) map[string]interface{} {
+	return map[string]interface{}{
+		StatType:        cacheType,
+		StatNumItems:    cache.Len(),
+		StatSizeInBytes: cache.SizeInBytesContained(),
+		StatCapacity:    cache.MaxSize(),
+	}
+}
+
+// StatsOf returns the stats of the provided component, or nil if it is not a StatsProvider
+func StatsOf(component interface{}) map[string]interface{} {
+	provider, ok := component.(StatsProvider)
+	if !ok || provider.IsInterfaceNil() {
+		return nil
+	}
+
+	return provider.Stats()
+}
@@ -0,0 +1,41 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheStats(t *testing.T) {
+	t.Parallel()
+
+	cache, err := lrucache.NewCacheWithSizeInBytes(10, 1024)
+	require.Nil(t, err)
+	cache.Put([]byte("key"), []byte("value"), 5)
+
+	stats := types.CacheStats("LRU", cache)
+	assert.Equal(t, map[string]interface{}{
+		types.StatType:        "LRU",
+		types.StatNumItems:    1,
+		types.StatSizeInBytes: uint64(5),
+		types.StatCapacity:    10,
+	}, stats)
+}
+
+func TestStatsOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, types.StatsOf(nil))
+	assert.Nil(t, types.StatsOf("not a provider"))
+
+	var nilCache types.StatsProvider
+	assert.Nil(t, types.StatsOf(nilCache))
+
+	cache, _ := lrucache.NewCache(10)
+	stats := types.StatsOf(cache)
+	assert.Equal(t, "LRU", stats[types.StatType])
+	assert.Equal(t, 0, stats["numPinned"])
+}
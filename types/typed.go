@@ -0,0 +1,299 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/TerraDharitri/drt-go-chain-core/core/check"
+	"github.com/TerraDharitri/drt-go-chain-core/marshal"
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+)
+
+// Key is the constraint of the keys of the typed facades, converted to and from the byte slices of the wrapped
+// components
+type Key interface {
+	~string | ~[]byte
+}
+
+// Codec converts the values of the typed storers to and from the byte slices of the wrapped storers
+type Codec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(buff []byte) (V, error)
+}
+
+// MarshalizerCodec is the Codec based on a marshalizer, the default one of the typed storers
+type MarshalizerCodec[V any] struct {
+	marshalizer marshal.Marshalizer
+}
+
+// NewMarshalizerCodec creates a Codec based on the provided marshalizer
+func NewMarshalizerCodec[V any](marshalizer marshal.Marshalizer) (*MarshalizerCodec[V], error) {
+	if check.IfNil(marshalizer) {
+		return nil, common.ErrNilMarshalizer
+	}
+
+	return &MarshalizerCodec[V]{
+		marshalizer: marshalizer,
+	}, nil
+}
+
+// Encode marshals the value
+func (mc *MarshalizerCodec[V]) Encode(value V) ([]byte, error) {
+	return mc.marshalizer.Marshal(value)
+}
+
+// Decode unmarshals the value. For the pointer types, the value is unmarshalled into a newly allocated one
+func (mc *MarshalizerCodec[V]) Decode(buff []byte) (V, error) {
+	var value V
+	var target interface{} = &value
+	valueType := reflect.TypeOf(value)
+	if valueType != nil && valueType.Kind() == reflect.Pointer {
+		value = reflect.New(valueType.Elem()).Interface().(V)
+		target = value
+	}
+
+	err := mc.marshalizer.Unmarshal(target, buff)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// BytesCodec is the Codec of the byte slice values, stored as they are
+type BytesCodec struct{}
+
+// Encode returns the value as it is
+func (bc BytesCodec) Encode(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+// Decode returns the buffer as it is
+func (bc BytesCodec) Decode(buff []byte) ([]byte, error) {
+	return buff, nil
+}
+
+// TypedCacher is a type safe facade of a Cacher holding values of type V under keys of type K. The values of other
+// types, put in the cacher by other means, are reported as missing
+type TypedCacher[K Key, V any] struct {
+	cacher Cacher
+}
+
+// NewTypedCacher creates a TypedCacher wrapping the provided cacher
+func NewTypedCacher[K Key, V any](cacher Cacher) (*TypedCacher[K, V], error) {
+	if check.IfNil(cacher) {
+		return nil, common.ErrNilCacher
+	}
+
+	return &TypedCacher[K, V]{
+		cacher: cacher,
+	}, nil
+}
+
+// Put adds the value to the cacher, returning true if an eviction occurred
+func (tc *TypedCacher[K, V]) Put(key K, value V, sizeInBytes int) bool {
+	return tc.cacher.Put([]byte(key), value, sizeInBytes)
+}
+
+// Get looks up the value of the key, updating its recent-ness
+func (tc *TypedCacher[K, V]) Get(key K) (V, bool) {
+	return asTyped[V](tc.cacher.Get([]byte(key)))
+}
+
+// Peek looks up the value of the key, without updating its recent-ness
+func (tc *TypedCacher[K, V]) Peek(key K) (V, bool) {
+	return asTyped[V](tc.cacher.Peek([]byte(key)))
+}
+
+// Has checks if the key is in the cacher
+func (tc *TypedCacher[K, V]) Has(key K) bool {
+	return tc.cacher.Has([]byte(key))
+}
+
+// HasOrAdd adds the value, unless the key is already in the cacher
+func (tc *TypedCacher[K, V]) HasOrAdd(key K, value V, sizeInBytes int) (has, added bool) {
+	return tc.cacher.HasOrAdd([]byte(key), value, sizeInBytes)
+}
+
+// Remove removes the key from the cacher
+func (tc *TypedCacher[K, V]) Remove(key K) {
+	tc.cacher.Remove([]byte(key))
+}
+
+// Keys returns the keys in the cacher, from oldest to newest
+func (tc *TypedCacher[K, V]) Keys() []K {
+	keys := tc.cacher.Keys()
+	typedKeys := make([]K, 0, len(keys))
+	for _, key := range keys {
+		typedKeys = append(typedKeys, K(key))
+	}
+
+	return typedKeys
+}
+
+// Len returns the number of items in the cacher
+func (tc *TypedCacher[K, V]) Len() int {
+	return tc.cacher.Len()
+}
+
+// Clear removes all the items from the cacher
+func (tc *TypedCacher[K, V]) Clear() {
+	tc.cacher.Clear()
+}
+
+// RegisterHandler registers a handler called when a value of type V is added
+func (tc *TypedCacher[K, V]) RegisterHandler(handler func(key K, value V), id string) {
+	tc.cacher.RegisterHandler(func(key []byte, value interface{}) {
+		typedValue, ok := value.(V)
+		if ok {
+			handler(K(key), typedValue)
+		}
+	}, id)
+}
+
+// Unwrap returns the wrapped cacher
+func (tc *TypedCacher[K, V]) Unwrap() Cacher {
+	return tc.cacher
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tc *TypedCacher[K, V]) IsInterfaceNil() bool {
+	return tc == nil
+}
+
+func asTyped[V any](value interface{}, ok bool) (V, bool) {
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	typedValue, ok := value.(V)
+	return typedValue, ok
+}
+
+// ArgsTypedStorer holds the arguments needed to create a TypedStorer
+type ArgsTypedStorer[V any] struct {
+	Storer Storer
+	// Codec converts the values, defaulting to a MarshalizerCodec based on the Marshalizer
+	Codec Codec[V]
+	// Marshalizer is required only when no Codec is provided
+	Marshalizer marshal.Marshalizer
+}
+
+// TypedStorer is a type safe facade of a Storer holding values of type V under keys of type K, the values being
+// converted to and from byte slices by its codec
+type TypedStorer[K Key, V any] struct {
+	storer Storer
+	codec  Codec[V]
+}
+
+// NewTypedStorer creates a TypedStorer wrapping the provided storer
+func NewTypedStorer[K Key, V any](args ArgsTypedStorer[V]) (*TypedStorer[K, V], error) {
+	if check.IfNil(args.Storer) {
+		return nil, common.ErrNilStorer
+	}
+
+	codec := args.Codec
+	if codec == nil {
+		marshalizerCodec, err := NewMarshalizerCodec[V](args.Marshalizer)
+		if err != nil {
+			return nil, err
+		}
+		codec = marshalizerCodec
+	}
+
+	return &TypedStorer[K, V]{
+		storer: args.Storer,
+		codec:  codec,
+	}, nil
+}
+
+// Put encodes the value and adds it to the storer
+func (ts *TypedStorer[K, V]) Put(key K, value V) error {
+	buff, err := ts.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("%w while encoding the value of key %x", err, []byte(key))
+	}
+
+	return ts.storer.Put([]byte(key), buff)
+}
+
+// PutInEpoch encodes the value and adds it to the storer, in the provided epoch
+func (ts *TypedStorer[K, V]) PutInEpoch(key K, value V, epoch uint32) error {
+	buff, err := ts.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("%w while encoding the value of key %x", err, []byte(key))
+	}
+
+	return ts.storer.PutInEpoch([]byte(key), buff, epoch)
+}
+
+// Get gets and decodes the value of the key
+func (ts *TypedStorer[K, V]) Get(key K) (V, error) {
+	buff, err := ts.storer.Get([]byte(key))
+	return ts.decodeValue(key, buff, err)
+}
+
+// GetFromEpoch gets and decodes the value of the key, from the provided epoch
+func (ts *TypedStorer[K, V]) GetFromEpoch(key K, epoch uint32) (V, error) {
+	buff, err := ts.storer.GetFromEpoch([]byte(key), epoch)
+	return ts.decodeValue(key, buff, err)
+}
+
+// SearchFirst gets and decodes the value of the key, from the first epoch holding it
+func (ts *TypedStorer[K, V]) SearchFirst(key K) (V, error) {
+	buff, err := ts.storer.SearchFirst([]byte(key))
+	return ts.decodeValue(key, buff, err)
+}
+
+func (ts *TypedStorer[K, V]) decodeValue(key K, buff []byte, err error) (V, error) {
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	value, err := ts.codec.Decode(buff)
+	if err != nil {
+		return value, fmt.Errorf("%w while decoding the value of key %x", err, []byte(key))
+	}
+
+	return value, nil
+}
+
+// Has returns nil if the key is in the storer
+func (ts *TypedStorer[K, V]) Has(key K) error {
+	return ts.storer.Has([]byte(key))
+}
+
+// Remove removes the key from the storer
+func (ts *TypedStorer[K, V]) Remove(key K) error {
+	return ts.storer.Remove([]byte(key))
+}
+
+// RangeKeys calls the handler for each (key, decoded value) pair of the storer. The iteration stops when the handler
+// returns false or when a value can not be decoded, the decoding error being returned
+func (ts *TypedStorer[K, V]) RangeKeys(handler func(key K, value V) bool) error {
+	var err error
+	ts.storer.RangeKeys(func(key []byte, buff []byte) bool {
+		value, errDecode := ts.codec.Decode(buff)
+		if errDecode != nil {
+			err = fmt.Errorf("%w while decoding the value of key %x", errDecode, key)
+			return false
+		}
+
+		return handler(K(key), value)
+	})
+
+	return err
+}
+
+// Unwrap returns the wrapped storer
+func (ts *TypedStorer[K, V]) Unwrap() Storer {
+	return ts.storer
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ts *TypedStorer[K, V]) IsInterfaceNil() bool {
+	return ts == nil
+}
@@ -0,0 +1,159 @@
+package types_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TerraDharitri/drt-go-chain-storage/common"
+	"github.com/TerraDharitri/drt-go-chain-storage/lrucache"
+	"github.com/TerraDharitri/drt-go-chain-storage/memorydb"
+	"github.com/TerraDharitri/drt-go-chain-storage/storageUnit"
+	"github.com/TerraDharitri/drt-go-chain-storage/testscommon"
+	"github.com/TerraDharitri/drt-go-chain-storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type account struct {
+	Nonce   uint64
+	Balance string
+}
+
+func TestTypedCacher(t *testing.T) {
+	t.Parallel()
+
+	typedCacher, err := types.NewTypedCacher[string, *account](nil)
+	assert.Nil(t, typedCacher)
+	assert.Equal(t, common.ErrNilCacher, err)
+
+	cacher, _ := lrucache.NewCache(10)
+	typedCacher, err = types.NewTypedCacher[string, *account](cacher)
+	require.Nil(t, err)
+	assert.False(t, typedCacher.IsInterfaceNil())
+
+	// the handlers are called asynchronously by the cacher
+	added := make(chan string, 10)
+	typedCacher.RegisterHandler(func(key string, value *account) {
+		added <- key
+	}, "id")
+	cacher.Put([]byte("carol"), "not an account", 0)
+
+	alice := &account{Nonce: 1, Balance: "10"}
+	typedCacher.Put("alice", alice, 0)
+	has, wasAdded := typedCacher.HasOrAdd("bob", &account{Nonce: 2}, 0)
+	assert.False(t, has)
+	assert.True(t, wasAdded)
+	value, ok := typedCacher.Get("alice")
+	assert.True(t, ok)
+	assert.True(t, value == alice)
+	_, ok = typedCacher.Peek("bob")
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"carol", "alice", "bob"}, typedCacher.Keys())
+	addedKeys := []string{<-added, <-added}
+	assert.ElementsMatch(t, []string{"alice", "bob"}, addedKeys)
+
+	// the values of other types are reported as missing
+	assert.True(t, typedCacher.Has("carol"))
+	value, ok = typedCacher.Get("carol")
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	typedCacher.Remove("alice")
+	assert.Equal(t, 2, typedCacher.Len())
+	typedCacher.Clear()
+	assert.Equal(t, 0, typedCacher.Unwrap().Len())
+}
+
+func TestTypedStorer(t *testing.T) {
+	t.Parallel()
+
+	createStorer := func() types.Storer {
+		cacher, _ := lrucache.NewCache(10)
+		storer, _ := storageUnit.NewStorageUnit(cacher, memorydb.New())
+		return storer
+	}
+
+	t.Run("invalid args should error", func(t *testing.T) {
+		t.Parallel()
+
+		typedStorer, err := types.NewTypedStorer[string, account](types.ArgsTypedStorer[account]{})
+		assert.Nil(t, typedStorer)
+		assert.Equal(t, common.ErrNilStorer, err)
+
+		typedStorer, err = types.NewTypedStorer[string, account](types.ArgsTypedStorer[account]{Storer: createStorer()})
+		assert.Nil(t, typedStorer)
+		assert.Equal(t, common.ErrNilMarshalizer, err)
+	})
+	t.Run("marshalizer codec", func(t *testing.T) {
+		t.Parallel()
+
+		storer := createStorer()
+		typedStorer, err := types.NewTypedStorer[[]byte, *account](types.ArgsTypedStorer[*account]{
+			Storer:      storer,
+			Marshalizer: &testscommon.MarshalizerMock{},
+		})
+		require.Nil(t, err)
+		assert.False(t, typedStorer.IsInterfaceNil())
+
+		alice := &account{Nonce: 1, Balance: "10"}
+		require.Nil(t, typedStorer.Put([]byte("alice"), alice))
+		require.Nil(t, typedStorer.PutInEpoch([]byte("bob"), &account{Nonce: 2}, 0))
+		value, err := typedStorer.Get([]byte("alice"))
+		assert.Nil(t, err)
+		assert.Equal(t, alice, value)
+		value, err = typedStorer.GetFromEpoch([]byte("bob"), 0)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), value.Nonce)
+		_, err = typedStorer.SearchFirst([]byte("bob"))
+		assert.Nil(t, err)
+		assert.Nil(t, typedStorer.Has([]byte("alice")))
+
+		numAccounts := 0
+		err = typedStorer.RangeKeys(func(key []byte, value *account) bool {
+			numAccounts++
+			return true
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, numAccounts)
+
+		require.Nil(t, typedStorer.Remove([]byte("alice")))
+		_, err = typedStorer.Get([]byte("alice"))
+		assert.NotNil(t, err)
+
+		_ = storer.Put([]byte("invalid"), []byte("not json"))
+		_, err = typedStorer.Get([]byte("invalid"))
+		assert.NotNil(t, err)
+		err = typedStorer.RangeKeys(func(key []byte, value *account) bool {
+			return true
+		})
+		assert.NotNil(t, err)
+	})
+	t.Run("custom codec", func(t *testing.T) {
+		t.Parallel()
+
+		typedStorer, err := types.NewTypedStorer[string, []byte](types.ArgsTypedStorer[[]byte]{
+			Storer: createStorer(),
+			Codec:  types.BytesCodec{},
+		})
+		require.Nil(t, err)
+
+		require.Nil(t, typedStorer.Put("key", []byte("value")))
+		value, err := typedStorer.Unwrap().Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+	t.Run("encoding error should not write", func(t *testing.T) {
+		t.Parallel()
+
+		storer := createStorer()
+		typedStorer, _ := types.NewTypedStorer[string, account](types.ArgsTypedStorer[account]{
+			Storer:      storer,
+			Marshalizer: &testscommon.MarshalizerMock{Fail: true},
+		})
+
+		err := typedStorer.Put("alice", account{})
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, common.ErrNilMarshalizer))
+		assert.NotNil(t, storer.Has([]byte("alice")))
+	})
+}